@@ -22,8 +22,9 @@ import "time"
 
 // LambdaInput is the request structure for the alerts-api Lambda function.
 type LambdaInput struct {
-	GetAlert   *GetAlertInput   `json:"getAlert"`
-	ListAlerts *ListAlertsInput `json:"listAlerts"`
+	GetAlert    *GetAlertInput    `json:"getAlert"`
+	ListAlerts  *ListAlertsInput  `json:"listAlerts"`
+	ResendAlert *ResendAlertInput `json:"resendAlert"`
 }
 
 // GetAlertInput retrieves details for a single alert.
@@ -46,6 +47,23 @@ type GetAlertInput struct {
 // GetAlertOutput retrieves details for a single alert.
 type GetAlertOutput = Alert
 
+// ResendAlertInput sends a stored alert again to the given outputs.
+//
+// The alert is rebuilt from the version of the rule that triggered it and queued for delivery,
+// its delivery responses are appended to those of the alert.
+// Only rule alerts can be resent, policy alerts are not stored.
+// Example:
+// {
+//     "resendAlert": {
+//         "alertId": "b25dc23fb2a0b362da8428dbec1381a8",
+//         "outputIds": ["7d1c5854-f3ea-491c-8a52-0aa0d58cb456"]
+//     }
+// }
+type ResendAlertInput struct {
+	AlertID   *string  `json:"alertId" validate:"required,hexadecimal,len=32"`
+	OutputIds []string `json:"outputIds" validate:"required,min=1,dive,uuid4"`
+}
+
 // ListAlertsInput lists the alerts in reverse-chronological order (newest to oldest)
 // If "ruleId" is not set, we return all the alerts for the organization
 // If the "exclusiveStartKey" is not set, we return alerts starting from the most recent one. If it is set,
@@ -122,4 +140,32 @@ type Alert struct {
 	AlertSummary
 	Events                 []*string `json:"events" validate:"required"`
 	EventsLastEvaluatedKey *string   `json:"eventsLastEvaluatedKey,omitempty"`
+	// DeliveryResponses are the results of sending the alert to its outputs, oldest first.
+	//
+	// Only the alerts of rules are stored in the alerts table and have delivery responses. The deliveries of
+	// policy alerts are only logged by the panther-alert-delivery function, as "policy alert delivery" entries.
+	DeliveryResponses []*DeliveryResponse `json:"deliveryResponses"`
+}
+
+// DeliveryResponse is the result of one attempt to send an alert to an output
+type DeliveryResponse struct {
+	OutputID   string `json:"outputId"`
+	OutputType string `json:"outputType"`
+	// DisplayName is the name of the output at the time of the attempt
+	DisplayName  string    `json:"displayName"`
+	DispatchedAt time.Time `json:"dispatchedAt"`
+	Success      bool      `json:"success"`
+	// StatusCode is the HTTP status of failed responses of the output, if any
+	StatusCode int `json:"statusCode,omitempty"`
+	// Message is the error of a failed attempt
+	Message string `json:"message,omitempty"`
+	// Permanent is true if a failed attempt is not retried
+	Permanent bool `json:"permanent,omitempty"`
+	// Resent is true if the alert was sent again with a resendAlert request
+	Resent bool `json:"resent,omitempty"`
+	// Throttled is true if the alert was held back by the throttle of the output, to be summarized in a digest.
+	// It is not a success: the outcome of the digest is recorded separately.
+	Throttled bool `json:"throttled,omitempty"`
+	// Digest is true for the outcome of the digest that summarized a throttled alert
+	Digest bool `json:"digest,omitempty"`
 }
@@ -60,6 +60,8 @@ const (
 	LogData DataType = "LogData"
 	// RuleData represents log data that have matched some rule
 	RuleData DataType = "RuleMatches"
+	// ErrorData represents log lines that Panther failed to parse
+	ErrorData DataType = "Errors"
 )
 
 func (d DataType) String() string {
@@ -18,6 +18,8 @@ package models
  * along with this program.  If not, see <https://www.gnu.org/licenses/>.
  */
 
+import "time"
+
 // LambdaInput is the invocation event expected by the Lambda function.
 //
 // Exactly one action must be specified.
@@ -28,6 +30,9 @@ type LambdaInput struct {
 	DeleteOutput          *DeleteOutputInput          `json:"deleteOutput"`
 	GetOutputs            *GetOutputsInput            `json:"getOutputs"`
 	GetOutputsWithSecrets *GetOutputsWithSecretsInput `json:"getOutputsWithSecrets"`
+	GetAlertRoutes        *GetAlertRoutesInput        `json:"getAlertRoutes"`
+	PreviewTemplates      *PreviewTemplatesInput      `json:"previewTemplates"`
+	SendTestAlert         *SendTestAlertInput         `json:"sendTestAlert"`
 }
 
 // AddOutputInput adds a new encrypted alert output to DynamoDB.
@@ -43,10 +48,13 @@ type LambdaInput struct {
 //     }
 // }
 type AddOutputInput struct {
-	UserID             *string       `json:"userId" validate:"required,uuid4"`
-	DisplayName        *string       `json:"displayName" validate:"required,min=1,excludesall='<>&\""`
-	OutputConfig       *OutputConfig `json:"outputConfig" validate:"required"`
-	DefaultForSeverity []*string     `json:"defaultForSeverity"`
+	UserID             *string           `json:"userId" validate:"required,uuid4"`
+	DisplayName        *string           `json:"displayName" validate:"required,min=1,excludesall='<>&\""`
+	OutputConfig       *OutputConfig     `json:"outputConfig" validate:"required"`
+	DefaultForSeverity []*string         `json:"defaultForSeverity"`
+	RoutingRules       []*RoutingRule    `json:"routingRules" validate:"omitempty,dive,required"`
+	Templates          *MessageTemplates `json:"templates"`
+	Throttle           *AlertThrottle    `json:"throttle"`
 }
 
 // AddOutputOutput returns a randomly generated UUID for the output.
@@ -86,6 +94,12 @@ type UpdateOutputInput struct {
 	OutputID           *string       `json:"outputId" validate:"required,uuid4"`
 	OutputConfig       *OutputConfig `json:"outputConfig"`
 	DefaultForSeverity []*string     `json:"defaultForSeverity"`
+	// RoutingRules replace the rules of the output if set, an empty list removes them
+	RoutingRules []*RoutingRule `json:"routingRules" validate:"omitempty,dive,required"`
+	// Templates replace the templates of the output if set, an empty object removes them
+	Templates *MessageTemplates `json:"templates"`
+	// Throttle replaces the rate limit of the output if set, a throttle without a window removes it
+	Throttle *AlertThrottle `json:"throttle"`
 }
 
 // UpdateOutputOutput returns the new updated output
@@ -131,6 +145,133 @@ type GetOutputsWithSecretsInput struct {
 // }
 type GetOutputsOutput = []*AlertOutput
 
+// GetAlertRoutesInput returns the outputs a sample alert would be sent to, without sending it
+//
+// Example:
+// {
+//     "getAlertRoutes": {
+//         "analysisId": "AWS.CloudTrail.RootActivity",
+//         "type": "RULE",
+//         "severity": "HIGH",
+//         "tags": ["Identity"],
+//         "logTypes": ["AWS.CloudTrail"],
+//         "createdAt": "2020-06-01T09:30:00Z"
+//     }
+// }
+type GetAlertRoutesInput struct {
+	AnalysisID string   `json:"analysisId" validate:"required"`
+	Type       string   `json:"type" validate:"oneof=RULE POLICY"`
+	Severity   string   `json:"severity" validate:"oneof=INFO LOW MEDIUM HIGH CRITICAL"`
+	Tags       []string `json:"tags"`
+	LogTypes   []string `json:"logTypes"`
+	// OutputIds are the outputs set by the rule or policy, they override severity defaults and routing rules
+	OutputIds []string `json:"outputIds"`
+	// CreatedAt is the time of the alert that schedules are matched against, defaults to now
+	CreatedAt *time.Time `json:"createdAt"`
+}
+
+// GetAlertRoutesOutput lists the outputs the alert would be sent to and why
+//
+// Example:
+// [
+//     {
+//         "outputId": "7d1c5854-f3ea-491c-8a52-0aa0d58cb456",
+//         "displayName": "identity-team",
+//         "outputType": "slack",
+//         "reason": "routingRule",
+//         "routingRule": 0
+//     }
+// ]
+type GetAlertRoutesOutput = []*AlertRoute
+
+// PreviewTemplatesInput renders message templates against a sample alert, without saving them
+//
+// Example:
+// {
+//     "previewTemplates": {
+//         "templates": {
+//             "title": "[{{.Severity}}] {{.Name}}",
+//             "body": "{{.Description}}\n{{.Link}}"
+//         }
+//     }
+// }
+type PreviewTemplatesInput struct {
+	Templates *MessageTemplates `json:"templates" validate:"required"`
+}
+
+// PreviewTemplatesOutput contains the rendered templates, those that are not set are empty
+//
+// Example:
+// {
+//     "title": "[HIGH] Root Account Activity",
+//     "body": "Root account activity was detected\nhttps://panther.example.com/log-analysis/alerts/..."
+// }
+type PreviewTemplatesOutput = MessageTemplates
+
+// SendTestAlertInput sends a test alert to a stored output, or to an output config that is not saved yet
+//
+// If both are set, the config is merged with the one of the stored output, like in updateOutput.
+// The templates replace those of the stored output when set.
+//
+// Example:
+// {
+//     "sendTestAlert": {
+//         "outputConfig": {
+//             "slack": {
+//                 "webhookURL": "https://hooks.slack.com/services/..."
+//             }
+//         }
+//     }
+// }
+type SendTestAlertInput struct {
+	OutputID     *string           `json:"outputId" validate:"omitempty,uuid4"`
+	OutputConfig *OutputConfig     `json:"outputConfig"`
+	Templates    *MessageTemplates `json:"templates"`
+}
+
+// SendTestAlertOutput is the result of the delivery of the test alert
+//
+// Example:
+// {
+//     "success": false,
+//     "statusCode": 404,
+//     "message": "request failed: 404 Not Found: no_team",
+//     "permanent": false
+// }
+type SendTestAlertOutput struct {
+	Success bool `json:"success"`
+
+	// StatusCode is the HTTP status of the response of the output, if it failed with one
+	StatusCode int `json:"statusCode,omitempty"`
+
+	// Message is the error of the delivery, including the response of the output if there is one
+	Message string `json:"message,omitempty"`
+
+	// Permanent is true if the delivery of an alert would not be retried after this error
+	Permanent bool `json:"permanent"`
+}
+
+// Reasons an alert is routed to an output
+const (
+	// RouteReasonOutputIds is the reason of the outputs set by the rule or policy of the alert
+	RouteReasonOutputIds = "outputIds"
+	// RouteReasonSeverity is the reason of the outputs that are the default for the severity of the alert
+	RouteReasonSeverity = "defaultForSeverity"
+	// RouteReasonRoutingRule is the reason of the outputs with a routing rule matching the alert
+	RouteReasonRoutingRule = "routingRule"
+)
+
+// AlertRoute is an output an alert is routed to
+type AlertRoute struct {
+	OutputID    *string `json:"outputId"`
+	DisplayName *string `json:"displayName"`
+	OutputType  *string `json:"outputType"`
+	// Reason is the first reason found to route the alert to the output
+	Reason string `json:"reason"`
+	// RoutingRule is the index of the first matching routing rule of the output, if that is the reason
+	RoutingRule *int `json:"routingRule,omitempty"`
+}
+
 // AlertOutput contains the information for alert output configuration
 type AlertOutput struct {
 
@@ -161,6 +302,73 @@ type AlertOutput struct {
 
 	// DefaultForSeverity defines the alert severities that will be forwarded through this output
 	DefaultForSeverity []*string `json:"defaultForSeverity"`
+
+	// RoutingRules forward the alerts they match through this output, in addition to the severity defaults
+	RoutingRules []*RoutingRule `json:"routingRules,omitempty"`
+
+	// Templates customize the content of the alerts delivered through this output
+	Templates *MessageTemplates `json:"templates,omitempty"`
+
+	// Throttle limits the number of alerts delivered through this output, the others are sent in a digest
+	Throttle *AlertThrottle `json:"throttle,omitempty"`
+}
+
+// AlertThrottle limits the alerts delivered through an output in each window of time.
+// The first MaxAlerts alerts of a window are delivered one by one. The alerts after them are summarized
+// in a single digest message, delivered when the window ends. With MaxAlerts set to 0, every alert is
+// in the digest.
+//
+// Example:
+// {
+//     "windowMinutes": 15,
+//     "maxAlerts": 5
+// }
+type AlertThrottle struct {
+	// WindowMinutes is the length of the windows, a window starts with the first alert after the previous one
+	WindowMinutes int `json:"windowMinutes" validate:"min=0,max=1440"`
+	// MaxAlerts is the number of alerts delivered one by one in each window
+	MaxAlerts int `json:"maxAlerts" validate:"min=0,max=1000"`
+}
+
+// MessageTemplates are Go text/templates rendered with the attributes of an alert, e.g. "{{.Severity}}".
+// The templates that are not set keep the default content of the output.
+type MessageTemplates struct {
+	// Title replaces the title, subject or summary of the alert
+	Title string `json:"title,omitempty" validate:"omitempty,max=1000"`
+	// Body replaces the description of the alert in outputs that deliver a human readable message
+	Body string `json:"body,omitempty" validate:"omitempty,max=10000"`
+	// Payload replaces the JSON document posted by custom webhooks, it must render valid JSON
+	Payload string `json:"payload,omitempty" validate:"omitempty,max=10000"`
+}
+
+// RoutingRule matches alerts by their attributes and creation time. All the conditions that are set must match,
+// a list condition matches if the alert has any of its values.
+//
+// Example:
+// {
+//     "description": "identity team during office hours",
+//     "analysisIds": ["AWS.IAM.*", "Okta.*"],
+//     "tags": ["Identity"],
+//     "types": ["RULE"],
+//     "daysOfWeek": ["MON", "TUE", "WED", "THU", "FRI"],
+//     "startTime": "09:00",
+//     "endTime": "18:00",
+//     "timeZone": "America/New_York"
+// }
+type RoutingRule struct {
+	Description string `json:"description,omitempty" validate:"omitempty,max=256"`
+	// AnalysisIDs are patterns of rule and policy ids where '*' matches any characters, e.g. "AWS.CloudTrail.*"
+	AnalysisIDs []string `json:"analysisIds,omitempty" validate:"omitempty,dive,required"`
+	Tags        []string `json:"tags,omitempty" validate:"omitempty,dive,required"`
+	Types       []string `json:"types,omitempty" validate:"omitempty,dive,oneof=RULE POLICY"`
+	LogTypes    []string `json:"logTypes,omitempty" validate:"omitempty,dive,required"`
+	Severities  []string `json:"severities,omitempty" validate:"omitempty,dive,oneof=INFO LOW MEDIUM HIGH CRITICAL"`
+	DaysOfWeek  []string `json:"daysOfWeek,omitempty" validate:"omitempty,dive,oneof=MON TUE WED THU FRI SAT SUN"`
+	// StartTime and EndTime (HH:MM) are the daily window of the rule, it spans midnight if EndTime is before StartTime
+	StartTime string `json:"startTime,omitempty"`
+	EndTime   string `json:"endTime,omitempty"`
+	// TimeZone is the IANA name of the zone of the days and times (e.g. "Europe/London"), defaults to UTC
+	TimeZone string `json:"timeZone,omitempty"`
 }
 
 // OutputConfig contains the configuration for the output
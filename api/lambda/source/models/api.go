@@ -36,6 +36,11 @@ type LambdaInput struct {
 
 	FullScan     *FullScanInput     `json:"fullScan"`
 	UpdateStatus *UpdateStatusInput `json:"updateStatus"`
+
+	PutCustomLog    *PutCustomLogInput    `json:"putCustomLog"`
+	GetCustomLog    *GetCustomLogInput    `json:"getCustomLog"`
+	ListCustomLogs  *ListCustomLogsInput  `json:"listCustomLogs"`
+	DeleteCustomLog *DeleteCustomLogInput `json:"deleteCustomLog"`
 }
 
 //
@@ -45,7 +50,7 @@ type LambdaInput struct {
 // CheckIntegrationInput is used to check the health of a potential configuration.
 type CheckIntegrationInput struct {
 	AWSAccountID     string `genericapi:"redact" json:"awsAccountId" validate:"omitempty,len=12,numeric"`
-	IntegrationType  string `json:"integrationType" validate:"oneof=aws-scan aws-s3 aws-sqs"`
+	IntegrationType  string `json:"integrationType" validate:"oneof=aws-scan aws-s3 aws-sqs http aws-kinesis"`
 	IntegrationLabel string `json:"integrationLabel" validate:"required,integrationLabel"`
 
 	// Checks for cloudsec integrations
@@ -59,6 +64,12 @@ type CheckIntegrationInput struct {
 
 	// Checks for Sqs configuration
 	SqsConfig *SqsConfig `json:"sqsConfig,omitempty"`
+
+	// Checks for HTTP configuration
+	HTTPConfig *HTTPConfig `json:"httpConfig,omitempty"`
+
+	// Checks for Kinesis configuration
+	KinesisConfig *KinesisConfig `json:"kinesisConfig,omitempty"`
 }
 
 //
@@ -73,7 +84,7 @@ type PutIntegrationInput struct {
 // PutIntegrationSettings are all the settings for the new integration.
 type PutIntegrationSettings struct {
 	IntegrationLabel   string   `json:"integrationLabel" validate:"required,integrationLabel,excludesall='<>&\""`
-	IntegrationType    string   `json:"integrationType" validate:"oneof=aws-scan aws-s3 aws-sqs"`
+	IntegrationType    string   `json:"integrationType" validate:"oneof=aws-scan aws-s3 aws-sqs http aws-kinesis"`
 	UserID             string   `json:"userId" validate:"required,uuid4"`
 	AWSAccountID       string   `genericapi:"redact" json:"awsAccountId" validate:"omitempty,len=12,numeric"`
 	CWEEnabled         *bool    `json:"cweEnabled"`
@@ -84,7 +95,14 @@ type PutIntegrationSettings struct {
 	KmsKey             string   `json:"kmsKey" validate:"omitempty,kmsKeyArn"`
 	LogTypes           []string `json:"logTypes" validate:"omitempty,min=1"`
 
+	// Optional log types per S3 prefix, they must be included in LogTypes
+	S3PrefixLogTypes S3PrefixLogTypes `json:"s3PrefixLogTypes,omitempty" validate:"omitempty,dive"`
+
 	SqsConfig *SqsConfig `json:"sqsConfig,omitempty"`
+
+	HTTPConfig *HTTPConfig `json:"httpConfig,omitempty"`
+
+	KinesisConfig *KinesisConfig `json:"kinesisConfig,omitempty"`
 }
 
 //
@@ -93,7 +111,7 @@ type PutIntegrationSettings struct {
 
 // ListIntegrationsInput allows filtering by the IntegrationType field
 type ListIntegrationsInput struct {
-	IntegrationType *string `json:"integrationType" validate:"omitempty,oneof=aws-scan aws-s3 aws-sqs"`
+	IntegrationType *string `json:"integrationType" validate:"omitempty,oneof=aws-scan aws-s3 aws-sqs http aws-kinesis"`
 }
 
 // UpdateIntegrationSettingsInput is used to update integration settings.
@@ -108,7 +126,14 @@ type UpdateIntegrationSettingsInput struct {
 	KmsKey             string   `json:"kmsKey" validate:"omitempty,kmsKeyArn"`
 	LogTypes           []string `json:"logTypes" validate:"omitempty,min=1"`
 
+	// Optional log types per S3 prefix, they must be included in LogTypes
+	S3PrefixLogTypes S3PrefixLogTypes `json:"s3PrefixLogTypes,omitempty" validate:"omitempty,dive"`
+
 	SqsConfig *SqsConfig `json:"sqsConfig,omitempty"`
+
+	HTTPConfig *HTTPConfig `json:"httpConfig,omitempty"`
+
+	KinesisConfig *KinesisConfig `json:"kinesisConfig,omitempty"`
 }
 
 // DeleteIntegrationInput is used to delete a specific item from the database.
@@ -174,3 +199,27 @@ type UpdateStatusInput struct {
 	IntegrationID     string    `json:"integrationId" validate:"required,uuid4"`
 	LastEventReceived time.Time `json:"lastEventReceived" validate:"required"`
 }
+
+//
+// CustomLogs: Used by the UI to manage user-defined log types and by the log processor to load them
+//
+
+// PutCustomLogInput creates or updates a custom log type.
+// The log type name is read from the schema spec (YAML or JSON).
+type PutCustomLogInput struct {
+	UserID string `json:"userId" validate:"required,uuid4"`
+	Spec   string `json:"spec" validate:"required"`
+}
+
+// GetCustomLogInput returns a custom log type
+type GetCustomLogInput struct {
+	LogType string `json:"logType" validate:"required"`
+}
+
+// ListCustomLogsInput returns all custom log types
+type ListCustomLogsInput struct{}
+
+// DeleteCustomLogInput deletes a custom log type, it must not be used by any source
+type DeleteCustomLogInput struct {
+	LogType string `json:"logType" validate:"required"`
+}
@@ -19,6 +19,7 @@ package models
  */
 
 import (
+	"strings"
 	"time"
 )
 
@@ -61,6 +62,34 @@ type SourceIntegrationMetadata struct {
 	LogProcessingRole  string     `json:"logProcessingRole,omitempty"`
 	StackName          string     `json:"stackName,omitempty"`
 	SqsConfig          *SqsConfig `json:"sqsConfig,omitempty"`
+
+	HTTPConfig *HTTPConfig `json:"httpConfig,omitempty"`
+
+	KinesisConfig *KinesisConfig `json:"kinesisConfig,omitempty"`
+
+	S3PrefixLogTypes S3PrefixLogTypes `json:"s3PrefixLogTypes,omitempty"`
+}
+
+// S3PrefixLogTypesMapping declares the log types of the S3 objects under a prefix
+type S3PrefixLogTypesMapping struct {
+	S3Prefix string   `json:"prefix"`
+	LogTypes []string `json:"logTypes" validate:"required,min=1"`
+}
+
+// S3PrefixLogTypes are the prefix to log types mappings of an S3 source
+type S3PrefixLogTypes []S3PrefixLogTypesMapping
+
+// LogTypes returns the log types of the longest prefix matching an object key.
+// It returns nil if no prefix matches.
+func (m S3PrefixLogTypes) LogTypes(key string) (logTypes []string) {
+	longestPrefix := -1
+	for _, mapping := range m {
+		if len(mapping.S3Prefix) > longestPrefix && strings.HasPrefix(key, mapping.S3Prefix) {
+			longestPrefix = len(mapping.S3Prefix)
+			logTypes = mapping.LogTypes
+		}
+	}
+	return logTypes
 }
 
 type SourceIntegrationHealth struct {
@@ -78,6 +107,9 @@ type SourceIntegrationHealth struct {
 
 	// Checks for Sqs integrations
 	SqsStatus SourceIntegrationItemStatus `json:"sqsStatus"`
+
+	// Checks for Kinesis integrations
+	KinesisStatus SourceIntegrationItemStatus `json:"kinesisStatus,omitempty"`
 }
 
 type SourceIntegrationItemStatus struct {
@@ -110,3 +142,49 @@ type SqsConfig struct {
 	// THe URL of the SQS queue
 	QueueURL string `json:"queueUrl"`
 }
+
+// The S3 Prefix where the data of HTTP sources will be stored, followed by the integration id
+const HTTPS3Prefix = "http"
+
+// Authentication methods of HTTP sources
+const (
+	// HTTPAuthBearer requires an "Authorization: Bearer <secret>" header
+	HTTPAuthBearer = "bearer"
+	// HTTPAuthHMAC requires the hex encoded HMAC-SHA256 of the request body, keyed by the secret
+	HTTPAuthHMAC = "hmac"
+
+	// DefaultHTTPSignatureHeader is the header of HMAC signatures if the source does not set one
+	DefaultHTTPSignatureHeader = "X-Panther-Signature"
+)
+
+type HTTPConfig struct {
+	// The log types associated with the source. Needs to be set by UI.
+	LogTypes []string `json:"logTypes" validate:"required,min=1"`
+	// How the requests of the source are authenticated, bearer or hmac. Needs to be set by UI.
+	AuthType string `json:"authType" validate:"oneof=bearer hmac"`
+	// The bearer token or HMAC key. A random secret is generated if it is empty.
+	AuthSecret string `genericapi:"redact" json:"authSecret" validate:"omitempty,min=16"`
+	// The header with the signature of hmac sources, defaults to DefaultHTTPSignatureHeader.
+	// A sha256= prefix of the signature (e.g. GitHub's X-Hub-Signature-256) is accepted.
+	SignatureHeader string `json:"signatureHeader,omitempty"`
+
+	// The Panther-internal S3 bucket where the data from this source will be available
+	S3Bucket string `json:"s3Bucket"`
+	// The S3 prefix where the data from this source will be available
+	S3Prefix string `json:"s3Prefix"`
+	// The Role that the log processor can use to access this data
+	LogProcessingRole string `json:"logProcessingRole"`
+}
+
+// HTTPS3PrefixForIntegration returns the S3 prefix of the data of an HTTP source
+func HTTPS3PrefixForIntegration(integrationID string) string {
+	return HTTPS3Prefix + "/" + integrationID + "/"
+}
+
+type KinesisConfig struct {
+	// The ARN of the Kinesis Data Stream in the Panther account. Needs to be set by UI.
+	// The records of the stream can be log lines or CloudWatch Logs subscription data.
+	StreamARN string `json:"streamArn" validate:"required,startswith=arn:"`
+	// The log types associated with the source. Needs to be set by UI.
+	LogTypes []string `json:"logTypes" validate:"required,min=1"`
+}
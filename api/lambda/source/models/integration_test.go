@@ -0,0 +1,38 @@
+package models
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestS3PrefixLogTypes(t *testing.T) {
+	mappings := S3PrefixLogTypes{
+		{S3Prefix: "logs/", LogTypes: []string{"AWS.S3ServerAccess"}},
+		{S3Prefix: "logs/cloudtrail/", LogTypes: []string{"AWS.CloudTrail"}},
+		{S3Prefix: "", LogTypes: []string{"AWS.VPCFlow"}},
+	}
+	// the longest matching prefix wins
+	require.Equal(t, []string{"AWS.CloudTrail"}, mappings.LogTypes("logs/cloudtrail/file.json.gz"))
+	require.Equal(t, []string{"AWS.S3ServerAccess"}, mappings.LogTypes("logs/access/file.log"))
+	require.Equal(t, []string{"AWS.VPCFlow"}, mappings.LogTypes("vpc/file.log"))
+	require.Nil(t, S3PrefixLogTypes{}.LogTypes("logs/file.log"))
+}
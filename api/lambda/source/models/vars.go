@@ -25,6 +25,10 @@ const (
 	IntegrationTypeAWS3 = "aws-s3"
 	// IntegrationTypeSqs is integration type for pulling data from an SQS queue.
 	IntegrationTypeSqs = "aws-sqs"
+	// IntegrationTypeHTTP is the integration type for logs pushed to the HTTP ingestion endpoint.
+	IntegrationTypeHTTP = "http"
+	// IntegrationTypeKinesis is the integration type for Kinesis Data Streams, including CloudWatch Logs subscriptions.
+	IntegrationTypeKinesis = "aws-kinesis"
 
 	// StatusError is the string set in the database when an error occurs in a scan.
 	StatusError = "error"
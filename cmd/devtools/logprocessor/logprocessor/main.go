@@ -1,10 +1,11 @@
 package main
 
 import (
-	"compress/gzip"
 	"flag"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
 	"runtime"
 	"runtime/pprof"
 	"strconv"
@@ -15,12 +16,16 @@ import (
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/destinations"
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/processor"
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/registry"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/sources"
 )
 
 /*
-Run log processor locally for profiling purposes.
+Run log processor locally for profiling purposes or to process files offline.
 
 Profiles can then be visualized with the pprof tool: go tool pprof cpu.prof
+
+To process a directory of log files without any AWS resources, write the output to a local directory:
+	logprocessor -file ./logs -out ./output
 */
 
 var (
@@ -28,7 +33,8 @@ var (
 	TOPICARN   = flag.String("topic", "", "The arn for log processor notifications")
 	QUEUEURL   = flag.String("queue", "", "The url of the input queue")
 	TIMEOUT    = flag.Int("timeout", 900, "timeout in sec")
-	FILE       = flag.String("file", "", "The file to process (assumed to be gzipped).")
+	FILE       = flag.String("file", "", "The file or directory to process, '-' reads from stdin. Files can be plain text, compressed or archives.")
+	OUTDIR     = flag.String("out", "", "Write output to this local directory instead of S3 (no AWS resources are used).")
 	LOGTYPE    = flag.String("logtype", "", "The logType.")
 	MEMORYSIZE = flag.Int("lambdaSize", 1024, "The memory size of the lambda")
 
@@ -41,38 +47,36 @@ var (
 func main() {
 	flag.Parse()
 
-	if *BUCKET == "" {
-		log.Fatal("-bucket not set")
-	}
-	if *TOPICARN == "" {
-		log.Fatal("-topic not set")
-	}
-	if *QUEUEURL == "" {
-		log.Fatal("-queue not set")
+	if *FILE == "" {
+		log.Fatal("-file not set")
 	}
 
-	os.Setenv("AWS_LAMBDA_FUNCTION_MEMORY_SIZE", strconv.Itoa(*MEMORYSIZE))
-	os.Setenv("S3_BUCKET", *BUCKET)
-	os.Setenv("SNS_TOPIC_ARN", *TOPICARN)
-	os.Setenv("SQS_QUEUE_URL", *QUEUEURL)
-	os.Setenv("TIME_LIMIT_SEC", strconv.Itoa(*TIMEOUT))
+	var destination destinations.Destination
+	if *OUTDIR != "" {
+		destination = destinations.CreateLocalDestination(*OUTDIR, registry.Default())
+	} else {
+		if *BUCKET == "" {
+			log.Fatal("-bucket not set")
+		}
+		if *TOPICARN == "" {
+			log.Fatal("-topic not set")
+		}
+		if *QUEUEURL == "" {
+			log.Fatal("-queue not set")
+		}
+
+		os.Setenv("AWS_LAMBDA_FUNCTION_MEMORY_SIZE", strconv.Itoa(*MEMORYSIZE))
+		os.Setenv("PROCESSED_DATA_BUCKET", *BUCKET)
+		os.Setenv("SNS_TOPIC_ARN", *TOPICARN)
+		os.Setenv("SQS_QUEUE_URL", *QUEUEURL)
+		os.Setenv("TIME_LIMIT_SEC", strconv.Itoa(*TIMEOUT))
+		common.Setup()
+		destination = destinations.CreateS3Destination(registry.Default(), nil)
+	}
 
 	log.Printf("cores: %d", runtime.NumCPU())
 	log.Printf("input %s", *FILE)
 
-	fileReader, err := os.Open(*FILE)
-	if err != nil {
-		log.Fatal(err)
-	}
-	gzipReader, err := gzip.NewReader(fileReader)
-	if err != nil {
-		log.Fatal(err)
-	}
-	streamChan := make(chan *common.DataStream, 1)
-	dataStream := &common.DataStream{Reader: gzipReader, LogType: LOGTYPE}
-	streamChan <- dataStream
-	close(streamChan)
-
 	if *CPUPROFILE != "" {
 		f, err := os.Create(*CPUPROFILE)
 		if err != nil {
@@ -97,7 +101,30 @@ func main() {
 	}
 	zap.ReplaceGlobals(logger)
 
-	err = processor.Process(streamChan, destinations.CreateS3Destination(registry.Default()))
+	streamChan := make(chan *common.DataStream, 1)
+	go func() {
+		defer close(streamChan)
+		if *FILE == "-" {
+			readInput("stdin", os.Stdin, streamChan)
+			return
+		}
+		err := filepath.Walk(*FILE, func(path string, info os.FileInfo, err error) error {
+			if err != nil || !info.Mode().IsRegular() {
+				return err
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			readInput(path, &closeOnEOFReader{File: f}, streamChan)
+			return nil
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	err = processor.Process(streamChan, destination)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -114,3 +141,38 @@ func main() {
 		}
 	}
 }
+
+// readInput sends the data stream of a file to the processor, skipping unsupported files
+func readInput(name string, r io.Reader, streamChan chan *common.DataStream) {
+	dataStream, err := sources.ReadLocalFile(name, r)
+	if err != nil {
+		if closer, ok := r.(io.Closer); ok {
+			closer.Close()
+		}
+		if _, ok := err.(*sources.ErrUnsupportedFileType); ok {
+			log.Printf("skipping %s: %s", name, err)
+			return
+		}
+		log.Fatalf("failed to read %s: %s", name, err)
+	}
+	dataStream.LogType = LOGTYPE
+	streamChan <- dataStream
+}
+
+// closeOnEOFReader closes the file once it has been read, so that we don't keep all input files open
+type closeOnEOFReader struct {
+	*os.File
+	closed bool
+}
+
+func (r *closeOnEOFReader) Read(p []byte) (int, error) {
+	if r.closed {
+		return 0, io.EOF
+	}
+	n, err := r.File.Read(p)
+	if err == io.EOF {
+		r.closed = true
+		r.File.Close()
+	}
+	return n, err
+}
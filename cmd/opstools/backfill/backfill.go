@@ -0,0 +1,307 @@
+package backfill
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/sources"
+	"github.com/panther-labs/panther/pkg/awsbatch/sqsbatch"
+)
+
+const (
+	pageSize             = 1000
+	batchSize            = 10
+	batchTimeout         = time.Minute
+	fakeTopicArnTemplate = "arn:aws:sns:us-east-1:%s:panther-fake-backfill-topic" // account is added for sqs messages
+	progressNotify       = 5000                                                   // log a line every this many to show progress
+)
+
+// Config selects the objects to replay and how fast they are sent to the log processor
+type Config struct {
+	// The s3 path to list (e.g., s3://<bucket>/<prefix>)
+	S3Path string
+	// Objects are selected if their time is in [Start, End), a zero time leaves that side of the range open
+	Start time.Time
+	End   time.Time
+	// If true the time of an object is the date found in its key, otherwise its LastModified time
+	KeyTime bool
+	// If non-zero, no more than this number of files are sent per second
+	FilesPerSecond float64
+	// If non-zero, then limit the number of files to this number (including files sent by resumed runs)
+	Limit uint64
+	// If set, the log processor stores the events under OutputPrefixRoot/OutputPrefix, apart from the live partitions
+	OutputPrefix string
+	// If set, progress is saved to this local file and a run with the same file resumes after the last sent key
+	CheckpointFile string
+	Verbose        bool
+}
+
+type Stats struct {
+	NumFiles   uint64 `json:"numFiles"`
+	NumBytes   uint64 `json:"numBytes"`
+	NumSkipped uint64 `json:"numSkipped"`
+}
+
+func Backfill(sess *session.Session, account, s3region, queueName string, config *Config, stats *Stats) error {
+	return backfill(s3.New(sess.Copy(&aws.Config{Region: &s3region})), sqs.New(sess), account, queueName, config, stats)
+}
+
+func backfill(s3Client s3iface.S3API, sqsClient sqsiface.SQSAPI, account, queueName string,
+	config *Config, stats *Stats) error {
+
+	bucket, prefix, err := parseS3Path(config.S3Path)
+	if err != nil {
+		return err
+	}
+
+	queueURL, err := sqsClient.GetQueueUrl(&sqs.GetQueueUrlInput{
+		QueueName: &queueName,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "could not get queue url for %s", queueName)
+	}
+
+	listInput := &s3.ListObjectsV2Input{
+		Bucket:  aws.String(bucket),
+		Prefix:  aws.String(prefix),
+		MaxKeys: aws.Int64(pageSize),
+	}
+	if config.CheckpointFile != "" {
+		checkpoint, err := LoadCheckpoint(config.CheckpointFile)
+		if err != nil {
+			return err
+		}
+		if checkpoint != nil {
+			if checkpoint.S3Path != config.S3Path {
+				return errors.Errorf("checkpoint %s is for %s not %s", config.CheckpointFile, checkpoint.S3Path, config.S3Path)
+			}
+			zap.L().Info("resuming from checkpoint", zap.String("lastKey", checkpoint.LastKey))
+			listInput.StartAfter = aws.String(checkpoint.LastKey)
+			*stats = checkpoint.Stats
+		}
+	}
+
+	s := &sender{
+		sqsClient: sqsClient,
+		queueURL:  queueURL.QueueUrl,
+		// the account id is taken from this arn to assume role for reading in the log processor
+		topicARN: fmt.Sprintf(fakeTopicArnTemplate, account),
+		bucket:   bucket,
+		config:   config,
+		stats:    stats,
+	}
+	if config.FilesPerSecond > 0 {
+		s.throttle.perFile = time.Duration(float64(time.Second) / config.FilesPerSecond)
+	}
+
+	var sendErr error
+	err = s3Client.ListObjectsV2Pages(listInput, func(page *s3.ListObjectsV2Output, morePages bool) bool {
+		for _, object := range page.Contents {
+			if s.limitReached() {
+				return false
+			}
+			if aws.Int64Value(object.Size) == 0 { // we only care about objects with size
+				continue
+			}
+			if !config.selects(aws.StringValue(object.Key), aws.TimeValue(object.LastModified)) {
+				stats.NumSkipped++
+				continue
+			}
+			if sendErr = s.add(object); sendErr != nil {
+				return false // "To stop iterating, return false from the fn function."
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list %s", config.S3Path)
+	}
+	if sendErr != nil {
+		return sendErr
+	}
+	return s.flush()
+}
+
+// selects returns true if the time of an object is in the configured range
+func (config *Config) selects(key string, lastModified time.Time) bool {
+	if config.Start.IsZero() && config.End.IsZero() {
+		return true
+	}
+	if !config.KeyTime {
+		return !lastModified.Before(config.Start) && (config.End.IsZero() || lastModified.Before(config.End))
+	}
+	// the object holds the events of a whole day or hour, it is selected if that period overlaps the range
+	keyTime, period, ok := KeyTime(key)
+	if !ok {
+		return false
+	}
+	return keyTime.Add(period).After(config.Start) && (config.End.IsZero() || keyTime.Before(config.End))
+}
+
+// sender posts a message per file as-if it was an S3 notification, saving a checkpoint after each batch
+type sender struct {
+	sqsClient sqsiface.SQSAPI
+	queueURL  *string
+	topicARN  string
+	bucket    string
+	config    *Config
+	stats     *Stats
+	throttle  throttle
+	// we have 1 file per notification to limit blast radius in case of failure.
+	entries []*sqs.SendMessageBatchRequestEntry
+	lastKey string
+	bytes   uint64
+}
+
+func (s *sender) limitReached() bool {
+	return s.config.Limit > 0 && s.stats.NumFiles+uint64(len(s.entries)) >= s.config.Limit
+}
+
+func (s *sender) add(object *s3.Object) error {
+	key := aws.StringValue(object.Key)
+	if s.config.Verbose {
+		zap.L().Info("sending file to SQS", zap.String("bucket", s.bucket), zap.String("key", key))
+	}
+
+	message, err := s.notification(key)
+	if err != nil {
+		return err
+	}
+	s.entries = append(s.entries, &sqs.SendMessageBatchRequestEntry{
+		Id:          aws.String(strconv.Itoa(len(s.entries))),
+		MessageBody: &message,
+	})
+	s.lastKey = key
+	s.bytes += uint64(aws.Int64Value(object.Size))
+	if len(s.entries) == batchSize {
+		return s.flush()
+	}
+	return nil
+}
+
+// notification makes an S3 event for the object look like an SNS notification
+func (s *sender) notification(key string) (string, error) {
+	s3Notification := &events.S3Event{
+		Records: []events.S3EventRecord{
+			{
+				S3: events.S3Entity{
+					Bucket: events.S3Bucket{
+						Name: s.bucket,
+					},
+					Object: events.S3Object{
+						Key: key,
+					},
+				},
+			},
+		},
+	}
+	ctnJSON, err := jsoniter.MarshalToString(s3Notification)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to marshal %#v", s3Notification)
+	}
+
+	snsNotification := events.SNSEntity{
+		Type:     "Notification",
+		TopicArn: s.topicARN, // this is needed by the log processor to get account associated with the S3 object
+		Message:  ctnJSON,
+	}
+	if s.config.OutputPrefix != "" {
+		snsNotification.MessageAttributes = map[string]interface{}{
+			sources.OutputPrefixAttribute: map[string]string{
+				"Type":  "String",
+				"Value": sources.OutputPrefixRoot + s.config.OutputPrefix,
+			},
+		}
+	}
+	message, err := jsoniter.MarshalToString(snsNotification)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to marshal %#v", snsNotification)
+	}
+	return message, nil
+}
+
+// flush sends the pending messages and saves the checkpoint
+func (s *sender) flush() error {
+	if len(s.entries) == 0 {
+		return nil
+	}
+	s.throttle.wait(len(s.entries))
+
+	input := &sqs.SendMessageBatchInput{
+		QueueUrl: s.queueURL,
+		Entries:  s.entries,
+	}
+	if _, err := sqsbatch.SendMessageBatch(s.sqsClient, batchTimeout, input); err != nil {
+		return errors.Wrapf(err, "failed to send %#v", input)
+	}
+
+	lastCount := s.stats.NumFiles
+	s.stats.NumFiles += uint64(len(s.entries))
+	s.stats.NumBytes += s.bytes
+	if s.stats.NumFiles/progressNotify != lastCount/progressNotify {
+		log.Printf("sent %d files ...", s.stats.NumFiles)
+	}
+	s.entries = make([]*sqs.SendMessageBatchRequestEntry, 0, batchSize) // reset
+	s.bytes = 0
+
+	if s.config.CheckpointFile == "" {
+		return nil
+	}
+	checkpoint := &Checkpoint{
+		S3Path:    s.config.S3Path,
+		LastKey:   s.lastKey,
+		Stats:     *s.stats,
+		UpdatedAt: time.Now().UTC(),
+	}
+	return checkpoint.Save(s.config.CheckpointFile)
+}
+
+// throttle spaces out batches so that on average no more than one file is sent every perFile
+type throttle struct {
+	perFile time.Duration
+	next    time.Time
+}
+
+func (t *throttle) wait(numFiles int) {
+	if t.perFile == 0 {
+		return
+	}
+	if delay := time.Until(t.next); delay > 0 {
+		time.Sleep(delay)
+	} else {
+		t.next = time.Now()
+	}
+	t.next = t.next.Add(time.Duration(numFiles) * t.perFile)
+}
+
+// parseS3Path splits an s3path (e.g., s3://mybucket/myprefix) into bucket and prefix
+func parseS3Path(s3path string) (bucket, prefix string, err error) {
+	parsedPath, err := url.Parse(s3path)
+	if err != nil {
+		return "", "", errors.Errorf("bad s3 url: %s,", err)
+	}
+	if parsedPath.Scheme != "s3" {
+		return "", "", errors.Errorf("not s3 protocol (expecting s3://): %s,", s3path)
+	}
+	if parsedPath.Host == "" {
+		return "", "", errors.Errorf("missing bucket: %s,", s3path)
+	}
+	if len(parsedPath.Path) > 0 {
+		prefix = parsedPath.Path[1:] // remove leading '/'
+	}
+	return parsedPath.Host, prefix, nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/panther-labs/panther/cmd/opstools/backfill"
+)
+
+const (
+	banner = "replays historical s3 objects selected by time range to the log processor queue"
+)
+
+var (
+	REGION         = flag.String("region", "", "The Panther AWS region (optional, defaults to session env vars) where the queue exists.")
+	ACCOUNT        = flag.String("account", "", "The Panther AWS account id (optional, defaults to session account)")
+	S3PATH         = flag.String("s3path", "", "The s3 path to list (e.g., s3://<bucket>/<prefix>).")
+	START          = flag.String("start", "", "If set, only send objects from this RFC3339 time on (e.g., 2020-01-02T00:00:00Z).")
+	END            = flag.String("end", "", "If set, only send objects before this RFC3339 time.")
+	KEYTIME        = flag.Bool("keytime", false, "Select objects by the date in their key (e.g., dt=2020-01-02) instead of LastModified.")
+	RATE           = flag.Float64("rate", 0, "If non-zero, then send at most this number of files per second.")
+	LIMIT          = flag.Uint64("limit", 0, "If non-zero, then limit the number of files to this number (including resumed runs).")
+	OUTPUTPREFIX   = flag.String("output-prefix", "", "If set, store events under backfill/<output-prefix>/ instead of the live tables.")
+	CHECKPOINTFILE = flag.String("checkpoint", "", "If set, save progress to this local file and resume from it when it exists.")
+	TOQ            = flag.String("queue", "panther-input-data-notifications-queue", "The name of the log processor queue.")
+	VERBOSE        = flag.Bool("verbose", false, "Enable verbose logging")
+
+	logger *zap.SugaredLogger
+)
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(),
+		"%s %s\nUsage:\n",
+		filepath.Base(os.Args[0]), banner)
+	flag.PrintDefaults()
+}
+
+func init() {
+	flag.Usage = usage
+
+	config := zap.NewDevelopmentConfig() // DEBUG by default
+	if !*VERBOSE {
+		// In normal mode, hide DEBUG messages and file/line numbers
+		config.DisableCaller = true
+		config.Level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	}
+
+	// Always disable error traces and use color-coded log levels and short timestamps
+	config.DisableStacktrace = true
+	config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+
+	rawLogger, err := config.Build()
+	if err != nil {
+		log.Fatalf("failed to build logger: %s", err)
+	}
+	zap.ReplaceGlobals(rawLogger)
+	logger = rawLogger.Sugar()
+}
+
+func main() {
+	flag.Parse()
+
+	config := newConfig()
+
+	sess, err := session.NewSession()
+	if err != nil {
+		logger.Fatal(err)
+		return
+	}
+
+	if *REGION != "" { //override
+		sess.Config.Region = REGION
+	} else {
+		REGION = sess.Config.Region
+	}
+
+	s3Region := getS3Region(sess, *S3PATH)
+
+	if *ACCOUNT == "" {
+		identity, err := sts.New(sess).GetCallerIdentity(&sts.GetCallerIdentityInput{})
+		if err != nil {
+			logger.Fatalf("failed to get caller identity: %v", err)
+		}
+		ACCOUNT = identity.Account
+	}
+
+	startTime := time.Now()
+	if *VERBOSE {
+		logger.Infof("sending files from %s in %s (%s - %s) to %s in %s",
+			*S3PATH, s3Region, *START, *END, *TOQ, *REGION)
+	}
+
+	stats := &backfill.Stats{}
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, syscall.SIGTERM, syscall.SIGINT)
+		caught := <-sig // wait for it
+		logger.Fatalf("caught %v, sent %d files (%.2fMB) to %s in %v",
+			caught, stats.NumFiles, float32(stats.NumBytes)/(1024.0*1024.0), *TOQ, time.Since(startTime))
+	}()
+
+	err = backfill.Backfill(sess, *ACCOUNT, s3Region, *TOQ, config, stats)
+	if err != nil {
+		logger.Fatal(err)
+	} else {
+		logger.Infof("sent %d files (%.2fMB), skipped %d files, to %s (%s) in %v",
+			stats.NumFiles, float32(stats.NumBytes)/(1024.0*1024.0), stats.NumSkipped, *TOQ, *REGION, time.Since(startTime))
+	}
+}
+
+// newConfig validates the flags and returns the backfill configuration
+func newConfig() *backfill.Config {
+	var err error
+	defer func() {
+		if err != nil {
+			fmt.Printf("%s\n", err)
+			flag.Usage()
+			os.Exit(-2)
+		}
+	}()
+
+	config := &backfill.Config{
+		S3Path:         *S3PATH,
+		KeyTime:        *KEYTIME,
+		FilesPerSecond: *RATE,
+		Limit:          *LIMIT,
+		CheckpointFile: *CHECKPOINTFILE,
+		Verbose:        *VERBOSE,
+	}
+	if *S3PATH == "" {
+		err = errors.New("-s3path not set")
+		return nil
+	}
+	if *TOQ == "" {
+		err = errors.New("-queue not set")
+		return nil
+	}
+	if *START != "" {
+		if config.Start, err = time.Parse(time.RFC3339, *START); err != nil {
+			err = errors.Wrap(err, "-start is not RFC3339")
+			return nil
+		}
+	}
+	if *END != "" {
+		if config.End, err = time.Parse(time.RFC3339, *END); err != nil {
+			err = errors.Wrap(err, "-end is not RFC3339")
+			return nil
+		}
+		if !config.End.After(config.Start) {
+			err = errors.New("-end must be after -start")
+			return nil
+		}
+	}
+	if *RATE < 0 {
+		err = errors.New("-rate must not be negative")
+		return nil
+	}
+	if prefix := strings.Trim(*OUTPUTPREFIX, "/"); prefix != "" {
+		config.OutputPrefix = prefix + "/"
+	}
+	return config
+}
+
+func getS3Region(sess *session.Session, s3Path string) string {
+	parsedPath, err := url.Parse(s3Path)
+	if err != nil {
+		logger.Fatalf("failed to find bucket region for provided path %s: %s", s3Path, err)
+	}
+
+	input := &s3.GetBucketLocationInput{Bucket: aws.String(parsedPath.Host)}
+	location, err := s3.New(sess).GetBucketLocation(input)
+	if err != nil {
+		logger.Fatalf("failed to find bucket region for provided path %s: %s", s3Path, err)
+	}
+
+	// Method may return nil if region is us-east-1,https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketLocation.html
+	// and https://docs.aws.amazon.com/general/latest/gr/rande.html#s3_region
+	if location.LocationConstraint == nil {
+		return endpoints.UsEast1RegionID
+	}
+	return *location.LocationConstraint
+}
@@ -0,0 +1,243 @@
+package backfill
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testAccount   = "012345678912"
+	testBucket    = "foo"
+	testS3Path    = "s3://" + testBucket + "/bar/"
+	testQueueName = "testQueue"
+)
+
+var refTime = time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+
+func testObject(key string, lastModified time.Time) *s3.Object {
+	return &s3.Object{
+		Size:         aws.Int64(1), // 1 object of some size
+		Key:          aws.String(key),
+		LastModified: aws.Time(lastModified),
+	}
+}
+
+func newMockSQS() *mockSQS {
+	sqsClient := &mockSQS{}
+	sqsClient.On("GetQueueUrl", mock.Anything).Return(&sqs.GetQueueUrlOutput{QueueUrl: aws.String("arn")}, nil).Once()
+	return sqsClient
+}
+
+// sentKeys returns the keys of the S3 objects in the messages sent to the queue
+func sentKeys(t *testing.T, sqsClient *mockSQS) (keys []string) {
+	for _, call := range sqsClient.Calls {
+		if call.Method != "SendMessageBatch" {
+			continue
+		}
+		for _, entry := range call.Arguments.Get(0).(*sqs.SendMessageBatchInput).Entries {
+			message := []byte(*entry.MessageBody)
+			require.Equal(t, "Notification", jsoniter.Get(message, "Type").ToString())
+			s3Event := []byte(jsoniter.Get(message, "Message").ToString())
+			keys = append(keys, jsoniter.Get(s3Event, "Records", 0, "s3", "object", "key").ToString())
+		}
+	}
+	return keys
+}
+
+func TestBackfillLastModifiedRange(t *testing.T) {
+	s3Client := &mockS3{}
+	page := &s3.ListObjectsV2Output{
+		Contents: []*s3.Object{
+			testObject("bar/before", refTime.Add(-time.Second)),
+			testObject("bar/start", refTime),
+			testObject("bar/empty", refTime),
+			testObject("bar/inside", refTime.Add(time.Hour)),
+			testObject("bar/end", refTime.Add(24*time.Hour)),
+		},
+	}
+	page.Contents[2].Size = aws.Int64(0)
+	s3Client.On("ListObjectsV2Pages", mock.Anything, mock.Anything).Return(page, nil).Once()
+	sqsClient := newMockSQS()
+	sqsClient.On("SendMessageBatch", mock.Anything).Return(&sqs.SendMessageBatchOutput{}, nil).Once()
+
+	config := &Config{
+		S3Path: testS3Path,
+		Start:  refTime,
+		End:    refTime.Add(24 * time.Hour),
+	}
+	stats := &Stats{}
+	require.NoError(t, backfill(s3Client, sqsClient, testAccount, testQueueName, config, stats))
+	s3Client.AssertExpectations(t)
+	sqsClient.AssertExpectations(t)
+	assert.Equal(t, []string{"bar/start", "bar/inside"}, sentKeys(t, sqsClient))
+	assert.Equal(t, Stats{NumFiles: 2, NumBytes: 2, NumSkipped: 2}, *stats)
+
+	listInput := s3Client.Calls[0].Arguments.Get(0).(*s3.ListObjectsV2Input)
+	assert.Equal(t, testBucket, *listInput.Bucket)
+	assert.Equal(t, "bar/", *listInput.Prefix)
+	assert.Nil(t, listInput.StartAfter)
+}
+
+func TestBackfillKeyTimeRange(t *testing.T) {
+	s3Client := &mockS3{}
+	page := &s3.ListObjectsV2Output{
+		Contents: []*s3.Object{
+			testObject("bar/2020/01/01/x", refTime),
+			testObject("bar/2020/01/01/23/x", refTime),
+			testObject("bar/2020/01/02/x", refTime),
+			testObject("bar/2020/01/03/x", refTime),
+			testObject("bar/nodate", refTime),
+		},
+	}
+	s3Client.On("ListObjectsV2Pages", mock.Anything, mock.Anything).Return(page, nil).Once()
+	sqsClient := newMockSQS()
+	sqsClient.On("SendMessageBatch", mock.Anything).Return(&sqs.SendMessageBatchOutput{}, nil).Once()
+
+	config := &Config{
+		S3Path:  testS3Path,
+		Start:   refTime.Add(-30 * time.Minute), // the last half of the last hour of 2020-01-01
+		End:     refTime.Add(24 * time.Hour),
+		KeyTime: true,
+	}
+	stats := &Stats{}
+	require.NoError(t, backfill(s3Client, sqsClient, testAccount, testQueueName, config, stats))
+	assert.Equal(t, []string{"bar/2020/01/01/x", "bar/2020/01/01/23/x", "bar/2020/01/02/x"}, sentKeys(t, sqsClient))
+	assert.Equal(t, uint64(2), stats.NumSkipped)
+}
+
+func TestBackfillLimitAndBatch(t *testing.T) {
+	var contents []*s3.Object
+	for i := 0; i < (2*batchSize)+5; i++ {
+		contents = append(contents, testObject("bar/x", refTime))
+	}
+	s3Client := &mockS3{}
+	s3Client.On("ListObjectsV2Pages", mock.Anything, mock.Anything).
+		Return(&s3.ListObjectsV2Output{Contents: contents}, nil).Once()
+	sqsClient := newMockSQS()
+	sqsClient.On("SendMessageBatch", mock.Anything).Return(&sqs.SendMessageBatchOutput{}, nil).Times(3)
+
+	config := &Config{
+		S3Path: testS3Path,
+		Limit:  (2 * batchSize) + 1, // 2 full batches and one partial
+	}
+	stats := &Stats{}
+	require.NoError(t, backfill(s3Client, sqsClient, testAccount, testQueueName, config, stats))
+	sqsClient.AssertExpectations(t)
+	assert.Equal(t, config.Limit, stats.NumFiles)
+}
+
+func TestBackfillOutputPrefix(t *testing.T) {
+	s3Client := &mockS3{}
+	s3Client.On("ListObjectsV2Pages", mock.Anything, mock.Anything).
+		Return(&s3.ListObjectsV2Output{Contents: []*s3.Object{testObject("bar/x", refTime)}}, nil).Once()
+	sqsClient := newMockSQS()
+	sqsClient.On("SendMessageBatch", mock.Anything).Return(&sqs.SendMessageBatchOutput{}, nil).Once()
+
+	config := &Config{
+		S3Path:       testS3Path,
+		OutputPrefix: "replay-1/",
+	}
+	require.NoError(t, backfill(s3Client, sqsClient, testAccount, testQueueName, config, &Stats{}))
+	input := sqsClient.Calls[1].Arguments.Get(0).(*sqs.SendMessageBatchInput)
+	message := []byte(*input.Entries[0].MessageBody)
+	assert.Equal(t, "backfill/replay-1/", jsoniter.Get(message, "MessageAttributes", "OutputPrefix", "Value").ToString())
+	assert.Equal(t, "arn:aws:sns:us-east-1:"+testAccount+":panther-fake-backfill-topic", jsoniter.Get(message, "TopicArn").ToString())
+}
+
+func TestBackfillCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backfill")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	checkpointFile := filepath.Join(dir, "checkpoint.json")
+
+	// the first run fails on the second batch
+	var contents []*s3.Object
+	for i := 0; i < batchSize+1; i++ {
+		contents = append(contents, testObject("bar/"+string(rune('a'+i)), refTime))
+	}
+	s3Client := &mockS3{}
+	s3Client.On("ListObjectsV2Pages", mock.Anything, mock.Anything).
+		Return(&s3.ListObjectsV2Output{Contents: contents}, nil).Once()
+	sqsClient := newMockSQS()
+	sqsClient.On("SendMessageBatch", mock.Anything).Return(&sqs.SendMessageBatchOutput{}, nil).Once()
+	sqsClient.On("SendMessageBatch", mock.Anything).Return(&sqs.SendMessageBatchOutput{}, assert.AnError).Once()
+
+	config := &Config{
+		S3Path:         testS3Path,
+		CheckpointFile: checkpointFile,
+	}
+	require.Error(t, backfill(s3Client, sqsClient, testAccount, testQueueName, config, &Stats{}))
+	checkpoint, err := LoadCheckpoint(checkpointFile)
+	require.NoError(t, err)
+	require.NotNil(t, checkpoint)
+	assert.Equal(t, testS3Path, checkpoint.S3Path)
+	assert.Equal(t, "bar/j", checkpoint.LastKey)
+	assert.Equal(t, uint64(batchSize), checkpoint.Stats.NumFiles)
+
+	// the second run resumes after the last sent key
+	s3Client = &mockS3{}
+	s3Client.On("ListObjectsV2Pages", mock.Anything, mock.Anything).
+		Return(&s3.ListObjectsV2Output{Contents: contents[batchSize:]}, nil).Once()
+	sqsClient = newMockSQS()
+	sqsClient.On("SendMessageBatch", mock.Anything).Return(&sqs.SendMessageBatchOutput{}, nil).Once()
+	stats := &Stats{}
+	require.NoError(t, backfill(s3Client, sqsClient, testAccount, testQueueName, config, stats))
+	listInput := s3Client.Calls[0].Arguments.Get(0).(*s3.ListObjectsV2Input)
+	assert.Equal(t, "bar/j", *listInput.StartAfter)
+	assert.Equal(t, uint64(batchSize+1), stats.NumFiles)
+	checkpoint, err = LoadCheckpoint(checkpointFile)
+	require.NoError(t, err)
+	assert.Equal(t, "bar/k", checkpoint.LastKey)
+
+	// a checkpoint can't be used for another path
+	config.S3Path = "s3://" + testBucket + "/other/"
+	require.Error(t, backfill(s3Client, newMockSQS(), testAccount, testQueueName, config, &Stats{}))
+}
+
+func TestThrottle(t *testing.T) {
+	throttle := throttle{perFile: 10 * time.Millisecond}
+	start := time.Now()
+	throttle.wait(batchSize) // the first batch is not delayed
+	throttle.wait(batchSize)
+	assert.True(t, time.Since(start) >= batchSize*throttle.perFile)
+}
+
+type mockS3 struct {
+	s3iface.S3API
+	mock.Mock
+}
+
+func (m *mockS3) ListObjectsV2Pages(input *s3.ListObjectsV2Input, f func(page *s3.ListObjectsV2Output, morePages bool) bool) error {
+	args := m.Called(input, f)
+	f(args.Get(0).(*s3.ListObjectsV2Output), false)
+	return args.Error(1)
+}
+
+type mockSQS struct {
+	sqsiface.SQSAPI
+	mock.Mock
+}
+
+// nolint (golint)
+func (m *mockSQS) GetQueueUrl(input *sqs.GetQueueUrlInput) (*sqs.GetQueueUrlOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*sqs.GetQueueUrlOutput), args.Error(1)
+}
+
+func (m *mockSQS) SendMessageBatch(input *sqs.SendMessageBatchInput) (*sqs.SendMessageBatchOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*sqs.SendMessageBatchOutput), args.Error(1)
+}
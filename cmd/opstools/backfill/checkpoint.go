@@ -0,0 +1,51 @@
+package backfill
+
+import (
+	"io/ioutil"
+	"os"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+)
+
+// Checkpoint records the progress of a backfill so it can be resumed
+type Checkpoint struct {
+	S3Path string `json:"s3Path"`
+	// Objects are listed in key order, so a resumed run lists the objects after this key
+	LastKey   string    `json:"lastKey"`
+	Stats     Stats     `json:"stats"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// LoadCheckpoint reads a checkpoint file, it returns nil if the file does not exist
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read checkpoint %s", path)
+	}
+	checkpoint := &Checkpoint{}
+	if err := jsoniter.Unmarshal(data, checkpoint); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse checkpoint %s", path)
+	}
+	return checkpoint, nil
+}
+
+// Save writes the checkpoint to a temporary file and renames it so an interrupted write never corrupts it
+func (checkpoint *Checkpoint) Save(path string) error {
+	data, err := jsoniter.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal checkpoint")
+	}
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write checkpoint %s", tmpPath)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return errors.Wrapf(err, "failed to save checkpoint %s", path)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package backfill
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// keyTimePatterns match the dates that log sources commonly put in object keys, tried in order.
+// The submatches are year, month, day and an optional hour.
+var keyTimePatterns = []*regexp.Regexp{
+	// 2020/01/02, 2020/01/02/13/ and year=2020/month=01/day=02/hour=13/
+	regexp.MustCompile(`\b(?:year=)?(\d{4})/(?:month=)?(\d{1,2})/(?:day=)?(\d{1,2})(?:/|$)(?:(?:hour=)?(\d{1,2})/)?`),
+	// dt=2020-01-02, 2020-01-02T13:45:00 and 2020-01-02-13-45-00 (S3 server access logs)
+	regexp.MustCompile(`(\d{4})-(\d{2})-(\d{2})(?:[T-](\d{2}))?`),
+	// 20200102T1345Z (CloudTrail, VPC flow logs)
+	regexp.MustCompile(`(\d{4})(\d{2})(\d{2})T(\d{2})`),
+}
+
+// KeyTime returns the time of the date found in an S3 object key and the period it covers (a day or an hour)
+func KeyTime(key string) (time.Time, time.Duration, bool) {
+	for _, pattern := range keyTimePatterns {
+		for _, match := range pattern.FindAllStringSubmatch(key, -1) {
+			if t, period, ok := matchTime(match); ok {
+				return t, period, true
+			}
+		}
+	}
+	return time.Time{}, 0, false
+}
+
+func matchTime(match []string) (time.Time, time.Duration, bool) {
+	year, _ := strconv.Atoi(match[1])
+	month, _ := strconv.Atoi(match[2])
+	day, _ := strconv.Atoi(match[3])
+	if year < 1970 || month < 1 || month > 12 || day < 1 || day > 31 {
+		return time.Time{}, 0, false
+	}
+	if match[4] == "" {
+		return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC), 24 * time.Hour, true
+	}
+	hour, _ := strconv.Atoi(match[4])
+	if hour > 23 {
+		return time.Time{}, 0, false
+	}
+	return time.Date(year, time.Month(month), day, hour, 0, 0, 0, time.UTC), time.Hour, true
+}
@@ -0,0 +1,34 @@
+package backfill
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyTime(t *testing.T) {
+	day := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	hour := time.Date(2020, 1, 2, 13, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		key    string
+		time   time.Time
+		period time.Duration
+	}{
+		{"AWSLogs/123456789012/CloudTrail/us-east-1/2020/01/02/123456789012_CloudTrail_us-east-1_20200102T1355Z_x.json.gz", day, 24 * time.Hour},
+		{"firehose/2020/01/02/13/stream-1-2020-01-02-13-45-00-x.gz", hour, time.Hour},
+		{"logs/aws_cloudtrail/year=2020/month=01/day=02/hour=13/x.json.gz", hour, time.Hour},
+		{"exports/dt=2020-01-02/part-0000.json", day, 24 * time.Hour},
+		{"access/2020-01-02-13-45-00-ABCDEF0123456789", hour, time.Hour},
+		{"flows/123456789012_vpcflowlogs_us-east-1_fl-1234_20200102T1345Z_x.log.gz", hour, time.Hour},
+		{"weird/9999/99/99/2020/01/02/x", day, 24 * time.Hour},
+	} {
+		keyTime, period, ok := KeyTime(tc.key)
+		require.True(t, ok, tc.key)
+		require.Equal(t, tc.time, keyTime, tc.key)
+		require.Equal(t, tc.period, period, tc.key)
+	}
+
+	_, _, ok := KeyTime("no/date/here.json")
+	require.False(t, ok)
+}
@@ -19,13 +19,17 @@ package delivery
  */
 
 import (
+	"os"
+
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/lambda"
 	"github.com/aws/aws-sdk-go/service/lambda/lambdaiface"
 	"github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
 
 	"github.com/panther-labs/panther/internal/core/alert_delivery/outputs"
+	alertstable "github.com/panther-labs/panther/internal/log_analysis/alerts_api/table"
 )
 
 var (
@@ -38,6 +42,12 @@ var (
 
 	// Lazy-load the SQS client - we only need it to retry failed alerts
 	sqsClient sqsiface.SQSAPI
+
+	// Lazy-load the alerts table - we only need it to record the delivery of rule alerts
+	alertsTable alertstable.API
+
+	// Lazy-load the throttle states - we only need them for throttled outputs
+	throttles throttleStore
 )
 
 func getSQSClient() sqsiface.SQSAPI {
@@ -46,3 +56,23 @@ func getSQSClient() sqsiface.SQSAPI {
 	}
 	return sqsClient
 }
+
+func getAlertsTable() alertstable.API {
+	if alertsTable == nil {
+		alertsTable = &alertstable.AlertsTable{
+			AlertsTableName: os.Getenv("ALERTS_TABLE"),
+			Client:          dynamodb.New(awsSession),
+		}
+	}
+	return alertsTable
+}
+
+func getThrottles() throttleStore {
+	if throttles == nil {
+		throttles = &dynamoThrottleStore{
+			client:    dynamodb.New(awsSession),
+			tableName: os.Getenv("THROTTLE_TABLE"),
+		}
+	}
+	return throttles
+}
@@ -23,9 +23,11 @@ import (
 	"github.com/aws/aws-sdk-go/service/lambda/lambdaiface"
 	"github.com/stretchr/testify/mock"
 
+	alertsmodels "github.com/panther-labs/panther/api/lambda/alerts/models"
 	outputmodels "github.com/panther-labs/panther/api/lambda/outputs/models"
 	alertmodels "github.com/panther-labs/panther/internal/core/alert_delivery/models"
 	"github.com/panther-labs/panther/internal/core/alert_delivery/outputs"
+	alertstable "github.com/panther-labs/panther/internal/log_analysis/alerts_api/table"
 )
 
 type mockOutputsClient struct {
@@ -33,8 +35,10 @@ type mockOutputsClient struct {
 	mock.Mock
 }
 
-func (m *mockOutputsClient) Slack(alert *alertmodels.Alert, config *outputmodels.SlackConfig) *outputs.AlertDeliveryError {
-	args := m.Called(alert, config)
+func (m *mockOutputsClient) Slack(
+	alert *alertmodels.Alert, config *outputmodels.SlackConfig, templates *outputmodels.MessageTemplates) *outputs.AlertDeliveryError {
+
+	args := m.Called(alert, config, templates)
 	return args.Get(0).(*outputs.AlertDeliveryError)
 }
 
@@ -47,3 +51,13 @@ func (m *mockLambdaClient) Invoke(input *lambda.InvokeInput) (*lambda.InvokeOutp
 	args := m.Called(input)
 	return args.Get(0).(*lambda.InvokeOutput), args.Error(1)
 }
+
+type mockAlertsTable struct {
+	alertstable.API
+	mock.Mock
+}
+
+func (m *mockAlertsTable) AddDeliveryResponses(alertID string, responses []*alertsmodels.DeliveryResponse) error {
+	args := m.Called(alertID, responses)
+	return args.Error(0)
+}
@@ -0,0 +1,193 @@
+package delivery
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"go.uber.org/zap"
+
+	outputmodels "github.com/panther-labs/panther/api/lambda/outputs/models"
+	alertmodels "github.com/panther-labs/panther/internal/core/alert_delivery/models"
+	"github.com/panther-labs/panther/internal/core/alert_delivery/outputs"
+)
+
+const (
+	// The size of a digest is bounded to keep throttle states in a DynamoDB item and digests in a message.
+	// The alerts over these limits are still counted.
+	maxDigestEntries  = 100
+	maxDigestLinks    = 5
+	maxDigestAlertIDs = 100
+)
+
+// Severities from the lowest to the highest
+var severities = []string{"INFO", "LOW", "MEDIUM", "HIGH", "CRITICAL"}
+
+func severityRank(severity string) int {
+	for i, s := range severities {
+		if s == severity {
+			return i
+		}
+	}
+	return -1
+}
+
+// digestEntry counts the held alerts of a rule or policy with the same severity.
+type digestEntry struct {
+	AnalysisID string   `json:"analysisId"`
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	Severity   string   `json:"severity"`
+	Count      int      `json:"count"`
+	Links      []string `json:"links"`
+}
+
+// hold adds an alert to the digest of the window.
+func (window *throttleWindow) hold(alert *alertmodels.Alert) {
+	window.Held++
+	if alert.AlertID != nil && len(window.AlertIDs) < maxDigestAlertIDs {
+		window.AlertIDs = append(window.AlertIDs, *alert.AlertID)
+	}
+	link := outputs.AlertURL(alert)
+	for _, entry := range window.Digest {
+		if entry.AnalysisID == alert.AnalysisID && entry.Type == alert.Type && entry.Severity == alert.Severity {
+			entry.Count++
+			if len(entry.Links) < maxDigestLinks && !containsString(entry.Links, link) {
+				entry.Links = append(entry.Links, link)
+			}
+			return
+		}
+	}
+	if len(window.Digest) >= maxDigestEntries {
+		return
+	}
+	name := aws.StringValue(alert.AnalysisName)
+	if name == "" {
+		name = alert.AnalysisID
+	}
+	window.Digest = append(window.Digest, &digestEntry{
+		AnalysisID: alert.AnalysisID,
+		Name:       name,
+		Type:       alert.Type,
+		Severity:   alert.Severity,
+		Count:      1,
+		Links:      []string{link},
+	})
+}
+
+func plural(count int, singular, plural string) string {
+	if count == 1 {
+		return singular
+	}
+	return plural
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// digestAlert summarizes the held alerts of a window, by rule or policy and severity.
+func digestAlert(window *throttleWindow) *alertmodels.Alert {
+	entries := make([]*digestEntry, len(window.Digest))
+	copy(entries, window.Digest)
+	// The most severe first, then the most frequent
+	sort.SliceStable(entries, func(i, j int) bool {
+		if rankI, rankJ := severityRank(entries[i].Severity), severityRank(entries[j].Severity); rankI != rankJ {
+			return rankI > rankJ
+		}
+		return entries[i].Count > entries[j].Count
+	})
+
+	severity := severities[0]
+	analyses := make(map[string]bool)
+	types := make(map[string]bool)
+	listed := 0
+	var description strings.Builder
+	fmt.Fprintf(&description, "%d alerts were held back by the throttle of this destination from %s to %s\n",
+		window.Held, window.WindowStart.Format(time.RFC3339), window.WindowEnd.Format(time.RFC3339))
+	for _, entry := range entries {
+		if severityRank(entry.Severity) > severityRank(severity) {
+			severity = entry.Severity
+		}
+		analyses[entry.AnalysisID] = true
+		types[entry.Type] = true
+		listed += entry.Count
+
+		fmt.Fprintf(&description, "\n[%s] %s: %d\n", entry.Severity, entry.Name, entry.Count)
+		for _, link := range entry.Links {
+			description.WriteString(link + "\n")
+		}
+	}
+	if listed < window.Held {
+		fmt.Fprintf(&description, "\n%d other %s\n", window.Held-listed, plural(window.Held-listed, "alert", "alerts"))
+	}
+
+	analysisNouns := [2]string{"rule or policy", "rules and policies"}
+	switch {
+	case !types[alertmodels.PolicyType]:
+		analysisNouns = [2]string{"rule", "rules"}
+	case !types[alertmodels.RuleType]:
+		analysisNouns = [2]string{"policy", "policies"}
+	}
+	title := fmt.Sprintf("%d %s from %d %s", window.Held, plural(window.Held, "alert", "alerts"),
+		len(analyses), plural(len(analyses), analysisNouns[0], analysisNouns[1]))
+
+	return &alertmodels.Alert{
+		AnalysisID:          "Panther.Digest",
+		AnalysisName:        aws.String("Alert Digest"),
+		AnalysisDescription: aws.String(description.String()),
+		CreatedAt:           window.WindowEnd,
+		Severity:            severity,
+		Tags:                []string{},
+		LogTypes:            []string{},
+		Title:               aws.String(title),
+		Type:                alertmodels.DigestType,
+	}
+}
+
+// sendDigest delivers the digest of a window to its output.
+//
+// A nil output is an output that was deleted, its digests fail permanently.
+func sendDigest(output *outputmodels.AlertOutput, outputID string, window *throttleWindow) *outputs.AlertDeliveryError {
+	fields := []zap.Field{
+		zap.String("outputID", outputID),
+		zap.Int("alerts", window.Held),
+		zap.Int("attempts", window.Attempts),
+	}
+	if output == nil {
+		zap.L().Warn("dropping alert digest of deleted output", fields...)
+		return &outputs.AlertDeliveryError{Message: "output was deleted", Permanent: true}
+	}
+
+	zap.L().Info("sending alert digest", fields...)
+	deliveryError := outputs.Deliver(outputClient, digestAlert(window), output)
+	if deliveryError != nil {
+		zap.L().Warn("failed to send alert digest", append(fields, zap.Error(deliveryError))...)
+	}
+	return deliveryError
+}
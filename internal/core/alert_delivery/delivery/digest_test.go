@@ -0,0 +1,92 @@
+package delivery
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	alertmodels "github.com/panther-labs/panther/internal/core/alert_delivery/models"
+)
+
+func TestHoldGroupsAlerts(t *testing.T) {
+	state := &throttleWindow{}
+	for i := 0; i < maxDigestLinks+2; i++ {
+		state.hold(ruleAlert(fmt.Sprintf("alert-%d", i)))
+	}
+	high := ruleAlert("alert-high")
+	high.Severity = "HIGH"
+	state.hold(high)
+
+	assert.Equal(t, maxDigestLinks+3, state.Held)
+	require.Len(t, state.Digest, 2)
+	assert.Equal(t, maxDigestLinks+2, state.Digest[0].Count)
+	assert.Len(t, state.Digest[0].Links, maxDigestLinks)
+	assert.Equal(t, "HIGH", state.Digest[1].Severity)
+	assert.Equal(t, 1, state.Digest[1].Count)
+
+	state.hold(sampleAlert()) // policy alerts have no id
+	assert.Len(t, state.AlertIDs, maxDigestLinks+3)
+	assert.Equal(t, "alert-high", state.AlertIDs[maxDigestLinks+2])
+}
+
+func TestHoldLimitsEntries(t *testing.T) {
+	state := &throttleWindow{}
+	for i := 0; i < maxDigestEntries+1; i++ {
+		alert := ruleAlert("alert")
+		alert.AnalysisID = fmt.Sprintf("rule-%d", i)
+		state.hold(alert)
+	}
+	assert.Equal(t, maxDigestEntries+1, state.Held)
+	assert.Len(t, state.Digest, maxDigestEntries)
+	assert.Len(t, state.AlertIDs, maxDigestAlertIDs)
+}
+
+func TestDigestAlert(t *testing.T) {
+	state := &throttleWindow{
+		WindowStart: throttleNow,
+		WindowEnd:   throttleNow.Add(15 * time.Minute),
+	}
+	state.hold(ruleAlert("alert-1"))
+	state.hold(ruleAlert("alert-2"))
+	policy := sampleAlert()
+	policy.Type = alertmodels.PolicyType
+	policy.AnalysisID = "test-policy-id"
+	policy.AnalysisName = nil
+	policy.Severity = "CRITICAL"
+	state.hold(policy)
+	state.Held++ // an alert over the limits
+
+	alert := digestAlert(state)
+	assert.Equal(t, alertmodels.DigestType, alert.Type)
+	assert.Equal(t, "Panther.Digest", alert.AnalysisID)
+	assert.Equal(t, "CRITICAL", alert.Severity)
+	assert.Equal(t, state.WindowEnd, alert.CreatedAt)
+	assert.Equal(t, "4 alerts from 2 rules and policies", aws.StringValue(alert.Title))
+	assert.Equal(t, "4 alerts were held back by the throttle of this destination from "+
+		"2020-06-01T09:30:00Z to 2020-06-01T09:45:00Z\n"+
+		"\n[CRITICAL] test-policy-id: 1\ntest-policy-id\n"+
+		"\n[INFO] test_rule_name: 2\nalert-1\nalert-2\n"+
+		"\n1 other alert\n", aws.StringValue(alert.AnalysisDescription))
+}
@@ -19,8 +19,12 @@ package delivery
  */
 
 import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
 	"go.uber.org/zap"
 
+	alertsmodels "github.com/panther-labs/panther/api/lambda/alerts/models"
 	outputmodels "github.com/panther-labs/panther/api/lambda/outputs/models"
 	alertmodels "github.com/panther-labs/panther/internal/core/alert_delivery/models"
 	"github.com/panther-labs/panther/internal/core/alert_delivery/outputs"
@@ -31,6 +35,7 @@ type outputStatus struct {
 	outputID   string
 	success    bool
 	needsRetry bool
+	response   *alertsmodels.DeliveryResponse
 }
 
 // Send an alert to one specific output (run as a child goroutine).
@@ -41,56 +46,65 @@ func send(alert *alertmodels.Alert, output *outputmodels.AlertOutput, statusChan
 		zap.String("outputID", *output.OutputID),
 		zap.String("policyId", alert.AnalysisID),
 	}
+	response := &alertsmodels.DeliveryResponse{
+		OutputID:     *output.OutputID,
+		OutputType:   aws.StringValue(output.OutputType),
+		DisplayName:  aws.StringValue(output.DisplayName),
+		DispatchedAt: time.Now().UTC(),
+		Resent:       alert.ResentAt != nil,
+	}
+	report := func(deliveryError *outputs.AlertDeliveryError) {
+		status := outputStatus{outputID: *output.OutputID, response: response}
+		if deliveryError == nil {
+			status.success = true
+			response.Success = true
+		} else {
+			status.needsRetry = !deliveryError.Permanent
+			response.Message = deliveryError.Message
+			response.StatusCode = deliveryError.StatusCode
+			response.Permanent = deliveryError.Permanent
+		}
+		statusChannel <- status
+	}
+
 	defer func() {
 		// If we panic when sending an alert, log an error and report back to the channel.
 		// Otherwise, the main routine will wait forever for this to finish.
 		if r := recover(); r != nil {
 			zap.L().Error("panic sending alert", append(commonFields, zap.Any("panic", r))...)
-			statusChannel <- outputStatus{outputID: *output.OutputID, success: false, needsRetry: false}
+			report(&outputs.AlertDeliveryError{Message: "panic sending alert", Permanent: true})
 		}
 	}()
 
+	if isThrottled(alert, output) {
+		held, err := throttleAlert(alert, output, time.Now().UTC())
+		if err != nil {
+			// The alert is delivered rather than lost
+			zap.L().Error("failed to throttle alert", append(commonFields, zap.Error(err))...)
+		} else if held {
+			// Held alerts are not retried, but they are not delivered either
+			zap.L().Info("alert held for digest", commonFields...)
+			response.Throttled = true
+			response.Message = "held for the digest of the output"
+			statusChannel <- outputStatus{outputID: *output.OutputID, success: true, response: response}
+			return
+		}
+	}
+
 	zap.L().Info(
 		"sending alert",
 		append(commonFields, zap.String("name", *output.DisplayName))...,
 	)
 
-	var alertDeliveryError *outputs.AlertDeliveryError
-	switch *output.OutputType {
-	case "slack":
-		alertDeliveryError = outputClient.Slack(alert, output.OutputConfig.Slack)
-	case "pagerduty":
-		alertDeliveryError = outputClient.PagerDuty(alert, output.OutputConfig.PagerDuty)
-	case "github":
-		alertDeliveryError = outputClient.Github(alert, output.OutputConfig.Github)
-	case "opsgenie":
-		alertDeliveryError = outputClient.Opsgenie(alert, output.OutputConfig.Opsgenie)
-	case "jira":
-		alertDeliveryError = outputClient.Jira(alert, output.OutputConfig.Jira)
-	case "msteams":
-		alertDeliveryError = outputClient.MsTeams(alert, output.OutputConfig.MsTeams)
-	case "sqs":
-		alertDeliveryError = outputClient.Sqs(alert, output.OutputConfig.Sqs)
-	case "sns":
-		alertDeliveryError = outputClient.Sns(alert, output.OutputConfig.Sns)
-	case "asana":
-		alertDeliveryError = outputClient.Asana(alert, output.OutputConfig.Asana)
-	case "customwebhook":
-		alertDeliveryError = outputClient.CustomWebhook(alert, output.OutputConfig.CustomWebhook)
-	default:
-		zap.L().Warn("unsupported output type", commonFields...)
-		statusChannel <- outputStatus{outputID: *output.OutputID, success: false, needsRetry: false}
-		return
-	}
+	alertDeliveryError := outputs.Deliver(outputClient, alert, output)
 	if alertDeliveryError != nil {
 		zap.L().Warn("failed to send alert", append(commonFields, zap.Error(alertDeliveryError))...)
-		statusChannel <- outputStatus{
-			outputID: *output.OutputID, success: false, needsRetry: !alertDeliveryError.Permanent}
+		report(alertDeliveryError)
 		return
 	}
 
 	zap.L().Info("alert success", commonFields...)
-	statusChannel <- outputStatus{outputID: *output.OutputID, success: true, needsRetry: false}
+	report(nil)
 }
 
 // Dispatch sends the alert to each of its designated outputs.
@@ -125,8 +139,10 @@ func dispatch(alert *alertmodels.Alert) bool {
 
 	// Wait until all outputs have finished, gathering any that need to be retried.
 	var retryOutputs []string
+	responses := make([]*alertsmodels.DeliveryResponse, 0, len(alertOutputs))
 	for range alertOutputs {
 		status := <-statusChannel
+		responses = append(responses, status.response)
 		if status.needsRetry {
 			retryOutputs = append(retryOutputs, status.outputID)
 		} else if !status.success {
@@ -137,6 +153,8 @@ func dispatch(alert *alertmodels.Alert) bool {
 		}
 	}
 
+	recordDeliveries(alert, responses)
+
 	if len(retryOutputs) > 0 {
 		alert.OutputIds = retryOutputs // Replace the outputs with the set that failed
 		return false
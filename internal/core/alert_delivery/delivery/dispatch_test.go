@@ -28,7 +28,10 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 
+	alertsmodels "github.com/panther-labs/panther/api/lambda/alerts/models"
 	outputmodels "github.com/panther-labs/panther/api/lambda/outputs/models"
 	alertmodels "github.com/panther-labs/panther/internal/core/alert_delivery/models"
 	"github.com/panther-labs/panther/internal/core/alert_delivery/outputs"
@@ -66,11 +69,16 @@ func TestSendPanic(t *testing.T) {
 	outputClient = mockOutputsClient
 
 	ch := make(chan outputStatus, 1)
-	mockOutputsClient.On("Slack", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+	mockOutputsClient.On("Slack", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
 		panic("panicking")
 	})
 	go send(sampleAlert(), alertOutput, ch)
-	require.Equal(t, outputStatus{outputID: *alertOutput.OutputID}, <-ch)
+	status := <-ch
+	assert.Equal(t, *alertOutput.OutputID, status.outputID)
+	assert.False(t, status.success)
+	assert.False(t, status.needsRetry)
+	assert.Equal(t, "panic sending alert", status.response.Message)
+	assert.True(t, status.response.Permanent)
 	mockOutputsClient.AssertExpectations(t)
 }
 
@@ -80,8 +88,14 @@ func TestSendUnsupportedOutput(t *testing.T) {
 	setCaches()
 	ch := make(chan outputStatus, 1)
 
-	send(sampleAlert(), alertOutput, ch)
-	assert.Equal(t, outputStatus{outputID: *alertOutput.OutputID}, <-ch)
+	unsupportedOutput := *alertOutput
+	unsupportedOutput.OutputType = aws.String("carrier-pigeon")
+	send(sampleAlert(), &unsupportedOutput, ch)
+	status := <-ch
+	assert.Equal(t, *alertOutput.OutputID, status.outputID)
+	assert.False(t, status.success)
+	assert.False(t, status.needsRetry)
+	assert.Equal(t, "unsupported output type", status.response.Message)
 	mockClient.AssertExpectations(t)
 }
 
@@ -90,10 +104,22 @@ func TestSendTransientFailure(t *testing.T) {
 	outputClient = mockClient
 	setCaches()
 	ch := make(chan outputStatus, 1)
-	mockClient.On("Slack", mock.Anything, mock.Anything).Return(&outputs.AlertDeliveryError{})
+	mockClient.On("Slack", mock.Anything, mock.Anything, mock.Anything).Return(
+		&outputs.AlertDeliveryError{Message: "request failed: 503 Service Unavailable", StatusCode: 503})
 
 	send(sampleAlert(), alertOutput, ch)
-	assert.Equal(t, outputStatus{outputID: *alertOutput.OutputID, needsRetry: true}, <-ch)
+	status := <-ch
+	assert.Equal(t, *alertOutput.OutputID, status.outputID)
+	assert.False(t, status.success)
+	assert.True(t, status.needsRetry)
+	assert.Equal(t, &alertsmodels.DeliveryResponse{
+		OutputID:     "output-id",
+		OutputType:   "slack",
+		DisplayName:  "slack:alerts",
+		DispatchedAt: status.response.DispatchedAt,
+		StatusCode:   503,
+		Message:      "request failed: 503 Service Unavailable",
+	}, status.response)
 	mockClient.AssertExpectations(t)
 }
 
@@ -101,11 +127,15 @@ func TestSendSuccess(t *testing.T) {
 	mockClient := &mockOutputsClient{}
 	outputClient = mockClient
 	setCaches()
-	mockClient.On("Slack", mock.Anything, mock.Anything).Return((*outputs.AlertDeliveryError)(nil))
+	mockClient.On("Slack", mock.Anything, mock.Anything, mock.Anything).Return((*outputs.AlertDeliveryError)(nil))
 	ch := make(chan outputStatus, 1)
 
 	send(sampleAlert(), alertOutput, ch)
-	assert.Equal(t, outputStatus{outputID: *alertOutput.OutputID, success: true}, <-ch)
+	status := <-ch
+	assert.Equal(t, *alertOutput.OutputID, status.outputID)
+	assert.True(t, status.success)
+	assert.False(t, status.needsRetry)
+	assert.True(t, status.response.Success)
 	mockClient.AssertExpectations(t)
 }
 
@@ -113,7 +143,7 @@ func TestDispatchFailure(t *testing.T) {
 	mockClient := &mockOutputsClient{}
 	outputClient = mockClient
 	setCaches()
-	mockClient.On("Slack", mock.Anything, mock.Anything).Return(&outputs.AlertDeliveryError{})
+	mockClient.On("Slack", mock.Anything, mock.Anything, mock.Anything).Return(&outputs.AlertDeliveryError{})
 
 	assert.False(t, dispatch(sampleAlert()))
 	mockClient.AssertExpectations(t)
@@ -123,10 +153,61 @@ func TestDispatchSuccess(t *testing.T) {
 	mockClient := &mockOutputsClient{}
 	outputClient = mockClient
 	setCaches()
-	mockClient.On("Slack", mock.Anything, mock.Anything).Return((*outputs.AlertDeliveryError)(nil))
+	mockClient.On("Slack", mock.Anything, mock.Anything, mock.Anything).Return((*outputs.AlertDeliveryError)(nil))
 	assert.True(t, dispatch(sampleAlert()))
 }
 
+func TestDispatchRecordsDeliveries(t *testing.T) {
+	mockClient := &mockOutputsClient{}
+	outputClient = mockClient
+	mockTable := &mockAlertsTable{}
+	alertsTable = mockTable
+	defer func() { alertsTable = nil }()
+	setCaches()
+	mockClient.On("Slack", mock.Anything, mock.Anything, mock.Anything).Return(
+		&outputs.AlertDeliveryError{Message: "request failed: 404 Not Found", StatusCode: 404, Permanent: true})
+	mockTable.On("AddDeliveryResponses", "alertId", mock.Anything).Return(nil)
+
+	alert := sampleAlert()
+	alert.AlertID = aws.String("alertId")
+	assert.True(t, dispatch(alert)) // permanent failures are not retried
+	mockClient.AssertExpectations(t)
+	mockTable.AssertExpectations(t)
+
+	responses := mockTable.Calls[0].Arguments.Get(1).([]*alertsmodels.DeliveryResponse)
+	require.Len(t, responses, 1)
+	assert.Equal(t, "output-id", responses[0].OutputID)
+	assert.False(t, responses[0].Success)
+	assert.Equal(t, 404, responses[0].StatusCode)
+	assert.True(t, responses[0].Permanent)
+	assert.False(t, responses[0].Resent)
+}
+
+func TestDispatchDoesNotRecordPolicyDeliveries(t *testing.T) {
+	mockClient := &mockOutputsClient{}
+	outputClient = mockClient
+	mockTable := &mockAlertsTable{}
+	alertsTable = mockTable
+	defer func() { alertsTable = nil }()
+	setCaches()
+	mockClient.On("Slack", mock.Anything, mock.Anything, mock.Anything).Return((*outputs.AlertDeliveryError)(nil))
+
+	core, logs := observer.New(zap.InfoLevel)
+	defer zap.ReplaceGlobals(zap.New(core))()
+
+	assert.True(t, dispatch(sampleAlert()))
+	mockTable.AssertExpectations(t) // no AlertID, nothing is recorded
+
+	// the delivery is logged instead
+	entries := logs.FilterMessage("policy alert delivery").AllUntimed()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "test-rule-id", fields["policyId"])
+	assert.Equal(t, "output-id", fields["outputId"])
+	assert.Equal(t, "slack", fields["outputType"])
+	assert.Equal(t, true, fields["success"])
+}
+
 func TestDispatchUseCachedDefault(t *testing.T) {
 	mockLambdaClient := &mockLambdaClient{}
 	lambdaClient = mockLambdaClient
@@ -48,7 +48,11 @@ func HandleAlerts(alerts []*models.Alert) {
 
 	for _, alert := range alerts {
 		if !dispatch(alert) {
-			if time.Since(alert.CreatedAt) > getMaxRetryDuration() {
+			retryStart := alert.CreatedAt
+			if alert.ResentAt != nil {
+				retryStart = *alert.ResentAt
+			}
+			if time.Since(retryStart) > getMaxRetryDuration() {
 				zap.L().Error(
 					"alert delivery permanently failed, exceeded max retry duration",
 					zap.Strings("failedOutputs", alert.OutputIds),
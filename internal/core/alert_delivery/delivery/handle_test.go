@@ -38,7 +38,7 @@ func TestHandleAlertsPermanentlyFailed(t *testing.T) {
 	createdAtTime, _ := time.Parse(time.RFC3339, "2019-05-03T11:40:13Z")
 	mockClient := &mockOutputsClient{}
 	outputClient = mockClient
-	mockClient.On("Slack", mock.Anything, mock.Anything).Return(&outputs.AlertDeliveryError{})
+	mockClient.On("Slack", mock.Anything, mock.Anything, mock.Anything).Return(&outputs.AlertDeliveryError{})
 	sqsClient = &mockSQSClient{}
 	setCaches()
 	os.Setenv("ALERT_RETRY_DURATION_MINS", "5")
@@ -55,7 +55,7 @@ func TestHandleAlertsTemporarilyFailed(t *testing.T) {
 	createdAtTime := time.Now()
 	mockClient := &mockOutputsClient{}
 	outputClient = mockClient
-	mockClient.On("Slack", mock.Anything, mock.Anything).Return(&outputs.AlertDeliveryError{})
+	mockClient.On("Slack", mock.Anything, mock.Anything, mock.Anything).Return(&outputs.AlertDeliveryError{})
 	sqsClient = &mockSQSClient{}
 	setCaches()
 	os.Setenv("ALERT_RETRY_DURATION_MINS", "5")
@@ -70,3 +70,24 @@ func TestHandleAlertsTemporarilyFailed(t *testing.T) {
 	HandleAlerts(alerts)
 	assert.Equal(t, 3, sqsMessages)
 }
+
+func TestHandleAlertsResentRetriesFromResendTime(t *testing.T) {
+	createdAtTime, _ := time.Parse(time.RFC3339, "2019-05-03T11:40:13Z")
+	resentAt := time.Now()
+	mockClient := &mockOutputsClient{}
+	outputClient = mockClient
+	mockClient.On("Slack", mock.Anything, mock.Anything, mock.Anything).Return(&outputs.AlertDeliveryError{})
+	sqsClient = &mockSQSClient{}
+	setCaches()
+	os.Setenv("ALERT_RETRY_DURATION_MINS", "5")
+	os.Setenv("ALERT_QUEUE_URL", "sqs.url")
+	os.Setenv("MIN_RETRY_DELAY_SECS", "10")
+	os.Setenv("MAX_RETRY_DELAY_SECS", "30")
+	alert := sampleAlert()
+	alert.CreatedAt = createdAtTime
+	alert.ResentAt = &resentAt
+	sqsMessages = 0
+
+	HandleAlerts([]*models.Alert{alert})
+	assert.Equal(t, 1, sqsMessages)
+}
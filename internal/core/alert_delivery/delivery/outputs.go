@@ -26,6 +26,7 @@ import (
 
 	outputmodels "github.com/panther-labs/panther/api/lambda/outputs/models"
 	alertmodels "github.com/panther-labs/panther/internal/core/alert_delivery/models"
+	"github.com/panther-labs/panther/internal/core/alert_delivery/routing"
 	"github.com/panther-labs/panther/pkg/genericapi"
 )
 
@@ -49,8 +50,8 @@ var (
 	refreshInterval = getRefreshInterval()
 )
 
-// Get output ids for an alert
-func getAlertOutputs(alert *alertmodels.Alert) ([]*outputmodels.AlertOutput, error) {
+// Get all the outputs, they are cached for the refresh interval
+func getOutputs() ([]*outputmodels.AlertOutput, error) {
 	if cache == nil || time.Since(cache.Timestamp) > refreshInterval {
 		zap.L().Debug("getting cached default outputs")
 		input := outputmodels.LambdaInput{GetOutputsWithSecrets: &outputmodels.GetOutputsWithSecretsInput{}}
@@ -63,35 +64,26 @@ func getAlertOutputs(alert *alertmodels.Alert) ([]*outputmodels.AlertOutput, err
 			Timestamp: time.Now().UTC(),
 		}
 	}
-
-	// If alert doesn't have outputs IDs specified, return the defaults for the severity
-	if len(alert.OutputIds) == 0 {
-		return getOutputsBySeverity(alert.Severity), nil
-	}
-
-	result := []*outputmodels.AlertOutput{}
-	for _, output := range cache.Outputs {
-		for _, alertOutputID := range alert.OutputIds {
-			if *output.OutputID == alertOutputID {
-				result = append(result, output)
-			}
-		}
-	}
-	return result, nil
+	return cache.Outputs, nil
 }
 
-func getOutputsBySeverity(severity string) []*outputmodels.AlertOutput {
-	result := []*outputmodels.AlertOutput{}
-	if cache == nil {
-		return result
+// Get the outputs of an alert, see routing.Routes
+func getAlertOutputs(alert *alertmodels.Alert) ([]*outputmodels.AlertOutput, error) {
+	allOutputs, err := getOutputs()
+	if err != nil {
+		return nil, err
 	}
 
-	for _, output := range cache.Outputs {
-		for _, outputSeverity := range output.DefaultForSeverity {
-			if severity == *outputSeverity {
-				result = append(result, output)
-			}
+	routes := routing.Routes(alert, allOutputs)
+	result := make([]*outputmodels.AlertOutput, 0, len(routes))
+	for _, route := range routes {
+		if route.Reason == outputmodels.RouteReasonRoutingRule {
+			zap.L().Debug("alert matched routing rule",
+				zap.String("policyId", alert.AnalysisID),
+				zap.String("outputID", *route.Output.OutputID),
+				zap.Int("routingRule", route.RoutingRule))
 		}
+		result = append(result, route.Output)
 	}
-	return result
+	return result, nil
 }
@@ -120,6 +120,44 @@ func TestGetAlertOutputsFromOutputIds(t *testing.T) {
 	mockClient.AssertExpectations(t)
 }
 
+func TestGetAlertOutputsFromRoutingRules(t *testing.T) {
+	mockClient := &mockLambdaClient{}
+	lambdaClient = mockClient
+
+	output := &outputmodels.GetOutputsOutput{
+		{
+			OutputID:           aws.String("default-info"),
+			DefaultForSeverity: aws.StringSlice([]string{"INFO"}),
+			RoutingRules:       []*outputmodels.RoutingRule{{Tags: []string{"Identity"}}},
+		},
+		{
+			OutputID:     aws.String("identity-team"),
+			RoutingRules: []*outputmodels.RoutingRule{{Tags: []string{"Network"}}, {Tags: []string{"Identity"}}},
+		},
+		{
+			OutputID:     aws.String("network-team"),
+			RoutingRules: []*outputmodels.RoutingRule{{Tags: []string{"Network"}}},
+		},
+	}
+	payload, err := jsoniter.Marshal(output)
+	require.NoError(t, err)
+	mockLambdaResponse := &lambda.InvokeOutput{Payload: payload}
+
+	cache = nil // Clear the cache
+	mockClient.On("Invoke", mock.Anything).Return(mockLambdaResponse, nil).Once()
+	alert := sampleAlert()
+	alert.OutputIds = nil
+	alert.Tags = []string{"Identity"}
+
+	result, err := getAlertOutputs(alert)
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	// the output of the severity is not repeated for its matching rule
+	assert.Equal(t, "default-info", *result[0].OutputID)
+	assert.Equal(t, "identity-team", *result[1].OutputID)
+	mockClient.AssertExpectations(t)
+}
+
 func TestGetAlertOutputsIdsError(t *testing.T) {
 	mockClient := &mockLambdaClient{}
 	lambdaClient = mockClient
@@ -0,0 +1,102 @@
+package delivery
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"go.uber.org/zap"
+
+	alertsmodels "github.com/panther-labs/panther/api/lambda/alerts/models"
+	outputmodels "github.com/panther-labs/panther/api/lambda/outputs/models"
+	alertmodels "github.com/panther-labs/panther/internal/core/alert_delivery/models"
+	"github.com/panther-labs/panther/internal/core/alert_delivery/outputs"
+)
+
+// recordDeliveries appends the results of a dispatch to the item of a rule alert in the alerts table.
+//
+// Policy alerts are not stored in the table, so their deliveries are only logged, one "policy alert delivery"
+// entry per output that can be queried with CloudWatch Logs Insights.
+// Failing to record a delivery does not fail it: the alert was already sent.
+func recordDeliveries(alert *alertmodels.Alert, responses []*alertsmodels.DeliveryResponse) {
+	if alert.AlertID == nil {
+		for _, response := range responses {
+			logPolicyDelivery(alert, response)
+		}
+		return
+	}
+	if len(responses) == 0 {
+		return
+	}
+	if err := getAlertsTable().AddDeliveryResponses(*alert.AlertID, responses); err != nil {
+		zap.L().Error("failed to record alert delivery",
+			zap.String("alertId", *alert.AlertID),
+			zap.Error(err),
+		)
+	}
+}
+
+func logPolicyDelivery(alert *alertmodels.Alert, response *alertsmodels.DeliveryResponse) {
+	zap.L().Info("policy alert delivery",
+		zap.String("policyId", alert.AnalysisID),
+		zap.Time("createdAt", alert.CreatedAt),
+		zap.String("outputId", response.OutputID),
+		zap.String("outputType", response.OutputType),
+		zap.String("displayName", response.DisplayName),
+		zap.Time("dispatchedAt", response.DispatchedAt),
+		zap.Bool("success", response.Success),
+		zap.Bool("throttled", response.Throttled),
+		zap.Int("statusCode", response.StatusCode),
+		zap.String("message", response.Message),
+		zap.Bool("permanent", response.Permanent),
+	)
+}
+
+// recordDigest appends the outcome of a digest to the items of the rule alerts it summarized.
+//
+// A nil output is an output that was deleted.
+func recordDigest(output *outputmodels.AlertOutput, outputID string, window *throttleWindow,
+	deliveryError *outputs.AlertDeliveryError, now time.Time) {
+
+	response := &alertsmodels.DeliveryResponse{
+		OutputID:     outputID,
+		DispatchedAt: now,
+		Digest:       true,
+		Success:      deliveryError == nil,
+	}
+	if output != nil {
+		response.OutputType = aws.StringValue(output.OutputType)
+		response.DisplayName = aws.StringValue(output.DisplayName)
+	}
+	if deliveryError != nil {
+		response.Message = deliveryError.Message
+		response.StatusCode = deliveryError.StatusCode
+		response.Permanent = deliveryError.Permanent
+	}
+
+	for _, alertID := range window.AlertIDs {
+		if err := getAlertsTable().AddDeliveryResponses(alertID, []*alertsmodels.DeliveryResponse{response}); err != nil {
+			zap.L().Error("failed to record alert digest delivery",
+				zap.String("alertId", alertID),
+				zap.Error(err),
+			)
+		}
+	}
+}
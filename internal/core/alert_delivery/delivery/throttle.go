@@ -0,0 +1,216 @@
+package delivery
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	outputmodels "github.com/panther-labs/panther/api/lambda/outputs/models"
+	alertmodels "github.com/panther-labs/panther/internal/core/alert_delivery/models"
+	"github.com/panther-labs/panther/internal/core/alert_delivery/outputs"
+)
+
+const (
+	// maxThrottleAttempts bounds the retries of concurrent updates of the throttle state of an output
+	maxThrottleAttempts = 5
+
+	// maxPendingDigests bounds the ended windows kept for an output while its digests fail to deliver.
+	// Once reached, the current window is extended instead of ended.
+	maxPendingDigests = 3
+)
+
+// isThrottled returns true if an alert is subject to the throttle of an output.
+//
+// Alerts that are re-sent on request are always delivered.
+func isThrottled(alert *alertmodels.Alert, output *outputmodels.AlertOutput) bool {
+	return output.Throttle != nil && output.Throttle.WindowMinutes > 0 && alert.ResentAt == nil
+}
+
+// throttleAlert counts an alert in the current window of a throttled output.
+//
+// It returns true if the alert is held back for the digest of the window instead of being delivered.
+// If the current window has ended, it is queued for its digest and a new window starts.
+// Digests are sent by SendDigests.
+func throttleAlert(alert *alertmodels.Alert, output *outputmodels.AlertOutput, now time.Time) (bool, error) {
+	store := getThrottles()
+	window := time.Duration(output.Throttle.WindowMinutes) * time.Minute
+	for attempt := 0; attempt < maxThrottleAttempts; attempt++ {
+		state, err := store.get(*output.OutputID)
+		if err != nil {
+			return false, err
+		}
+
+		switch {
+		case state == nil:
+			state = &throttleState{OutputID: *output.OutputID}
+			state.startWindow(now, window)
+		case now.Before(state.WindowEnd):
+		case len(state.Pending) < maxPendingDigests:
+			state.endWindow()
+			state.startWindow(now, window)
+		default:
+			// The digests of the output are failing, keep holding alerts in the current window
+			state.WindowEnd = now.Add(window)
+		}
+
+		held := state.Delivered >= output.Throttle.MaxAlerts
+		if held {
+			state.hold(alert)
+		} else {
+			state.Delivered++
+		}
+
+		if err = store.put(state); err != nil {
+			if err == errThrottleConflict {
+				continue
+			}
+			return false, err
+		}
+		return held, nil
+	}
+	return false, errors.New("too many concurrent updates of the throttle state")
+}
+
+func (state *throttleState) startWindow(now time.Time, window time.Duration) {
+	state.throttleWindow = throttleWindow{WindowStart: now, WindowEnd: now.Add(window)}
+	state.Delivered = 0
+}
+
+// endWindow queues the digest of the current window, if alerts were held back in it.
+func (state *throttleState) endWindow() {
+	if state.Held > 0 {
+		ended := state.throttleWindow
+		state.Pending = append(state.Pending, &ended)
+	}
+	state.throttleWindow = throttleWindow{}
+	state.Delivered = 0
+}
+
+// SendDigests sends the digests of the throttle windows that have ended.
+func SendDigests() {
+	sendDigests(time.Now().UTC())
+}
+
+func sendDigests(now time.Time) {
+	states, err := getThrottles().list()
+	if err != nil {
+		zap.L().Error("failed to list throttle states", zap.Error(err))
+		return
+	}
+
+	var outputsByID map[string]*outputmodels.AlertOutput
+	for _, state := range states {
+		ended := !now.Before(state.WindowEnd) && len(state.Pending) < maxPendingDigests
+		if ended {
+			state.endWindow()
+		}
+		if !ended && len(state.Pending) == 0 {
+			continue
+		}
+		if len(state.Pending) > 0 && outputsByID == nil {
+			allOutputs, err := getOutputs()
+			if err != nil {
+				zap.L().Error("failed to get outputs to send alert digests", zap.Error(err))
+				return
+			}
+			outputsByID = make(map[string]*outputmodels.AlertOutput, len(allOutputs))
+			for _, output := range allOutputs {
+				outputsByID[*output.OutputID] = output
+			}
+		}
+
+		// Windows are identified by their start. A digest is done once delivered, permanently failed
+		// or failing for longer than alerts are retried.
+		done := make(map[int64]*outputs.AlertDeliveryError)
+		for _, window := range state.Pending {
+			deliveryError := sendDigest(outputsByID[state.OutputID], state.OutputID, window)
+			if deliveryError == nil || deliveryError.Permanent || now.Sub(window.WindowEnd) > getMaxRetryDuration() {
+				done[window.WindowStart.UnixNano()] = deliveryError
+			}
+		}
+
+		sent := state.Pending
+		if err = updateDigests(state, done, now); err != nil {
+			// The digests that were sent will be sent again
+			zap.L().Error("failed to update throttle state", zap.String("outputID", state.OutputID), zap.Error(err))
+			continue
+		}
+
+		for _, window := range sent {
+			if deliveryError, ok := done[window.WindowStart.UnixNano()]; ok {
+				recordDigest(outputsByID[state.OutputID], state.OutputID, window, deliveryError, now)
+			}
+		}
+	}
+}
+
+// updateDigests removes the windows whose digest is done from the state of an output
+// and counts a failed attempt for the others.
+//
+// The state is read again on concurrent updates: an alert may have started a new window.
+func updateDigests(state *throttleState, done map[int64]*outputs.AlertDeliveryError, now time.Time) error {
+	store := getThrottles()
+	sent := state.Pending
+	for attempt := 0; attempt < maxThrottleAttempts; attempt++ {
+		if attempt > 0 {
+			var err error
+			if state, err = store.get(state.OutputID); err != nil || state == nil {
+				return err
+			}
+			if !now.Before(state.WindowEnd) && len(state.Pending) < maxPendingDigests {
+				state.endWindow()
+			}
+		}
+
+		pending := make([]*throttleWindow, 0, len(state.Pending))
+		for _, window := range state.Pending {
+			if _, ok := done[window.WindowStart.UnixNano()]; ok {
+				continue
+			}
+			if containsWindow(sent, window) {
+				window.Attempts++
+			}
+			pending = append(pending, window)
+		}
+		state.Pending = pending
+
+		var err error
+		if state.Held == 0 && state.Delivered == 0 && len(state.Pending) == 0 {
+			err = store.delete(state)
+		} else {
+			err = store.put(state)
+		}
+		if err != errThrottleConflict {
+			return err
+		}
+	}
+	return errors.New("too many concurrent updates of the throttle state")
+}
+
+func containsWindow(windows []*throttleWindow, window *throttleWindow) bool {
+	for _, w := range windows {
+		if w.WindowStart.Equal(window.WindowStart) {
+			return true
+		}
+	}
+	return false
+}
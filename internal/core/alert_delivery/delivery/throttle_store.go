@@ -0,0 +1,200 @@
+package delivery
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+	"github.com/pkg/errors"
+)
+
+// errThrottleConflict is returned when a throttle state was changed since it was read
+var errThrottleConflict = errors.New("throttle state was updated concurrently")
+
+// throttleState is the current window of a throttled output, persisted across invocations.
+type throttleState struct {
+	OutputID string `json:"outputId"`
+
+	throttleWindow
+
+	// Delivered is the number of alerts delivered one by one in the current window
+	Delivered int `json:"delivered"`
+
+	// Pending are the ended windows whose digest was not delivered yet, oldest first
+	Pending []*throttleWindow `json:"pending,omitempty"`
+
+	// Version is incremented by every write, so that concurrent invocations do not overwrite each other
+	Version int `json:"version"`
+}
+
+// throttleWindow holds the alerts held back in a window, until its digest is delivered.
+type throttleWindow struct {
+	WindowStart time.Time `json:"windowStart"`
+	WindowEnd   time.Time `json:"windowEnd"`
+
+	// Held is the number of alerts held back for the digest of the window
+	Held int `json:"held"`
+
+	// Digest summarizes the held alerts, see hold
+	Digest []*digestEntry `json:"digest,omitempty"`
+
+	// AlertIDs are the ids of the held rule alerts, the outcome of the digest is recorded for them
+	AlertIDs []string `json:"alertIds,omitempty"`
+
+	// Attempts is the number of failed deliveries of the digest
+	Attempts int `json:"attempts,omitempty"`
+}
+
+// throttleStore persists the throttle states of outputs.
+type throttleStore interface {
+	// get returns the state of an output, or nil if it has none
+	get(outputID string) (*throttleState, error)
+
+	// put saves a state and increments its version, unless it was changed since it was read
+	put(state *throttleState) error
+
+	// delete removes a state, unless it was changed since it was read
+	delete(state *throttleState) error
+
+	// list returns all the states
+	list() ([]*throttleState, error)
+}
+
+// dynamoThrottleStore keeps one item per throttled output in a DynamoDB table.
+type dynamoThrottleStore struct {
+	client    dynamodbiface.DynamoDBAPI
+	tableName string
+}
+
+func (s *dynamoThrottleStore) key(outputID string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{"outputId": {S: aws.String(outputID)}}
+}
+
+// condition matches the item of a state if it was not changed since the state was read
+func (s *dynamoThrottleStore) condition(state *throttleState) (expression.Expression, error) {
+	condition := expression.AttributeNotExists(expression.Name("outputId"))
+	if state.Version > 0 {
+		condition = expression.Name("version").Equal(expression.Value(state.Version))
+	}
+	return expression.NewBuilder().WithCondition(condition).Build()
+}
+
+func (s *dynamoThrottleStore) get(outputID string) (*throttleState, error) {
+	response, err := s.client.GetItem(&dynamodb.GetItemInput{
+		ConsistentRead: aws.Bool(true),
+		Key:            s.key(outputID),
+		TableName:      aws.String(s.tableName),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get throttle state")
+	}
+	if len(response.Item) == 0 {
+		return nil, nil
+	}
+
+	state := &throttleState{}
+	if err = dynamodbattribute.UnmarshalMap(response.Item, state); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal throttle state")
+	}
+	return state, nil
+}
+
+func (s *dynamoThrottleStore) put(state *throttleState) error {
+	condition, err := s.condition(state)
+	if err != nil {
+		return errors.Wrap(err, "failed to build throttle state condition")
+	}
+
+	next := *state
+	next.Version++
+	item, err := dynamodbattribute.MarshalMap(&next)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal throttle state")
+	}
+
+	_, err = s.client.PutItem(&dynamodb.PutItemInput{
+		ConditionExpression:       condition.Condition(),
+		ExpressionAttributeNames:  condition.Names(),
+		ExpressionAttributeValues: condition.Values(),
+		Item:                      item,
+		TableName:                 aws.String(s.tableName),
+	})
+	if err != nil {
+		return s.wrapWriteError(err, "failed to put throttle state")
+	}
+	state.Version = next.Version
+	return nil
+}
+
+func (s *dynamoThrottleStore) delete(state *throttleState) error {
+	condition, err := s.condition(state)
+	if err != nil {
+		return errors.Wrap(err, "failed to build throttle state condition")
+	}
+
+	_, err = s.client.DeleteItem(&dynamodb.DeleteItemInput{
+		ConditionExpression:       condition.Condition(),
+		ExpressionAttributeNames:  condition.Names(),
+		ExpressionAttributeValues: condition.Values(),
+		Key:                       s.key(state.OutputID),
+		TableName:                 aws.String(s.tableName),
+	})
+	if err != nil {
+		return s.wrapWriteError(err, "failed to delete throttle state")
+	}
+	return nil
+}
+
+func (s *dynamoThrottleStore) list() ([]*throttleState, error) {
+	var states []*throttleState
+	input := &dynamodb.ScanInput{
+		ConsistentRead: aws.Bool(true),
+		TableName:      aws.String(s.tableName),
+	}
+	for {
+		response, err := s.client.Scan(input)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan throttle states")
+		}
+		for _, item := range response.Items {
+			state := &throttleState{}
+			if err = dynamodbattribute.UnmarshalMap(item, state); err != nil {
+				return nil, errors.Wrap(err, "failed to unmarshal throttle state")
+			}
+			states = append(states, state)
+		}
+		if len(response.LastEvaluatedKey) == 0 {
+			return states, nil
+		}
+		input.ExclusiveStartKey = response.LastEvaluatedKey
+	}
+}
+
+func (s *dynamoThrottleStore) wrapWriteError(err error, message string) error {
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return errThrottleConflict
+	}
+	return errors.Wrap(err, message)
+}
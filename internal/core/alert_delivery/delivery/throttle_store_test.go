@@ -0,0 +1,139 @@
+package delivery
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/pkg/testutils"
+)
+
+func TestThrottleStoreGet(t *testing.T) {
+	mockDynamo := &testutils.DynamoDBMock{}
+	store := &dynamoThrottleStore{client: mockDynamo, tableName: "table"}
+	expected := &throttleState{
+		OutputID:       "output-id",
+		throttleWindow: throttleWindow{WindowStart: throttleNow, Held: 1, AlertIDs: []string{"alert-1"}},
+		Delivered:      2,
+		Pending:        []*throttleWindow{{WindowStart: throttleNow.Add(-time.Hour), Held: 3, Attempts: 1}},
+		Version:        3,
+	}
+	item, err := dynamodbattribute.MarshalMap(expected)
+	require.NoError(t, err)
+	assert.NotNil(t, item["windowStart"]) // the current window is flattened in the item
+
+	mockDynamo.On("GetItem", &dynamodb.GetItemInput{
+		ConsistentRead: aws.Bool(true),
+		Key:            map[string]*dynamodb.AttributeValue{"outputId": {S: aws.String("output-id")}},
+		TableName:      aws.String("table"),
+	}).Return(&dynamodb.GetItemOutput{Item: item}, nil).Once()
+	mockDynamo.On("GetItem", mock.Anything).Return(&dynamodb.GetItemOutput{}, nil).Once()
+
+	state, err := store.get("output-id")
+	require.NoError(t, err)
+	assert.Equal(t, expected, state)
+
+	state, err = store.get("other-id")
+	require.NoError(t, err)
+	assert.Nil(t, state)
+	mockDynamo.AssertExpectations(t)
+}
+
+func TestThrottleStorePut(t *testing.T) {
+	mockDynamo := &testutils.DynamoDBMock{}
+	store := &dynamoThrottleStore{client: mockDynamo, tableName: "table"}
+	state := &throttleState{OutputID: "output-id", Version: 3}
+
+	mockDynamo.On("PutItem", mock.Anything).Return(&dynamodb.PutItemOutput{}, nil).Once()
+	require.NoError(t, store.put(state))
+	assert.Equal(t, 4, state.Version)
+
+	input := mockDynamo.Calls[0].Arguments.Get(0).(*dynamodb.PutItemInput)
+	assert.Equal(t, "#0 = :0", aws.StringValue(input.ConditionExpression))
+	assert.Equal(t, "version", aws.StringValue(input.ExpressionAttributeNames["#0"]))
+	assert.Equal(t, "3", aws.StringValue(input.ExpressionAttributeValues[":0"].N))
+	assert.Equal(t, "4", aws.StringValue(input.Item["version"].N))
+	mockDynamo.AssertExpectations(t)
+}
+
+func TestThrottleStorePutNew(t *testing.T) {
+	mockDynamo := &testutils.DynamoDBMock{}
+	store := &dynamoThrottleStore{client: mockDynamo, tableName: "table"}
+
+	mockDynamo.On("PutItem", mock.Anything).Return(&dynamodb.PutItemOutput{}, nil).Once()
+	require.NoError(t, store.put(&throttleState{OutputID: "output-id"}))
+
+	input := mockDynamo.Calls[0].Arguments.Get(0).(*dynamodb.PutItemInput)
+	assert.Equal(t, "attribute_not_exists (#0)", aws.StringValue(input.ConditionExpression))
+	assert.Equal(t, "outputId", aws.StringValue(input.ExpressionAttributeNames["#0"]))
+	mockDynamo.AssertExpectations(t)
+}
+
+func TestThrottleStoreConflict(t *testing.T) {
+	mockDynamo := &testutils.DynamoDBMock{}
+	store := &dynamoThrottleStore{client: mockDynamo, tableName: "table"}
+	state := &throttleState{OutputID: "output-id", Version: 3}
+	conflict := awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "conflict", nil)
+
+	mockDynamo.On("PutItem", mock.Anything).Return(&dynamodb.PutItemOutput{}, conflict).Once()
+	mockDynamo.On("DeleteItem", mock.Anything).Return(&dynamodb.DeleteItemOutput{}, conflict).Once()
+	assert.Equal(t, errThrottleConflict, store.put(state))
+	assert.Equal(t, 3, state.Version)
+	assert.Equal(t, errThrottleConflict, store.delete(state))
+
+	mockDynamo.On("DeleteItem", mock.Anything).Return(&dynamodb.DeleteItemOutput{}, errors.New("error")).Once()
+	err := store.delete(state)
+	require.Error(t, err)
+	assert.NotEqual(t, errThrottleConflict, err)
+	mockDynamo.AssertExpectations(t)
+}
+
+func TestThrottleStoreList(t *testing.T) {
+	mockDynamo := &testutils.DynamoDBMock{}
+	store := &dynamoThrottleStore{client: mockDynamo, tableName: "table"}
+	first, err := dynamodbattribute.MarshalMap(&throttleState{OutputID: "first-id"})
+	require.NoError(t, err)
+	second, err := dynamodbattribute.MarshalMap(&throttleState{OutputID: "second-id"})
+	require.NoError(t, err)
+	lastKey := map[string]*dynamodb.AttributeValue{"outputId": {S: aws.String("first-id")}}
+
+	mockDynamo.On("Scan", mock.MatchedBy(func(input *dynamodb.ScanInput) bool {
+		return input.ExclusiveStartKey == nil
+	})).Return(&dynamodb.ScanOutput{Items: []map[string]*dynamodb.AttributeValue{first}, LastEvaluatedKey: lastKey}, nil).Once()
+	mockDynamo.On("Scan", mock.MatchedBy(func(input *dynamodb.ScanInput) bool {
+		return input.ExclusiveStartKey != nil
+	})).Return(&dynamodb.ScanOutput{Items: []map[string]*dynamodb.AttributeValue{second}}, nil).Once()
+
+	states, err := store.list()
+	require.NoError(t, err)
+	require.Len(t, states, 2)
+	assert.Equal(t, "first-id", states[0].OutputID)
+	assert.Equal(t, "second-id", states[1].OutputID)
+	mockDynamo.AssertExpectations(t)
+}
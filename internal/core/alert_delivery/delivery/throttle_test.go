@@ -0,0 +1,361 @@
+package delivery
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	alertsmodels "github.com/panther-labs/panther/api/lambda/alerts/models"
+	outputmodels "github.com/panther-labs/panther/api/lambda/outputs/models"
+	alertmodels "github.com/panther-labs/panther/internal/core/alert_delivery/models"
+	"github.com/panther-labs/panther/internal/core/alert_delivery/outputs"
+)
+
+// memoryThrottleStore keeps copies of the states, like the table does
+type memoryThrottleStore struct {
+	states map[string]*throttleState
+	// conflicts is the number of writes that fail as if the state was updated concurrently
+	conflicts int
+}
+
+func newMemoryThrottleStore(states ...*throttleState) *memoryThrottleStore {
+	store := &memoryThrottleStore{states: make(map[string]*throttleState)}
+	for _, state := range states {
+		store.states[state.OutputID] = copyThrottleState(state)
+	}
+	return store
+}
+
+func copyThrottleState(state *throttleState) *throttleState {
+	data, _ := jsoniter.Marshal(state)
+	result := &throttleState{}
+	_ = jsoniter.Unmarshal(data, result)
+	return result
+}
+
+func (s *memoryThrottleStore) get(outputID string) (*throttleState, error) {
+	if state, ok := s.states[outputID]; ok {
+		return copyThrottleState(state), nil
+	}
+	return nil, nil
+}
+
+func (s *memoryThrottleStore) check(state *throttleState) error {
+	if s.conflicts > 0 {
+		s.conflicts--
+		return errThrottleConflict
+	}
+	current, ok := s.states[state.OutputID]
+	if (!ok && state.Version != 0) || (ok && current.Version != state.Version) {
+		return errThrottleConflict
+	}
+	return nil
+}
+
+func (s *memoryThrottleStore) put(state *throttleState) error {
+	if err := s.check(state); err != nil {
+		return err
+	}
+	state.Version++
+	s.states[state.OutputID] = copyThrottleState(state)
+	return nil
+}
+
+func (s *memoryThrottleStore) delete(state *throttleState) error {
+	if err := s.check(state); err != nil {
+		return err
+	}
+	delete(s.states, state.OutputID)
+	return nil
+}
+
+func (s *memoryThrottleStore) list() (result []*throttleState, err error) {
+	for _, state := range s.states {
+		result = append(result, copyThrottleState(state))
+	}
+	return result, nil
+}
+
+func throttledOutput(maxAlerts int) *outputmodels.AlertOutput {
+	output := *alertOutput
+	output.Throttle = &outputmodels.AlertThrottle{WindowMinutes: 15, MaxAlerts: maxAlerts}
+	return &output
+}
+
+var throttleNow = time.Date(2020, 6, 1, 9, 30, 0, 0, time.UTC)
+
+func ruleAlert(alertID string) *alertmodels.Alert {
+	alert := sampleAlert()
+	alert.Type = alertmodels.RuleType
+	alert.AlertID = aws.String(alertID)
+	return alert
+}
+
+func TestIsThrottled(t *testing.T) {
+	alert := sampleAlert()
+	assert.False(t, isThrottled(alert, alertOutput))
+	assert.False(t, isThrottled(alert, &outputmodels.AlertOutput{Throttle: &outputmodels.AlertThrottle{}}))
+	assert.True(t, isThrottled(alert, throttledOutput(0)))
+
+	alert.ResentAt = aws.Time(throttleNow)
+	assert.False(t, isThrottled(alert, throttledOutput(0)))
+}
+
+func TestThrottleAlertDeliversUpToMaxAlerts(t *testing.T) {
+	store := newMemoryThrottleStore()
+	throttles = store
+	defer func() { throttles = nil }()
+	output := throttledOutput(2)
+
+	for i, expected := range []bool{false, false, true, true} {
+		held, err := throttleAlert(ruleAlert("alert-1"), output, throttleNow.Add(time.Duration(i)*time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, expected, held, i)
+	}
+
+	state := store.states["output-id"]
+	require.NotNil(t, state)
+	assert.Equal(t, throttleNow, state.WindowStart)
+	assert.Equal(t, throttleNow.Add(15*time.Minute), state.WindowEnd)
+	assert.Equal(t, 2, state.Delivered)
+	assert.Equal(t, 2, state.Held)
+	require.Len(t, state.Digest, 1)
+	assert.Equal(t, 2, state.Digest[0].Count)
+	assert.Equal(t, "test_rule_name", state.Digest[0].Name)
+	assert.Equal(t, 4, state.Version)
+}
+
+func TestThrottleAlertQueuesEndedWindow(t *testing.T) {
+	ended := &throttleState{OutputID: "output-id", Delivered: 1, Version: 3}
+	ended.WindowStart, ended.WindowEnd = throttleNow.Add(-time.Hour), throttleNow
+	ended.hold(ruleAlert("alert-1"))
+	store := newMemoryThrottleStore(ended)
+	throttles = store
+	defer func() { throttles = nil }()
+
+	held, err := throttleAlert(sampleAlert(), throttledOutput(1), throttleNow)
+	require.NoError(t, err)
+	assert.False(t, held)
+
+	state := store.states["output-id"]
+	assert.Equal(t, throttleNow, state.WindowStart)
+	assert.Equal(t, 1, state.Delivered)
+	assert.Equal(t, 0, state.Held)
+	assert.Empty(t, state.Digest)
+	require.Len(t, state.Pending, 1)
+	assert.Equal(t, throttleNow.Add(-time.Hour), state.Pending[0].WindowStart)
+	assert.Equal(t, []string{"alert-1"}, state.Pending[0].AlertIDs)
+	assert.Equal(t, 4, state.Version)
+}
+
+func TestThrottleAlertExtendsWindowWithPendingDigests(t *testing.T) {
+	ended := &throttleState{OutputID: "output-id", Delivered: 1, Version: 1}
+	ended.WindowStart, ended.WindowEnd = throttleNow.Add(-time.Hour), throttleNow
+	for i := 0; i < maxPendingDigests; i++ {
+		ended.Pending = append(ended.Pending, &throttleWindow{WindowStart: throttleNow.Add(-time.Duration(i+2) * time.Hour)})
+	}
+	store := newMemoryThrottleStore(ended)
+	throttles = store
+	defer func() { throttles = nil }()
+
+	held, err := throttleAlert(ruleAlert("alert-1"), throttledOutput(1), throttleNow)
+	require.NoError(t, err)
+	assert.True(t, held)
+
+	state := store.states["output-id"]
+	assert.Equal(t, throttleNow.Add(-time.Hour), state.WindowStart)
+	assert.Equal(t, throttleNow.Add(15*time.Minute), state.WindowEnd)
+	assert.Equal(t, 1, state.Held)
+	assert.Len(t, state.Pending, maxPendingDigests)
+}
+
+func TestThrottleAlertRetriesConflicts(t *testing.T) {
+	store := newMemoryThrottleStore()
+	store.conflicts = 2
+	throttles = store
+	defer func() { throttles = nil }()
+
+	held, err := throttleAlert(sampleAlert(), throttledOutput(0), throttleNow)
+	require.NoError(t, err)
+	assert.True(t, held)
+	assert.Equal(t, 1, store.states["output-id"].Held)
+
+	store.conflicts = maxThrottleAttempts
+	_, err = throttleAlert(sampleAlert(), throttledOutput(0), throttleNow)
+	assert.Error(t, err)
+	assert.Equal(t, 1, store.states["output-id"].Held)
+}
+
+func TestSendThrottledAlertHeld(t *testing.T) {
+	mockClient := &mockOutputsClient{}
+	outputClient = mockClient
+	throttles = newMemoryThrottleStore()
+	defer func() { throttles = nil }()
+	ch := make(chan outputStatus, 1)
+
+	send(sampleAlert(), throttledOutput(0), ch)
+	status := <-ch
+	assert.True(t, status.success)
+	assert.False(t, status.needsRetry)
+	assert.False(t, status.response.Success)
+	assert.True(t, status.response.Throttled)
+	assert.Equal(t, "held for the digest of the output", status.response.Message)
+	mockClient.AssertExpectations(t) // nothing is delivered
+}
+
+func TestSendResentAlertIsNotThrottled(t *testing.T) {
+	mockClient := &mockOutputsClient{}
+	outputClient = mockClient
+	store := newMemoryThrottleStore()
+	throttles = store
+	defer func() { throttles = nil }()
+	ch := make(chan outputStatus, 1)
+	mockClient.On("Slack", mock.Anything, mock.Anything, mock.Anything).Return((*outputs.AlertDeliveryError)(nil)).Once()
+
+	alert := sampleAlert()
+	alert.ResentAt = aws.Time(throttleNow)
+	send(alert, throttledOutput(0), ch)
+	status := <-ch
+	assert.True(t, status.success)
+	assert.Empty(t, status.response.Message)
+	assert.Empty(t, store.states)
+	mockClient.AssertExpectations(t)
+}
+
+// endedState is the state of an output whose window ended at throttleNow
+func endedState(outputID string, alertIDs ...string) *throttleState {
+	state := &throttleState{OutputID: outputID, Version: 1}
+	state.WindowStart, state.WindowEnd = throttleNow.Add(-15*time.Minute), throttleNow
+	for _, alertID := range alertIDs {
+		state.hold(ruleAlert(alertID))
+	}
+	return state
+}
+
+func TestSendDigests(t *testing.T) {
+	mockClient := &mockOutputsClient{}
+	outputClient = mockClient
+	mockTable := &mockAlertsTable{}
+	alertsTable = mockTable
+	defer func() { alertsTable = nil }()
+	os.Setenv("ALERT_RETRY_DURATION_MINS", "30")
+	output := throttledOutput(0)
+	cache = &outputsCache{Outputs: []*outputmodels.AlertOutput{output}, Timestamp: time.Now()}
+
+	due := endedState("output-id", "alert-1", "alert-2")
+	active := &throttleState{OutputID: "active-id", Version: 1}
+	active.WindowStart, active.WindowEnd = throttleNow, throttleNow.Add(time.Minute)
+	active.hold(sampleAlert())
+	empty := endedState("empty-id")
+	empty.Delivered = 2
+	deleted := endedState("deleted-id", "alert-3")
+	store := newMemoryThrottleStore(due, active, empty, deleted)
+	throttles = store
+	defer func() { throttles = nil }()
+
+	mockClient.On("Slack", mock.MatchedBy(func(alert *alertmodels.Alert) bool {
+		return aws.StringValue(alert.Title) == "2 alerts from 1 rule"
+	}), output.OutputConfig.Slack, output.Templates).Return((*outputs.AlertDeliveryError)(nil)).Once()
+	recorded := func(success bool) interface{} {
+		return mock.MatchedBy(func(responses []*alertsmodels.DeliveryResponse) bool {
+			return len(responses) == 1 && responses[0].Digest && responses[0].Success == success
+		})
+	}
+	mockTable.On("AddDeliveryResponses", "alert-1", recorded(true)).Return(nil).Once()
+	mockTable.On("AddDeliveryResponses", "alert-2", recorded(true)).Return(nil).Once()
+	mockTable.On("AddDeliveryResponses", "alert-3", recorded(false)).Return(nil).Once()
+
+	sendDigests(throttleNow)
+	mockClient.AssertExpectations(t)
+	mockTable.AssertExpectations(t)
+	assert.Len(t, store.states, 1)
+	assert.NotNil(t, store.states["active-id"])
+	assert.Equal(t, 1, store.states["active-id"].Version) // not rewritten
+}
+
+func TestSendDigestsRetriesFailures(t *testing.T) {
+	mockClient := &mockOutputsClient{}
+	outputClient = mockClient
+	mockTable := &mockAlertsTable{}
+	alertsTable = mockTable
+	defer func() { alertsTable = nil }()
+	os.Setenv("ALERT_RETRY_DURATION_MINS", "30")
+	output := throttledOutput(0)
+	cache = &outputsCache{Outputs: []*outputmodels.AlertOutput{output}, Timestamp: time.Now()}
+	store := newMemoryThrottleStore(endedState("output-id", "alert-1"))
+	throttles = store
+	defer func() { throttles = nil }()
+	mockClient.On("Slack", mock.Anything, mock.Anything, mock.Anything).Return(
+		&outputs.AlertDeliveryError{Message: "request failed: 503", StatusCode: 503})
+
+	// The digest is kept while it fails
+	sendDigests(throttleNow)
+	sendDigests(throttleNow.Add(time.Minute))
+	state := store.states["output-id"]
+	require.NotNil(t, state)
+	require.Len(t, state.Pending, 1)
+	assert.Equal(t, 2, state.Pending[0].Attempts)
+	mockTable.AssertNotCalled(t, "AddDeliveryResponses", mock.Anything, mock.Anything)
+
+	// Until the retry duration is over
+	mockTable.On("AddDeliveryResponses", "alert-1", mock.MatchedBy(func(responses []*alertsmodels.DeliveryResponse) bool {
+		return responses[0].Digest && !responses[0].Success && responses[0].StatusCode == 503
+	})).Return(nil).Once()
+	sendDigests(throttleNow.Add(31 * time.Minute))
+	mockClient.AssertNumberOfCalls(t, "Slack", 3)
+	mockTable.AssertExpectations(t)
+	assert.Empty(t, store.states)
+}
+
+func TestSendDigestsKeepsConcurrentWindow(t *testing.T) {
+	mockClient := &mockOutputsClient{}
+	outputClient = mockClient
+	alertsTable = &mockAlertsTable{}
+	defer func() { alertsTable = nil }()
+	os.Setenv("ALERT_RETRY_DURATION_MINS", "30")
+	output := throttledOutput(0)
+	cache = &outputsCache{Outputs: []*outputmodels.AlertOutput{output}, Timestamp: time.Now()}
+	store := newMemoryThrottleStore(endedState("output-id"))
+	store.states["output-id"].Pending = []*throttleWindow{{WindowStart: throttleNow.Add(-time.Hour), Held: 1}}
+	throttles = store
+	defer func() { throttles = nil }()
+
+	// An alert starts a new window while the digest is sent
+	mockClient.On("Slack", mock.Anything, mock.Anything, mock.Anything).Return((*outputs.AlertDeliveryError)(nil)).Run(
+		func(mock.Arguments) {
+			_, err := throttleAlert(ruleAlert("alert-2"), output, throttleNow)
+			require.NoError(t, err)
+		}).Once()
+
+	sendDigests(throttleNow)
+	mockClient.AssertExpectations(t)
+	state := store.states["output-id"]
+	require.NotNil(t, state)
+	assert.Empty(t, state.Pending)
+	assert.Equal(t, throttleNow, state.WindowStart)
+	assert.Equal(t, 1, state.Held)
+}
@@ -37,7 +37,15 @@ import (
 
 var validate = validator.New()
 
-func lambdaHandler(ctx context.Context, event events.SQSEvent) (err error) {
+// deliveryEvent is either a batch of the alert queue, or a scheduled event to send the digests of throttled outputs
+type deliveryEvent struct {
+	events.SQSEvent
+	Source string `json:"source"`
+}
+
+const scheduledEventSource = "aws.events"
+
+func lambdaHandler(ctx context.Context, event deliveryEvent) (err error) {
 	var alerts []*models.Alert
 
 	lc, _ := lambdalogger.ConfigureGlobal(ctx, nil)
@@ -46,6 +54,11 @@ func lambdaHandler(ctx context.Context, event events.SQSEvent) (err error) {
 		operation.Stop().Log(err, zap.Int("numEvents", len(event.Records)), zap.Int("numAlerts", len(alerts)))
 	}()
 
+	if event.Source == scheduledEventSource {
+		delivery.SendDigests()
+		return nil
+	}
+
 	for _, record := range event.Records {
 		alert := &models.Alert{}
 		if err = jsoniter.UnmarshalFromString(record.Body, alert); err != nil {
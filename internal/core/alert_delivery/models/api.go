@@ -26,6 +26,10 @@ const (
 
 	// PolicyType identifies the Alert to be for a Policy
 	PolicyType = "POLICY"
+
+	// DigestType identifies an Alert summarizing the alerts held back by the throttle of an output.
+	// Digests are delivered directly, they are never queued.
+	DigestType = "DIGEST"
 )
 
 // Alert is the schema for each row in the Dynamo alerts table.
@@ -60,9 +64,15 @@ type Alert struct {
 	// Tags is the set of policy tags.
 	Tags []string `json:"tags,omitempty"`
 
+	// LogTypes are the log types of the events that triggered a rule alert.
+	LogTypes []string `json:"logTypes,omitempty"`
+
 	// AlertID specifies the alertId that this Alert is associated with.
 	AlertID *string `json:"alertId,omitempty"`
 
 	// Title is the optional title for the alert generated by Python Rules engine
 	Title *string `json:"title,omitempty"`
+
+	// ResentAt is set if the alert is sent again on request, retries are bounded from this time instead of CreatedAt.
+	ResentAt *time.Time `json:"resentAt,omitempty"`
 }
@@ -33,13 +33,15 @@ const (
 )
 
 // Asana creates a task in Asana projects
-func (client *OutputClient) Asana(alert *alertmodels.Alert, config *outputmodels.AsanaConfig) *AlertDeliveryError {
+func (client *OutputClient) Asana(
+	alert *alertmodels.Alert, config *outputmodels.AsanaConfig, templates *outputmodels.MessageTemplates) *AlertDeliveryError {
+
 	zap.L().Debug("sending alert to Asana")
 	payload := map[string]interface{}{
 		"data": map[string]interface{}{
-			"name":     generateAlertTitle(alert),
+			"name":     generateTitle(alert, templates),
 			"projects": config.ProjectGids,
-			"notes":    generateDetailedAlertMessage(alert),
+			"notes":    generateBody(alert, templates, generateDetailedAlertMessage(alert)),
 		},
 	}
 
@@ -19,17 +19,31 @@ package outputs
  */
 
 import (
+	jsoniter "github.com/json-iterator/go"
+	"go.uber.org/zap"
+
 	outputmodels "github.com/panther-labs/panther/api/lambda/outputs/models"
 	alertmodels "github.com/panther-labs/panther/internal/core/alert_delivery/models"
 )
 
 // CustomWebhook alert send an alert.
 func (client *OutputClient) CustomWebhook(
-	alert *alertmodels.Alert, config *outputmodels.CustomWebhookConfig) *AlertDeliveryError {
+	alert *alertmodels.Alert, config *outputmodels.CustomWebhookConfig, templates *outputmodels.MessageTemplates) *AlertDeliveryError {
+
+	var body interface{} = generateNotificationFromAlert(alert, templates)
+	if templates != nil && templates.Payload != "" {
+		payload, err := renderPayload(templates.Payload, newTemplateData(alert))
+		if err != nil {
+			// The alert is still delivered, with the default payload
+			zap.L().Warn("using the default payload", zap.String("policyId", alert.AnalysisID), zap.Error(err))
+		} else {
+			body = jsoniter.RawMessage(payload)
+		}
+	}
 
 	postInput := &PostInput{
 		url:  config.WebhookURL,
-		body: generateNotificationFromAlert(alert),
+		body: body,
 	}
 	return client.httpWrapper.post(postInput)
 }
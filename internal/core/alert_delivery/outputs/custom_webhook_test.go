@@ -22,6 +22,8 @@ import (
 	"testing"
 	"time"
 
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	outputmodels "github.com/panther-labs/panther/api/lambda/outputs/models"
@@ -71,6 +73,55 @@ func TestCustomWebhookAlert(t *testing.T) {
 
 	httpWrapper.On("post", expectedPostInput).Return((*AlertDeliveryError)(nil))
 
-	require.Nil(t, client.CustomWebhook(alert, customWebhookConfig))
+	require.Nil(t, client.CustomWebhook(alert, customWebhookConfig, nil))
 	httpWrapper.AssertExpectations(t)
 }
+
+func TestCustomWebhookAlertWithPayloadTemplate(t *testing.T) {
+	httpWrapper := &mockHTTPWrapper{}
+	client := &OutputClient{httpWrapper: httpWrapper}
+
+	alert := &alertmodels.Alert{
+		AnalysisID: "policyId",
+		CreatedAt:  time.Now(),
+		Severity:   "INFO",
+	}
+	templates := &outputmodels.MessageTemplates{
+		Payload: `{"text": {{json .Title}}, "priority": "{{lower .Severity}}"}`,
+	}
+
+	expectedPostInput := &PostInput{
+		url:  "custom-webhook-url",
+		body: jsoniter.RawMessage(`{"text": "Policy Failure: policyId", "priority": "info"}`),
+	}
+
+	httpWrapper.On("post", expectedPostInput).Return((*AlertDeliveryError)(nil))
+
+	require.Nil(t, client.CustomWebhook(alert, customWebhookConfig, templates))
+	httpWrapper.AssertExpectations(t)
+}
+
+func TestCustomWebhookAlertInvalidPayloadUsesDefault(t *testing.T) {
+	httpWrapper := &mockHTTPWrapper{}
+	client := &OutputClient{httpWrapper: httpWrapper}
+
+	alert := &alertmodels.Alert{
+		AnalysisID: "policyId",
+		CreatedAt:  time.Now(),
+		Severity:   "INFO",
+	}
+	// Valid at save time, but the alert makes it render an invalid document
+	templates := &outputmodels.MessageTemplates{
+		Title:   "{{.Name}}",
+		Payload: `{"runbook": {{if .Runbook}}{{json .Runbook}}{{end}}}`,
+	}
+
+	httpWrapper.On("post", mock.Anything).Return((*AlertDeliveryError)(nil))
+
+	require.NoError(t, ValidateTemplates(templates))
+
+	require.Nil(t, client.CustomWebhook(alert, customWebhookConfig, templates))
+	httpWrapper.AssertExpectations(t)
+	notification := httpWrapper.Calls[0].Arguments.Get(0).(*PostInput).body.(Notification)
+	require.Equal(t, "policyId", notification.Title)
+}
@@ -0,0 +1,55 @@
+package outputs
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+
+	outputmodels "github.com/panther-labs/panther/api/lambda/outputs/models"
+	alertmodels "github.com/panther-labs/panther/internal/core/alert_delivery/models"
+)
+
+// Deliver sends an alert to an output with the client method of its type.
+func Deliver(client API, alert *alertmodels.Alert, output *outputmodels.AlertOutput) *AlertDeliveryError {
+	config, templates := output.OutputConfig, output.Templates
+	switch aws.StringValue(output.OutputType) {
+	case "slack":
+		return client.Slack(alert, config.Slack, templates)
+	case "pagerduty":
+		return client.PagerDuty(alert, config.PagerDuty, templates)
+	case "github":
+		return client.Github(alert, config.Github, templates)
+	case "opsgenie":
+		return client.Opsgenie(alert, config.Opsgenie, templates)
+	case "jira":
+		return client.Jira(alert, config.Jira, templates)
+	case "msteams":
+		return client.MsTeams(alert, config.MsTeams, templates)
+	case "sqs":
+		return client.Sqs(alert, config.Sqs, templates)
+	case "sns":
+		return client.Sns(alert, config.Sns, templates)
+	case "asana":
+		return client.Asana(alert, config.Asana, templates)
+	case "customwebhook":
+		return client.CustomWebhook(alert, config.CustomWebhook, templates)
+	default:
+		return &AlertDeliveryError{Message: "unsupported output type", Permanent: true}
+	}
+}
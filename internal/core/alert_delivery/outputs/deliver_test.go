@@ -0,0 +1,63 @@
+package outputs
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/assert"
+
+	outputmodels "github.com/panther-labs/panther/api/lambda/outputs/models"
+	alertmodels "github.com/panther-labs/panther/internal/core/alert_delivery/models"
+)
+
+type snsOnlyClient struct {
+	API
+	config *outputmodels.SnsConfig
+}
+
+func (c *snsOnlyClient) Sns(
+	_ *alertmodels.Alert, config *outputmodels.SnsConfig, _ *outputmodels.MessageTemplates) *AlertDeliveryError {
+
+	c.config = config
+	return &AlertDeliveryError{Message: "sns failed"}
+}
+
+func TestDeliver(t *testing.T) {
+	client := &snsOnlyClient{}
+	config := &outputmodels.SnsConfig{TopicArn: "arn:aws:sns:us-west-2:123456789012:alerts"}
+	output := &outputmodels.AlertOutput{
+		OutputType:   aws.String("sns"),
+		OutputConfig: &outputmodels.OutputConfig{Sns: config},
+	}
+
+	assert.Equal(t, &AlertDeliveryError{Message: "sns failed"}, Deliver(client, &alertmodels.Alert{}, output))
+	assert.Equal(t, config, client.config)
+}
+
+func TestDeliverUnsupportedType(t *testing.T) {
+	output := &outputmodels.AlertOutput{
+		OutputType:   aws.String("carrier-pigeon"),
+		OutputConfig: &outputmodels.OutputConfig{},
+	}
+
+	assert.Equal(t, &AlertDeliveryError{Message: "unsupported output type", Permanent: true},
+		Deliver(&snsOnlyClient{}, &alertmodels.Alert{}, output))
+}
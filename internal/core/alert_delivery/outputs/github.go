@@ -35,7 +35,7 @@ const (
 
 // Github alert send an issue.
 func (client *OutputClient) Github(
-	alert *alertmodels.Alert, config *outputmodels.GithubConfig) *AlertDeliveryError {
+	alert *alertmodels.Alert, config *outputmodels.GithubConfig, templates *outputmodels.MessageTemplates) *AlertDeliveryError {
 
 	description := "**Description:** " + aws.StringValue(alert.AnalysisDescription)
 	link := "\n [Click here to view in the Panther UI](" + generateURL(alert) + ")"
@@ -44,8 +44,8 @@ func (client *OutputClient) Github(
 	tags := "\n **Tags:** " + strings.Join(alert.Tags, ", ")
 
 	githubRequest := map[string]interface{}{
-		"title": generateAlertTitle(alert),
-		"body":  description + link + runBook + severity + tags,
+		"title": generateTitle(alert, templates),
+		"body":  generateBody(alert, templates, description+link+runBook+severity+tags),
 	}
 
 	token := "token " + config.Token
@@ -65,6 +65,6 @@ func TestGithubAlert(t *testing.T) {
 
 	httpWrapper.On("post", expectedPostInput).Return((*AlertDeliveryError)(nil))
 
-	require.Nil(t, client.Github(alert, githubConfig))
+	require.Nil(t, client.Github(alert, githubConfig, nil))
 	httpWrapper.AssertExpectations(t)
 }
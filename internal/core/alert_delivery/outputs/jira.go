@@ -34,7 +34,7 @@ const (
 
 // Jira alert send an issue.
 func (client *OutputClient) Jira(
-	alert *alertmodels.Alert, config *outputmodels.JiraConfig) *AlertDeliveryError {
+	alert *alertmodels.Alert, config *outputmodels.JiraConfig, templates *outputmodels.MessageTemplates) *AlertDeliveryError {
 
 	description := "*Description:* " + aws.StringValue(alert.AnalysisDescription)
 	link := "\n [Click here to view in the Panther UI](" + generateURL(alert) + ")"
@@ -43,8 +43,8 @@ func (client *OutputClient) Jira(
 	tags := "\n *Tags:* " + strings.Join(alert.Tags, ", ")
 
 	fields := map[string]interface{}{
-		"summary":     generateAlertTitle(alert),
-		"description": description + link + runBook + severity + tags,
+		"summary":     generateTitle(alert, templates),
+		"description": generateBody(alert, templates, description+link+runBook+severity+tags),
 		"project": map[string]*string{
 			"key": aws.String(config.ProjectKey),
 		},
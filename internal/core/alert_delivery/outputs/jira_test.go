@@ -83,6 +83,6 @@ func TestJiraAlert(t *testing.T) {
 
 	httpWrapper.On("post", expectedPostInput).Return((*AlertDeliveryError)(nil))
 
-	require.Nil(t, client.Jira(alert, jiraConfig))
+	require.Nil(t, client.Jira(alert, jiraConfig, nil))
 	httpWrapper.AssertExpectations(t)
 }
@@ -29,25 +29,29 @@ import (
 
 // MsTeams alert send an alert.
 func (client *OutputClient) MsTeams(
-	alert *alertmodels.Alert, config *outputmodels.MsTeamsConfig) *AlertDeliveryError {
+	alert *alertmodels.Alert, config *outputmodels.MsTeamsConfig, templates *outputmodels.MessageTemplates) *AlertDeliveryError {
 
 	link := "[Click here to view in the Panther UI](" + policyURLPrefix + alert.AnalysisID + ").\n"
 
+	section := map[string]interface{}{
+		"facts": []interface{}{
+			map[string]string{"name": "Description", "value": aws.StringValue(alert.AnalysisDescription)},
+			map[string]string{"name": "Runbook", "value": aws.StringValue(alert.Runbook)},
+			map[string]string{"name": "Severity", "value": alert.Severity},
+			map[string]string{"name": "Tags", "value": strings.Join(alert.Tags, ", ")},
+		},
+		"text": link,
+	}
+	// A body template replaces the default facts
+	if body := generateBody(alert, templates, ""); body != "" {
+		section = map[string]interface{}{"text": body}
+	}
+
 	msTeamsRequestBody := map[string]interface{}{
 		"@context": "http://schema.org/extensions",
 		"@type":    "MessageCard",
-		"text":     generateAlertTitle(alert),
-		"sections": []interface{}{
-			map[string]interface{}{
-				"facts": []interface{}{
-					map[string]string{"name": "Description", "value": aws.StringValue(alert.AnalysisDescription)},
-					map[string]string{"name": "Runbook", "value": aws.StringValue(alert.Runbook)},
-					map[string]string{"name": "Severity", "value": alert.Severity},
-					map[string]string{"name": "Tags", "value": strings.Join(alert.Tags, ", ")},
-				},
-				"text": link,
-			},
-		},
+		"text":     generateTitle(alert, templates),
+		"sections": []interface{}{section},
 		"potentialAction": []interface{}{
 			map[string]interface{}{
 				"@type": "OpenUri",
@@ -39,7 +39,7 @@ var pantherToOpsGeniePriority = map[string]string{
 
 // Opsgenie alert send an alert.
 func (client *OutputClient) Opsgenie(
-	alert *alertmodels.Alert, config *outputmodels.OpsgenieConfig) *AlertDeliveryError {
+	alert *alertmodels.Alert, config *outputmodels.OpsgenieConfig, templates *outputmodels.MessageTemplates) *AlertDeliveryError {
 
 	description := "<strong>Description:</strong> " + aws.StringValue(alert.AnalysisDescription)
 	link := "\n<a href=\"" + generateURL(alert) + "\">Click here to view in the Panther UI</a>"
@@ -47,8 +47,8 @@ func (client *OutputClient) Opsgenie(
 	severity := "\n <strong>Severity:</strong> " + alert.Severity
 
 	opsgenieRequest := map[string]interface{}{
-		"message":     generateAlertTitle(alert),
-		"description": description + link + runBook + severity,
+		"message":     generateTitle(alert, templates),
+		"description": generateBody(alert, templates, description+link+runBook+severity),
 		"tags":        alert.Tags,
 		"priority":    pantherToOpsGeniePriority[alert.Severity],
 	}
@@ -73,6 +73,6 @@ func TestOpsgenieAlert(t *testing.T) {
 
 	httpWrapper.On("post", expectedPostInput).Return((*AlertDeliveryError)(nil))
 
-	require.Nil(t, client.Opsgenie(alert, opsgenieConfig))
+	require.Nil(t, client.Opsgenie(alert, opsgenieConfig, nil))
 	httpWrapper.AssertExpectations(t)
 }
@@ -62,17 +62,18 @@ type HTTPiface interface {
 }
 
 // API is the interface for output delivery that can be used for mocks in tests.
+// The message templates of the output are optional.
 type API interface {
-	Slack(*alertmodels.Alert, *outputmodels.SlackConfig) *AlertDeliveryError
-	PagerDuty(*alertmodels.Alert, *outputmodels.PagerDutyConfig) *AlertDeliveryError
-	Github(*alertmodels.Alert, *outputmodels.GithubConfig) *AlertDeliveryError
-	Jira(*alertmodels.Alert, *outputmodels.JiraConfig) *AlertDeliveryError
-	Opsgenie(*alertmodels.Alert, *outputmodels.OpsgenieConfig) *AlertDeliveryError
-	MsTeams(*alertmodels.Alert, *outputmodels.MsTeamsConfig) *AlertDeliveryError
-	Sqs(*alertmodels.Alert, *outputmodels.SqsConfig) *AlertDeliveryError
-	Sns(*alertmodels.Alert, *outputmodels.SnsConfig) *AlertDeliveryError
-	Asana(*alertmodels.Alert, *outputmodels.AsanaConfig) *AlertDeliveryError
-	CustomWebhook(*alertmodels.Alert, *outputmodels.CustomWebhookConfig) *AlertDeliveryError
+	Slack(*alertmodels.Alert, *outputmodels.SlackConfig, *outputmodels.MessageTemplates) *AlertDeliveryError
+	PagerDuty(*alertmodels.Alert, *outputmodels.PagerDutyConfig, *outputmodels.MessageTemplates) *AlertDeliveryError
+	Github(*alertmodels.Alert, *outputmodels.GithubConfig, *outputmodels.MessageTemplates) *AlertDeliveryError
+	Jira(*alertmodels.Alert, *outputmodels.JiraConfig, *outputmodels.MessageTemplates) *AlertDeliveryError
+	Opsgenie(*alertmodels.Alert, *outputmodels.OpsgenieConfig, *outputmodels.MessageTemplates) *AlertDeliveryError
+	MsTeams(*alertmodels.Alert, *outputmodels.MsTeamsConfig, *outputmodels.MessageTemplates) *AlertDeliveryError
+	Sqs(*alertmodels.Alert, *outputmodels.SqsConfig, *outputmodels.MessageTemplates) *AlertDeliveryError
+	Sns(*alertmodels.Alert, *outputmodels.SnsConfig, *outputmodels.MessageTemplates) *AlertDeliveryError
+	Asana(*alertmodels.Alert, *outputmodels.AsanaConfig, *outputmodels.MessageTemplates) *AlertDeliveryError
+	CustomWebhook(*alertmodels.Alert, *outputmodels.CustomWebhookConfig, *outputmodels.MessageTemplates) *AlertDeliveryError
 }
 
 // OutputClient encapsulates the clients that allow sending alerts to multiple outputs
@@ -115,7 +116,7 @@ type Notification struct {
 	// [REQUIRED] The severity enum of the alert set in Panther UI. Will be one of INFO LOW MEDIUM HIGH CRITICAL.
 	Severity string `json:"severity"`
 
-	// [REQUIRED] The Type enum if an alert is for a rule or policy. Will be one of RULE POLICY.
+	// [REQUIRED] The Type enum if an alert is for a rule, a policy or a digest. Will be one of RULE POLICY DIGEST.
 	Type string `json:"type"`
 
 	// [REQUIRED] Link to the alert in Panther UI
@@ -143,7 +144,7 @@ type Notification struct {
 	Version *string `json:"version"`
 }
 
-func generateNotificationFromAlert(alert *alertmodels.Alert) Notification {
+func generateNotificationFromAlert(alert *alertmodels.Alert, templates *outputmodels.MessageTemplates) Notification {
 	notification := Notification{
 		ID:          alert.AnalysisID,
 		AlertID:     alert.AlertID,
@@ -151,7 +152,7 @@ func generateNotificationFromAlert(alert *alertmodels.Alert) Notification {
 		Severity:    alert.Severity,
 		Type:        alert.Type,
 		Link:        generateURL(alert),
-		Title:       generateAlertTitle(alert),
+		Title:       generateTitle(alert, templates),
 		Description: alert.AnalysisDescription,
 		Runbook:     alert.Runbook,
 		Tags:        alert.Tags,
@@ -163,6 +164,9 @@ func generateNotificationFromAlert(alert *alertmodels.Alert) Notification {
 }
 
 func generateAlertMessage(alert *alertmodels.Alert) string {
+	if alert.Type == alertmodels.DigestType {
+		return aws.StringValue(alert.Title)
+	}
 	if alert.Type == alertmodels.RuleType {
 		return getDisplayName(alert) + " triggered"
 	}
@@ -181,6 +185,9 @@ func generateDetailedAlertMessage(alert *alertmodels.Alert) string {
 }
 
 func generateAlertTitle(alert *alertmodels.Alert) string {
+	if alert.Type == alertmodels.DigestType {
+		return "Alert Digest: " + aws.StringValue(alert.Title)
+	}
 	if alert.Title != nil {
 		return "New Alert: " + *alert.Title
 	}
@@ -198,8 +205,18 @@ func getDisplayName(alert *alertmodels.Alert) string {
 }
 
 func generateURL(alert *alertmodels.Alert) string {
-	if alert.Type == alertmodels.RuleType {
+	switch alert.Type {
+	case alertmodels.RuleType:
 		return alertURLPrefix + *alert.AlertID
+	case alertmodels.DigestType:
+		// A digest links to the list of alerts
+		return alertURLPrefix
+	default:
+		return policyURLPrefix + alert.AnalysisID
 	}
-	return policyURLPrefix + alert.AnalysisID
+}
+
+// AlertURL is the link to an alert in the Panther UI
+func AlertURL(alert *alertmodels.Alert) string {
+	return generateURL(alert)
 }
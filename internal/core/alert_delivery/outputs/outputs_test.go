@@ -82,3 +82,21 @@ func TestGenerateAlertTitlePolicyId(t *testing.T) {
 	}
 	assert.Equal(t, "Policy Failure: policy.id", generateAlertTitle(alert))
 }
+
+func TestGenerateAlertTitleDigest(t *testing.T) {
+	alert := &alertModel.Alert{
+		Type:         alertModel.DigestType,
+		AnalysisName: aws.String("Alert Digest"),
+		Title:        aws.String("3 alerts from 1 rule"),
+	}
+	assert.Equal(t, "Alert Digest: 3 alerts from 1 rule", generateAlertTitle(alert))
+	assert.Equal(t, "3 alerts from 1 rule", generateAlertMessage(alert))
+}
+
+func TestGenerateURL(t *testing.T) {
+	assert.Equal(t, "https://panther.io/alerts/alert.id",
+		AlertURL(&alertModel.Alert{Type: alertModel.RuleType, AlertID: aws.String("alert.id")}))
+	assert.Equal(t, "https://panther.io/policies/policy.id",
+		AlertURL(&alertModel.Alert{Type: alertModel.PolicyType, AnalysisID: "policy.id"}))
+	assert.Equal(t, "https://panther.io/alerts/", AlertURL(&alertModel.Alert{Type: alertModel.DigestType}))
+}
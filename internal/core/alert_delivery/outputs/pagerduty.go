@@ -31,18 +31,20 @@ var (
 )
 
 // PagerDuty sends an alert to a pager duty integration endpoint.
-func (client *OutputClient) PagerDuty(alert *alertmodels.Alert, config *outputmodels.PagerDutyConfig) *AlertDeliveryError {
+func (client *OutputClient) PagerDuty(
+	alert *alertmodels.Alert, config *outputmodels.PagerDutyConfig, templates *outputmodels.MessageTemplates) *AlertDeliveryError {
+
 	severity, err := pantherSeverityToPagerDuty(alert.Severity)
 	if err != nil {
 		return err
 	}
 
 	payload := map[string]interface{}{
-		"summary":        generateAlertTitle(alert),
+		"summary":        generateTitle(alert, templates),
 		"severity":       severity,
 		"timestamp":      alert.CreatedAt.Format(time.RFC3339),
 		"source":         "pantherlabs",
-		"custom_details": generateNotificationFromAlert(alert),
+		"custom_details": generateNotificationFromAlert(alert, templates),
 	}
 
 	pagerDutyRequest := map[string]interface{}{
@@ -77,7 +77,7 @@ func TestSendPagerDutyAlert(t *testing.T) {
 	}
 
 	httpWrapper.On("post", expectedPostInput).Return((*AlertDeliveryError)(nil))
-	result := outputClient.PagerDuty(pagerDutyAlert, pagerDutyConfig)
+	result := outputClient.PagerDuty(pagerDutyAlert, pagerDutyConfig, nil)
 
 	assert.Nil(t, result)
 	httpWrapper.AssertExpectations(t)
@@ -89,6 +89,6 @@ func TestSendPagerDutyAlertPostError(t *testing.T) {
 
 	httpWrapper.On("post", mock.Anything).Return(&AlertDeliveryError{Message: "Exception"})
 
-	require.Error(t, outputClient.PagerDuty(pagerDutyAlert, pagerDutyConfig))
+	require.Error(t, outputClient.PagerDuty(pagerDutyAlert, pagerDutyConfig, nil))
 	httpWrapper.AssertExpectations(t)
 }
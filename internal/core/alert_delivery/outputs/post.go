@@ -59,7 +59,9 @@ func (client *HTTPWrapper) post(input *PostInput) *AlertDeliveryError {
 	if response.StatusCode < 200 || response.StatusCode > 299 {
 		body, _ := ioutil.ReadAll(response.Body)
 		return &AlertDeliveryError{
-			Message: "request failed: " + response.Status + ": " + string(body)}
+			Message:    "request failed: " + response.Status + ": " + string(body),
+			StatusCode: response.StatusCode,
+		}
 	}
 
 	return nil
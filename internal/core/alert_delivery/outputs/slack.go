@@ -37,7 +37,10 @@ var severityColors = map[string]string{
 }
 
 // Slack sends an alert to a slack channel.
-func (client *OutputClient) Slack(alert *alertmodels.Alert, config *outputmodels.SlackConfig) *AlertDeliveryError {
+func (client *OutputClient) Slack(
+	alert *alertmodels.Alert, config *outputmodels.SlackConfig, templates *outputmodels.MessageTemplates) *AlertDeliveryError {
+
+	title := generateTitle(alert, templates)
 	messageField := fmt.Sprintf("<%s|%s>",
 		generateURL(alert),
 		"Click here to view in the Panther UI")
@@ -58,15 +61,20 @@ func (client *OutputClient) Slack(alert *alertmodels.Alert, config *outputmodels
 		},
 	}
 
+	attachment := map[string]interface{}{
+		"fallback": title,
+		"color":    severityColors[alert.Severity],
+		"title":    title,
+		"fields":   fields,
+	}
+	// A body template replaces the default fields
+	if body := generateBody(alert, templates, ""); body != "" {
+		delete(attachment, "fields")
+		attachment["text"] = body
+	}
+
 	payload := map[string]interface{}{
-		"attachments": []map[string]interface{}{
-			{
-				"fallback": generateAlertTitle(alert),
-				"color":    severityColors[alert.Severity],
-				"title":    generateAlertTitle(alert),
-				"fields":   fields,
-			},
-		},
+		"attachments": []map[string]interface{}{attachment},
 	}
 	postInput := &PostInput{
 		url:  config.WebhookURL,
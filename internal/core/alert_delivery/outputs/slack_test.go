@@ -76,6 +76,42 @@ func TestSlackAlert(t *testing.T) {
 
 	httpWrapper.On("post", expectedPostInput).Return((*AlertDeliveryError)(nil))
 
-	require.Nil(t, client.Slack(alert, slackConfig))
+	require.Nil(t, client.Slack(alert, slackConfig, nil))
+	httpWrapper.AssertExpectations(t)
+}
+
+func TestSlackAlertWithTemplates(t *testing.T) {
+	httpWrapper := &mockHTTPWrapper{}
+	client := &OutputClient{httpWrapper: httpWrapper}
+
+	alert := &alertmodels.Alert{
+		AnalysisID:   "policyId",
+		CreatedAt:    time.Now(),
+		AnalysisName: aws.String("policyName"),
+		Severity:     "INFO",
+	}
+	templates := &outputmodels.MessageTemplates{
+		Title: "{{.Severity}}: {{.Name}}",
+		Body:  "See {{.Link}}",
+	}
+
+	expectedPostPayload := map[string]interface{}{
+		"attachments": []map[string]interface{}{
+			{
+				"color":    "#47b881",
+				"fallback": "INFO: policyName",
+				"title":    "INFO: policyName",
+				"text":     "See https://panther.io/policies/policyId",
+			},
+		},
+	}
+	expectedPostInput := &PostInput{
+		url:  slackConfig.WebhookURL,
+		body: expectedPostPayload,
+	}
+
+	httpWrapper.On("post", expectedPostInput).Return((*AlertDeliveryError)(nil))
+
+	require.Nil(t, client.Slack(alert, slackConfig, templates))
 	httpWrapper.AssertExpectations(t)
 }
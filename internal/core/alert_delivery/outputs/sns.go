@@ -39,8 +39,10 @@ type snsMessage struct {
 
 // Sns sends an alert to an SNS Topic.
 // nolint: dupl
-func (client *OutputClient) Sns(alert *alertmodels.Alert, config *outputmodels.SnsConfig) *AlertDeliveryError {
-	notification := generateNotificationFromAlert(alert)
+func (client *OutputClient) Sns(
+	alert *alertmodels.Alert, config *outputmodels.SnsConfig, templates *outputmodels.MessageTemplates) *AlertDeliveryError {
+
+	notification := generateNotificationFromAlert(alert, templates)
 	serializedDefaultMessage, err := jsoniter.MarshalToString(notification)
 	if err != nil {
 		errorMsg := "Failed to serialize default message"
@@ -50,7 +52,7 @@ func (client *OutputClient) Sns(alert *alertmodels.Alert, config *outputmodels.S
 
 	outputMessage := &snsMessage{
 		DefaultMessage: serializedDefaultMessage,
-		EmailMessage:   generateDetailedAlertMessage(alert),
+		EmailMessage:   generateBody(alert, templates, generateDetailedAlertMessage(alert)),
 	}
 
 	serializedMessage, err := jsoniter.MarshalToString(outputMessage)
@@ -64,7 +66,7 @@ func (client *OutputClient) Sns(alert *alertmodels.Alert, config *outputmodels.S
 		TopicArn: aws.String(config.TopicArn),
 		Message:  aws.String(serializedMessage),
 		// Subject is optional in case the topic is subscribed to Email
-		Subject:          aws.String(generateAlertTitle(alert)),
+		Subject:          aws.String(generateTitle(alert, templates)),
 		MessageStructure: aws.String("json"),
 	}
 
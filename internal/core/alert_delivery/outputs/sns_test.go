@@ -81,7 +81,7 @@ func TestSendSns(t *testing.T) {
 	}
 
 	client.On("Publish", expectedSnsPublishInput).Return(&sns.PublishOutput{}, nil)
-	result := outputClient.Sns(alert, snsOutputConfig)
+	result := outputClient.Sns(alert, snsOutputConfig, nil)
 	assert.Nil(t, result)
 	client.AssertExpectations(t)
 }
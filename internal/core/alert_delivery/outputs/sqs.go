@@ -33,8 +33,10 @@ import (
 
 // Sqs sends an alert to an SQS Queue.
 // nolint: dupl
-func (client *OutputClient) Sqs(alert *alertmodels.Alert, config *outputmodels.SqsConfig) *AlertDeliveryError {
-	notification := generateNotificationFromAlert(alert)
+func (client *OutputClient) Sqs(
+	alert *alertmodels.Alert, config *outputmodels.SqsConfig, templates *outputmodels.MessageTemplates) *AlertDeliveryError {
+
+	notification := generateNotificationFromAlert(alert, templates)
 
 	serializedMessage, err := jsoniter.MarshalToString(notification)
 	if err != nil {
@@ -66,7 +66,7 @@ func TestSendSqs(t *testing.T) {
 	}
 
 	client.On("SendMessage", expectedSqsSendMessageInput).Return(&sqs.SendMessageOutput{}, nil)
-	result := outputClient.Sqs(alert, sqsOutputConfig)
+	result := outputClient.Sqs(alert, sqsOutputConfig, nil)
 	assert.Nil(t, result)
 	client.AssertExpectations(t)
 }
@@ -0,0 +1,237 @@
+package outputs
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	outputmodels "github.com/panther-labs/panther/api/lambda/outputs/models"
+	alertmodels "github.com/panther-labs/panther/internal/core/alert_delivery/models"
+)
+
+// maxRenderedTemplateSize bounds the output of a template, no destination accepts larger messages
+const maxRenderedTemplateSize = 64 * 1024
+
+// TemplateData is the alert data available to message templates, e.g. "{{.Name}} ({{.Severity}})"
+type TemplateData struct {
+	// AlertID is the id of rule alerts, it is empty for policies
+	AlertID    string
+	AnalysisID string
+	// Name is the name of the rule or policy, or its id if it has no name
+	Name string
+	// Title is the default title of the alert, e.g. "New Alert: Root Account Activity"
+	Title       string
+	Description string
+	Runbook     string
+	Severity    string
+	// Type is RULE or POLICY
+	Type      string
+	Version   string
+	Tags      []string
+	LogTypes  []string
+	CreatedAt time.Time
+	// Link is the URL of the alert in the Panther UI
+	Link string
+}
+
+func newTemplateData(alert *alertmodels.Alert) *TemplateData {
+	data := &TemplateData{
+		AlertID:     aws.StringValue(alert.AlertID),
+		AnalysisID:  alert.AnalysisID,
+		Name:        getDisplayName(alert),
+		Title:       generateAlertTitle(alert),
+		Description: aws.StringValue(alert.AnalysisDescription),
+		Runbook:     aws.StringValue(alert.Runbook),
+		Severity:    alert.Severity,
+		Type:        alert.Type,
+		Version:     aws.StringValue(alert.Version),
+		Tags:        alert.Tags,
+		LogTypes:    alert.LogTypes,
+		CreatedAt:   alert.CreatedAt,
+	}
+	if alert.Type != alertmodels.RuleType || alert.AlertID != nil {
+		data.Link = generateURL(alert)
+	}
+	return data
+}
+
+// templateFuncs is the complete set of functions available to message templates (in addition to the
+// text/template builtins). They only transform their arguments: templates cannot read the environment,
+// files or the network.
+var templateFuncs = template.FuncMap{
+	"upper":     strings.ToUpper,
+	"lower":     strings.ToLower,
+	"title":     strings.Title,
+	"trim":      strings.TrimSpace,
+	"contains":  func(substr, s string) bool { return strings.Contains(s, substr) },
+	"hasPrefix": func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+	"replace":   func(old, replacement, s string) string { return strings.ReplaceAll(s, old, replacement) },
+	"join":      func(sep string, values []string) string { return strings.Join(values, sep) },
+	"truncate":  truncate,
+	"default":   defaultValue,
+	"json":      toJSON,
+	"formatTime": func(layout string, t time.Time) string {
+		return t.Format(layout)
+	},
+}
+
+// truncate shortens s to at most n characters
+func truncate(n int, s string) string {
+	runes := []rune(s)
+	if n < 0 || len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+// defaultValue returns value, or fallback if value is the empty string
+func defaultValue(fallback, value string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// templateJSON does not escape HTML characters, messages are not embedded in HTML
+var templateJSON = jsoniter.Config{EscapeHTML: false}.Froze()
+
+// toJSON encodes a value as JSON, e.g. to quote strings in webhook payloads
+func toJSON(value interface{}) (string, error) {
+	return templateJSON.MarshalToString(value)
+}
+
+// limitedBuffer fails writes past maxRenderedTemplateSize
+type limitedBuffer struct {
+	bytes.Buffer
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > maxRenderedTemplateSize {
+		return 0, errors.Errorf("rendered template exceeds %d bytes", maxRenderedTemplateSize)
+	}
+	return b.Buffer.Write(p)
+}
+
+func renderTemplate(name, text string, data *TemplateData) (string, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse %s template", name)
+	}
+	var buffer limitedBuffer
+	if err = tmpl.Execute(&buffer, data); err != nil {
+		return "", errors.Wrapf(err, "failed to render %s template", name)
+	}
+	return buffer.String(), nil
+}
+
+// renderPayload renders a webhook payload template, which must produce a JSON document
+func renderPayload(text string, data *TemplateData) (string, error) {
+	payload, err := renderTemplate("payload", text, data)
+	if err != nil {
+		return "", err
+	}
+	if !jsoniter.Valid([]byte(payload)) {
+		return "", errors.New("payload template does not render valid JSON")
+	}
+	return payload, nil
+}
+
+// RenderTemplates renders the message templates of an output for an alert.
+//
+// The templates that are not set are empty in the result.
+func RenderTemplates(alert *alertmodels.Alert, templates *outputmodels.MessageTemplates) (*outputmodels.MessageTemplates, error) {
+	data := newTemplateData(alert)
+	result := &outputmodels.MessageTemplates{}
+	var err error
+	if templates.Title != "" {
+		if result.Title, err = renderTemplate("title", templates.Title, data); err != nil {
+			return nil, err
+		}
+	}
+	if templates.Body != "" {
+		if result.Body, err = renderTemplate("body", templates.Body, data); err != nil {
+			return nil, err
+		}
+	}
+	if templates.Payload != "" {
+		if result.Payload, err = renderPayload(templates.Payload, data); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// ValidateTemplates checks that message templates render against a sample alert
+func ValidateTemplates(templates *outputmodels.MessageTemplates) error {
+	_, err := RenderTemplates(SampleAlert(), templates)
+	return err
+}
+
+// SampleAlert is the rule alert templates are validated and previewed with
+func SampleAlert() *alertmodels.Alert {
+	return &alertmodels.Alert{
+		AnalysisID:          "AWS.CloudTrail.RootActivity",
+		Type:                alertmodels.RuleType,
+		CreatedAt:           time.Date(2020, 6, 1, 9, 30, 0, 0, time.UTC),
+		Severity:            "HIGH",
+		AnalysisDescription: aws.String("Root account activity was detected"),
+		AnalysisName:        aws.String("Root Account Activity"),
+		Version:             aws.String("3Jk3ZH9B0eSOVpgNfUXLzYqgRuZPqoOb"),
+		Runbook:             aws.String("Verify the activity with the owner of the account"),
+		Tags:                []string{"AWS", "Identity & Access Management"},
+		LogTypes:            []string{"AWS.CloudTrail"},
+		AlertID:             aws.String("a3e07bd5ff2ef7f3e5a6cde8d9f2ba2c"),
+		Title:               aws.String("Root account activity in 123456789012"),
+	}
+}
+
+// generateTitle renders the title template of an output, or the default title if it has none
+func generateTitle(alert *alertmodels.Alert, templates *outputmodels.MessageTemplates) string {
+	if templates == nil || templates.Title == "" {
+		return generateAlertTitle(alert)
+	}
+	title, err := renderTemplate("title", templates.Title, newTemplateData(alert))
+	if err != nil {
+		// The alert is still delivered, with its default content
+		zap.L().Warn("using the default title", zap.String("policyId", alert.AnalysisID), zap.Error(err))
+		return generateAlertTitle(alert)
+	}
+	return title
+}
+
+// generateBody renders the body template of an output, or returns the default body if it has none
+func generateBody(alert *alertmodels.Alert, templates *outputmodels.MessageTemplates, defaultBody string) string {
+	if templates == nil || templates.Body == "" {
+		return defaultBody
+	}
+	body, err := renderTemplate("body", templates.Body, newTemplateData(alert))
+	if err != nil {
+		zap.L().Warn("using the default body", zap.String("policyId", alert.AnalysisID), zap.Error(err))
+		return defaultBody
+	}
+	return body
+}
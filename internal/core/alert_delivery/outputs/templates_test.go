@@ -0,0 +1,88 @@
+package outputs
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	outputmodels "github.com/panther-labs/panther/api/lambda/outputs/models"
+	alertmodels "github.com/panther-labs/panther/internal/core/alert_delivery/models"
+)
+
+func TestRenderTemplates(t *testing.T) {
+	templates := &outputmodels.MessageTemplates{
+		Title: "[{{upper .Severity}}] {{.Name}}",
+		Body: "{{.Description}} ({{join \", \" .Tags}})\n" +
+			"{{formatTime \"2006-01-02\" .CreatedAt}} {{truncate 6 .AlertID}} {{default \"none\" .Version}}\n{{.Link}}",
+		Payload: `{"summary": {{json .Title}}, "logTypes": {{json .LogTypes}}}`,
+	}
+	result, err := RenderTemplates(SampleAlert(), templates)
+	require.NoError(t, err)
+	assert.Equal(t, "[HIGH] Root Account Activity", result.Title)
+	assert.Equal(t, "Root account activity was detected (AWS, Identity & Access Management)\n"+
+		"2020-06-01 a3e07b 3Jk3ZH9B0eSOVpgNfUXLzYqgRuZPqoOb\nhttps://panther.io/alerts/a3e07bd5ff2ef7f3e5a6cde8d9f2ba2c",
+		result.Body)
+	assert.Equal(t, `{"summary": "New Alert: Root account activity in 123456789012", "logTypes": ["AWS.CloudTrail"]}`,
+		result.Payload)
+}
+
+func TestRenderTemplatesUnset(t *testing.T) {
+	result, err := RenderTemplates(SampleAlert(), &outputmodels.MessageTemplates{Title: "{{.Type}}"})
+	require.NoError(t, err)
+	assert.Equal(t, &outputmodels.MessageTemplates{Title: "RULE"}, result)
+}
+
+func TestRenderTemplatesErrors(t *testing.T) {
+	for name, templates := range map[string]*outputmodels.MessageTemplates{
+		"syntax":        {Title: "{{.Name"},
+		"unknown field": {Body: "{{.Secret}}"},
+		"unknown func":  {Body: `{{env "HOME"}}`},
+		"invalid json":  {Payload: `{"name": {{.Name}}}`},
+		"too large":     {Body: "{{range .Tags}}" + strings.Repeat("x", maxRenderedTemplateSize/2+1) + "{{end}}"},
+	} {
+		_, err := RenderTemplates(SampleAlert(), templates)
+		assert.Error(t, err, name)
+		assert.Error(t, ValidateTemplates(templates), name)
+	}
+}
+
+func TestGenerateTitleAndBody(t *testing.T) {
+	alert := &alertmodels.Alert{
+		AnalysisID:   "policy.id",
+		Type:         alertmodels.PolicyType,
+		AnalysisName: aws.String("policy name"),
+		Severity:     "LOW",
+	}
+	assert.Equal(t, "Policy Failure: policy name", generateTitle(alert, nil))
+	assert.Equal(t, "default", generateBody(alert, nil, "default"))
+
+	templates := &outputmodels.MessageTemplates{Title: "{{.AnalysisID}}", Body: "{{.Severity}}"}
+	assert.Equal(t, "policy.id", generateTitle(alert, templates))
+	assert.Equal(t, "LOW", generateBody(alert, templates, "default"))
+
+	// Templates failing to render fall back to the default content
+	templates = &outputmodels.MessageTemplates{Title: "{{truncate .Name}}", Body: "{{truncate .Name}}"}
+	assert.Equal(t, "Policy Failure: policy name", generateTitle(alert, templates))
+	assert.Equal(t, "default", generateBody(alert, templates, "default"))
+}
@@ -0,0 +1,227 @@
+package routing
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	outputmodels "github.com/panther-labs/panther/api/lambda/outputs/models"
+	alertmodels "github.com/panther-labs/panther/internal/core/alert_delivery/models"
+)
+
+// timeOfDayFormat is the format of the start and end times of routing rules
+const timeOfDayFormat = "15:04"
+
+var weekdays = map[string]time.Weekday{
+	"SUN": time.Sunday,
+	"MON": time.Monday,
+	"TUE": time.Tuesday,
+	"WED": time.Wednesday,
+	"THU": time.Thursday,
+	"FRI": time.Friday,
+	"SAT": time.Saturday,
+}
+
+// Route is an output an alert is sent to and the reason it was chosen
+type Route struct {
+	Output *outputmodels.AlertOutput
+	// Reason is one of the outputmodels.RouteReason* constants
+	Reason string
+	// RoutingRule is the index of the matching routing rule of the output, -1 for other reasons
+	RoutingRule int
+}
+
+// Routes returns the outputs an alert is sent to.
+//
+// If the rule or policy of the alert sets outputs, only those are used.
+// Otherwise the alert is sent to the outputs that are the default for its severity, and to the outputs
+// with a routing rule matching it.
+func Routes(alert *alertmodels.Alert, outputs []*outputmodels.AlertOutput) []*Route {
+	result := []*Route{}
+	if len(alert.OutputIds) > 0 {
+		for _, output := range outputs {
+			for _, alertOutputID := range alert.OutputIds {
+				if *output.OutputID == alertOutputID {
+					result = append(result, &Route{Output: output, Reason: outputmodels.RouteReasonOutputIds, RoutingRule: -1})
+				}
+			}
+		}
+		return result
+	}
+
+	for _, output := range outputs {
+		if isDefaultForSeverity(output, alert.Severity) {
+			result = append(result, &Route{Output: output, Reason: outputmodels.RouteReasonSeverity, RoutingRule: -1})
+			continue
+		}
+		for i, rule := range output.RoutingRules {
+			if Match(rule, alert) {
+				result = append(result, &Route{Output: output, Reason: outputmodels.RouteReasonRoutingRule, RoutingRule: i})
+				break
+			}
+		}
+	}
+	return result
+}
+
+func isDefaultForSeverity(output *outputmodels.AlertOutput, severity string) bool {
+	for _, outputSeverity := range output.DefaultForSeverity {
+		if outputSeverity != nil && *outputSeverity == severity {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns true if all the conditions of the rule match the alert, schedules are matched at its creation time.
+// Rules are expected to be valid, invalid times and time zones never match.
+func Match(rule *outputmodels.RoutingRule, alert *alertmodels.Alert) bool {
+	if rule == nil {
+		return false
+	}
+	if len(rule.AnalysisIDs) > 0 && !matchAnyPattern(rule.AnalysisIDs, alert.AnalysisID) {
+		return false
+	}
+	if len(rule.Types) > 0 && !containsAny(rule.Types, alert.Type) {
+		return false
+	}
+	if len(rule.Severities) > 0 && !containsAny(rule.Severities, alert.Severity) {
+		return false
+	}
+	if len(rule.Tags) > 0 && !containsAny(rule.Tags, alert.Tags...) {
+		return false
+	}
+	if len(rule.LogTypes) > 0 && !containsAny(rule.LogTypes, alert.LogTypes...) {
+		return false
+	}
+	return matchSchedule(rule, alert.CreatedAt)
+}
+
+// Validate checks the values of a routing rule that are not covered by its struct tags
+func Validate(rule *outputmodels.RoutingRule) error {
+	if _, err := time.LoadLocation(rule.TimeZone); err != nil {
+		return errors.Errorf("invalid time zone %q", rule.TimeZone)
+	}
+	for _, t := range []string{rule.StartTime, rule.EndTime} {
+		if t == "" {
+			continue
+		}
+		if _, err := time.Parse(timeOfDayFormat, t); err != nil {
+			return errors.Errorf("invalid time %q, expected HH:MM", t)
+		}
+	}
+	if (rule.StartTime == "") != (rule.EndTime == "") {
+		return errors.New("startTime and endTime must be set together")
+	}
+	for _, day := range rule.DaysOfWeek {
+		if _, ok := weekdays[day]; !ok {
+			return errors.Errorf("invalid day of week %q", day)
+		}
+	}
+	return nil
+}
+
+func matchSchedule(rule *outputmodels.RoutingRule, at time.Time) bool {
+	if len(rule.DaysOfWeek) == 0 && rule.StartTime == "" {
+		return true
+	}
+	// LoadLocation returns UTC for an empty name
+	location, err := time.LoadLocation(rule.TimeZone)
+	if err != nil {
+		return false
+	}
+	at = at.In(location)
+
+	if len(rule.DaysOfWeek) > 0 {
+		matched := false
+		for _, day := range rule.DaysOfWeek {
+			if weekday, ok := weekdays[day]; ok && weekday == at.Weekday() {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if rule.StartTime == "" {
+		return true
+	}
+	start, err := time.Parse(timeOfDayFormat, rule.StartTime)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse(timeOfDayFormat, rule.EndTime)
+	if err != nil {
+		return false
+	}
+	minute := at.Hour()*60 + at.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+	if startMinute <= endMinute {
+		return startMinute <= minute && minute < endMinute
+	}
+	// the window spans midnight
+	return minute >= startMinute || minute < endMinute
+}
+
+func containsAny(values []string, candidates ...string) bool {
+	for _, value := range values {
+		for _, candidate := range candidates {
+			if value == candidate {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchAnyPattern(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if matchPattern(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPattern matches a value against a pattern where '*' matches any sequence of characters
+func matchPattern(pattern, value string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == value
+	}
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(value, part)
+		if i < 0 {
+			return false
+		}
+		value = value[i+len(part):]
+	}
+	return strings.HasSuffix(value, last)
+}
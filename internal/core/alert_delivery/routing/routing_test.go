@@ -0,0 +1,139 @@
+package routing
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	outputmodels "github.com/panther-labs/panther/api/lambda/outputs/models"
+	alertmodels "github.com/panther-labs/panther/internal/core/alert_delivery/models"
+)
+
+// Wednesday 2020-06-03 14:30 UTC, 10:30 in New York
+var refTime = time.Date(2020, 6, 3, 14, 30, 0, 0, time.UTC)
+
+func testAlert() *alertmodels.Alert {
+	return &alertmodels.Alert{
+		AnalysisID: "AWS.CloudTrail.RootActivity",
+		Type:       alertmodels.RuleType,
+		Severity:   "HIGH",
+		Tags:       []string{"Identity", "AWS"},
+		LogTypes:   []string{"AWS.CloudTrail"},
+		CreatedAt:  refTime,
+	}
+}
+
+func TestMatch(t *testing.T) {
+	alert := testAlert()
+	for _, tc := range []struct {
+		rule  outputmodels.RoutingRule
+		match bool
+	}{
+		{outputmodels.RoutingRule{}, true},
+		{outputmodels.RoutingRule{AnalysisIDs: []string{"AWS.CloudTrail.*"}}, true},
+		{outputmodels.RoutingRule{AnalysisIDs: []string{"*.Root*"}}, true},
+		{outputmodels.RoutingRule{AnalysisIDs: []string{"Okta.*", "AWS.CloudTrail.RootActivity"}}, true},
+		{outputmodels.RoutingRule{AnalysisIDs: []string{"AWS.CloudTrail"}}, false},
+		{outputmodels.RoutingRule{AnalysisIDs: []string{"AWS.*.Console*"}}, false},
+		{outputmodels.RoutingRule{Types: []string{"RULE"}}, true},
+		{outputmodels.RoutingRule{Types: []string{"POLICY"}}, false},
+		{outputmodels.RoutingRule{Severities: []string{"HIGH", "CRITICAL"}}, true},
+		{outputmodels.RoutingRule{Severities: []string{"LOW"}}, false},
+		{outputmodels.RoutingRule{Tags: []string{"Network", "Identity"}}, true},
+		{outputmodels.RoutingRule{Tags: []string{"Network"}}, false},
+		{outputmodels.RoutingRule{LogTypes: []string{"AWS.CloudTrail"}}, true},
+		{outputmodels.RoutingRule{LogTypes: []string{"AWS.VPCFlow"}}, false},
+		// all conditions must match
+		{outputmodels.RoutingRule{Tags: []string{"Identity"}, Types: []string{"POLICY"}}, false},
+		{outputmodels.RoutingRule{DaysOfWeek: []string{"MON", "WED"}}, true},
+		{outputmodels.RoutingRule{DaysOfWeek: []string{"SAT", "SUN"}}, false},
+		{outputmodels.RoutingRule{StartTime: "09:00", EndTime: "17:00"}, true},
+		{outputmodels.RoutingRule{StartTime: "09:00", EndTime: "14:30"}, false},
+		{outputmodels.RoutingRule{StartTime: "14:30", EndTime: "15:00"}, true},
+		{outputmodels.RoutingRule{StartTime: "09:00", EndTime: "17:00", TimeZone: "America/New_York"}, true},
+		{outputmodels.RoutingRule{StartTime: "12:00", EndTime: "17:00", TimeZone: "America/New_York"}, false},
+		// spans midnight
+		{outputmodels.RoutingRule{StartTime: "22:00", EndTime: "15:00"}, true},
+		{outputmodels.RoutingRule{StartTime: "18:00", EndTime: "08:00"}, false},
+		// the day is that of the time zone: 02:30 on Thursday in Auckland
+		{outputmodels.RoutingRule{DaysOfWeek: []string{"THU"}, TimeZone: "Pacific/Auckland"}, true},
+		{outputmodels.RoutingRule{TimeZone: "Nowhere/Special", StartTime: "00:00", EndTime: "23:59"}, false},
+	} {
+		rule := tc.rule
+		assert.Equal(t, tc.match, Match(&rule, alert), "%+v", rule)
+	}
+	assert.False(t, Match(nil, alert))
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Validate(&outputmodels.RoutingRule{}))
+	require.NoError(t, Validate(&outputmodels.RoutingRule{
+		DaysOfWeek: []string{"MON"},
+		StartTime:  "22:00",
+		EndTime:    "06:00",
+		TimeZone:   "Europe/London",
+	}))
+	for _, invalid := range []outputmodels.RoutingRule{
+		{TimeZone: "Nowhere/Special"},
+		{StartTime: "9am", EndTime: "17:00"},
+		{StartTime: "09:00", EndTime: "25:00"},
+		{StartTime: "09:00"},
+		{DaysOfWeek: []string{"MONDAY"}},
+	} {
+		rule := invalid
+		require.Error(t, Validate(&rule), "%+v", rule)
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	outputs := []*outputmodels.AlertOutput{
+		{
+			OutputID:           aws.String("default-high"),
+			DefaultForSeverity: aws.StringSlice([]string{"HIGH"}),
+		},
+		{
+			OutputID:     aws.String("identity-team"),
+			RoutingRules: []*outputmodels.RoutingRule{{Tags: []string{"Network"}}, {Tags: []string{"Identity"}}},
+		},
+		{
+			OutputID:           aws.String("network-team"),
+			DefaultForSeverity: aws.StringSlice([]string{"CRITICAL"}),
+			RoutingRules:       []*outputmodels.RoutingRule{{Tags: []string{"Network"}}},
+		},
+	}
+
+	alert := testAlert()
+	routes := Routes(alert, outputs)
+	require.Len(t, routes, 2)
+	assert.Equal(t, &Route{Output: outputs[0], Reason: outputmodels.RouteReasonSeverity, RoutingRule: -1}, routes[0])
+	assert.Equal(t, &Route{Output: outputs[1], Reason: outputmodels.RouteReasonRoutingRule, RoutingRule: 1}, routes[1])
+
+	// outputs set by the rule override severity defaults and routing rules
+	alert.OutputIds = []string{"network-team", "missing"}
+	routes = Routes(alert, outputs)
+	require.Len(t, routes, 1)
+	assert.Equal(t, &Route{Output: outputs[2], Reason: outputmodels.RouteReasonOutputIds, RoutingRule: -1}, routes[0])
+
+	assert.Empty(t, Routes(alert, nil))
+}
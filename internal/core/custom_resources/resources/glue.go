@@ -32,12 +32,15 @@ import (
 	"github.com/panther-labs/panther/internal/log_analysis/awsglue"
 	"github.com/panther-labs/panther/internal/log_analysis/datacatalog_updater/process"
 	"github.com/panther-labs/panther/internal/log_analysis/gluetables"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/deadletter"
 )
 
 type UpdateGlueTablesProperties struct {
 	// TablesSignature should change every time the tables change (for CF master.yml this can be the Panther version)
 	TablesSignature     string `validate:"required"`
 	ProcessedDataBucket string `validate:"required"`
+	// ParquetLogTypes are the log types whose tables are stored as Parquet
+	ParquetLogTypes []string
 }
 
 func customUpdateGlueTables(_ context.Context, event cfn.Event) (string, map[string]interface{}, error) {
@@ -48,6 +51,7 @@ func customUpdateGlueTables(_ context.Context, event cfn.Event) (string, map[str
 		if err := parseProperties(event.ResourceProperties, &props); err != nil {
 			return resourceID, nil, err
 		}
+		awsglue.SetParquetLogTypes(props.ParquetLogTypes...)
 
 		// ensure databases are all there
 		for pantherDatabase, pantherDatabaseDescription := range awsglue.PantherDatabases {
@@ -62,6 +66,13 @@ func customUpdateGlueTables(_ context.Context, event cfn.Event) (string, map[str
 			}
 		}
 
+		// the table of log lines that failed to parse is always deployed
+		zap.L().Info("updating table", zap.String("database", deadletter.GlueTableMetadata.DatabaseName()),
+			zap.String("table", deadletter.GlueTableMetadata.TableName()))
+		if err := deadletter.GlueTableMetadata.CreateOrUpdateTable(glueClient, props.ProcessedDataBucket); err != nil {
+			return "", nil, err
+		}
+
 		// update schemas for tables that are deployed
 		deployedLogTables, err := gluetables.DeployedLogTables(glueClient)
 		if err != nil {
@@ -51,6 +51,14 @@ func (API) AddOutput(input *models.AddOutputInput) (*models.AddOutputOutput, err
 		return nil, &genericapi.InvalidInputError{Message: err.Error()}
 	}
 
+	if err = validateRoutingRules(input.RoutingRules); err != nil {
+		return nil, err
+	}
+
+	if err = validateTemplates(input.Templates, outputType); err != nil {
+		return nil, err
+	}
+
 	alertOutput := &models.AlertOutput{
 		OutputID:           aws.String(uuid.New().String()),
 		DisplayName:        input.DisplayName,
@@ -61,6 +69,12 @@ func (API) AddOutput(input *models.AddOutputInput) (*models.AddOutputOutput, err
 		OutputType:         outputType,
 		OutputConfig:       input.OutputConfig,
 		DefaultForSeverity: input.DefaultForSeverity,
+		RoutingRules:       input.RoutingRules,
+		Templates:          input.Templates,
+	}
+	// A throttle without a window does not limit anything
+	if input.Throttle != nil && input.Throttle.WindowMinutes > 0 {
+		alertOutput.Throttle = input.Throttle
 	}
 
 	alertOutputItem, err := AlertOutputToItem(alertOutput)
@@ -117,6 +117,120 @@ func TestAddOutputSlack(t *testing.T) {
 	mockEncryptionKey.AssertExpectations(t)
 }
 
+func TestAddOutputInvalidRoutingRule(t *testing.T) {
+	mockEncryptionKey := &mockEncryptionKey{}
+	encryptionKey = mockEncryptionKey
+	mockOutputTable := &mockOutputTable{}
+	outputsTable = mockOutputTable
+
+	mockOutputTable.On("GetOutputByName", aws.String("my-channel")).Return(nil, nil)
+
+	input := &models.AddOutputInput{
+		UserID:       aws.String("userId"),
+		DisplayName:  aws.String("my-channel"),
+		OutputConfig: &models.OutputConfig{Slack: &models.SlackConfig{WebhookURL: "hooks.slack.com"}},
+		RoutingRules: []*models.RoutingRule{{Tags: []string{"Identity"}}, {StartTime: "09:00"}},
+	}
+
+	result, err := (API{}).AddOutput(input)
+	assert.Nil(t, result)
+	require.Error(t, err)
+	assert.Equal(t, "invalid routing rule 1: startTime and endTime must be set together", err.Error())
+	mockOutputTable.AssertExpectations(t)
+	mockEncryptionKey.AssertExpectations(t)
+}
+
+func TestAddOutputInvalidTemplates(t *testing.T) {
+	mockEncryptionKey := &mockEncryptionKey{}
+	encryptionKey = mockEncryptionKey
+	mockOutputTable := &mockOutputTable{}
+	outputsTable = mockOutputTable
+
+	mockOutputTable.On("GetOutputByName", aws.String("my-channel")).Return(nil, nil)
+
+	input := &models.AddOutputInput{
+		UserID:       aws.String("userId"),
+		DisplayName:  aws.String("my-channel"),
+		OutputConfig: &models.OutputConfig{Slack: &models.SlackConfig{WebhookURL: "hooks.slack.com"}},
+		Templates:    &models.MessageTemplates{Title: "{{.Severity}}", Payload: "{}"},
+	}
+	_, err := (API{}).AddOutput(input)
+	require.Error(t, err)
+	assert.Equal(t, "payload templates are only supported by custom webhooks", err.Error())
+
+	input.Templates = &models.MessageTemplates{Title: "{{.Severity}", Body: "{{.Link}}"}
+	_, err = (API{}).AddOutput(input)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid templates: failed to parse title template")
+
+	mockOutputTable.AssertExpectations(t)
+	mockEncryptionKey.AssertExpectations(t)
+}
+
+func TestAddOutputCustomWebhookWithTemplates(t *testing.T) {
+	mockEncryptionKey := &mockEncryptionKey{}
+	encryptionKey = mockEncryptionKey
+	mockOutputTable := &mockOutputTable{}
+	outputsTable = mockOutputTable
+
+	templates := &models.MessageTemplates{Title: "{{.Severity}}", Payload: `{"text": {{json .Title}}}`}
+	mockOutputTable.On("GetOutputByName", aws.String("my-webhook")).Return(nil, nil)
+	mockEncryptionKey.On("EncryptConfig", mock.Anything).Return(make([]byte, 1), nil)
+	mockOutputTable.On("PutOutput", mock.MatchedBy(func(item *table.AlertOutputItem) bool {
+		return assert.ObjectsAreEqual(templates, item.Templates)
+	})).Return(nil)
+
+	input := &models.AddOutputInput{
+		UserID:       aws.String("userId"),
+		DisplayName:  aws.String("my-webhook"),
+		OutputConfig: &models.OutputConfig{CustomWebhook: &models.CustomWebhookConfig{WebhookURL: "https://example.com"}},
+		Templates:    templates,
+	}
+	result, err := (API{}).AddOutput(input)
+	require.NoError(t, err)
+	assert.Equal(t, templates, result.Templates)
+
+	mockOutputTable.AssertExpectations(t)
+	mockEncryptionKey.AssertExpectations(t)
+}
+
+func TestAddOutputWithThrottle(t *testing.T) {
+	mockEncryptionKey := &mockEncryptionKey{}
+	encryptionKey = mockEncryptionKey
+	mockOutputTable := &mockOutputTable{}
+	outputsTable = mockOutputTable
+
+	throttle := &models.AlertThrottle{WindowMinutes: 15, MaxAlerts: 5}
+	mockOutputTable.On("GetOutputByName", mock.Anything).Return(nil, nil)
+	mockEncryptionKey.On("EncryptConfig", mock.Anything).Return(make([]byte, 1), nil)
+	mockOutputTable.On("PutOutput", mock.MatchedBy(func(item *table.AlertOutputItem) bool {
+		return assert.ObjectsAreEqual(throttle, item.Throttle)
+	})).Return(nil).Once()
+	mockOutputTable.On("PutOutput", mock.MatchedBy(func(item *table.AlertOutputItem) bool {
+		return item.Throttle == nil
+	})).Return(nil).Once()
+
+	input := &models.AddOutputInput{
+		UserID:       aws.String("userId"),
+		DisplayName:  aws.String("alerts"),
+		OutputConfig: &models.OutputConfig{Slack: &models.SlackConfig{WebhookURL: "https://hooks.slack.com"}},
+		Throttle:     throttle,
+	}
+	result, err := (API{}).AddOutput(input)
+	require.NoError(t, err)
+	assert.Equal(t, throttle, result.Throttle)
+
+	// A throttle without a window is not stored
+	input.Throttle = &models.AlertThrottle{MaxAlerts: 5}
+	input.OutputConfig = &models.OutputConfig{Slack: &models.SlackConfig{WebhookURL: "https://hooks.slack.com"}}
+	result, err = (API{}).AddOutput(input)
+	require.NoError(t, err)
+	assert.Nil(t, result.Throttle)
+
+	mockOutputTable.AssertExpectations(t)
+	mockEncryptionKey.AssertExpectations(t)
+}
+
 func TestAddOutputSns(t *testing.T) {
 	mockEncryptionKey := &mockEncryptionKey{}
 	encryptionKey = mockEncryptionKey
@@ -24,6 +24,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws/session"
 
+	"github.com/panther-labs/panther/internal/core/alert_delivery/outputs"
 	"github.com/panther-labs/panther/internal/core/outputs_api/table"
 	"github.com/panther-labs/panther/pkg/encryption"
 )
@@ -40,4 +41,6 @@ var (
 		os.Getenv("OUTPUTS_TABLE_NAME"),
 		os.Getenv("OUTPUTS_DISPLAY_NAME_INDEX_NAME"),
 		awsSession)
+
+	outputClient outputs.API = outputs.New(awsSession)
 )
@@ -0,0 +1,77 @@
+package api
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+
+	"github.com/panther-labs/panther/api/lambda/outputs/models"
+	alertmodels "github.com/panther-labs/panther/internal/core/alert_delivery/models"
+	"github.com/panther-labs/panther/internal/core/alert_delivery/routing"
+)
+
+// GetAlertRoutes returns the outputs alert delivery would send a sample alert to, without sending it.
+func (API) GetAlertRoutes(input *models.GetAlertRoutesInput) (models.GetAlertRoutesOutput, error) {
+	outputItems, err := outputsTable.GetOutputs()
+	if err != nil {
+		return nil, err
+	}
+
+	// routing only needs the attributes of the outputs, their configs are not decrypted
+	outputs := make([]*models.AlertOutput, len(outputItems))
+	for i, item := range outputItems {
+		outputs[i] = &models.AlertOutput{
+			DisplayName:        item.DisplayName,
+			OutputID:           item.OutputID,
+			OutputType:         item.OutputType,
+			DefaultForSeverity: item.DefaultForSeverity,
+			RoutingRules:       item.RoutingRules,
+		}
+	}
+
+	alert := &alertmodels.Alert{
+		AnalysisID: input.AnalysisID,
+		Type:       input.Type,
+		Severity:   input.Severity,
+		Tags:       input.Tags,
+		LogTypes:   input.LogTypes,
+		OutputIds:  input.OutputIds,
+		CreatedAt:  time.Now().UTC(),
+	}
+	if input.CreatedAt != nil {
+		alert.CreatedAt = *input.CreatedAt
+	}
+
+	result := models.GetAlertRoutesOutput{}
+	for _, route := range routing.Routes(alert, outputs) {
+		alertRoute := &models.AlertRoute{
+			OutputID:    route.Output.OutputID,
+			DisplayName: route.Output.DisplayName,
+			OutputType:  route.Output.OutputType,
+			Reason:      route.Reason,
+		}
+		if route.RoutingRule >= 0 {
+			alertRoute.RoutingRule = aws.Int(route.RoutingRule)
+		}
+		result = append(result, alertRoute)
+	}
+	return result, nil
+}
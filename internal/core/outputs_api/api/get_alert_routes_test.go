@@ -0,0 +1,115 @@
+package api
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/api/lambda/outputs/models"
+	"github.com/panther-labs/panther/internal/core/outputs_api/table"
+)
+
+func TestGetAlertRoutes(t *testing.T) {
+	mockOutputsTable := &mockOutputTable{}
+	outputsTable = mockOutputsTable
+	mockEncryptionKey := &mockEncryptionKey{}
+	encryptionKey = mockEncryptionKey
+
+	mockOutputsTable.On("GetOutputs").Return([]*table.AlertOutputItem{
+		{
+			OutputID:           aws.String("default-high"),
+			DisplayName:        aws.String("all-high"),
+			OutputType:         aws.String("sns"),
+			EncryptedConfig:    make([]byte, 1),
+			DefaultForSeverity: aws.StringSlice([]string{"HIGH"}),
+		},
+		{
+			OutputID:        aws.String("identity-team"),
+			DisplayName:     aws.String("identity-team"),
+			OutputType:      aws.String("slack"),
+			EncryptedConfig: make([]byte, 1),
+			RoutingRules: []*models.RoutingRule{
+				{LogTypes: []string{"Okta.SystemLog"}},
+				// office hours in New York
+				{Tags: []string{"Identity"}, StartTime: "09:00", EndTime: "18:00", TimeZone: "America/New_York"},
+			},
+		},
+	}, nil)
+
+	input := &models.GetAlertRoutesInput{
+		AnalysisID: "AWS.IAM.RootLogin",
+		Type:       "RULE",
+		Severity:   "HIGH",
+		Tags:       []string{"Identity"},
+		LogTypes:   []string{"AWS.CloudTrail"},
+		CreatedAt:  aws.Time(time.Date(2020, 6, 3, 14, 30, 0, 0, time.UTC)),
+	}
+	result, err := (API{}).GetAlertRoutes(input)
+	require.NoError(t, err)
+	assert.Equal(t, models.GetAlertRoutesOutput{
+		{
+			OutputID:    aws.String("default-high"),
+			DisplayName: aws.String("all-high"),
+			OutputType:  aws.String("sns"),
+			Reason:      models.RouteReasonSeverity,
+		},
+		{
+			OutputID:    aws.String("identity-team"),
+			DisplayName: aws.String("identity-team"),
+			OutputType:  aws.String("slack"),
+			Reason:      models.RouteReasonRoutingRule,
+			RoutingRule: aws.Int(1),
+		},
+	}, result)
+
+	// outside office hours and with outputs set by the rule
+	input.CreatedAt = aws.Time(time.Date(2020, 6, 3, 23, 0, 0, 0, time.UTC))
+	input.Severity = "LOW"
+	result, err = (API{}).GetAlertRoutes(input)
+	require.NoError(t, err)
+	assert.Empty(t, result)
+
+	input.OutputIds = []string{"identity-team"}
+	result, err = (API{}).GetAlertRoutes(input)
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	assert.Equal(t, models.RouteReasonOutputIds, result[0].Reason)
+
+	mockOutputsTable.AssertExpectations(t)
+	// the configs of the outputs are not decrypted
+	mockEncryptionKey.AssertExpectations(t)
+}
+
+func TestGetAlertRoutesDdbError(t *testing.T) {
+	mockOutputsTable := &mockOutputTable{}
+	outputsTable = mockOutputsTable
+
+	mockOutputsTable.On("GetOutputs").Return([]*table.AlertOutputItem{}, errors.New("fake error"))
+
+	result, err := (API{}).GetAlertRoutes(&models.GetAlertRoutesInput{})
+	require.Error(t, err)
+	assert.Nil(t, result)
+	mockOutputsTable.AssertExpectations(t)
+}
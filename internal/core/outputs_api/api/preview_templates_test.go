@@ -0,0 +1,51 @@
+package api
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/api/lambda/outputs/models"
+)
+
+func TestPreviewTemplates(t *testing.T) {
+	result, err := (API{}).PreviewTemplates(&models.PreviewTemplatesInput{
+		Templates: &models.MessageTemplates{
+			Title:   "[{{.Severity}}] {{.Name}}",
+			Payload: `{"tags": {{json .Tags}}}`,
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &models.PreviewTemplatesOutput{
+		Title:   "[HIGH] Root Account Activity",
+		Payload: `{"tags": ["AWS","Identity & Access Management"]}`,
+	}, result)
+}
+
+func TestPreviewTemplatesInvalid(t *testing.T) {
+	result, err := (API{}).PreviewTemplates(&models.PreviewTemplatesInput{
+		Templates: &models.MessageTemplates{Body: "{{.Unknown}}"},
+	})
+	assert.Nil(t, result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid templates: failed to render body template")
+}
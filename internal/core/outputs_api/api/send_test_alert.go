@@ -0,0 +1,127 @@
+package api
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/panther-labs/panther/api/lambda/outputs/models"
+	alertmodels "github.com/panther-labs/panther/internal/core/alert_delivery/models"
+	"github.com/panther-labs/panther/internal/core/alert_delivery/outputs"
+	"github.com/panther-labs/panther/pkg/genericapi"
+)
+
+// SendTestAlert delivers a test alert to an output and returns the result of the delivery.
+//
+// Delivery failures are not errors of the API, they are described in the output.
+func (API) SendTestAlert(input *models.SendTestAlertInput) (*models.SendTestAlertOutput, error) {
+	output, err := getTestOutput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	zap.L().Info("sending test alert", zap.String("outputType", *output.OutputType))
+	result := &models.SendTestAlertOutput{Success: true}
+	if deliveryError := outputs.Deliver(outputClient, testAlert(), output); deliveryError != nil {
+		result.Success = false
+		result.StatusCode = deliveryError.StatusCode
+		result.Message = deliveryError.Message
+		result.Permanent = deliveryError.Permanent
+	}
+	return result, nil
+}
+
+// getTestOutput builds the output to send a test alert to, from a stored output and/or a new config
+func getTestOutput(input *models.SendTestAlertInput) (*models.AlertOutput, error) {
+	if input.OutputID == nil && input.OutputConfig == nil {
+		return nil, &genericapi.InvalidInputError{Message: "either an outputId or an outputConfig is required"}
+	}
+
+	output := &models.AlertOutput{
+		OutputID:    aws.String("test"),
+		DisplayName: aws.String("test"),
+	}
+	if input.OutputID != nil {
+		item, err := outputsTable.GetOutput(input.OutputID)
+		if err != nil {
+			return nil, &genericapi.DoesNotExistError{
+				Message: "A destination with the ID " + *input.OutputID + " does not exist."}
+		}
+		if output, err = ItemToAlertOutput(item); err != nil {
+			return nil, &genericapi.InternalError{
+				Message: "Unable to decrypt existing configuration for output " + *input.OutputID,
+			}
+		}
+	}
+
+	if input.OutputConfig != nil {
+		outputType, err := getOutputType(input.OutputConfig)
+		if err != nil {
+			return nil, &genericapi.InvalidInputError{Message: err.Error()}
+		}
+		config := input.OutputConfig
+		if input.OutputID != nil {
+			if *outputType != *output.OutputType {
+				return nil, &genericapi.InvalidInputError{
+					Message: "the outputConfig must be of the type of the output: " + *output.OutputType}
+			}
+			// Redacted secrets are kept from the stored config
+			if config, err = mergeConfigs(output.OutputConfig, input.OutputConfig); err != nil {
+				return nil, err
+			}
+		}
+		if err = validateConfigByType(config, outputType); err != nil {
+			return nil, &genericapi.InvalidInputError{Message: err.Error()}
+		}
+		output.OutputType = outputType
+		output.OutputConfig = config
+	}
+
+	if input.Templates != nil {
+		if err := validateTemplates(input.Templates, output.OutputType); err != nil {
+			return nil, err
+		}
+		output.Templates = input.Templates
+	}
+	return output, nil
+}
+
+// testAlert is the alert sent by SendTestAlert, it does not belong to any rule.
+//
+// Its id is random, so that outputs which deduplicate alerts by id do not drop repeated tests.
+func testAlert() *alertmodels.Alert {
+	return &alertmodels.Alert{
+		AlertID:             aws.String(strings.ReplaceAll(uuid.New().String(), "-", "")),
+		AnalysisID:          "Panther.Test",
+		Type:                alertmodels.RuleType,
+		CreatedAt:           time.Now().UTC(),
+		Severity:            "INFO",
+		AnalysisDescription: aws.String("This alert was sent to test the configuration of this destination in Panther"),
+		AnalysisName:        aws.String("Panther Test Alert"),
+		Runbook:             aws.String("No action is required"),
+		Tags:                []string{},
+		LogTypes:            []string{},
+		Title:               aws.String("This is a test alert from Panther"),
+	}
+}
@@ -0,0 +1,156 @@
+package api
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/api/lambda/outputs/models"
+	alertmodels "github.com/panther-labs/panther/internal/core/alert_delivery/models"
+	"github.com/panther-labs/panther/internal/core/alert_delivery/outputs"
+	"github.com/panther-labs/panther/internal/core/outputs_api/table"
+	"github.com/panther-labs/panther/pkg/genericapi"
+)
+
+type mockOutputClient struct {
+	outputs.API
+	mock.Mock
+}
+
+func (m *mockOutputClient) Slack(
+	alert *alertmodels.Alert, config *models.SlackConfig, templates *models.MessageTemplates) *outputs.AlertDeliveryError {
+
+	args := m.Called(alert, config, templates)
+	return args.Get(0).(*outputs.AlertDeliveryError)
+}
+
+func TestSendTestAlertConfig(t *testing.T) {
+	mockClient := &mockOutputClient{}
+	outputClient = mockClient
+	config := &models.SlackConfig{WebhookURL: "https://hooks.slack.com/services/new"}
+	templates := &models.MessageTemplates{Title: "{{.Name}}"}
+
+	mockClient.On("Slack", mock.MatchedBy(func(alert *alertmodels.Alert) bool {
+		return alert.AnalysisID == "Panther.Test" && len(aws.StringValue(alert.AlertID)) == 32
+	}), config, templates).Return((*outputs.AlertDeliveryError)(nil)).Once()
+
+	result, err := (API{}).SendTestAlert(&models.SendTestAlertInput{
+		OutputConfig: &models.OutputConfig{Slack: config},
+		Templates:    templates,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &models.SendTestAlertOutput{Success: true}, result)
+	mockClient.AssertExpectations(t)
+}
+
+func TestSendTestAlertStoredOutput(t *testing.T) {
+	mockClient := &mockOutputClient{}
+	outputClient = mockClient
+	mockOutputsTable := &mockOutputTable{}
+	outputsTable = mockOutputsTable
+	mockEncryptionKey := &mockEncryptionKey{}
+	encryptionKey = mockEncryptionKey
+
+	storedTemplates := &models.MessageTemplates{Body: "{{.Description}}"}
+	mockOutputsTable.On("GetOutput", aws.String("outputId")).Return(&table.AlertOutputItem{
+		OutputID:        aws.String("outputId"),
+		DisplayName:     aws.String("alerts"),
+		OutputType:      aws.String("slack"),
+		EncryptedConfig: make([]byte, 1),
+		Templates:       storedTemplates,
+	}, nil).Once()
+	mockEncryptionKey.On("DecryptConfig", mock.Anything, mock.Anything).Return(nil).Once()
+	mockClient.On("Slack", mock.Anything, &models.SlackConfig{WebhookURL: "https://hooks.slack.com/services/bb/aa/11"}, storedTemplates).
+		Return(&outputs.AlertDeliveryError{Message: "request failed: 404 Not Found: no_team", StatusCode: 404}).Once()
+
+	result, err := (API{}).SendTestAlert(&models.SendTestAlertInput{OutputID: aws.String("outputId")})
+	require.NoError(t, err)
+	assert.Equal(t, &models.SendTestAlertOutput{
+		StatusCode: 404,
+		Message:    "request failed: 404 Not Found: no_team",
+	}, result)
+	mockClient.AssertExpectations(t)
+	mockOutputsTable.AssertExpectations(t)
+	mockEncryptionKey.AssertExpectations(t)
+}
+
+func TestSendTestAlertMergesStoredConfig(t *testing.T) {
+	mockClient := &mockOutputClient{}
+	outputClient = mockClient
+	mockOutputsTable := &mockOutputTable{}
+	outputsTable = mockOutputsTable
+	mockEncryptionKey := &mockEncryptionKey{}
+	encryptionKey = mockEncryptionKey
+
+	mockOutputsTable.On("GetOutput", aws.String("outputId")).Return(&table.AlertOutputItem{
+		OutputID:        aws.String("outputId"),
+		OutputType:      aws.String("slack"),
+		EncryptedConfig: make([]byte, 1),
+	}, nil).Once()
+	mockEncryptionKey.On("DecryptConfig", mock.Anything, mock.Anything).Return(nil).Once()
+	mockClient.On("Slack", mock.Anything, &models.SlackConfig{WebhookURL: "https://hooks.slack.com/services/bb/aa/11"},
+		(*models.MessageTemplates)(nil)).Return((*outputs.AlertDeliveryError)(nil)).Once()
+
+	// The webhook is redacted, so it is kept from the stored config
+	result, err := (API{}).SendTestAlert(&models.SendTestAlertInput{
+		OutputID:     aws.String("outputId"),
+		OutputConfig: &models.OutputConfig{Slack: &models.SlackConfig{}},
+	})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	mockClient.AssertExpectations(t)
+}
+
+func TestSendTestAlertInvalidInput(t *testing.T) {
+	mockClient := &mockOutputClient{}
+	outputClient = mockClient
+	mockOutputsTable := &mockOutputTable{}
+	outputsTable = mockOutputsTable
+	mockEncryptionKey := &mockEncryptionKey{}
+	encryptionKey = mockEncryptionKey
+	mockOutputsTable.On("GetOutput", aws.String("outputId")).Return(&table.AlertOutputItem{
+		OutputID:        aws.String("outputId"),
+		OutputType:      aws.String("slack"),
+		EncryptedConfig: make([]byte, 1),
+	}, nil)
+	mockEncryptionKey.On("DecryptConfig", mock.Anything, mock.Anything).Return(nil)
+
+	for name, input := range map[string]*models.SendTestAlertInput{
+		"no output": {},
+		"no type":   {OutputConfig: &models.OutputConfig{}},
+		"type mismatch": {
+			OutputID:     aws.String("outputId"),
+			OutputConfig: &models.OutputConfig{Sns: &models.SnsConfig{TopicArn: "arn:aws:sns:us-west-2:123456789012:alerts"}},
+		},
+		"payload template": {
+			OutputConfig: &models.OutputConfig{Slack: &models.SlackConfig{WebhookURL: "https://hooks.slack.com/services/new"}},
+			Templates:    &models.MessageTemplates{Payload: "{}"},
+		},
+	} {
+		result, err := (API{}).SendTestAlert(input)
+		assert.Nil(t, result, name)
+		assert.IsType(t, &genericapi.InvalidInputError{}, err, name)
+	}
+	mockClient.AssertExpectations(t)
+}
@@ -40,15 +40,25 @@ func (API) UpdateOutput(input *models.UpdateOutputInput) (*models.UpdateOutputOu
 			Message: "A destination with the name" + *input.DisplayName + " already exists, please choose another display name"}
 	}
 
-	// Next check the outputConfig, this is to support partial updates of the outputConfig
-	var newConfig *models.OutputConfig
-	if input.OutputConfig != nil {
-		// Get the existing configuration
+	if err = validateRoutingRules(input.RoutingRules); err != nil {
+		return nil, err
+	}
+
+	// Get the existing output, its config is merged with the new one and templates are validated against its type
+	if input.OutputConfig != nil || input.Templates != nil {
 		existingOutput, err = outputsTable.GetOutput(input.OutputID)
 		if err != nil {
 			return nil, &genericapi.DoesNotExistError{
 				Message: "A destination with the ID " + *input.OutputID + " does not exist."}
 		}
+		if err = validateTemplates(input.Templates, existingOutput.OutputType); err != nil {
+			return nil, err
+		}
+	}
+
+	// Next check the outputConfig, this is to support partial updates of the outputConfig
+	var newConfig *models.OutputConfig
+	if input.OutputConfig != nil {
 		// Decrypt the existing configuration
 		decryptedConfig := &models.OutputConfig{}
 		err = encryptionKey.DecryptConfig(existingOutput.EncryptedConfig, decryptedConfig)
@@ -71,6 +81,9 @@ func (API) UpdateOutput(input *models.UpdateOutputInput) (*models.UpdateOutputOu
 		OutputID:           input.OutputID,
 		OutputConfig:       newConfig,
 		DefaultForSeverity: input.DefaultForSeverity,
+		RoutingRules:       input.RoutingRules,
+		Templates:          input.Templates,
+		Throttle:           input.Throttle,
 	}
 
 	alertOutputItem, err := AlertOutputToItem(alertOutput)
@@ -128,3 +128,29 @@ func TestUpdateSameOutputOutput(t *testing.T) {
 
 	mockOutputsTable.AssertExpectations(t)
 }
+
+func TestUpdateOutputTemplatesOfOutputType(t *testing.T) {
+	mockOutputsTable := &mockOutputTable{}
+	outputsTable = mockOutputsTable
+
+	alertOutputItem := &table.AlertOutputItem{
+		OutputID:        aws.String("outputId"),
+		DisplayName:     aws.String("displayName"),
+		OutputType:      aws.String("sns"),
+		EncryptedConfig: make([]byte, 1),
+	}
+	mockOutputsTable.On("GetOutputByName", aws.String("displayName")).Return(nil, nil)
+	mockOutputsTable.On("GetOutput", aws.String("outputId")).Return(alertOutputItem, nil)
+
+	input := &models.UpdateOutputInput{
+		OutputID:    aws.String("outputId"),
+		DisplayName: aws.String("displayName"),
+		UserID:      aws.String("userId"),
+		Templates:   &models.MessageTemplates{Payload: "{}"},
+	}
+	result, err := (API{}).UpdateOutput(input)
+	assert.Nil(t, result)
+	require.Error(t, err)
+	assert.Equal(t, "payload templates are only supported by custom webhooks", err.Error())
+	mockOutputsTable.AssertExpectations(t)
+}
@@ -20,11 +20,14 @@ package api
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/aws/aws-sdk-go/aws"
 	jsoniter "github.com/json-iterator/go"
 
 	"github.com/panther-labs/panther/api/lambda/outputs/models"
+	"github.com/panther-labs/panther/internal/core/alert_delivery/outputs"
+	"github.com/panther-labs/panther/internal/core/alert_delivery/routing"
 	"github.com/panther-labs/panther/internal/core/outputs_api/table"
 	"github.com/panther-labs/panther/pkg/genericapi"
 )
@@ -42,6 +45,9 @@ func AlertOutputToItem(input *models.AlertOutput) (*table.AlertOutputItem, error
 		OutputID:           input.OutputID,
 		OutputType:         input.OutputType,
 		DefaultForSeverity: input.DefaultForSeverity,
+		RoutingRules:       input.RoutingRules,
+		Templates:          input.Templates,
+		Throttle:           input.Throttle,
 	}
 
 	if input.OutputConfig != nil {
@@ -66,6 +72,9 @@ func ItemToAlertOutput(input *table.AlertOutputItem) (alertOutput *models.AlertO
 		OutputID:           input.OutputID,
 		OutputType:         input.OutputType,
 		DefaultForSeverity: input.DefaultForSeverity,
+		RoutingRules:       input.RoutingRules,
+		Templates:          input.Templates,
+		Throttle:           input.Throttle,
 	}
 
 	// Decrypt the output before returning to the caller
@@ -78,6 +87,30 @@ func ItemToAlertOutput(input *table.AlertOutputItem) (alertOutput *models.AlertO
 	return alertOutput, nil
 }
 
+// validateRoutingRules checks the schedules of routing rules, their other fields are checked by the validator
+func validateRoutingRules(rules []*models.RoutingRule) error {
+	for i, rule := range rules {
+		if err := routing.Validate(rule); err != nil {
+			return &genericapi.InvalidInputError{Message: fmt.Sprintf("invalid routing rule %d: %s", i, err)}
+		}
+	}
+	return nil
+}
+
+// validateTemplates checks that message templates render, payload templates are only supported by custom webhooks
+func validateTemplates(templates *models.MessageTemplates, outputType *string) error {
+	if templates == nil {
+		return nil
+	}
+	if templates.Payload != "" && aws.StringValue(outputType) != "customwebhook" {
+		return &genericapi.InvalidInputError{Message: "payload templates are only supported by custom webhooks"}
+	}
+	if err := outputs.ValidateTemplates(templates); err != nil {
+		return &genericapi.InvalidInputError{Message: "invalid templates: " + err.Error()}
+	}
+	return nil
+}
+
 func redactOutput(outputConfig *models.OutputConfig) {
 	if outputConfig.Slack != nil {
 		outputConfig.Slack.WebhookURL = redacted
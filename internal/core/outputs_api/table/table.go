@@ -24,6 +24,8 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+
+	"github.com/panther-labs/panther/api/lambda/outputs/models"
 )
 
 // OutputsAPI defines the interface for the outputs table which can be used for mocking.
@@ -84,4 +86,13 @@ type AlertOutputItem struct {
 	OutputType *string `json:"outputType"`
 
 	DefaultForSeverity []*string `json:"defaultForSeverity" dynamodbav:"defaultForSeverity,stringset"`
+
+	// RoutingRules forward the alerts they match through this output
+	RoutingRules []*models.RoutingRule `json:"routingRules,omitempty"`
+
+	// Templates customize the content of the alerts delivered through this output
+	Templates *models.MessageTemplates `json:"templates,omitempty"`
+
+	// Throttle limits the number of alerts delivered through this output
+	Throttle *models.AlertThrottle `json:"throttle,omitempty"`
 }
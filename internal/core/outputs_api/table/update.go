@@ -25,6 +25,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
 
+	"github.com/panther-labs/panther/api/lambda/outputs/models"
 	"github.com/panther-labs/panther/pkg/genericapi"
 )
 
@@ -44,6 +45,23 @@ func (table *OutputsTable) UpdateOutput(alertOutput *AlertOutputItem) (*AlertOut
 	if alertOutput.DefaultForSeverity != nil {
 		updateExpression.Set(expression.Name("defaultForSeverity"), expression.Value(alertOutput.DefaultForSeverity))
 	}
+	if alertOutput.RoutingRules != nil {
+		updateExpression.Set(expression.Name("routingRules"), expression.Value(alertOutput.RoutingRules))
+	}
+	if alertOutput.Templates != nil {
+		if *alertOutput.Templates == (models.MessageTemplates{}) {
+			updateExpression.Remove(expression.Name("templates"))
+		} else {
+			updateExpression.Set(expression.Name("templates"), expression.Value(alertOutput.Templates))
+		}
+	}
+	if alertOutput.Throttle != nil {
+		if alertOutput.Throttle.WindowMinutes == 0 {
+			updateExpression.Remove(expression.Name("throttle"))
+		} else {
+			updateExpression.Set(expression.Name("throttle"), expression.Value(alertOutput.Throttle))
+		}
+	}
 
 	conditionExpression := expression.Name("outputId").Equal(expression.Value(alertOutput.OutputID))
 	combinedExpression, err := expression.NewBuilder().
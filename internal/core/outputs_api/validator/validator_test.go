@@ -87,3 +87,16 @@ func TestAddNonSnsArn(t *testing.T) {
 	require.Error(t, err)
 	assert.Equal(t, expectedMsg("AddOutputInput.OutputConfig.Sns", "TopicArn", "snsArn"), err.Error())
 }
+
+func TestAddOutputInvalidRoutingRule(t *testing.T) {
+	validator, err := Validator()
+	require.NoError(t, err)
+	err = validator.Struct(&models.AddOutputInput{
+		UserID:       aws.String("3601990c-b566-404b-b367-3c6eacd6fe60"),
+		DisplayName:  aws.String("mychannel"),
+		OutputConfig: &models.OutputConfig{Slack: &models.SlackConfig{WebhookURL: "https://hooks.slack.com"}},
+		RoutingRules: []*models.RoutingRule{{Types: []string{"ALERT"}}},
+	})
+	require.Error(t, err)
+	assert.Equal(t, expectedMsg("AddOutputInput.RoutingRules[0]", "Types[0]", "oneof"), err.Error())
+}
@@ -57,6 +57,11 @@ func (api API) CheckIntegration(input *models.CheckIntegrationInput) (*models.So
 		return checkAwsS3Integration(input), nil
 	case models.IntegrationTypeSqs:
 		return checkSqsQueueHealth(input), nil
+	case models.IntegrationTypeHTTP:
+		// HTTP sources push their data to us, there are no resources to check
+		return &models.SourceIntegrationHealth{IntegrationType: input.IntegrationType}, nil
+	case models.IntegrationTypeKinesis:
+		return checkKinesisStreamHealth(input), nil
 	default:
 		return nil, checkIntegrationInternalError
 	}
@@ -204,6 +209,16 @@ func evaluateIntegration(api API, integration *models.CheckIntegrationInput) (st
 			return status.SqsStatus.ErrorMessage, false, nil
 		}
 		return "", true, nil
+	case models.IntegrationTypeHTTP:
+		if integration.HTTPConfig == nil {
+			return "missing http configuration", false, nil
+		}
+		return "", true, nil
+	case models.IntegrationTypeKinesis:
+		if !status.KinesisStatus.Healthy {
+			return status.KinesisStatus.ErrorMessage, false, nil
+		}
+		return "", true, nil
 
 	default:
 		return "", false, errors.New("invalid integration type")
@@ -0,0 +1,180 @@
+package api
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/panther-labs/panther/api/lambda/source/models"
+	"github.com/panther-labs/panther/internal/core/source_api/ddb"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/customlogs"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/registry"
+	"github.com/panther-labs/panther/pkg/genericapi"
+)
+
+var customLogsInternalError = &genericapi.InternalError{Message: "Failed to update custom log types. Please try again later"}
+
+// PutCustomLog creates or updates a custom log type.
+// If the log type is used by sources, its Glue tables are updated to the new schema.
+func (API) PutCustomLog(input *models.PutCustomLogInput) (*models.CustomLog, error) {
+	schema, err := customlogs.ParseSchema([]byte(input.Spec))
+	if err != nil {
+		return nil, &genericapi.InvalidInputError{Message: err.Error()}
+	}
+	if _, err := schema.Config(); err != nil {
+		return nil, &genericapi.InvalidInputError{Message: err.Error()}
+	}
+
+	item := &ddb.CustomLog{
+		LogType:      schema.Name,
+		Description:  schema.Description,
+		ReferenceURL: schema.ReferenceURL,
+		Spec:         input.Spec,
+		UpdatedAt:    time.Now().UTC(),
+		UpdatedBy:    input.UserID,
+	}
+	if err := dynamoClient.PutCustomLog(item); err != nil {
+		zap.L().Error("failed to store custom log", zap.String("logType", item.LogType), zap.Error(err))
+		return nil, customLogsInternalError
+	}
+
+	inUse, err := isLogTypeInUse(item.LogType)
+	if err != nil {
+		zap.L().Error("failed to check custom log usage", zap.String("logType", item.LogType), zap.Error(err))
+		return nil, customLogsInternalError
+	}
+	if inUse {
+		if err := addGlueTables([]string{item.LogType}); err != nil {
+			zap.L().Error("failed to update custom log tables", zap.String("logType", item.LogType), zap.Error(err))
+			return nil, customLogsInternalError
+		}
+	}
+	return itemToCustomLog(item), nil
+}
+
+// GetCustomLog returns a custom log type
+func (API) GetCustomLog(input *models.GetCustomLogInput) (*models.CustomLog, error) {
+	item, err := dynamoClient.GetCustomLog(input.LogType)
+	if err != nil {
+		zap.L().Error("failed to get custom log", zap.String("logType", input.LogType), zap.Error(err))
+		return nil, customLogsInternalError
+	}
+	if item == nil {
+		return nil, &genericapi.DoesNotExistError{Message: fmt.Sprintf("custom log type %q does not exist", input.LogType)}
+	}
+	return itemToCustomLog(item), nil
+}
+
+// ListCustomLogs returns all custom log types
+func (API) ListCustomLogs(_ *models.ListCustomLogsInput) ([]*models.CustomLog, error) {
+	items, err := dynamoClient.ScanCustomLogs()
+	if err != nil {
+		zap.L().Error("failed to list custom logs", zap.Error(err))
+		return nil, customLogsInternalError
+	}
+	result := make([]*models.CustomLog, len(items))
+	for i, item := range items {
+		result[i] = itemToCustomLog(item)
+	}
+	return result, nil
+}
+
+// DeleteCustomLog deletes a custom log type that is not used by any source.
+// The Glue tables of the log type are kept so that its data can still be queried.
+func (API) DeleteCustomLog(input *models.DeleteCustomLogInput) error {
+	item, err := dynamoClient.GetCustomLog(input.LogType)
+	if err != nil {
+		zap.L().Error("failed to get custom log", zap.String("logType", input.LogType), zap.Error(err))
+		return customLogsInternalError
+	}
+	if item == nil {
+		return &genericapi.DoesNotExistError{Message: fmt.Sprintf("custom log type %q does not exist", input.LogType)}
+	}
+
+	inUse, err := isLogTypeInUse(input.LogType)
+	if err != nil {
+		zap.L().Error("failed to check custom log usage", zap.String("logType", input.LogType), zap.Error(err))
+		return customLogsInternalError
+	}
+	if inUse {
+		return &genericapi.InvalidInputError{
+			Message: fmt.Sprintf("custom log type %q is used by a source", input.LogType),
+		}
+	}
+
+	if err := dynamoClient.DeleteCustomLog(input.LogType); err != nil {
+		zap.L().Error("failed to delete custom log", zap.String("logType", input.LogType), zap.Error(err))
+		return customLogsInternalError
+	}
+	return nil
+}
+
+// refreshCustomLogTypes loads the custom log types in the registry
+func refreshCustomLogTypes() error {
+	items, err := dynamoClient.ScanCustomLogs()
+	if err != nil {
+		return err
+	}
+	specs := make([]string, len(items))
+	for i, item := range items {
+		specs[i] = item.Spec
+	}
+	// Specs are validated before they are stored
+	return customlogs.Update(registry.Default(), specs)
+}
+
+func isLogTypeInUse(logType string) (bool, error) {
+	integrations, err := dynamoClient.ScanIntegrations(nil)
+	if err != nil {
+		return false, errors.WithMessage(err, "failed to list integrations")
+	}
+	for _, integration := range integrations {
+		logTypes := integration.LogTypes
+		if integration.SqsConfig != nil {
+			logTypes = append(logTypes, integration.SqsConfig.LogTypes...)
+		}
+		if integration.HTTPConfig != nil {
+			logTypes = append(logTypes, integration.HTTPConfig.LogTypes...)
+		}
+		if integration.KinesisConfig != nil {
+			logTypes = append(logTypes, integration.KinesisConfig.LogTypes...)
+		}
+		for _, integrationLogType := range logTypes {
+			if integrationLogType == logType {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func itemToCustomLog(item *ddb.CustomLog) *models.CustomLog {
+	return &models.CustomLog{
+		LogType:      item.LogType,
+		Description:  item.Description,
+		ReferenceURL: item.ReferenceURL,
+		Spec:         item.Spec,
+		UpdatedAt:    item.UpdatedAt,
+		UpdatedBy:    item.UpdatedBy,
+	}
+}
@@ -0,0 +1,151 @@
+package api
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/api/lambda/source/models"
+	"github.com/panther-labs/panther/internal/core/source_api/ddb"
+	"github.com/panther-labs/panther/pkg/genericapi"
+	"github.com/panther-labs/panther/pkg/testutils"
+)
+
+const testCustomLogSpec = `
+name: Custom.Test
+description: A test log type
+fields:
+  - name: id
+    type: string
+    required: true
+`
+
+func TestPutCustomLog(t *testing.T) {
+	mockClient := &testutils.DynamoDBMock{}
+	dynamoClient = &ddb.DDB{Client: mockClient, TableName: "test", CustomLogsTableName: "test-custom-logs"}
+
+	mockClient.On("PutItem", mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+		return aws.StringValue(input.TableName) == "test-custom-logs" &&
+			aws.StringValue(input.Item["logType"].S) == "Custom.Test"
+	})).Return(&dynamodb.PutItemOutput{}, nil)
+	// the log type is not used by any source, tables are not updated
+	mockClient.On("Scan", mock.Anything).Return(&dynamodb.ScanOutput{
+		Items: []map[string]*dynamodb.AttributeValue{generateDDBAttributes(models.IntegrationTypeAWS3)},
+	}, nil)
+
+	result, err := apiTest.PutCustomLog(&models.PutCustomLogInput{UserID: testUserID, Spec: testCustomLogSpec})
+	require.NoError(t, err)
+	require.Equal(t, "Custom.Test", result.LogType)
+	require.Equal(t, "A test log type", result.Description)
+	require.Equal(t, testUserID, result.UpdatedBy)
+	require.Equal(t, testCustomLogSpec, result.Spec)
+	mockClient.AssertExpectations(t)
+}
+
+func TestPutCustomLogInvalidSpec(t *testing.T) {
+	mockClient := &testutils.DynamoDBMock{}
+	dynamoClient = &ddb.DDB{Client: mockClient, TableName: "test", CustomLogsTableName: "test-custom-logs"}
+
+	_, err := apiTest.PutCustomLog(&models.PutCustomLogInput{UserID: testUserID, Spec: "name: AWS.CloudTrail"})
+	require.IsType(t, &genericapi.InvalidInputError{}, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestListCustomLogs(t *testing.T) {
+	mockClient := &testutils.DynamoDBMock{}
+	dynamoClient = &ddb.DDB{Client: mockClient, TableName: "test", CustomLogsTableName: "test-custom-logs"}
+
+	mockClient.On("Scan", &dynamodb.ScanInput{TableName: aws.String("test-custom-logs")}).Return(&dynamodb.ScanOutput{
+		Items:            []map[string]*dynamodb.AttributeValue{generateCustomLogAttributes("Custom.A")},
+		LastEvaluatedKey: map[string]*dynamodb.AttributeValue{"logType": {S: aws.String("Custom.A")}},
+	}, nil).Once()
+	mockClient.On("Scan", mock.MatchedBy(func(input *dynamodb.ScanInput) bool {
+		return input.ExclusiveStartKey != nil
+	})).Return(&dynamodb.ScanOutput{
+		Items: []map[string]*dynamodb.AttributeValue{generateCustomLogAttributes("Custom.B")},
+	}, nil).Once()
+
+	result, err := apiTest.ListCustomLogs(&models.ListCustomLogsInput{})
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	require.Equal(t, "Custom.A", result[0].LogType)
+	require.Equal(t, "Custom.B", result[1].LogType)
+	mockClient.AssertExpectations(t)
+}
+
+func TestDeleteCustomLogInUse(t *testing.T) {
+	mockClient := &testutils.DynamoDBMock{}
+	dynamoClient = &ddb.DDB{Client: mockClient, TableName: "test", CustomLogsTableName: "test-custom-logs"}
+
+	mockClient.On("GetItem", mock.Anything).Return(&dynamodb.GetItemOutput{
+		Item: generateCustomLogAttributes("Custom.Test"),
+	}, nil)
+	integration := generateDDBAttributes(models.IntegrationTypeAWS3)
+	integration["logTypes"] = &dynamodb.AttributeValue{SS: aws.StringSlice([]string{"AWS.CloudTrail", "Custom.Test"})}
+	mockClient.On("Scan", mock.Anything).Return(&dynamodb.ScanOutput{
+		Items: []map[string]*dynamodb.AttributeValue{integration},
+	}, nil)
+
+	err := apiTest.DeleteCustomLog(&models.DeleteCustomLogInput{LogType: "Custom.Test"})
+	require.IsType(t, &genericapi.InvalidInputError{}, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestDeleteCustomLog(t *testing.T) {
+	mockClient := &testutils.DynamoDBMock{}
+	dynamoClient = &ddb.DDB{Client: mockClient, TableName: "test", CustomLogsTableName: "test-custom-logs"}
+
+	mockClient.On("GetItem", mock.Anything).Return(&dynamodb.GetItemOutput{
+		Item: generateCustomLogAttributes("Custom.Test"),
+	}, nil)
+	mockClient.On("Scan", mock.Anything).Return(&dynamodb.ScanOutput{}, nil)
+	mockClient.On("DeleteItem", &dynamodb.DeleteItemInput{
+		TableName: aws.String("test-custom-logs"),
+		Key:       map[string]*dynamodb.AttributeValue{"logType": {S: aws.String("Custom.Test")}},
+	}).Return(&dynamodb.DeleteItemOutput{}, nil)
+
+	require.NoError(t, apiTest.DeleteCustomLog(&models.DeleteCustomLogInput{LogType: "Custom.Test"}))
+	mockClient.AssertExpectations(t)
+}
+
+func TestDeleteCustomLogDoesNotExist(t *testing.T) {
+	mockClient := &testutils.DynamoDBMock{}
+	dynamoClient = &ddb.DDB{Client: mockClient, TableName: "test", CustomLogsTableName: "test-custom-logs"}
+
+	mockClient.On("GetItem", mock.Anything).Return(&dynamodb.GetItemOutput{}, nil)
+
+	err := apiTest.DeleteCustomLog(&models.DeleteCustomLogInput{LogType: "Custom.Test"})
+	require.IsType(t, &genericapi.DoesNotExistError{}, err)
+	mockClient.AssertExpectations(t)
+}
+
+func generateCustomLogAttributes(logType string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"logType":     {S: aws.String(logType)},
+		"description": {S: aws.String("A test log type")},
+		"spec":        {S: aws.String(testCustomLogSpec)},
+		"updatedAt":   {S: aws.String("2020-01-01T00:00:00Z")},
+		"updatedBy":   {S: aws.String(testUserID)},
+	}
+}
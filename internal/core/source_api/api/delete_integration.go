@@ -89,6 +89,13 @@ func (API) DeleteIntegration(input *models.DeleteIntegrationInput) error {
 				zap.Error(err))
 			return deleteIntegrationInternalError
 		}
+	case models.IntegrationTypeKinesis:
+		if err := RemoveKinesisStreamFromLambdaTrigger(integrationItem.KinesisConfig.StreamARN); err != nil {
+			zap.L().Error("failed to remove kinesis stream from source",
+				zap.String("integrationId", input.IntegrationID),
+				zap.Error(err))
+			return deleteIntegrationInternalError
+		}
 	}
 
 	err = dynamoClient.DeleteItem(input.IntegrationID)
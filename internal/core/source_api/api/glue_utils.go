@@ -19,12 +19,28 @@ package api
  */
 
 import (
+	"github.com/pkg/errors"
+
 	"github.com/panther-labs/panther/internal/log_analysis/athenaviews"
 	"github.com/panther-labs/panther/internal/log_analysis/gluetables"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/customlogs"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/registry"
 )
 
 func addGlueTables(logTypes []string) error {
 	for _, logType := range logTypes {
+		if customlogs.IsCustomLogType(logType) {
+			// Custom log types can change at any time, load the current schemas
+			if err := refreshCustomLogTypes(); err != nil {
+				return errors.WithMessage(err, "failed to load custom log types")
+			}
+			break
+		}
+	}
+	for _, logType := range logTypes {
+		if registry.Default().Get(logType) == nil {
+			return errors.Errorf("unknown log type %q", logType)
+		}
 		_, _, err := gluetables.CreateOrUpdateGlueTablesForLogType(glueClient, logType, env.ProcessedDataBucket)
 		if err != nil {
 			return err
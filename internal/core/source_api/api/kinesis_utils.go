@@ -0,0 +1,131 @@
+package api
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/panther-labs/panther/api/lambda/source/models"
+)
+
+const (
+	logProcessorLambda = "panther-log-processor"
+
+	// Records are batched for up to a minute to create fewer, bigger files
+	kinesisBatchSize          = 1000
+	kinesisBatchWindowSeconds = 60
+
+	// Failing batches are split in half to isolate bad records, and are sent to the failure queue
+	// once the retries are exhausted, so that a single batch can't block its shard for the whole retention of the stream
+	kinesisMaxRetryAttempts    = 10
+	kinesisMaxRecordAgeSeconds = 24 * 60 * 60
+)
+
+// AddKinesisStreamAsLambdaTrigger configures the log processor to read the records of a Kinesis stream
+func AddKinesisStreamAsLambdaTrigger(streamARN string) error {
+	input := &lambda.CreateEventSourceMappingInput{
+		EventSourceArn:                 aws.String(streamARN),
+		FunctionName:                   aws.String(logProcessorLambda),
+		Enabled:                        aws.Bool(true),
+		BatchSize:                      aws.Int64(kinesisBatchSize),
+		MaximumBatchingWindowInSeconds: aws.Int64(kinesisBatchWindowSeconds),
+		StartingPosition:               aws.String(lambda.EventSourcePositionLatest),
+		BisectBatchOnFunctionError:     aws.Bool(true),
+		MaximumRetryAttempts:           aws.Int64(kinesisMaxRetryAttempts),
+		MaximumRecordAgeInSeconds:      aws.Int64(kinesisMaxRecordAgeSeconds),
+		DestinationConfig: &lambda.DestinationConfig{
+			OnFailure: &lambda.OnFailure{
+				Destination: aws.String(env.KinesisFailureQueueArn),
+			},
+		},
+	}
+	_, err := lambdaClient.CreateEventSourceMapping(input)
+	if err != nil {
+		return errors.Wrap(err, "failed to configure new trigger for log processor lambda")
+	}
+	return nil
+}
+
+// RemoveKinesisStreamFromLambdaTrigger stops the log processor from reading the records of a Kinesis stream
+func RemoveKinesisStreamFromLambdaTrigger(streamARN string) error {
+	listInput := &lambda.ListEventSourceMappingsInput{
+		FunctionName:   aws.String(logProcessorLambda),
+		EventSourceArn: aws.String(streamARN),
+		MaxItems:       aws.Int64(1),
+	}
+	listOutput, err := lambdaClient.ListEventSourceMappings(listInput)
+	if err != nil {
+		return errors.Wrap(err, "failed to list lambda event mappings")
+	}
+
+	if len(listOutput.EventSourceMappings) == 0 {
+		zap.L().Debug("the panther-log-processor lambda doesn't have an event source for the stream",
+			zap.String("streamArn", streamARN))
+		return nil
+	}
+
+	deleteInput := &lambda.DeleteEventSourceMappingInput{
+		UUID: listOutput.EventSourceMappings[0].UUID,
+	}
+	if _, err := lambdaClient.DeleteEventSourceMapping(deleteInput); err != nil {
+		return errors.Wrap(err, "failed to delete source mapping")
+	}
+	return nil
+}
+
+func checkKinesisStreamHealth(input *models.CheckIntegrationInput) *models.SourceIntegrationHealth {
+	health := &models.SourceIntegrationHealth{
+		IntegrationType: input.IntegrationType,
+	}
+	if input.KinesisConfig == nil {
+		health.KinesisStatus.ErrorMessage = "missing kinesis configuration"
+		return health
+	}
+
+	output, err := kinesisClient.DescribeStreamSummary(&kinesis.DescribeStreamSummaryInput{
+		StreamName: aws.String(kinesisStreamName(input.KinesisConfig.StreamARN)),
+	})
+	if err != nil {
+		zap.L().Warn("failed to describe kinesis stream", zap.Error(err))
+		health.KinesisStatus.ErrorMessage = "failed to describe kinesis stream"
+		return health
+	}
+	if arn := aws.StringValue(output.StreamDescriptionSummary.StreamARN); arn != input.KinesisConfig.StreamARN {
+		health.KinesisStatus.ErrorMessage = "kinesis stream is not in the panther account and region"
+		return health
+	}
+	switch status := aws.StringValue(output.StreamDescriptionSummary.StreamStatus); status {
+	case kinesis.StreamStatusActive, kinesis.StreamStatusUpdating:
+		health.KinesisStatus.Healthy = true
+	default:
+		health.KinesisStatus.ErrorMessage = "kinesis stream is " + status
+	}
+	return health
+}
+
+// kinesisStreamName returns the name of a stream from its ARN, e.g. arn:aws:kinesis:us-east-1:123456789012:stream/name
+func kinesisStreamName(streamARN string) string {
+	return streamARN[strings.LastIndex(streamARN, "/")+1:]
+}
@@ -109,11 +109,25 @@ func setupExternalResources(integration *models.SourceIntegration) error {
 		if err := AddSourceAsLambdaTrigger(integration.IntegrationID); err != nil {
 			return errors.Wrap(err, "failed to configure queue as lambda source")
 		}
+	case models.IntegrationTypeHTTP:
+		if err := AllowInputDataBucketSubscription(); err != nil {
+			return errors.Wrap(err, "failed to enable subscription for input bucket")
+		}
+	case models.IntegrationTypeKinesis:
+		if err := AddKinesisStreamAsLambdaTrigger(integration.KinesisConfig.StreamARN); err != nil {
+			return errors.Wrap(err, "failed to configure stream as lambda source")
+		}
 	}
 	return nil
 }
 
 func (api API) validateIntegration(input *models.PutIntegrationInput) error {
+	if input.IntegrationType == models.IntegrationTypeAWS3 {
+		if err := validateS3PrefixLogTypes(input.LogTypes, input.S3PrefixLogTypes); err != nil {
+			return err
+		}
+	}
+
 	// Validate the new integration
 	reason, passing, err := evaluateIntegrationFunc(api, &models.CheckIntegrationInput{
 		AWSAccountID:      input.AWSAccountID,
@@ -125,6 +139,8 @@ func (api API) validateIntegration(input *models.PutIntegrationInput) error {
 		S3Prefix:          input.S3Prefix,
 		KmsKey:            input.KmsKey,
 		SqsConfig:         input.SqsConfig,
+		HTTPConfig:        input.HTTPConfig,
+		KinesisConfig:     input.KinesisConfig,
 	})
 	if err != nil {
 		return putIntegrationInternalError
@@ -178,6 +194,26 @@ func (api API) integrationAlreadyExists(input *models.PutIntegrationInput) error
 						Message: fmt.Sprintf("Integration with label %s already exists", input.IntegrationLabel),
 					}
 				}
+			case models.IntegrationTypeHTTP:
+				if existingIntegration.IntegrationLabel == input.IntegrationLabel {
+					// HTTP sources need to have different labels
+					return &genericapi.InvalidInputError{
+						Message: fmt.Sprintf("Integration with label %s already exists", input.IntegrationLabel),
+					}
+				}
+			case models.IntegrationTypeKinesis:
+				if existingIntegration.IntegrationLabel == input.IntegrationLabel {
+					// Kinesis sources need to have different labels
+					return &genericapi.InvalidInputError{
+						Message: fmt.Sprintf("Integration with label %s already exists", input.IntegrationLabel),
+					}
+				}
+				if input.KinesisConfig != nil && existingIntegration.KinesisConfig.StreamARN == input.KinesisConfig.StreamARN {
+					// The log processor can only read each stream once
+					return &genericapi.InvalidInputError{
+						Message: fmt.Sprintf("Stream %s already onboarded", input.KinesisConfig.StreamARN),
+					}
+				}
 			}
 		}
 	}
@@ -255,6 +291,7 @@ func generateNewIntegration(input *models.PutIntegrationInput) *models.SourceInt
 		metadata.S3Prefix = input.S3Prefix
 		metadata.KmsKey = input.KmsKey
 		metadata.LogTypes = input.LogTypes
+		metadata.S3PrefixLogTypes = input.S3PrefixLogTypes
 		metadata.StackName = getStackName(input.IntegrationType, input.IntegrationLabel)
 		metadata.LogProcessingRole = generateLogProcessingRoleArn(input.AWSAccountID, input.IntegrationLabel)
 	case models.IntegrationTypeSqs:
@@ -267,6 +304,24 @@ func generateNewIntegration(input *models.PutIntegrationInput) *models.SourceInt
 			LogTypes:          input.SqsConfig.LogTypes,
 			QueueURL:          SourceSqsQueueURL(metadata.IntegrationID),
 		}
+	case models.IntegrationTypeHTTP:
+		metadata.HTTPConfig = &models.HTTPConfig{
+			S3Bucket:          env.InputDataBucketName,
+			S3Prefix:          models.HTTPS3PrefixForIntegration(metadata.IntegrationID),
+			LogProcessingRole: env.InputDataRoleArn,
+			LogTypes:          input.HTTPConfig.LogTypes,
+			AuthType:          input.HTTPConfig.AuthType,
+			AuthSecret:        input.HTTPConfig.AuthSecret,
+			SignatureHeader:   input.HTTPConfig.SignatureHeader,
+		}
+		if metadata.HTTPConfig.AuthSecret == "" {
+			metadata.HTTPConfig.AuthSecret = newHTTPAuthSecret()
+		}
+	case models.IntegrationTypeKinesis:
+		metadata.KinesisConfig = &models.KinesisConfig{
+			StreamARN: input.KinesisConfig.StreamARN,
+			LogTypes:  input.KinesisConfig.LogTypes,
+		}
 	}
 	return &models.SourceIntegration{
 		SourceIntegrationMetadata: metadata,
@@ -279,6 +334,10 @@ func createTables(integration *models.SourceIntegration) (err error) {
 		err = addGlueTables(integration.LogTypes)
 	case models.IntegrationTypeSqs:
 		err = addGlueTables(integration.SqsConfig.LogTypes)
+	case models.IntegrationTypeHTTP:
+		err = addGlueTables(integration.HTTPConfig.LogTypes)
+	case models.IntegrationTypeKinesis:
+		err = addGlueTables(integration.KinesisConfig.LogTypes)
 	}
 	if err != nil {
 		return errors.Wrap(err, "failed to create Glue tables")
@@ -41,6 +41,7 @@ import (
 	"github.com/panther-labs/panther/internal/core/source_api/ddb"
 	"github.com/panther-labs/panther/internal/core/source_api/ddb/modelstest"
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/registry"
+	"github.com/panther-labs/panther/pkg/genericapi"
 	"github.com/panther-labs/panther/pkg/testutils"
 )
 
@@ -316,6 +317,28 @@ func TestPutLogIntegrationUpdateSqsQueuePermissions(t *testing.T) {
 	mockAthena.AssertExpectations(t)
 }
 
+func TestPutLogIntegrationUndeclaredPrefixLogType(t *testing.T) {
+	dynamoClient = &ddb.DDB{Client: &modelstest.MockDDBClient{TestErr: false}, TableName: "test"}
+	evaluateIntegrationFunc = func(_ API, _ *models.CheckIntegrationInput) (string, bool, error) { return "", true, nil }
+
+	out, err := apiTest.PutIntegration(&models.PutIntegrationInput{
+		PutIntegrationSettings: models.PutIntegrationSettings{
+			AWSAccountID:     testAccountID,
+			IntegrationLabel: testIntegrationLabel,
+			IntegrationType:  models.IntegrationTypeAWS3,
+			UserID:           testUserID,
+			S3Bucket:         "bucket",
+			LogTypes:         []string{"AWS.VPCFlow"},
+			S3PrefixLogTypes: models.S3PrefixLogTypes{
+				{S3Prefix: "cloudtrail/", LogTypes: []string{"AWS.CloudTrail"}},
+			},
+		},
+	})
+	require.Error(t, err)
+	require.IsType(t, &genericapi.InvalidInputError{}, err)
+	require.Empty(t, out)
+}
+
 func TestPutLogIntegrationUpdateSqsQueuePermissionsFailure(t *testing.T) {
 	dynamoClient = &ddb.DDB{Client: &modelstest.MockDDBClient{TestErr: false}, TableName: "test"}
 	mockSQS := &testutils.SqsMock{}
@@ -448,3 +471,134 @@ func TestPutSqsIntegration(t *testing.T) {
 	mockAthena.AssertExpectations(t)
 	mockLambda.AssertExpectations(t)
 }
+
+func TestPutHTTPIntegration(t *testing.T) {
+	dynamoClient = &ddb.DDB{Client: &modelstest.MockDDBClient{TestErr: false}, TableName: "test"}
+	mockSQS := &testutils.SqsMock{}
+	sqsClient = mockSQS
+	mockGlue := &testutils.GlueMock{}
+	glueClient = mockGlue
+	mockAthena := &testutils.AthenaMock{}
+	athenaClient = mockAthena
+	env.LogProcessorQueueURL = "https://sqs.eu-west-1.amazonaws.com/123456789012/testqueue"
+	env.AccountID = "123456789012"
+	env.InputDataBucketName = "input-data"
+	env.InputDataRoleArn = "role-arn"
+	evaluateIntegrationFunc = func(_ API, _ *models.CheckIntegrationInput) (string, bool, error) { return "", true, nil }
+
+	// Configuring the Log Processor SQS queue
+	alreadyExistingAttributes := generateQueueAttributeOutput(t, []string{})
+	mockSQS.On("GetQueueAttributes", mock.Anything).
+		Return(&sqs.GetQueueAttributesOutput{Attributes: alreadyExistingAttributes}, nil).Once()
+	mockSQS.On("SetQueueAttributes", mock.Anything).Return(&sqs.SetQueueAttributesOutput{}, nil).Once()
+
+	// create the Glue tables
+	mockGlue.On("CreateTable", mock.Anything).Return(&glue.CreateTableOutput{}, nil).Twice()
+	// create/replace the view
+	mockGlue.On("GetTable", mock.Anything).Return(&glue.GetTableOutput{}, nil).Times(len(registry.AvailableLogTypes()))
+	mockAthena.On("StartQueryExecution", mock.Anything).Return(&athena.StartQueryExecutionOutput{
+		QueryExecutionId: aws.String("test-query-1234"),
+	}, nil).Twice()
+	mockAthena.On("GetQueryExecution", mock.Anything).Return(&athena.GetQueryExecutionOutput{
+		QueryExecution: &athena.QueryExecution{
+			QueryExecutionId: aws.String("test-query-1234"),
+			Status: &athena.QueryExecutionStatus{
+				State: aws.String(athena.QueryExecutionStateSucceeded),
+			},
+		},
+	}, nil).Twice()
+	mockAthena.On("GetQueryResults", mock.Anything).Return(&athena.GetQueryResultsOutput{}, nil).Twice()
+
+	out, err := apiTest.PutIntegration(&models.PutIntegrationInput{
+		PutIntegrationSettings: models.PutIntegrationSettings{
+			IntegrationLabel: testIntegrationLabel,
+			IntegrationType:  models.IntegrationTypeHTTP,
+			HTTPConfig: &models.HTTPConfig{
+				LogTypes: []string{"AWS.CloudTrail"},
+				AuthType: models.HTTPAuthHMAC,
+			},
+		},
+	})
+
+	// Verify returned values
+	require.NoError(t, err)
+	require.NotEmpty(t, out)
+	assert.Equal(t, "http/"+out.IntegrationID+"/", out.HTTPConfig.S3Prefix)
+	assert.Equal(t, "input-data", out.HTTPConfig.S3Bucket)
+	assert.Equal(t, "role-arn", out.HTTPConfig.LogProcessingRole)
+	assert.Equal(t, []string{"AWS.CloudTrail"}, out.HTTPConfig.LogTypes)
+	assert.Equal(t, models.HTTPAuthHMAC, out.HTTPConfig.AuthType)
+	// A secret is generated if none is provided
+	assert.Len(t, out.HTTPConfig.AuthSecret, 2*httpAuthSecretLength)
+	mockSQS.AssertExpectations(t)
+	mockGlue.AssertExpectations(t)
+	mockAthena.AssertExpectations(t)
+}
+
+func TestPutKinesisIntegration(t *testing.T) {
+	dynamoClient = &ddb.DDB{Client: &modelstest.MockDDBClient{TestErr: false}, TableName: "test"}
+	mockGlue := &testutils.GlueMock{}
+	glueClient = mockGlue
+	mockAthena := &testutils.AthenaMock{}
+	athenaClient = mockAthena
+	mockLambda := &testutils.LambdaMock{}
+	lambdaClient = mockLambda
+	evaluateIntegrationFunc = func(_ API, _ *models.CheckIntegrationInput) (string, bool, error) { return "", true, nil }
+
+	// create the Glue tables
+	mockGlue.On("CreateTable", mock.Anything).Return(&glue.CreateTableOutput{}, nil).Twice()
+	// create/replace the view
+	mockGlue.On("GetTable", mock.Anything).Return(&glue.GetTableOutput{}, nil).Times(len(registry.AvailableLogTypes()))
+	mockAthena.On("StartQueryExecution", mock.Anything).Return(&athena.StartQueryExecutionOutput{
+		QueryExecutionId: aws.String("test-query-1234"),
+	}, nil).Twice()
+	mockAthena.On("GetQueryExecution", mock.Anything).Return(&athena.GetQueryExecutionOutput{
+		QueryExecution: &athena.QueryExecution{
+			QueryExecutionId: aws.String("test-query-1234"),
+			Status: &athena.QueryExecutionStatus{
+				State: aws.String(athena.QueryExecutionStateSucceeded),
+			},
+		},
+	}, nil).Twice()
+	mockAthena.On("GetQueryResults", mock.Anything).Return(&athena.GetQueryResultsOutput{}, nil).Twice()
+
+	env.KinesisFailureQueueArn = "arn:aws:sqs:eu-west-1:123456789012:panther-log-processor-kinesis-dlq"
+	streamARN := "arn:aws:kinesis:eu-west-1:123456789012:stream/cloudwatch-logs"
+	expectedMapping := &lambda.CreateEventSourceMappingInput{
+		EventSourceArn:                 aws.String(streamARN),
+		FunctionName:                   aws.String("panther-log-processor"),
+		Enabled:                        aws.Bool(true),
+		BatchSize:                      aws.Int64(kinesisBatchSize),
+		MaximumBatchingWindowInSeconds: aws.Int64(kinesisBatchWindowSeconds),
+		StartingPosition:               aws.String(lambda.EventSourcePositionLatest),
+		BisectBatchOnFunctionError:     aws.Bool(true),
+		MaximumRetryAttempts:           aws.Int64(10),
+		MaximumRecordAgeInSeconds:      aws.Int64(86400),
+		DestinationConfig: &lambda.DestinationConfig{
+			OnFailure: &lambda.OnFailure{
+				Destination: aws.String("arn:aws:sqs:eu-west-1:123456789012:panther-log-processor-kinesis-dlq"),
+			},
+		},
+	}
+	mockLambda.On("CreateEventSourceMapping", expectedMapping).Return(&lambda.EventSourceMappingConfiguration{}, nil).Once()
+
+	out, err := apiTest.PutIntegration(&models.PutIntegrationInput{
+		PutIntegrationSettings: models.PutIntegrationSettings{
+			IntegrationLabel: testIntegrationLabel,
+			IntegrationType:  models.IntegrationTypeKinesis,
+			KinesisConfig: &models.KinesisConfig{
+				StreamARN: streamARN,
+				LogTypes:  []string{"AWS.CloudTrail"},
+			},
+		},
+	})
+
+	// Verify returned values
+	require.NoError(t, err)
+	require.NotEmpty(t, out)
+	assert.Equal(t, streamARN, out.KinesisConfig.StreamARN)
+	assert.Equal(t, []string{"AWS.CloudTrail"}, out.KinesisConfig.LogTypes)
+	mockGlue.AssertExpectations(t)
+	mockAthena.AssertExpectations(t)
+	mockLambda.AssertExpectations(t)
+}
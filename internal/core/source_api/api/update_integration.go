@@ -43,6 +43,12 @@ func (api API) UpdateIntegrationSettings(input *models.UpdateIntegrationSettings
 		return nil, err
 	}
 
+	if existingIntegrationItem.IntegrationType == models.IntegrationTypeAWS3 {
+		if err := validateS3PrefixLogTypes(input.LogTypes, input.S3PrefixLogTypes); err != nil {
+			return nil, err
+		}
+	}
+
 	// Validate the updated existingIntegrationItem settings
 	reason, passing, err := evaluateIntegrationFunc(api, &models.CheckIntegrationInput{
 		// From existing existingIntegrationItem
@@ -57,6 +63,8 @@ func (api API) UpdateIntegrationSettings(input *models.UpdateIntegrationSettings
 		S3Prefix:          input.S3Prefix,
 		KmsKey:            input.KmsKey,
 		SqsConfig:         input.SqsConfig,
+		HTTPConfig:        input.HTTPConfig,
+		KinesisConfig:     input.KinesisConfig,
 	})
 	if err != nil {
 		return nil, err
@@ -101,6 +109,7 @@ func normalizeIntegration(item *ddb.Integration, input *models.UpdateIntegration
 		item.S3Prefix = input.S3Prefix
 		item.KmsKey = input.KmsKey
 		item.LogTypes = input.LogTypes
+		item.S3PrefixLogTypes = s3PrefixLogTypesToItem(input.S3PrefixLogTypes)
 	case models.IntegrationTypeSqs:
 		item.IntegrationLabel = input.IntegrationLabel
 		item.SqsConfig.LogTypes = input.SqsConfig.LogTypes
@@ -112,6 +121,19 @@ func normalizeIntegration(item *ddb.Integration, input *models.UpdateIntegration
 		if err := UpdateSourceSqsQueue(item.IntegrationID, newAllowedPrincipals, newAllowedSourceArns); err != nil {
 			return updateIntegrationInternalError
 		}
+	case models.IntegrationTypeHTTP:
+		item.IntegrationLabel = input.IntegrationLabel
+		item.HTTPConfig.LogTypes = input.HTTPConfig.LogTypes
+		item.HTTPConfig.AuthType = input.HTTPConfig.AuthType
+		item.HTTPConfig.SignatureHeader = input.HTTPConfig.SignatureHeader
+		// The secret is only replaced if a new one is provided
+		if input.HTTPConfig.AuthSecret != "" {
+			item.HTTPConfig.AuthSecret = input.HTTPConfig.AuthSecret
+		}
+	case models.IntegrationTypeKinesis:
+		// The stream of a source cannot change, it would need a new event source mapping
+		item.IntegrationLabel = input.IntegrationLabel
+		item.KinesisConfig.LogTypes = input.KinesisConfig.LogTypes
 	}
 	return nil
 }
@@ -167,6 +189,10 @@ func updateTables(integrationType string, input *models.UpdateIntegrationSetting
 		err = addGlueTables(input.LogTypes)
 	case models.IntegrationTypeSqs:
 		err = addGlueTables(input.SqsConfig.LogTypes)
+	case models.IntegrationTypeHTTP:
+		err = addGlueTables(input.HTTPConfig.LogTypes)
+	case models.IntegrationTypeKinesis:
+		err = addGlueTables(input.KinesisConfig.LogTypes)
 	}
 	if err != nil {
 		return errors.Wrap(err, "failed to create Glue tables")
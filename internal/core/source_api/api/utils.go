@@ -19,8 +19,11 @@ package api
  */
 
 import (
+	"fmt"
+
 	"github.com/panther-labs/panther/api/lambda/source/models"
 	"github.com/panther-labs/panther/internal/core/source_api/ddb"
+	"github.com/panther-labs/panther/pkg/genericapi"
 )
 
 func integrationToItem(input *models.SourceIntegration) *ddb.Integration {
@@ -41,6 +44,7 @@ func integrationToItem(input *models.SourceIntegration) *ddb.Integration {
 		item.S3Prefix = input.S3Prefix
 		item.KmsKey = input.KmsKey
 		item.LogTypes = input.LogTypes
+		item.S3PrefixLogTypes = s3PrefixLogTypesToItem(input.S3PrefixLogTypes)
 		item.StackName = input.StackName
 		item.LogProcessingRole = generateLogProcessingRoleArn(input.AWSAccountID, input.IntegrationLabel)
 	case models.IntegrationTypeAWSScan:
@@ -64,6 +68,21 @@ func integrationToItem(input *models.SourceIntegration) *ddb.Integration {
 			AllowedPrincipals: input.SqsConfig.AllowedPrincipals,
 			AllowedSourceArns: input.SqsConfig.AllowedSourceArns,
 		}
+	case models.IntegrationTypeHTTP:
+		item.HTTPConfig = &ddb.HTTPConfig{
+			S3Bucket:          input.HTTPConfig.S3Bucket,
+			S3Prefix:          input.HTTPConfig.S3Prefix,
+			LogProcessingRole: input.HTTPConfig.LogProcessingRole,
+			LogTypes:          input.HTTPConfig.LogTypes,
+			AuthType:          input.HTTPConfig.AuthType,
+			AuthSecret:        input.HTTPConfig.AuthSecret,
+			SignatureHeader:   input.HTTPConfig.SignatureHeader,
+		}
+	case models.IntegrationTypeKinesis:
+		item.KinesisConfig = &ddb.KinesisConfig{
+			StreamARN: input.KinesisConfig.StreamARN,
+			LogTypes:  input.KinesisConfig.LogTypes,
+		}
 	}
 	return item
 }
@@ -85,6 +104,7 @@ func itemToIntegration(item *ddb.Integration) *models.SourceIntegration {
 		integration.S3Prefix = item.S3Prefix
 		integration.KmsKey = item.KmsKey
 		integration.LogTypes = item.LogTypes
+		integration.S3PrefixLogTypes = itemToS3PrefixLogTypes(item.S3PrefixLogTypes)
 		integration.StackName = item.StackName
 		integration.LogProcessingRole = item.LogProcessingRole
 	case models.IntegrationTypeAWSScan:
@@ -108,6 +128,60 @@ func itemToIntegration(item *ddb.Integration) *models.SourceIntegration {
 			AllowedPrincipals: item.SqsConfig.AllowedPrincipals,
 			AllowedSourceArns: item.SqsConfig.AllowedSourceArns,
 		}
+	case models.IntegrationTypeHTTP:
+		integration.HTTPConfig = &models.HTTPConfig{
+			S3Bucket:          item.HTTPConfig.S3Bucket,
+			S3Prefix:          item.HTTPConfig.S3Prefix,
+			LogProcessingRole: item.HTTPConfig.LogProcessingRole,
+			LogTypes:          item.HTTPConfig.LogTypes,
+			AuthType:          item.HTTPConfig.AuthType,
+			AuthSecret:        item.HTTPConfig.AuthSecret,
+			SignatureHeader:   item.HTTPConfig.SignatureHeader,
+		}
+	case models.IntegrationTypeKinesis:
+		integration.KinesisConfig = &models.KinesisConfig{
+			StreamARN: item.KinesisConfig.StreamARN,
+			LogTypes:  item.KinesisConfig.LogTypes,
+		}
 	}
 	return integration
 }
+
+func s3PrefixLogTypesToItem(input models.S3PrefixLogTypes) (items []ddb.S3PrefixLogTypes) {
+	for _, mapping := range input {
+		items = append(items, ddb.S3PrefixLogTypes{
+			S3Prefix: mapping.S3Prefix,
+			LogTypes: mapping.LogTypes,
+		})
+	}
+	return items
+}
+
+func itemToS3PrefixLogTypes(items []ddb.S3PrefixLogTypes) (result models.S3PrefixLogTypes) {
+	for _, item := range items {
+		result = append(result, models.S3PrefixLogTypesMapping{
+			S3Prefix: item.S3Prefix,
+			LogTypes: item.LogTypes,
+		})
+	}
+	return result
+}
+
+// validateS3PrefixLogTypes checks that the log types of the prefix mappings are declared by the source,
+// so that their Glue tables are created.
+func validateS3PrefixLogTypes(logTypes []string, mappings models.S3PrefixLogTypes) error {
+	declared := make(map[string]bool, len(logTypes))
+	for _, logType := range logTypes {
+		declared[logType] = true
+	}
+	for _, mapping := range mappings {
+		for _, logType := range mapping.LogTypes {
+			if !declared[logType] {
+				return &genericapi.InvalidInputError{
+					Message: fmt.Sprintf("log type %s of prefix %q is not a log type of the source", logType, mapping.S3Prefix),
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -28,6 +28,8 @@ import (
 	"github.com/aws/aws-sdk-go/service/athena/athenaiface"
 	"github.com/aws/aws-sdk-go/service/glue"
 	"github.com/aws/aws-sdk-go/service/glue/glueiface"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/aws/aws-sdk-go/service/kinesis/kinesisiface"
 	"github.com/aws/aws-sdk-go/service/lambda"
 	"github.com/aws/aws-sdk-go/service/lambda/lambdaiface"
 	"github.com/aws/aws-sdk-go/service/s3"
@@ -37,6 +39,7 @@ import (
 	"github.com/kelseyhightower/envconfig"
 
 	"github.com/panther-labs/panther/internal/core/source_api/ddb"
+	"github.com/panther-labs/panther/internal/log_analysis/awsglue"
 )
 
 const (
@@ -54,27 +57,32 @@ var (
 	glueClient       glueiface.GlueAPI
 	athenaClient     athenaiface.AthenaAPI
 	lambdaClient     lambdaiface.LambdaAPI
+	kinesisClient    kinesisiface.KinesisAPI
 )
 
 type envConfig struct {
-	SnapshotPollersQueueURL string `required:"true" split_words:"true"`
-	LogProcessorQueueURL    string `required:"true" split_words:"true"`
-	LogProcessorQueueArn    string `required:"true" split_words:"true"`
-	ProcessedDataBucket     string `required:"true" split_words:"true"`
-	TableName               string `required:"true" split_words:"true"`
-	AccountID               string `required:"true" split_words:"true"`
-	InputDataRoleArn        string `required:"true" split_words:"true"`
-	InputDataBucketName     string `required:"true" split_words:"true"`
-	InputDataTopicArn       string `required:"true" split_words:"true"`
+	SnapshotPollersQueueURL string   `required:"true" split_words:"true"`
+	LogProcessorQueueURL    string   `required:"true" split_words:"true"`
+	LogProcessorQueueArn    string   `required:"true" split_words:"true"`
+	ProcessedDataBucket     string   `required:"true" split_words:"true"`
+	TableName               string   `required:"true" split_words:"true"`
+	CustomLogsTableName     string   `required:"true" split_words:"true"`
+	AccountID               string   `required:"true" split_words:"true"`
+	InputDataRoleArn        string   `required:"true" split_words:"true"`
+	InputDataBucketName     string   `required:"true" split_words:"true"`
+	InputDataTopicArn       string   `required:"true" split_words:"true"`
+	KinesisFailureQueueArn  string   `required:"true" split_words:"true"`
+	ParquetLogTypes         []string `split_words:"true"`
 }
 
 // Setup parses the environment and constructs AWS and http clients on a cold Lambda start.
 // All required environment variables must be present or this function will panic.
 func Setup() {
 	envconfig.MustProcess("", &env)
+	awsglue.SetParquetLogTypes(env.ParquetLogTypes...)
 
 	awsSession = session.Must(session.NewSession())
-	dynamoClient = ddb.New(env.TableName)
+	dynamoClient = ddb.New(env.TableName, env.CustomLogsTableName)
 	sqsClient = sqs.New(awsSession)
 	templateS3Client = s3.New(awsSession, &aws.Config{
 		Region: aws.String(templateBucketRegion),
@@ -82,6 +90,7 @@ func Setup() {
 	glueClient = glue.New(awsSession)
 	athenaClient = athena.New(awsSession)
 	lambdaClient = lambda.New(awsSession)
+	kinesisClient = kinesis.New(awsSession)
 }
 
 // API provides receiver methods for each route handler.
@@ -0,0 +1,103 @@
+package ddb
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/pkg/errors"
+
+	"github.com/panther-labs/panther/pkg/genericapi"
+)
+
+// PutCustomLog adds or replaces a custom log type
+func (ddb *DDB) PutCustomLog(input *CustomLog) error {
+	item, err := dynamodbattribute.MarshalMap(input)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal custom log")
+	}
+
+	_, err = ddb.Client.PutItem(&dynamodb.PutItemInput{
+		TableName: &ddb.CustomLogsTableName,
+		Item:      item,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to put custom log")
+	}
+	return nil
+}
+
+// GetCustomLog returns a custom log type, or nil if it does not exist
+func (ddb *DDB) GetCustomLog(logType string) (*CustomLog, error) {
+	output, err := ddb.Client.GetItem(&dynamodb.GetItemInput{
+		TableName: &ddb.CustomLogsTableName,
+		Key: map[string]*dynamodb.AttributeValue{
+			customLogHashKey: {S: &logType},
+		},
+	})
+	if err != nil {
+		return nil, &genericapi.AWSError{Err: err, Method: "Dynamodb.GetItem"}
+	}
+	if output.Item == nil {
+		return nil, nil
+	}
+
+	var customLog CustomLog
+	if err := dynamodbattribute.UnmarshalMap(output.Item, &customLog); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal DDB item")
+	}
+	return &customLog, nil
+}
+
+// ScanCustomLogs returns all custom log types
+func (ddb *DDB) ScanCustomLogs() ([]*CustomLog, error) {
+	scanInput := &dynamodb.ScanInput{
+		TableName: &ddb.CustomLogsTableName,
+	}
+	var customLogs []*CustomLog
+	for {
+		output, err := ddb.Client.Scan(scanInput)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan custom logs table")
+		}
+		var items []*CustomLog
+		if err := dynamodbattribute.UnmarshalListOfMaps(output.Items, &items); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal scan results")
+		}
+		customLogs = append(customLogs, items...)
+		if len(output.LastEvaluatedKey) == 0 {
+			return customLogs, nil
+		}
+		scanInput.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
+
+// DeleteCustomLog deletes a custom log type
+func (ddb *DDB) DeleteCustomLog(logType string) error {
+	_, err := ddb.Client.DeleteItem(&dynamodb.DeleteItemInput{
+		TableName: &ddb.CustomLogsTableName,
+		Key: map[string]*dynamodb.AttributeValue{
+			customLogHashKey: {S: &logType},
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to delete custom log")
+	}
+	return nil
+}
@@ -45,7 +45,13 @@ type Integration struct {
 	StackName         string   `json:"stackName,omitempty"`
 	LogProcessingRole string   `json:"logProcessingRole,omitempty"`
 
+	S3PrefixLogTypes []S3PrefixLogTypes `json:"s3PrefixLogTypes,omitempty"`
+
 	SqsConfig *SqsConfig `json:"sqsConfig,omitempty"`
+
+	HTTPConfig *HTTPConfig `json:"httpConfig,omitempty"`
+
+	KinesisConfig *KinesisConfig `json:"kinesisConfig,omitempty"`
 }
 
 type IntegrationStatus struct {
@@ -54,6 +60,11 @@ type IntegrationStatus struct {
 	LastEventReceived *time.Time `json:"lastEventReceived,omitempty"`
 }
 
+type S3PrefixLogTypes struct {
+	S3Prefix string   `json:"prefix"`
+	LogTypes []string `json:"logTypes" dynamodbav:",stringset"`
+}
+
 type SqsConfig struct {
 	S3Bucket          string   `json:"s3Bucket,omitempty"`
 	S3Prefix          string   `json:"s3Prefix,omitempty"`
@@ -63,3 +74,28 @@ type SqsConfig struct {
 	AllowedSourceArns []string `json:"allowedSourceArns" dynamodbav:",stringset"`
 	QueueURL          string   `json:"queueUrl,omitempty"`
 }
+
+type HTTPConfig struct {
+	S3Bucket          string   `json:"s3Bucket,omitempty"`
+	S3Prefix          string   `json:"s3Prefix,omitempty"`
+	LogProcessingRole string   `json:"logProcessingRole,omitempty"`
+	LogTypes          []string `json:"logTypes" dynamodbav:",stringset"`
+	AuthType          string   `json:"authType,omitempty"`
+	AuthSecret        string   `json:"authSecret,omitempty"`
+	SignatureHeader   string   `json:"signatureHeader,omitempty"`
+}
+
+type KinesisConfig struct {
+	StreamARN string   `json:"streamArn,omitempty"`
+	LogTypes  []string `json:"logTypes" dynamodbav:",stringset"`
+}
+
+// CustomLog represents a user-defined log type as it is stored in DynamoDB.
+type CustomLog struct {
+	LogType      string    `json:"logType"`
+	Description  string    `json:"description"`
+	ReferenceURL string    `json:"referenceURL,omitempty"`
+	Spec         string    `json:"spec"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+	UpdatedBy    string    `json:"updatedBy"`
+}
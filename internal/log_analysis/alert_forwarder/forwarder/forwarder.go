@@ -211,6 +211,7 @@ func (h *Handler) sendAlertNotification(rule *models.Rule, alertDedup *AlertDedu
 		Runbook:      aws.String(string(rule.Runbook)),
 		Severity:     string(rule.Severity),
 		Tags:         rule.Tags,
+		LogTypes:     alertDedup.LogTypes,
 		Type:         alertModel.RuleType,
 		Title:        aws.String(getAlertTitle(rule, alertDedup)),
 		Version:      &alertDedup.RuleVersion,
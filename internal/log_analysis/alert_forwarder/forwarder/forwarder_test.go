@@ -114,6 +114,7 @@ func TestHandleStoreAndSendNotification(t *testing.T) {
 		Runbook:             aws.String(string(testRuleResponse.Runbook)),
 		Severity:            string(testRuleResponse.Severity),
 		Tags:                []string{"Tag"},
+		LogTypes:            newAlertDedupEvent.LogTypes,
 		Type:                alertModel.RuleType,
 		AlertID:             aws.String("b25dc23fb2a0b362da8428dbec1381a8"),
 		Title:               newAlertDedupEvent.GeneratedTitle,
@@ -200,6 +201,7 @@ func TestHandleStoreAndSendNotificationNoRuleDisplayNameNoTitle(t *testing.T) {
 		Runbook:             aws.String(string(testRuleResponse.Runbook)),
 		Severity:            string(testRuleResponse.Severity),
 		Tags:                []string{"Tag"},
+		LogTypes:            newAlertDedupEventWithoutTitle.LogTypes,
 		Type:                alertModel.RuleType,
 		AlertID:             aws.String("b25dc23fb2a0b362da8428dbec1381a8"),
 		Title:               aws.String(newAlertDedupEventWithoutTitle.RuleID),
@@ -283,6 +285,7 @@ func TestHandleStoreAndSendNotificationNoGeneratedTitle(t *testing.T) {
 		Runbook:             aws.String(string(testRuleResponse.Runbook)),
 		Severity:            string(testRuleResponse.Severity),
 		Tags:                []string{"Tag"},
+		LogTypes:            newAlertDedupEvent.LogTypes,
 		Type:                alertModel.RuleType,
 		AlertID:             aws.String("b25dc23fb2a0b362da8428dbec1381a8"),
 		Title:               aws.String("DisplayName"),
@@ -370,6 +373,7 @@ func TestHandleStoreAndSendNotificationNilOldDedup(t *testing.T) {
 		Runbook:             aws.String(string(testRuleResponse.Runbook)),
 		Severity:            string(testRuleResponse.Severity),
 		Tags:                []string{"Tag"},
+		LogTypes:            newAlertDedupEvent.LogTypes,
 		Type:                alertModel.RuleType,
 		AlertID:             aws.String("b25dc23fb2a0b362da8428dbec1381a8"),
 		Title:               newAlertDedupEvent.GeneratedTitle,
@@ -21,15 +21,20 @@ package api
 
 import (
 	"encoding/base64"
+	"net/http"
 
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/kelseyhightower/envconfig"
 
+	analysisclient "github.com/panther-labs/panther/api/gateway/analysis/client"
 	"github.com/panther-labs/panther/internal/log_analysis/alerts_api/table"
+	"github.com/panther-labs/panther/pkg/gatewayapi"
 )
 
 // API has all of the handlers as receiver methods.
@@ -40,6 +45,10 @@ var (
 	awsSession *session.Session
 	alertsDB   table.API
 	s3Client   s3iface.S3API
+	sqsClient  sqsiface.SQSAPI
+
+	httpClient     *http.Client
+	analysisClient *analysisclient.PantherAnalysis
 )
 
 type envConfig struct {
@@ -49,6 +58,7 @@ type envConfig struct {
 	RuleIndexName       string `required:"true" split_words:"true"`
 	TimeIndexName       string `required:"true" split_words:"true"`
 	ProcessedDataBucket string `required:"true" split_words:"true"`
+	AlertingQueueURL    string `required:"true" split_words:"true"`
 }
 
 // Setup - parses the environment and builds the AWS and http clients.
@@ -63,6 +73,11 @@ func Setup() {
 		TimePartitionCreationTimeIndexName: env.TimeIndexName,
 	}
 	s3Client = s3.New(awsSession)
+	sqsClient = sqs.New(awsSession)
+	httpClient = gatewayapi.GatewayClient(awsSession)
+	analysisClient = analysisclient.NewHTTPClientWithConfig(nil, analysisclient.DefaultTransportConfig().
+		WithHost(env.AnalysisAPIHost).
+		WithBasePath(env.AnalysisAPIPath))
 }
 
 // EventPaginationToken - token used for paginating through the events in an alert
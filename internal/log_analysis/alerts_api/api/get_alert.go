@@ -111,6 +111,7 @@ func (API) GetAlert(input *models.GetAlertInput) (result *models.GetAlertOutput,
 		},
 		Events:                 aws.StringSlice(events),
 		EventsLastEvaluatedKey: aws.String(encodedToken),
+		DeliveryResponses:      alertItem.DeliveryResponses,
 	}
 
 	gatewayapi.ReplaceMapSliceNils(result)
@@ -132,6 +132,9 @@ func TestGetAlert(t *testing.T) {
 		Severity:     "INFO",
 		EventCount:   5,
 		LogTypes:     []string{"logtype"},
+		DeliveryResponses: []*models.DeliveryResponse{
+			{OutputID: "outputId", OutputType: "pagerduty", DispatchedAt: time.Date(2020, 1, 1, 1, 0, 1, 0, time.UTC), Success: true},
+		},
 	}
 
 	expectedListObjectsRequest := &s3.ListObjectsV2Input{
@@ -185,6 +188,7 @@ func TestGetAlert(t *testing.T) {
 		EventsLastEvaluatedKey:
 		// nolint
 		aws.String("eyJsb2dUeXBlVG9Ub2tlbiI6eyJsb2d0eXBlIjp7InMzT2JqZWN0S2V5IjoicnVsZXMvbG9ndHlwZS95ZWFyPTIwMjAvbW9udGg9MDEvZGF5PTAxL2hvdXI9MDEvcnVsZV9pZD1ydWxlSWQvMjAyMDAxMDFUMDEwMTAwWi11dWlkNC5qc29uLmd6IiwiZXZlbnRJbmRleCI6MX19fQ=="),
+		DeliveryResponses: alertItem.DeliveryResponses,
 	}, result)
 	s3Mock.AssertExpectations(t)
 	tableMock.AssertExpectations(t)
@@ -235,6 +239,7 @@ func TestGetAlert(t *testing.T) {
 		EventsLastEvaluatedKey:
 		// nolint
 		aws.String("eyJsb2dUeXBlVG9Ub2tlbiI6eyJsb2d0eXBlIjp7InMzT2JqZWN0S2V5IjoicnVsZXMvbG9ndHlwZS95ZWFyPTIwMjAvbW9udGg9MDEvZGF5PTAxL2hvdXI9MDEvcnVsZV9pZD1ydWxlSWQvMjAyMDAxMDFUMDEwMTAwWi11dWlkNC5qc29uLmd6IiwiZXZlbnRJbmRleCI6MH19fQ=="),
+		DeliveryResponses: alertItem.DeliveryResponses,
 	}, result)
 
 	s3Mock.AssertExpectations(t)
@@ -307,6 +312,7 @@ func TestGetAlertFilterOutS3KeysOutsideTheTimePeriod(t *testing.T) {
 		EventsLastEvaluatedKey:
 		// nolint
 		aws.String("eyJsb2dUeXBlVG9Ub2tlbiI6eyJsb2d0eXBlIjp7InMzT2JqZWN0S2V5IjoicnVsZXMvbG9ndHlwZS95ZWFyPTIwMjAvbW9udGg9MDEvZGF5PTAxL2hvdXI9MDEvcnVsZV9pZD1ydWxlSWQvMjAyMDAxMDFUMDEwNTAwWi11dWlkNC5qc29uLmd6IiwiZXZlbnRJbmRleCI6MX19fQ=="),
+		DeliveryResponses: []*models.DeliveryResponse{},
 	}, result)
 	s3Mock.AssertExpectations(t)
 	tableMock.AssertExpectations(t)
@@ -0,0 +1,96 @@
+package api
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+
+	analysisoperations "github.com/panther-labs/panther/api/gateway/analysis/client/operations"
+	"github.com/panther-labs/panther/api/lambda/alerts/models"
+	alertmodels "github.com/panther-labs/panther/internal/core/alert_delivery/models"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/common"
+)
+
+// ResendAlert queues a stored alert for delivery to the given outputs
+func (API) ResendAlert(input *models.ResendAlertInput) (err error) {
+	operation := common.OpLogManager.Start("resendAlert")
+	defer func() {
+		operation.Stop()
+		operation.Log(err)
+	}()
+
+	alertItem, err := alertsDB.GetAlert(input.AlertID)
+	if err != nil {
+		return err
+	}
+	if alertItem == nil {
+		err = errors.New("alert does not exist: " + *input.AlertID)
+		return err
+	}
+
+	// The alert is rebuilt from the version of the rule that triggered it
+	response, err := analysisClient.Operations.GetRule(&analysisoperations.GetRuleParams{
+		RuleID:     alertItem.RuleID,
+		VersionID:  aws.String(alertItem.RuleVersion),
+		HTTPClient: httpClient,
+	})
+	if err != nil {
+		err = errors.Wrapf(err, "failed to fetch rule [%s], version [%s]", alertItem.RuleID, alertItem.RuleVersion)
+		return err
+	}
+	rule := response.Payload
+
+	resentAt := time.Now().UTC()
+	alert := &alertmodels.Alert{
+		AlertID:             aws.String(alertItem.AlertID),
+		AnalysisDescription: aws.String(string(rule.Description)),
+		AnalysisID:          alertItem.RuleID,
+		CreatedAt:           alertItem.CreationTime,
+		OutputIds:           input.OutputIds,
+		AnalysisName:        alertItem.RuleDisplayName,
+		Runbook:             aws.String(string(rule.Runbook)),
+		Severity:            alertItem.Severity,
+		Tags:                rule.Tags,
+		LogTypes:            alertItem.LogTypes,
+		Type:                alertmodels.RuleType,
+		Title:               getAlertTitle(alertItem),
+		Version:             aws.String(alertItem.RuleVersion),
+		ResentAt:            &resentAt,
+	}
+
+	body, err := jsoniter.MarshalToString(alert)
+	if err != nil {
+		err = errors.Wrap(err, "failed to marshal alert")
+		return err
+	}
+	_, err = sqsClient.SendMessage(&sqs.SendMessageInput{
+		QueueUrl:    aws.String(env.AlertingQueueURL),
+		MessageBody: aws.String(body),
+	})
+	if err != nil {
+		err = errors.Wrap(err, "failed to queue alert")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package api
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	analysisclient "github.com/panther-labs/panther/api/gateway/analysis/client"
+	analysismodels "github.com/panther-labs/panther/api/gateway/analysis/models"
+	"github.com/panther-labs/panther/api/lambda/alerts/models"
+	alertmodels "github.com/panther-labs/panther/internal/core/alert_delivery/models"
+	"github.com/panther-labs/panther/internal/log_analysis/alerts_api/table"
+	"github.com/panther-labs/panther/pkg/testutils"
+)
+
+type mockRoundTripper struct {
+	http.RoundTripper
+	mock.Mock
+}
+
+func (m *mockRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	args := m.Called(request)
+	return args.Get(0).(*http.Response), args.Error(1)
+}
+
+func generateResponse(body interface{}, httpCode int) *http.Response {
+	serializedBody, _ := jsoniter.MarshalToString(body)
+	return &http.Response{StatusCode: httpCode, Body: ioutil.NopCloser(strings.NewReader(serializedBody))}
+}
+
+var (
+	resendAlertItem = &table.AlertItem{
+		AlertID:         "a3f6b0e1c2d4e5f60718293a4b5c6d7e",
+		RuleID:          "ruleId",
+		RuleVersion:     "ruleVersion",
+		RuleDisplayName: aws.String("ruleName"),
+		Severity:        "INFO",
+		LogTypes:        []string{"AWS.CloudTrail"},
+		CreationTime:    time.Date(2020, 1, 1, 1, 59, 0, 0, time.UTC),
+	}
+	resendRule = &analysismodels.Rule{
+		ID:          "ruleId",
+		Description: "Description",
+		Runbook:     "Runbook",
+		Tags:        []string{"Tag"},
+	}
+	resendOutputIds = []string{"8c74b4b0-3f3b-4a2e-9a4b-9d2f4c2f6e1a"}
+)
+
+func setupResendAlert() (*tableMock, *testutils.SqsMock, *mockRoundTripper) {
+	tableMock := &tableMock{}
+	alertsDB = tableMock
+	sqsMock := &testutils.SqsMock{}
+	sqsClient = sqsMock
+	roundTripper := &mockRoundTripper{}
+	httpClient = &http.Client{Transport: roundTripper}
+	analysisClient = analysisclient.NewHTTPClientWithConfig(nil, analysisclient.DefaultTransportConfig().
+		WithHost("host").
+		WithBasePath("path"))
+	env.AlertingQueueURL = "queueUrl"
+	return tableMock, sqsMock, roundTripper
+}
+
+func TestResendAlert(t *testing.T) {
+	tableMock, sqsMock, roundTripper := setupResendAlert()
+	input := &models.ResendAlertInput{
+		AlertID:   aws.String(resendAlertItem.AlertID),
+		OutputIds: resendOutputIds,
+	}
+
+	tableMock.On("GetAlert", input.AlertID).Return(resendAlertItem, nil).Once()
+	roundTripper.On("RoundTrip", mock.Anything).Return(generateResponse(resendRule, http.StatusOK), nil).Once()
+	var sentAlert alertmodels.Alert
+	sqsMock.On("SendMessage", mock.MatchedBy(func(input *sqs.SendMessageInput) bool {
+		return aws.StringValue(input.QueueUrl) == "queueUrl" &&
+			jsoniter.UnmarshalFromString(aws.StringValue(input.MessageBody), &sentAlert) == nil
+	})).Return(&sqs.SendMessageOutput{}, nil).Once()
+
+	require.NoError(t, API{}.ResendAlert(input))
+
+	assert.Equal(t, resendAlertItem.AlertID, aws.StringValue(sentAlert.AlertID))
+	assert.Equal(t, "ruleId", sentAlert.AnalysisID)
+	assert.Equal(t, "ruleVersion", aws.StringValue(sentAlert.Version))
+	assert.Equal(t, "ruleName", aws.StringValue(sentAlert.Title))
+	assert.Equal(t, "Description", aws.StringValue(sentAlert.AnalysisDescription))
+	assert.Equal(t, "Runbook", aws.StringValue(sentAlert.Runbook))
+	assert.Equal(t, []string{"Tag"}, sentAlert.Tags)
+	assert.Equal(t, resendOutputIds, sentAlert.OutputIds)
+	assert.Equal(t, alertmodels.RuleType, sentAlert.Type)
+	assert.Equal(t, resendAlertItem.CreationTime, sentAlert.CreatedAt)
+	require.NotNil(t, sentAlert.ResentAt)
+	assert.False(t, sentAlert.ResentAt.Before(resendAlertItem.CreationTime))
+	tableMock.AssertExpectations(t)
+	sqsMock.AssertExpectations(t)
+	roundTripper.AssertExpectations(t)
+}
+
+func TestResendAlertDoesNotExist(t *testing.T) {
+	tableMock, sqsMock, roundTripper := setupResendAlert()
+	input := &models.ResendAlertInput{
+		AlertID:   aws.String(resendAlertItem.AlertID),
+		OutputIds: resendOutputIds,
+	}
+
+	tableMock.On("GetAlert", input.AlertID).Return(nil, nil).Once()
+
+	assert.Error(t, API{}.ResendAlert(input))
+	tableMock.AssertExpectations(t)
+	sqsMock.AssertExpectations(t)
+	roundTripper.AssertExpectations(t)
+}
+
+func TestResendAlertQueueError(t *testing.T) {
+	tableMock, sqsMock, roundTripper := setupResendAlert()
+	input := &models.ResendAlertInput{
+		AlertID:   aws.String(resendAlertItem.AlertID),
+		OutputIds: resendOutputIds,
+	}
+
+	tableMock.On("GetAlert", input.AlertID).Return(resendAlertItem, nil).Once()
+	roundTripper.On("RoundTrip", mock.Anything).Return(generateResponse(resendRule, http.StatusOK), nil).Once()
+	sqsMock.On("SendMessage", mock.Anything).Return(&sqs.SendMessageOutput{}, errors.New("error")).Once()
+
+	assert.Error(t, API{}.ResendAlert(input))
+	tableMock.AssertExpectations(t)
+	sqsMock.AssertExpectations(t)
+	roundTripper.AssertExpectations(t)
+}
@@ -0,0 +1,57 @@
+package table
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+	"github.com/pkg/errors"
+
+	"github.com/panther-labs/panther/api/lambda/alerts/models"
+)
+
+// AddDeliveryResponses appends delivery responses to an existing alert
+func (table *AlertsTable) AddDeliveryResponses(alertID string, responses []*models.DeliveryResponse) error {
+	// Start the list if this is the first delivery of the alert
+	deliveryResponses := expression.ListAppend(
+		expression.Name(DeliveryKey).IfNotExists(expression.Value([]*models.DeliveryResponse{})),
+		expression.Value(responses),
+	)
+	expr, err := expression.NewBuilder().
+		WithCondition(expression.AttributeExists(expression.Name(AlertIDKey))).
+		WithUpdate(expression.Set(expression.Name(DeliveryKey), deliveryResponses)).
+		Build()
+	if err != nil {
+		return errors.Wrap(err, "failed to build update expression")
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(table.AlertsTableName),
+		Key:                       DynamoItem{AlertIDKey: {S: aws.String(alertID)}},
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+	if _, err = table.Client.UpdateItem(input); err != nil {
+		return errors.Wrap(err, "UpdateItem() failed for: "+alertID)
+	}
+	return nil
+}
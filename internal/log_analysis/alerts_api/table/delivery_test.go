@@ -0,0 +1,83 @@
+package table
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/api/lambda/alerts/models"
+)
+
+func (m *mockDynamoDB) UpdateItem(input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*dynamodb.UpdateItemOutput), args.Error(1)
+}
+
+func TestAddDeliveryResponses(t *testing.T) {
+	mockDdbClient := &mockDynamoDB{}
+	table := AlertsTable{
+		AlertsTableName: "alertsTableName",
+		Client:          mockDdbClient,
+	}
+	responses := []*models.DeliveryResponse{
+		{
+			OutputID:     "7d1c5854-f3ea-491c-8a52-0aa0d58cb456",
+			OutputType:   "pagerduty",
+			DisplayName:  "on-call",
+			DispatchedAt: time.Date(2020, 6, 1, 9, 30, 0, 0, time.UTC),
+			StatusCode:   503,
+			Message:      "request failed: 503 Service Unavailable",
+		},
+	}
+
+	mockDdbClient.On("UpdateItem", mock.Anything).Return(&dynamodb.UpdateItemOutput{}, nil)
+	require.NoError(t, table.AddDeliveryResponses("alertId", responses))
+	mockDdbClient.AssertExpectations(t)
+
+	input := mockDdbClient.Calls[0].Arguments.Get(0).(*dynamodb.UpdateItemInput)
+	assert.Equal(t, "alertsTableName", aws.StringValue(input.TableName))
+	assert.Equal(t, "alertId", aws.StringValue(input.Key["id"].S))
+	assert.Equal(t, "SET #1 = list_append(if_not_exists(#1, :0), :1)\n", aws.StringValue(input.UpdateExpression))
+	assert.Equal(t, "attribute_exists (#0)", aws.StringValue(input.ConditionExpression))
+	assert.Equal(t, map[string]*string{"#0": aws.String("id"), "#1": aws.String("deliveryResponses")},
+		input.ExpressionAttributeNames)
+	appended := input.ExpressionAttributeValues[":1"].L
+	require.Len(t, appended, 1)
+	assert.Equal(t, "pagerduty", aws.StringValue(appended[0].M["outputType"].S))
+	assert.Equal(t, "503", aws.StringValue(appended[0].M["statusCode"].N))
+}
+
+func TestAddDeliveryResponsesError(t *testing.T) {
+	mockDdbClient := &mockDynamoDB{}
+	table := AlertsTable{
+		AlertsTableName: "alertsTableName",
+		Client:          mockDdbClient,
+	}
+
+	mockDdbClient.On("UpdateItem", mock.Anything).Return(&dynamodb.UpdateItemOutput{}, errors.New("test"))
+	require.Error(t, table.AddDeliveryResponses("alertId", []*models.DeliveryResponse{{OutputID: "outputId"}}))
+}
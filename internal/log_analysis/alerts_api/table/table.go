@@ -37,12 +37,14 @@ const (
 	TitleKey           = "title"
 	SeverityKey        = "severity"
 	EventCountKey      = "eventCount"
+	DeliveryKey        = "deliveryResponses"
 )
 
 // API defines the interface for the alerts table which can be used for mocking.
 type API interface {
 	GetAlert(*string) (*AlertItem, error)
 	ListAll(*models.ListAlertsInput) ([]*AlertItem, *string, error)
+	AddDeliveryResponses(string, []*models.DeliveryResponse) error
 }
 
 // AlertsTable encapsulates a connection to the Dynamo alerts table.
@@ -72,4 +74,6 @@ type AlertItem struct {
 	Severity        string    `json:"severity"`
 	EventCount      int       `json:"eventCount"`
 	LogTypes        []string  `json:"logTypes"`
+	// DeliveryResponses are appended by alert delivery
+	DeliveryResponses []*models.DeliveryResponse `json:"deliveryResponses"`
 }
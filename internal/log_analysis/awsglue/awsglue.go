@@ -29,6 +29,7 @@ import (
 const (
 	logS3Prefix       = "logs"
 	ruleMatchS3Prefix = "rules"
+	errorS3Prefix     = "errors"
 
 	LogProcessingDatabaseName        = "panther_logs"
 	LogProcessingDatabaseDescription = "Holds tables with data from Panther log processing"
@@ -36,6 +37,9 @@ const (
 	RuleMatchDatabaseName        = "panther_rule_matches"
 	RuleMatchDatabaseDescription = "Holds tables with data from Panther rule matching (same table structure as panther_logs)"
 
+	ErrorsDatabaseName        = "panther_errors"
+	ErrorsDatabaseDescription = "Holds tables with log lines that Panther failed to parse"
+
 	ViewsDatabaseName        = "panther_views"
 	ViewsDatabaseDescription = "Holds views useful for querying Panther data"
 
@@ -48,6 +52,7 @@ var (
 	PantherDatabases = map[string]string{
 		LogProcessingDatabaseName: LogProcessingDatabaseDescription,
 		RuleMatchDatabaseName:     RuleMatchDatabaseDescription,
+		ErrorsDatabaseName:        ErrorsDatabaseDescription,
 		ViewsDatabaseName:         ViewsDatabaseDescription,
 		TempDatabaseName:          TempDatabaseDescription,
 	}
@@ -55,18 +60,26 @@ var (
 
 // Returns the prefix of the table in S3 or error if it failed to generate it
 func getDatabase(dataType models.DataType) string {
-	if dataType == models.LogData {
+	switch dataType {
+	case models.LogData:
 		return LogProcessingDatabaseName
+	case models.ErrorData:
+		return ErrorsDatabaseName
+	default:
+		return RuleMatchDatabaseName
 	}
-	return RuleMatchDatabaseName
 }
 
 // Returns the prefix of the table in S3 or error if it failed to generate it
 func getTablePrefix(dataType models.DataType, tableName string) string {
-	if dataType == models.LogData {
+	switch dataType {
+	case models.LogData:
 		return logS3Prefix + "/" + tableName + "/"
+	case models.ErrorData:
+		return errorS3Prefix + "/" + tableName + "/"
+	default:
+		return ruleMatchS3Prefix + "/" + tableName + "/"
 	}
-	return ruleMatchS3Prefix + "/" + tableName + "/"
 }
 
 func GetTableName(logType string) string {
@@ -81,6 +94,8 @@ func GetDataPrefix(databaseName string) string {
 		return logS3Prefix
 	case RuleMatchDatabaseName:
 		return ruleMatchS3Prefix
+	case ErrorsDatabaseName:
+		return errorS3Prefix
 	default:
 		if strings.Contains(databaseName, "test") {
 			return logS3Prefix // assume logs, used for integration tests
@@ -92,7 +92,7 @@ type PartitionColumnInfo struct {
 
 // Gets the partition from S3bucket and S3 object key info.
 // The s3Object key is expected to be in the the format
-// `{logs,rules}/{table_name}/year=d{4}/month=d{2}/[day=d{2}/][hour=d{2}/]/{S+}.json.gz` otherwise an error is returned.
+// `{logs,rules,errors}/{table_name}/year=d{4}/month=d{2}/[day=d{2}/][hour=d{2}/]/{S+}.{json.gz,parquet}` otherwise an error is returned.
 func GetPartitionFromS3(s3Bucket, s3ObjectKey string) (*GluePartition, error) {
 	partition := &GluePartition{s3Bucket: s3Bucket}
 
@@ -108,6 +108,9 @@ func GetPartitionFromS3(s3Bucket, s3ObjectKey string) (*GluePartition, error) {
 	case ruleMatchS3Prefix:
 		partition.databaseName = RuleMatchDatabaseName
 		partition.datatype = models.RuleData
+	case errorS3Prefix:
+		partition.databaseName = ErrorsDatabaseName
+		partition.datatype = models.ErrorData
 	default:
 		return nil, errors.Errorf("unsupported S3 object prefix %s from %s", s3Keys[0], s3ObjectKey)
 	}
@@ -93,6 +93,17 @@ func TestCreatePartitionFromS3Log(t *testing.T) {
 	assert.Equal(t, expectedPartitionValues, partition.GetPartitionColumnsInfo())
 }
 
+func TestCreatePartitionFromS3Error(t *testing.T) {
+	s3ObjectKey := "errors/table/year=2020/month=02/day=26/hour=15/item.json.gz"
+	partition, err := GetPartitionFromS3("bucket", s3ObjectKey)
+	require.NoError(t, err)
+
+	assert.Equal(t, ErrorsDatabaseName, partition.GetDatabase())
+	assert.Equal(t, "table", partition.GetTable())
+	assert.Equal(t, "s3://bucket/errors/table/year=2020/month=02/day=26/hour=15/", partition.GetPartitionLocation())
+	assert.Len(t, partition.GetPartitionColumnsInfo(), 4)
+}
+
 func TestCreatePartitionUnknownPrefix(t *testing.T) {
 	s3ObjectKey := "wrong_prefix/table/year=2020/month=02/day=26/hour=15/rule_id=Rule.Id/item.json.gz"
 	_, err := GetPartitionFromS3("bucket", s3ObjectKey)
@@ -38,6 +38,29 @@ import (
 	"github.com/panther-labs/panther/pkg/box"
 )
 
+// StorageFormat is the format of the S3 objects of a table
+type StorageFormat string
+
+const (
+	// StorageFormatJSON tables store gzipped newline delimited JSON
+	StorageFormatJSON StorageFormat = "json"
+	// StorageFormatParquet tables store Parquet files, they are cheaper and faster to scan with Athena
+	StorageFormatParquet StorageFormat = "parquet"
+)
+
+// parquetLogTypes are the log types stored as Parquet, set by SetParquetLogTypes
+var parquetLogTypes = map[string]bool{}
+
+// SetParquetLogTypes sets the log types whose log tables are stored as Parquet, all others are stored as JSON.
+// It should be called once at startup by all components that create tables or write log data.
+func SetParquetLogTypes(logTypes ...string) {
+	formats := make(map[string]bool, len(logTypes))
+	for _, logType := range logTypes {
+		formats[logType] = true
+	}
+	parquetLogTypes = formats
+}
+
 type PartitionKey struct {
 	Name string
 	Type string
@@ -106,6 +129,15 @@ func (gm *GlueTableMetadata) EventStruct() interface{} {
 	return gm.eventStruct
 }
 
+// StorageFormat returns the format of the table objects.
+// Only log tables can be Parquet, rule matches and errors are read by other components as JSON.
+func (gm *GlueTableMetadata) StorageFormat() StorageFormat {
+	if gm.dataType == models.LogData && parquetLogTypes[gm.logType] {
+		return StorageFormatParquet
+	}
+	return StorageFormatJSON
+}
+
 func (gm *GlueTableMetadata) HasPartitions(glueClient glueiface.GlueAPI) (bool, error) {
 	return TableHasPartitions(glueClient, gm.databaseName, gm.tableName)
 }
@@ -146,10 +178,7 @@ func (gm *GlueTableMetadata) glueTableInput(bucketName string) *glue.TableInput
 	}
 
 	// columns -> []*glue.Column
-	columns, structFieldNames := InferJSONColumns(gm.eventStruct, GlueMappings...)
-	if gm.dataType == models.RuleData { // append the columns added by the rule engine
-		columns = append(columns, RuleMatchColumns...)
-	}
+	columns := gm.Columns()
 	glueColumns := make([]*glue.Column, len(columns))
 	for i := range columns {
 		glueColumns[i] = &glue.Column{
@@ -159,6 +188,51 @@ func (gm *GlueTableMetadata) glueTableInput(bucketName string) *glue.TableInput
 		}
 	}
 
+	storageDescriptor := &glue.StorageDescriptor{
+		Columns:  glueColumns,
+		Location: aws.String("s3://" + bucketName + "/" + gm.prefix),
+	}
+	switch gm.StorageFormat() {
+	case StorageFormatParquet:
+		storageDescriptor.InputFormat = aws.String("org.apache.hadoop.hive.ql.io.parquet.MapredParquetInputFormat")
+		storageDescriptor.OutputFormat = aws.String("org.apache.hadoop.hive.ql.io.parquet.MapredParquetOutputFormat")
+		storageDescriptor.SerdeInfo = &glue.SerDeInfo{
+			SerializationLibrary: aws.String("org.apache.hadoop.hive.ql.io.parquet.serde.ParquetHiveSerDe"),
+			Parameters: map[string]*string{
+				"serialization.format": aws.String("1"),
+			},
+		}
+	default: // configure as JSON
+		storageDescriptor.InputFormat = aws.String("org.apache.hadoop.mapred.TextInputFormat")
+		storageDescriptor.OutputFormat = aws.String("org.apache.hadoop.hive.ql.io.HiveIgnoreKeyTextOutputFormat")
+		storageDescriptor.SerdeInfo = gm.jsonSerDeInfo()
+	}
+
+	return &glue.TableInput{
+		Name:              &gm.tableName,
+		Description:       &gm.description,
+		PartitionKeys:     partitionColumns,
+		StorageDescriptor: storageDescriptor,
+		TableType:         aws.String("EXTERNAL_TABLE"),
+	}
+}
+
+// Columns returns the columns of the table, Parquet files of the table are written with this schema
+func (gm *GlueTableMetadata) Columns() []Column {
+	columns, _ := InferJSONColumns(gm.eventStruct, GlueMappings...)
+	if gm.dataType == models.RuleData { // append the columns added by the rule engine
+		columns = append(columns, RuleMatchColumns...)
+	}
+	return columns
+}
+
+// jsonSerDeInfo returns the SerDe of JSON tables and partitions
+func (gm *GlueTableMetadata) jsonSerDeInfo() *glue.SerDeInfo {
+	columns, structFieldNames := InferJSONColumns(gm.eventStruct, GlueMappings...)
+	if gm.dataType == models.RuleData {
+		columns = append(columns, RuleMatchColumns...)
+	}
+
 	// Need to be case sensitive to deal with columns that have same name but different casing
 	descriptorParameters := map[string]*string{
 		"serialization.format": aws.String("1"),
@@ -166,29 +240,17 @@ func (gm *GlueTableMetadata) glueTableInput(bucketName string) *glue.TableInput
 	}
 
 	// Add mapping for column names. This is required when columns are case sensitive
-	for _, column := range glueColumns {
-		descriptorParameters[fmt.Sprintf("mapping.%s", strings.ToLower(*column.Name))] = column.Name
+	for i := range columns {
+		descriptorParameters[fmt.Sprintf("mapping.%s", strings.ToLower(columns[i].Name))] = &columns[i].Name
 	}
 	// Add mapping for field names inside columns names. This is required when columns are case sensitive
 	for _, name := range structFieldNames {
 		descriptorParameters[fmt.Sprintf("mapping.%s", strings.ToLower(name))] = box.String(name)
 	}
 
-	return &glue.TableInput{
-		Name:          &gm.tableName,
-		Description:   &gm.description,
-		PartitionKeys: partitionColumns,
-		StorageDescriptor: &glue.StorageDescriptor{ // configure as JSON
-			Columns:      glueColumns,
-			Location:     aws.String("s3://" + bucketName + "/" + gm.prefix),
-			InputFormat:  aws.String("org.apache.hadoop.mapred.TextInputFormat"),
-			OutputFormat: aws.String("org.apache.hadoop.hive.ql.io.HiveIgnoreKeyTextOutputFormat"),
-			SerdeInfo: &glue.SerDeInfo{
-				SerializationLibrary: aws.String("org.openx.data.jsonserde.JsonSerDe"),
-				Parameters:           descriptorParameters,
-			},
-		},
-		TableType: aws.String("EXTERNAL_TABLE"),
+	return &glue.SerDeInfo{
+		SerializationLibrary: aws.String("org.openx.data.jsonserde.JsonSerDe"),
+		Parameters:           descriptorParameters,
 	}
 }
 
@@ -296,7 +358,11 @@ func (gm *GlueTableMetadata) SyncPartitions(glueClient glueiface.GlueAPI, s3Clie
 				storageDescriptor.Columns = columns
 				// we need to update the SerDeInfo for JSON partitions to get the column mappings
 				if IsJSONPartition(&storageDescriptor) {
-					storageDescriptor.SerdeInfo = tableOutput.Table.StorageDescriptor.SerdeInfo
+					if IsJSONPartition(tableOutput.Table.StorageDescriptor) {
+						storageDescriptor.SerdeInfo = tableOutput.Table.StorageDescriptor.SerdeInfo
+					} else { // partitions written before the table was switched to Parquet
+						storageDescriptor.SerdeInfo = gm.jsonSerDeInfo()
+					}
 				}
 				_, err = UpdatePartition(glueClient, gm.databaseName, gm.tableName, values,
 					&storageDescriptor, nil)
@@ -335,6 +401,21 @@ func (gm *GlueTableMetadata) SyncPartitions(glueClient glueiface.GlueAPI, s3Clie
 	return nextTimeBin, <-errChan
 }
 
+// CreatePartition creates the partition of time t with the storage format of the table, JSON or Parquet
+func (gm *GlueTableMetadata) CreatePartition(client glueiface.GlueAPI, t time.Time) (created bool, err error) {
+	// inherit StorageDescriptor from table
+	tableOutput, err := GetTable(client, gm.databaseName, gm.tableName)
+	if err != nil {
+		return false, err
+	}
+
+	if !IsJSONPartition(tableOutput.Table.StorageDescriptor) && !IsParquetPartition(tableOutput.Table.StorageDescriptor) {
+		return false, errors.Errorf("not a JSON or Parquet table: %#v", *tableOutput.Table.StorageDescriptor)
+	}
+
+	return gm.createPartition(client, t, tableOutput)
+}
+
 func (gm *GlueTableMetadata) CreateJSONPartition(client glueiface.GlueAPI, t time.Time) (created bool, err error) {
 	// inherit StorageDescriptor from table
 	tableOutput, err := GetTable(client, gm.databaseName, gm.tableName)
@@ -147,6 +147,89 @@ func TestGlueTableMetadataSignature(t *testing.T) {
 	assert.Equal(t, "5a3ca736985afab5ba83361dcb17ecb4fd1ea5632b11674137e6887148556e67", sig)
 }
 
+func TestGlueTableMetadataStorageFormat(t *testing.T) {
+	SetParquetLogTypes("My.Logs.Type")
+	defer SetParquetLogTypes()
+
+	type testEvent struct {
+		Name string `json:"name" description:"test field"`
+	}
+	gm := NewGlueTableMetadata(models.LogData, "My.Logs.Type", "description", GlueTableHourly, testEvent{})
+	assert.Equal(t, StorageFormatParquet, gm.StorageFormat())
+	tableInput := gm.glueTableInput(metadataTestBucket)
+	assert.True(t, IsParquetPartition(tableInput.StorageDescriptor))
+	assert.False(t, IsJSONPartition(tableInput.StorageDescriptor))
+	assert.Equal(t, "org.apache.hadoop.hive.ql.io.parquet.MapredParquetInputFormat", *tableInput.StorageDescriptor.InputFormat)
+	assert.Equal(t, "org.apache.hadoop.hive.ql.io.parquet.MapredParquetOutputFormat", *tableInput.StorageDescriptor.OutputFormat)
+	assert.Equal(t, "name", *tableInput.StorageDescriptor.Columns[0].Name)
+	parquetSig, err := gm.Signature()
+	require.NoError(t, err)
+
+	// rule matches are always JSON
+	ruleTable := gm.RuleTable()
+	assert.Equal(t, StorageFormatJSON, ruleTable.StorageFormat())
+	assert.True(t, IsJSONPartition(ruleTable.glueTableInput(metadataTestBucket).StorageDescriptor))
+
+	// the signature changes with the format so that tables are updated
+	SetParquetLogTypes()
+	assert.Equal(t, StorageFormatJSON, gm.StorageFormat())
+	tableInput = gm.glueTableInput(metadataTestBucket)
+	assert.True(t, IsJSONPartition(tableInput.StorageDescriptor))
+	assert.Equal(t, aws.String("name"), tableInput.StorageDescriptor.SerdeInfo.Parameters["mapping.name"])
+	jsonSig, err := gm.Signature()
+	require.NoError(t, err)
+	assert.NotEqual(t, parquetSig, jsonSig)
+}
+
+func TestCreatePartitionParquet(t *testing.T) {
+	gm := NewGlueTableMetadata(models.LogData, "Test.Logs", "Description", GlueTableHourly, partitionTestEvent{})
+
+	parquetStorageDescriptor := *testStorageDescriptor
+	parquetStorageDescriptor.SerdeInfo = &glue.SerDeInfo{
+		SerializationLibrary: aws.String("org.apache.hadoop.hive.ql.io.parquet.serde.ParquetHiveSerDe"),
+	}
+	getTableOutput := &glue.GetTableOutput{
+		Table: &glue.TableData{
+			StorageDescriptor: &parquetStorageDescriptor,
+		},
+	}
+	glueClient := &testutils.GlueMock{}
+	glueClient.On("GetTable", mock.Anything).Return(getTableOutput, nil).Once()
+	glueClient.On("CreatePartition", mock.Anything).Return(testCreatePartitionOutput, nil).Once()
+	created, err := gm.CreatePartition(glueClient, refTime)
+	require.NoError(t, err)
+	assert.True(t, created)
+	glueClient.AssertExpectations(t)
+
+	input := glueClient.Calls[1].Arguments.Get(0).(*glue.CreatePartitionInput)
+	assert.True(t, IsParquetPartition(input.PartitionInput.StorageDescriptor))
+	assert.Equal(t, "s3://"+metadataTestBucket+"/logs/test_logs/year=2020/month=01/day=03/hour=01/",
+		*input.PartitionInput.StorageDescriptor.Location)
+
+	// a JSON only table cannot create Parquet partitions
+	glueClient.On("GetTable", mock.Anything).Return(getTableOutput, nil).Once()
+	created, err = gm.CreateJSONPartition(glueClient, refTime)
+	assert.Error(t, err)
+	assert.False(t, created)
+}
+
+func TestCreatePartitionUnknownFormat(t *testing.T) {
+	gm := NewGlueTableMetadata(models.LogData, "Test.Logs", "Description", GlueTableHourly, partitionTestEvent{})
+
+	csvStorageDescriptor := *testStorageDescriptor
+	csvStorageDescriptor.SerdeInfo = &glue.SerDeInfo{
+		SerializationLibrary: aws.String("org.apache.hadoop.hive.serde2.OpenCSVSerde"),
+	}
+	glueClient := &testutils.GlueMock{}
+	glueClient.On("GetTable", mock.Anything).Return(&glue.GetTableOutput{
+		Table: &glue.TableData{StorageDescriptor: &csvStorageDescriptor},
+	}, nil).Once()
+	created, err := gm.CreatePartition(glueClient, refTime)
+	assert.Error(t, err)
+	assert.False(t, created)
+	glueClient.AssertExpectations(t)
+}
+
 func TestCreateJSONPartition(t *testing.T) {
 	gm := NewGlueTableMetadata(models.LogData, "Test.Logs", "Description", GlueTableHourly, partitionTestEvent{})
 
@@ -221,6 +304,41 @@ func TestSyncPartitions(t *testing.T) {
 	}
 }
 
+func TestSyncPartitionsParquetTable(t *testing.T) {
+	var startDate time.Time // default unset
+	gm := NewGlueTableMetadata(models.LogData, "Test.Logs", "Description", GlueTableHourly, partitionTestEvent{})
+
+	parquetStorageDescriptor := *syncStorageDescriptor
+	parquetStorageDescriptor.SerdeInfo = &glue.SerDeInfo{
+		SerializationLibrary: aws.String("org.apache.hadoop.hive.ql.io.parquet.serde.ParquetHiveSerDe"),
+	}
+	getTableOutput := &glue.GetTableOutput{
+		Table: &glue.TableData{
+			CreateTime:        syncGetTableOutput.Table.CreateTime,
+			StorageDescriptor: &parquetStorageDescriptor,
+		},
+	}
+
+	// existing partitions are JSON, they keep a JSON SerDe
+	glueClient := &testutils.GlueMock{}
+	glueClient.On("GetTable", mock.Anything).Return(getTableOutput, nil).Once()
+	glueClient.On("GetPartition", mock.Anything).Return(testGetPartitionOutput, nil).Times(24)
+	glueClient.On("UpdatePartition", mock.Anything).Return(testUpdatePartitionOutput, nil).Times(24)
+	s3Client := &testutils.S3Mock{}
+	_, err := gm.SyncPartitions(glueClient, s3Client, startDate, nil)
+	assert.NoError(t, err)
+	glueClient.AssertExpectations(t)
+
+	for _, updateCall := range glueClient.Calls {
+		switch updateInput := updateCall.Arguments.Get(0).(type) {
+		case *glue.UpdatePartitionInput:
+			storageDescriptor := updateInput.PartitionInput.StorageDescriptor
+			assert.Equal(t, parquetStorageDescriptor.Columns, storageDescriptor.Columns)
+			assert.Equal(t, gm.jsonSerDeInfo(), storageDescriptor.SerdeInfo)
+		}
+	}
+}
+
 func TestSyncPartitionsPartitionDoesntExistAndNoData(t *testing.T) {
 	var startDate time.Time // default unset
 	gm := NewGlueTableMetadata(models.LogData, "Test.Logs", "Description", GlueTableHourly, partitionTestEvent{})
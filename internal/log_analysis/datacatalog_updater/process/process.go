@@ -60,7 +60,7 @@ func SQS(event events.SQSEvent) error {
 			}
 
 			// attempt to create the partition
-			_, err = gluePartition.GetGlueTableMetadata().CreateJSONPartition(glueClient, gluePartition.GetTime())
+			_, err = gluePartition.GetGlueTableMetadata().CreatePartition(glueClient, gluePartition.GetTime())
 			if err != nil {
 				return errors.Wrapf(err, "failed to create partition %#v", notification)
 			}
@@ -0,0 +1,47 @@
+package config
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/aws/aws-sdk-go/service/lambda/lambdaiface"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager/s3manageriface"
+)
+
+var (
+	awsSession   *session.Session
+	S3Uploader   s3manageriface.UploaderAPI
+	LambdaClient lambdaiface.LambdaAPI
+)
+
+const (
+	SourceAPIFunctionName = "panther-source-api"
+	MaxRetries            = 10
+)
+
+// Setup builds the AWS clients.
+func Setup() {
+	awsSession = session.Must(session.NewSession(aws.NewConfig().WithMaxRetries(MaxRetries)))
+
+	S3Uploader = s3manager.NewUploader(awsSession)
+	LambdaClient = lambda.New(awsSession)
+}
@@ -0,0 +1,71 @@
+package ingest
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/panther-labs/panther/api/lambda/source/models"
+)
+
+const (
+	bearerPrefix    = "Bearer "
+	signaturePrefix = "sha256="
+)
+
+// authenticate checks the credentials of a request to an HTTP source
+func authenticate(config *models.HTTPConfig, header http.Header, body []byte) bool {
+	if config.AuthSecret == "" {
+		// Never accept requests for a source without a secret
+		return false
+	}
+	switch config.AuthType {
+	case models.HTTPAuthBearer:
+		token := header.Get("Authorization")
+		if !strings.HasPrefix(token, bearerPrefix) {
+			return false
+		}
+		token = strings.TrimPrefix(token, bearerPrefix)
+		return subtle.ConstantTimeCompare([]byte(token), []byte(config.AuthSecret)) == 1
+	case models.HTTPAuthHMAC:
+		headerName := config.SignatureHeader
+		if headerName == "" {
+			headerName = models.DefaultHTTPSignatureHeader
+		}
+		signature, err := hex.DecodeString(strings.TrimPrefix(header.Get(headerName), signaturePrefix))
+		if err != nil || len(signature) == 0 {
+			return false
+		}
+		return hmac.Equal(signature, Sign([]byte(config.AuthSecret), body))
+	default:
+		return false
+	}
+}
+
+// Sign computes the HMAC-SHA256 signature of a request body
+func Sign(secret, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(body)
+	return mac.Sum(nil)
+}
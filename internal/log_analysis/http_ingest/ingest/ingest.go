@@ -0,0 +1,175 @@
+package ingest
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager/s3manageriface"
+	"github.com/google/uuid"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/panther-labs/panther/api/lambda/source/models"
+	"github.com/panther-labs/panther/internal/log_analysis/message_forwarder/forwarder"
+)
+
+// MaxBodySize is the maximum size of a request body, the maximum Lambda payload is 6MB
+const MaxBodySize = 6 * 1024 * 1024
+
+// Handler serves POST requests to /{integrationId} and stores the posted events
+// in the S3 prefix of the HTTP source, wrapped in the same envelope as the messages of SQS sources.
+//
+// The body of a request can either be a JSON array, in which case each element is a separate event,
+// or newline delimited events.
+type Handler struct {
+	Sources  *Sources
+	Uploader s3manageriface.UploaderAPI
+}
+
+// Response is the body of successful responses
+type Response struct {
+	Events int `json:"events"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	now := time.Now().UTC()
+	integrationID := path.Base(r.URL.Path)
+	source := h.Sources.Get(integrationID, now)
+	if source == nil {
+		http.Error(w, "source not found", http.StatusNotFound)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodySize))
+	if err != nil {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if !authenticate(source.HTTPConfig, r.Header, body) {
+		zap.L().Warn("unauthorized request", zap.String("integrationId", integrationID))
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	events, err := SplitEvents(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(events) == 0 {
+		http.Error(w, "no events in request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.upload(source, events, now); err != nil {
+		zap.L().Error("failed to store events",
+			zap.String("integrationId", integrationID),
+			zap.Error(err))
+		http.Error(w, "failed to store events", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = jsoniter.NewEncoder(w).Encode(Response{Events: len(events)})
+}
+
+// upload stores a batch of events as a single gzipped object of forwarder messages
+func (h *Handler) upload(source *models.SourceIntegration, events []string, now time.Time) error {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	for _, event := range events {
+		data, err := jsoniter.Marshal(forwarder.Message{
+			Payload:             event,
+			SourceIntegrationID: source.IntegrationID,
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal event")
+		}
+		data = append(data, forwarder.RecordDelimiter)
+		if _, err := writer.Write(data); err != nil {
+			return errors.Wrap(err, "failed to compress events")
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return errors.Wrap(err, "failed to compress events")
+	}
+
+	_, err := h.Uploader.Upload(&s3manager.UploadInput{
+		Bucket:          aws.String(source.HTTPConfig.S3Bucket),
+		Key:             aws.String(objectKey(source.HTTPConfig.S3Prefix, now)),
+		Body:            &buffer,
+		ContentEncoding: aws.String("gzip"),
+		ContentType:     aws.String("application/json"),
+	})
+	return errors.Wrap(err, "failed to upload events to S3")
+}
+
+// objectKey returns a unique key for a batch, partitioned by hour like the keys of the Firehose of SQS sources
+func objectKey(prefix string, now time.Time) string {
+	return fmt.Sprintf("%s%s/%s-%s.json.gz", prefix, now.Format("2006/01/02/15"), now.Format("20060102T150405Z"), uuid.New())
+}
+
+// SplitEvents splits a request body into events.
+// A JSON array is split into its elements, anything else into its non-empty lines.
+func SplitEvents(body []byte) ([]string, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var elements []json.RawMessage
+		if err := json.Unmarshal(body, &elements); err != nil {
+			return nil, errors.New("invalid JSON array in request body")
+		}
+		events := make([]string, 0, len(elements))
+		for _, element := range elements {
+			// Events need to fit in a single line for the log processor
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, element); err != nil {
+				return nil, errors.New("invalid JSON array in request body")
+			}
+			events = append(events, compact.String())
+		}
+		return events, nil
+	}
+
+	var events []string
+	for _, line := range bytes.Split(body, []byte{'\n'}) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		events = append(events, string(line))
+	}
+	return events, nil
+}
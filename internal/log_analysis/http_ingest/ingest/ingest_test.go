@@ -0,0 +1,276 @@
+package ingest
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/api/lambda/source/models"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/sources"
+	"github.com/panther-labs/panther/pkg/testutils"
+)
+
+const (
+	bearerSourceID = "45c378a7-2e36-4b12-8e16-2d3c49ff1371"
+	hmacSourceID   = "7b0a4e2b-93a2-4d8e-b5f1-30f4b9d0e6a2"
+	testSecret     = "0123456789abcdef0123456789abcdef"
+)
+
+var testSources = []*models.SourceIntegration{
+	{
+		SourceIntegrationMetadata: models.SourceIntegrationMetadata{
+			IntegrationID:   bearerSourceID,
+			IntegrationType: models.IntegrationTypeHTTP,
+			HTTPConfig: &models.HTTPConfig{
+				LogTypes:   []string{"Okta.SystemLog"},
+				AuthType:   models.HTTPAuthBearer,
+				AuthSecret: testSecret,
+				S3Bucket:   "input-data",
+				S3Prefix:   models.HTTPS3PrefixForIntegration(bearerSourceID),
+			},
+		},
+	},
+	{
+		SourceIntegrationMetadata: models.SourceIntegrationMetadata{
+			IntegrationID:   hmacSourceID,
+			IntegrationType: models.IntegrationTypeHTTP,
+			HTTPConfig: &models.HTTPConfig{
+				LogTypes:        []string{"GitHub.Audit"},
+				AuthType:        models.HTTPAuthHMAC,
+				AuthSecret:      testSecret,
+				SignatureHeader: "X-Hub-Signature-256",
+				S3Bucket:        "input-data",
+				S3Prefix:        models.HTTPS3PrefixForIntegration(hmacSourceID),
+			},
+		},
+	},
+}
+
+func newTestServer(t *testing.T) (*httptest.Server, *testutils.S3UploaderMock) {
+	uploader := &testutils.S3UploaderMock{}
+	handler := &Handler{
+		Sources: NewSources(func() ([]*models.SourceIntegration, error) {
+			return testSources, nil
+		}, time.Minute),
+		Uploader: uploader,
+	}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server, uploader
+}
+
+func post(t *testing.T, url string, body string, header http.Header) *http.Response {
+	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	require.NoError(t, err)
+	for name, values := range header {
+		request.Header[name] = values
+	}
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = response.Body.Close() })
+	return response
+}
+
+// readUpload reads the events of an uploaded object the same way the log processor does
+func readUpload(t *testing.T, input *s3manager.UploadInput) []string {
+	gzipReader, err := gzip.NewReader(input.Body)
+	require.NoError(t, err)
+	scanner := bufio.NewScanner(sources.NewMessageForwarderReader(gzipReader))
+	var events []string
+	for scanner.Scan() {
+		events = append(events, scanner.Text())
+	}
+	require.NoError(t, scanner.Err())
+	return events
+}
+
+func TestIngestBearer(t *testing.T) {
+	server, uploader := newTestServer(t)
+	uploader.On("Upload", mock.Anything, mock.Anything).Return(&s3manager.UploadOutput{}, nil).Once()
+
+	body := `[{"uuid": "1", "eventType": "user.session.start"},
+	{"uuid": "2",
+	 "eventType": "user.session.end"}]`
+	header := http.Header{"Authorization": []string{"Bearer " + testSecret}}
+	response := post(t, server.URL+"/"+bearerSourceID, body, header)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	responseBody, err := ioutil.ReadAll(response.Body)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"events":2}`, string(responseBody))
+
+	uploader.AssertExpectations(t)
+	input := uploader.Calls[0].Arguments.Get(0).(*s3manager.UploadInput)
+	require.Equal(t, "input-data", aws.StringValue(input.Bucket))
+	require.True(t, strings.HasPrefix(aws.StringValue(input.Key), "http/"+bearerSourceID+"/"))
+	require.True(t, strings.HasSuffix(aws.StringValue(input.Key), ".json.gz"))
+	require.Equal(t, []string{
+		`{"uuid":"1","eventType":"user.session.start"}`,
+		`{"uuid":"2","eventType":"user.session.end"}`,
+	}, readUpload(t, input))
+}
+
+func TestIngestHMAC(t *testing.T) {
+	server, uploader := newTestServer(t)
+	uploader.On("Upload", mock.Anything, mock.Anything).Return(&s3manager.UploadOutput{}, nil).Once()
+
+	body := "line one\r\n\nline two\n"
+	signature := hex.EncodeToString(Sign([]byte(testSecret), []byte(body)))
+	header := http.Header{"X-Hub-Signature-256": []string{"sha256=" + signature}}
+	response := post(t, server.URL+"/"+hmacSourceID, body, header)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	uploader.AssertExpectations(t)
+	input := uploader.Calls[0].Arguments.Get(0).(*s3manager.UploadInput)
+	require.Equal(t, []string{"line one", "line two"}, readUpload(t, input))
+}
+
+func TestIngestErrors(t *testing.T) {
+	server, uploader := newTestServer(t)
+	validBearer := http.Header{"Authorization": []string{"Bearer " + testSecret}}
+
+	testCases := []struct {
+		name       string
+		path       string
+		body       string
+		header     http.Header
+		statusCode int
+	}{
+		{"unknown source", "/c9ad3e5d-43b2-4fd0-8e14-4d1a4d2b1a8f", "event", validBearer, http.StatusNotFound},
+		{"missing token", "/" + bearerSourceID, "event", nil, http.StatusUnauthorized},
+		{"invalid token", "/" + bearerSourceID, "event",
+			http.Header{"Authorization": []string{"Bearer invalid"}}, http.StatusUnauthorized},
+		{"invalid signature", "/" + hmacSourceID, "event",
+			http.Header{"X-Hub-Signature-256": []string{"sha256=00ff"}}, http.StatusUnauthorized},
+		// The bearer token of a source is not valid as an HMAC signature
+		{"wrong auth type", "/" + hmacSourceID, "event", validBearer, http.StatusUnauthorized},
+		{"empty body", "/" + bearerSourceID, " \n ", validBearer, http.StatusBadRequest},
+		{"invalid array", "/" + bearerSourceID, "[{", validBearer, http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			response := post(t, server.URL+tc.path, tc.body, tc.header)
+			require.Equal(t, tc.statusCode, response.StatusCode)
+		})
+	}
+
+	response, err := http.Get(server.URL + "/" + bearerSourceID)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, response.StatusCode)
+
+	// Nothing should be stored for rejected requests
+	uploader.AssertNotCalled(t, "Upload", mock.Anything, mock.Anything)
+}
+
+func TestIngestUploadFailure(t *testing.T) {
+	server, uploader := newTestServer(t)
+	uploader.On("Upload", mock.Anything, mock.Anything).Return(&s3manager.UploadOutput{}, errors.New("failure")).Once()
+
+	header := http.Header{"Authorization": []string{"Bearer " + testSecret}}
+	response := post(t, server.URL+"/"+bearerSourceID, "event", header)
+	require.Equal(t, http.StatusInternalServerError, response.StatusCode)
+	uploader.AssertExpectations(t)
+}
+
+func TestSourcesRefresh(t *testing.T) {
+	calls := 0
+	available := testSources[:1]
+	cache := NewSources(func() ([]*models.SourceIntegration, error) {
+		calls++
+		return available, nil
+	}, time.Minute)
+
+	now := time.Now()
+	require.NotNil(t, cache.Get(bearerSourceID, now))
+	require.Equal(t, 1, calls)
+
+	// Unknown sources are looked up again, but not more often than minMissRefreshInterval
+	available = testSources
+	require.Nil(t, cache.Get(hmacSourceID, now.Add(time.Second)))
+	require.Equal(t, 1, calls)
+	require.NotNil(t, cache.Get(hmacSourceID, now.Add(minMissRefreshInterval)))
+	require.Equal(t, 2, calls)
+
+	// Stale sources are reloaded
+	available = nil
+	require.NotNil(t, cache.Get(bearerSourceID, now.Add(30*time.Second)))
+	require.Nil(t, cache.Get(bearerSourceID, now.Add(2*time.Minute)))
+}
+
+func TestServeLambda(t *testing.T) {
+	uploader := &testutils.S3UploaderMock{}
+	uploader.On("Upload", mock.Anything, mock.Anything).Return(&s3manager.UploadOutput{}, nil).Once()
+	handler := &Handler{
+		Sources: NewSources(func() ([]*models.SourceIntegration, error) {
+			return testSources, nil
+		}, time.Minute),
+		Uploader: uploader,
+	}
+
+	response, err := ServeLambda(context.Background(), handler, &events.APIGatewayProxyRequest{
+		HTTPMethod:      http.MethodPost,
+		Path:            "/http/" + bearerSourceID,
+		PathParameters:  map[string]string{"integrationId": bearerSourceID},
+		Headers:         map[string]string{"authorization": "Bearer " + testSecret},
+		Body:            "ZXZlbnQ=", // "event"
+		IsBase64Encoded: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "application/json", response.Headers["Content-Type"])
+	assert.JSONEq(t, `{"events":1}`, response.Body)
+	input := uploader.Calls[0].Arguments.Get(0).(*s3manager.UploadInput)
+	assert.Equal(t, []string{"event"}, readUpload(t, input))
+}
+
+func TestSplitEvents(t *testing.T) {
+	events, err := SplitEvents([]byte(` [] `))
+	require.NoError(t, err)
+	require.Empty(t, events)
+
+	events, err = SplitEvents([]byte("{\"a\": 1}\n{\"a\": 2}\n"))
+	require.NoError(t, err)
+	require.Equal(t, []string{`{"a": 1}`, `{"a": 2}`}, events)
+
+	events, err = SplitEvents([]byte(`["text", 1, {"nested": [1, 2]}]`))
+	require.NoError(t, err)
+	require.Equal(t, []string{`"text"`, `1`, `{"nested":[1,2]}`}, events)
+
+	_, err = SplitEvents(bytes.Repeat([]byte("["), 3))
+	require.Error(t, err)
+}
@@ -0,0 +1,99 @@
+package ingest
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/pkg/errors"
+)
+
+// ServeLambda serves an API Gateway proxy request with an http.Handler.
+// The integration id is read from the integrationId path parameter.
+func ServeLambda(ctx context.Context, handler http.Handler,
+	input *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+
+	body := []byte(input.Body)
+	if input.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(input.Body)
+		if err != nil {
+			return &events.APIGatewayProxyResponse{StatusCode: http.StatusBadRequest, Body: "invalid request body"}, nil
+		}
+		body = decoded
+	}
+
+	request, err := http.NewRequestWithContext(ctx, input.HTTPMethod,
+		"/"+input.PathParameters["integrationId"], bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build request")
+	}
+	for name, values := range input.MultiValueHeaders {
+		for _, value := range values {
+			request.Header.Add(name, value)
+		}
+	}
+	if len(input.MultiValueHeaders) == 0 {
+		for name, value := range input.Headers {
+			request.Header.Set(name, value)
+		}
+	}
+
+	w := &responseWriter{header: http.Header{}, statusCode: http.StatusOK}
+	handler.ServeHTTP(w, request)
+
+	headers := make(map[string]string, len(w.header))
+	for name, values := range w.header {
+		headers[name] = strings.Join(values, ",")
+	}
+	return &events.APIGatewayProxyResponse{
+		StatusCode: w.statusCode,
+		Headers:    headers,
+		Body:       w.body.String(),
+	}, nil
+}
+
+// responseWriter buffers a response for API Gateway
+type responseWriter struct {
+	header      http.Header
+	body        bytes.Buffer
+	statusCode  int
+	wroteHeader bool
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(p)
+}
+
+func (w *responseWriter) WriteHeader(statusCode int) {
+	if w.wroteHeader {
+		return
+	}
+	w.statusCode = statusCode
+	w.wroteHeader = true
+}
@@ -0,0 +1,115 @@
+package ingest
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/lambda/lambdaiface"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/panther-labs/panther/api/lambda/source/models"
+	"github.com/panther-labs/panther/pkg/genericapi"
+)
+
+const (
+	// DefaultSourcesRefreshInterval is how often the sources are reloaded, so that updated secrets take effect
+	DefaultSourcesRefreshInterval = time.Minute
+	// Minimum time between reloads triggered by an unknown integration id
+	minMissRefreshInterval = 10 * time.Second
+)
+
+// ListSources returns the configured HTTP sources
+type ListSources func() ([]*models.SourceIntegration, error)
+
+// ListSourcesFromAPI lists the HTTP sources using the source-api Lambda
+func ListSourcesFromAPI(client lambdaiface.LambdaAPI, functionName string) ListSources {
+	return func() ([]*models.SourceIntegration, error) {
+		input := &models.LambdaInput{ListIntegrations: &models.ListIntegrationsInput{
+			IntegrationType: aws.String(models.IntegrationTypeHTTP),
+		}}
+		var output []*models.SourceIntegration
+		if err := genericapi.Invoke(client, functionName, input, &output); err != nil {
+			return nil, errors.Wrap(err, "failed to fetch available integrations")
+		}
+		return output, nil
+	}
+}
+
+// Sources is a cache of the HTTP sources by integration id.
+// It is safe to use from multiple goroutines.
+type Sources struct {
+	list            ListSources
+	refreshInterval time.Duration
+
+	mu          sync.Mutex
+	byID        map[string]*models.SourceIntegration
+	lastRefresh time.Time
+}
+
+// NewSources creates a cache of HTTP sources that reloads them every refreshInterval
+func NewSources(list ListSources, refreshInterval time.Duration) *Sources {
+	if refreshInterval <= 0 {
+		refreshInterval = DefaultSourcesRefreshInterval
+	}
+	return &Sources{
+		list:            list,
+		refreshInterval: refreshInterval,
+	}
+}
+
+// Get returns the HTTP source with the provided id or nil if there is no such source.
+// Sources are reloaded when the cache is stale or when the id is unknown,
+// at most once every minMissRefreshInterval for unknown ids.
+func (s *Sources) Get(integrationID string, now time.Time) *models.SourceIntegration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastRefresh) >= s.refreshInterval {
+		s.refresh(now)
+	}
+	source, found := s.byID[integrationID]
+	if !found && now.Sub(s.lastRefresh) >= minMissRefreshInterval {
+		s.refresh(now)
+		source = s.byID[integrationID]
+	}
+	return source
+}
+
+func (s *Sources) refresh(now time.Time) {
+	// Failed attempts also count as a refresh so that we don't call the API on every request
+	s.lastRefresh = now
+	sources, err := s.list()
+	if err != nil {
+		// Keep serving the sources we already know of
+		zap.L().Warn("failed to refresh http sources", zap.Error(err))
+		return
+	}
+	byID := make(map[string]*models.SourceIntegration, len(sources))
+	for _, source := range sources {
+		if source.IntegrationType != models.IntegrationTypeHTTP || source.HTTPConfig == nil {
+			continue
+		}
+		byID[source.IntegrationID] = source
+	}
+	s.byID = byID
+}
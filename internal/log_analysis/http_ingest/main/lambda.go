@@ -0,0 +1,63 @@
+package main
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/panther-labs/panther/internal/log_analysis/http_ingest/config"
+	"github.com/panther-labs/panther/internal/log_analysis/http_ingest/ingest"
+	"github.com/panther-labs/panther/pkg/lambdalogger"
+	"github.com/panther-labs/panther/pkg/oplog"
+)
+
+var handler *ingest.Handler
+
+func main() {
+	config.Setup()
+	handler = &ingest.Handler{
+		Sources: ingest.NewSources(
+			ingest.ListSourcesFromAPI(config.LambdaClient, config.SourceAPIFunctionName),
+			ingest.DefaultSourcesRefreshInterval,
+		),
+		Uploader: config.S3Uploader,
+	}
+	lambda.Start(handle)
+}
+
+func handle(ctx context.Context, input *events.APIGatewayProxyRequest) (result *events.APIGatewayProxyResponse, err error) {
+	lc, _ := lambdalogger.ConfigureGlobal(ctx, nil)
+	operation := oplog.NewManager("log_analysis", "http_ingest").
+		Start(lc.InvokedFunctionArn, zap.String("service", "lambda")).
+		WithMemUsed(lambdacontext.MemoryLimitInMB)
+	defer func() {
+		if err == nil && result != nil && result.StatusCode >= 500 {
+			operation.Stop().LogError(errors.New("server error"), zap.Int("statusCode", result.StatusCode))
+			return
+		}
+		operation.Stop().Log(err)
+	}()
+	return ingest.ServeLambda(ctx, handler, input)
+}
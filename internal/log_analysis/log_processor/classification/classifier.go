@@ -47,6 +47,32 @@ type ClassifierResult struct {
 	Events []*parsers.Result
 	// LogType is the identified type of the log
 	LogType *string
+	// ParserErrors are the errors of the parsers that failed to parse the log, in the order they were tried.
+	// If the log was classified only parser panics are kept.
+	ParserErrors []*ParserError
+}
+
+// ParserError is the error of a parser that failed to parse a log
+type ParserError struct {
+	LogType string
+	Err     error
+	// Panic is true if the parser panicked
+	Panic bool
+}
+
+// Panicked returns true if any of the parsers panicked
+func (r *ClassifierResult) Panicked() bool {
+	for _, parserErr := range r.ParserErrors {
+		if parserErr.Panic {
+			return true
+		}
+	}
+	return false
+}
+
+// errParserPanic is returned by safeLogParse when a parser panics
+type errParserPanic struct {
+	error
 }
 
 // NewClassifier returns a new instance of a ClassifierAPI implementation
@@ -57,9 +83,21 @@ func NewClassifier(parsers map[string]parsers.Interface) ClassifierAPI {
 	}
 }
 
+// NewClassifierWithFallback returns a ClassifierAPI that tries the fallback parsers
+// only if none of the parsers succeeds.
+func NewClassifierWithFallback(parsers, fallback map[string]parsers.Interface) ClassifierAPI {
+	return &Classifier{
+		parsers:         NewParserPriorityQueue(parsers),
+		fallbackParsers: NewParserPriorityQueue(fallback),
+		parserStats:     make(map[string]*ParserStats),
+	}
+}
+
 // Classifier is the struct responsible for classifying logs
 type Classifier struct {
 	parsers *ParserPriorityQueue
+	// fallbackParsers are tried if none of the parsers succeeds, can be nil
+	fallbackParsers *ParserPriorityQueue
 	// aggregate stats
 	stats ClassifierStats
 	// per-parser stats, map of LogType -> stats
@@ -78,7 +116,7 @@ func (c *Classifier) ParserStats() map[string]*ParserStats {
 func safeLogParse(logType string, parser parsers.Interface, log string) (results []*parsers.Result, err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			err = errors.Errorf("parser %q panic: %v", logType, r)
+			err = &errParserPanic{errors.Errorf("parser %q panic: %v", logType, r)}
 			results = nil
 		}
 	}()
@@ -92,8 +130,6 @@ func safeLogParse(logType string, parser parsers.Interface, log string) (results
 // Classify attempts to classify the provided log line
 func (c *Classifier) Classify(log string) *ClassifierResult {
 	startClassify := time.Now().UTC()
-	// Slice containing the popped queue items
-	var popped []interface{}
 	result := &ClassifierResult{}
 
 	if len(log) == 0 { // likely empty file, nothing to do
@@ -121,8 +157,29 @@ func (c *Classifier) Classify(log string) *ClassifierResult {
 		return result
 	}
 
-	for c.parsers.Len() > 0 {
-		currentItem := c.parsers.Peek()
+	c.classify(c.parsers, log, result)
+	if result.LogType == nil && c.fallbackParsers != nil {
+		c.classify(c.fallbackParsers, log, result)
+	}
+	if result.LogType != nil && result.ParserErrors != nil {
+		// Parsers failing on logs of other types is expected, only panics are worth reporting
+		var panics []*ParserError
+		for _, parserErr := range result.ParserErrors {
+			if parserErr.Panic {
+				panics = append(panics, parserErr)
+			}
+		}
+		result.ParserErrors = panics
+	}
+	return result
+}
+
+// classify tries the parsers in a queue in priority order and sets the result of the first one that succeeds
+func (c *Classifier) classify(queue *ParserPriorityQueue, log string, result *ClassifierResult) {
+	// Slice containing the popped queue items
+	var popped []interface{}
+	for queue.Len() > 0 {
+		currentItem := queue.Peek()
 
 		startParseTime := time.Now().UTC()
 		logType := currentItem.logType
@@ -132,8 +189,14 @@ func (c *Classifier) Classify(log string) *ClassifierResult {
 		// Parser failed to parse event
 		if err != nil {
 			zap.L().Debug("failed to parse event", zap.String("expectedLogType", logType), zap.Error(err))
+			_, panicked := err.(*errParserPanic)
+			result.ParserErrors = append(result.ParserErrors, &ParserError{
+				LogType: logType,
+				Err:     err,
+				Panic:   panicked,
+			})
 			// Removing parser from queue
-			popped = append(popped, heap.Pop(c.parsers))
+			popped = append(popped, heap.Pop(queue))
 			// Increasing penalty of the parser
 			// Due to increased penalty the parser will be lower priority in the queue
 			currentItem.penalty++
@@ -167,9 +230,8 @@ func (c *Classifier) Classify(log string) *ClassifierResult {
 
 	// Put back the popped items to the ParserPriorityQueue.
 	for _, item := range popped {
-		heap.Push(c.parsers, item)
+		heap.Push(queue, item)
 	}
-	return result
 }
 
 // aggregate stats
@@ -188,5 +250,9 @@ type ParserStats struct {
 	BytesProcessedCount    uint64 // input bytes
 	LogLineCount           uint64 // input records
 	EventCount             uint64 // output records
+	FilteredEventCount     uint64 // output records dropped by filter rules
+	SampledOutEventCount   uint64 // output records dropped by sample rules
+	RoutedEventCount       uint64 // output records stored apart from the data lake by route rules
+	DuplicateEventCount    uint64 // output records dropped as repeats within the dedup window
 	LogType                string
 }
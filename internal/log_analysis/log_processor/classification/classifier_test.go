@@ -102,6 +102,22 @@ func TestClassifyRespectsPriorityOfParsers(t *testing.T) {
 	require.Nil(t, classifier.ParserStats()["fail2"])
 }
 
+func TestClassifyTriesParsersInLogTypeOrder(t *testing.T) {
+	logLine := "log"
+	for i := 0; i < 10; i++ {
+		classifier := NewClassifier(map[string]parsers.Interface{
+			"c": testutil.ParserConfig{logLine: errors.New("fail")}.Parser(),
+			"a": testutil.ParserConfig{logLine: errors.New("fail")}.Parser(),
+			"b": testutil.ParserConfig{logLine: errors.New("fail")}.Parser(),
+		})
+		result := classifier.Classify(logLine)
+		require.Len(t, result.ParserErrors, 3)
+		for j, logType := range []string{"a", "b", "c"} {
+			require.Equal(t, logType, result.ParserErrors[j].LogType)
+		}
+	}
+}
+
 func TestClassifyNoMatch(t *testing.T) {
 	logLine := "log"
 	failingParser := testutil.ParserConfig{
@@ -124,11 +140,55 @@ func TestClassifyNoMatch(t *testing.T) {
 	expectedStats.ClassifyTimeMicroseconds = classifier.Stats().ClassifyTimeMicroseconds
 	require.Equal(t, expectedStats, classifier.Stats())
 
-	require.Equal(t, &ClassifierResult{}, result)
+	require.Nil(t, result.LogType)
+	require.Nil(t, result.Events)
+	require.Len(t, result.ParserErrors, 1)
+	require.Equal(t, "failure", result.ParserErrors[0].LogType)
+	require.EqualError(t, result.ParserErrors[0].Err, "fail")
+	require.False(t, result.Panicked())
 	failingParser.AssertNumberOfCalls(t, "Parse", 1)
 	require.Nil(t, classifier.ParserStats()["failure"])
 }
 
+func TestClassifyWithFallback(t *testing.T) {
+	hintedLine, otherLine := "hinted", "other"
+	hintedResult := &parsers.Result{
+		LogType: "hinted",
+		JSON:    []byte(`{"p_log_type":"hinted"}`),
+	}
+	otherResult := &parsers.Result{
+		LogType: "other",
+		JSON:    []byte(`{"p_log_type":"other"}`),
+	}
+	hintedParser := testutil.ParserConfig{
+		hintedLine: hintedResult,
+		otherLine:  errors.New("fail"),
+	}.Parser()
+	otherParser := testutil.ParserConfig{
+		otherLine: otherResult,
+	}.Parser()
+
+	classifier := NewClassifierWithFallback(map[string]parsers.Interface{
+		"hinted": hintedParser,
+	}, map[string]parsers.Interface{
+		"other": otherParser,
+	})
+
+	// fallback parsers are not tried if a parser succeeds
+	result := classifier.Classify(hintedLine)
+	require.Equal(t, &ClassifierResult{Events: []*parsers.Result{hintedResult}, LogType: box.String("hinted")}, result)
+	otherParser.AssertNotCalled(t, "Parse", hintedLine)
+
+	result = classifier.Classify(otherLine)
+	require.Equal(t, &ClassifierResult{Events: []*parsers.Result{otherResult}, LogType: box.String("other")}, result)
+	hintedParser.AssertNumberOfCalls(t, "Parse", 2)
+	otherParser.AssertNumberOfCalls(t, "Parse", 1)
+
+	require.Equal(t, uint64(2), classifier.Stats().SuccessfullyClassifiedCount)
+	require.Equal(t, uint64(1), classifier.ParserStats()["hinted"].LogLineCount)
+	require.Equal(t, uint64(1), classifier.ParserStats()["other"].LogLineCount)
+}
+
 func TestClassifyParserPanic(t *testing.T) {
 	// uncomment to see the logs produced
 	/*
@@ -160,10 +220,41 @@ func TestClassifyParserPanic(t *testing.T) {
 	expectedStats.ClassifyTimeMicroseconds = classifier.Stats().ClassifyTimeMicroseconds
 	require.Equal(t, expectedStats, classifier.Stats())
 
-	require.Equal(t, &ClassifierResult{}, result)
+	require.Nil(t, result.LogType)
+	require.Nil(t, result.Events)
+	require.Len(t, result.ParserErrors, 1)
+	require.Equal(t, "panic", result.ParserErrors[0].LogType)
+	require.Contains(t, result.ParserErrors[0].Err.Error(), "test parser panic")
+	require.True(t, result.Panicked())
 	panicParser.AssertNumberOfCalls(t, "Parse", 1)
 }
 
+func TestClassifyKeepsPanicsOfOtherParsers(t *testing.T) {
+	logLine := "log"
+	result := &parsers.Result{LogType: "success", JSON: []byte(`{}`)}
+	panicParser := &testutil.MockParser{}
+	panicParser.On("Parse", mock.Anything).Run(func(args mock.Arguments) { panic("test parser panic") })
+	failingParser := testutil.ParserConfig{logLine: errors.New("fail")}.Parser()
+	successParser := testutil.ParserConfig{logLine: result}.Parser()
+	classifier := NewClassifier(map[string]parsers.Interface{
+		"panic":   panicParser,
+		"failure": failingParser,
+		"success": successParser,
+	})
+
+	for i := 0; i < 3; i++ {
+		classifierResult := classifier.Classify(logLine)
+		require.Equal(t, box.String("success"), classifierResult.LogType)
+		// errors of parsers failing are dropped, panics are kept
+		for _, parserErr := range classifierResult.ParserErrors {
+			require.True(t, parserErr.Panic)
+			require.Equal(t, "panic", parserErr.LogType)
+		}
+	}
+	// the panicking parser is tried at most once before the successful parser takes priority
+	panicParser.RequireLessOrEqualNumberOfCalls(t, "Parse", 1)
+}
+
 func TestClassifyParserReturningEmptyResults(t *testing.T) {
 	parser := &testutil.MockParser{}
 	parser.On("Parse", mock.Anything).Return([]*parsers.Result{}, nil).Once()
@@ -19,6 +19,8 @@ package classification
  */
 
 import (
+	"container/heap"
+
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
 )
 
@@ -34,7 +36,7 @@ func NewParserPriorityQueue(parsers map[string]parsers.Interface) *ParserPriorit
 }
 
 // initialize adds all registered parsers to the priority queue
-// All parsers have the same priority
+// All parsers have the same priority, they are tried in the order of their log types
 func (q *ParserPriorityQueue) initialize(parsers map[string]parsers.Interface) {
 	for logType, parser := range parsers {
 		q.items = append(q.items, &ParserQueueItem{
@@ -43,6 +45,7 @@ func (q *ParserPriorityQueue) initialize(parsers map[string]parsers.Interface) {
 			penalty: 1,
 		})
 	}
+	heap.Init(q)
 }
 
 // ParserQueueItem contains all the information needed to initialize a schema.
@@ -58,9 +61,13 @@ func (q *ParserPriorityQueue) Len() int {
 	return len(q.items)
 }
 
-// Less compares two items of the priority queue
+// Less compares two items of the priority queue, parsers with the same penalty are ordered by log type
+// so that classification does not depend on the order of a map
 func (q *ParserPriorityQueue) Less(i, j int) bool {
-	return q.items[i].penalty < q.items[j].penalty
+	if q.items[i].penalty != q.items[j].penalty {
+		return q.items[i].penalty < q.items[j].penalty
+	}
+	return q.items[i].logType < q.items[j].logType
 }
 
 // Swap swaps two items in the priority queue
@@ -32,6 +32,8 @@ import (
 	"github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
 	"github.com/kelseyhightower/envconfig"
+
+	"github.com/panther-labs/panther/internal/log_analysis/awsglue"
 )
 
 const (
@@ -55,6 +57,28 @@ type EnvConfig struct {
 	ProcessedDataBucket         string `required:"true" split_words:"true"`
 	SqsQueueURL                 string `required:"true" split_words:"true"`
 	SnsTopicARN                 string `required:"true" split_words:"true"`
+	// If true, logs that don't match the log types declared by their source are classified using all parsers
+	ClassificationFallback bool `split_words:"true"`
+	// Log types stored as Parquet in addition to JSON, see awsglue.SetParquetLogTypes
+	ParquetLogTypes []string `split_words:"true"`
+	// S3 URL or local path of the enrichment config, enrichment is disabled if empty
+	EnrichmentConfig string `split_words:"true"`
+	// S3 URL or local path of the redaction config, redaction is disabled if empty
+	RedactionConfig string `split_words:"true"`
+	// Name or ARN of the Secrets Manager secret holding the HMAC key of redaction hash rules
+	RedactionHMACKeySecret string `split_words:"true"`
+	// S3 URL or local path of the framing config, all logs are read line by line if empty
+	FramingConfig string `split_words:"true"`
+	// S3 URL or local path of the filter config, all events are stored if empty
+	FilterConfig string `split_words:"true"`
+	// S3 URL or local path of the flush config, the S3 destination uses its default limits if empty
+	FlushConfig string `split_words:"true"`
+	// Events whose raw line repeats within this many minutes are dropped, dedup is disabled if zero
+	DedupWindowMinutes int `split_words:"true"`
+	// DynamoDB table of the keys of recent events, keys are kept in memory if empty
+	DedupTable string `split_words:"true"`
+	// Log types that keep the fields not in their schema in the p_unknown_fields column, "*" for all log types
+	PreserveUnknownFields []string `split_words:"true"`
 }
 
 func Setup() {
@@ -68,6 +92,7 @@ func Setup() {
 	if err != nil {
 		panic(err)
 	}
+	awsglue.SetParquetLogTypes(Config.ParquetLogTypes...)
 }
 
 // DataStream represents a data stream that read by the processor
@@ -77,16 +102,45 @@ type DataStream struct {
 	// The log type if known
 	// If it is nil, it means the log type hasn't been identified yet
 	LogType *string
+	// Unpack is set if the data holds several streams, e.g. the members of an archive.
+	// It is called when the stream is processed, so that archives are copied to disk one at a time,
+	// and returns a function that releases the streams once they are processed.
+	Unpack func() (streams []*DataStream, release func(), err error)
+}
+
+// Close releases the resources of the reader (e.g. decoders), whether it was read or not
+func (s *DataStream) Close() {
+	if closer, ok := s.Reader.(io.Closer); ok {
+		_ = closer.Close()
+	}
 }
 
 // Used in a DataStream as meta data to describe the data
 type DataStreamHints struct {
 	S3 *S3DataStreamHints // if nil, no hint
+	// Set if the data are the log events of a CloudWatch Logs subscription
+	CloudWatchLogs *CloudWatchLogsDataStreamHints
+	// The log types declared by the source of the data, if empty any log type is possible
+	LogTypes []string
+	// The integration id of the source of the data, if known
+	SourceID string
+	// The S3 key prefix to store the events under, set when replaying historical data apart from live partitions
+	OutputPrefix string
 }
 
 // Used in a DataStreamHints as meta data to describe the S3 object backing the stream
 type S3DataStreamHints struct {
-	Bucket      string
-	Key         string
-	ContentType string
+	Bucket string
+	Key    string
+	// The name of the file inside the object if the object is an archive
+	ArchiveMember string
+	ContentType   string
+}
+
+// Used in a DataStreamHints as meta data to describe the CloudWatch Logs log stream of the data
+type CloudWatchLogsDataStreamHints struct {
+	// The AWS account id of the log group
+	Owner     string
+	LogGroup  string
+	LogStream string
 }
@@ -37,4 +37,56 @@ var (
 			Unit: metrics.UnitCount,
 		},
 	})
+
+	// BufferAgeAtFlushLogger reports how long the S3 destination buffered events before storing them
+	BufferAgeAtFlushLogger = metrics.MustStaticLogger([]metrics.DimensionSet{
+		{
+			"LogType",
+		},
+		{
+			"LogType",
+			"FlushReason",
+		},
+	}, []metrics.Metric{
+		{
+			Name: "BufferAgeAtFlush",
+			Unit: metrics.UnitSeconds,
+		},
+	})
+
+	// ArchivesTooLargeLogger reports S3 objects skipped because they are archives larger than the log processor can read
+	ArchivesTooLargeLogger = metrics.MustStaticLogger([]metrics.DimensionSet{
+		{
+			"ArchiveFormat",
+		},
+	}, []metrics.Metric{
+		{
+			Name: "ArchivesTooLarge",
+			Unit: metrics.UnitCount,
+		},
+	})
+
+	// ParquetRowsDroppedLogger reports events the S3 destination failed to add to Parquet files
+	ParquetRowsDroppedLogger = metrics.MustStaticLogger([]metrics.DimensionSet{
+		{
+			"LogType",
+		},
+	}, []metrics.Metric{
+		{
+			Name: "ParquetRowsDropped",
+			Unit: metrics.UnitCount,
+		},
+	})
+
+	// ParquetJSONBytesLogger reports the size of the hidden JSON objects stored next to Parquet files for the rules engine
+	ParquetJSONBytesLogger = metrics.MustStaticLogger([]metrics.DimensionSet{
+		{
+			"LogType",
+		},
+	}, []metrics.Metric{
+		{
+			Name: "ParquetJSONBytes",
+			Unit: metrics.UnitBytes,
+		},
+	})
 )
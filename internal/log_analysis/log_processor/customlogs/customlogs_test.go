@@ -0,0 +1,294 @@
+package customlogs
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/internal/log_analysis/awsglue"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/logtypes"
+)
+
+const testSpec = `
+name: Custom.MyApp
+description: Logs of my application
+fields:
+  - name: time
+    type: timestamp
+    timeFormat: unix_ms
+    isEventTime: true
+    description: The time of the request
+  - name: client_ip
+    type: string
+    required: true
+    indicators: [ip]
+  - name: "@host"
+    type: string
+    indicators: [domain]
+  - name: status
+    type: int
+  - name: bytes
+    type: bigint
+  - name: cached
+    type: boolean
+  - name: tags
+    type: array
+    element:
+      type: string
+  - name: user
+    type: object
+    fields:
+      - name: id
+        type: string
+        indicators: [username]
+      - name: email
+        type: string
+        indicators: [email, username]
+      - name: role
+        type: string
+        indicators: [cloud_principal]
+      - name: hashes
+        type: array
+        indicators: [sha256]
+        element:
+          type: string
+  - name: extra
+    type: json
+`
+
+func TestParseLog(t *testing.T) {
+	config := compileTestSpec(t, testSpec)
+	parser, err := config.NewParser(nil)
+	require.NoError(t, err)
+
+	log := `{
+		"time": 1577836861000,
+		"client_ip": "192.168.1.1",
+		"@host": "example.com",
+		"status": "200",
+		"bytes": 9007199254740993,
+		"cached": true,
+		"tags": ["a", "b"],
+		"user": {"id": "u1", "email": "u1@example.com", "role": "arn:aws:iam::123456789012:role/app", "hashes": ["abcd"]},
+		"extra": {"foo": [1, 2]},
+		"unknown": "ignored"
+	}`
+	results, err := parser.ParseLog(log)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, "Custom.MyApp", results[0].LogType)
+	require.Equal(t, time.Date(2020, 1, 1, 0, 1, 1, 0, time.UTC), results[0].EventTime)
+
+	var event map[string]interface{}
+	require.NoError(t, jsoniter.Unmarshal(results[0].JSON, &event))
+	require.Equal(t, "2020-01-01 00:01:01.000000000", event["time"])
+	require.Equal(t, "192.168.1.1", event["client_ip"])
+	require.Equal(t, "example.com", event["at_sign_host"])
+	require.Equal(t, float64(200), event["status"])
+	require.Equal(t, true, event["cached"])
+	require.Equal(t, []interface{}{"a", "b"}, event["tags"])
+	require.Equal(t, map[string]interface{}{
+		"id":     "u1",
+		"email":  "u1@example.com",
+		"role":   "arn:aws:iam::123456789012:role/app",
+		"hashes": []interface{}{"abcd"},
+	}, event["user"])
+	require.Equal(t, map[string]interface{}{"foo": []interface{}{float64(1), float64(2)}}, event["extra"])
+	require.NotContains(t, event, "unknown")
+	require.Equal(t, "Custom.MyApp", event["p_log_type"])
+	require.Equal(t, []interface{}{"192.168.1.1"}, event["p_any_ip_addresses"])
+	require.Equal(t, []interface{}{"example.com"}, event["p_any_domain_names"])
+	require.Equal(t, []interface{}{"abcd"}, event["p_any_sha256_hashes"])
+	require.Equal(t, []interface{}{"u1@example.com"}, event["p_any_emails"])
+	require.ElementsMatch(t, []interface{}{"u1", "u1@example.com"}, event["p_any_usernames"])
+	require.Equal(t, []interface{}{"arn:aws:iam::123456789012:role/app"}, event["p_any_cloud_principals"])
+	// large integers are not rounded
+	require.Contains(t, string(results[0].JSON), `"bytes":9007199254740993`)
+}
+
+func TestParseLogErrors(t *testing.T) {
+	config := compileTestSpec(t, testSpec)
+	parser, err := config.NewParser(nil)
+	require.NoError(t, err)
+
+	for _, log := range []string{
+		`not json`,
+		`["client_ip"]`,
+		`{"time": 1577836861000}`,
+		`{"client_ip": "192.168.1.1", "status": "OK"}`,
+		`{"client_ip": "192.168.1.1", "tags": "a"}`,
+		`{"client_ip": "192.168.1.1", "user": {"id": {}}}`,
+		`{"client_ip": "192.168.1.1", "time": "2020-01-01"}`,
+	} {
+		_, err := parser.ParseLog(log)
+		require.Error(t, err, log)
+	}
+}
+
+func TestGlueColumns(t *testing.T) {
+	config := compileTestSpec(t, testSpec)
+	columns, _ := awsglue.InferJSONColumns(config.Schema, awsglue.GlueMappings...)
+	types := make(map[string]string)
+	for _, column := range columns {
+		types[column.Name] = column.Type
+	}
+	require.Equal(t, "timestamp", types["time"])
+	require.Equal(t, "string", types["client_ip"])
+	require.Equal(t, "string", types["at_sign_host"])
+	require.Equal(t, "int", types["status"])
+	require.Equal(t, "bigint", types["bytes"])
+	require.Equal(t, "boolean", types["cached"])
+	require.Equal(t, "array<string>", types["tags"])
+	require.Equal(t, "struct<id:string,email:string,role:string,hashes:array<string>>", types["user"])
+	require.Equal(t, "string", types["extra"])
+	require.Equal(t, "array<string>", types["p_any_ip_addresses"])
+	require.Equal(t, "timestamp", types["p_event_time"])
+}
+
+func TestSchemaValidation(t *testing.T) {
+	for name, spec := range map[string]string{
+		"builtin name":      "name: AWS.CloudTrail\ndescription: d\nfields: [{name: a, type: string, required: true}]",
+		"no description":    "name: Custom.A\nfields: [{name: a, type: string, required: true}]",
+		"no required field": "name: Custom.A\ndescription: d\nfields: [{name: a, type: string}]",
+		"unknown type":      "name: Custom.A\ndescription: d\nfields: [{name: a, type: uuid, required: true}]",
+		"unknown key":       "name: Custom.A\ndescription: d\nfields: [{name: a, type: string, required: true, foo: bar}]",
+		"duplicate field":   "name: Custom.A\ndescription: d\nfields: [{name: a, type: string, required: true}, {name: A, type: int}]",
+		"reserved name":     "name: Custom.A\ndescription: d\nfields: [{name: p_a, type: string, required: true}]",
+		"bad indicator":     "name: Custom.A\ndescription: d\nfields: [{name: a, type: int, required: true, indicators: [ip]}]",
+		"bad event time":    "name: Custom.A\ndescription: d\nfields: [{name: a, type: string, required: true, isEventTime: true}]",
+		"array of arrays":   "name: Custom.A\ndescription: d\nfields: [{name: a, type: array, required: true, element: {type: array}}]",
+		"empty object":      "name: Custom.A\ndescription: d\nfields: [{name: a, type: object, required: true}]",
+	} {
+		_, err := ParseSchema([]byte(spec))
+		require.Error(t, err, name)
+	}
+
+	// JSON is valid YAML
+	schema, err := ParseSchema([]byte(`{"name": "Custom.A", "description": "d", "fields": [{"name": "a", "type": "string", "required": true}]}`))
+	require.NoError(t, err)
+	require.Equal(t, "Custom.A", schema.Name)
+}
+
+func TestUpdate(t *testing.T) {
+	registry := &logtypes.Registry{}
+	builtin := compileTestSpec(t, testSpec)
+	builtin.Name = "Builtin.Type"
+	builtin.ReferenceURL = "-"
+	registry.MustRegister(*builtin)
+
+	specA := "name: Custom.A\ndescription: d\nfields: [{name: a, type: string, required: true}]"
+	specB := "name: Custom.B\ndescription: d\nfields: [{name: b, type: string, required: true}]"
+	require.NoError(t, Update(registry, []string{specA, specB}))
+	require.ElementsMatch(t, []string{"Builtin.Type", "Custom.A", "Custom.B"}, registry.LogTypes())
+
+	// removed custom log types are unregistered, invalid specs are reported
+	require.Error(t, Update(registry, []string{specB, "name: Custom.C"}))
+	require.ElementsMatch(t, []string{"Builtin.Type", "Custom.B"}, registry.LogTypes())
+}
+
+func compileTestSpec(t *testing.T, spec string) *logtypes.Config {
+	schema, err := ParseSchema([]byte(spec))
+	require.NoError(t, err)
+	config, err := schema.Config()
+	require.NoError(t, err)
+	return config
+}
+
+// nolint:lll
+const testGrokSpec = `
+name: Custom.HAProxy.HTTP
+description: HAProxy HTTP logs
+grok:
+  patterns:
+    HAPROXYDATE: '%{MONTHDAY}/%{MONTH}/%{YEAR}:%{TIME}'
+  pattern: '%{SYSLOGTIMESTAMP} %{IPORHOST} %{SYSLOGPROG}: %{IP:client_ip}:%{INT:client_port} \[%{HAPROXYDATE:accept_date}\] %{NOTSPACE:frontend_name} %{NOTSPACE:backend_name}/%{NOTSPACE:server_name} %{INT}/%{INT}/%{INT}/%{INT}/%{INT:time_duration} %{INT:http_status_code} %{NOTSPACE:bytes_read} %{GREEDYDATA}'
+fields:
+  - name: client_ip
+    type: string
+    indicators: [ip]
+  - name: client_port
+    type: int
+  - name: accept_date
+    type: timestamp
+    timeFormat: 02/Jan/2006:15:04:05.000
+    isEventTime: true
+  - name: frontend_name
+    type: string
+  - name: backend_name
+    type: string
+  - name: server_name
+    type: string
+  - name: time_duration
+    type: bigint
+  - name: http_status_code
+    type: int
+  - name: bytes_read
+    type: bigint
+`
+
+func TestParseGrokLog(t *testing.T) {
+	config := compileTestSpec(t, testGrokSpec)
+	parser, err := config.NewParser(nil)
+	require.NoError(t, err)
+
+	// nolint:lll
+	log := `Sep 14 02:01:37 lb1 haproxy[12345]: 10.0.0.1:33317 [14/Sep/2020:02:01:37.452] http-in static/srv1 10/0/30/69/109 200 2750 - - ---- 1/1/0/0/0 0/0 {1wt.eu} {} "GET /index.html HTTP/1.1"`
+	results, err := parser.ParseLog(log)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, "Custom.HAProxy.HTTP", results[0].LogType)
+	require.Equal(t, time.Date(2020, 9, 14, 2, 1, 37, 452000000, time.UTC), results[0].EventTime)
+
+	var event map[string]interface{}
+	require.NoError(t, jsoniter.Unmarshal(results[0].JSON, &event))
+	require.Equal(t, "10.0.0.1", event["client_ip"])
+	require.Equal(t, float64(33317), event["client_port"])
+	require.Equal(t, "http-in", event["frontend_name"])
+	require.Equal(t, "static", event["backend_name"])
+	require.Equal(t, "srv1", event["server_name"])
+	require.Equal(t, float64(109), event["time_duration"])
+	require.Equal(t, float64(200), event["http_status_code"])
+	require.Equal(t, float64(2750), event["bytes_read"])
+	require.Equal(t, []interface{}{"10.0.0.1"}, event["p_any_ip_addresses"])
+
+	_, err = parser.ParseLog(`{"client_ip": "10.0.0.1"}`)
+	require.Error(t, err)
+	// captures are converted to the field types
+	_, err = parser.ParseLog(`Sep 14 02:01:37 lb1 haproxy[12345]: 10.0.0.1:33317 [14/Sep/2020:02:01:37.452] http-in static/srv1 10/0/30/69/109 200 - -`)
+	require.Error(t, err)
+}
+
+func TestGrokSchemaValidation(t *testing.T) {
+	for name, spec := range map[string]string{
+		"uncaptured field": "name: Custom.A\ndescription: d\ngrok: {pattern: '%{WORD:a}'}\nfields: [{name: b, type: string}]",
+		"object field":     "name: Custom.A\ndescription: d\ngrok: {pattern: '%{WORD:a}'}\nfields: [{name: a, type: object, fields: [{name: b, type: string}]}]",
+		"unknown pattern":  "name: Custom.A\ndescription: d\ngrok: {pattern: '%{FOO:a}'}\nfields: [{name: a, type: string}]",
+		"empty pattern":    "name: Custom.A\ndescription: d\ngrok: {pattern: ''}\nfields: [{name: a, type: string}]",
+	} {
+		_, err := ParseSchema([]byte(spec))
+		require.Error(t, err, name)
+	}
+	// grok schemas do not need required fields
+	_, err := ParseSchema([]byte("name: Custom.A\ndescription: d\ngrok: {pattern: '%{WORD:a} %{INT}'}\nfields: [{name: a, type: string}]"))
+	require.NoError(t, err)
+}
@@ -0,0 +1,415 @@
+package customlogs
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/logtypes"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/grok"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/timestamp"
+)
+
+// decoder keeps numbers as json.Number so that large integers are not rounded
+var decoder = jsoniter.Config{UseNumber: true}.Froze()
+
+var (
+	typeString    = reflect.TypeOf("")
+	typeBool      = reflect.TypeOf(false)
+	typeInt       = reflect.TypeOf(int32(0))
+	typeBigInt    = reflect.TypeOf(int64(0))
+	typeFloat     = reflect.TypeOf(float32(0))
+	typeDouble    = reflect.TypeOf(float64(0))
+	typeTimestamp = reflect.TypeOf(timestamp.RFC3339{})
+	typeJSON      = reflect.TypeOf(jsoniter.RawMessage{})
+	typePanther   = reflect.TypeOf(parsers.PantherLog{})
+)
+
+// Config compiles a schema to a log type config with a generic JSON or grok parser.
+// The event struct of the log type is built at runtime so that Glue tables are derived like for built-in log types.
+func (s *Schema) Config() (*logtypes.Config, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	fields, structType := compileFields(s.Fields, true)
+	referenceURL := s.ReferenceURL
+	if referenceURL == "" {
+		referenceURL = "-"
+	}
+	var pattern *grok.Pattern
+	if s.Grok != nil {
+		var err error
+		if pattern, err = s.Grok.Compile(); err != nil {
+			return nil, err
+		}
+	}
+	p := &parser{
+		grok:       pattern,
+		logType:    s.Name,
+		fields:     fields,
+		structType: structType,
+		// PantherLog is the last field, see compileFields
+		pantherLogIndex: len(fields),
+	}
+	return &logtypes.Config{
+		Name:         s.Name,
+		Description:  s.Description,
+		ReferenceURL: referenceURL,
+		Schema:       reflect.New(structType).Interface(),
+		NewParser: func(_ interface{}) (parsers.Interface, error) {
+			return p, nil // the parser is stateless
+		},
+	}, nil
+}
+
+type compiledField struct {
+	*Field
+	// goType is the type of the struct field, pointers for scalars so that missing values are omitted
+	goType  reflect.Type
+	element *compiledField
+	fields  []*compiledField
+}
+
+func compileFields(fields []*Field, topLevel bool) ([]*compiledField, reflect.Type) {
+	compiled := make([]*compiledField, len(fields))
+	structFields := make([]reflect.StructField, len(fields), len(fields)+1)
+	for i, field := range fields {
+		compiled[i] = compileField(field)
+		structFields[i] = reflect.StructField{
+			Name: fmt.Sprintf("Field%d", i),
+			Type: compiled[i].goType,
+			Tag:  fieldTag(field),
+		}
+	}
+	if topLevel {
+		// Like built-in log types, the Panther fields are added at the end of the struct
+		structFields = append(structFields, reflect.StructField{
+			Name:      typePanther.Name(),
+			Type:      typePanther,
+			Anonymous: true,
+		})
+	}
+	return compiled, reflect.StructOf(structFields)
+}
+
+func compileField(field *Field) *compiledField {
+	compiled := &compiledField{Field: field}
+	switch field.Type {
+	case TypeArray:
+		compiled.element = compileField(field.Element)
+		elementType := compiled.element.goType
+		if elementType.Kind() == reflect.Ptr {
+			elementType = elementType.Elem()
+		}
+		compiled.goType = reflect.SliceOf(elementType)
+	case TypeObject:
+		var structType reflect.Type
+		compiled.fields, structType = compileFields(field.Fields, false)
+		compiled.goType = reflect.PtrTo(structType)
+	case TypeJSON:
+		compiled.goType = typeJSON
+	default:
+		compiled.goType = reflect.PtrTo(scalarType(field.Type))
+	}
+	return compiled
+}
+
+func scalarType(typ string) reflect.Type {
+	switch typ {
+	case TypeString:
+		return typeString
+	case TypeBoolean:
+		return typeBool
+	case TypeInt:
+		return typeInt
+	case TypeBigInt:
+		return typeBigInt
+	case TypeFloat:
+		return typeFloat
+	case TypeDouble:
+		return typeDouble
+	case TypeTimestamp:
+		return typeTimestamp
+	default:
+		panic(errors.Errorf("invalid scalar type %q", typ)) // types are validated before compiling
+	}
+}
+
+func fieldTag(field *Field) reflect.StructTag {
+	description := strings.TrimSpace(field.Description)
+	if description == "" {
+		description = field.Name // Glue columns require a comment
+	}
+	// The parser sets struct fields directly, the JSON name only matters for output and Glue columns
+	tag := fmt.Sprintf(`json:%s description:%s`,
+		strconv.Quote(parsers.RewriteFieldName(field.Name)+",omitempty"), strconv.Quote(description))
+	if field.Required {
+		tag += ` validate:"required"`
+	}
+	return reflect.StructTag(tag)
+}
+
+// parser parses JSON or text logs of a custom log type
+type parser struct {
+	// grok is the pattern of text logs, nil for JSON logs
+	grok            *grok.Pattern
+	logType         string
+	fields          []*compiledField
+	structType      reflect.Type
+	pantherLogIndex int
+}
+
+var _ parsers.Interface = (*parser)(nil)
+
+// ParseLog implements parsers.Interface
+func (p *parser) ParseLog(log string) ([]*parsers.Result, error) {
+	values, err := p.values(log)
+	if err != nil {
+		return nil, err
+	}
+	event := reflect.New(p.structType)
+	pantherLog := event.Elem().Field(p.pantherLogIndex).Addr().Interface().(*parsers.PantherLog)
+	if err := setFields(event.Elem(), p.fields, values, pantherLog); err != nil {
+		return nil, errors.WithMessagef(err, "invalid %s log", p.logType)
+	}
+	var eventTime *timestamp.RFC3339
+	for i, field := range p.fields {
+		if field.IsEventTime {
+			eventTime, _ = event.Elem().Field(i).Interface().(*timestamp.RFC3339)
+		}
+	}
+	pantherLog.SetCoreFields(p.logType, eventTime, event.Interface())
+	return pantherLog.Results()
+}
+
+func (p *parser) values(log string) (map[string]interface{}, error) {
+	if p.grok == nil {
+		var values map[string]interface{}
+		if err := decoder.UnmarshalFromString(log, &values); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s log", p.logType)
+		}
+		return values, nil
+	}
+	captures := p.grok.Match(log)
+	if captures == nil {
+		return nil, errors.Errorf("log does not match the %s pattern", p.logType)
+	}
+	values := make(map[string]interface{}, len(captures))
+	for name, value := range captures {
+		values[name] = value
+	}
+	return values, nil
+}
+
+func setFields(target reflect.Value, fields []*compiledField, values map[string]interface{}, pantherLog *parsers.PantherLog) error {
+	for i, field := range fields {
+		value, ok := values[field.Name]
+		if !ok || value == nil {
+			if field.Required {
+				return errors.Errorf("missing required field %q", field.Name)
+			}
+			continue
+		}
+		fieldValue, err := convert(field, value, pantherLog)
+		if err != nil {
+			return errors.WithMessagef(err, "invalid field %q", field.Name)
+		}
+		target.Field(i).Set(fieldValue)
+	}
+	return nil
+}
+
+// convert returns a value of field.goType
+func convert(field *compiledField, value interface{}, pantherLog *parsers.PantherLog) (reflect.Value, error) {
+	switch field.Type {
+	case TypeArray:
+		items, ok := value.([]interface{})
+		if !ok {
+			return reflect.Value{}, errors.Errorf("expected array, got %T", value)
+		}
+		slice := reflect.MakeSlice(field.goType, len(items), len(items))
+		for i, item := range items {
+			if item == nil {
+				continue // leave the zero value
+			}
+			itemValue, err := convert(field.element, item, pantherLog)
+			if err != nil {
+				return reflect.Value{}, errors.WithMessagef(err, "invalid element %d", i)
+			}
+			if itemValue.Kind() == reflect.Ptr {
+				itemValue = itemValue.Elem()
+			}
+			slice.Index(i).Set(itemValue)
+		}
+		if len(field.Indicators) > 0 {
+			for _, item := range slice.Interface().([]string) {
+				appendIndicators(pantherLog, field.Indicators, item)
+			}
+		}
+		return slice, nil
+	case TypeObject:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return reflect.Value{}, errors.Errorf("expected object, got %T", value)
+		}
+		target := reflect.New(field.goType.Elem())
+		if err := setFields(target.Elem(), field.fields, object, pantherLog); err != nil {
+			return reflect.Value{}, err
+		}
+		return target, nil
+	case TypeJSON:
+		data, err := jsoniter.Marshal(value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(jsoniter.RawMessage(data)), nil
+	case TypeTimestamp:
+		tm, err := parseTime(field.TimeFormat, value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ts := timestamp.RFC3339(tm.UTC())
+		return reflect.ValueOf(&ts), nil
+	default:
+		scalar, err := convertScalar(field.Type, value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if field.Type == TypeString {
+			appendIndicators(pantherLog, field.Indicators, scalar.(string))
+		}
+		ptr := reflect.New(field.goType.Elem())
+		ptr.Elem().Set(reflect.ValueOf(scalar))
+		return ptr, nil
+	}
+}
+
+// convertScalar is lenient with numbers and booleans in strings, as long as they are valid
+func convertScalar(typ string, value interface{}) (interface{}, error) {
+	switch typ {
+	case TypeString:
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case json.Number:
+			return v.String(), nil
+		case bool:
+			return strconv.FormatBool(v), nil
+		}
+	case TypeBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			return strconv.ParseBool(v)
+		}
+	case TypeInt, TypeBigInt:
+		var s string
+		switch v := value.(type) {
+		case json.Number:
+			s = v.String()
+		case string:
+			s = v
+		default:
+			return nil, errors.Errorf("expected integer, got %T", value)
+		}
+		if typ == TypeInt {
+			n, err := strconv.ParseInt(s, 10, 32)
+			return int32(n), err
+		}
+		return strconv.ParseInt(s, 10, 64)
+	case TypeFloat, TypeDouble:
+		var s string
+		switch v := value.(type) {
+		case json.Number:
+			s = v.String()
+		case string:
+			s = v
+		default:
+			return nil, errors.Errorf("expected number, got %T", value)
+		}
+		if typ == TypeFloat {
+			f, err := strconv.ParseFloat(s, 32)
+			return float32(f), err
+		}
+		return strconv.ParseFloat(s, 64)
+	}
+	return nil, errors.Errorf("expected %s, got %T", typ, value)
+}
+
+func parseTime(format string, value interface{}) (time.Time, error) {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case json.Number:
+		s = v.String()
+	default:
+		return time.Time{}, errors.Errorf("expected timestamp, got %T", value)
+	}
+	switch format {
+	case "", TimeFormatRFC3339:
+		return time.Parse(time.RFC3339Nano, s)
+	case TimeFormatUnix, TimeFormatUnixMillis:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if format == TimeFormatUnixMillis {
+			f /= 1000
+		}
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*float64(time.Second))), nil
+	default:
+		return time.Parse(format, s)
+	}
+}
+
+func appendIndicators(pantherLog *parsers.PantherLog, indicators []string, value string) {
+	for _, indicator := range indicators {
+		switch indicator {
+		case IndicatorIP:
+			pantherLog.AppendAnyIPAddress(value)
+		case IndicatorDomain:
+			pantherLog.AppendAnyDomainNames(value)
+		case IndicatorMD5:
+			pantherLog.AppendAnyMD5Hashes(value)
+		case IndicatorSHA1:
+			pantherLog.AppendAnySHA1Hashes(value)
+		case IndicatorSHA256:
+			pantherLog.AppendAnySHA256Hashes(value)
+		case IndicatorEmail:
+			pantherLog.AppendAnyEmails(value)
+		case IndicatorUsername:
+			pantherLog.AppendAnyUsernames(value)
+		case IndicatorCloudPrincipal:
+			pantherLog.AppendAnyCloudPrincipals(value)
+		}
+	}
+}
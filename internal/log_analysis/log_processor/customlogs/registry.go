@@ -0,0 +1,74 @@
+package customlogs
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/logtypes"
+)
+
+// IsCustomLogType returns true if a log type is user-defined
+func IsCustomLogType(logType string) bool {
+	return strings.HasPrefix(logType, LogTypePrefix)
+}
+
+// Update replaces the custom log types of a registry with the ones described by specs.
+// Custom log types that are not in specs are removed, built-in log types are not affected.
+// Invalid specs are skipped and the first error is returned after registering the rest.
+func Update(registry *logtypes.Registry, specs []string) error {
+	var configs []*logtypes.Config
+	var firstErr error
+	for _, spec := range specs {
+		config, err := compileSpec(spec)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		configs = append(configs, config)
+	}
+
+	for _, logType := range registry.LogTypes() {
+		if IsCustomLogType(logType) {
+			registry.Del(logType)
+		}
+	}
+	for _, config := range configs {
+		if _, err := registry.Register(*config); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func compileSpec(spec string) (*logtypes.Config, error) {
+	schema, err := ParseSchema([]byte(spec))
+	if err != nil {
+		return nil, err
+	}
+	config, err := schema.Config()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to compile log type %q", schema.Name)
+	}
+	return config, nil
+}
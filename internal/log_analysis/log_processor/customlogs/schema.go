@@ -0,0 +1,289 @@
+package customlogs
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/grok"
+)
+
+// LogTypePrefix is the required prefix of custom log types, it prevents conflicts with built-in log types
+const LogTypePrefix = "Custom."
+
+// Field types
+const (
+	TypeString    = "string"
+	TypeBoolean   = "boolean"
+	TypeInt       = "int"
+	TypeBigInt    = "bigint"
+	TypeFloat     = "float"
+	TypeDouble    = "double"
+	TypeTimestamp = "timestamp"
+	TypeArray     = "array"
+	TypeObject    = "object"
+	// TypeJSON stores any JSON value as is
+	TypeJSON = "json"
+)
+
+// Timestamp formats, any other format is used as a Go time layout
+const (
+	TimeFormatRFC3339 = "rfc3339"
+	// TimeFormatUnix is seconds since the epoch, with optional fractional part
+	TimeFormatUnix = "unix"
+	// TimeFormatUnixMillis is milliseconds since the epoch
+	TimeFormatUnixMillis = "unix_ms"
+)
+
+// Indicators are the fields a value is added to
+const (
+	IndicatorIP             = "ip"
+	IndicatorDomain         = "domain"
+	IndicatorMD5            = "md5"
+	IndicatorSHA1           = "sha1"
+	IndicatorSHA256         = "sha256"
+	IndicatorEmail          = "email"
+	IndicatorUsername       = "username"
+	IndicatorCloudPrincipal = "cloud_principal"
+)
+
+var (
+	logTypeRegex = regexp.MustCompile(`^Custom\.[A-Za-z][A-Za-z0-9]*(\.[A-Za-z][A-Za-z0-9]*)*$`)
+
+	// Array elements are limited to types that map to a Glue array element as is
+	arrayElementTypes = map[string]bool{
+		TypeString:  true,
+		TypeBoolean: true,
+		TypeInt:     true,
+		TypeBigInt:  true,
+		TypeFloat:   true,
+		TypeDouble:  true,
+		TypeObject:  true,
+	}
+
+	indicators = map[string]bool{
+		IndicatorIP:             true,
+		IndicatorDomain:         true,
+		IndicatorMD5:            true,
+		IndicatorSHA1:           true,
+		IndicatorSHA256:         true,
+		IndicatorEmail:          true,
+		IndicatorUsername:       true,
+		IndicatorCloudPrincipal: true,
+	}
+)
+
+// Schema is a declarative description of a JSON or text log type
+type Schema struct {
+	Name         string   `yaml:"name" json:"name"`
+	Description  string   `yaml:"description" json:"description"`
+	ReferenceURL string   `yaml:"referenceURL,omitempty" json:"referenceURL,omitempty"`
+	Fields       []*Field `yaml:"fields" json:"fields"`
+	// Grok declares a text log type, fields are captured by a grok pattern instead of read from JSON
+	Grok *Grok `yaml:"grok,omitempty" json:"grok,omitempty"`
+}
+
+// Grok is the pattern of text log lines
+type Grok struct {
+	// Pattern must match whole log lines, the fields of the schema are captured with %{PATTERN:field}
+	Pattern string `yaml:"pattern" json:"pattern"`
+	// Patterns are additional named patterns, they take precedence over the built-in patterns
+	Patterns map[string]string `yaml:"patterns,omitempty" json:"patterns,omitempty"`
+}
+
+// Compile compiles the grok pattern
+func (g *Grok) Compile() (*grok.Pattern, error) {
+	return grok.Compile(g.Pattern, g.Patterns)
+}
+
+// Field describes a field of a JSON log
+type Field struct {
+	Name        string `yaml:"name" json:"name"`
+	Type        string `yaml:"type" json:"type"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	// Required fields must be present for a log to be of this type
+	Required bool `yaml:"required,omitempty" json:"required,omitempty"`
+	// TimeFormat is the format of timestamp fields, defaults to rfc3339
+	TimeFormat string `yaml:"timeFormat,omitempty" json:"timeFormat,omitempty"`
+	// IsEventTime marks the top level timestamp field used as p_event_time
+	IsEventTime bool `yaml:"isEventTime,omitempty" json:"isEventTime,omitempty"`
+	// Indicators lists the p_any_* fields the values of a string field are added to
+	Indicators []string `yaml:"indicators,omitempty" json:"indicators,omitempty"`
+	// Element is the type of array elements
+	Element *Field `yaml:"element,omitempty" json:"element,omitempty"`
+	// Fields are the fields of an object
+	Fields []*Field `yaml:"fields,omitempty" json:"fields,omitempty"`
+}
+
+// ParseSchema reads a schema in YAML or JSON format and validates it
+func ParseSchema(spec []byte) (*Schema, error) {
+	schema := Schema{}
+	if err := yaml.UnmarshalStrict(spec, &schema); err != nil {
+		return nil, errors.Wrap(err, "invalid log schema")
+	}
+	if err := schema.Validate(); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// Validate checks that a schema can be compiled to a log type
+func (s *Schema) Validate() error {
+	if !logTypeRegex.MatchString(s.Name) {
+		return errors.Errorf("invalid log type name %q, it must start with %q followed by alphanumeric words separated by dots",
+			s.Name, LogTypePrefix)
+	}
+	if strings.TrimSpace(s.Description) == "" {
+		return errors.Errorf("missing description for log type %q", s.Name)
+	}
+	if len(s.Fields) == 0 {
+		return errors.Errorf("log type %q has no fields", s.Name)
+	}
+	if err := validateFields(s.Fields, true); err != nil {
+		return errors.WithMessagef(err, "invalid log type %q", s.Name)
+	}
+	var required, eventTime int
+	for _, field := range s.Fields {
+		if field.Required {
+			required++
+		}
+		if field.IsEventTime {
+			eventTime++
+		}
+	}
+	if eventTime > 1 {
+		return errors.Errorf("log type %q has more than one event time field", s.Name)
+	}
+	if s.Grok != nil {
+		// The pattern matches whole lines, it is as selective as required fields
+		if err := validateGrok(s.Grok, s.Fields); err != nil {
+			return errors.WithMessagef(err, "invalid log type %q", s.Name)
+		}
+		return nil
+	}
+	// Custom parsers are tried on all logs of sources that don't declare their log types.
+	// Requiring a field avoids classifying any JSON object as a custom log.
+	if required == 0 {
+		return errors.Errorf("log type %q must have at least one required top level field", s.Name)
+	}
+	return nil
+}
+
+// validateGrok checks that all fields are captured by the pattern.
+// Captures are strings, only scalar fields are supported.
+func validateGrok(g *Grok, fields []*Field) error {
+	if g.Pattern == "" {
+		return errors.New("missing grok pattern")
+	}
+	pattern, err := g.Compile()
+	if err != nil {
+		return err
+	}
+	captured := make(map[string]bool)
+	for _, name := range pattern.Fields() {
+		captured[name] = true
+	}
+	for _, field := range fields {
+		switch field.Type {
+		case TypeArray, TypeObject, TypeJSON:
+			return errors.Errorf("field %q of type %s cannot be captured by a grok pattern", field.Name, field.Type)
+		}
+		if !captured[field.Name] {
+			return errors.Errorf("field %q is not captured by the grok pattern", field.Name)
+		}
+	}
+	return nil
+}
+
+func validateFields(fields []*Field, topLevel bool) error {
+	names := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if field == nil {
+			return errors.New("empty field")
+		}
+		if field.Name == "" {
+			return errors.New("missing field name")
+		}
+		if topLevel && strings.HasPrefix(field.Name, parsers.PantherFieldPrefix) {
+			return errors.Errorf("field name %q uses the reserved prefix %q", field.Name, parsers.PantherFieldPrefix)
+		}
+		// Athena column names are case insensitive
+		name := strings.ToLower(parsers.RewriteFieldName(field.Name))
+		if names[name] {
+			return errors.Errorf("duplicate field %q", field.Name)
+		}
+		names[name] = true
+		if err := validateField(field, topLevel); err != nil {
+			return errors.WithMessagef(err, "invalid field %q", field.Name)
+		}
+	}
+	return nil
+}
+
+func validateField(field *Field, topLevel bool) error {
+	if field.IsEventTime && (!topLevel || field.Type != TypeTimestamp) {
+		return errors.New("only top level timestamp fields can be the event time")
+	}
+	if field.TimeFormat != "" && field.Type != TypeTimestamp {
+		return errors.New("time format is only valid for timestamp fields")
+	}
+	if len(field.Indicators) > 0 && field.Type != TypeString && !(field.Type == TypeArray && field.Element != nil &&
+		field.Element.Type == TypeString) {
+		return errors.New("indicators are only valid for string fields")
+	}
+	for _, indicator := range field.Indicators {
+		if !indicators[indicator] {
+			return errors.Errorf("unknown indicator %q", indicator)
+		}
+	}
+	if field.Element != nil && field.Type != TypeArray {
+		return errors.New("element is only valid for array fields")
+	}
+	if len(field.Fields) > 0 && field.Type != TypeObject {
+		return errors.New("fields are only valid for object fields")
+	}
+	switch field.Type {
+	case TypeString, TypeBoolean, TypeInt, TypeBigInt, TypeFloat, TypeDouble, TypeTimestamp, TypeJSON:
+		return nil
+	case TypeArray:
+		element := field.Element
+		if element == nil {
+			return errors.New("missing array element type")
+		}
+		if !arrayElementTypes[element.Type] {
+			return errors.Errorf("invalid array element type %q", element.Type)
+		}
+		if element.Name != "" || element.Required || len(element.Indicators) > 0 {
+			return errors.New("array elements only have a type and fields")
+		}
+		return validateField(element, false)
+	case TypeObject:
+		if len(field.Fields) == 0 {
+			return errors.New("object has no fields")
+		}
+		return validateFields(field.Fields, false)
+	default:
+		return errors.Errorf("unknown type %q", field.Type)
+	}
+}
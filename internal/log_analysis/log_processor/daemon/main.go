@@ -0,0 +1,125 @@
+package main
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+	"go.uber.org/zap"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/common"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/processor"
+)
+
+// The daemon runs the log processor as a long running service (e.g. a container) instead of a Lambda function.
+// It reads the same environment as the Lambda, AWS_LAMBDA_FUNCTION_MEMORY_SIZE being the memory of the container.
+
+const shutdownTimeout = 5 * time.Second
+
+type daemonEnv struct {
+	// The number of goroutines consuming the queue
+	Concurrency int `default:"4"`
+	// Events are written to S3 and their messages deleted at least this often, must be shorter than the queue visibility timeout
+	FlushIntervalSeconds int `default:"120" split_words:"true"`
+	// The address of the health and metrics endpoints
+	ListenAddress string `default:":8080" split_words:"true"`
+}
+
+func main() {
+	common.Setup()
+	logger, err := zap.NewProduction()
+	if err != nil {
+		panic(err)
+	}
+	zap.ReplaceGlobals(logger)
+
+	var env daemonEnv
+	if err := envconfig.Process("daemon", &env); err != nil {
+		zap.L().Fatal("invalid daemon config", zap.Error(err))
+	}
+	if env.Concurrency < 1 || env.FlushIntervalSeconds < 1 {
+		zap.L().Fatal("DAEMON_CONCURRENCY and DAEMON_FLUSH_INTERVAL_SECONDS must be positive")
+	}
+	config := processor.DaemonConfig{
+		Concurrency:   env.Concurrency,
+		FlushInterval: time.Duration(env.FlushIntervalSeconds) * time.Second,
+		MemorySizeMB:  common.Config.AwsLambdaFunctionMemorySize,
+	}
+
+	// stop reading the queue on SIGTERM, the events that were read are processed before exiting
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, syscall.SIGTERM, syscall.SIGINT)
+		caught := <-sig
+		zap.L().Info("draining log processor", zap.String("signal", caught.String()))
+		cancel()
+	}()
+
+	stats := &processor.DaemonStats{}
+	// a consumer is considered stuck if none of its cycles completes within two flush intervals and a minute
+	server := &http.Server{
+		Addr:    env.ListenAddress,
+		Handler: newHandler(stats, 2*config.FlushInterval+time.Minute),
+	}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			zap.L().Fatal("failed to serve health and metrics endpoints", zap.Error(err))
+		}
+	}()
+
+	zap.L().Info("starting log processor daemon",
+		zap.Int("concurrency", config.Concurrency),
+		zap.Duration("flushInterval", config.FlushInterval))
+	processor.RunDaemon(ctx, common.SqsClient, config, stats)
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer shutdownCancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		zap.L().Warn("failed to shutdown health and metrics endpoints", zap.Error(err))
+	}
+	zap.L().Info("log processor daemon stopped",
+		zap.Uint64("messagesReceived", stats.MessagesReceived),
+		zap.Uint64("messagesDeleted", stats.MessagesDeleted))
+}
+
+// newHandler serves /healthz, which fails if a consumer has not completed a cycle within maxCycleAge, and /metrics for Prometheus
+func newHandler(stats *processor.DaemonStats, maxCycleAge time.Duration) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if !stats.Healthy(time.Now(), maxCycleAge) {
+			http.Error(w, "a consumer has not completed a processing cycle recently", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		if err := stats.WriteMetrics(w); err != nil {
+			zap.L().Warn("failed to write metrics", zap.Error(err))
+		}
+	})
+	return mux
+}
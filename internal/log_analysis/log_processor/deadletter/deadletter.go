@@ -0,0 +1,118 @@
+package deadletter
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+
+	"github.com/panther-labs/panther/api/lambda/core/log_analysis/log_processor/models"
+	"github.com/panther-labs/panther/internal/log_analysis/awsglue"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/classification"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/common"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/timestamp"
+)
+
+const (
+	// LogType is the pseudo log type of dead-letter events, destinations store them in the errors database
+	LogType = "Classification.Failures"
+
+	// MaxLineSize is the max size of a log line stored in a dead-letter event, longer lines are truncated.
+	// Some log types have very large lines (e.g. CloudTrail files are a single JSON document).
+	MaxLineSize = 256 * 1024
+)
+
+// GlueTableMetadata is the table over the dead-letter events
+var GlueTableMetadata = awsglue.NewGlueTableMetadata(
+	models.ErrorData, LogType, "Log lines that Panther failed to parse", awsglue.GlueTableHourly, &Event{})
+
+// nolint:lll
+type Event struct {
+	ParseTime     *timestamp.RFC3339 `json:"p_parse_time" description:"The time the log line failed to parse."`
+	Bucket        string             `json:"bucket,omitempty" description:"The S3 bucket of the log file."`
+	Key           string             `json:"key,omitempty" description:"The S3 object key of the log file."`
+	ArchiveMember string             `json:"archiveMember,omitempty" description:"The archive member of the log file, if the file is an archive."`
+	LineNumber    uint64             `json:"lineNumber" description:"The line number in the log file (starting at 1)."`
+	Line          string             `json:"line" description:"The log line."`
+	LineTruncated bool               `json:"lineTruncated,omitempty" description:"True if the log line was longer than the max size and was truncated."`
+	LogType       *string            `json:"logType,omitempty" description:"The log type of the log line, if it was parsed despite parser panics."`
+	Errors        []ParserError      `json:"errors" description:"The errors of the parsers that were tried, in order."`
+	Redacted      bool               `json:"redacted,omitempty" description:"True if the log line and the error messages were removed because the log types of the parsers have redaction rules."`
+}
+
+// nolint:lll
+type ParserError struct {
+	LogType string `json:"logType" description:"The log type of the parser."`
+	Error   string `json:"error" description:"The error message of the parser."`
+	Panic   bool   `json:"panic" description:"True if the parser panicked."`
+}
+
+// NewResult returns the dead-letter event of a log line that failed to classify or made a parser panic.
+// Destinations store the result like any other event. Redaction rules cannot apply to lines that failed to parse,
+// so the line is removed if any of the log types tried has rules, along with the error messages that can quote it.
+func NewResult(line string, lineNumber uint64, hints *common.S3DataStreamHints,
+	result *classification.ClassifierResult, parseTime time.Time) (*parsers.Result, error) {
+
+	parseTime = parseTime.UTC()
+	event := Event{
+		ParseTime:  (*timestamp.RFC3339)(&parseTime),
+		LineNumber: lineNumber,
+		Line:       line,
+		LogType:    result.LogType,
+		Errors:     make([]ParserError, len(result.ParserErrors)),
+	}
+	if len(event.Line) > MaxLineSize {
+		event.Line = event.Line[:MaxLineSize]
+		event.LineTruncated = true
+	}
+	if hints != nil {
+		event.Bucket = hints.Bucket
+		event.Key = hints.Key
+		event.ArchiveMember = hints.ArchiveMember
+	}
+	event.Redacted = result.LogType != nil && parsers.Redacts(*result.LogType)
+	for _, parserErr := range result.ParserErrors {
+		event.Redacted = event.Redacted || parsers.Redacts(parserErr.LogType)
+	}
+	if event.Redacted {
+		event.Line, event.LineTruncated = "", false
+	}
+	for i, parserErr := range result.ParserErrors {
+		event.Errors[i] = ParserError{
+			LogType: parserErr.LogType,
+			Error:   parserErr.Err.Error(),
+			Panic:   parserErr.Panic,
+		}
+		if event.Redacted {
+			event.Errors[i].Error = ""
+		}
+	}
+	data, err := jsoniter.Marshal(&event)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal dead-letter event")
+	}
+	return &parsers.Result{
+		LogType:   LogType,
+		EventTime: parseTime,
+		JSON:      data,
+	}, nil
+}
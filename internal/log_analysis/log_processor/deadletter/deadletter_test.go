@@ -0,0 +1,136 @@
+package deadletter
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/internal/log_analysis/awsglue"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/classification"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/common"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
+	"github.com/panther-labs/panther/pkg/box"
+)
+
+func TestNewResult(t *testing.T) {
+	parseTime := time.Date(2020, 1, 1, 0, 1, 1, 0, time.UTC)
+	hints := &common.S3DataStreamHints{
+		Bucket:        "bucket",
+		Key:           "logs.tar.gz",
+		ArchiveMember: "member.log",
+	}
+	classifierResult := &classification.ClassifierResult{
+		ParserErrors: []*classification.ParserError{
+			{LogType: "Foo.Bar", Err: errors.New("invalid JSON")},
+			{LogType: "Foo.Baz", Err: errors.New("parser panic"), Panic: true},
+		},
+	}
+
+	result, err := NewResult("garbage", 42, hints, classifierResult, parseTime)
+	require.NoError(t, err)
+	require.Equal(t, LogType, result.LogType)
+	require.Equal(t, parseTime, result.EventTime)
+	expect := `{
+		"p_parse_time": "2020-01-01 00:01:01.000000000",
+		"bucket": "bucket",
+		"key": "logs.tar.gz",
+		"archiveMember": "member.log",
+		"lineNumber": 42,
+		"line": "garbage",
+		"errors": [
+			{"logType": "Foo.Bar", "error": "invalid JSON", "panic": false},
+			{"logType": "Foo.Baz", "error": "parser panic", "panic": true}
+		]
+	}`
+	require.JSONEq(t, expect, string(result.JSON))
+}
+
+func TestNewResultTruncatesLongLines(t *testing.T) {
+	classifierResult := &classification.ClassifierResult{
+		LogType: box.String("Foo.Bar"),
+	}
+	line := strings.Repeat("x", MaxLineSize+1)
+	result, err := NewResult(line, 1, nil, classifierResult, time.Now())
+	require.NoError(t, err)
+
+	event := struct {
+		Line          string
+		LineTruncated bool
+		LogType       *string
+		Errors        []ParserError
+	}{}
+	require.NoError(t, jsoniter.Unmarshal(result.JSON, &event))
+	require.Len(t, event.Line, MaxLineSize)
+	require.True(t, event.LineTruncated)
+	require.Equal(t, "Foo.Bar", *event.LogType)
+	require.Empty(t, event.Errors)
+}
+
+type testRedactor map[string]bool
+
+func (r testRedactor) Redact(string, interface{}) {}
+
+func (r testRedactor) Redacts(logType string) bool {
+	return r[logType]
+}
+
+func TestNewResultRedacted(t *testing.T) {
+	parsers.SetRedactor(testRedactor{"Foo.Baz": true})
+	defer parsers.SetRedactor(nil)
+	classifierResult := &classification.ClassifierResult{
+		ParserErrors: []*classification.ParserError{
+			{LogType: "Foo.Bar", Err: errors.New("invalid JSON near 'secret'")},
+			{LogType: "Foo.Baz", Err: errors.New("invalid JSON near 'secret'")},
+		},
+	}
+	result, err := NewResult("secret", 1, nil, classifierResult, time.Date(2020, 1, 1, 0, 1, 1, 0, time.UTC))
+	require.NoError(t, err)
+	expect := `{
+		"p_parse_time": "2020-01-01 00:01:01.000000000",
+		"lineNumber": 1,
+		"line": "",
+		"redacted": true,
+		"errors": [
+			{"logType": "Foo.Bar", "error": "", "panic": false},
+			{"logType": "Foo.Baz", "error": "", "panic": false}
+		]
+	}`
+	require.JSONEq(t, expect, string(result.JSON))
+
+	// lines of log types without rules are kept
+	classifierResult.ParserErrors = classifierResult.ParserErrors[:1]
+	result, err = NewResult("secret", 1, nil, classifierResult, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, "secret", jsoniter.Get(result.JSON, "line").ToString())
+	require.False(t, jsoniter.Get(result.JSON, "redacted").ToBool())
+}
+
+func TestGlueTableMetadata(t *testing.T) {
+	require.Equal(t, awsglue.ErrorsDatabaseName, GlueTableMetadata.DatabaseName())
+	require.Equal(t, "classification_failures", GlueTableMetadata.TableName())
+	require.Equal(t, "errors/classification_failures/", GlueTableMetadata.Prefix())
+	columns, _ := awsglue.InferJSONColumns(GlueTableMetadata.EventStruct(), awsglue.GlueMappings...)
+	require.Len(t, columns, 10)
+}
@@ -0,0 +1,134 @@
+package dedup
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Window drops events whose raw line was already seen for the same log type within a time window.
+// S3 objects redelivered by replays or overlapping exports produce the same lines, this keeps them from being stored twice.
+//
+// Keys are checked as lines are read but only recorded by Commit, once their events are stored.
+// This way the lines of a failed invocation are not dropped when its input is delivered again.
+// A window holds the keys of one processing run, concurrent runs share the store but not a window.
+type Window struct {
+	store    Store
+	duration time.Duration
+	mu       sync.Mutex
+	// pending are the keys of the lines not recorded yet and the time they were first seen
+	pending map[string]time.Time
+}
+
+// NewWindow returns a dedup window of the given duration backed by store, it returns nil if duration is not positive
+func NewWindow(store Store, duration time.Duration) *Window {
+	if duration <= 0 {
+		return nil
+	}
+	return &Window{
+		store:    store,
+		duration: duration,
+		pending:  make(map[string]time.Time),
+	}
+}
+
+// Duplicates reports for each key whether it was seen in the window ending at now, either recorded in the store or
+// pending earlier in the same batch of keys or a previous one. Keys that are not duplicates are pending until Commit.
+// On a store error no stored key is reported, the lines are kept since a duplicate is better than a lost event.
+// A nil window never reports duplicates.
+func (w *Window) Duplicates(keys []string, now time.Time) ([]bool, error) {
+	duplicates := make([]bool, len(keys))
+	if w == nil || len(keys) == 0 {
+		return duplicates, nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var unchecked []string
+	for _, key := range keys {
+		if _, ok := w.pending[key]; !ok {
+			unchecked = append(unchecked, key)
+		}
+	}
+	var seen map[string]bool
+	var err error
+	if len(unchecked) > 0 {
+		seen, err = w.store.Seen(unchecked, now)
+	}
+	for i, key := range keys {
+		if _, ok := w.pending[key]; ok || seen[key] {
+			duplicates[i] = true
+			continue
+		}
+		w.pending[key] = now
+	}
+	return duplicates, err
+}
+
+// Commit records the pending keys until the end of their window, it is called once their events are stored.
+func (w *Window) Commit(now time.Time) error {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	pending := w.pending
+	w.pending = make(map[string]time.Time)
+	w.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+	expires := make(map[string]time.Time, len(pending))
+	for key, seenAt := range pending {
+		expires[key] = seenAt.Add(w.duration)
+	}
+	return w.store.Record(expires, now)
+}
+
+// Discard forgets the pending keys, it is called when their events could not be stored.
+func (w *Window) Discard() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending = make(map[string]time.Time)
+}
+
+// Pending returns the number of keys waiting for Commit
+func (w *Window) Pending() int {
+	if w == nil {
+		return 0
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.pending)
+}
+
+// Key returns the stable hash of a raw log line of a log type.
+// Line endings are ignored so that the same event framed differently maps to the same key.
+func Key(logType, line string) string {
+	h := sha256.New()
+	_, _ = h.Write([]byte(logType))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(strings.TrimRight(line, "\r\n")))
+	return hex.EncodeToString(h.Sum(nil))
+}
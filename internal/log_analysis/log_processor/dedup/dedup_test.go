@@ -0,0 +1,110 @@
+package dedup
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWindow(t *testing.T) {
+	store := NewMemoryStore()
+	w := NewWindow(store, time.Minute)
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	line := Key("AWS.CloudTrail", `{"eventID":"1"}`)
+
+	// the same line is a duplicate within a batch, regardless of its line ending
+	dups, err := w.Duplicates([]string{line, Key("AWS.CloudTrail", "{\"eventID\":\"1\"}\r\n")}, now)
+	require.NoError(t, err)
+	require.Equal(t, []bool{false, true}, dups)
+	// and in the next batches, before it is recorded
+	dups, err = w.Duplicates([]string{line, Key("AWS.S3ServerAccess", `{"eventID":"1"}`)}, now)
+	require.NoError(t, err)
+	require.Equal(t, []bool{true, false}, dups)
+	require.Equal(t, 0, store.Len())
+
+	require.NoError(t, w.Commit(now))
+	require.Equal(t, 0, w.Pending())
+	require.Equal(t, 2, store.Len())
+	dups, err = w.Duplicates([]string{line}, now.Add(30*time.Second))
+	require.NoError(t, err)
+	require.Equal(t, []bool{true}, dups)
+	require.Equal(t, 0, w.Pending())
+	// the window is not extended by duplicates
+	dups, err = w.Duplicates([]string{line}, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, []bool{false}, dups)
+}
+
+func TestWindowDiscard(t *testing.T) {
+	store := NewMemoryStore()
+	w := NewWindow(store, time.Minute)
+	now := time.Now()
+
+	// the lines of a failed invocation are not dropped when its input is processed again
+	dups, err := w.Duplicates([]string{"a", "b"}, now)
+	require.NoError(t, err)
+	require.Equal(t, []bool{false, false}, dups)
+	w.Discard()
+	require.NoError(t, w.Commit(now))
+	require.Equal(t, 0, store.Len())
+	dups, err = w.Duplicates([]string{"a", "b"}, now)
+	require.NoError(t, err)
+	require.Equal(t, []bool{false, false}, dups)
+}
+
+type failingStore struct{}
+
+func (failingStore) Seen([]string, time.Time) (map[string]bool, error) {
+	return nil, errors.New("unavailable")
+}
+
+func (failingStore) Record(map[string]time.Time, time.Time) error {
+	return errors.New("unavailable")
+}
+
+func TestWindowStoreError(t *testing.T) {
+	w := NewWindow(failingStore{}, time.Minute)
+	// repeats are still detected within the invocation
+	dups, err := w.Duplicates([]string{"a", "a"}, time.Now())
+	require.Error(t, err)
+	require.Equal(t, []bool{false, true}, dups)
+	require.Error(t, w.Commit(time.Now()))
+}
+
+func TestWindowDisabled(t *testing.T) {
+	require.Nil(t, NewWindow(NewMemoryStore(), 0))
+	var w *Window
+	dups, err := w.Duplicates([]string{"a"}, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, []bool{false}, dups)
+	require.NoError(t, w.Commit(time.Now()))
+	w.Discard()
+}
+
+func TestKey(t *testing.T) {
+	require.Equal(t, Key("A", "line"), Key("A", "line\n"))
+	require.NotEqual(t, Key("A", "line"), Key("B", "line"))
+	// the separator keeps log type and line apart
+	require.NotEqual(t, Key("AB", "C"), Key("A", "BC"))
+	require.Len(t, Key("A", "line"), 64)
+}
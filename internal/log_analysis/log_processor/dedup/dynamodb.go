@@ -0,0 +1,108 @@
+package dedup
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/pkg/errors"
+
+	"github.com/panther-labs/panther/pkg/awsbatch/dynamodbbatch"
+)
+
+const (
+	// the attributes of the dedup table, expiresAt is also the TTL attribute of the table
+	keyAttribute     = "eventKey"
+	expiresAttribute = "expiresAt"
+
+	// recordMaxElapsedTime bounds the retries of throttled writes
+	recordMaxElapsedTime = 30 * time.Second
+)
+
+// DynamoDBStore keeps keys in a DynamoDB table so that they are shared by all log processor invocations.
+// Keys are read and written in batches: one BatchGetItem call per 100 keys and one BatchWriteItem call per 25.
+// Items expire through the table TTL, the expiresAt of items not yet deleted by DynamoDB is checked on read.
+type DynamoDBStore struct {
+	Client    dynamodbiface.DynamoDBAPI
+	TableName string
+}
+
+var _ Store = (*DynamoDBStore)(nil)
+
+// Seen implements Store
+func (s *DynamoDBStore) Seen(keys []string, now time.Time) (map[string]bool, error) {
+	input := &dynamodb.BatchGetItemInput{
+		RequestItems: map[string]*dynamodb.KeysAndAttributes{
+			s.TableName: {
+				Keys:                     make([]map[string]*dynamodb.AttributeValue, len(keys)),
+				ProjectionExpression:     aws.String("#key, #expiresAt"),
+				ExpressionAttributeNames: map[string]*string{"#key": aws.String(keyAttribute), "#expiresAt": aws.String(expiresAttribute)},
+			},
+		},
+	}
+	for i, key := range keys {
+		input.RequestItems[s.TableName].Keys[i] = map[string]*dynamodb.AttributeValue{keyAttribute: {S: aws.String(key)}}
+	}
+	output, err := dynamodbbatch.BatchGetItem(s.Client, input)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read event keys from %s", s.TableName)
+	}
+
+	seen := make(map[string]bool)
+	for _, item := range output.Responses[s.TableName] {
+		key, expires := item[keyAttribute], item[expiresAttribute]
+		if key == nil || key.S == nil || expires == nil || expires.N == nil {
+			continue
+		}
+		expiresAt, err := strconv.ParseInt(*expires.N, 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid expiration of event key in %s", s.TableName)
+		}
+		if now.Unix() < expiresAt {
+			seen[*key.S] = true
+		}
+	}
+	return seen, nil
+}
+
+// Record implements Store
+func (s *DynamoDBStore) Record(expires map[string]time.Time, _ time.Time) error {
+	requests := make([]*dynamodb.WriteRequest, 0, len(expires))
+	for key, expiresAt := range expires {
+		requests = append(requests, &dynamodb.WriteRequest{
+			PutRequest: &dynamodb.PutRequest{
+				Item: map[string]*dynamodb.AttributeValue{
+					keyAttribute:     {S: aws.String(key)},
+					expiresAttribute: {N: aws.String(strconv.FormatInt(expiresAt.Unix(), 10))},
+				},
+			},
+		})
+	}
+	input := &dynamodb.BatchWriteItemInput{
+		RequestItems: map[string][]*dynamodb.WriteRequest{s.TableName: requests},
+	}
+	if err := dynamodbbatch.BatchWriteItem(s.Client, recordMaxElapsedTime, input); err != nil {
+		return errors.Wrapf(err, "failed to record event keys in %s", s.TableName)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package dedup
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/pkg/testutils"
+)
+
+func TestDynamoDBStoreSeen(t *testing.T) {
+	client := &testutils.DynamoDBMock{}
+	s := &DynamoDBStore{Client: client, TableName: "table"}
+	now := time.Unix(1577836800, 0)
+	item := func(key, expiresAt string) map[string]*dynamodb.AttributeValue {
+		return map[string]*dynamodb.AttributeValue{"eventKey": {S: aws.String(key)}, "expiresAt": {N: aws.String(expiresAt)}}
+	}
+
+	client.On("BatchGetItemPages", mock.MatchedBy(func(input *dynamodb.BatchGetItemInput) bool {
+		request := input.RequestItems["table"]
+		return request != nil && len(request.Keys) == 3 && aws.StringValue(request.Keys[0]["eventKey"].S) == "a"
+	})).Return(&dynamodb.BatchGetItemOutput{
+		Responses: map[string][]map[string]*dynamodb.AttributeValue{
+			// "b" has expired but was not deleted by the table TTL yet
+			"table": {item("a", "1577836860"), item("b", "1577836800")},
+		},
+	}, nil).Once()
+	seen, err := s.Seen([]string{"a", "b", "c"}, now)
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"a": true}, seen)
+
+	client.On("BatchGetItemPages", mock.Anything).Return(&dynamodb.BatchGetItemOutput{}, errors.New("throttled")).Once()
+	_, err = s.Seen([]string{"a"}, now)
+	require.Error(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestDynamoDBStoreRecord(t *testing.T) {
+	client := &testutils.DynamoDBMock{}
+	s := &DynamoDBStore{Client: client, TableName: "table"}
+	now := time.Unix(1577836800, 0)
+	expires := make(map[string]time.Time)
+	for _, key := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l", "m",
+		"n", "o", "p", "q", "r", "s", "t", "u", "v", "w", "x", "y", "z"} {
+
+		expires[key] = now.Add(time.Minute)
+	}
+
+	// 26 keys are written with 2 calls
+	client.On("BatchWriteItem", mock.MatchedBy(func(input *dynamodb.BatchWriteItemInput) bool {
+		put := input.RequestItems["table"][0].PutRequest
+		return aws.StringValue(put.Item["expiresAt"].N) == "1577836860"
+	})).Return(&dynamodb.BatchWriteItemOutput{}, nil).Twice()
+	require.NoError(t, s.Record(expires, now))
+	client.AssertExpectations(t)
+
+	client.On("BatchWriteItem", mock.Anything).Return(&dynamodb.BatchWriteItemOutput{}, errors.New("denied")).Once()
+	require.Error(t, s.Record(map[string]time.Time{"a": now}, now))
+}
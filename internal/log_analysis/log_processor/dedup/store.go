@@ -0,0 +1,94 @@
+package dedup
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"sync"
+	"time"
+)
+
+// Store records the keys of recently seen events, it is shared by concurrent processing runs
+type Store interface {
+	// Seen returns the keys that are recorded and still unexpired at now
+	Seen(keys []string, now time.Time) (map[string]bool, error)
+	// Record records each key until its expiration time, now is the time of the commit
+	Record(expires map[string]time.Time, now time.Time) error
+}
+
+// MemoryStore keeps keys in memory, it is meant for tests and single process use
+type MemoryStore struct {
+	mu      sync.Mutex
+	expires map[string]time.Time
+	// MaxSize bounds the number of keys kept, expired keys are purged when it is reached (0 means unbounded)
+	MaxSize int
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore returns an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		expires: make(map[string]time.Time),
+	}
+}
+
+// Seen implements Store
+func (s *MemoryStore) Seen(keys []string, now time.Time) (map[string]bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		if expires, ok := s.expires[key]; ok && now.Before(expires) {
+			seen[key] = true
+		}
+	}
+	return seen, nil
+}
+
+// Record implements Store
+func (s *MemoryStore) Record(expires map[string]time.Time, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, expiresAt := range expires {
+		if s.MaxSize > 0 && len(s.expires) >= s.MaxSize {
+			s.purge(now)
+		}
+		s.expires[key] = expiresAt
+	}
+	return nil
+}
+
+// Len returns the number of keys in the store, including expired keys not yet purged
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.expires)
+}
+
+// purge removes expired keys, if none have expired the store is reset to bound memory
+func (s *MemoryStore) purge(now time.Time) {
+	for key, expires := range s.expires {
+		if !now.Before(expires) {
+			delete(s.expires, key)
+		}
+	}
+	if len(s.expires) >= s.MaxSize {
+		s.expires = make(map[string]time.Time)
+	}
+}
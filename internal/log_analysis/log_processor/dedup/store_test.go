@@ -0,0 +1,59 @@
+package dedup
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	s := NewMemoryStore()
+	now := time.Now()
+	seen, err := s.Seen([]string{"a", "b"}, now)
+	require.NoError(t, err)
+	require.Empty(t, seen)
+	require.NoError(t, s.Record(map[string]time.Time{"a": now.Add(time.Second)}, now))
+	seen, err = s.Seen([]string{"a", "b"}, now)
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"a": true}, seen)
+	// expired keys are not seen
+	seen, err = s.Seen([]string{"a"}, now.Add(time.Second))
+	require.NoError(t, err)
+	require.Empty(t, seen)
+}
+
+func TestMemoryStoreMaxSize(t *testing.T) {
+	s := NewMemoryStore()
+	s.MaxSize = 2
+	now := time.Now()
+	require.NoError(t, s.Record(map[string]time.Time{"a": now.Add(time.Second), "b": now.Add(time.Minute)}, now))
+	require.Equal(t, 2, s.Len())
+	// "a" has expired and is purged to make room
+	require.NoError(t, s.Record(map[string]time.Time{"c": now.Add(time.Minute)}, now.Add(time.Second)))
+	require.Equal(t, 2, s.Len())
+	seen, err := s.Seen([]string{"b", "c"}, now.Add(time.Second))
+	require.NoError(t, err)
+	require.Len(t, seen, 2)
+	// nothing has expired so the store is reset
+	require.NoError(t, s.Record(map[string]time.Time{"d": now.Add(time.Minute)}, now.Add(time.Second)))
+	require.Equal(t, 1, s.Len())
+}
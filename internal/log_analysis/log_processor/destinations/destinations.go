@@ -19,6 +19,11 @@ package destinations
  */
 
 import (
+	"github.com/pkg/errors"
+
+	"github.com/panther-labs/panther/internal/log_analysis/awsglue"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/deadletter"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/logtypes"
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
 )
 
@@ -26,3 +31,15 @@ import (
 type Destination interface {
 	SendEvents(parsedEventChannel chan *parsers.Result, errChan chan error)
 }
+
+// glueTableMeta returns the table that stores the events of a log type
+func glueTableMeta(registry *logtypes.Registry, logType string) (*awsglue.GlueTableMetadata, error) {
+	if logType == deadletter.LogType {
+		return deadletter.GlueTableMetadata, nil
+	}
+	typ := registry.Get(logType)
+	if typ == nil {
+		return nil, errors.Errorf(`unknown log type %q`, logType)
+	}
+	return typ.GlueTableMeta(), nil
+}
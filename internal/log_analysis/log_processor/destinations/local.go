@@ -0,0 +1,166 @@
+package destinations
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/logtypes"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
+)
+
+const (
+	// localFileKeyFormat is the same as s3ObjectKeyFormat for uncompressed files
+	localFileKeyFormat = "%s%s-%s.json"
+
+	// the most files we keep open at a time, when exceeded all open files are closed
+	maxOpenLocalFiles = 256
+)
+
+// LocalDestination writes normalized events to a local directory.
+// Files are laid out with the same partition prefixes as in S3, which allows running the log processor without AWS.
+type LocalDestination struct {
+	// rootDir is the directory under which the partitions are created
+	rootDir  string
+	registry *logtypes.Registry
+}
+
+func CreateLocalDestination(rootDir string, registry *logtypes.Registry) Destination {
+	return &LocalDestination{
+		rootDir:  rootDir,
+		registry: registry,
+	}
+}
+
+// SendEvents writes events to files per log type and hour.
+// If the method encounters an error it writes an error to the errorChannel
+// and continues until channel is closed (skipping events).
+func (destination *LocalDestination) SendEvents(parsedEventChannel chan *parsers.Result, errChan chan error) {
+	files := make(map[localFileKey]*localFile)
+	failed := false // set to true on error and loop will drain channel
+	eventsProcessed := 0
+	for event := range parsedEventChannel {
+		if failed { // drain channel
+			continue
+		}
+		key := localFileKey{
+			logType: event.LogType,
+			hour:    event.EventTime.Truncate(time.Hour),
+		}
+		file, ok := files[key]
+		if !ok {
+			if len(files) >= maxOpenLocalFiles {
+				if err := closeLocalFiles(files); err != nil {
+					failed = true
+					errChan <- err
+					continue
+				}
+			}
+			var err error
+			if file, err = destination.createFile(key); err != nil {
+				failed = true
+				errChan <- err
+				continue
+			}
+			files[key] = file
+		}
+		if err := file.addEvent(event.JSON); err != nil {
+			failed = true
+			errChan <- err
+			continue
+		}
+		eventsProcessed++
+	}
+
+	if err := closeLocalFiles(files); err != nil && !failed {
+		errChan <- err
+	}
+	zap.L().Debug("finished writing local files", zap.Int("events", eventsProcessed))
+}
+
+func (destination *LocalDestination) createFile(key localFileKey) (*localFile, error) {
+	meta, err := glueTableMeta(destination.registry, key.logType)
+	if err != nil {
+		return nil, err
+	}
+	name := filepath.Join(destination.rootDir, filepath.FromSlash(fmt.Sprintf(localFileKeyFormat,
+		meta.GetPartitionPrefix(key.hour.UTC()),
+		key.hour.Format(S3ObjectTimestampFormat),
+		uuid.New().String(),
+	)))
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return nil, errors.Wrapf(err, "failed to create partition directory for %s", name)
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create %s", name)
+	}
+	return &localFile{
+		file:   f,
+		writer: bufio.NewWriter(f),
+	}, nil
+}
+
+type localFileKey struct {
+	logType string
+	hour    time.Time
+}
+
+// localFile is a file of newline delimited JSON events of the same type
+type localFile struct {
+	file   *os.File
+	writer *bufio.Writer
+}
+
+func (f *localFile) addEvent(event []byte) error {
+	if _, err := f.writer.Write(event); err != nil {
+		return errors.Wrapf(err, "failed to write to %s", f.file.Name())
+	}
+	if _, err := f.writer.Write(newLineDelimiter); err != nil {
+		return errors.Wrapf(err, "failed to write to %s", f.file.Name())
+	}
+	return nil
+}
+
+func (f *localFile) close() error {
+	if err := f.writer.Flush(); err != nil {
+		_ = f.file.Close()
+		return errors.Wrapf(err, "failed to flush %s", f.file.Name())
+	}
+	return f.file.Close()
+}
+
+// closeLocalFiles closes all files and removes them from the map, returning the first error
+func closeLocalFiles(files map[localFileKey]*localFile) (err error) {
+	for key, file := range files {
+		if closeErr := file.close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+		delete(files, key)
+	}
+	return err
+}
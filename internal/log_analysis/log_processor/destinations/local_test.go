@@ -0,0 +1,99 @@
+package destinations
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
+)
+
+func TestSendDataToLocalFiles(t *testing.T) {
+	rootDir, err := ioutil.TempDir("", "local-destination")
+	require.NoError(t, err)
+	defer os.RemoveAll(rootDir)
+
+	destination := CreateLocalDestination(rootDir, newRegistry())
+	eventChannel := make(chan *parsers.Result, 3)
+
+	testResult, err := newTestEvent(testLogType, refTime).Result()
+	require.NoError(t, err)
+	testResultNextHour, err := newTestEvent(testLogType, refTimePlusHour).Result()
+	require.NoError(t, err)
+
+	eventChannel <- testResult
+	eventChannel <- testResult
+	eventChannel <- testResultNextHour
+
+	runSendEvents(t, destination, eventChannel, false)
+
+	files := listLocalFiles(t, rootDir)
+	require.Len(t, files, 2)
+
+	// one file per hour, laid out like the S3 partitions
+	require.True(t, strings.HasPrefix(files[0], expectedS3Prefix))
+	require.True(t, strings.HasSuffix(files[0], ".json"))
+	content, err := ioutil.ReadFile(filepath.Join(rootDir, files[0]))
+	require.NoError(t, err)
+	require.Equal(t, string(testResult.JSON)+"\n"+string(testResult.JSON)+"\n", string(content))
+
+	require.True(t, strings.HasPrefix(files[1], expectedS3Prefix2))
+	content, err = ioutil.ReadFile(filepath.Join(rootDir, files[1]))
+	require.NoError(t, err)
+	require.Equal(t, string(testResultNextHour.JSON)+"\n", string(content))
+}
+
+func TestSendDataToLocalFilesUnknownLogType(t *testing.T) {
+	rootDir, err := ioutil.TempDir("", "local-destination")
+	require.NoError(t, err)
+	defer os.RemoveAll(rootDir)
+
+	destination := CreateLocalDestination(rootDir, newRegistry())
+	eventChannel := make(chan *parsers.Result, 1)
+
+	testResult, err := newTestEvent("unknownLogType", refTime).Result()
+	require.NoError(t, err)
+	eventChannel <- testResult
+
+	runSendEvents(t, destination, eventChannel, true)
+	require.Empty(t, listLocalFiles(t, rootDir))
+}
+
+// listLocalFiles returns the sorted paths of all files under rootDir, relative to rootDir and slash separated
+func listLocalFiles(t *testing.T, rootDir string) (files []string) {
+	err := filepath.Walk(rootDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(rootDir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	require.NoError(t, err)
+	return files
+}
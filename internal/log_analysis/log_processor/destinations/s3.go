@@ -37,9 +37,22 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/panther-labs/panther/api/lambda/core/log_analysis/log_processor/models"
+	"github.com/panther-labs/panther/internal/log_analysis/awsglue"
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/common"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/flushing"
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/logtypes"
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
+	"github.com/panther-labs/panther/internal/log_analysis/parquet"
+	"github.com/panther-labs/panther/pkg/metrics"
+)
+
+// Reasons for flushing a buffer, reported as the FlushReason dimension of the BufferAgeAtFlush metric
+const (
+	flushReasonMaxAge    = "MaxAge"
+	flushReasonMaxBytes  = "MaxBytes"
+	flushReasonMaxEvents = "MaxEvents"
+	flushReasonMemory    = "Memory"
+	flushReasonEnd       = "End"
 )
 
 const (
@@ -48,6 +61,12 @@ const (
 	// 1. The key prefix 2. Timestamp in format `s3ObjectTimestampFormat` 3. UUID4
 	s3ObjectKeyFormat = "%s%s-%s.json.gz"
 
+	// parquetObjectKeyFormat is the format of the S3 object key of Parquet files, it has the same parts as s3ObjectKeyFormat
+	parquetObjectKeyFormat = "%s%s-%s.parquet"
+	// hiddenObjectKeyFormat is the format of the S3 object key of JSON files stored next to Parquet files.
+	// Athena ignores files starting with '_' so the events are not read twice by queries.
+	hiddenObjectKeyFormat = "%s_%s-%s.json.gz"
+
 	// The timestamp format in the S3 objects with second precision: yyyyMMddTHHmmssZ
 	S3ObjectTimestampFormat = "20060102T150405Z"
 
@@ -58,6 +77,8 @@ const (
 
 	//  maximum time to hold an s3 buffer in memory (controls latency of rules engine which processes this output)
 	maxDuration = 2 * time.Minute
+	// how often buffers are checked for expiration, bounds how late a buffer is flushed after its max age
+	flushCheckInterval = time.Second
 
 	bytesPerMB                  = 1024 * 1024
 	defaultMaxS3BufferSizeBytes = 50 * bytesPerMB
@@ -77,18 +98,33 @@ func init() {
 	memUsedAtStartupMB = (int)(memStats.Sys/(bytesPerMB)) + 1
 }
 
-func CreateS3Destination(registry *logtypes.Registry) Destination {
+// CreateS3Destination creates an S3 destination that flushes buffers according to flushConfig, which can be nil
+func CreateS3Destination(registry *logtypes.Registry, flushConfig *flushing.Config) Destination {
+	return NewS3Destination(registry, common.Config.AwsLambdaFunctionMemorySize, flushConfig)
+}
+
+// NewS3Destination creates an S3 destination that buffers events in the given share of the process memory
+func NewS3Destination(registry *logtypes.Registry, memorySizeMB int, flushConfig *flushing.Config) Destination {
 	return &S3Destination{
 		s3Uploader:          common.S3Uploader,
 		snsClient:           common.SnsClient,
 		s3Bucket:            common.Config.ProcessedDataBucket,
 		snsTopicArn:         common.Config.SnsTopicARN,
-		maxBufferedMemBytes: maxS3BufferMemUsageBytes(common.Config.AwsLambdaFunctionMemorySize),
+		maxBufferedMemBytes: maxS3BufferMemUsageBytes(memorySizeMB),
 		maxDuration:         maxDuration,
+		flushConfig:         flushConfig,
 		registry:            registry,
+		now:                 time.Now,
+		newTicker:           newTicker,
 	}
 }
 
+// newTicker returns the channel of a time.Ticker and the function that stops it
+func newTicker(d time.Duration) (<-chan time.Time, func()) {
+	ticker := time.NewTicker(d)
+	return ticker.C, ticker.Stop
+}
+
 // the largest we let total size of compressed output buffers get before calling sendData() to write to S3 in bytes
 // NOTE: this presumes processing 1 file at a time
 func maxS3BufferMemUsageBytes(lambdaSizeMB int) uint64 {
@@ -127,7 +163,14 @@ type S3Destination struct {
 	// thresholds for ejection
 	maxBufferedMemBytes uint64 // max will hold in buffers before ejection
 	maxDuration         time.Duration
-	registry            *logtypes.Registry
+	// flushConfig overrides the thresholds by log type, it is nil if no flush policies are configured
+	flushConfig *flushing.Config
+	registry    *logtypes.Registry
+	// parquetSchemas caches the schemas of log types stored as Parquet
+	parquetSchemas map[string]*parquet.Schema
+	// the clock and tickers of the destination, replaced in tests
+	now       func() time.Time
+	newTicker func(d time.Duration) (<-chan time.Time, func())
 }
 
 // SendEvents stores events in S3.
@@ -135,10 +178,15 @@ type S3Destination struct {
 // and stores them in the appropriate S3 path. If the method encounters an error
 // it writes an error to the errorChannel and continues until channel is closed (skipping events).
 // The sendData() method is called as go routine to allow processing to continue and hide network latency.
+// A buffer is stored when it reaches the max age, bytes or events of its log type, or to relieve memory pressure.
 func (destination *S3Destination) SendEvents(parsedEventChannel chan *parsers.Result, errChan chan error) {
-	// used to flush expired buffers
-	flushExpired := time.NewTicker(destination.maxDuration)
-	defer flushExpired.Stop()
+	// used to flush expired buffers, also when no events arrive
+	checkInterval := flushCheckInterval
+	if destination.maxDuration < checkInterval {
+		checkInterval = destination.maxDuration
+	}
+	flushExpired, stopFlushExpired := destination.newTicker(checkInterval)
+	defer stopFlushExpired()
 
 	// use a single go routine for safety/back pressure when writing to s3 concurrently with buffer accumulation
 	var sendWaitGroup sync.WaitGroup
@@ -154,55 +202,39 @@ func (destination *S3Destination) SendEvents(parsedEventChannel chan *parsers.Re
 	// accumulate results gzip'd in a buffer
 	failed := false // set to true on error and loop will drain channel
 	bufferSet := newS3EventBufferSet()
+	flush := func(buffer *s3EventBuffer, reason string) {
+		bufferSet.removeBuffer(buffer) // bufferSet is not thread safe, do this here
+		destination.logBufferAge(buffer, reason)
+		sendChan <- buffer
+	}
 	eventsProcessed := 0
 	zap.L().Debug("starting to read events from channel")
-	for event := range parsedEventChannel {
-		if failed { // drain channel
-			continue
-		}
-
-		// Check if any buffer has data for longer than maxDuration
+	for parsedEventChannel != nil {
 		select {
-		case <-flushExpired.C:
-			now := time.Now()                                  // NOTE: not the same as the tick time which can be older
+		case <-flushExpired:
+			// Check if any buffer has data for longer than its max age
+			now := destination.now()                           // NOTE: not the same as the tick time which can be older
 			_ = bufferSet.apply(func(b *s3EventBuffer) error { // does not return an error
-				if now.Sub(b.createTime) >= destination.maxDuration {
-					bufferSet.removeBuffer(b) // bufferSet is not thread safe, do this here
-					sendChan <- b
+				if b.limits.MaxAge > 0 && now.Sub(b.createTime) >= b.limits.MaxAge {
+					flush(b, flushReasonMaxAge)
 				}
 				return nil
 			})
-		default: // makes select non-blocking
-		}
-
-		buffer := bufferSet.getBuffer(event)
-
-		err := bufferSet.addEvent(buffer, event.JSON)
-		if err != nil {
-			failed = true
-			errChan <- err
-			continue
-		}
-
-		// Check if buffer is bigger than threshold for a single buffer
-		if buffer.bytes >= maxS3BufferSizeBytes {
-			bufferSet.removeBuffer(buffer) // bufferSet is not thread safe, do this here
-			sendChan <- buffer
-		}
-
-		// Check if bufferSet is bigger than threshold for total memory usage
-		if bufferSet.totalBufferedMemBytes >= destination.maxBufferedMemBytes {
-			largestBuffer := bufferSet.largestBuffer()
-			if largestBuffer == nil { // this should NEVER happen since we exceeded threshold
-				zap.L().Error("bufferSet error",
-					zap.Error(errors.New("non-empty bufferSet does not have buffer")))
-			} else {
-				bufferSet.removeBuffer(largestBuffer) // bufferSet is not thread safe, do this here
-				sendChan <- buffer
+		case event, ok := <-parsedEventChannel:
+			if !ok {
+				parsedEventChannel = nil // ends the loop
+				continue
+			}
+			if failed { // drain channel
+				continue
+			}
+			if err := destination.addEvent(bufferSet, event, flush); err != nil {
+				failed = true
+				errChan <- err
+				continue
 			}
+			eventsProcessed++
 		}
-
-		eventsProcessed++
 	}
 
 	if failed {
@@ -212,8 +244,7 @@ func (destination *S3Destination) SendEvents(parsedEventChannel chan *parsers.Re
 	zap.L().Debug("output channel closed, sending last events")
 	// If the channel has been closed send the buffered messages before terminating
 	_ = bufferSet.apply(func(buffer *s3EventBuffer) error {
-		bufferSet.removeBuffer(buffer) // bufferSet is not thread safe, do this here
-		sendChan <- buffer
+		flush(buffer, flushReasonEnd)
 		return nil
 	})
 
@@ -223,7 +254,110 @@ func (destination *S3Destination) SendEvents(parsedEventChannel chan *parsers.Re
 	zap.L().Debug("finished sending s3 files", zap.Int("events", eventsProcessed))
 }
 
-// sendData puts data in S3 and sends notification to SNS
+// addEvent adds an event to the buffer of its log type and flushes the buffers that reached their thresholds
+func (destination *S3Destination) addEvent(bufferSet *s3EventBufferSet, event *parsers.Result,
+	flush func(buffer *s3EventBuffer, reason string)) error {
+
+	buffer := bufferSet.getBuffer(event, destination.now())
+	if buffer.events == 0 {
+		buffer.limits = destination.limits(buffer.logType)
+		if buffer.parquet == nil {
+			if err := destination.initParquet(buffer); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := bufferSet.addEvent(buffer, event.JSON); err != nil {
+		return err
+	}
+
+	// Check if buffer is bigger than the thresholds for a single buffer
+	if buffer.bytes >= buffer.limits.MaxBytes {
+		flush(buffer, flushReasonMaxBytes)
+	} else if buffer.limits.MaxEvents > 0 && buffer.events >= buffer.limits.MaxEvents {
+		flush(buffer, flushReasonMaxEvents)
+	}
+
+	// Check if bufferSet is bigger than threshold for total memory usage
+	if bufferSet.totalBufferedMemBytes >= destination.maxBufferedMemBytes {
+		largestBuffer := bufferSet.largestBuffer()
+		if largestBuffer == nil { // this should NEVER happen since we exceeded threshold
+			zap.L().Error("bufferSet error",
+				zap.Error(errors.New("non-empty bufferSet does not have buffer")))
+		} else {
+			flush(largestBuffer, flushReasonMemory)
+		}
+	}
+	return nil
+}
+
+// limits returns the flush thresholds of the buffers of a log type, a buffer never exceeds maxS3BufferSizeBytes
+func (destination *S3Destination) limits(logType string) flushing.Limits {
+	limits := destination.flushConfig.Limits(logType, flushing.Limits{
+		MaxAge:   destination.maxDuration,
+		MaxBytes: maxS3BufferSizeBytes,
+	})
+	if limits.MaxBytes > maxS3BufferSizeBytes {
+		limits.MaxBytes = maxS3BufferSizeBytes
+	}
+	return limits
+}
+
+// reportParquetDropped reports the events of a buffer that are missing from its Parquet file.
+// They cannot be queried with Athena, the error log triggers the alarm of logged errors of the log processor.
+func reportParquetDropped(buffer *s3EventBuffer, parquetKey string) {
+	if buffer.parquetDropped == 0 {
+		return
+	}
+	zap.L().Error("events are missing from Parquet file",
+		zap.String("logType", buffer.logType),
+		zap.String("parquetKey", parquetKey),
+		zap.Int("droppedEvents", buffer.parquetDropped),
+		zap.Int("events", buffer.events))
+	common.ParquetRowsDroppedLogger.LogSingle(buffer.parquetDropped,
+		metrics.Dimension{Name: "LogType", Value: buffer.logType})
+}
+
+// logBufferAge reports how long the events of a buffer waited before being flushed
+func (destination *S3Destination) logBufferAge(buffer *s3EventBuffer, reason string) {
+	if buffer.events == 0 {
+		return
+	}
+	age := destination.now().Sub(buffer.createTime)
+	common.BufferAgeAtFlushLogger.LogSingle(age.Seconds(),
+		metrics.Dimension{Name: "LogType", Value: buffer.logType},
+		metrics.Dimension{Name: "FlushReason", Value: reason})
+}
+
+// initParquet adds a Parquet writer to the buffer if its log type is stored as Parquet
+func (destination *S3Destination) initParquet(buffer *s3EventBuffer) error {
+	meta, err := glueTableMeta(destination.registry, buffer.logType)
+	if err != nil {
+		return err
+	}
+	if meta.StorageFormat() != awsglue.StorageFormatParquet {
+		return nil
+	}
+	schema, ok := destination.parquetSchemas[buffer.logType]
+	if !ok {
+		schema, err = parquet.NewSchema(meta.Columns())
+		if err != nil {
+			return errors.WithMessagef(err, "cannot write %s as Parquet", buffer.logType)
+		}
+		if destination.parquetSchemas == nil {
+			destination.parquetSchemas = make(map[string]*parquet.Schema)
+		}
+		destination.parquetSchemas[buffer.logType] = schema
+	}
+	buffer.parquet, err = parquet.NewWriter(schema)
+	return err
+}
+
+// sendData puts data in S3 and sends notification to SNS.
+// Buffers with a Parquet writer are stored as a Parquet object for Athena and a hidden JSON object for the
+// rules engine, the notification is sent for the JSON object. The rules engine only reads JSON, the size of the
+// hidden copies is reported with the ParquetJSONBytes metric.
 func (destination *S3Destination) sendData(buffer *s3EventBuffer, errChan chan error) {
 	if buffer.events == 0 { // skip empty buffers
 		return
@@ -233,6 +367,7 @@ func (destination *S3Destination) sendData(buffer *s3EventBuffer, errChan chan e
 		err           error
 		contentLength int64
 		key           string
+		parquetKey    string
 	)
 
 	operation := common.OpLogManager.Start("sendData", common.OpLogS3ServiceDim)
@@ -242,22 +377,46 @@ func (destination *S3Destination) sendData(buffer *s3EventBuffer, errChan chan e
 			// s3 dim info
 			zap.Int64("contentLength", contentLength),
 			zap.String("bucket", destination.s3Bucket),
-			zap.String("key", key))
+			zap.String("key", key),
+			zap.String("parquetKey", parquetKey))
 	}()
 
-	key, err = destination.getS3ObjectKey(buffer.logType, buffer.hour)
+	meta, err := glueTableMeta(destination.registry, buffer.logType)
 	if err != nil {
 		errChan <- err
 		return
 	}
 
+	if buffer.parquet != nil {
+		parquetKey, key = getParquetObjectKeys(meta, buffer.hour)
+		parquetKey = buffer.outputPrefix + parquetKey
+		var parquetPayload bytes.Buffer
+		if contentLength, err = buffer.parquet.WriteTo(&parquetPayload); err != nil {
+			errChan <- errors.Wrap(err, "failed to write Parquet file")
+			return
+		}
+		buffer.parquet = nil // clear to make GC more effective
+		if _, err = destination.s3Uploader.Upload(&s3manager.UploadInput{
+			Bucket: &destination.s3Bucket,
+			Key:    &parquetKey,
+			Body:   &parquetPayload,
+		}); err != nil {
+			errChan <- errors.Wrap(err, "S3Upload")
+			return
+		}
+		reportParquetDropped(buffer, parquetKey)
+	} else {
+		key = getS3ObjectKey(meta, buffer.hour)
+	}
+	key = buffer.outputPrefix + key
+
 	payload, err := buffer.read()
 	if err != nil {
 		errChan <- err
 		return
 	}
 
-	contentLength = int64(len(payload)) // for logging above
+	contentLength += int64(len(payload)) // for logging above
 
 	if _, err := destination.s3Uploader.Upload(&s3manager.UploadInput{
 		Bucket: &destination.s3Bucket,
@@ -267,14 +426,22 @@ func (destination *S3Destination) sendData(buffer *s3EventBuffer, errChan chan e
 		errChan <- errors.Wrap(err, "S3Upload")
 		return
 	}
+	if parquetKey != "" {
+		common.ParquetJSONBytesLogger.LogSingle(len(payload), metrics.Dimension{Name: "LogType", Value: buffer.logType})
+	}
 
-	err = destination.sendSNSNotification(key, buffer) // if send fails we fail whole operation
+	// replayed events stored apart from the table partitions are neither cataloged nor analyzed
+	if buffer.outputPrefix != "" {
+		return
+	}
+
+	err = destination.sendSNSNotification(key, meta.DataType(), buffer) // if send fails we fail whole operation
 	if err != nil {
 		errChan <- err
 	}
 }
 
-func (destination *S3Destination) sendSNSNotification(key string, buffer *s3EventBuffer) error {
+func (destination *S3Destination) sendSNSNotification(key string, dataType models.DataType, buffer *s3EventBuffer) error {
 	var err error
 	operation := common.OpLogManager.Start("sendSNSNotification", common.OpLogSNSServiceDim)
 	defer func() {
@@ -296,7 +463,7 @@ func (destination *S3Destination) sendSNSNotification(key string, buffer *s3Even
 		Message:  aws.String(marshalledNotification),
 		MessageAttributes: map[string]*sns.MessageAttributeValue{
 			logDataTypeAttributeName: {
-				StringValue: aws.String(models.LogData.String()),
+				StringValue: aws.String(dataType.String()),
 				DataType:    aws.String(messageAttributeDataType),
 			},
 			logTypeAttributeName: {
@@ -313,46 +480,56 @@ func (destination *S3Destination) sendSNSNotification(key string, buffer *s3Even
 	return err
 }
 
-func (destination *S3Destination) getS3ObjectKey(logType string, timestamp time.Time) (string, error) {
-	typ := destination.registry.Get(logType)
-	if typ == nil {
-		return "", errors.Errorf(`unknown log type %q`, logType)
-	}
-	meta := typ.GlueTableMeta()
+func getS3ObjectKey(meta *awsglue.GlueTableMetadata, timestamp time.Time) string {
 	return fmt.Sprintf(s3ObjectKeyFormat,
 		meta.GetPartitionPrefix(timestamp.UTC()), // get the path to store the data in S3
 		timestamp.Format(S3ObjectTimestampFormat),
 		uuid.New().String(),
-	), nil
+	)
+}
+
+// getParquetObjectKeys returns the key of a Parquet object and the key of the hidden JSON object with the same events
+func getParquetObjectKeys(meta *awsglue.GlueTableMetadata, timestamp time.Time) (parquetKey, jsonKey string) {
+	prefix := meta.GetPartitionPrefix(timestamp.UTC())
+	ts := timestamp.Format(S3ObjectTimestampFormat)
+	id := uuid.New().String()
+	return fmt.Sprintf(parquetObjectKeyFormat, prefix, ts, id), fmt.Sprintf(hiddenObjectKeyFormat, prefix, ts, id)
 }
 
 // s3BufferSet is a group of buffers associated with hour time bins, pointing to maps logtype->s3EventBuffer
 type s3EventBufferSet struct {
 	totalBufferedMemBytes uint64 // managed by addEvent() and removeBuffer()
-	set                   map[time.Time]map[string]*s3EventBuffer
+	set                   map[time.Time]map[s3EventBufferKey]*s3EventBuffer
+}
+
+// s3EventBufferKey identifies the buffer of a log type in an hour bin, events with an output prefix are kept apart
+type s3EventBufferKey struct {
+	logType      string
+	outputPrefix string
 }
 
 func newS3EventBufferSet() *s3EventBufferSet {
 	return &s3EventBufferSet{
-		set: make(map[time.Time]map[string]*s3EventBuffer),
+		set: make(map[time.Time]map[s3EventBufferKey]*s3EventBuffer),
 	}
 }
 
-func (bs *s3EventBufferSet) getBuffer(event *parsers.Result) *s3EventBuffer {
+func (bs *s3EventBufferSet) getBuffer(event *parsers.Result, now time.Time) *s3EventBuffer {
 	// bin by hour (this is our partition size)
 	hour := event.EventTime.Truncate(time.Hour)
 
 	logTypeToBuffer, ok := bs.set[hour]
 	if !ok {
-		logTypeToBuffer = make(map[string]*s3EventBuffer)
+		logTypeToBuffer = make(map[s3EventBufferKey]*s3EventBuffer)
 		bs.set[hour] = logTypeToBuffer
 	}
 
-	logType := event.LogType
-	buffer, ok := logTypeToBuffer[logType]
+	key := s3EventBufferKey{logType: event.LogType, outputPrefix: event.OutputPrefix}
+	buffer, ok := logTypeToBuffer[key]
 	if !ok {
-		buffer = newS3EventBuffer(logType, hour)
-		logTypeToBuffer[logType] = buffer
+		buffer = newS3EventBuffer(event.LogType, hour, now)
+		buffer.outputPrefix = event.OutputPrefix
+		logTypeToBuffer[key] = buffer
 	}
 
 	return buffer
@@ -370,7 +547,7 @@ func (bs *s3EventBufferSet) removeBuffer(buffer *s3EventBuffer) {
 		return
 	}
 	bs.totalBufferedMemBytes -= (uint64)(buffer.bytes)
-	delete(logTypeToBuffer, buffer.logType)
+	delete(logTypeToBuffer, s3EventBufferKey{logType: buffer.logType, outputPrefix: buffer.outputPrefix})
 }
 
 func (bs *s3EventBufferSet) largestBuffer() (largestBuffer *s3EventBuffer) {
@@ -400,16 +577,22 @@ func (bs *s3EventBufferSet) apply(f func(buffer *s3EventBuffer) error) error {
 // s3EventBuffer is a group of events of the same type
 // that will be stored in the same S3 object
 type s3EventBuffer struct {
-	logType    string
-	buffer     *bytes.Buffer
-	writer     *gzip.Writer
-	bytes      int
-	events     int
-	hour       time.Time // the event time bin
-	createTime time.Time // used to expire buffer
+	logType string
+	buffer  *bytes.Buffer
+	writer  *gzip.Writer
+	parquet *parquet.Writer // set if the log type is stored as Parquet, the events are also kept as JSON
+	bytes   int
+	events  int
+	// parquetDropped is the number of events missing from the Parquet file, they are only in the JSON file
+	parquetDropped int
+	hour           time.Time // the event time bin
+	createTime     time.Time // used to expire buffer
+	limits         flushing.Limits
+	// the S3 key prefix of replayed events, empty for live events
+	outputPrefix string
 }
 
-func newS3EventBuffer(logType string, hour time.Time) *s3EventBuffer {
+func newS3EventBuffer(logType string, hour, createTime time.Time) *s3EventBuffer {
 	buffer := &bytes.Buffer{}
 	writer := gzip.NewWriter(buffer)
 	return &s3EventBuffer{
@@ -417,13 +600,13 @@ func newS3EventBuffer(logType string, hour time.Time) *s3EventBuffer {
 		buffer:     buffer,
 		writer:     writer,
 		hour:       hour,
-		createTime: time.Now(), // used with time.Tick() to check expiration ... no need for UTC()
+		createTime: createTime, // compared with the destination clock to check expiration ... no need for UTC()
 	}
 }
 
 // addEvent adds new data to the s3EventBuffer, return bytes added and error
 func (b *s3EventBuffer) addEvent(event []byte) (int, error) {
-	startBufferSize := b.buffer.Len()
+	startBytes := b.bytes
 
 	_, err := b.writer.Write(event)
 	if err != nil {
@@ -439,8 +622,16 @@ func (b *s3EventBuffer) addEvent(event []byte) (int, error) {
 	}
 
 	b.bytes = b.buffer.Len() // size of compressed data minus gzip buffer (that's ok we just use this for memory pressure)
+	if b.parquet != nil {
+		if err := b.parquet.WriteRow(event); err != nil {
+			// the event is still stored as JSON, only the Parquet file is missing it, see reportParquetDropped
+			b.parquetDropped++
+			zap.L().Debug("failed to add event to Parquet file", zap.String("logType", b.logType), zap.Error(err))
+		}
+		b.bytes += b.parquet.Size()
+	}
 	b.events++
-	return b.bytes - startBufferSize, nil
+	return b.bytes - startBytes, nil
 }
 
 func (b *s3EventBuffer) read() ([]byte, error) {
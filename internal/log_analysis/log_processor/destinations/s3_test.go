@@ -22,6 +22,7 @@ import (
 	"bytes"
 	"compress/gzip"
 	"io/ioutil"
+	"sort"
 	"strings"
 	"sync"
 	"testing"
@@ -39,11 +40,16 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/panther-labs/panther/api/lambda/core/log_analysis/log_processor/models"
+	"github.com/panther-labs/panther/internal/log_analysis/awsglue"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/classification"
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/common"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/deadletter"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/flushing"
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/logtypes"
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/testutil"
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/timestamp"
+	"github.com/panther-labs/panther/internal/log_analysis/parquet"
 )
 
 const (
@@ -144,12 +150,48 @@ func newS3Destination(logTypes ...string) *testS3Destination {
 			maxBufferedMemBytes: 10 * 1024 * 1024, // an arbitrary amount enough to hold default test data
 			maxDuration:         maxDuration,
 			registry:            newRegistry(logTypes...),
+			now:                 time.Now,
+			newTicker:           newTicker,
 		},
 		mockSns:        mockSns,
 		mockS3Uploader: mockS3Uploader,
 	}
 }
 
+// fakeClock replaces the clock and the ticker of a destination, ticks are sent when the clock is advanced
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	ticks chan time.Time
+}
+
+func newFakeClock(destination *testS3Destination) *fakeClock {
+	clock := &fakeClock{
+		now:   time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		ticks: make(chan time.Time), // unbuffered so the destination is done with the previous event when a tick is read
+	}
+	destination.now = clock.Now
+	destination.newTicker = func(_ time.Duration) (<-chan time.Time, func()) {
+		return clock.ticks, func() {}
+	}
+	return clock
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// advance moves the clock after the destination handled the previous event, then ticks
+func (c *fakeClock) advance(d time.Duration) {
+	c.ticks <- c.Now()
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+	c.ticks <- c.Now()
+}
+
 func newRegistry(names ...string) *logtypes.Registry {
 	names = append([]string{testLogType}, names...)
 	r := logtypes.Registry{}
@@ -238,6 +280,68 @@ func TestSendDataToS3BeforeTerminating(t *testing.T) {
 	assert.Equal(t, expectedSnsPublishInput, publishInput)
 }
 
+func TestSendParquetDataToS3(t *testing.T) {
+	initTest()
+	const parquetLogType = "parquetLogType"
+	awsglue.SetParquetLogTypes(parquetLogType)
+	defer awsglue.SetParquetLogTypes()
+
+	destination := newS3Destination(parquetLogType)
+	eventChannel := make(chan *parsers.Result, 1)
+	testResult, err := newTestEvent(parquetLogType, refTime).Result()
+	require.NoError(t, err)
+	eventChannel <- testResult
+
+	destination.mockS3Uploader.On("Upload", mock.Anything, mock.Anything).Return(&s3manager.UploadOutput{}, nil).Twice()
+	destination.mockSns.On("Publish", mock.Anything).Return(&sns.PublishOutput{}, nil).Once()
+
+	runSendEvents(t, destination, eventChannel, false)
+
+	destination.mockS3Uploader.AssertExpectations(t)
+	destination.mockSns.AssertExpectations(t)
+
+	// the Parquet object is read by Athena
+	const expectedPrefix = "logs/parquetlogtype/year=2020/month=01/day=01/hour=00/"
+	parquetInput := destination.mockS3Uploader.Calls[0].Arguments.Get(0).(*s3manager.UploadInput)
+	require.True(t, strings.HasPrefix(*parquetInput.Key, expectedPrefix+"20200101T000000Z-"))
+	require.True(t, strings.HasSuffix(*parquetInput.Key, ".parquet"))
+	parquetBytes, _ := ioutil.ReadAll(parquetInput.Body)
+	require.Equal(t, "PAR1", string(parquetBytes[:4]))
+
+	// the JSON object with the same events is hidden from Athena
+	jsonInput := destination.mockS3Uploader.Calls[1].Arguments.Get(0).(*s3manager.UploadInput)
+	id := strings.TrimSuffix(strings.TrimPrefix(*parquetInput.Key, expectedPrefix), ".parquet")
+	require.Equal(t, expectedPrefix+"_"+id+".json.gz", *jsonInput.Key)
+	reader, err := gzip.NewReader(jsonInput.Body)
+	require.NoError(t, err)
+	jsonBytes, _ := ioutil.ReadAll(reader)
+	require.Equal(t, string(testResult.JSON)+"\n", string(jsonBytes))
+
+	// the notification is sent for the JSON object
+	publishInput := destination.mockSns.Calls[0].Arguments.Get(0).(*sns.PublishInput)
+	notification := models.S3Notification{}
+	require.NoError(t, jsoniter.UnmarshalFromString(*publishInput.Message, &notification))
+	require.Equal(t, *jsonInput.Key, notification.Records[0].S3.Object.Key)
+}
+
+func TestParquetDroppedEventsAreCounted(t *testing.T) {
+	schema, err := parquet.NewSchema([]awsglue.Column{{Name: "name", Type: "string"}})
+	require.NoError(t, err)
+	buffer := newS3EventBuffer(testLogType, time.Time(refTime), time.Time(refTime))
+	buffer.parquet, err = parquet.NewWriter(schema)
+	require.NoError(t, err)
+
+	_, err = buffer.addEvent([]byte(`{"name":"a"}`))
+	require.NoError(t, err)
+	_, err = buffer.addEvent([]byte(`["not an object"]`))
+	require.NoError(t, err)
+
+	// the event is kept in the JSON object only
+	require.Equal(t, 2, buffer.events)
+	require.Equal(t, 1, buffer.parquet.Rows())
+	require.Equal(t, 1, buffer.parquetDropped)
+}
+
 func TestSendDataIfTotalMemSizeLimitHasBeenReached(t *testing.T) {
 	initTest()
 
@@ -296,7 +400,7 @@ func TestSendDataIfBufferSizeLimitHasBeenReached(t *testing.T) {
 func TestSendDataIfTimeLimitHasBeenReached(t *testing.T) {
 	initTest()
 
-	eventChannel := make(chan *parsers.Result, 2)
+	eventChannel := make(chan *parsers.Result) // unbuffered so events and ticks are handled in order
 	doneChannel := make(chan bool, 1)
 
 	const nevents = 7
@@ -304,21 +408,19 @@ func TestSendDataIfTimeLimitHasBeenReached(t *testing.T) {
 	testResult, err := testEvent.Result()
 	require.NoError(t, err)
 	destination := newS3Destination()
-	destination.maxDuration = time.Second / 4
+	clock := newFakeClock(destination)
 
 	destination.mockS3Uploader.On("Upload", mock.Anything, mock.Anything).Return(&s3manager.UploadOutput{}, nil).Times(nevents)
 	destination.mockSns.On("Publish", mock.Anything).Return(&sns.PublishOutput{}, nil).Times(nevents)
 
-	// sending nevents to buffered channel
-	// The first n-1 should cause the S3 time limit to be exceeded
-	// so we expect two objects to be written to s3 from that,
-	// the last event is needed to trigger the flush of the previous
+	// a tick just before the max duration does not flush, so each pair of events is stored in one object
 	go func() {
-		for i := 0; i < nevents-1; i++ {
+		for i := 0; i < nevents; i++ {
+			eventChannel <- testResult
+			clock.advance(destination.maxDuration - time.Nanosecond)
 			eventChannel <- testResult
-			time.Sleep(destination.maxDuration + (time.Millisecond * 10)) // give time to for timers to expire
+			clock.advance(time.Nanosecond)
 		}
-		eventChannel <- testResult // last event will trigger flush of the last event above
 		doneChannel <- true
 	}()
 
@@ -330,6 +432,150 @@ func TestSendDataIfTimeLimitHasBeenReached(t *testing.T) {
 	destination.mockSns.AssertExpectations(t)
 }
 
+func TestSendDataIfLogTypeMaxAgeHasBeenReached(t *testing.T) {
+	initTest()
+
+	logType1 := "testtype1"
+	testResult1, err := newTestEvent(logType1, refTime).Result()
+	require.NoError(t, err)
+	logType2 := "testtype2"
+	testResult2, err := newTestEvent(logType2, refTime).Result()
+	require.NoError(t, err)
+
+	destination := newS3Destination(logType1, logType2)
+	destination.flushConfig = mustParseFlushConfig(t, `logTypes: {testtype1: {maxAge: 30s}}`)
+	clock := newFakeClock(destination)
+
+	destination.mockS3Uploader.On("Upload", mock.Anything, mock.Anything).Return(&s3manager.UploadOutput{}, nil).Twice()
+	destination.mockSns.On("Publish", mock.Anything).Return(&sns.PublishOutput{}, nil).Twice()
+
+	// the first log type is flushed by its max age while no events arrive, the second at the end
+	eventChannel := make(chan *parsers.Result)
+	doneChannel := make(chan bool, 1)
+	go func() {
+		eventChannel <- testResult1
+		eventChannel <- testResult2
+		clock.advance(30 * time.Second)
+		doneChannel <- true
+	}()
+	runSendEventsSignaled(t, destination, eventChannel, false, doneChannel)
+
+	destination.mockS3Uploader.AssertExpectations(t)
+	uploadKeys := uploadedKeys(destination)
+	require.Len(t, uploadKeys, 2)
+	assert.True(t, strings.HasPrefix(uploadKeys[0], "logs/testtype1/"), uploadKeys[0])
+	assert.True(t, strings.HasPrefix(uploadKeys[1], "logs/testtype2/"), uploadKeys[1])
+}
+
+func TestSendDataIfLatencySLOHasBeenReached(t *testing.T) {
+	initTest()
+
+	testResult, err := newSimpleTestEvent().Result()
+	require.NoError(t, err)
+
+	destination := newS3Destination()
+	destination.flushConfig = mustParseFlushConfig(t, `{latencySLO: 10s, default: {maxAge: 1m}}`)
+	clock := newFakeClock(destination)
+
+	destination.mockS3Uploader.On("Upload", mock.Anything, mock.Anything).Return(&s3manager.UploadOutput{}, nil).Twice()
+	destination.mockSns.On("Publish", mock.Anything).Return(&sns.PublishOutput{}, nil).Twice()
+
+	// the second event is sent after the first expired, so they are stored apart
+	eventChannel := make(chan *parsers.Result)
+	doneChannel := make(chan bool, 1)
+	go func() {
+		eventChannel <- testResult
+		clock.advance(10 * time.Second)
+		eventChannel <- testResult
+		doneChannel <- true
+	}()
+	runSendEventsSignaled(t, destination, eventChannel, false, doneChannel)
+
+	destination.mockS3Uploader.AssertExpectations(t)
+	destination.mockSns.AssertExpectations(t)
+}
+
+func TestSendDataIfLogTypeMaxEventsHasBeenReached(t *testing.T) {
+	initTest()
+
+	testResult, err := newSimpleTestEvent().Result()
+	require.NoError(t, err)
+
+	destination := newS3Destination()
+	destination.flushConfig = mustParseFlushConfig(t, `logTypes: {testLogType: {maxEvents: 2}}`)
+
+	// 2 full buffers and the last event at the end
+	eventChannel := make(chan *parsers.Result, 5)
+	for i := 0; i < 5; i++ {
+		eventChannel <- testResult
+	}
+
+	destination.mockS3Uploader.On("Upload", mock.Anything, mock.Anything).Return(&s3manager.UploadOutput{}, nil).Times(3)
+	destination.mockSns.On("Publish", mock.Anything).Return(&sns.PublishOutput{}, nil).Times(3)
+
+	runSendEvents(t, destination, eventChannel, false)
+
+	destination.mockS3Uploader.AssertExpectations(t)
+	destination.mockSns.AssertExpectations(t)
+}
+
+func TestLimits(t *testing.T) {
+	initTest()
+
+	destination := newS3Destination()
+	assert.Equal(t, flushing.Limits{MaxAge: maxDuration, MaxBytes: maxS3BufferSizeBytes}, destination.limits(testLogType))
+
+	// a buffer never exceeds maxS3BufferSizeBytes
+	destination.flushConfig = mustParseFlushConfig(t, `logTypes: {testLogType: {maxBytes: 1073741824, maxEvents: 10}}`)
+	assert.Equal(t, flushing.Limits{MaxAge: maxDuration, MaxBytes: maxS3BufferSizeBytes, MaxEvents: 10},
+		destination.limits(testLogType))
+}
+
+func TestMemoryPressureFlushesLargestBuffer(t *testing.T) {
+	initTest()
+
+	logType1 := "testtype1"
+	logType2 := "testtype2"
+	testResult1, err := newTestEvent(logType1, refTime).Result()
+	require.NoError(t, err)
+	testResult2, err := newTestEvent(logType2, refTime).Result()
+	require.NoError(t, err)
+
+	destination := newS3Destination(logType1, logType2)
+	destination.maxBufferedMemBytes = 1000
+	bufferSet := newS3EventBufferSet()
+	largest := bufferSet.getBuffer(testResult1, time.Now())
+	largest.bytes = 1000
+	largest.limits = destination.limits(logType1)
+	bufferSet.totalBufferedMemBytes = 1000
+
+	var flushed []*s3EventBuffer
+	var reasons []string
+	flush := func(buffer *s3EventBuffer, reason string) {
+		bufferSet.removeBuffer(buffer)
+		flushed = append(flushed, buffer)
+		reasons = append(reasons, reason)
+	}
+	require.NoError(t, destination.addEvent(bufferSet, testResult2, flush))
+	require.Len(t, flushed, 1)
+	assert.Same(t, largest, flushed[0])
+	assert.Equal(t, []string{flushReasonMemory}, reasons)
+	assert.Equal(t, 1, bufferSet.getBuffer(testResult2, time.Now()).events)
+}
+
+func mustParseFlushConfig(t *testing.T, config string) *flushing.Config {
+	flushConfig, err := flushing.ParseConfig([]byte(config))
+	require.NoError(t, err)
+	return flushConfig
+}
+
+func uploadedKeys(destination *testS3Destination) (keys []string) {
+	for _, call := range destination.mockS3Uploader.Calls {
+		keys = append(keys, *call.Arguments.Get(0).(*s3manager.UploadInput).Key)
+	}
+	return keys
+}
+
 func TestSendDataToS3FromMultipleLogTypesBeforeTerminating(t *testing.T) {
 	initTest()
 
@@ -425,6 +671,69 @@ func TestSendDataToS3FromMultipleHoursBeforeTerminating(t *testing.T) {
 		strings.HasPrefix(*uploadInput.Key, expectedS3Prefix2)) // order of results is async
 }
 
+func TestSendDeadLetterToS3(t *testing.T) {
+	initTest()
+
+	destination := newS3Destination()
+	eventChannel := make(chan *parsers.Result, 1)
+
+	deadLetter, err := deadletter.NewResult("garbage", 1, nil, &classification.ClassifierResult{}, (time.Time)(refTime))
+	require.NoError(t, err)
+	eventChannel <- deadLetter
+
+	destination.mockS3Uploader.On("Upload", mock.Anything, mock.Anything).Return(&s3manager.UploadOutput{}, nil).Once()
+	destination.mockSns.On("Publish", mock.Anything).Return(&sns.PublishOutput{}, nil).Once()
+
+	runSendEvents(t, destination, eventChannel, false)
+
+	destination.mockS3Uploader.AssertExpectations(t)
+	destination.mockSns.AssertExpectations(t)
+
+	// dead letters are stored in the errors database and are not sent to the rules engine
+	uploadInput := destination.mockS3Uploader.Calls[0].Arguments.Get(0).(*s3manager.UploadInput)
+	require.True(t, strings.HasPrefix(*uploadInput.Key,
+		"errors/classification_failures/year=2020/month=01/day=01/hour=00/20200101T000000Z"))
+	publishInput := destination.mockSns.Calls[0].Arguments.Get(0).(*sns.PublishInput)
+	require.Equal(t, models.ErrorData.String(), *publishInput.MessageAttributes["type"].StringValue)
+	require.Equal(t, deadletter.LogType, *publishInput.MessageAttributes["id"].StringValue)
+}
+
+func TestSendDataWithOutputPrefix(t *testing.T) {
+	initTest()
+
+	destination := newS3Destination()
+	eventChannel := make(chan *parsers.Result, 2)
+
+	testEvent := newSimpleTestEvent()
+	liveResult, err := testEvent.Result()
+	require.NoError(t, err)
+	replayedResult, err := testEvent.Result()
+	require.NoError(t, err)
+	replayedResult.OutputPrefix = "backfill/"
+	eventChannel <- liveResult
+	eventChannel <- replayedResult
+
+	// replayed events are stored in their own object and no notification is sent for them
+	destination.mockS3Uploader.On("Upload", mock.Anything, mock.Anything).Return(&s3manager.UploadOutput{}, nil).Twice()
+	destination.mockSns.On("Publish", mock.Anything).Return(&sns.PublishOutput{}, nil).Once()
+
+	runSendEvents(t, destination, eventChannel, false)
+
+	destination.mockS3Uploader.AssertExpectations(t)
+	destination.mockSns.AssertExpectations(t)
+
+	var keys []string
+	for _, call := range destination.mockS3Uploader.Calls {
+		keys = append(keys, *call.Arguments.Get(0).(*s3manager.UploadInput).Key)
+	}
+	sort.Strings(keys)
+	require.Len(t, keys, 2)
+	require.True(t, strings.HasPrefix(keys[0], "backfill/"+expectedS3Prefix))
+	require.True(t, strings.HasPrefix(keys[1], expectedS3Prefix))
+	publishInput := destination.mockSns.Calls[0].Arguments.Get(0).(*sns.PublishInput)
+	require.Contains(t, *publishInput.Message, keys[1])
+}
+
 func TestSendDataFailsIfS3Fails(t *testing.T) {
 	initTest()
 
@@ -475,12 +784,12 @@ func TestBufferSetLargest(t *testing.T) {
 	bs := newS3EventBufferSet()
 	result, err := event.Result()
 	require.NoError(t, err)
-	expectedLargest := bs.getBuffer(result)
+	expectedLargest := bs.getBuffer(result, time.Now())
 	expectedLargest.bytes = size
 	for i := 0; i < size-1; i++ {
 		// incr hour so we get new buffers
 		result.EventTime = result.EventTime.Add(time.Hour)
-		buffer := bs.getBuffer(result)
+		buffer := bs.getBuffer(result, time.Now())
 		buffer.bytes = i
 	}
 	assert.Equal(t, size, len(bs.set))
@@ -0,0 +1,117 @@
+package drift
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
+)
+
+// Field is a field of a log line that is not in the schema of its log type
+type Field struct {
+	// Path is the dot separated path of the field, array elements are indexed (e.g. `resources[1].tags`)
+	Path string
+	// Value is the raw JSON value of the field
+	Value []byte
+}
+
+// Detector finds the fields of JSON log lines that are not in the schema of a log type.
+// JSON parsers silently drop such fields when unmarshalling, so they usually appear when vendors add fields to their logs.
+type Detector struct {
+	root *node
+}
+
+// NewDetector returns a detector for the Go type of the events of a log type, it returns nil if the type is not a struct
+func NewDetector(schema interface{}) *Detector {
+	if schema == nil {
+		return nil
+	}
+	root := newNode(reflect.TypeOf(schema), make(map[reflect.Type]*node))
+	if root.fields == nil {
+		return nil
+	}
+	return &Detector{root: root}
+}
+
+// Unknown returns the fields of a JSON object line that are not in the schema.
+// Lines that are not JSON objects and objects without any known top level field (e.g. envelopes of records)
+// are not checked. A nil detector finds no fields.
+func (d *Detector) Unknown(line string) []Field {
+	if d == nil || !strings.HasPrefix(strings.TrimSpace(line), "{") {
+		return nil
+	}
+	iter := parsers.JSON.BorrowIterator([]byte(line))
+	defer parsers.JSON.ReturnIterator(iter)
+
+	var fields []Field
+	known := 0
+	iter.ReadObjectCB(func(iter *jsoniter.Iterator, key string) bool {
+		child, ok := d.root.fields[strings.ToLower(key)]
+		if !ok {
+			fields = append(fields, Field{Path: key, Value: copyBytes(iter.SkipAndReturnBytes())})
+			return true
+		}
+		known++
+		walk(iter, child, key, &fields)
+		return true
+	})
+	if iter.Error != nil || known == 0 {
+		return nil
+	}
+	return fields
+}
+
+// walk reads the next value and appends its fields that are not in n to fields
+func walk(iter *jsoniter.Iterator, n *node, path string, fields *[]Field) {
+	switch {
+	case n.open:
+		iter.Skip()
+	case n.fields != nil && iter.WhatIsNext() == jsoniter.ObjectValue:
+		iter.ReadObjectCB(func(iter *jsoniter.Iterator, key string) bool {
+			childPath := path + "." + key
+			child, ok := n.fields[strings.ToLower(key)]
+			if !ok {
+				*fields = append(*fields, Field{Path: childPath, Value: copyBytes(iter.SkipAndReturnBytes())})
+				return true
+			}
+			walk(iter, child, childPath, fields)
+			return true
+		})
+	case n.elem != nil && iter.WhatIsNext() == jsoniter.ArrayValue:
+		i := 0
+		iter.ReadArrayCB(func(iter *jsoniter.Iterator) bool {
+			walk(iter, n.elem, path+"["+strconv.Itoa(i)+"]", fields)
+			i++
+			return true
+		})
+	default:
+		iter.Skip()
+	}
+}
+
+// copyBytes copies a value without its leading whitespace since the iterator reuses its buffer
+func copyBytes(data []byte) []byte {
+	return append([]byte(nil), bytes.TrimSpace(data)...)
+}
@@ -0,0 +1,94 @@
+package drift
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/gcplogs"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/timestamp"
+)
+
+type testTag struct {
+	Key   *string `json:"key"`
+	Value *string `json:"value"`
+}
+
+type testEvent struct {
+	Name    *string             `json:"name"`
+	Time    *timestamp.RFC3339  `json:"time"`
+	Tags    []testTag           `json:"tags"`
+	Labels  map[string]string   `json:"labels"`
+	Details jsoniter.RawMessage `json:"details"`
+	Parent  *testEvent          `json:"parent"`
+	Ignored string              `json:"-"`
+
+	parsers.PantherLog
+}
+
+func TestDetectorUnknown(t *testing.T) {
+	d := NewDetector(&testEvent{})
+	require.NotNil(t, d)
+
+	fields := d.Unknown(`{
+		"Name": "event",
+		"time": "2020-01-01T00:00:00Z",
+		"tags": [{"key": "a", "value": "b"}, {"key": "c", "color": "red"}],
+		"labels": {"any": "label"},
+		"details": {"any": {"nested": "value"}},
+		"parent": {"name": "parent", "size": 42},
+		"Ignored": true,
+		"p_log_type": "Test",
+		"new": {"a": [1, 2]}
+	}`)
+	require.Equal(t, []Field{
+		{Path: "tags[1].color", Value: []byte(`"red"`)},
+		{Path: "parent.size", Value: []byte(`42`)},
+		{Path: "Ignored", Value: []byte(`true`)},
+		{Path: "new", Value: []byte(`{"a": [1, 2]}`)},
+	}, fields)
+
+	require.Empty(t, d.Unknown(`{"name": "event", "tags": []}`))
+	// envelopes without known fields are not checked
+	require.Nil(t, d.Unknown(`{"Records": [{"name": "event"}]}`))
+	// neither are lines that are not JSON objects
+	require.Nil(t, d.Unknown(`name=event`))
+	require.Nil(t, d.Unknown(`{"name": `))
+}
+
+func TestDetectorGCPAuditLog(t *testing.T) {
+	d := NewDetector(&gcplogs.LogEntryAuditLog{})
+	// nolint:lll
+	fields := d.Unknown(`{"protoPayload":{"@type":"type.googleapis.com/google.cloud.audit.AuditLog","authenticationInfo":{"principalEmail":"a@b.com","principalSubject":"user:a@b.com"},"request":{"anything":1}},"insertId":"1","logName":"projects/p/logs/cloudaudit.googleapis.com%2Factivity","resource":{"type":"gce_instance"},"timestamp":"2020-01-01T00:00:00Z","receiveTimestamp":"2020-01-01T00:00:00Z","split":{"index":1}}`)
+	require.Equal(t, []Field{
+		{Path: "protoPayload.authenticationInfo.principalSubject", Value: []byte(`"user:a@b.com"`)},
+		{Path: "split", Value: []byte(`{"index":1}`)},
+	}, fields)
+}
+
+func TestNilDetector(t *testing.T) {
+	require.Nil(t, NewDetector(nil))
+	require.Nil(t, NewDetector("not a struct"))
+	var d *Detector
+	require.Nil(t, d.Unknown(`{"name": "event"}`))
+}
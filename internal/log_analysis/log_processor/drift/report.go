@@ -0,0 +1,109 @@
+package drift
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MaxExampleSize is the maximum size of the example values kept in reports
+const MaxExampleSize = 128
+
+// arrayIndex matches the indexes of array elements in field paths
+var arrayIndex = regexp.MustCompile(`\[\d+\]`)
+
+// FieldStats counts the occurrences of an unknown field of a log type
+type FieldStats struct {
+	LogType string
+	// Path of the field with array indexes removed (e.g. `resources[].tags`)
+	Path  string
+	Count uint64
+	// Example is the first value seen, truncated to MaxExampleSize
+	Example string
+}
+
+// Report accumulates the unknown fields of each log type between flushes
+type Report struct {
+	mu     sync.Mutex
+	stats  map[[2]string]*FieldStats
+	start  time.Time
+	period time.Duration
+}
+
+// NewReport returns an empty report flushed every period
+func NewReport(period time.Duration, now time.Time) *Report {
+	return &Report{
+		stats:  make(map[[2]string]*FieldStats),
+		start:  now,
+		period: period,
+	}
+}
+
+// Add counts unknown fields of a log type
+func (r *Report) Add(logType string, fields []Field) {
+	if len(fields) == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, field := range fields {
+		path := arrayIndex.ReplaceAllString(field.Path, "[]")
+		key := [2]string{logType, path}
+		stats, ok := r.stats[key]
+		if !ok {
+			example := field.Value
+			if len(example) > MaxExampleSize {
+				example = example[:MaxExampleSize]
+			}
+			stats = &FieldStats{
+				LogType: logType,
+				Path:    path,
+				Example: string(example),
+			}
+			r.stats[key] = stats
+		}
+		stats.Count++
+	}
+}
+
+// Flush returns the stats accumulated since the last flush sorted by log type and path, once the period has passed.
+// It returns nil before that.
+func (r *Report) Flush(now time.Time) []FieldStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if now.Before(r.start.Add(r.period)) {
+		return nil
+	}
+	r.start = now
+	result := make([]FieldStats, 0, len(r.stats))
+	for _, stats := range r.stats {
+		result = append(result, *stats)
+	}
+	r.stats = make(map[[2]string]*FieldStats)
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].LogType != result[j].LogType {
+			return result[i].LogType < result[j].LogType
+		}
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
@@ -0,0 +1,46 @@
+package drift
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReport(t *testing.T) {
+	now := time.Now()
+	r := NewReport(time.Hour, now)
+	r.Add("B", []Field{{Path: "tags[0].color", Value: []byte(`"red"`)}})
+	r.Add("B", []Field{{Path: "tags[3].color", Value: []byte(`"blue"`)}})
+	r.Add("A", []Field{{Path: "long", Value: []byte(strings.Repeat("x", 2*MaxExampleSize))}})
+	r.Add("A", nil)
+
+	require.Nil(t, r.Flush(now.Add(time.Minute)))
+	require.Equal(t, []FieldStats{
+		{LogType: "A", Path: "long", Count: 1, Example: strings.Repeat("x", MaxExampleSize)},
+		{LogType: "B", Path: "tags[].color", Count: 2, Example: `"red"`},
+	}, r.Flush(now.Add(time.Hour)))
+
+	// stats are reset by a flush
+	require.Nil(t, r.Flush(now.Add(time.Hour+time.Minute)))
+	require.Empty(t, r.Flush(now.Add(2*time.Hour)))
+}
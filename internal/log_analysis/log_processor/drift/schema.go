@@ -0,0 +1,113 @@
+package drift
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+var (
+	// values of these types are decoded by their own methods, any JSON value is accepted
+	unmarshalerTypes = []reflect.Type{
+		reflect.TypeOf((*json.Unmarshaler)(nil)).Elem(),
+		reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem(),
+	}
+	rawMessageType = reflect.TypeOf(jsoniter.RawMessage{})
+)
+
+// node describes the JSON values accepted by a Go type
+type node struct {
+	// fields by lower case JSON name, set if the value is an object with known fields
+	fields map[string]*node
+	// elem is the node of array elements
+	elem *node
+	// open is true if any value is accepted (maps, interfaces, raw JSON, custom unmarshalers)
+	open bool
+}
+
+// newNode builds the node of a type, seen holds the nodes being built to handle recursive types
+func newNode(t reflect.Type, seen map[reflect.Type]*node) *node {
+	if n, ok := seen[t]; ok {
+		return n
+	}
+	if t == rawMessageType {
+		return &node{open: true}
+	}
+	for _, u := range unmarshalerTypes {
+		if t.Implements(u) || reflect.PtrTo(t).Implements(u) {
+			return &node{open: true}
+		}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return newNode(t.Elem(), seen)
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &node{} // base64 strings
+		}
+		n := &node{}
+		seen[t] = n
+		n.elem = newNode(t.Elem(), seen)
+		return n
+	case reflect.Struct:
+		n := &node{fields: make(map[string]*node)}
+		seen[t] = n
+		addFields(n, t, seen)
+		return n
+	case reflect.Map, reflect.Interface:
+		return &node{open: true}
+	default:
+		return &node{}
+	}
+}
+
+// addFields adds the fields of a struct to n, fields of embedded structs (e.g. parsers.PantherLog) are added to n as well
+func addFields(n *node, t reflect.Type, seen map[reflect.Type]*node) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				addFields(n, embedded, seen)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+		if name == "" {
+			name = field.Name
+		}
+		// field names are matched case insensitively like jsoniter does when decoding
+		n.fields[strings.ToLower(name)] = newNode(field.Type, seen)
+	}
+}
@@ -0,0 +1,77 @@
+package enrichment
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
+)
+
+const awsAccountIDLength = 12
+
+// AWSAccounts adds the names of the AWS accounts of events
+type AWSAccounts struct {
+	fields []string
+	names  map[string]string
+}
+
+// NewAWSAccounts returns an enricher adding the names of the account ids found in the fields
+func NewAWSAccounts(names map[string]string, fields []string) *AWSAccounts {
+	return &AWSAccounts{
+		fields: fields,
+		names:  names,
+	}
+}
+
+// Enrich implements Enricher
+func (a *AWSAccounts) Enrich(event *Event, out *parsers.PantherEnrichment) {
+	for _, field := range a.fields {
+		for _, accountID := range event.Values(field) {
+			if !isAWSAccountID(accountID) || containsAccount(out.AWSAccounts, accountID) {
+				continue
+			}
+			if name, ok := a.names[accountID]; ok {
+				out.AWSAccounts = append(out.AWSAccounts, parsers.EnrichmentAccount{
+					AccountID: accountID,
+					Name:      name,
+				})
+			}
+		}
+	}
+}
+
+func isAWSAccountID(s string) bool {
+	if len(s) != awsAccountIDLength {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func containsAccount(accounts []parsers.EnrichmentAccount, accountID string) bool {
+	for i := range accounts {
+		if accounts[i].AccountID == accountID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package enrichment
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/reload"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+var (
+	// DefaultGeoIPFields are the fields with the IP addresses looked up in the GeoIP database
+	DefaultGeoIPFields = []string{"p_any_ip_addresses"}
+
+	// DefaultAWSAccountFields are the fields with AWS account ids in the built-in log types
+	DefaultAWSAccountFields = []string{
+		"recipientAccountId",
+		"userIdentity.accountId",
+		"accountId",
+		"account_id",
+	}
+)
+
+// Config declares the enrichment sources of the log processor.
+// Paths are either local files or s3://bucket/key URLs, files ending in .gz are decompressed.
+type Config struct {
+	// RefreshInterval is a Go duration (e.g. 30m), defaults to reload.DefaultInterval
+	RefreshInterval string             `yaml:"refreshInterval,omitempty" json:"refreshInterval,omitempty"`
+	GeoIP           *GeoIPConfig       `yaml:"geoip,omitempty" json:"geoip,omitempty"`
+	AWSAccounts     *AWSAccountsConfig `yaml:"awsAccounts,omitempty" json:"awsAccounts,omitempty"`
+	Lookups         []*LookupConfig    `yaml:"lookups,omitempty" json:"lookups,omitempty"`
+}
+
+// GeoIPConfig adds the location and network owner of IP addresses.
+// The database is a CSV file with a header row, see LoadGeoIP.
+type GeoIPConfig struct {
+	Path string `yaml:"path" json:"path"`
+	// Fields default to DefaultGeoIPFields
+	Fields []string `yaml:"fields,omitempty" json:"fields,omitempty"`
+}
+
+// AWSAccountsConfig adds the names of AWS accounts
+type AWSAccountsConfig struct {
+	// Path is an optional table with account_id and name columns
+	Path   string `yaml:"path,omitempty" json:"path,omitempty"`
+	Format string `yaml:"format,omitempty" json:"format,omitempty"`
+	// IncludeSources adds the labels of the AWS accounts onboarded as Panther sources.
+	// Names read from Path take precedence.
+	IncludeSources bool `yaml:"includeSources,omitempty" json:"includeSources,omitempty"`
+	// Fields default to DefaultAWSAccountFields
+	Fields []string `yaml:"fields,omitempty" json:"fields,omitempty"`
+}
+
+// LookupConfig joins the rows of a lookup table on the values of event fields
+type LookupConfig struct {
+	Name string `yaml:"name" json:"name"`
+	Path string `yaml:"path" json:"path"`
+	// Format is csv or json (an array of objects or one object per line), defaults to the extension of Path
+	Format string `yaml:"format,omitempty" json:"format,omitempty"`
+	// Key is the column matched against the values of Fields
+	Key    string   `yaml:"key" json:"key"`
+	Fields []string `yaml:"fields" json:"fields"`
+}
+
+// ParseConfig reads a config in YAML or JSON format and validates it
+func ParseConfig(data []byte) (*Config, error) {
+	config := Config{}
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, errors.Wrap(err, "invalid enrichment config")
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Validate checks the config and sets the defaults
+func (c *Config) Validate() error {
+	if _, err := c.Interval(); err != nil {
+		return err
+	}
+	if geoip := c.GeoIP; geoip != nil {
+		if geoip.Path == "" {
+			return errors.New("missing geoip path")
+		}
+		if len(geoip.Fields) == 0 {
+			geoip.Fields = DefaultGeoIPFields
+		}
+	}
+	if accounts := c.AWSAccounts; accounts != nil {
+		if accounts.Path == "" && !accounts.IncludeSources {
+			return errors.New("awsAccounts needs a path or includeSources")
+		}
+		if accounts.Path != "" {
+			format, err := tableFormat(accounts.Path, accounts.Format)
+			if err != nil {
+				return errors.WithMessage(err, "invalid awsAccounts")
+			}
+			accounts.Format = format
+		}
+		if len(accounts.Fields) == 0 {
+			accounts.Fields = DefaultAWSAccountFields
+		}
+	}
+	names := make(map[string]bool, len(c.Lookups))
+	for _, lookup := range c.Lookups {
+		if lookup == nil || lookup.Name == "" {
+			return errors.New("missing lookup name")
+		}
+		if names[lookup.Name] {
+			return errors.Errorf("duplicate lookup %q", lookup.Name)
+		}
+		names[lookup.Name] = true
+		if lookup.Path == "" || lookup.Key == "" || len(lookup.Fields) == 0 {
+			return errors.Errorf("lookup %q needs a path, a key and fields", lookup.Name)
+		}
+		format, err := tableFormat(lookup.Path, lookup.Format)
+		if err != nil {
+			return errors.WithMessagef(err, "invalid lookup %q", lookup.Name)
+		}
+		lookup.Format = format
+	}
+	return nil
+}
+
+// Interval returns how often enrichment data is reloaded
+func (c *Config) Interval() (time.Duration, error) {
+	return reload.ParseInterval(c.RefreshInterval)
+}
+
+func tableFormat(filePath, format string) (string, error) {
+	if format == "" {
+		format = strings.TrimPrefix(path.Ext(strings.TrimSuffix(filePath, ".gz")), ".")
+	}
+	switch format = strings.ToLower(format); format {
+	case FormatCSV, FormatJSON:
+		return format, nil
+	default:
+		return "", errors.Errorf("unknown table format %q of %s", format, filePath)
+	}
+}
@@ -0,0 +1,167 @@
+package enrichment
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
+)
+
+// GeoIP adds the location and network owner of the IP addresses of events
+type GeoIP struct {
+	fields []string
+	ranges []geoIPRange
+}
+
+type geoIPRange struct {
+	start, end net.IP
+	location   parsers.EnrichmentGeoIP
+}
+
+// ReadGeoIP reads a GeoIP database from a CSV file with a header row.
+// IP ranges are declared by a network column with a CIDR or by start_ip and end_ip columns.
+// The optional columns are country_code, country, region, city, latitude, longitude, asn and as_organization.
+// Databases in other formats (e.g. MaxMind GeoLite2 CSV) need to be joined to this format first.
+func ReadGeoIP(r io.Reader, fields []string) (*GeoIP, error) {
+	rows, err := readCSVRows(r)
+	if err != nil {
+		return nil, err
+	}
+	ranges := make([]geoIPRange, 0, len(rows))
+	for i, row := range rows {
+		ipRange, err := newGeoIPRange(row)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "invalid GeoIP row %d", i+1)
+		}
+		ranges = append(ranges, ipRange)
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return bytes.Compare(ranges[i].start, ranges[j].start) < 0
+	})
+	return &GeoIP{
+		fields: fields,
+		ranges: ranges,
+	}, nil
+}
+
+func newGeoIPRange(row map[string]string) (geoIPRange, error) {
+	ipRange := geoIPRange{
+		location: parsers.EnrichmentGeoIP{
+			CountryCode:    row["country_code"],
+			Country:        row["country"],
+			Region:         row["region"],
+			City:           row["city"],
+			ASOrganization: row["as_organization"],
+		},
+	}
+	if network := row["network"]; network != "" {
+		_, ipNet, err := net.ParseCIDR(network)
+		if err != nil {
+			return ipRange, errors.Errorf("invalid network %q", network)
+		}
+		ipRange.start = ipNet.IP.To16()
+		ipRange.end = make(net.IP, net.IPv6len)
+		// IPv4 addresses are stored as IPv4-mapped IPv6 addresses
+		ones, bits := ipNet.Mask.Size()
+		mask := net.CIDRMask(ones+8*net.IPv6len-bits, 8*net.IPv6len)
+		for i := range ipRange.end {
+			ipRange.end[i] = ipRange.start[i] | ^mask[i]
+		}
+	} else {
+		ipRange.start = net.ParseIP(row["start_ip"]).To16()
+		ipRange.end = net.ParseIP(row["end_ip"]).To16()
+		if ipRange.start == nil || ipRange.end == nil || bytes.Compare(ipRange.start, ipRange.end) > 0 {
+			return ipRange, errors.New("missing network or invalid start_ip and end_ip")
+		}
+	}
+	var err error
+	if ipRange.location.Latitude, err = parseFloat(row["latitude"]); err != nil {
+		return ipRange, errors.Wrap(err, "invalid latitude")
+	}
+	if ipRange.location.Longitude, err = parseFloat(row["longitude"]); err != nil {
+		return ipRange, errors.Wrap(err, "invalid longitude")
+	}
+	if asn := row["asn"]; asn != "" {
+		n, err := strconv.ParseInt(asn, 10, 64)
+		if err != nil {
+			return ipRange, errors.Wrap(err, "invalid asn")
+		}
+		ipRange.location.ASN = &n
+	}
+	return ipRange, nil
+}
+
+func parseFloat(s string) (*float64, error) {
+	if s == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Lookup returns the location of an IP address or nil if it is not in the database
+func (g *GeoIP) Lookup(address string) *parsers.EnrichmentGeoIP {
+	ip := net.ParseIP(address).To16()
+	if ip == nil {
+		return nil
+	}
+	// the first range starting after the IP, the IP can only be in the previous one
+	i := sort.Search(len(g.ranges), func(i int) bool {
+		return bytes.Compare(g.ranges[i].start, ip) > 0
+	})
+	if i == 0 || bytes.Compare(g.ranges[i-1].end, ip) < 0 {
+		return nil
+	}
+	location := g.ranges[i-1].location
+	location.IP = address
+	return &location
+}
+
+// Enrich implements Enricher
+func (g *GeoIP) Enrich(event *Event, out *parsers.PantherEnrichment) {
+	for _, field := range g.fields {
+		for _, address := range event.Values(field) {
+			if containsGeoIP(out.GeoIP, address) {
+				continue
+			}
+			if location := g.Lookup(address); location != nil {
+				out.GeoIP = append(out.GeoIP, *location)
+			}
+		}
+	}
+}
+
+func containsGeoIP(locations []parsers.EnrichmentGeoIP, address string) bool {
+	for i := range locations {
+		if locations[i].IP == address {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package enrichment
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeoIPLookup(t *testing.T) {
+	f, err := os.Open("testdata/geoip.csv")
+	require.NoError(t, err)
+	defer f.Close()
+	geoip, err := ReadGeoIP(f, DefaultGeoIPFields)
+	require.NoError(t, err)
+
+	location := geoip.Lookup("1.2.3.255")
+	require.NotNil(t, location)
+	require.Equal(t, "1.2.3.255", location.IP)
+	require.Equal(t, "San Francisco", location.City)
+	require.Equal(t, 37.7749, *location.Latitude)
+	require.Equal(t, int64(64500), *location.ASN)
+
+	location = geoip.Lookup("5.6.7.20")
+	require.NotNil(t, location)
+	require.Equal(t, "DE", location.CountryCode)
+
+	location = geoip.Lookup("2001:db8:1::1")
+	require.NotNil(t, location)
+	require.Equal(t, "Netherlands", location.Country)
+	require.Nil(t, location.Latitude)
+	require.Nil(t, location.ASN)
+
+	require.Nil(t, geoip.Lookup("1.2.4.0"))
+	require.Nil(t, geoip.Lookup("5.6.7.21"))
+	require.Nil(t, geoip.Lookup("0.0.0.1"))
+	require.Nil(t, geoip.Lookup("not an ip"))
+}
+
+func TestGeoIPInvalid(t *testing.T) {
+	_, err := ReadGeoIP(strings.NewReader("network,country\n1.2.3.0/33,US\n"), DefaultGeoIPFields)
+	require.Error(t, err)
+	_, err = ReadGeoIP(strings.NewReader("start_ip,end_ip\n1.2.3.4,1.2.3.0\n"), DefaultGeoIPFields)
+	require.Error(t, err)
+	_, err = ReadGeoIP(strings.NewReader("network,asn\n1.2.3.0/24,AS1\n"), DefaultGeoIPFields)
+	require.Error(t, err)
+}
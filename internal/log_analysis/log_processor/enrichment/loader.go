@@ -0,0 +1,128 @@
+package enrichment
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/reload"
+)
+
+// ListAWSAccounts returns the names of AWS accounts by account id
+type ListAWSAccounts func() (map[string]string, error)
+
+// Opener opens the config and the enrichment files
+type Opener = reload.Opener
+
+// Parser returns the reload.Parse of enrichment configs, the value is the *Pipeline of the config with its data loaded.
+// listAccounts is used by configs including the AWS accounts of Panther sources.
+func Parser(open Opener, listAccounts ListAWSAccounts) reload.Parse {
+	return func(data []byte) (interface{}, time.Duration, error) {
+		config, err := ParseConfig(data)
+		if err != nil {
+			return nil, 0, err
+		}
+		// Validate already checked the interval
+		interval, _ := config.Interval()
+		pipeline, err := Load(config, open, listAccounts)
+		if err != nil {
+			return nil, 0, err
+		}
+		return pipeline, interval, nil
+	}
+}
+
+// Load reads the enrichment data of a validated config
+func Load(config *Config, open Opener, listAccounts ListAWSAccounts) (*Pipeline, error) {
+	var enrichers []Enricher
+	if config.GeoIP != nil {
+		var geoip *GeoIP
+		err := readWith(open, config.GeoIP.Path, func(r io.Reader) (err error) {
+			geoip, err = ReadGeoIP(r, config.GeoIP.Fields)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		enrichers = append(enrichers, geoip)
+	}
+	if config.AWSAccounts != nil {
+		accounts, err := loadAWSAccounts(config.AWSAccounts, open, listAccounts)
+		if err != nil {
+			return nil, err
+		}
+		enrichers = append(enrichers, accounts)
+	}
+	for _, lookup := range config.Lookups {
+		var table Table
+		err := readWith(open, lookup.Path, func(r io.Reader) (err error) {
+			table, err = ReadTable(r, lookup.Format, lookup.Key)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		enrichers = append(enrichers, NewLookup(lookup.Name, table, lookup.Fields))
+	}
+	return NewPipeline(enrichers...), nil
+}
+
+func loadAWSAccounts(config *AWSAccountsConfig, open Opener, listAccounts ListAWSAccounts) (*AWSAccounts, error) {
+	names := make(map[string]string)
+	if config.IncludeSources {
+		if listAccounts == nil {
+			return nil, errors.New("cannot list the AWS accounts of sources")
+		}
+		sourceNames, err := listAccounts()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list the AWS accounts of sources")
+		}
+		for accountID, name := range sourceNames {
+			names[accountID] = name
+		}
+	}
+	if config.Path != "" {
+		var table Table
+		err := readWith(open, config.Path, func(r io.Reader) (err error) {
+			table, err = ReadTable(r, config.Format, "account_id")
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for accountID, row := range table {
+			if name := row["name"]; name != "" {
+				names[accountID] = name
+			}
+		}
+	}
+	return NewAWSAccounts(names, config.Fields), nil
+}
+
+func readWith(open Opener, path string, read func(r io.Reader) error) error {
+	r, err := open(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return errors.WithMessagef(read(r), "failed to load %s", path)
+}
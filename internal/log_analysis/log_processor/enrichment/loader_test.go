@@ -0,0 +1,103 @@
+package enrichment
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/reload"
+)
+
+func TestParser(t *testing.T) {
+	listAccounts := func() (map[string]string, error) {
+		return map[string]string{
+			"123456789012": "source label",
+			"210987654321": "audit",
+		}, nil
+	}
+	data, err := ioutil.ReadFile("testdata/config.yml")
+	require.NoError(t, err)
+	value, interval, err := Parser(reload.NewOpener(nil), listAccounts)(data)
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Minute, interval)
+	pipeline := value.(*Pipeline)
+
+	result := &parsers.Result{
+		JSON: []byte(`{"recipientAccountId":"123456789012","userIdentity":{"accountId":"210987654321",` +
+			`"userName":"alice@example.com"},"p_any_ip_addresses":["1.2.3.4","10.0.0.1"]}`),
+	}
+	require.NoError(t, pipeline.Enrich(result))
+	out := struct {
+		Enrichment parsers.PantherEnrichment `json:"p_enrichment"`
+	}{}
+	require.NoError(t, parsers.JSON.Unmarshal(result.JSON, &out))
+	require.Len(t, out.Enrichment.GeoIP, 1)
+	require.Equal(t, "1.2.3.4", out.Enrichment.GeoIP[0].IP)
+	require.Equal(t, "US", out.Enrichment.GeoIP[0].CountryCode)
+	// names from the config file take precedence over source labels
+	require.Equal(t, []parsers.EnrichmentAccount{
+		{AccountID: "123456789012", Name: "production"},
+		{AccountID: "210987654321", Name: "audit"},
+	}, out.Enrichment.AWSAccounts)
+	require.Equal(t, []parsers.EnrichmentLookup{{
+		Table: "users",
+		Key:   "alice@example.com",
+		Values: map[string]string{
+			"email": "alice@example.com",
+			"team":  "security",
+			"admin": "true",
+		},
+	}}, out.Enrichment.Lookups)
+
+	// failures to load the data fail the config
+	failing := func() (map[string]string, error) {
+		return nil, errors.New("failed")
+	}
+	_, _, err = Parser(reload.NewOpener(nil), failing)(data)
+	require.Error(t, err)
+}
+
+func TestParseConfig(t *testing.T) {
+	config, err := ParseConfig([]byte(`{"awsAccounts":{"includeSources":true},"lookups":[{"name":"a","path":"a.csv.gz","key":"k","fields":["f"]}]}`))
+	require.NoError(t, err)
+	require.Equal(t, DefaultAWSAccountFields, config.AWSAccounts.Fields)
+	require.Equal(t, FormatCSV, config.Lookups[0].Format)
+	interval, err := config.Interval()
+	require.NoError(t, err)
+	require.Equal(t, reload.DefaultInterval, interval)
+
+	for _, invalid := range []string{
+		`refreshInterval: 1 hour`,
+		`geoip: {}`,
+		`awsAccounts: {}`,
+		`lookups: [{name: a, path: a.txt, key: k, fields: [f]}]`,
+		`lookups: [{name: a, path: a.csv, key: k}]`,
+		`lookups: [{name: a, path: a.csv, key: k, fields: [f]}, {name: a, path: b.csv, key: k, fields: [f]}]`,
+		`unknown: true`,
+	} {
+		_, err := ParseConfig([]byte(invalid))
+		require.Error(t, err, invalid)
+	}
+}
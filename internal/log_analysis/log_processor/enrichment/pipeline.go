@@ -0,0 +1,133 @@
+package enrichment
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bytes"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/jsonutil"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
+)
+
+const enrichmentField = `"p_enrichment":`
+
+// Enricher adds context to the events matching its data
+type Enricher interface {
+	Enrich(event *Event, out *parsers.PantherEnrichment)
+}
+
+// Event is an event being enriched
+type Event struct {
+	data   []byte
+	root   jsoniter.Any
+	values map[string][]string
+}
+
+// NewEvent returns an event reading the fields of a JSON object
+func NewEvent(data []byte) *Event {
+	return &Event{
+		data: data,
+	}
+}
+
+// Values returns the distinct values of a field, nested fields are separated by dots (e.g. userIdentity.accountId).
+// Arrays are flattened and numbers are returned as in the JSON of the event, other values are ignored.
+func (e *Event) Values(field string) []string {
+	if values, ok := e.values[field]; ok {
+		return values
+	}
+	if e.root == nil {
+		e.root = jsoniter.Get(e.data)
+		e.values = make(map[string][]string)
+	}
+	values := collectValues(e.root, strings.Split(field, "."), nil)
+	e.values[field] = values
+	return values
+}
+
+func collectValues(value jsoniter.Any, path []string, values []string) []string {
+	switch value.ValueType() {
+	case jsoniter.ArrayValue:
+		for i := 0; i < value.Size(); i++ {
+			values = collectValues(value.Get(i), path, values)
+		}
+		return values
+	case jsoniter.ObjectValue:
+		if len(path) == 0 {
+			return values
+		}
+		return collectValues(value.Get(path[0]), path[1:], values)
+	case jsoniter.StringValue, jsoniter.NumberValue:
+		if len(path) != 0 {
+			return values
+		}
+		s := value.ToString()
+		for _, v := range values {
+			if v == s {
+				return values
+			}
+		}
+		return append(values, s)
+	default:
+		return values
+	}
+}
+
+// Pipeline runs enrichers on parsed events and adds their output as the p_enrichment field.
+// A nil pipeline does nothing.
+type Pipeline struct {
+	enrichers []Enricher
+}
+
+// NewPipeline returns a pipeline running the enrichers in order
+func NewPipeline(enrichers ...Enricher) *Pipeline {
+	return &Pipeline{
+		enrichers: enrichers,
+	}
+}
+
+// Enrich adds the p_enrichment field to the JSON of the result if any enricher matched the event.
+// Events that already have the field are left as is.
+func (p *Pipeline) Enrich(result *parsers.Result) error {
+	if p == nil || len(p.enrichers) == 0 || bytes.Contains(result.JSON, []byte(enrichmentField)) {
+		return nil
+	}
+	event := NewEvent(result.JSON)
+	out := parsers.PantherEnrichment{}
+	for _, enricher := range p.enrichers {
+		enricher.Enrich(event, &out)
+	}
+	if out.IsEmpty() {
+		return nil
+	}
+	enrichment, err := parsers.JSON.Marshal(&out)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal enrichment")
+	}
+	data, err := jsonutil.AppendField(result.JSON, enrichmentField, enrichment)
+	if err != nil {
+		return err
+	}
+	result.JSON = data
+	return nil
+}
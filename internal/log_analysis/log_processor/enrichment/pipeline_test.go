@@ -0,0 +1,66 @@
+package enrichment
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
+)
+
+func TestEventValues(t *testing.T) {
+	event := NewEvent([]byte(`{"a":{"b":[{"c":"x"},{"c":2},{"c":"x"},{"c":null}]},"d":["y",["z"]],"e":true}`))
+	require.Equal(t, []string{"x", "2"}, event.Values("a.b.c"))
+	require.Equal(t, []string{"y", "z"}, event.Values("d"))
+	require.Empty(t, event.Values("a"))
+	require.Empty(t, event.Values("e"))
+	require.Empty(t, event.Values("missing.field"))
+}
+
+func TestPipelineEnrich(t *testing.T) {
+	pipeline := NewPipeline(
+		NewAWSAccounts(map[string]string{"123456789012": "production"}, DefaultAWSAccountFields),
+		NewLookup("hosts", Table{"web": {"host": "web", "owner": "alice"}}, []string{"host"}),
+	)
+	result := &parsers.Result{
+		JSON: []byte(`{"recipientAccountId":"123456789012","userIdentity":{"accountId":"123456789012"},"host":"web"}`),
+	}
+	require.NoError(t, pipeline.Enrich(result))
+	// nolint(lll)
+	expected := `{"recipientAccountId":"123456789012","userIdentity":{"accountId":"123456789012"},"host":"web",` +
+		`"p_enrichment":{"aws_accounts":[{"account_id":"123456789012","name":"production"}],` +
+		`"lookups":[{"table":"hosts","key":"web","values":{"host":"web","owner":"alice"}}]}}`
+	require.JSONEq(t, expected, string(result.JSON))
+
+	// already enriched events are left as is
+	enriched := string(result.JSON)
+	require.NoError(t, pipeline.Enrich(result))
+	require.Equal(t, enriched, string(result.JSON))
+
+	// events without matches are left as is
+	result = &parsers.Result{JSON: []byte(`{"host":"db"}`)}
+	require.NoError(t, pipeline.Enrich(result))
+	require.Equal(t, `{"host":"db"}`, string(result.JSON))
+
+	// a nil pipeline does nothing
+	var nilPipeline *Pipeline
+	require.NoError(t, nilPipeline.Enrich(result))
+}
@@ -0,0 +1,181 @@
+package enrichment
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
+)
+
+// Table is a lookup table, rows are indexed by the value of the key column
+type Table map[string]map[string]string
+
+// ReadTable reads a CSV file with a header row, a JSON array of objects or JSON objects separated by whitespace.
+// Rows without key are skipped, the last row wins for duplicate keys.
+func ReadTable(r io.Reader, format, key string) (Table, error) {
+	rows, err := readRows(r, format)
+	if err != nil {
+		return nil, err
+	}
+	table := make(Table, len(rows))
+	for _, row := range rows {
+		if value := row[key]; value != "" {
+			table[value] = row
+		}
+	}
+	return table, nil
+}
+
+func readRows(r io.Reader, format string) ([]map[string]string, error) {
+	switch format {
+	case FormatCSV:
+		return readCSVRows(r)
+	case FormatJSON:
+		return readJSONRows(r)
+	default:
+		return nil, errors.Errorf("unknown table format %q", format)
+	}
+}
+
+func readCSVRows(r io.Reader) ([]map[string]string, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read CSV header")
+	}
+	columns := append([]string(nil), header...)
+	var rows []map[string]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read CSV row")
+		}
+		row := make(map[string]string, len(columns))
+		for i, column := range columns {
+			if record[i] != "" {
+				row[column] = record[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+func readJSONRows(r io.Reader) ([]map[string]string, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	var rows []map[string]string
+	for {
+		var value interface{}
+		err := decoder.Decode(&value)
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read JSON row")
+		}
+		switch value := value.(type) {
+		case map[string]interface{}:
+			rows = append(rows, jsonRow(value))
+		case []interface{}:
+			for _, element := range value {
+				object, ok := element.(map[string]interface{})
+				if !ok {
+					return nil, errors.New("JSON rows must be objects")
+				}
+				rows = append(rows, jsonRow(object))
+			}
+		default:
+			return nil, errors.New("JSON rows must be objects")
+		}
+	}
+}
+
+func jsonRow(object map[string]interface{}) map[string]string {
+	row := make(map[string]string, len(object))
+	for column, value := range object {
+		switch value := value.(type) {
+		case nil:
+		case string:
+			row[column] = value
+		case json.Number:
+			row[column] = value.String()
+		case bool:
+			row[column] = strconv.FormatBool(value)
+		default:
+			// nested values are kept as JSON
+			data, _ := json.Marshal(value)
+			row[column] = string(data)
+		}
+	}
+	return row
+}
+
+// Lookup adds the rows of a lookup table matching the values of event fields
+type Lookup struct {
+	name   string
+	table  Table
+	fields []string
+}
+
+// NewLookup returns an enricher joining the table on the values of the fields
+func NewLookup(name string, table Table, fields []string) *Lookup {
+	return &Lookup{
+		name:   name,
+		table:  table,
+		fields: fields,
+	}
+}
+
+// Enrich implements Enricher
+func (l *Lookup) Enrich(event *Event, out *parsers.PantherEnrichment) {
+	start := len(out.Lookups)
+	for _, field := range l.fields {
+		for _, value := range event.Values(field) {
+			row, ok := l.table[value]
+			if !ok || containsLookup(out.Lookups[start:], value) {
+				continue
+			}
+			out.Lookups = append(out.Lookups, parsers.EnrichmentLookup{
+				Table:  l.name,
+				Key:    value,
+				Values: row,
+			})
+		}
+	}
+}
+
+func containsLookup(lookups []parsers.EnrichmentLookup, key string) bool {
+	for i := range lookups {
+		if lookups[i].Key == key {
+			return true
+		}
+	}
+	return false
+}
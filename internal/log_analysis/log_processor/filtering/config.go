@@ -0,0 +1,226 @@
+package filtering
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/reload"
+)
+
+// Filter actions
+const (
+	// ActionDrop drops the matching events
+	ActionDrop = "drop"
+	// ActionKeep keeps the matching events, it is useful as an exception to the rules that follow it
+	ActionKeep = "keep"
+	// ActionSample keeps a deterministic sample of the matching events
+	ActionSample = "sample"
+	// ActionRoute stores the matching events under an S3 prefix apart from the data lake, they are not analyzed
+	ActionRoute = "route"
+
+	// RouteRoot is the root of the prefixes of route rules, the log processor is only allowed to write under it
+	RouteRoot = "routed/"
+)
+
+var validPrefix = regexp.MustCompile(`^[a-zA-Z0-9_-]+(/[a-zA-Z0-9_-]+)*/?$`)
+
+// Config declares the filter rules of sources and log types
+type Config struct {
+	// RefreshInterval is a Go duration (e.g. 30m), defaults to reload.DefaultInterval
+	RefreshInterval string `yaml:"refreshInterval,omitempty" json:"refreshInterval,omitempty"`
+	// Sources are the rules by source integration id, they are evaluated before the rules of log types
+	Sources map[string][]*Rule `yaml:"sources,omitempty" json:"sources,omitempty"`
+	// LogTypes are the rules by log type
+	LogTypes map[string][]*Rule `yaml:"logTypes,omitempty" json:"logTypes,omitempty"`
+}
+
+// Rule decides what happens to the events matching all its conditions
+type Rule struct {
+	Action string `yaml:"action" json:"action"`
+	// Match are the conditions of the rule, a rule without conditions matches all events
+	Match []*Condition `yaml:"match,omitempty" json:"match,omitempty"`
+	// SampleRate is the fraction of the matching events kept by sample rules, between 0 and 1
+	SampleRate float64 `yaml:"sampleRate,omitempty" json:"sampleRate,omitempty"`
+	// SampleBy are the fields whose values decide if an event is in the sample, defaults to the whole log line.
+	// Events with the same values are either all kept or all dropped.
+	SampleBy []string `yaml:"sampleBy,omitempty" json:"sampleBy,omitempty"`
+	// Prefix is the S3 key prefix of route rules under RouteRoot (e.g. archive/flows)
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	sampleBy     [][]interface{}
+	outputPrefix string
+}
+
+// Condition matches the values of a field, it needs exactly one of Equals, In, CIDR or Regex
+type Condition struct {
+	// Field is the JSON name of the field as stored, nested fields are separated by dots (e.g. userIdentity.type).
+	// Any element of an array field can match.
+	Field  string   `yaml:"field" json:"field"`
+	Equals *string  `yaml:"equals,omitempty" json:"equals,omitempty"`
+	In     []string `yaml:"in,omitempty" json:"in,omitempty"`
+	// CIDR matches IP addresses in any of the networks
+	CIDR  []string `yaml:"cidr,omitempty" json:"cidr,omitempty"`
+	Regex string   `yaml:"regex,omitempty" json:"regex,omitempty"`
+	// Not negates the condition, it matches events without the field as well
+	Not bool `yaml:"not,omitempty" json:"not,omitempty"`
+
+	path     []interface{}
+	in       map[string]bool
+	networks []*net.IPNet
+	regex    *regexp.Regexp
+}
+
+// ParseConfig reads a config in YAML or JSON format and validates it
+func ParseConfig(data []byte) (*Config, error) {
+	config := Config{}
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, errors.Wrap(err, "invalid filter config")
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Validate checks the config and compiles its rules
+func (c *Config) Validate() error {
+	if _, err := c.Interval(); err != nil {
+		return err
+	}
+	for id, rules := range c.Sources {
+		for _, rule := range rules {
+			if err := rule.compile(); err != nil {
+				return errors.WithMessagef(err, "invalid rule for source %q", id)
+			}
+		}
+	}
+	for logType, rules := range c.LogTypes {
+		for _, rule := range rules {
+			if err := rule.compile(); err != nil {
+				return errors.WithMessagef(err, "invalid rule for log type %q", logType)
+			}
+		}
+	}
+	return nil
+}
+
+// Interval returns how often the config is reloaded
+func (c *Config) Interval() (time.Duration, error) {
+	return reload.ParseInterval(c.RefreshInterval)
+}
+
+func (r *Rule) compile() error {
+	if r == nil {
+		return errors.New("empty rule")
+	}
+	switch r.Action {
+	case ActionDrop, ActionKeep, ActionRoute:
+		if r.SampleRate != 0 || len(r.SampleBy) != 0 {
+			return errors.New("sampleRate and sampleBy are only valid for sample rules")
+		}
+	case ActionSample:
+		if r.SampleRate <= 0 || r.SampleRate >= 1 {
+			return errors.New("sampleRate must be between 0 and 1")
+		}
+	default:
+		return errors.Errorf("unknown action %q", r.Action)
+	}
+	if (r.Action == ActionRoute) != (r.Prefix != "") {
+		return errors.New("route rules need a prefix, it is only valid for route rules")
+	}
+	if r.Prefix != "" {
+		if !validPrefix.MatchString(r.Prefix) {
+			return errors.Errorf("invalid prefix %q, it must be relative with letters, digits, - and _", r.Prefix)
+		}
+		r.outputPrefix = RouteRoot + strings.TrimSuffix(r.Prefix, "/") + "/"
+	}
+	r.sampleBy = make([][]interface{}, len(r.SampleBy))
+	for i, field := range r.SampleBy {
+		path, err := fieldPath(field)
+		if err != nil {
+			return err
+		}
+		r.sampleBy[i] = path
+	}
+	for _, condition := range r.Match {
+		if err := condition.compile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Condition) compile() (err error) {
+	if c == nil {
+		return errors.New("empty condition")
+	}
+	if c.path, err = fieldPath(c.Field); err != nil {
+		return err
+	}
+	matchers := 0
+	if c.Equals != nil {
+		matchers++
+	}
+	if len(c.In) > 0 {
+		matchers++
+		c.in = make(map[string]bool, len(c.In))
+		for _, value := range c.In {
+			c.in[value] = true
+		}
+	}
+	if len(c.CIDR) > 0 {
+		matchers++
+		for _, cidr := range c.CIDR {
+			_, network, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return errors.Wrapf(err, "invalid cidr of field %q", c.Field)
+			}
+			c.networks = append(c.networks, network)
+		}
+	}
+	if c.Regex != "" {
+		matchers++
+		if c.regex, err = regexp.Compile(c.Regex); err != nil {
+			return errors.Wrapf(err, "invalid regex of field %q", c.Field)
+		}
+	}
+	if matchers != 1 {
+		return errors.Errorf("the condition of field %q needs one of equals, in, cidr or regex", c.Field)
+	}
+	return nil
+}
+
+func fieldPath(field string) ([]interface{}, error) {
+	names := strings.Split(field, ".")
+	path := make([]interface{}, len(names))
+	for i, name := range names {
+		if name == "" {
+			return nil, errors.Errorf("invalid field name %q", field)
+		}
+		path[i] = name
+	}
+	return path, nil
+}
@@ -0,0 +1,66 @@
+package filtering
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	for _, invalid := range []string{
+		`refreshInterval: 1 hour`,
+		`logTypes: {A.B: [{action: erase}]}`,
+		`logTypes: {A.B: [{action: drop, sampleRate: 0.5}]}`,
+		`logTypes: {A.B: [{action: sample}]}`,
+		`logTypes: {A.B: [{action: sample, sampleRate: 1.5}]}`,
+		`logTypes: {A.B: [{action: sample, sampleRate: 0.5, sampleBy: [a..b]}]}`,
+		`logTypes: {A.B: [{action: drop, match: [{field: a}]}]}`,
+		`logTypes: {A.B: [{action: drop, match: [{field: a, in: []}]}]}`,
+		`logTypes: {A.B: [{action: drop, match: [{field: a, equals: x, regex: y}]}]}`,
+		`logTypes: {A.B: [{action: drop, match: [{field: a, cidr: [10.0.0.0/33]}]}]}`,
+		`logTypes: {A.B: [{action: drop, match: [{field: a, regex: "("}]}]}`,
+		`logTypes: {A.B: [{action: drop, match: [{field: "", equals: x}]}]}`,
+		`logTypes: {A.B: [{action: drop, match: [null]}]}`,
+		`sources: {id: [null]}`,
+		`logTypes: {A.B: [{action: route}]}`,
+		`logTypes: {A.B: [{action: drop, prefix: archive}]}`,
+		`logTypes: {A.B: [{action: route, prefix: /archive}]}`,
+		`logTypes: {A.B: [{action: route, prefix: ../logs}]}`,
+		`logTypes: {A.B: [{action: route, prefix: "a//b"}]}`,
+		`unknown: true`,
+	} {
+		_, err := ParseConfig([]byte(invalid))
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestParse(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/filters.yml")
+	require.NoError(t, err)
+	value, interval, err := Parse(data)
+	require.NoError(t, err)
+	require.Equal(t, 10*time.Minute, interval)
+	config := value.(*Config)
+	require.Len(t, config.LogTypes["AWS.VPCFlow"], 2)
+	require.Len(t, config.Sources, 1)
+}
@@ -0,0 +1,146 @@
+package filtering
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"hash/fnv"
+	"math"
+	"net"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Decision is the outcome of the filter rules for an event
+type Decision int
+
+const (
+	// Keep means the event is stored
+	Keep Decision = iota
+	// Drop means a drop rule matched the event
+	Drop
+	// SampleOut means a sample rule matched the event but the event is not in the sample
+	SampleOut
+	// Route means the event is stored under the output prefix of a route rule
+	Route
+)
+
+// Apply evaluates the rules of the source and then the rules of the log type of an event, the first matching rule decides.
+// Events matching no rule are kept. line is the log line the event was parsed from, it is the default key of samples.
+// outputPrefix is the S3 key prefix of the events to Route. It is safe to call on a nil config.
+func (c *Config) Apply(sourceID, logType, line string, event []byte) (decision Decision, outputPrefix string) {
+	if c == nil {
+		return Keep, ""
+	}
+	for _, rules := range [][]*Rule{c.Sources[sourceID], c.LogTypes[logType]} {
+		for _, rule := range rules {
+			if rule.matches(event) {
+				return rule.decide(line, event), rule.outputPrefix
+			}
+		}
+	}
+	return Keep, ""
+}
+
+func (r *Rule) matches(event []byte) bool {
+	for _, condition := range r.Match {
+		if !condition.matches(event) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Rule) decide(line string, event []byte) Decision {
+	switch r.Action {
+	case ActionDrop:
+		return Drop
+	case ActionRoute:
+		return Route
+	case ActionSample:
+		if !r.inSample(line, event) {
+			return SampleOut
+		}
+	}
+	return Keep
+}
+
+// inSample hashes the sample key of an event, so the same events are kept if logs are processed again
+func (r *Rule) inSample(line string, event []byte) bool {
+	h := fnv.New64a()
+	if len(r.sampleBy) == 0 {
+		_, _ = h.Write([]byte(line))
+	}
+	for _, path := range r.sampleBy {
+		_, _ = h.Write([]byte(jsoniter.Get(event, path...).ToString()))
+		_, _ = h.Write([]byte{0})
+	}
+	return float64(mix(h.Sum64())) < r.SampleRate*math.MaxUint64
+}
+
+// mix is the finalizer of MurmurHash3, FNV hashes of similar keys are not uniform enough in their high bits
+func mix(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+func (c *Condition) matches(event []byte) bool {
+	return c.matchesValue(jsoniter.Get(event, c.path...)) != c.Not
+}
+
+func (c *Condition) matchesValue(value jsoniter.Any) bool {
+	switch value.ValueType() {
+	case jsoniter.ArrayValue:
+		for i := 0; i < value.Size(); i++ {
+			if c.matchesValue(value.Get(i)) {
+				return true
+			}
+		}
+		return false
+	case jsoniter.StringValue, jsoniter.NumberValue, jsoniter.BoolValue:
+		return c.matchesString(value.ToString())
+	default:
+		return false
+	}
+}
+
+func (c *Condition) matchesString(value string) bool {
+	switch {
+	case c.Equals != nil:
+		return value == *c.Equals
+	case c.in != nil:
+		return c.in[value]
+	case c.networks != nil:
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return false
+		}
+		for _, network := range c.networks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	default:
+		return c.regex.MatchString(value)
+	}
+}
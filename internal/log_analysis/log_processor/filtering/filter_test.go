@@ -0,0 +1,136 @@
+package filtering
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func decide(config *Config, sourceID, logType, line string, event []byte) Decision {
+	decision, _ := config.Apply(sourceID, logType, line, event)
+	return decision
+}
+
+func TestApply(t *testing.T) {
+	config, err := ParseConfig([]byte(`
+sources:
+  source-id:
+    - action: drop
+      match:
+        - {field: srcAddr, cidr: [10.0.0.0/8, "fd00::/8"]}
+logTypes:
+  AWS.VPCFlow:
+    - action: keep
+      match:
+        - {field: dstPort, in: ["22", "3389"]}
+    - action: drop
+      match:
+        - {field: action, equals: REJECT}
+        - {field: protocol, equals: 17}
+    - action: drop
+      match:
+        - {field: tags, regex: '^debug'}
+    - action: drop
+      match:
+        - {field: account.id, equals: "123456789012", not: true}
+`))
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		SourceID string
+		Event    string
+		Expect   Decision
+	}{
+		{"source-id", `{"srcAddr": "10.1.2.3", "account": {"id": "123456789012"}}`, Drop},
+		{"source-id", `{"srcAddr": "fd00::1", "account": {"id": "123456789012"}}`, Drop},
+		{"other-id", `{"srcAddr": "10.1.2.3", "account": {"id": "123456789012"}}`, Keep},
+		{"source-id", `{"srcAddr": "not an ip", "account": {"id": "123456789012"}}`, Keep},
+		{"", `{"dstPort": 22, "action": "REJECT", "protocol": 17}`, Keep},
+		{"", `{"dstPort": 443, "action": "REJECT", "protocol": 17, "account": {"id": "123456789012"}}`, Drop},
+		{"", `{"dstPort": 443, "action": "REJECT", "protocol": 6, "account": {"id": "123456789012"}}`, Keep},
+		{"", `{"tags": ["prod", "debug-1"], "account": {"id": "123456789012"}}`, Drop},
+		{"", `{"tags": ["prod"], "account": {"id": "123456789012"}}`, Keep},
+		{"", `{"account": {"id": "210987654321"}}`, Drop},
+		{"", `{}`, Drop},
+	} {
+		require.Equal(t, tc.Expect, decide(config, tc.SourceID, "AWS.VPCFlow", "", []byte(tc.Event)), tc.Event)
+	}
+	require.Equal(t, Keep, decide(config, "", "AWS.ALB", "", []byte(`{}`)))
+
+	var none *Config
+	require.Equal(t, Keep, decide(none, "source-id", "AWS.VPCFlow", "", []byte(`{"srcAddr": "10.1.2.3"}`)))
+}
+
+func TestApplySample(t *testing.T) {
+	config, err := ParseConfig([]byte(`
+logTypes:
+  A.Lines:
+    - {action: sample, sampleRate: 0.25}
+  A.Flows:
+    - {action: sample, sampleRate: 0.25, sampleBy: [src, dst]}
+`))
+	require.NoError(t, err)
+
+	kept := 0
+	for i := 0; i < 10000; i++ {
+		line := fmt.Sprintf("line %d", i)
+		decision := decide(config, "", "A.Lines", line, []byte(`{}`))
+		// the sample is deterministic
+		require.Equal(t, decision, decide(config, "", "A.Lines", line, []byte(`{}`)))
+		if decision == Keep {
+			kept++
+		} else {
+			require.Equal(t, SampleOut, decision)
+		}
+	}
+	require.InDelta(t, 2500, kept, 250)
+
+	// events with the same sampleBy values share the decision
+	first := decide(config, "", "A.Flows", "line 1", []byte(`{"src": "10.0.0.1", "dst": "10.0.0.2", "bytes": 1}`))
+	for i := 0; i < 100; i++ {
+		event := fmt.Sprintf(`{"src": "10.0.0.1", "dst": "10.0.0.2", "bytes": %d}`, i)
+		require.Equal(t, first, decide(config, "", "A.Flows", fmt.Sprintf("line %d", i), []byte(event)))
+	}
+}
+
+func TestApplyRoute(t *testing.T) {
+	config, err := ParseConfig([]byte(`
+sources:
+  source-id:
+    - {action: route, prefix: archive/flows, match: [{field: action, equals: REJECT}]}
+logTypes:
+  AWS.VPCFlow:
+    - {action: route, prefix: debug/, match: [{field: tags, regex: '^debug'}]}
+    - {action: drop, match: [{field: action, equals: REJECT}]}
+`))
+	require.NoError(t, err)
+
+	decision, prefix := config.Apply("source-id", "AWS.VPCFlow", "", []byte(`{"action": "REJECT", "tags": ["debug"]}`))
+	require.Equal(t, Route, decision)
+	require.Equal(t, "routed/archive/flows/", prefix)
+	decision, prefix = config.Apply("other-id", "AWS.VPCFlow", "", []byte(`{"action": "REJECT", "tags": ["debug"]}`))
+	require.Equal(t, Route, decision)
+	require.Equal(t, "routed/debug/", prefix)
+	decision, prefix = config.Apply("other-id", "AWS.VPCFlow", "", []byte(`{"action": "REJECT"}`))
+	require.Equal(t, Drop, decision)
+	require.Empty(t, prefix)
+}
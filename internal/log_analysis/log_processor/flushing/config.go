@@ -0,0 +1,155 @@
+package flushing
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/reload"
+)
+
+// Config declares how long and how much the S3 destination buffers the events of log types before storing them.
+// Buffering longer makes fewer, bigger files that are faster to query, at the cost of latency to detection.
+type Config struct {
+	// RefreshInterval is a Go duration (e.g. 30m), defaults to reload.DefaultInterval
+	RefreshInterval string `yaml:"refreshInterval,omitempty" json:"refreshInterval,omitempty"`
+	// LatencySLO is a Go duration that bounds the time events are buffered for all log types, it caps their maxAge
+	LatencySLO string `yaml:"latencySLO,omitempty" json:"latencySLO,omitempty"`
+	// Default is the policy of the log types without one
+	Default *Policy `yaml:"default,omitempty" json:"default,omitempty"`
+	// LogTypes are the policies by log type, unset limits are taken from the default policy
+	LogTypes map[string]*Policy `yaml:"logTypes,omitempty" json:"logTypes,omitempty"`
+
+	latencySLO time.Duration
+}
+
+// Policy decides when a buffer is stored, whichever of its limits is reached first
+type Policy struct {
+	// MaxAge is a Go duration (e.g. 30s), the longest time the first event of a buffer waits to be stored
+	MaxAge string `yaml:"maxAge,omitempty" json:"maxAge,omitempty"`
+	// MaxBytes is the largest size of the compressed events of a buffer
+	MaxBytes int `yaml:"maxBytes,omitempty" json:"maxBytes,omitempty"`
+	// MaxEvents is the largest number of events of a buffer
+	MaxEvents int `yaml:"maxEvents,omitempty" json:"maxEvents,omitempty"`
+
+	maxAge time.Duration
+}
+
+// Limits are the resolved limits of a policy, a zero limit is not applied
+type Limits struct {
+	MaxAge    time.Duration
+	MaxBytes  int
+	MaxEvents int
+}
+
+// ParseConfig reads a config in YAML or JSON format and validates it
+func ParseConfig(data []byte) (*Config, error) {
+	config := Config{}
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, errors.Wrap(err, "invalid flush config")
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Validate checks the config and parses its durations
+func (c *Config) Validate() error {
+	if _, err := c.Interval(); err != nil {
+		return err
+	}
+	if c.LatencySLO != "" {
+		slo, err := time.ParseDuration(c.LatencySLO)
+		if err != nil || slo <= 0 {
+			return errors.Errorf("invalid latency SLO %q", c.LatencySLO)
+		}
+		c.latencySLO = slo
+	}
+	if err := c.Default.compile(); err != nil {
+		return errors.WithMessage(err, "invalid default policy")
+	}
+	for logType, policy := range c.LogTypes {
+		if policy == nil {
+			return errors.Errorf("empty policy for log type %q", logType)
+		}
+		if err := policy.compile(); err != nil {
+			return errors.WithMessagef(err, "invalid policy for log type %q", logType)
+		}
+	}
+	return nil
+}
+
+// Interval returns how often the config is reloaded
+func (c *Config) Interval() (time.Duration, error) {
+	return reload.ParseInterval(c.RefreshInterval)
+}
+
+// Limits returns the limits of a log type. The limits its policy and the default policy leave unset are taken from
+// defaults, the max age is capped by the latency SLO. If the config is nil the defaults are returned.
+func (c *Config) Limits(logType string, defaults Limits) Limits {
+	if c == nil {
+		return defaults
+	}
+	limits := c.LogTypes[logType].apply(c.Default.apply(defaults))
+	if c.latencySLO > 0 && (limits.MaxAge == 0 || limits.MaxAge > c.latencySLO) {
+		limits.MaxAge = c.latencySLO
+	}
+	return limits
+}
+
+func (p *Policy) compile() error {
+	if p == nil {
+		return nil
+	}
+	if p.MaxAge != "" {
+		maxAge, err := time.ParseDuration(p.MaxAge)
+		if err != nil || maxAge <= 0 {
+			return errors.Errorf("invalid max age %q", p.MaxAge)
+		}
+		p.maxAge = maxAge
+	}
+	if p.MaxBytes < 0 {
+		return errors.Errorf("invalid max bytes %d", p.MaxBytes)
+	}
+	if p.MaxEvents < 0 {
+		return errors.Errorf("invalid max events %d", p.MaxEvents)
+	}
+	return nil
+}
+
+// apply overrides the limits set by the policy
+func (p *Policy) apply(limits Limits) Limits {
+	if p == nil {
+		return limits
+	}
+	if p.maxAge > 0 {
+		limits.MaxAge = p.maxAge
+	}
+	if p.MaxBytes > 0 {
+		limits.MaxBytes = p.MaxBytes
+	}
+	if p.MaxEvents > 0 {
+		limits.MaxEvents = p.MaxEvents
+	}
+	return limits
+}
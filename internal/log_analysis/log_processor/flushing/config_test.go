@@ -0,0 +1,86 @@
+package flushing
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	for _, invalid := range []string{
+		`refreshInterval: 1 hour`,
+		`latencySLO: -1m`,
+		`latencySLO: soon`,
+		`default: {maxAge: 0s}`,
+		`default: {maxBytes: -1}`,
+		`logTypes: {A.B: {maxAge: 1 minute}}`,
+		`logTypes: {A.B: {maxEvents: -1}}`,
+		`logTypes: {A.B: null}`,
+		`logTypes: {A.B: {maxRows: 1}}`,
+		`unknown: true`,
+	} {
+		_, err := ParseConfig([]byte(invalid))
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	defaults := Limits{MaxAge: 2 * time.Minute, MaxBytes: 50}
+	var none *Config
+	require.Equal(t, defaults, none.Limits("A.B", defaults))
+
+	config, err := ParseConfig([]byte(`
+default: {maxAge: 1m}
+logTypes:
+  A.B: {maxAge: 30s, maxEvents: 10}
+  C.D: {maxBytes: 20}
+`))
+	require.NoError(t, err)
+	require.Equal(t, Limits{MaxAge: 30 * time.Second, MaxBytes: 50, MaxEvents: 10}, config.Limits("A.B", defaults))
+	require.Equal(t, Limits{MaxAge: time.Minute, MaxBytes: 20}, config.Limits("C.D", defaults))
+	require.Equal(t, Limits{MaxAge: time.Minute, MaxBytes: 50}, config.Limits("E.F", defaults))
+
+	// the latency SLO caps the max age of all log types
+	config, err = ParseConfig([]byte(`
+latencySLO: 45s
+logTypes:
+  A.B: {maxAge: 30s}
+  C.D: {maxAge: 10m}
+`))
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Second, config.Limits("A.B", defaults).MaxAge)
+	require.Equal(t, 45*time.Second, config.Limits("C.D", defaults).MaxAge)
+	require.Equal(t, 45*time.Second, config.Limits("E.F", defaults).MaxAge)
+	require.Equal(t, 45*time.Second, config.Limits("E.F", Limits{}).MaxAge)
+}
+
+func TestParse(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/flush.yml")
+	require.NoError(t, err)
+	value, interval, err := Parse(data)
+	require.NoError(t, err)
+	require.Equal(t, 10*time.Minute, interval)
+	config := value.(*Config)
+	require.Len(t, config.LogTypes, 2)
+	require.Equal(t, Limits{MaxAge: 5 * time.Minute, MaxEvents: 100000}, config.Limits("AWS.CloudTrail", Limits{}))
+}
@@ -0,0 +1,163 @@
+package framing
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/reload"
+)
+
+// Framing modes
+const (
+	// ModeLines reads one event per line, it is the default for sources and log types without a rule
+	ModeLines = "lines"
+	// ModeMultiline groups lines into events with regular expressions (e.g. stack traces)
+	ModeMultiline = "multiline"
+	// ModeJSON reads JSON values spanning multiple lines (e.g. pretty-printed JSON).
+	// The elements of top level arrays are read as separate events.
+	ModeJSON = "json"
+)
+
+const (
+	// DefaultMaxEventSize is the max size in bytes of the events of a rule that does not set it
+	DefaultMaxEventSize = 1024 * 1024
+)
+
+// Config declares the framing rules of sources and log types
+type Config struct {
+	// RefreshInterval is a Go duration (e.g. 30m), defaults to reload.DefaultInterval
+	RefreshInterval string `yaml:"refreshInterval,omitempty" json:"refreshInterval,omitempty"`
+	// Sources are the rules by source integration id, they take precedence over the rules of log types
+	Sources map[string]*Rule `yaml:"sources,omitempty" json:"sources,omitempty"`
+	// LogTypes are the rules by log type, used for the sources declaring the log type
+	LogTypes map[string]*Rule `yaml:"logTypes,omitempty" json:"logTypes,omitempty"`
+}
+
+// Rule declares how a stream is split into events
+type Rule struct {
+	Mode string `yaml:"mode" json:"mode"`
+	// StartPattern matches the first line of multiline events
+	StartPattern string `yaml:"startPattern,omitempty" json:"startPattern,omitempty"`
+	// ContinuationPattern matches the lines that continue multiline events
+	ContinuationPattern string `yaml:"continuationPattern,omitempty" json:"continuationPattern,omitempty"`
+	// MaxEventSize is the max size in bytes of an event, defaults to DefaultMaxEventSize.
+	// Longer multiline events are split at line boundaries, longer JSON values are split in chunks.
+	MaxEventSize int `yaml:"maxEventSize,omitempty" json:"maxEventSize,omitempty"`
+
+	start        *regexp.Regexp
+	continuation *regexp.Regexp
+}
+
+// ParseConfig reads a config in YAML or JSON format and validates it
+func ParseConfig(data []byte) (*Config, error) {
+	config := Config{}
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, errors.Wrap(err, "invalid framing config")
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Validate checks the config and compiles its rules
+func (c *Config) Validate() error {
+	if _, err := c.Interval(); err != nil {
+		return err
+	}
+	for id, rule := range c.Sources {
+		if err := rule.compile(); err != nil {
+			return errors.WithMessagef(err, "invalid rule for source %q", id)
+		}
+	}
+	for logType, rule := range c.LogTypes {
+		if err := rule.compile(); err != nil {
+			return errors.WithMessagef(err, "invalid rule for log type %q", logType)
+		}
+	}
+	return nil
+}
+
+// Interval returns how often the config is reloaded
+func (c *Config) Interval() (time.Duration, error) {
+	return reload.ParseInterval(c.RefreshInterval)
+}
+
+// Rule returns the rule of the source with id sourceID if there is one,
+// otherwise the rule of the first of its log types that has one.
+// It returns nil if the stream should be read line by line.
+// It is safe to call on a nil config.
+func (c *Config) Rule(sourceID string, logTypes []string) *Rule {
+	if c == nil {
+		return nil
+	}
+	if rule, ok := c.Sources[sourceID]; ok {
+		return rule
+	}
+	for _, logType := range logTypes {
+		if rule, ok := c.LogTypes[logType]; ok {
+			return rule
+		}
+	}
+	return nil
+}
+
+func (r *Rule) compile() error {
+	if r == nil {
+		return errors.New("empty rule")
+	}
+	if r.MaxEventSize < 0 {
+		return errors.New("maxEventSize cannot be negative")
+	}
+	if r.MaxEventSize == 0 {
+		r.MaxEventSize = DefaultMaxEventSize
+	}
+	if r.Mode != ModeMultiline && (r.StartPattern != "" || r.ContinuationPattern != "") {
+		return errors.New("patterns are only valid for multiline rules")
+	}
+	switch r.Mode {
+	case ModeLines, ModeJSON:
+	case ModeMultiline:
+		if r.StartPattern == "" && r.ContinuationPattern == "" {
+			return errors.New("multiline rules need a startPattern or a continuationPattern")
+		}
+		var err error
+		if r.start, err = compilePattern(r.StartPattern); err != nil {
+			return errors.WithMessage(err, "invalid startPattern")
+		}
+		if r.continuation, err = compilePattern(r.ContinuationPattern); err != nil {
+			return errors.WithMessage(err, "invalid continuationPattern")
+		}
+	default:
+		return errors.Errorf("unknown mode %q", r.Mode)
+	}
+	return nil
+}
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile(pattern)
+}
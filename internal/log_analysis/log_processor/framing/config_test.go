@@ -0,0 +1,76 @@
+package framing
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	for _, invalid := range []string{
+		`refreshInterval: 1 hour`,
+		`logTypes: {A.B: {mode: paragraphs}}`,
+		`logTypes: {A.B: {mode: multiline}}`,
+		`logTypes: {A.B: {mode: multiline, startPattern: "("}}`,
+		`logTypes: {A.B: {mode: multiline, continuationPattern: "("}}`,
+		`logTypes: {A.B: {mode: json, startPattern: "^{"}}`,
+		`logTypes: {A.B: {mode: json, maxEventSize: -1}}`,
+		`logTypes: {A.B: null}`,
+		`sources: {id: {mode: lines, continuationPattern: "^\\s"}}`,
+		`unknown: true`,
+	} {
+		_, err := ParseConfig([]byte(invalid))
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestConfigRule(t *testing.T) {
+	config, err := ParseConfig([]byte(`
+sources:
+  source-id: {mode: lines}
+logTypes:
+  A.B: {mode: json}
+  C.D: {mode: multiline, continuationPattern: '^\s'}
+`))
+	require.NoError(t, err)
+	require.Equal(t, DefaultMaxEventSize, config.LogTypes["A.B"].MaxEventSize)
+
+	require.Same(t, config.Sources["source-id"], config.Rule("source-id", []string{"A.B"}))
+	require.Same(t, config.LogTypes["C.D"], config.Rule("other-id", []string{"X.Y", "C.D", "A.B"}))
+	require.Nil(t, config.Rule("other-id", []string{"X.Y"}))
+	require.Nil(t, config.Rule("other-id", nil))
+
+	var none *Config
+	require.Nil(t, none.Rule("source-id", []string{"A.B"}))
+}
+
+func TestParse(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/framing.yml")
+	require.NoError(t, err)
+	value, interval, err := Parse(data)
+	require.NoError(t, err)
+	require.Equal(t, 10*time.Minute, interval)
+	config := value.(*Config)
+	require.Equal(t, ModeJSON, config.Rule("", []string{"GitLab.Exceptions"}).Mode)
+	require.Equal(t, ModeMultiline, config.Rule("", []string{"Custom.JavaApp"}).Mode)
+}
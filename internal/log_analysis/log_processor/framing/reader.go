@@ -0,0 +1,279 @@
+package framing
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/common"
+)
+
+// Reader splits a stream of logs into events
+type Reader interface {
+	// ReadEvent returns the next event as it appears in the stream, line delimiters included.
+	// It returns io.EOF after the last event.
+	ReadEvent() (string, error)
+	// Line returns the number, starting at 1, of the first line of the last event read
+	Line() uint64
+}
+
+// NewReader returns a reader splitting r into events with the rule, a nil rule reads one event per line
+func NewReader(r io.Reader, rule *Rule) Reader {
+	lines := lineReader{r: bufio.NewReader(r)}
+	if rule == nil {
+		return &lines
+	}
+	switch rule.Mode {
+	case ModeMultiline:
+		return &multilineReader{lineReader: lines, rule: rule}
+	case ModeJSON:
+		return &jsonReader{r: lines.r, maxSize: rule.MaxEventSize, line: 1}
+	default:
+		return &lines
+	}
+}
+
+type lineReader struct {
+	r         *bufio.Reader
+	line      uint64 // the number of lines read
+	eventLine uint64
+}
+
+func (l *lineReader) ReadEvent() (string, error) {
+	line, err := l.readLine()
+	if err != nil {
+		return "", err
+	}
+	l.eventLine = l.line
+	return line, nil
+}
+
+func (l *lineReader) Line() uint64 {
+	return l.eventLine
+}
+
+func (l *lineReader) readLine() (string, error) {
+	line, err := l.r.ReadString(common.EventDelimiter)
+	if err == io.EOF && line != "" {
+		// the last line has no delimiter, the next read returns io.EOF
+		err = nil
+	}
+	if err != nil {
+		return "", err
+	}
+	l.line++
+	return line, nil
+}
+
+type multilineReader struct {
+	lineReader
+	rule *Rule
+	// the first line of the next event, read while looking for the end of the previous one
+	next     string
+	nextLine uint64
+}
+
+func (m *multilineReader) ReadEvent() (string, error) {
+	if m.next == "" {
+		line, err := m.readLine()
+		if err != nil {
+			return "", err
+		}
+		m.next, m.nextLine = line, m.line
+	}
+	event := []byte(m.next)
+	m.eventLine = m.nextLine
+	m.next = ""
+	for {
+		line, err := m.readLine()
+		if err == io.EOF {
+			return string(event), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if m.rule.startsEvent(line) || len(event)+len(line) > m.rule.MaxEventSize {
+			m.next, m.nextLine = line, m.line
+			return string(event), nil
+		}
+		event = append(event, line...)
+	}
+}
+
+// startsEvent checks if a line is the first line of a multiline event.
+// Lines matching the start pattern start events, and so do lines not matching the continuation pattern if there is one.
+func (r *Rule) startsEvent(line string) bool {
+	line = strings.TrimRight(line, "\r\n")
+	if r.start != nil && r.start.MatchString(line) {
+		return true
+	}
+	return r.continuation != nil && !r.continuation.MatchString(line)
+}
+
+// jsonReader reads JSON objects and arrays, and the elements of top level arrays, without parsing them.
+// Values larger than maxSize are read in chunks. Other top level data are read line by line.
+type jsonReader struct {
+	r         *bufio.Reader
+	maxSize   int
+	line      uint64 // the number of the current line
+	eventLine uint64
+	inArray   bool // inside a top level array
+	// the state of the value being read, kept between the chunks of large values
+	depth    int
+	inString bool
+	escaped  bool
+}
+
+func (j *jsonReader) Line() uint64 {
+	return j.eventLine
+}
+
+func (j *jsonReader) ReadEvent() (string, error) {
+	if j.depth > 0 {
+		// the next chunk of a large value
+		j.eventLine = j.line
+		return j.readValue()
+	}
+	c, err := j.skipSeparators()
+	if err != nil {
+		return "", err
+	}
+	j.eventLine = j.line
+	switch {
+	case c == '{' || c == '[':
+		return j.readValue()
+	case j.inArray:
+		return j.readScalar()
+	default:
+		return j.readLine()
+	}
+}
+
+// skipSeparators skips whitespace and the delimiters of top level arrays and returns the next byte without reading it
+func (j *jsonReader) skipSeparators() (byte, error) {
+	for {
+		c, err := j.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case c == '\n':
+			j.line++
+		case c == ' ' || c == '\t' || c == '\r':
+		case c == '[' && !j.inArray:
+			j.inArray = true
+		case (c == ',' || c == ']') && j.inArray:
+			j.inArray = c == ','
+		default:
+			return c, j.r.UnreadByte()
+		}
+	}
+}
+
+func (j *jsonReader) readValue() (string, error) {
+	var event []byte
+	for {
+		c, err := j.r.ReadByte()
+		if err != nil {
+			// a value truncated by the end of the stream is returned as is
+			j.depth, j.inString, j.escaped = 0, false, false
+			if err == io.EOF && len(event) > 0 {
+				return string(event), nil
+			}
+			return "", err
+		}
+		event = append(event, c)
+		if c == '\n' {
+			j.line++
+		}
+		if j.inString {
+			switch {
+			case j.escaped:
+				j.escaped = false
+			case c == '\\':
+				j.escaped = true
+			case c == '"':
+				j.inString = false
+			}
+		} else {
+			switch c {
+			case '"':
+				j.inString = true
+			case '{', '[':
+				j.depth++
+			case '}', ']':
+				j.depth--
+			}
+		}
+		if j.depth == 0 || len(event) >= j.maxSize {
+			return string(event), nil
+		}
+	}
+}
+
+// readScalar reads a string, number or literal element of a top level array
+func (j *jsonReader) readScalar() (string, error) {
+	var event []byte
+	inString, escaped := false, false
+	for {
+		c, err := j.r.ReadByte()
+		if err == io.EOF && len(event) > 0 {
+			return string(event), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if !inString {
+			switch c {
+			case ',', ']', ' ', '\t', '\r', '\n':
+				return string(event), j.r.UnreadByte()
+			}
+		}
+		event = append(event, c)
+		switch {
+		case c == '\n':
+			j.line++
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		}
+		if len(event) >= j.maxSize {
+			return string(event), nil
+		}
+	}
+}
+
+func (j *jsonReader) readLine() (string, error) {
+	line, err := j.r.ReadString(common.EventDelimiter)
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if strings.HasSuffix(line, "\n") {
+		j.line++
+	}
+	return line, nil
+}
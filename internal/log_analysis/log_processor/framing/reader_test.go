@@ -0,0 +1,129 @@
+package framing
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type event struct {
+	Line uint64
+	Text string
+}
+
+func readEvents(t *testing.T, input string, rule *Rule) (events []event) {
+	if rule != nil {
+		require.NoError(t, rule.compile())
+	}
+	r := NewReader(strings.NewReader(input), rule)
+	for {
+		text, err := r.ReadEvent()
+		if err == io.EOF {
+			return events
+		}
+		require.NoError(t, err)
+		events = append(events, event{Line: r.Line(), Text: text})
+	}
+}
+
+func TestReadLines(t *testing.T) {
+	require.Equal(t, []event{
+		{1, "a\n"},
+		{2, "\n"},
+		{3, "b"},
+	}, readEvents(t, "a\n\nb", nil))
+	require.Equal(t, []event{{1, "a\n"}}, readEvents(t, "a\n", &Rule{Mode: ModeLines}))
+	require.Empty(t, readEvents(t, "", nil))
+}
+
+func TestReadMultiline(t *testing.T) {
+	const input = `2020-06-01 10:00:00 ERROR request failed
+java.lang.IllegalStateException: closed
+	at com.example.Api.handle(Api.java:42)
+	at com.example.Server.run(Server.java:7)
+2020-06-01 10:00:01 INFO request done
+2020-06-01 10:00:02 ERROR retry
+	at com.example.Api.retry(Api.java:50)`
+
+	expect := []event{
+		{1, "2020-06-01 10:00:00 ERROR request failed\njava.lang.IllegalStateException: closed\n" +
+			"\tat com.example.Api.handle(Api.java:42)\n\tat com.example.Server.run(Server.java:7)\n"},
+		{5, "2020-06-01 10:00:01 INFO request done\n"},
+		{6, "2020-06-01 10:00:02 ERROR retry\n\tat com.example.Api.retry(Api.java:50)"},
+	}
+	require.Equal(t, expect, readEvents(t, input, &Rule{
+		Mode:         ModeMultiline,
+		StartPattern: `^\d{4}-\d{2}-\d{2} `,
+	}))
+
+	// lines not starting with whitespace start events
+	require.Equal(t, []event{
+		{1, "ERROR failed\n  File \"app.py\", line 3\n    main()\n"},
+		{4, "INFO done"},
+	}, readEvents(t, "ERROR failed\n  File \"app.py\", line 3\n    main()\nINFO done", &Rule{
+		Mode:                ModeMultiline,
+		ContinuationPattern: `^\s`,
+	}))
+
+	// continuation lines that would exceed the max size start a new event
+	require.Equal(t, []event{
+		{1, "a\n b\n"},
+		{3, " c\n"},
+		{4, "d\n"},
+	}, readEvents(t, "a\n b\n c\nd\n", &Rule{
+		Mode:                ModeMultiline,
+		ContinuationPattern: `^ `,
+		MaxEventSize:        6,
+	}))
+}
+
+func TestReadJSON(t *testing.T) {
+	const input = `{
+  "msg": "a } \" {",
+  "nested": {"a": [1, 2]}
+}
+{"msg": "b"}
+
+[
+  {"msg": "c"},
+  {"msg": "d"}, "e", 42
+]
+not json
+`
+	require.Equal(t, []event{
+		{1, "{\n  \"msg\": \"a } \\\" {\",\n  \"nested\": {\"a\": [1, 2]}\n}"},
+		{5, `{"msg": "b"}`},
+		{8, `{"msg": "c"}`},
+		{9, `{"msg": "d"}`},
+		{9, `"e"`},
+		{9, `42`},
+		{11, "not json\n"},
+	}, readEvents(t, input, &Rule{Mode: ModeJSON}))
+
+	// large values are read in chunks, truncated values are returned at the end of the stream
+	require.Equal(t, []event{
+		{1, `{"a": "bc`},
+		{1, `d"}`},
+		{2, `{"e":`},
+	}, readEvents(t, "{\"a\": \"bcd\"}\n{\"e\":", &Rule{Mode: ModeJSON, MaxEventSize: 9}))
+}
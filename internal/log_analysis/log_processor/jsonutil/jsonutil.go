@@ -19,9 +19,11 @@ package jsonutil
  */
 
 import (
+	"bytes"
 	"strings"
 
 	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
 )
 
 func NewEncoderNamingStrategy(translate func(string) string) jsoniter.Extension {
@@ -54,3 +56,21 @@ func (extension *encoderNamingStrategy) UpdateStructDescriptor(structDescriptor
 		}
 	}
 }
+
+// AppendField adds a field to a JSON object.
+// The field is the quoted field name followed by a colon, e.g. `"p_enrichment":`.
+func AppendField(object []byte, field string, value []byte) ([]byte, error) {
+	object = bytes.TrimRight(object, " \t\r\n")
+	end := len(object) - 1
+	if end < 1 || object[0] != '{' || object[end] != '}' {
+		return nil, errors.New("event is not a JSON object")
+	}
+	data := make([]byte, 0, len(object)+len(field)+len(value)+1)
+	data = append(data, object[:end]...)
+	if len(bytes.TrimSpace(object[1:end])) != 0 {
+		data = append(data, ',')
+	}
+	data = append(data, field...)
+	data = append(data, value...)
+	return append(data, '}'), nil
+}
@@ -46,3 +46,14 @@ func TestEncoderNamingStrategy(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, `{"bar":"foo","baz":"baz"}`, data)
 }
+
+func TestAppendField(t *testing.T) {
+	data, err := AppendField([]byte(`{}`), `"b":`, []byte(`2`))
+	require.NoError(t, err)
+	require.Equal(t, `{"b":2}`, string(data))
+	data, err = AppendField([]byte("{\"a\":1}\n"), `"b":`, []byte(`2`))
+	require.NoError(t, err)
+	require.Equal(t, `{"a":1,"b":2}`, string(data))
+	_, err = AppendField([]byte(`[]`), `"b":`, []byte(`2`))
+	require.Error(t, err)
+}
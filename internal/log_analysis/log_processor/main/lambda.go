@@ -25,6 +25,8 @@ import (
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-lambda-go/lambdacontext"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/common"
@@ -32,15 +34,34 @@ import (
 	"github.com/panther-labs/panther/pkg/lambdalogger"
 )
 
+const kinesisEventSource = "aws:kinesis"
+
 func main() {
 	common.Setup()
 	lambda.Start(handle)
 }
 
-func handle(ctx context.Context, event events.SQSEvent) error {
+// The log processor is triggered by its SQS queue of S3 notifications and by the Kinesis streams of sources
+func handle(ctx context.Context, event jsoniter.RawMessage) error {
 	lc, _ := lambdalogger.ConfigureGlobal(ctx, nil)
+	if isKinesisEvent(event) {
+		kinesisEvent := events.KinesisEvent{}
+		if err := jsoniter.Unmarshal(event, &kinesisEvent); err != nil {
+			return errors.Wrap(err, "failed to unmarshal Kinesis event")
+		}
+		return processKinesis(lc, &kinesisEvent)
+	}
+	sqsEvent := events.SQSEvent{}
+	if err := jsoniter.Unmarshal(event, &sqsEvent); err != nil {
+		return errors.Wrap(err, "failed to unmarshal SQS event")
+	}
 	deadline, _ := ctx.Deadline()
-	return process(lc, deadline, event)
+	return process(lc, deadline, sqsEvent)
+}
+
+// isKinesisEvent checks the event source of the records, all records of an event have the same source
+func isKinesisEvent(event []byte) bool {
+	return jsoniter.Get(event, "Records", 0, "eventSource").ToString() == kinesisEventSource
 }
 
 func process(lc *lambdacontext.LambdaContext, deadline time.Time, event events.SQSEvent) (err error) {
@@ -55,3 +76,16 @@ func process(lc *lambdacontext.LambdaContext, deadline time.Time, event events.S
 	sqsMessageCount, err = processor.StreamEvents(common.SqsClient, deadline, event)
 	return err
 }
+
+func processKinesis(lc *lambdacontext.LambdaContext, event *events.KinesisEvent) (err error) {
+	operation := common.OpLogManager.Start(lc.InvokedFunctionArn, common.OpLogLambdaServiceDim).WithMemUsed(lambdacontext.MemoryLimitInMB)
+
+	var kinesisRecordCount int
+
+	defer func() {
+		operation.Stop().Log(err, zap.Int("kinesisRecordCount", kinesisRecordCount))
+	}()
+
+	kinesisRecordCount, err = processor.ProcessKinesisEvent(event)
+	return err
+}
@@ -68,3 +68,9 @@ func TestProcessOpLog(t *testing.T) {
 		t.Errorf("unknown type for sqsMessageCount: %#v", sqsMessageCount)
 	}
 }
+
+func TestIsKinesisEvent(t *testing.T) {
+	assert.True(t, isKinesisEvent([]byte(`{"Records": [{"eventSource": "aws:kinesis", "kinesis": {"data": ""}}]}`)))
+	assert.False(t, isKinesisEvent([]byte(`{"Records": [{"eventSource": "aws:sqs", "body": ""}]}`)))
+	assert.False(t, isKinesisEvent([]byte(`{"Records": []}`)))
+}
@@ -19,6 +19,8 @@ package awslogs
  */
 
 import (
+	"path"
+
 	jsoniter "github.com/json-iterator/go"
 
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
@@ -160,11 +162,18 @@ func (event *CloudTrail) updatePantherFields(p *CloudTrailParser) {
 	if event.UserIdentity != nil {
 		event.AppendAnyAWSAccountIdPtrs(event.UserIdentity.AccountID)
 		event.AppendAnyAWSARNPtrs(event.UserIdentity.ARN)
+		event.AppendAnyUsernamePtrs(event.UserIdentity.Username)
+		event.AppendAnyCloudPrincipalPtrs(event.UserIdentity.ARN, event.UserIdentity.PrincipalID)
+		if event.UserIdentity.ARN != nil {
+			// the session name of SSO and federated users is often their email
+			event.AppendAnyEmails(path.Base(*event.UserIdentity.ARN))
+		}
 
 		if event.UserIdentity.SessionContext != nil {
 			if event.UserIdentity.SessionContext.SessionIssuer != nil {
 				event.AppendAnyAWSAccountIdPtrs(event.UserIdentity.SessionContext.SessionIssuer.AccountID)
 				event.AppendAnyAWSARNPtrs(event.UserIdentity.SessionContext.SessionIssuer.Arn)
+				event.AppendAnyCloudPrincipalPtrs(event.UserIdentity.SessionContext.SessionIssuer.Arn)
 			}
 		}
 	}
@@ -134,6 +134,9 @@ func TestCloudTrailLogDecrypt(t *testing.T) {
 		"arn:aws:lambda:us-east-1:888888888888:function:panther-log-processor")
 	expectedEvent.AppendAnyAWSAccountIds("888888888888")
 	expectedEvent.AppendAnyIPAddress("1.2.3.4")
+	expectedEvent.AppendAnyCloudPrincipals("AROAQXSBWDWTDYDZAXXXX:panther-log-processor",
+		"arn:aws:iam::888888888888:role/panther-app-LogProcessor-XXXXXXXXXXXX-FunctionRole-XXXXXXXXXX",
+		"arn:aws:sts::888888888888:assumed-role/panther-app-LogProcessor-XXXXXXXXXXXX-FunctionRole-XXXXXXXXXX/panther-log-processor")
 
 	checkCloudTrailLog(t, log, expectedEvent)
 }
@@ -128,5 +128,6 @@ func TestCloudWatchEventCloudTrail(t *testing.T) {
 	expectedEvent.SetEvent(expectedEvent)
 	expectedEvent.AppendAnyAWSAccountIds("123456789012")
 	expectedEvent.AppendAnyAWSARNs("arn:aws:iam::123456789012:root")
+	expectedEvent.AppendAnyCloudPrincipals("123456789012", "arn:aws:iam::123456789012:root")
 	testutil.CheckPantherParser(t, fmt.Sprintf(log, logDetail), (&CloudWatchEventParser{}).New(), &expectedEvent.PantherLog)
 }
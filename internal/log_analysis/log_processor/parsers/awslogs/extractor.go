@@ -23,20 +23,28 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws/arn"
 	"github.com/tidwall/gjson"
+
+	"github.com/panther-labs/panther/pkg/extract"
 )
 
 // extracts useful AWS features that can be detected generically (w/context)
 type AWSExtractor struct {
 	pl *AWSPantherLog
+	// extracts the standard fields of all log types (emails, usernames, cloud principals)
+	indicators extract.Extractor
 }
 
 func NewAWSExtractor(pl *AWSPantherLog) *AWSExtractor {
-	return &AWSExtractor{pl: pl}
+	return &AWSExtractor{
+		pl:         pl,
+		indicators: pl.IndicatorExtractor(),
+	}
 }
 
 func (e *AWSExtractor) Extract(key, value gjson.Result) {
 	// NOTE: add tests as you add new extractions!
 	// NOTE: be very careful returning early, keep sure following code does not need to execute
+	e.indicators.Extract(key, value)
 
 	// value based matching
 	if strings.HasPrefix(value.Str, "arn:") {
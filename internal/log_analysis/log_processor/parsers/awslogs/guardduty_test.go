@@ -70,6 +70,8 @@ func TestGuardDutyLogIAMUserLoggingConfigurationModified(t *testing.T) {
 	expectedEvent.AppendAnyAWSAccountIds("123456789012")
 	// nolint(lll)
 	expectedEvent.AppendAnyAWSARNs("arn:aws:guardduty:eu-west-1:123456789012:detector/b2b7c4e8df224d1b74bece34cc2cf1d5/finding/44b7c4e9781822beb75d3fbd518abf5b")
+	expectedEvent.AppendAnyCloudPrincipals("GeneratedFindingPrincipalId")
+	expectedEvent.AppendAnyUsernames("GeneratedFindingUserName")
 
 	checkGuardDutyLog(t, log, expectedEvent)
 }
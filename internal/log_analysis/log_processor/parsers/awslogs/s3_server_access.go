@@ -156,4 +156,6 @@ func (event *S3ServerAccess) updatePantherFields(p *S3ServerAccessParser) {
 	if event.Requester != nil && strings.HasPrefix(*event.Requester, "arn:") {
 		event.AppendAnyAWSARNs(*event.Requester)
 	}
+	// the canonical user id, IAM ARN or service principal of authenticated requests
+	event.AppendAnyCloudPrincipalPtrs(event.Requester)
 }
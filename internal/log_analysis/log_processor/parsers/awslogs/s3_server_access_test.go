@@ -59,6 +59,7 @@ func TestS3AccessLogGetHttpOk(t *testing.T) {
 	expectedEvent.PantherLogType = aws.String("AWS.S3ServerAccess")
 	expectedEvent.PantherEventTime = (*timestamp.RFC3339)(&date)
 	expectedEvent.AppendAnyIPAddress("192.0.2.3")
+	expectedEvent.AppendAnyCloudPrincipals("79a59df900b949e55d96a1e698fbacedfd6e09d98eacf8f8d5218e7cd47ef2be")
 
 	checkS3AccessLog(t, log, expectedEvent)
 }
@@ -94,6 +95,7 @@ func TestS3AccessLogGetHttpNotFound(t *testing.T) {
 	expectedEvent.PantherLogType = aws.String("AWS.S3ServerAccess")
 	expectedEvent.PantherEventTime = (*timestamp.RFC3339)(&date)
 	expectedEvent.AppendAnyIPAddress("192.0.2.3")
+	expectedEvent.AppendAnyCloudPrincipals("79a59df900b949e55d96a1e698fbacedfd6e09d98eacf8f8d5218e7cd47ef2be")
 
 	checkS3AccessLog(t, log, expectedEvent)
 }
@@ -131,6 +133,7 @@ func TestS3AccessLogPutHttpOK(t *testing.T) {
 	expectedEvent.PantherEventTime = (*timestamp.RFC3339)(&date)
 	expectedEvent.AppendAnyIPAddress("192.0.2.3")
 	expectedEvent.AppendAnyAWSARNs("arn:aws:sts::123456789012:assumed-role/PantherLogProcessingRole/1579693334126446707")
+	expectedEvent.AppendAnyCloudPrincipals("arn:aws:sts::123456789012:assumed-role/PantherLogProcessingRole/1579693334126446707")
 
 	checkS3AccessLog(t, log, expectedEvent)
 }
@@ -168,6 +171,7 @@ func TestS3AccessLogPutHttpOKExtraFields(t *testing.T) {
 	expectedEvent.PantherLogType = aws.String("AWS.S3ServerAccess")
 	expectedEvent.PantherEventTime = (*timestamp.RFC3339)(&date)
 	expectedEvent.AppendAnyIPAddress("192.0.2.3")
+	expectedEvent.AppendAnyCloudPrincipals("79a59df900b949e55d96a1e698fbacedfd6e09d98eacf8f8d5218e7cd47ef2be")
 
 	checkS3AccessLog(t, log, expectedEvent)
 }
@@ -193,6 +197,7 @@ func TestS3AccessLogExpireNoHttpStatusObject(t *testing.T) {
 	// panther fields
 	expectedEvent.PantherLogType = aws.String("AWS.S3ServerAccess")
 	expectedEvent.PantherEventTime = (*timestamp.RFC3339)(&date)
+	expectedEvent.AppendAnyCloudPrincipals("AmazonS3")
 
 	checkS3AccessLog(t, log, expectedEvent)
 }
@@ -0,0 +1,351 @@
+package ceflogs
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/sysloglogs"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/timestamp"
+)
+
+const (
+	cefPrefix = "CEF:"
+	// Version|Device Vendor|Device Product|Device Version|Device Event Class ID|Name|Severity|Extension
+	cefHeaderFields = 7
+)
+
+var (
+	// customKeyRegex matches the keys of the extension fields that are labeled by a `<key>Label` field
+	customKeyRegex = regexp.MustCompile(`^(?:cs[1-6]|cn[1-3]|cfp[1-4]|c6a[1-4]|flexString[12]|flexNumber[12]|flexDate1|` +
+		`deviceCustom(?:String[1-6]|Number[1-3]|FloatingPoint[1-4]|IPv6Address[1-4]|Date[12])|deviceFlex(?:String[12]|Number[12]|Date1))$`)
+
+	// Extension keys (short and full names) of IP addresses
+	ipKeys = map[string]bool{
+		"src": true, "sourceAddress": true,
+		"dst": true, "destinationAddress": true,
+		"dvc": true, "deviceAddress": true,
+		"agt": true, "agentAddress": true,
+		"sourceTranslatedAddress":      true,
+		"destinationTranslatedAddress": true,
+		"deviceTranslatedAddress":      true,
+		"c6a1":                         true, "deviceCustomIPv6Address1": true,
+		"c6a2": true, "deviceCustomIPv6Address2": true,
+		"c6a3": true, "deviceCustomIPv6Address3": true,
+		"c6a4": true, "deviceCustomIPv6Address4": true,
+	}
+	// Extension keys (short and full names) of host names, they can also be IP addresses
+	hostKeys = map[string]bool{
+		"shost": true, "sourceHostName": true,
+		"dhost": true, "destinationHostName": true,
+		"dvchost": true, "deviceHostName": true,
+		"ahost": true, "agentHostName": true,
+		"sourceDnsDomain":      true,
+		"destinationDnsDomain": true,
+		"deviceDnsDomain":      true,
+	}
+	// Extension keys of file hashes, the algorithm is not specified
+	hashKeys = map[string]bool{
+		"fileHash":    true,
+		"oldFileHash": true,
+	}
+	// Extension keys of the event time, in order of preference
+	timeKeys = []string{"rt", "deviceReceiptTime", "end", "endTime", "start", "startTime"}
+	// Time formats of the extension, besides milliseconds since the epoch
+	timeLayouts = []string{
+		"Jan 02 2006 15:04:05.000 MST",
+		"Jan 02 2006 15:04:05 MST",
+		"Jan 02 2006 15:04:05.000",
+		"Jan 02 2006 15:04:05",
+		"Jan _2 2006 15:04:05.000 MST",
+		"Jan _2 2006 15:04:05 MST",
+		"Jan _2 2006 15:04:05.000",
+		"Jan _2 2006 15:04:05",
+		time.RFC3339Nano,
+	}
+)
+
+// nolint:lll
+type CEF struct {
+	Syslog             *sysloglogs.Envelope `json:"syslog,omitempty" description:"The syslog envelope of the event, if it was sent over syslog."`
+	Version            *int                 `json:"version" validate:"required" description:"The version of the CEF format."`
+	DeviceVendor       *string              `json:"deviceVendor" validate:"required" description:"The vendor of the sending device."`
+	DeviceProduct      *string              `json:"deviceProduct" validate:"required" description:"The product of the sending device."`
+	DeviceVersion      *string              `json:"deviceVersion" validate:"required" description:"The version of the sending device."`
+	DeviceEventClassID *string              `json:"deviceEventClassId" validate:"required" description:"The unique identifier of the event type (signature ID)."`
+	Name               *string              `json:"name" validate:"required" description:"A human-readable description of the event."`
+	Severity           *string              `json:"severity" validate:"required" description:"The importance of the event, 0 to 10 or Unknown, Low, Medium, High, Very-High."`
+	Extension          map[string]string    `json:"extension,omitempty" description:"The key value pairs of the extension, except custom fields."`
+	CustomFields       map[string]string    `json:"customFields,omitempty" description:"The custom fields of the extension (e.g. cs1) by label (e.g. the value of cs1Label). Fields without label, or whose label names another field, are keyed by name."`
+
+	// NOTE: added to end of struct to allow expansion later
+	parsers.PantherLog
+}
+
+// CEFParser parses CEF events
+type CEFParser struct {
+	envelope *sysloglogs.EnvelopeParser
+}
+
+var _ parsers.LogParser = (*CEFParser)(nil)
+
+// New returns an initialized LogParser for CEF events
+func (p *CEFParser) New() parsers.LogParser {
+	return &CEFParser{
+		envelope: sysloglogs.NewEnvelopeParser(),
+	}
+}
+
+// LogType returns the log type supported by this parser
+func (p *CEFParser) LogType() string {
+	return TypeCEF
+}
+
+// Parse returns the parsed events or nil if parsing failed
+func (p *CEFParser) Parse(log string) ([]*parsers.PantherLog, error) {
+	event := &CEF{}
+	if !strings.HasPrefix(log, cefPrefix) {
+		if p.envelope == nil || !strings.Contains(log, cefPrefix) {
+			return nil, errors.New("not a CEF event")
+		}
+		envelope, message, err := p.envelope.Parse(log)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(message, cefPrefix) {
+			return nil, errors.New("syslog message is not a CEF event")
+		}
+		event.Syslog, log = envelope, message
+	}
+
+	header, extension, err := splitHeader(strings.TrimPrefix(log, cefPrefix))
+	if err != nil {
+		return nil, err
+	}
+	version, err := strconv.Atoi(header[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid CEF version")
+	}
+	event.Version = &version
+	event.DeviceVendor = &header[1]
+	event.DeviceProduct = &header[2]
+	event.DeviceVersion = &header[3]
+	event.DeviceEventClassID = &header[4]
+	event.Name = &header[5]
+	event.Severity = &header[6]
+
+	fields, err := parseExtension(extension)
+	if err != nil {
+		return nil, err
+	}
+	event.updatePantherFields(fields)
+	event.Extension, event.CustomFields = splitCustomFields(fields)
+
+	if err := parsers.Validator.Struct(event); err != nil {
+		return nil, err
+	}
+	return event.Logs(), nil
+}
+
+func (event *CEF) updatePantherFields(fields map[string]string) {
+	var eventTime *timestamp.RFC3339
+	for _, key := range timeKeys {
+		if value, ok := fields[key]; ok {
+			if tm, err := parseTime(value); err == nil {
+				eventTime = (*timestamp.RFC3339)(&tm)
+				break
+			}
+		}
+	}
+	if eventTime == nil && event.Syslog != nil {
+		eventTime = event.Syslog.Timestamp
+	}
+	event.SetCoreFields(TypeCEF, eventTime, event)
+
+	if event.Syslog != nil && !event.AppendAnyIPAddressPtr(event.Syslog.Hostname) {
+		event.AppendAnyDomainNamePtrs(event.Syslog.Hostname)
+	}
+	for key, value := range fields {
+		switch {
+		case ipKeys[key]:
+			event.AppendAnyIPAddress(value)
+		case hostKeys[key]:
+			if !event.AppendAnyIPAddress(value) {
+				event.AppendAnyDomainNames(value)
+			}
+		case hashKeys[key]:
+			event.appendHash(value)
+		}
+	}
+}
+
+// appendHash adds a hash of unknown algorithm to the matching field, based on its length
+func (event *CEF) appendHash(value string) {
+	switch len(value) {
+	case 32:
+		event.AppendAnyMD5Hashes(value)
+	case 40:
+		event.AppendAnySHA1Hashes(value)
+	case 64:
+		event.AppendAnySHA256Hashes(value)
+	}
+}
+
+// splitHeader splits the header fields, the last element is the extension
+func splitHeader(log string) ([]string, string, error) {
+	header := make([]string, 0, cefHeaderFields)
+	var field strings.Builder
+	for i := 0; i < len(log); i++ {
+		switch c := log[i]; c {
+		case '\\':
+			// Only pipes and backslashes are escaped in the header
+			if i+1 < len(log) && (log[i+1] == '|' || log[i+1] == '\\') {
+				i++
+				c = log[i]
+			}
+			field.WriteByte(c)
+		case '|':
+			header = append(header, field.String())
+			field.Reset()
+			if len(header) == cefHeaderFields {
+				return header, log[i+1:], nil
+			}
+		default:
+			field.WriteByte(c)
+		}
+	}
+	return nil, "", errors.Errorf("invalid CEF header, expected %d fields", cefHeaderFields)
+}
+
+// parseExtension parses space separated key=value pairs.
+// Values can contain spaces, equal signs in values must be escaped.
+func parseExtension(extension string) (map[string]string, error) {
+	fields := make(map[string]string)
+	var key string
+	valueStart := 0
+	for i := 0; i < len(extension); i++ {
+		switch extension[i] {
+		case '\\':
+			i++ // skip the escaped character
+		case '=':
+			keyStart := i
+			for keyStart > 0 && isKeyChar(extension[keyStart-1]) {
+				keyStart--
+			}
+			if keyStart == i || (keyStart > 0 && extension[keyStart-1] != ' ') {
+				continue // not preceded by a key, the equal sign is part of the value
+			}
+			if key != "" {
+				fields[key] = unescapeValue(strings.TrimRight(extension[valueStart:keyStart], " "))
+			} else if strings.TrimSpace(extension[:keyStart]) != "" {
+				return nil, errors.New("invalid CEF extension, expected key=value pairs")
+			}
+			key, valueStart = extension[keyStart:i], i+1
+		}
+	}
+	if key != "" {
+		fields[key] = unescapeValue(strings.TrimRight(extension[valueStart:], " "))
+	} else if strings.TrimSpace(extension) != "" {
+		return nil, errors.New("invalid CEF extension, expected key=value pairs")
+	}
+	return fields, nil
+}
+
+func isKeyChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_' || c == '.' || c == '-'
+}
+
+func unescapeValue(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c == '\\' && i+1 < len(value) {
+			i++
+			switch c = value[i]; c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case '=', '\\', '|':
+			default:
+				b.WriteByte('\\') // not an escape sequence
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// splitCustomFields moves the custom fields and their labels out of the extension.
+// Custom fields are named by their label, unless it is the key of a custom field or the label of a previous key
+// in sorted order, so that the names do not depend on the order of the extension.
+func splitCustomFields(fields map[string]string) (extension, custom map[string]string) {
+	var keys []string
+	for key := range fields {
+		if customKeyRegex.MatchString(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if custom == nil {
+			custom = make(map[string]string)
+		}
+		name := key
+		if label := fields[key+"Label"]; label != "" && !customKeyRegex.MatchString(label) {
+			if _, duplicate := custom[label]; !duplicate {
+				name = label
+			}
+		}
+		custom[name] = fields[key]
+	}
+	for key := range fields {
+		if customKeyRegex.MatchString(key) || customKeyRegex.MatchString(strings.TrimSuffix(key, "Label")) {
+			continue
+		}
+		if extension == nil {
+			extension = make(map[string]string)
+		}
+		extension[key] = fields[key]
+	}
+	return extension, custom
+}
+
+// parseTime parses extension timestamps, they are milliseconds since the epoch or dates
+func parseTime(value string) (time.Time, error) {
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(0, ms*int64(time.Millisecond)).UTC(), nil
+	}
+	for _, layout := range timeLayouts {
+		if tm, err := time.Parse(layout, value); err == nil {
+			return tm.UTC(), nil
+		}
+	}
+	return time.Time{}, errors.Errorf("invalid CEF timestamp %q", value)
+}
@@ -0,0 +1,183 @@
+package ceflogs
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/sysloglogs"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/testutil"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/timestamp"
+)
+
+func TestCEF(t *testing.T) {
+	//nolint:lll
+	log := `CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1 dst=2.1.2.2 spt=1232 rt=1600504570000 shost=host.example.com msg=Detected a threat. No action needed cs1Label=Policy cs1=Block all\=true fileHash=d41d8cd98f00b204e9800998ecf8427e`
+
+	expectedTime := time.Date(2020, 9, 19, 8, 36, 10, 0, time.UTC)
+	expectedEvent := &CEF{
+		Version:            aws.Int(0),
+		DeviceVendor:       aws.String("Security"),
+		DeviceProduct:      aws.String("threatmanager"),
+		DeviceVersion:      aws.String("1.0"),
+		DeviceEventClassID: aws.String("100"),
+		Name:               aws.String("worm successfully stopped"),
+		Severity:           aws.String("10"),
+		Extension: map[string]string{
+			"src":      "10.0.0.1",
+			"dst":      "2.1.2.2",
+			"spt":      "1232",
+			"rt":       "1600504570000",
+			"shost":    "host.example.com",
+			"msg":      "Detected a threat. No action needed",
+			"fileHash": "d41d8cd98f00b204e9800998ecf8427e",
+		},
+		CustomFields: map[string]string{
+			"Policy": "Block all=true",
+		},
+	}
+	expectedEvent.SetCoreFields(TypeCEF, (*timestamp.RFC3339)(&expectedTime), expectedEvent)
+	expectedEvent.AppendAnyIPAddress("10.0.0.1")
+	expectedEvent.AppendAnyIPAddress("2.1.2.2")
+	expectedEvent.AppendAnyDomainNames("host.example.com")
+	expectedEvent.AppendAnyMD5Hashes("d41d8cd98f00b204e9800998ecf8427e")
+
+	checkCEF(t, log, expectedEvent)
+}
+
+func TestCEFSyslog(t *testing.T) {
+	//nolint:lll
+	log := `<134>Sep 19 08:26:10 fw01 CEF:0|Palo Alto Networks|PAN-OS|9.1|end|TRAFFIC|1|rt=Sep 19 2020 08:26:10 UTC deviceExternalId=0001 cs3Label=Virtual System cs3=vsys1 cn1=42`
+
+	expectedTime := time.Date(2020, 9, 19, 8, 26, 10, 0, time.UTC)
+	syslogTime := time.Date(time.Now().UTC().Year(), 9, 19, 8, 26, 10, 0, time.UTC)
+	expectedEvent := &CEF{
+		Syslog: &sysloglogs.Envelope{
+			Priority:  aws.Uint8(134),
+			Facility:  aws.Uint8(16),
+			Severity:  aws.Uint8(6),
+			Timestamp: (*timestamp.RFC3339)(&syslogTime),
+			Hostname:  aws.String("fw01"),
+		},
+		Version:            aws.Int(0),
+		DeviceVendor:       aws.String("Palo Alto Networks"),
+		DeviceProduct:      aws.String("PAN-OS"),
+		DeviceVersion:      aws.String("9.1"),
+		DeviceEventClassID: aws.String("end"),
+		Name:               aws.String("TRAFFIC"),
+		Severity:           aws.String("1"),
+		Extension: map[string]string{
+			"rt":               "Sep 19 2020 08:26:10 UTC",
+			"deviceExternalId": "0001",
+		},
+		CustomFields: map[string]string{
+			"Virtual System": "vsys1",
+			"cn1":            "42",
+		},
+	}
+	expectedEvent.SetCoreFields(TypeCEF, (*timestamp.RFC3339)(&expectedTime), expectedEvent)
+	expectedEvent.AppendAnyDomainNames("fw01")
+
+	checkCEF(t, log, expectedEvent)
+}
+
+func TestCEFEscapedHeader(t *testing.T) {
+	log := `CEF:1|Vendor\|Inc|Product\\X|1.0|42|Name|Low|`
+
+	parser := (&CEFParser{}).New()
+	logs, err := parser.Parse(log)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	event := logs[0].Event().(*CEF)
+	require.Equal(t, "Vendor|Inc", *event.DeviceVendor)
+	require.Equal(t, `Product\X`, *event.DeviceProduct)
+	require.Equal(t, "Low", *event.Severity)
+	require.Nil(t, event.Extension)
+	require.Nil(t, event.CustomFields)
+	// no timestamp, the parse time is used
+	require.NotNil(t, event.PantherEventTime)
+}
+
+func TestCEFInvalid(t *testing.T) {
+	parser := (&CEFParser{}).New()
+	for _, log := range []string{
+		`{"not": "cef"}`,
+		`CEF:0|a|b|c|d|e`,
+		`CEF:x|a|b|c|d|e|f|`,
+		`CEF:0|a|b|c|d|e|f|no pairs`,
+		`<134>Sep 19 08:26:10 host app: not CEF:0|a|b|c|d|e|f|`,
+	} {
+		logs, err := parser.Parse(log)
+		require.Error(t, err, log)
+		require.Nil(t, logs)
+	}
+}
+
+func TestParseExtension(t *testing.T) {
+	fields, err := parseExtension(`act=blocked a \= in value request=https://example.com/?a=b&c=d msg=line1\nline2 cs1=C:\\temp  `)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"act":     "blocked a = in value",
+		"request": "https://example.com/?a=b&c=d",
+		"msg":     "line1\nline2",
+		"cs1":     `C:\temp`,
+	}, fields)
+}
+
+func TestSplitCustomFields(t *testing.T) {
+	fields := map[string]string{
+		"src":      "10.0.0.1",
+		"cs1Label": "Policy",
+		"cs1":      "first",
+		"cs2Label": "Policy",
+		"cs2":      "second",
+		"cs3Label": "cs4",
+		"cs3":      "third",
+		"cs4":      "fourth",
+		"cn1":      "1",
+	}
+	for i := 0; i < 10; i++ {
+		extension, custom := splitCustomFields(fields)
+		require.Equal(t, map[string]string{"src": "10.0.0.1"}, extension)
+		// colliding labels name the first key in sorted order, labels never replace the key of another field
+		require.Equal(t, map[string]string{
+			"Policy": "first",
+			"cs2":    "second",
+			"cs3":    "third",
+			"cs4":    "fourth",
+			"cn1":    "1",
+		}, custom)
+	}
+}
+
+func TestCEFType(t *testing.T) {
+	parser := &CEFParser{}
+	require.Equal(t, "CEF.Event", parser.LogType())
+}
+
+func checkCEF(t *testing.T, log string, expectedEvent *CEF) {
+	expectedEvent.SetEvent(expectedEvent)
+	parser := (&CEFParser{}).New()
+	logs, err := parser.Parse(log)
+	testutil.EqualPantherLog(t, expectedEvent.Log(), logs, err)
+}
@@ -0,0 +1,58 @@
+package parsers
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// PantherEnrichment is the context added to rows by the enrichment stage of the log processor
+// nolint(lll)
+type PantherEnrichment struct {
+	GeoIP       []EnrichmentGeoIP   `json:"geoip,omitempty" description:"Location and network owner of the IP addresses of the row"`
+	AWSAccounts []EnrichmentAccount `json:"aws_accounts,omitempty" description:"Names of the AWS accounts of the row"`
+	Lookups     []EnrichmentLookup  `json:"lookups,omitempty" description:"Rows of lookup tables matching values of the row"`
+}
+
+// IsEmpty returns true if no enrichment source matched the row
+func (e *PantherEnrichment) IsEmpty() bool {
+	return len(e.GeoIP) == 0 && len(e.AWSAccounts) == 0 && len(e.Lookups) == 0
+}
+
+// EnrichmentGeoIP is the location and network owner of an IP address
+type EnrichmentGeoIP struct {
+	IP             string   `json:"ip" description:"The IP address"`
+	CountryCode    string   `json:"country_code,omitempty" description:"The ISO 3166-1 code of the country"`
+	Country        string   `json:"country,omitempty" description:"The name of the country"`
+	Region         string   `json:"region,omitempty" description:"The name of the region (e.g. state or province)"`
+	City           string   `json:"city,omitempty" description:"The name of the city"`
+	Latitude       *float64 `json:"latitude,omitempty" description:"The approximate latitude"`
+	Longitude      *float64 `json:"longitude,omitempty" description:"The approximate longitude"`
+	ASN            *int64   `json:"asn,omitempty" description:"The autonomous system number of the network"`
+	ASOrganization string   `json:"as_organization,omitempty" description:"The organization owning the autonomous system"`
+}
+
+// EnrichmentAccount is the name of an AWS account
+type EnrichmentAccount struct {
+	AccountID string `json:"account_id" description:"The AWS account id"`
+	Name      string `json:"name" description:"The name of the AWS account"`
+}
+
+// EnrichmentLookup is a row of a lookup table matching a value of the row
+type EnrichmentLookup struct {
+	Table  string            `json:"table" description:"The name of the lookup table"`
+	Key    string            `json:"key" description:"The value of the row matching the key of the lookup table"`
+	Values map[string]string `json:"values,omitempty" description:"The columns of the lookup table row"`
+}
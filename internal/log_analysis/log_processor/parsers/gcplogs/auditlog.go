@@ -25,6 +25,7 @@ import (
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/numerics"
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/timestamp"
+	"github.com/panther-labs/panther/pkg/extract"
 )
 
 type LogEntryAuditLog struct {
@@ -85,6 +86,14 @@ func (p *AuditLogParser) Parse(log string) ([]*parsers.PantherLog, error) {
 	if meta := entry.Payload.RequestMetadata; meta != nil {
 		entry.AppendAnyIPAddressPtr(meta.CallerIP)
 	}
+	if info := entry.Payload.AuthenticationInfo; info != nil {
+		entry.AppendAnyEmailPtrs(info.PrincipalEmail)
+		entry.AppendAnyCloudPrincipalPtrs(info.PrincipalEmail)
+	}
+	indicators := entry.IndicatorExtractor()
+	extract.Extract(&entry.Payload.Request, indicators)
+	extract.Extract(&entry.Payload.Response, indicators)
+	extract.Extract(&entry.Payload.ServiceData, indicators)
 	if err := parsers.Validator.Struct(entry); err != nil {
 		return nil, err
 	}
@@ -139,6 +139,7 @@ func TestAuditLogParserActivity(t *testing.T) {
 
 	entry.SetCoreFields(TypeAuditLog, entry.Timestamp, entry)
 	entry.AppendAnyIPAddress("35.238.150.117")
+	entry.AppendAnyCloudPrincipals("system:serviceaccount:monitoring:prometheus-k8s")
 	testutil.CheckPantherParser(t, log, NewAuditLogParser(), &entry.PantherLog)
 }
 
@@ -231,6 +232,8 @@ func TestAuditLogParserSystemEvent(t *testing.T) {
 	}
 
 	entry.SetCoreFields(TypeAuditLog, entry.Timestamp, entry)
+	entry.AppendAnyEmails("system@google.com")
+	entry.AppendAnyCloudPrincipals("system@google.com")
 	testutil.CheckPantherParser(t, log, NewAuditLogParser(), &entry.PantherLog)
 }
 
@@ -373,5 +376,7 @@ func TestAuditLogParserActivityBug(t *testing.T) {
 
 	entry.SetCoreFields(TypeAuditLog, entry.Timestamp, entry)
 	entry.AppendAnyIPAddress("0:0:0:0:0:0:0:1")
+	entry.AppendAnyEmails("test@runpanther.io")
+	entry.AppendAnyCloudPrincipals("test@runpanther.io")
 	testutil.CheckPantherParser(t, log, NewAuditLogParser(), &entry.PantherLog)
 }
@@ -96,4 +96,5 @@ func (p *APIParser) LogType() string {
 func (event *API) updatePantherFields(p *APIParser) {
 	event.SetCoreFields(p.LogType(), event.Time, event)
 	event.AppendAnyIPAddressPtr(event.RemoteIP)
+	event.AppendAnyUsernamePtrs(event.UserName, event.MetaUser)
 }
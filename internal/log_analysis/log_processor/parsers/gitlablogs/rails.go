@@ -103,4 +103,5 @@ func (p *ProductionParser) LogType() string {
 func (event *Production) updatePantherFields(p *ProductionParser) {
 	event.SetCoreFields(p.LogType(), event.Time, event)
 	event.AppendAnyIPAddressPtr(event.RemoteIP)
+	event.AppendAnyUsernamePtrs(event.UserName)
 }
@@ -0,0 +1,174 @@
+package grok
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// maxDepth limits the nesting of patterns, it stops recursive pattern definitions
+const maxDepth = 32
+
+// refRegex matches pattern references %{NAME} and %{NAME:field}
+var refRegex = regexp.MustCompile(`%\{(\w+)(?::([^:{}]+))?(?::([^:{}]*))?\}`)
+
+// Pattern is a compiled grok pattern.
+// Captures are named after the field of %{NAME:field} references, regexp named groups are also captured.
+type Pattern struct {
+	source string
+	regex  *regexp.Regexp
+	// fields are the field names of the regexp groups, empty for non-capturing references
+	fields []string
+}
+
+// Compile compiles a grok pattern to a regular expression that matches whole lines.
+// Pattern references are looked up in library first, then in the built-in patterns.
+func Compile(pattern string, library map[string]string) (*Pattern, error) {
+	c := compiler{library: library}
+	expr, err := c.expand(pattern, 0)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "invalid grok pattern %q", pattern)
+	}
+	regex, err := regexp.Compile(`^(?:` + expr + `)$`)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid grok pattern %q", pattern)
+	}
+	fields := make([]string, len(regex.SubexpNames()))
+	for i, name := range regex.SubexpNames() {
+		if i == 0 || name == "" {
+			continue
+		}
+		if strings.HasPrefix(name, captureGroupPrefix) {
+			fields[i] = c.fields[name]
+		} else {
+			fields[i] = name // plain regexp named group
+		}
+	}
+	return &Pattern{
+		source: pattern,
+		regex:  regex,
+		fields: fields,
+	}, nil
+}
+
+// MustCompile is like Compile but panics if the pattern is invalid
+func MustCompile(pattern string, library map[string]string) *Pattern {
+	p, err := Compile(pattern, library)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// String returns the source of the pattern
+func (p *Pattern) String() string {
+	return p.source
+}
+
+// Fields returns the names of the captured fields, in order of appearance
+func (p *Pattern) Fields() (fields []string) {
+	seen := make(map[string]bool, len(p.fields))
+	for _, field := range p.fields {
+		if field != "" && !seen[field] {
+			seen[field] = true
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// Match returns the captured fields of a line, or nil if the line does not match.
+// Optional captures that did not participate in the match are omitted.
+// If a field is captured more than once, the first non-empty value is kept.
+func (p *Pattern) Match(line string) map[string]string {
+	indexes := p.regex.FindStringSubmatchIndex(line)
+	if indexes == nil {
+		return nil
+	}
+	values := make(map[string]string, len(p.fields))
+	for i, field := range p.fields {
+		start, end := indexes[2*i], indexes[2*i+1]
+		if field == "" || start < 0 || start == end {
+			continue
+		}
+		if _, duplicate := values[field]; !duplicate {
+			values[field] = line[start:end]
+		}
+	}
+	return values
+}
+
+// captureGroupPrefix is the prefix of the regexp groups of captured references.
+// Field names are not valid group names in general (e.g. `@timestamp`), groups are numbered instead.
+const captureGroupPrefix = "grok"
+
+type compiler struct {
+	library map[string]string
+	// fields maps capture group names to field names
+	fields map[string]string
+}
+
+func (c *compiler) lookup(name string) (string, bool) {
+	if expr, ok := c.library[name]; ok {
+		return expr, true
+	}
+	expr, ok := Patterns[name]
+	return expr, ok
+}
+
+func (c *compiler) expand(pattern string, depth int) (string, error) {
+	if depth > maxDepth {
+		return "", errors.New("patterns are nested too deep, check for recursive pattern definitions")
+	}
+	var err error
+	expr := refRegex.ReplaceAllStringFunc(pattern, func(ref string) string {
+		if err != nil {
+			return ""
+		}
+		match := refRegex.FindStringSubmatch(ref)
+		name, field, typ := match[1], match[2], match[3]
+		if typ != "" {
+			err = errors.Errorf("type conversion in %s is not supported, declare the type of field %q instead", ref, field)
+			return ""
+		}
+		def, ok := c.lookup(name)
+		if !ok {
+			err = errors.Errorf("unknown pattern %q", name)
+			return ""
+		}
+		var sub string
+		if sub, err = c.expand(def, depth+1); err != nil {
+			return ""
+		}
+		if field == "" {
+			return `(?:` + sub + `)`
+		}
+		if c.fields == nil {
+			c.fields = make(map[string]string)
+		}
+		group := fmt.Sprintf("%s%d", captureGroupPrefix, len(c.fields))
+		c.fields[group] = field
+		return `(?P<` + group + `>` + sub + `)`
+	})
+	return expr, err
+}
@@ -0,0 +1,126 @@
+package grok
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	p := MustCompile(`%{IP:client} %{WORD:method} %{URIPATHPARAM:request} %{NUMBER:bytes}(?: %{NUMBER:duration})?`, nil)
+	require.Equal(t, []string{"client", "method", "request", "bytes", "duration"}, p.Fields())
+
+	require.Equal(t, map[string]string{
+		"client":   "55.3.244.1",
+		"method":   "GET",
+		"request":  "/index.html?q=1",
+		"bytes":    "15824",
+		"duration": "0.043",
+	}, p.Match("55.3.244.1 GET /index.html?q=1 15824 0.043"))
+
+	// optional captures are omitted
+	require.Equal(t, map[string]string{
+		"client":  "::1",
+		"method":  "GET",
+		"request": "/",
+		"bytes":   "0",
+	}, p.Match("::1 GET / 0"))
+
+	// patterns match whole lines
+	require.Nil(t, p.Match("55.3.244.1 GET /index.html 15824 0.043 trailing"))
+	require.Nil(t, p.Match("prefix 55.3.244.1 GET /index.html 15824"))
+	require.Nil(t, p.Match("55.3.244 GET /index.html 15824"))
+}
+
+func TestLibrary(t *testing.T) {
+	library := map[string]string{
+		"REQUEST_ID": `[a-f0-9]{8}`,
+		// library patterns override built-in patterns
+		"WORD": `[A-Z]+`,
+		// library patterns can reference other patterns
+		"HEADER": `%{REQUEST_ID:id} %{LOGLEVEL:level}`,
+	}
+	p := MustCompile(`%{HEADER} %{WORD:action} (?P<rest>.*)`, library)
+	require.Equal(t, map[string]string{
+		"id":     "deadbeef",
+		"level":  "INFO",
+		"action": "LOGIN",
+		"rest":   "user=alice",
+	}, p.Match("deadbeef INFO LOGIN user=alice"))
+	require.Nil(t, p.Match("deadbeef INFO login user=alice"))
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, pattern := range []string{
+		`%{NOPE:field}`,
+		`%{INT:field:int}`,
+		`%{LOOP}`,
+		`%{WORD:field} (`,
+	} {
+		_, err := Compile(pattern, map[string]string{"LOOP": `a%{LOOP}`})
+		require.Error(t, err, pattern)
+	}
+}
+
+func TestDuplicateFields(t *testing.T) {
+	p := MustCompile(`(?:%{INT:value}|%{WORD:value})`, nil)
+	require.Equal(t, []string{"value"}, p.Fields())
+	require.Equal(t, map[string]string{"value": "42"}, p.Match("42"))
+	require.Equal(t, map[string]string{"value": "foo"}, p.Match("foo"))
+}
+
+func TestCombinedApacheLog(t *testing.T) {
+	p := MustCompile(`%{COMBINEDAPACHELOG}`, nil)
+	// nolint:lll
+	line := `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08 [en] (Win98; I ;Nav)"`
+	require.Equal(t, map[string]string{
+		"clientip":    "127.0.0.1",
+		"ident":       "-",
+		"auth":        "frank",
+		"timestamp":   "10/Oct/2000:13:55:36 -0700",
+		"verb":        "GET",
+		"request":     "/apache_pb.gif",
+		"httpversion": "1.0",
+		"response":    "200",
+		"bytes":       "2326",
+		"referrer":    `"http://www.example.com/start.html"`,
+		"agent":       `"Mozilla/4.08 [en] (Win98; I ;Nav)"`,
+	}, p.Match(line))
+}
+
+func TestSyslogBase(t *testing.T) {
+	p := MustCompile(`%{SYSLOGBASE} %{GREEDYDATA:message}`, nil)
+	require.Equal(t, map[string]string{
+		"timestamp": "Jan  2 15:04:05",
+		"logsource": "host-1.example.com",
+		"program":   "sshd",
+		"pid":       "4242",
+		"message":   "Accepted publickey for alice from 10.0.0.1",
+	}, p.Match("Jan  2 15:04:05 host-1.example.com sshd[4242]: Accepted publickey for alice from 10.0.0.1"))
+}
+
+// All built-in patterns must compile
+func TestBuiltinPatterns(t *testing.T) {
+	for name := range Patterns {
+		_, err := Compile(`%{`+name+`}`, nil)
+		require.NoError(t, err, name)
+	}
+}
@@ -87,6 +87,7 @@ func (p *AuditParser) Parse(log string) ([]*parsers.PantherLog, error) {
 	if event.LoginIP != nil {
 		event.AppendAnyIPAddress(*event.LoginIP)
 	}
+	event.AppendAnyUsernamePtrs(event.Username)
 	return event.Logs(), nil
 }
 
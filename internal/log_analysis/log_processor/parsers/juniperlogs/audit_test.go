@@ -44,6 +44,7 @@ func TestAuditParserParse(t *testing.T) {
 
 		event.SetCoreFields(TypeAudit, (*timestamp.RFC3339)(&tm), &event)
 		event.AppendAnyIPAddress("10.10.0.117")
+		event.AppendAnyUsernames("mykonos")
 		testutil.CheckPantherParser(t, log, NewAuditParser(), &event.PantherLog)
 	})
 	t.Run("Response deactivation", func(t *testing.T) {
@@ -76,6 +77,7 @@ func TestAuditParserParse(t *testing.T) {
 		}
 
 		event.SetCoreFields(TypeAudit, (*timestamp.RFC3339)(&tm), &event)
+		event.AppendAnyUsernames("mykonos")
 		testutil.CheckPantherParser(t, log, NewAuditParser(), &event.PantherLog)
 	})
 }
@@ -283,5 +283,16 @@ func (event *Lacework) updatePantherFields(p *LaceworkParser) {
 		for _, address := range data.EntityMap.SourceIPAddress {
 			event.AppendAnyIPAddressPtr(address.SourceIPAddress)
 		}
+
+		for _, user := range data.EntityMap.User {
+			event.AppendAnyUsernamePtrs(user.Username)
+			event.AppendAnyEmailPtrs(user.Username)
+		}
+
+		for _, user := range data.EntityMap.CTUser {
+			event.AppendAnyUsernamePtrs(user.Username)
+			event.AppendAnyEmailPtrs(user.Username)
+			event.AppendAnyCloudPrincipalPtrs(user.AccessKeyID)
+		}
 	}
 }
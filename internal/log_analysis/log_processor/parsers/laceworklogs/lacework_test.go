@@ -173,6 +173,7 @@ func TestLaceworkAws(t *testing.T) {
 	expectedEvent.PantherEventTime = (*timestamp.RFC3339)(&expectedDate)
 	expectedEvent.AppendAnyIPAddress("169.254.169.254")
 	expectedEvent.AppendAnyIPAddress("0.0.0.0")
+	expectedEvent.AppendAnyUsernames("root")
 
 	checkLaceworkLog(t, log, expectedEvent)
 }
@@ -0,0 +1,267 @@
+package leeflogs
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/sysloglogs"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/timestamp"
+)
+
+const (
+	leefPrefix = "LEEF:"
+	// Version|Vendor|Product|Version|EventID|
+	leefHeaderFields = 5
+	// LEEF 1.0 attributes are always tab separated, it is the default delimiter of LEEF 2.0
+	defaultDelimiter = "\t"
+
+	devTimeKey       = "devTime"
+	devTimeFormatKey = "devTimeFormat"
+	// devTime is milliseconds since the epoch or this format if devTimeFormat is not set
+	defaultTimeLayout = "Jan 02 2006 15:04:05"
+)
+
+var (
+	// Attribute keys of IP addresses
+	ipKeys = map[string]bool{
+		"src":        true,
+		"dst":        true,
+		"srcPreNAT":  true,
+		"dstPreNAT":  true,
+		"srcPostNAT": true,
+		"dstPostNAT": true,
+		"identSrc":   true,
+	}
+	// Attribute keys of host names, they can also be IP addresses
+	hostKeys = map[string]bool{
+		"identHostName": true,
+	}
+
+	// Replaces the tokens of Java SimpleDateFormat patterns used by devTimeFormat with Go layout tokens.
+	// Longer tokens must come first.
+	javaTimeFormat = strings.NewReplacer(
+		"yyyy", "2006",
+		"yy", "06",
+		"MMMM", "January",
+		"MMM", "Jan",
+		"MM", "01",
+		"M", "1",
+		"EEEE", "Monday",
+		"EEE", "Mon",
+		"dd", "02",
+		"d", "2",
+		"HH", "15",
+		"hh", "03",
+		"h", "3",
+		"mm", "04",
+		"ss", "05",
+		"SSS", "000",
+		"a", "PM",
+		"zzz", "MST",
+		"z", "MST",
+		"Z", "-0700",
+		"XXX", "Z07:00",
+	)
+)
+
+// nolint:lll
+type LEEF struct {
+	Syslog     *sysloglogs.Envelope `json:"syslog,omitempty" description:"The syslog envelope of the event, if it was sent over syslog."`
+	Version    *string              `json:"version" validate:"required" description:"The version of the LEEF format (1.0 or 2.0)."`
+	Vendor     *string              `json:"vendor" validate:"required" description:"The vendor of the sending device."`
+	Product    *string              `json:"product" validate:"required" description:"The product of the sending device."`
+	ProductVer *string              `json:"productVersion" validate:"required" description:"The version of the sending device."`
+	EventID    *string              `json:"eventId" validate:"required" description:"The unique identifier of the event type."`
+	Delimiter  *string              `json:"delimiter,omitempty" description:"The attribute delimiter declared by LEEF 2.0 events."`
+	Attributes map[string]string    `json:"attributes,omitempty" description:"The key value pairs of the event attributes."`
+
+	// NOTE: added to end of struct to allow expansion later
+	parsers.PantherLog
+}
+
+// LEEFParser parses LEEF events
+type LEEFParser struct {
+	envelope *sysloglogs.EnvelopeParser
+}
+
+var _ parsers.LogParser = (*LEEFParser)(nil)
+
+// New returns an initialized LogParser for LEEF events
+func (p *LEEFParser) New() parsers.LogParser {
+	return &LEEFParser{
+		envelope: sysloglogs.NewEnvelopeParser(),
+	}
+}
+
+// LogType returns the log type supported by this parser
+func (p *LEEFParser) LogType() string {
+	return TypeLEEF
+}
+
+// Parse returns the parsed events or nil if parsing failed
+func (p *LEEFParser) Parse(log string) ([]*parsers.PantherLog, error) {
+	event := &LEEF{}
+	if !strings.HasPrefix(log, leefPrefix) {
+		if p.envelope == nil || !strings.Contains(log, leefPrefix) {
+			return nil, errors.New("not a LEEF event")
+		}
+		envelope, message, err := p.envelope.Parse(log)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(message, leefPrefix) {
+			return nil, errors.New("syslog message is not a LEEF event")
+		}
+		event.Syslog, log = envelope, message
+	}
+
+	// Header fields are not escaped
+	header := strings.SplitN(strings.TrimPrefix(log, leefPrefix), "|", leefHeaderFields+1)
+	if len(header) != leefHeaderFields+1 {
+		return nil, errors.Errorf("invalid LEEF header, expected %d fields", leefHeaderFields)
+	}
+	event.Version = &header[0]
+	event.Vendor = &header[1]
+	event.Product = &header[2]
+	event.ProductVer = &header[3]
+	event.EventID = &header[4]
+
+	attributes := header[leefHeaderFields]
+	delimiter := defaultDelimiter
+	switch *event.Version {
+	case "1.0":
+	case "2.0":
+		// The delimiter is an optional header field, an empty field selects the default
+		if pos := strings.IndexByte(attributes, '|'); pos != -1 {
+			declared := attributes[:pos]
+			attributes = attributes[pos+1:]
+			if declared != "" {
+				d, err := parseDelimiter(declared)
+				if err != nil {
+					return nil, err
+				}
+				event.Delimiter, delimiter = &declared, d
+			}
+		}
+	default:
+		return nil, errors.Errorf("unsupported LEEF version %q", *event.Version)
+	}
+
+	event.Attributes = parseAttributes(attributes, delimiter)
+	event.updatePantherFields()
+
+	if err := parsers.Validator.Struct(event); err != nil {
+		return nil, err
+	}
+	return event.Logs(), nil
+}
+
+func (event *LEEF) updatePantherFields() {
+	var eventTime *timestamp.RFC3339
+	if value, ok := event.Attributes[devTimeKey]; ok {
+		if tm, err := parseTime(value, event.Attributes[devTimeFormatKey]); err == nil {
+			eventTime = (*timestamp.RFC3339)(&tm)
+		}
+	}
+	if eventTime == nil && event.Syslog != nil {
+		eventTime = event.Syslog.Timestamp
+	}
+	event.SetCoreFields(TypeLEEF, eventTime, event)
+
+	if event.Syslog != nil && !event.AppendAnyIPAddressPtr(event.Syslog.Hostname) {
+		event.AppendAnyDomainNamePtrs(event.Syslog.Hostname)
+	}
+	for key, value := range event.Attributes {
+		switch {
+		case ipKeys[key]:
+			event.AppendAnyIPAddress(value)
+		case hostKeys[key]:
+			if !event.AppendAnyIPAddress(value) {
+				event.AppendAnyDomainNames(value)
+			}
+		}
+	}
+}
+
+// parseDelimiter reads the delimiter of LEEF 2.0 events, it is a single character or its hex code (e.g. x09 or 0x5E)
+func parseDelimiter(value string) (string, error) {
+	if len(value) == 1 {
+		return value, nil
+	}
+	code := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(value), "0"), "x")
+	if len(code) == len(value) {
+		return "", errors.Errorf("invalid LEEF delimiter %q", value)
+	}
+	delimiter, err := hex.DecodeString(code)
+	if err != nil || len(delimiter) == 0 {
+		return "", errors.Errorf("invalid LEEF delimiter %q", value)
+	}
+	return string(delimiter), nil
+}
+
+func parseAttributes(attributes, delimiter string) map[string]string {
+	var fields map[string]string
+	for _, attribute := range strings.Split(attributes, delimiter) {
+		pos := strings.IndexByte(attribute, '=')
+		if pos <= 0 {
+			continue
+		}
+		if fields == nil {
+			fields = make(map[string]string)
+		}
+		fields[attribute[:pos]] = strings.TrimRight(attribute[pos+1:], "\r\n")
+	}
+	return fields
+}
+
+// parseTime parses devTime, using the Java date format declared by devTimeFormat
+func parseTime(value, format string) (time.Time, error) {
+	if format != "" {
+		tm, err := time.Parse(javaLayout(format), value)
+		if err != nil {
+			return time.Time{}, errors.Wrapf(err, "invalid LEEF devTime %q", value)
+		}
+		return tm.UTC(), nil
+	}
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(0, ms*int64(time.Millisecond)).UTC(), nil
+	}
+	tm, err := time.Parse(defaultTimeLayout, value)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "invalid LEEF devTime %q", value)
+	}
+	return tm.UTC(), nil
+}
+
+// javaLayout converts a Java SimpleDateFormat pattern to a Go time layout.
+// Text in single quotes is literal.
+func javaLayout(format string) string {
+	parts := strings.Split(format, "'")
+	for i := 0; i < len(parts); i += 2 {
+		parts[i] = javaTimeFormat.Replace(parts[i])
+	}
+	return strings.Join(parts, "")
+}
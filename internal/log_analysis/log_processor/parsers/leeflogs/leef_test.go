@@ -0,0 +1,145 @@
+package leeflogs
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/sysloglogs"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/testutil"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/timestamp"
+)
+
+func TestLEEF1(t *testing.T) {
+	//nolint:lll
+	log := "LEEF:1.0|Microsoft|MSExchange|4.0 SP1|15345|src=10.50.1.1\tdst=2.10.20.20\tsev=5\tdevTime=Sep 19 2020 08:26:10\tidentHostName=mail.example.com\tmsg=a=b"
+
+	expectedTime := time.Date(2020, 9, 19, 8, 26, 10, 0, time.UTC)
+	expectedEvent := &LEEF{
+		Version:    aws.String("1.0"),
+		Vendor:     aws.String("Microsoft"),
+		Product:    aws.String("MSExchange"),
+		ProductVer: aws.String("4.0 SP1"),
+		EventID:    aws.String("15345"),
+		Attributes: map[string]string{
+			"src":           "10.50.1.1",
+			"dst":           "2.10.20.20",
+			"sev":           "5",
+			"devTime":       "Sep 19 2020 08:26:10",
+			"identHostName": "mail.example.com",
+			"msg":           "a=b",
+		},
+	}
+	expectedEvent.SetCoreFields(TypeLEEF, (*timestamp.RFC3339)(&expectedTime), expectedEvent)
+	expectedEvent.AppendAnyIPAddress("10.50.1.1")
+	expectedEvent.AppendAnyIPAddress("2.10.20.20")
+	expectedEvent.AppendAnyDomainNames("mail.example.com")
+
+	checkLEEF(t, log, expectedEvent)
+}
+
+func TestLEEF2Syslog(t *testing.T) {
+	//nolint:lll
+	log := `<13>1 2020-09-19T08:26:10Z qradar - - - - LEEF:2.0|Lancope|StealthWatch|1.0|41|^|src=10.0.1.8^dst=10.0.0.5^devTime=2020-09-19 10:26:10.123 +0200^devTimeFormat=yyyy-MM-dd HH:mm:ss.SSS Z`
+
+	syslogTime := time.Date(2020, 9, 19, 8, 26, 10, 0, time.UTC)
+	expectedTime := time.Date(2020, 9, 19, 8, 26, 10, 123000000, time.UTC)
+	expectedEvent := &LEEF{
+		Syslog: &sysloglogs.Envelope{
+			Priority:  aws.Uint8(13),
+			Facility:  aws.Uint8(1),
+			Severity:  aws.Uint8(5),
+			Timestamp: (*timestamp.RFC3339)(&syslogTime),
+			Hostname:  aws.String("qradar"),
+		},
+		Version:    aws.String("2.0"),
+		Vendor:     aws.String("Lancope"),
+		Product:    aws.String("StealthWatch"),
+		ProductVer: aws.String("1.0"),
+		EventID:    aws.String("41"),
+		Delimiter:  aws.String("^"),
+		Attributes: map[string]string{
+			"src":           "10.0.1.8",
+			"dst":           "10.0.0.5",
+			"devTime":       "2020-09-19 10:26:10.123 +0200",
+			"devTimeFormat": "yyyy-MM-dd HH:mm:ss.SSS Z",
+		},
+	}
+	expectedEvent.SetCoreFields(TypeLEEF, (*timestamp.RFC3339)(&expectedTime), expectedEvent)
+	expectedEvent.AppendAnyDomainNames("qradar")
+	expectedEvent.AppendAnyIPAddress("10.0.1.8")
+	expectedEvent.AppendAnyIPAddress("10.0.0.5")
+
+	checkLEEF(t, log, expectedEvent)
+}
+
+func TestLEEF2HexDelimiter(t *testing.T) {
+	parser := (&LEEFParser{}).New()
+	logs, err := parser.Parse(`LEEF:2.0|Vendor|Product|1.0|42|0x7C|src=10.0.0.1|devTime=1600503970000`)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	event := logs[0].Event().(*LEEF)
+	require.Equal(t, "0x7C", *event.Delimiter)
+	require.Equal(t, map[string]string{"src": "10.0.0.1", "devTime": "1600503970000"}, event.Attributes)
+	require.Equal(t, time.Date(2020, 9, 19, 8, 26, 10, 0, time.UTC), (time.Time)(*event.PantherEventTime))
+
+	// default delimiter
+	logs, err = parser.Parse("LEEF:2.0|Vendor|Product|1.0|42||src=10.0.0.1\tdst=10.0.0.2")
+	require.NoError(t, err)
+	event = logs[0].Event().(*LEEF)
+	require.Nil(t, event.Delimiter)
+	require.Equal(t, map[string]string{"src": "10.0.0.1", "dst": "10.0.0.2"}, event.Attributes)
+}
+
+func TestLEEFInvalid(t *testing.T) {
+	parser := (&LEEFParser{}).New()
+	for _, log := range []string{
+		`{"not": "leef"}`,
+		`LEEF:1.0|a|b|c`,
+		`LEEF:3.0|a|b|c|d|src=1`,
+		`LEEF:2.0|a|b|c|d|xZZ|src=1`,
+		`<134>Sep 19 08:26:10 host app: not LEEF:1.0|a|b|c|d|`,
+	} {
+		logs, err := parser.Parse(log)
+		require.Error(t, err, log)
+		require.Nil(t, logs)
+	}
+}
+
+func TestJavaLayout(t *testing.T) {
+	require.Equal(t, "Jan 02 2006 15:04:05", javaLayout("MMM dd yyyy HH:mm:ss"))
+	require.Equal(t, "2006-01-02T15:04:05.000-0700", javaLayout("yyyy-MM-dd'T'HH:mm:ss.SSSZ"))
+	require.Equal(t, "2/1/06 3:04 PM MST", javaLayout("d/M/yy h:mm a z"))
+}
+
+func TestLEEFType(t *testing.T) {
+	parser := &LEEFParser{}
+	require.Equal(t, "LEEF.Event", parser.LogType())
+}
+
+func checkLEEF(t *testing.T, log string, expectedEvent *LEEF) {
+	expectedEvent.SetEvent(expectedEvent)
+	parser := (&LEEFParser{}).New()
+	logs, err := parser.Parse(log)
+	testutil.EqualPantherLog(t, expectedEvent.Log(), logs, err)
+}
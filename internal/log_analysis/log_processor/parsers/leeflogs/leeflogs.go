@@ -0,0 +1,39 @@
+package leeflogs
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/logtypes"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
+)
+
+const (
+	TypeLEEF = "LEEF.Event"
+)
+
+func init() {
+	logtypes.MustRegister(logtypes.Config{
+		Name: TypeLEEF,
+		Description: `IBM QRadar Log Event Extended Format (LEEF) events, optionally sent over syslog.
+Versions 1.0 and 2.0 of the format are supported.`,
+		ReferenceURL: `https://www.ibm.com/support/knowledgecenter/SS42VS_DSM/com.ibm.dsm.doc/c_LEEF_Format_Guide_intro.html`,
+		Schema:       LEEF{},
+		NewParser:    parsers.AdapterFactory(&LEEFParser{}),
+	})
+}
@@ -81,4 +81,9 @@ func (p *BatchParser) LogType() string {
 func (event *Batch) updatePantherFields(p *BatchParser) {
 	event.SetCoreFields(p.LogType(), (*timestamp.RFC3339)(event.CalendarTime), event)
 	event.AppendAnyDomainNamePtrs(event.Hostname)
+	appendAnyRowFields(&event.PantherLog, event.Decorations)
+	if event.DiffResults != nil {
+		appendAnyRowFields(&event.PantherLog, event.DiffResults.Added...)
+		appendAnyRowFields(&event.PantherLog, event.DiffResults.Removed...)
+	}
 }
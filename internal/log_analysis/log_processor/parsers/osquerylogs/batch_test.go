@@ -68,6 +68,46 @@ func TestBatchLog(t *testing.T) {
 	checkOsQueryBatcLog(t, log, expectedEvent)
 }
 
+func TestBatchLogWithUsers(t *testing.T) {
+	//nolint:lll
+	log := `{"diffResults": {"added": [ { "username": "root", "uid": "0" } ],"removed": [ { "username": "guest", "uid": "201" } ] },"name": "users", "hostname": "hostname.local", "calendarTime": "Tue Nov 5 06:08:26 2018 UTC","unixTime": "1412123850", "epoch": "314159265", "counter": "1", "decorations": {"username": "admin"} }`
+
+	expectedTime := time.Unix(1541398106, 0).UTC()
+	expectedEvent := &Batch{
+		CalendarTime: (*timestamp.ANSICwithTZ)(&expectedTime),
+		Name:         aws.String("users"),
+		Epoch:        (*numerics.Integer)(aws.Int(314159265)),
+		Hostname:     aws.String(("hostname.local")),
+		UnixTime:     (*numerics.Integer)(aws.Int(1412123850)),
+		Counter:      (*numerics.Integer)(aws.Int(1)),
+		Decorations: map[string]string{
+			"username": "admin",
+		},
+		DiffResults: &BatchDiffResults{
+			Added: []map[string]string{
+				{
+					"username": "root",
+					"uid":      "0",
+				},
+			},
+			Removed: []map[string]string{
+				{
+					"username": "guest",
+					"uid":      "201",
+				},
+			},
+		},
+	}
+
+	// panther fields
+	expectedEvent.PantherLogType = aws.String("Osquery.Batch")
+	expectedEvent.PantherEventTime = (*timestamp.RFC3339)(&expectedTime)
+	expectedEvent.AppendAnyDomainNames("hostname.local")
+	expectedEvent.AppendAnyUsernames("admin", "root", "guest")
+
+	checkOsQueryBatcLog(t, log, expectedEvent)
+}
+
 func TestOsQueryBatchLogType(t *testing.T) {
 	parser := &BatchParser{}
 	require.Equal(t, "Osquery.Batch", parser.LogType())
@@ -86,6 +86,5 @@ func (event *Differential) updatePantherFields(p *DifferentialParser) {
 	event.SetCoreFields(p.LogType(), (*timestamp.RFC3339)(event.CalendarTime), event)
 	event.AppendAnyDomainNamePtrs(event.HostIdentifier)
 
-	event.AppendAnyIPAddress(event.Columns["local_address"])
-	event.AppendAnyIPAddress(event.Columns["remote_address"])
+	appendAnyRowFields(&event.PantherLog, event.Columns, event.Decorations)
 }
@@ -62,3 +62,26 @@ func init() {
 		},
 	)
 }
+
+// Query result columns and decorations holding indicators, osquery tables name the user of a row `username` or `user`
+var (
+	ipAddressColumns = []string{"local_address", "remote_address"}
+	usernameColumns  = []string{"username", "user"}
+)
+
+// appendAnyRowFields appends the indicators found in rows of query results or decorations
+func appendAnyRowFields(event *parsers.PantherLog, rows ...map[string]string) {
+	for _, row := range rows {
+		for _, column := range ipAddressColumns {
+			if value := row[column]; value != "" {
+				event.AppendAnyIPAddress(value)
+			}
+		}
+		for _, column := range usernameColumns {
+			if value := row[column]; value != "" {
+				event.AppendAnyUsernames(value)
+				event.AppendAnyEmails(value)
+			}
+		}
+	}
+}
@@ -75,4 +75,6 @@ func (p *SnapshotParser) LogType() string {
 func (event *Snapshot) updatePantherFields(p *SnapshotParser) {
 	event.SetCoreFields(p.LogType(), (*timestamp.RFC3339)(event.CalendarTime), event)
 	event.AppendAnyDomainNamePtrs(event.HostIdentifier)
+	appendAnyRowFields(&event.PantherLog, event.Decorations)
+	appendAnyRowFields(&event.PantherLog, event.Snapshot...)
 }
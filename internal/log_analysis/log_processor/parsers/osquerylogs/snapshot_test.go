@@ -60,6 +60,41 @@ func TestSnapshotLog(t *testing.T) {
 	checkOsQuerySnapshotLog(t, log, expectedEvent)
 }
 
+func TestSnapshotLogWithUsers(t *testing.T) {
+	//nolint:lll
+	log := `{"action": "snapshot","snapshot": [{"user": "root","pid": "1"},{"user": "jane@example.com","pid": "2"}],"name": "logged_in_users","hostIdentifier": "hostname.local","calendarTime": "Tue Nov 5 06:08:26 2018 UTC","unixTime": "1462228052","epoch": "314159265","counter": "1"}`
+
+	expectedTime := time.Unix(1541398106, 0).UTC()
+	expectedEvent := &Snapshot{
+		Action:         aws.String("snapshot"),
+		Name:           aws.String("logged_in_users"),
+		Epoch:          (*numerics.Integer)(aws.Int(314159265)),
+		HostIdentifier: aws.String(("hostname.local")),
+		UnixTime:       (*numerics.Integer)(aws.Int(1462228052)),
+		CalendarTime:   (*timestamp.ANSICwithTZ)(&expectedTime),
+		Counter:        (*numerics.Integer)(aws.Int(1)),
+		Snapshot: []map[string]string{
+			{
+				"user": "root",
+				"pid":  "1",
+			},
+			{
+				"user": "jane@example.com",
+				"pid":  "2",
+			},
+		},
+	}
+
+	// panther fields
+	expectedEvent.PantherLogType = aws.String("Osquery.Snapshot")
+	expectedEvent.PantherEventTime = (*timestamp.RFC3339)(&expectedTime)
+	expectedEvent.AppendAnyDomainNames("hostname.local")
+	expectedEvent.AppendAnyUsernames("root", "jane@example.com")
+	expectedEvent.AppendAnyEmails("jane@example.com")
+
+	checkOsQuerySnapshotLog(t, log, expectedEvent)
+}
+
 func TestOsQuerySnapshotLogType(t *testing.T) {
 	parser := &SnapshotParser{}
 	require.Equal(t, "Osquery.Snapshot", parser.LogType())
@@ -55,6 +55,7 @@ func TestStatusLog(t *testing.T) {
 	expectedEvent.PantherLogType = aws.String("Osquery.Status")
 	expectedEvent.PantherEventTime = (*timestamp.RFC3339)(&expectedTime)
 	expectedEvent.AppendAnyDomainNames("jacks-mbp.lan")
+	expectedEvent.AppendAnyUsernames("user")
 
 	checkOsQueryStatusLog(t, log, expectedEvent)
 }
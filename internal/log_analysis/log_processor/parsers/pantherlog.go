@@ -28,6 +28,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/timestamp"
+	"github.com/panther-labs/panther/pkg/extract"
 )
 
 const (
@@ -58,6 +59,21 @@ type PantherLog struct {
 	PantherAnySHA1Hashes   *PantherAnyString `json:"p_any_sha1_hashes,omitempty" description:"Panther added field with collection of SHA1 hashes associated with the row"`
 	PantherAnyMD5Hashes    *PantherAnyString `json:"p_any_md5_hashes,omitempty" description:"Panther added field with collection of MD5 hashes associated with the row"`
 	PantherAnySHA256Hashes *PantherAnyString `json:"p_any_sha256_hashes,omitempty" description:"Panther added field with collection of SHA256 hashes of any algorithm associated with the row"`
+
+	// added by the enrichment stage of the log processor, parsers never set it
+	PantherEnrichment *PantherEnrichment `json:"p_enrichment,omitempty" description:"Panther added field with context from enrichment sources associated with the row"`
+
+	// added by the log processor for data of CloudWatch Logs subscriptions, parsers never set them
+	PantherSourceLogGroup  *string `json:"p_source_log_group,omitempty" description:"Panther added field with the CloudWatch Logs log group the row was received from"`
+	PantherSourceLogStream *string `json:"p_source_log_stream,omitempty" description:"Panther added field with the CloudWatch Logs log stream the row was received from"`
+
+	// optional (any)
+	PantherAnyEmails          *PantherAnyString `json:"p_any_emails,omitempty" description:"Panther added field with collection of email addresses associated with the row"`
+	PantherAnyUsernames       *PantherAnyString `json:"p_any_usernames,omitempty" description:"Panther added field with collection of usernames associated with the row"`
+	PantherAnyCloudPrincipals *PantherAnyString `json:"p_any_cloud_principals,omitempty" description:"Panther added field with collection of cloud identities (e.g. IAM user and role ARNs, GCP service accounts) associated with the row"`
+
+	// added by the log processor for the log types that preserve unknown fields, parsers never set it
+	PantherUnknownFields map[string]string `json:"p_unknown_fields,omitempty" description:"Panther added field with the JSON values of the fields not in the schema of the log type, by path"`
 }
 
 type PantherAnyString struct { // needed to declare as struct (rather than map) for CF generation
@@ -234,6 +250,97 @@ func (pl *PantherLog) AppendAnySHA256HashesPtr(values ...*string) {
 	}
 }
 
+// AppendAnyEmailPtrs appends the values that are email addresses
+func (pl *PantherLog) AppendAnyEmailPtrs(values ...*string) {
+	for _, value := range values {
+		if value != nil {
+			pl.AppendAnyEmails(*value)
+		}
+	}
+}
+
+// AppendAnyEmails appends the values that are email addresses
+func (pl *PantherLog) AppendAnyEmails(values ...string) {
+	for _, value := range values {
+		if !extract.IsEmail(value) {
+			continue
+		}
+		if pl.PantherAnyEmails == nil { // lazy create
+			pl.PantherAnyEmails = NewPantherAnyString()
+		}
+		AppendAnyString(pl.PantherAnyEmails, value)
+	}
+}
+
+// AppendAnyUsernamePtrs appends non-nil usernames
+func (pl *PantherLog) AppendAnyUsernamePtrs(values ...*string) {
+	for _, value := range values {
+		if value != nil {
+			pl.AppendAnyUsernames(*value)
+		}
+	}
+}
+
+// AppendAnyUsernames appends usernames
+func (pl *PantherLog) AppendAnyUsernames(values ...string) {
+	if pl.PantherAnyUsernames == nil { // lazy create
+		pl.PantherAnyUsernames = NewPantherAnyString()
+	}
+	AppendAnyString(pl.PantherAnyUsernames, values...)
+}
+
+// AppendAnyCloudPrincipalPtrs appends non-nil cloud principal identifiers
+func (pl *PantherLog) AppendAnyCloudPrincipalPtrs(values ...*string) {
+	for _, value := range values {
+		if value != nil {
+			pl.AppendAnyCloudPrincipals(*value)
+		}
+	}
+}
+
+// AppendAnyCloudPrincipals appends cloud principal identifiers
+func (pl *PantherLog) AppendAnyCloudPrincipals(values ...string) {
+	if pl.PantherAnyCloudPrincipals == nil { // lazy create
+		pl.PantherAnyCloudPrincipals = NewPantherAnyString()
+	}
+	AppendAnyString(pl.PantherAnyCloudPrincipals, values...)
+}
+
+// RemoveAnyStrings removes the values of the p_any_* fields that match, fields left without values are removed
+func (pl *PantherLog) RemoveAnyStrings(match func(value string) bool) {
+	for _, field := range []**PantherAnyString{
+		&pl.PantherAnyIPAddresses,
+		&pl.PantherAnyDomainNames,
+		&pl.PantherAnySHA1Hashes,
+		&pl.PantherAnyMD5Hashes,
+		&pl.PantherAnySHA256Hashes,
+		&pl.PantherAnyEmails,
+		&pl.PantherAnyUsernames,
+		&pl.PantherAnyCloudPrincipals,
+	} {
+		if *field == nil {
+			continue
+		}
+		for value := range (*field).set {
+			if match(value) {
+				delete((*field).set, value)
+			}
+		}
+		if len((*field).set) == 0 {
+			*field = nil
+		}
+	}
+}
+
+// IndicatorExtractor returns an extractor of the email, username and cloud principal fields of JSON values
+func (pl *PantherLog) IndicatorExtractor() extract.Extractor {
+	return extract.Extractors{
+		extract.NewEmailExtractor(pl),
+		extract.NewUsernameExtractor(pl),
+		extract.NewCloudPrincipalExtractor(pl),
+	}
+}
+
 func AppendAnyString(any *PantherAnyString, values ...string) {
 	// add new if not present
 	for _, v := range values {
@@ -261,6 +368,8 @@ func (pl *PantherLog) Result() (*Result, error) {
 		return nil, errors.New("nil event time")
 	}
 	tm := ((*time.Time)(pl.PantherEventTime)).UTC()
+	// Sensitive values must not reach the JSON
+	Redact(*pl.PantherLogType, event)
 	// Use custom JSON marshaler to rewrite fields
 	data, err := JSON.Marshal(event)
 	if err != nil {
@@ -27,6 +27,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/timestamp"
+	"github.com/panther-labs/panther/pkg/extract"
 )
 
 func TestAnyStringMarshal(t *testing.T) {
@@ -249,3 +250,66 @@ func TestAppendAnyMD5Hashes(t *testing.T) {
 	event.AppendAnyMD5HashPtrs(&value)
 	require.Equal(t, expectedAny, event.PantherAnyMD5Hashes)
 }
+
+func TestAppendAnyEmails(t *testing.T) {
+	event := PantherLog{}
+	value := "alice@example.com"
+	notEmail := "alice"
+	expectedAny := &PantherAnyString{
+		set: map[string]struct{}{
+			value: {},
+		},
+	}
+	event.AppendAnyEmails(value, notEmail)
+	require.Equal(t, expectedAny, event.PantherAnyEmails)
+
+	event = PantherLog{}
+	event.AppendAnyEmailPtrs(&value, &notEmail, nil)
+	require.Equal(t, expectedAny, event.PantherAnyEmails)
+
+	event = PantherLog{}
+	event.AppendAnyEmails(notEmail)
+	require.Nil(t, event.PantherAnyEmails)
+}
+
+func TestAppendAnyUsernames(t *testing.T) {
+	event := PantherLog{}
+	value := "a"
+	expectedAny := &PantherAnyString{
+		set: map[string]struct{}{
+			value: {},
+		},
+	}
+	event.AppendAnyUsernames(value)
+	require.Equal(t, expectedAny, event.PantherAnyUsernames)
+
+	event = PantherLog{}
+	event.AppendAnyUsernamePtrs(&value)
+	require.Equal(t, expectedAny, event.PantherAnyUsernames)
+}
+
+func TestAppendAnyCloudPrincipals(t *testing.T) {
+	event := PantherLog{}
+	value := "arn:aws:iam::123456789012:user/alice"
+	expectedAny := &PantherAnyString{
+		set: map[string]struct{}{
+			value: {},
+		},
+	}
+	event.AppendAnyCloudPrincipals(value)
+	require.Equal(t, expectedAny, event.PantherAnyCloudPrincipals)
+
+	event = PantherLog{}
+	event.AppendAnyCloudPrincipalPtrs(&value)
+	require.Equal(t, expectedAny, event.PantherAnyCloudPrincipals)
+}
+
+func TestIndicatorExtractor(t *testing.T) {
+	event := PantherLog{}
+	raw := jsoniter.RawMessage(`{"member": "bob@example.com", "userName": "bob", "roleArn": "arn:aws:iam::123456789012:role/Admin"}`)
+	extract.Extract(&raw, event.IndicatorExtractor())
+	require.Equal(t, &PantherAnyString{set: map[string]struct{}{"bob@example.com": {}}}, event.PantherAnyEmails)
+	require.Equal(t, &PantherAnyString{set: map[string]struct{}{"bob": {}}}, event.PantherAnyUsernames)
+	require.Equal(t, &PantherAnyString{set: map[string]struct{}{"arn:aws:iam::123456789012:role/Admin": {}}},
+		event.PantherAnyCloudPrincipals)
+}
@@ -90,6 +90,8 @@ type Result struct {
 	LogType   string
 	EventTime time.Time
 	JSON      []byte
+	// If set, the event is stored under this S3 key prefix instead of the table partitions and is not analyzed
+	OutputPrefix string
 }
 
 // Results wraps a single Result in a slice.
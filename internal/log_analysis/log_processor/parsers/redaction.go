@@ -0,0 +1,58 @@
+package parsers
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"sync/atomic"
+)
+
+// Redactor removes or masks sensitive values of parsed events before they are marshaled to JSON
+type Redactor interface {
+	// Redact modifies the event in place
+	Redact(logType string, event interface{})
+	// Redacts checks if the events of the log type are modified
+	Redacts(logType string) bool
+}
+
+type redactorHolder struct {
+	Redactor
+}
+
+var redactor atomic.Value
+
+// SetRedactor sets the redactor applied by PantherLog.Result to all events, nil disables redaction
+func SetRedactor(r Redactor) {
+	redactor.Store(redactorHolder{r})
+}
+
+// Redact applies the redactor to a value of a log type, e.g. fields added to events after they are parsed.
+// The value must be a pointer.
+func Redact(logType string, event interface{}) {
+	if holder, ok := redactor.Load().(redactorHolder); ok && holder.Redactor != nil {
+		holder.Redact(logType, event)
+	}
+}
+
+// Redacts checks if the redactor modifies the events of a log type, raw data of such log types must not be stored
+func Redacts(logType string) bool {
+	if holder, ok := redactor.Load().(redactorHolder); ok && holder.Redactor != nil {
+		return holder.Redacts(logType)
+	}
+	return false
+}
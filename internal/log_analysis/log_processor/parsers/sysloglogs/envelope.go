@@ -0,0 +1,130 @@
+package sysloglogs
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/influxdata/go-syslog/v3"
+	"github.com/influxdata/go-syslog/v3/rfc3164"
+	"github.com/influxdata/go-syslog/v3/rfc5424"
+	"github.com/pkg/errors"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/timestamp"
+)
+
+// defaultPriority is added to messages without priority (user-level notice)
+const defaultPriority = "<13>"
+
+// rfc5424Header matches the priority and version of RFC5424 messages
+var rfc5424Header = regexp.MustCompile(`^<\d{1,3}>\d{1,2} `)
+
+// Envelope is the syslog header of messages in other formats that are sent over syslog (e.g. CEF).
+// nolint:lll
+type Envelope struct {
+	Priority  *uint8             `json:"priority,omitempty" description:"Priority is calculated by (Facility * 8 + Severity). The lower this value, the higher importance of the log message."`
+	Facility  *uint8             `json:"facility,omitempty" description:"Facility value helps determine which process created the message. Eg: 0 = kernel messages, 3 = system daemons."`
+	Severity  *uint8             `json:"severity,omitempty" description:"Severity indicates how severe the message is. Eg: 0=Emergency to 7=Debug."`
+	Timestamp *timestamp.RFC3339 `json:"timestamp,omitempty" description:"Timestamp of the syslog message in UTC."`
+	Hostname  *string            `json:"hostname,omitempty" description:"Hostname identifies the machine that originally sent the syslog message."`
+	Appname   *string            `json:"appname,omitempty" description:"Appname identifies the device or application that originated the syslog message."`
+	ProcID    *string            `json:"procid,omitempty" description:"ProcID is often the process ID, but can be any value used to enable log analyzers to detect discontinuities in syslog reporting."`
+	MsgID     *string            `json:"msgid,omitempty" description:"MsgID identifies the type of message. For example, a firewall might use the MsgID 'TCPIN' for incoming TCP traffic."`
+}
+
+// EnvelopeParser unwraps messages sent over syslog
+type EnvelopeParser struct {
+	rfc5424 syslog.Machine
+	rfc3164 syslog.Machine
+}
+
+// NewEnvelopeParser returns a parser for RFC5424 and RFC3164 envelopes
+func NewEnvelopeParser() *EnvelopeParser {
+	return &EnvelopeParser{
+		rfc5424: rfc5424.NewParser(rfc5424.WithBestEffort()),
+		rfc3164: rfc3164.NewParser(
+			rfc3164.WithBestEffort(),
+			rfc3164.WithTimezone(time.UTC),
+			rfc3164.WithYear(rfc3164.CurrentYear{}),
+			rfc3164.WithRFC3339(),
+		),
+	}
+}
+
+// Parse parses the envelope of an RFC5424 or RFC3164 syslog message and returns the message.
+// Syslog daemons often write messages to files without priority, they are parsed as RFC3164 messages.
+func (p *EnvelopeParser) Parse(log string) (*Envelope, string, error) {
+	if !strings.HasPrefix(log, "<") {
+		envelope, message, err := p.Parse(defaultPriority + log)
+		if err != nil {
+			return nil, "", err
+		}
+		envelope.Priority, envelope.Facility, envelope.Severity = nil, nil, nil
+		return envelope, message, nil
+	}
+	if rfc5424Header.MatchString(log) {
+		msg, err := p.rfc5424.Parse([]byte(log))
+		if err != nil {
+			return nil, "", errors.Wrap(err, "invalid RFC5424 syslog message")
+		}
+		m := msg.(*rfc5424.SyslogMessage)
+		envelope := &Envelope{
+			Priority:  m.Priority,
+			Facility:  m.Facility,
+			Severity:  m.Severity,
+			Timestamp: (*timestamp.RFC3339)(m.Timestamp),
+			Hostname:  m.Hostname,
+			Appname:   m.Appname,
+			ProcID:    m.ProcID,
+			MsgID:     m.MsgID,
+		}
+		return envelope, stringValue(m.Message), nil
+	}
+
+	msg, err := p.rfc3164.Parse([]byte(log))
+	if err != nil {
+		return nil, "", errors.Wrap(err, "invalid RFC3164 syslog message")
+	}
+	m := msg.(*rfc3164.SyslogMessage)
+	envelope := &Envelope{
+		Priority:  m.Priority,
+		Facility:  m.Facility,
+		Severity:  m.Severity,
+		Timestamp: (*timestamp.RFC3339)(m.Timestamp),
+		Hostname:  m.Hostname,
+		Appname:   m.Appname,
+		ProcID:    m.ProcID,
+		MsgID:     m.MsgID,
+	}
+	message := stringValue(m.Message)
+	// The tag is optional in RFC3164 messages, messages like `CEF:0|...` are not tagged by `CEF`
+	if m.Appname != nil && m.ProcID == nil && strings.HasPrefix(message, *m.Appname+":") {
+		envelope.Appname = nil
+	}
+	return envelope, message, nil
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,74 @@
+package sysloglogs
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/timestamp"
+)
+
+func TestEnvelopeRFC5424(t *testing.T) {
+	envelope, message, err := NewEnvelopeParser().Parse(`<134>1 2020-09-19T08:26:10Z host app 42 ID47 - CEF:0|a|b|c|d|e|f|src=1`)
+	require.NoError(t, err)
+	tm := time.Date(2020, 9, 19, 8, 26, 10, 0, time.UTC)
+	require.Equal(t, &Envelope{
+		Priority:  aws.Uint8(134),
+		Facility:  aws.Uint8(16),
+		Severity:  aws.Uint8(6),
+		Timestamp: (*timestamp.RFC3339)(&tm),
+		Hostname:  aws.String("host"),
+		Appname:   aws.String("app"),
+		ProcID:    aws.String("42"),
+		MsgID:     aws.String("ID47"),
+	}, envelope)
+	require.Equal(t, "CEF:0|a|b|c|d|e|f|src=1", message)
+}
+
+func TestEnvelopeRFC3164(t *testing.T) {
+	p := NewEnvelopeParser()
+	envelope, message, err := p.Parse(`<134>Sep 19 08:26:10 host app[12]: LEEF:1.0|a|b|c|d|src=1`)
+	require.NoError(t, err)
+	require.Equal(t, "host", *envelope.Hostname)
+	require.Equal(t, "app", *envelope.Appname)
+	require.Equal(t, "12", *envelope.ProcID)
+	require.Equal(t, "LEEF:1.0|a|b|c|d|src=1", message)
+
+	// untagged messages
+	envelope, message, err = p.Parse(`<134>Sep 19 08:26:10 host CEF:0|a|b|c|d|e|f|src=1`)
+	require.NoError(t, err)
+	require.Equal(t, "host", *envelope.Hostname)
+	require.Nil(t, envelope.Appname)
+	require.Equal(t, "CEF:0|a|b|c|d|e|f|src=1", message)
+
+	// no priority
+	envelope, message, err = p.Parse(`Sep 19 08:26:10 host CEF:0|a|b|c|d|e|f|src=1`)
+	require.NoError(t, err)
+	require.Nil(t, envelope.Priority)
+	require.Nil(t, envelope.Severity)
+	require.Equal(t, "host", *envelope.Hostname)
+	require.Equal(t, "CEF:0|a|b|c|d|e|f|src=1", message)
+
+	_, _, err = p.Parse(`<134>`)
+	require.Error(t, err)
+}
@@ -0,0 +1,167 @@
+package processor
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/common"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/enrichment"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/filtering"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/flushing"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/framing"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/redaction"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/reload"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/sources"
+)
+
+var (
+	enrichmentConfig = &reloadedConfig{
+		name:  "enrichment data",
+		path:  func() string { return common.Config.EnrichmentConfig },
+		parse: parseEnrichment,
+	}
+	framingConfig = &reloadedConfig{
+		name:  "framing rules",
+		path:  func() string { return common.Config.FramingConfig },
+		parse: framing.Parse,
+	}
+	filterConfig = &reloadedConfig{
+		name:  "filter rules",
+		path:  func() string { return common.Config.FilterConfig },
+		parse: filtering.Parse,
+	}
+	flushConfig = &reloadedConfig{
+		name:  "flush policies",
+		path:  func() string { return common.Config.FlushConfig },
+		parse: flushing.Parse,
+	}
+	redactionConfig = &reloadedConfig{
+		name:  "redaction rules",
+		path:  func() string { return common.Config.RedactionConfig },
+		parse: parseRedaction,
+	}
+)
+
+// reloadedConfig is a config file of the log processor that is reloaded when its refresh interval has passed.
+// The loader is created on first use since the path comes from the environment.
+// It stays nil, and so does the value of the config, if the path is empty.
+type reloadedConfig struct {
+	name  string
+	path  func() string
+	parse reload.Parse
+	// mu guards the creation of the loader, the consumers of the daemon refresh the configs concurrently
+	mu     sync.Mutex
+	loader *reload.Loader
+}
+
+// refresh reloads the config when its refresh interval has passed.
+// Once the config is loaded, failures are logged and the previous value is kept.
+// It returns an error if the config is enabled but was never loaded.
+func (c *reloadedConfig) refresh() error {
+	path := c.path()
+	if path == "" {
+		return nil
+	}
+	c.mu.Lock()
+	if c.loader == nil {
+		c.loader = reload.NewLoader(path, newOpener(), c.parse)
+	}
+	loader := c.loader
+	c.mu.Unlock()
+	err := loader.Refresh(time.Now())
+	if loader.Value() == nil {
+		// until the config is loaded every refresh is an attempt, err is not nil
+		return errors.WithMessagef(err, "failed to load %s", c.name)
+	}
+	if err != nil {
+		zap.L().Warn("failed to reload "+c.name, zap.Error(err))
+	}
+	return nil
+}
+
+func (c *reloadedConfig) value() interface{} {
+	c.mu.Lock()
+	loader := c.loader
+	c.mu.Unlock()
+	return loader.Value()
+}
+
+func enrichmentPipeline() *enrichment.Pipeline {
+	pipeline, _ := enrichmentConfig.value().(*enrichment.Pipeline)
+	return pipeline
+}
+
+func framingRules() *framing.Config {
+	config, _ := framingConfig.value().(*framing.Config)
+	return config
+}
+
+func filterRules() *filtering.Config {
+	config, _ := filterConfig.value().(*filtering.Config)
+	return config
+}
+
+func flushPolicies() *flushing.Config {
+	config, _ := flushConfig.value().(*flushing.Config)
+	return config
+}
+
+func redactor() *redaction.Redactor {
+	r, _ := redactionConfig.value().(*redaction.Redactor)
+	return r
+}
+
+// parseEnrichment loads the enrichment data of the config, the AWS accounts of sources are listed with the source API
+func parseEnrichment(data []byte) (interface{}, time.Duration, error) {
+	return enrichment.Parser(newOpener(), sources.ListAWSAccountNames)(data)
+}
+
+// parseRedaction loads the HMAC key of hash rules from Secrets Manager, it is kept out of the config file
+func parseRedaction(data []byte) (interface{}, time.Duration, error) {
+	return redaction.Parser(redactionHMACKey)(data)
+}
+
+func redactionHMACKey() ([]byte, error) {
+	secretID := common.Config.RedactionHMACKeySecret
+	if secretID == "" {
+		return nil, errors.New("no HMAC key secret is configured")
+	}
+	output, err := secretsmanager.New(common.Session).GetSecretValue(&secretsmanager.GetSecretValueInput{
+		SecretId: &secretID,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read secret %s", secretID)
+	}
+	if output.SecretString != nil {
+		return []byte(*output.SecretString), nil
+	}
+	return output.SecretBinary, nil
+}
+
+// newOpener opens the S3 URLs and local files of the log processor configs
+func newOpener() reload.Opener {
+	return reload.NewOpener(s3.New(common.Session))
+}
@@ -0,0 +1,253 @@
+package processor
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/common"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/destinations"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/registry"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/sources"
+	"github.com/panther-labs/panther/pkg/awsbatch/sqsbatch"
+)
+
+const (
+	// daemonWaitTimeSeconds is shorter than sqsWaitTimeSeconds so that a stopping daemon drains promptly
+	daemonWaitTimeSeconds = 5
+	// daemonRetryInterval is the pause after a failure, the messages of a failed cycle are received again once visible
+	daemonRetryInterval = 10 * time.Second
+)
+
+// DaemonConfig configures the long running log processor
+type DaemonConfig struct {
+	// The number of goroutines consuming the queue, each with its own S3 destination
+	Concurrency int
+	// Each consumer writes its events to S3 and deletes their messages from the queue at least this often.
+	// It must be shorter than the visibility timeout of the queue.
+	FlushInterval time.Duration
+	// The memory of the process, split between the S3 destinations of the consumers
+	MemorySizeMB int
+}
+
+// DaemonStats are the counters of a running daemon, they are updated atomically and can be read while it runs
+type DaemonStats struct {
+	MessagesReceived uint64
+	MessagesDeleted  uint64
+	Cycles           uint64
+	FailedCycles     uint64
+	ActiveConsumers  int64
+	// Unix time of the end of the last cycle of the consumer that has waited the longest, or of the start of the daemon
+	LastCycleTime int64
+	// Unix time of the end of the last cycle of each consumer
+	consumerCycleTimes []int64
+}
+
+/*
+RunDaemon consumes the queue until ctx is done, as a long running alternative to the Lambda for sustained load.
+Each consumer reads the queue in cycles of its own: the config is refreshed, then messages are read and processed until
+the flush interval has passed, the buffers of the consumer are written to S3 and the messages are deleted.
+A consumer that fails pauses before its next cycle while the others keep going.
+When ctx is done the consumers stop reading and complete their current cycle, so no received message is lost.
+*/
+func RunDaemon(ctx context.Context, sqsClient sqsiface.SQSAPI, config DaemonConfig, stats *DaemonStats) {
+	newDestination := func() destinations.Destination {
+		return destinations.NewS3Destination(registry.Default(), config.MemorySizeMB/config.Concurrency, flushPolicies())
+	}
+	runDaemon(ctx, sqsClient, config, stats, refreshConfig, newDestination, Process, sources.ReadSnsMessages)
+}
+
+// entry point for unit testing, pass in refresh/destination/read/process functions
+func runDaemon(ctx context.Context, sqsClient sqsiface.SQSAPI, config DaemonConfig, stats *DaemonStats,
+	refreshFunc func() error,
+	newDestinationFunc func() destinations.Destination,
+	processFunc func(chan *common.DataStream, destinations.Destination) error,
+	generateDataStreamsFunc func([]string) ([]*common.DataStream, error)) {
+
+	stats.consumerCycleTimes = make([]int64, config.Concurrency)
+	for consumer := range stats.consumerCycleTimes {
+		stats.endCycle(consumer, time.Now())
+	}
+	var consumersWg sync.WaitGroup
+	for consumer := 0; consumer < config.Concurrency; consumer++ {
+		consumersWg.Add(1)
+		go func(consumer int) {
+			defer consumersWg.Done()
+			for ctx.Err() == nil {
+				// the configs are refreshed by each consumer, running processors keep the config they were created with
+				if err := refreshFunc(); err != nil {
+					zap.L().Error("failed to refresh log processor config", zap.Error(err))
+					sleepContext(ctx, daemonRetryInterval)
+					continue
+				}
+				deadline := time.Now().Add(config.FlushInterval)
+				atomic.AddInt64(&stats.ActiveConsumers, 1)
+				err := consumeQueue(ctx, sqsClient, deadline, stats, newDestinationFunc(), processFunc, generateDataStreamsFunc)
+				atomic.AddInt64(&stats.ActiveConsumers, -1)
+				atomic.AddUint64(&stats.Cycles, 1)
+				stats.endCycle(consumer, time.Now())
+				if err != nil {
+					atomic.AddUint64(&stats.FailedCycles, 1)
+					zap.L().Error("log processor cycle failed", zap.Error(err))
+					sleepContext(ctx, daemonRetryInterval)
+				}
+			}
+		}(consumer)
+	}
+	consumersWg.Wait()
+}
+
+// endCycle records the end of a cycle of a consumer, LastCycleTime is the oldest cycle end of all consumers
+func (stats *DaemonStats) endCycle(consumer int, now time.Time) {
+	atomic.StoreInt64(&stats.consumerCycleTimes[consumer], now.Unix())
+	oldest := now.Unix()
+	for i := range stats.consumerCycleTimes {
+		if cycleTime := atomic.LoadInt64(&stats.consumerCycleTimes[i]); cycleTime != 0 && cycleTime < oldest {
+			oldest = cycleTime
+		}
+	}
+	atomic.StoreInt64(&stats.LastCycleTime, oldest)
+}
+
+// consumeQueue reads messages until the deadline or until ctx is done, then deletes them once they are processed
+func consumeQueue(ctx context.Context, sqsClient sqsiface.SQSAPI, deadline time.Time, stats *DaemonStats,
+	destination destinations.Destination,
+	processFunc func(chan *common.DataStream, destinations.Destination) error,
+	generateDataStreamsFunc func([]string) ([]*common.DataStream, error)) error {
+
+	// stops reading if processing fails
+	readCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	streamChan := make(chan *common.DataStream, 2*sqsMaxBatchSize) // use small buffer to pipeline events
+	var messageReceipts []*string                                  // accumulate message receipts for delete at the end
+
+	readEventErrorChan := make(chan error, 1) // below go routine closes over this for errors, 1 deep buffer
+	go func() {
+		defer func() {
+			close(streamChan)         // done reading messages, this will cause processFunc() to return
+			close(readEventErrorChan) // no more writes on err chan
+		}()
+
+		highMemoryCounter := 0
+		for readCtx.Err() == nil && isProcessingTimeRemaining(deadline) {
+			// if we push too fast we can oom
+			if heapUsedMB, memAvailableMB, isHigh := highMemoryUsage(); isHigh {
+				if highMemoryCounter%100 == 0 { // limit logging
+					zap.L().Warn("high memory usage",
+						zap.Float32("heapUsedDB", heapUsedMB),
+						zap.Float32("memAvailableDB", memAvailableMB),
+						zap.Int("sqsMessagesRead", len(messageReceipts)))
+				}
+				time.Sleep(time.Second)
+				highMemoryCounter++
+				continue
+			}
+
+			messages, receipts, err := sqsbatch.ReceiveMessage(sqsClient, common.Config.SqsQueueURL, daemonWaitTimeSeconds)
+			if err != nil {
+				readEventErrorChan <- err
+				return
+			}
+			// remember so we can delete when done
+			messageReceipts = append(messageReceipts, receipts...)
+			atomic.AddUint64(&stats.MessagesReceived, uint64(len(messages)))
+
+			dataStreams, err := sqsDataStreams(messages, generateDataStreamsFunc)
+			if err != nil {
+				readEventErrorChan <- err
+				return
+			}
+			for _, dataStream := range dataStreams {
+				streamChan <- dataStream
+			}
+		}
+	}()
+
+	// process streamChan until closed (blocks)
+	if err := processFunc(streamChan, destination); err != nil {
+		cancel()
+		for dataStream := range streamChan { // let the reader exit
+			dataStream.Close()
+		}
+		return err
+	}
+	if err := <-readEventErrorChan; err != nil {
+		return err
+	}
+
+	// delete messages from sqs q on success (best effort)
+	sqsbatch.DeleteMessageBatch(sqsClient, common.Config.SqsQueueURL, messageReceipts)
+	atomic.AddUint64(&stats.MessagesDeleted, uint64(len(messageReceipts)))
+	return nil
+}
+
+// sleepContext pauses for d unless ctx is done first
+func sleepContext(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// Healthy returns false if a consumer has not completed a cycle within maxAge, which means it is stuck
+func (stats *DaemonStats) Healthy(now time.Time, maxAge time.Duration) bool {
+	lastCycleTime := time.Unix(atomic.LoadInt64(&stats.LastCycleTime), 0)
+	return now.Sub(lastCycleTime) <= maxAge
+}
+
+// WriteMetrics writes the stats in the Prometheus text exposition format
+func (stats *DaemonStats) WriteMetrics(w io.Writer) error {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	for _, metric := range []struct {
+		name  string
+		kind  string
+		help  string
+		value interface{}
+	}{
+		{"messages_received_total", "counter", "SQS messages received.", atomic.LoadUint64(&stats.MessagesReceived)},
+		{"messages_deleted_total", "counter", "SQS messages deleted after processing.", atomic.LoadUint64(&stats.MessagesDeleted)},
+		{"cycles_total", "counter", "Completed processing cycles.", atomic.LoadUint64(&stats.Cycles)},
+		{"failed_cycles_total", "counter", "Consumer cycles that failed.", atomic.LoadUint64(&stats.FailedCycles)},
+		{"active_consumers", "gauge", "Consumers reading the queue.", atomic.LoadInt64(&stats.ActiveConsumers)},
+		{"last_cycle_timestamp_seconds", "gauge", "Unix time of the slowest consumer's last cycle.", atomic.LoadInt64(&stats.LastCycleTime)},
+		{"heap_bytes", "gauge", "Bytes of allocated heap objects.", memStats.HeapAlloc},
+	} {
+		name := "panther_log_processor_" + metric.name
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %d\n",
+			name, metric.help, name, metric.kind, name, metric.value); err != nil {
+
+			return errors.Wrap(err, "failed to write metrics")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package processor
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bytes"
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/common"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/destinations"
+)
+
+func noopRefreshFunc() error {
+	return nil
+}
+
+func noopDestinationFunc() destinations.Destination {
+	return nil
+}
+
+func TestDaemonFlushInterval(t *testing.T) {
+	initTest()
+
+	const flushInterval = 10 * time.Millisecond
+	// receiving takes longer than the flush interval, so there is one message in each cycle
+	streamTestSqsClient.On("ReceiveMessage", mock.Anything).Return(streamTestReceiveMessageOutput, nil).
+		Run(func(mock.Arguments) { time.Sleep(2 * flushInterval) }).Twice()
+	streamTestSqsClient.On("DeleteMessageBatch", mock.Anything).Return(&sqs.DeleteMessageBatchOutput{}, nil).Twice()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var refreshCount, streamCount int
+	refreshFunc := func() error {
+		refreshCount++
+		return nil
+	}
+	processFunc := func(streamChan chan *common.DataStream, dest destinations.Destination) error {
+		for range streamChan {
+			streamCount++
+		}
+		if streamCount == 2 {
+			cancel() // stop after the second message is processed
+		}
+		return nil
+	}
+	config := DaemonConfig{
+		Concurrency:   1,
+		FlushInterval: flushInterval,
+	}
+	stats := &DaemonStats{}
+	runDaemon(ctx, streamTestSqsClient, config, stats, refreshFunc, noopDestinationFunc, processFunc, noopReadSnsMessagesFunc)
+
+	streamTestSqsClient.AssertExpectations(t)
+	assert.Equal(t, 2, streamCount)
+	assert.Equal(t, refreshCount, int(stats.Cycles))
+	assert.Equal(t, uint64(2), stats.MessagesReceived)
+	assert.Equal(t, uint64(2), stats.MessagesDeleted)
+	assert.Equal(t, uint64(0), stats.FailedCycles)
+	assert.Equal(t, int64(0), stats.ActiveConsumers)
+}
+
+func TestDaemonDrainsWhenStopped(t *testing.T) {
+	initTest()
+
+	streamTestSqsClient.On("ReceiveMessage", mock.Anything).Return(streamTestReceiveMessageOutput, nil)
+	streamTestSqsClient.On("DeleteMessageBatch", mock.Anything).Return(&sqs.DeleteMessageBatchOutput{}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	config := DaemonConfig{
+		Concurrency:   2,
+		FlushInterval: time.Hour,
+	}
+	stats := &DaemonStats{}
+	var streamCount int64
+	processFunc := func(streamChan chan *common.DataStream, dest destinations.Destination) error {
+		for range streamChan {
+			// wait for all consumers to be in a cycle, like SIGTERM in the middle of their cycles
+			if atomic.AddInt64(&streamCount, 1) >= 100 && atomic.LoadInt64(&stats.ActiveConsumers) == int64(config.Concurrency) {
+				cancel()
+			}
+		}
+		return nil
+	}
+	runDaemon(ctx, streamTestSqsClient, config, stats, noopRefreshFunc, noopDestinationFunc, processFunc, noopReadSnsMessagesFunc)
+
+	// all received messages are processed and deleted
+	assert.Equal(t, uint64(config.Concurrency), stats.Cycles)
+	assert.Equal(t, uint64(streamCount), stats.MessagesReceived)
+	assert.Equal(t, stats.MessagesReceived, stats.MessagesDeleted)
+}
+
+func TestDaemonProcessError(t *testing.T) {
+	initTest()
+
+	streamTestSqsClient.On("ReceiveMessage", mock.Anything).Return(streamTestReceiveMessageOutput, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	processFunc := func(streamChan chan *common.DataStream, dest destinations.Destination) error {
+		<-streamChan
+		cancel()
+		return failProcessorFunc(streamChan, dest)
+	}
+	config := DaemonConfig{
+		Concurrency:   1,
+		FlushInterval: time.Hour,
+	}
+	stats := &DaemonStats{}
+	runDaemon(ctx, streamTestSqsClient, config, stats, noopRefreshFunc, noopDestinationFunc, processFunc, noopReadSnsMessagesFunc)
+
+	// the messages are not deleted so they are received again
+	streamTestSqsClient.AssertNotCalled(t, "DeleteMessageBatch", mock.Anything)
+	assert.Equal(t, uint64(1), stats.FailedCycles)
+	assert.Equal(t, uint64(0), stats.MessagesDeleted)
+}
+
+func TestDaemonStats(t *testing.T) {
+	now := time.Now()
+	stats := &DaemonStats{
+		MessagesReceived: 3,
+		ActiveConsumers:  2,
+		LastCycleTime:    now.Add(-time.Minute).Unix(),
+	}
+	assert.True(t, stats.Healthy(now, 2*time.Minute))
+	assert.False(t, stats.Healthy(now, 30*time.Second))
+
+	var metrics bytes.Buffer
+	require.NoError(t, stats.WriteMetrics(&metrics))
+	assert.Contains(t, metrics.String(), "# TYPE panther_log_processor_messages_received_total counter\n"+
+		"panther_log_processor_messages_received_total 3\n")
+	assert.Contains(t, metrics.String(), "\npanther_log_processor_active_consumers 2\n")
+}
+
+func TestDaemonConsumersAreIndependent(t *testing.T) {
+	initTest()
+
+	streamTestSqsClient.On("ReceiveMessage", mock.Anything).Return(streamTestReceiveMessageOutput, nil)
+	streamTestSqsClient.On("DeleteMessageBatch", mock.Anything).Return(&sqs.DeleteMessageBatchOutput{}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var calls, succeeded int64
+	processFunc := func(streamChan chan *common.DataStream, dest destinations.Destination) error {
+		if atomic.AddInt64(&calls, 1) == 1 {
+			// the consumer pauses for daemonRetryInterval after this failure
+			return failProcessorFunc(streamChan, dest)
+		}
+		for range streamChan {
+		}
+		if atomic.AddInt64(&succeeded, 1) == 3 {
+			cancel()
+		}
+		return nil
+	}
+	config := DaemonConfig{
+		Concurrency:   2,
+		FlushInterval: 10 * time.Millisecond,
+	}
+	stats := &DaemonStats{}
+	start := time.Now()
+	runDaemon(ctx, streamTestSqsClient, config, stats, noopRefreshFunc, noopDestinationFunc, processFunc, noopReadSnsMessagesFunc)
+
+	// the other consumer completes its cycles while the failed one is paused
+	assert.Less(t, int64(time.Since(start)), int64(daemonRetryInterval))
+	assert.Equal(t, uint64(1), stats.FailedCycles)
+	assert.GreaterOrEqual(t, atomic.LoadInt64(&succeeded), int64(3))
+}
@@ -0,0 +1,95 @@
+package processor
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"go.uber.org/zap"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/common"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/dedup"
+)
+
+const (
+	// memoryDedupMaxSize bounds the keys kept in memory when no dedup table is configured
+	memoryDedupMaxSize = 1000000
+
+	// dedupBatchSize is the number of lines checked together, the most keys a DynamoDB BatchGetItem call can read
+	dedupBatchSize = 100
+)
+
+// dedupStore is created on first use since it needs the config from the environment.
+// It stays nil if dedup is disabled. It is shared by all processing runs, each run has its own window.
+var (
+	dedupStore dedup.Store
+	// dedupMu guards dedupStore, the consumers of the daemon set it up concurrently
+	dedupMu sync.Mutex
+)
+
+// setupDedup creates the dedup store, keys are kept in the dedup table so that they are shared by all invocations.
+// Without a table, repeats are only detected within the lifetime of the Lambda container.
+func setupDedup() {
+	dedupMu.Lock()
+	defer dedupMu.Unlock()
+	if dedupStore != nil || common.Config.DedupWindowMinutes <= 0 {
+		return
+	}
+	var store dedup.Store
+	if common.Config.DedupTable != "" {
+		store = &dedup.DynamoDBStore{
+			Client:    dynamodb.New(common.Session),
+			TableName: common.Config.DedupTable,
+		}
+	} else {
+		memoryStore := dedup.NewMemoryStore()
+		memoryStore.MaxSize = memoryDedupMaxSize
+		store = memoryStore
+	}
+	dedupStore = store
+}
+
+// newDedupWindow returns the window of a processing run, it holds the keys of the lines read by the run.
+// It returns nil if dedup is disabled.
+func newDedupWindow() *dedup.Window {
+	dedupMu.Lock()
+	store := dedupStore
+	dedupMu.Unlock()
+	if store == nil {
+		return nil
+	}
+	return dedup.NewWindow(store, time.Duration(common.Config.DedupWindowMinutes)*time.Minute)
+}
+
+// commitDedup records the keys of the lines read by a processing run once all its events are stored.
+// If the run failed the keys are discarded, the lines are processed again when its input is redelivered.
+// Only the keys of the run are affected, runs of the daemon that are processing concurrently are not.
+func commitDedup(window *dedup.Window, processErr error) {
+	if processErr != nil {
+		window.Discard()
+		return
+	}
+	pending := window.Pending()
+	if err := window.Commit(time.Now()); err != nil {
+		// the events are stored, only repeats of them will not be detected
+		zap.L().Warn("failed to record dedup keys", zap.Int("keys", pending), zap.Error(err))
+	}
+}
@@ -0,0 +1,164 @@
+package processor
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/common"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/drift"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/jsonutil"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/registry"
+)
+
+// driftReportPeriod is how often the unknown fields seen by the Lambda container are logged
+const driftReportPeriod = time.Hour
+
+var (
+	driftReport = drift.NewReport(driftReportPeriod, time.Now())
+
+	// detectors are cached by the type of the events since user-defined log types can change at any time
+	driftDetectorsMu sync.Mutex
+	driftDetectors   = make(map[reflect.Type]*drift.Detector)
+)
+
+// driftDetector returns the detector of the unknown fields of a log type, it is nil if the log type is not registered
+func driftDetector(logType string) *drift.Detector {
+	entry := registry.Default().Get(logType)
+	if entry == nil {
+		return nil
+	}
+	schema := entry.Schema()
+	t := reflect.TypeOf(schema)
+	driftDetectorsMu.Lock()
+	defer driftDetectorsMu.Unlock()
+	detector, ok := driftDetectors[t]
+	if !ok {
+		detector = drift.NewDetector(schema)
+		driftDetectors[t] = detector
+	}
+	return detector
+}
+
+// preservesUnknownFields checks if a log type keeps the fields not in its schema in the p_unknown_fields column
+func preservesUnknownFields(logType string) bool {
+	for _, preserved := range common.Config.PreserveUnknownFields {
+		if preserved == logType || preserved == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// redactUnknownFields applies the redaction rules of the log type to the values of unknown fields.
+// Each value is nested at the path of its field so that rules match it as they match the fields of parsed events.
+// Fields dropped by a rule are removed.
+func redactUnknownFields(logType string, fields []drift.Field) []drift.Field {
+	if len(fields) == 0 {
+		return fields
+	}
+	redacted := make([]drift.Field, 0, len(fields))
+	for _, field := range fields {
+		segments := strings.Split(field.Path, ".")
+		var event interface{} = jsoniter.RawMessage(field.Value)
+		for i := len(segments) - 1; i >= 0; i-- {
+			name, indexes := splitPathSegment(segments[i])
+			// the index does not matter, rules apply to all the elements of arrays
+			for j := 0; j < indexes; j++ {
+				event = []interface{}{event}
+			}
+			event = map[string]interface{}{name: event}
+		}
+		parsers.Redact(logType, &event)
+		if value, ok := nestedValue(event, segments); ok {
+			redacted = append(redacted, drift.Field{Path: field.Path, Value: value})
+		}
+	}
+	return redacted
+}
+
+// nestedValue returns the raw JSON value nested by redactUnknownFields, ok is false if it was removed
+func nestedValue(event interface{}, segments []string) (_ []byte, ok bool) {
+	for _, segment := range segments {
+		name, indexes := splitPathSegment(segment)
+		object, ok := event.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		event = object[name]
+		for j := 0; j < indexes; j++ {
+			array, ok := event.([]interface{})
+			if !ok || len(array) != 1 {
+				return nil, false
+			}
+			event = array[0]
+		}
+	}
+	value, ok := event.(jsoniter.RawMessage)
+	return value, ok
+}
+
+// splitPathSegment returns the field name of a path segment and its number of array indexes (e.g. `tags[1][0]`)
+func splitPathSegment(segment string) (name string, indexes int) {
+	for strings.HasSuffix(segment, "]") {
+		open := strings.LastIndexByte(segment, '[')
+		if open < 0 {
+			break
+		}
+		segment = segment[:open]
+		indexes++
+	}
+	return segment, indexes
+}
+
+// addUnknownFields adds the fields not in the schema of the log type to an event
+func addUnknownFields(event *parsers.Result, fields []drift.Field) error {
+	if len(fields) == 0 || len(event.JSON) == 0 {
+		return nil
+	}
+	values := make(map[string]string, len(fields))
+	for _, field := range fields {
+		values[field.Path] = string(field.Value)
+	}
+	value, err := jsoniter.Marshal(values)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal unknown fields")
+	}
+	event.JSON, err = jsonutil.AppendField(event.JSON, `"p_unknown_fields":`, value)
+	return err
+}
+
+// logDriftReport logs the unknown fields seen by each log type once the report period has passed
+func logDriftReport(now time.Time) {
+	for _, stats := range driftReport.Flush(now) {
+		zap.L().Warn("log fields not in schema",
+			zap.String("logType", stats.LogType),
+			zap.String("field", stats.Path),
+			zap.Uint64("count", stats.Count),
+			zap.String("example", stats.Example))
+	}
+}
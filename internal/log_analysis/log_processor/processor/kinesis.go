@@ -0,0 +1,67 @@
+package processor
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/common"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/destinations"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/registry"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/sources"
+)
+
+// ProcessKinesisEvent processes the records of a Kinesis stream source.
+// Any error fails the whole batch, so that Lambda retries it. Lambda splits failing batches in half
+// and sends their metadata to the failure queue once the retries are exhausted.
+func ProcessKinesisEvent(event *events.KinesisEvent) (recordCount int, err error) {
+	if len(event.Records) == 0 {
+		return 0, nil
+	}
+	if err := refreshConfig(); err != nil {
+		return 0, err
+	}
+	return processKinesisEvent(event, Process, sources.ReadKinesisRecords)
+}
+
+// entry point for unit testing, pass in read/process functions
+func processKinesisEvent(event *events.KinesisEvent,
+	processFunc func(chan *common.DataStream, destinations.Destination) error,
+	readRecordsFunc func([]*events.KinesisEventRecord) ([]*common.DataStream, error)) (int, error) {
+
+	records := make([]*events.KinesisEventRecord, len(event.Records))
+	for i := range event.Records {
+		records[i] = &event.Records[i]
+	}
+	dataStreams, err := readRecordsFunc(records)
+	if err != nil {
+		return 0, err
+	}
+
+	streamChan := make(chan *common.DataStream, len(dataStreams))
+	for _, dataStream := range dataStreams {
+		streamChan <- dataStream
+	}
+	close(streamChan)
+
+	if err := processFunc(streamChan, destinations.CreateS3Destination(registry.Default(), flushPolicies())); err != nil {
+		return 0, err
+	}
+	return len(records), nil
+}
@@ -0,0 +1,78 @@
+package processor
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/common"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/destinations"
+)
+
+var kinesisTestEvent = &events.KinesisEvent{
+	Records: []events.KinesisEventRecord{
+		{Kinesis: events.KinesisRecord{Data: []byte("one")}},
+		{Kinesis: events.KinesisRecord{Data: []byte("two")}},
+	},
+}
+
+func TestProcessKinesisEvent(t *testing.T) {
+	initTest()
+
+	var read []string
+	readRecords := func(records []*events.KinesisEventRecord) ([]*common.DataStream, error) {
+		for _, record := range records {
+			read = append(read, string(record.Kinesis.Data))
+		}
+		return []*common.DataStream{{}}, nil
+	}
+	processed := 0
+	processFunc := func(streamChan chan *common.DataStream, _ destinations.Destination) error {
+		for range streamChan {
+			processed++
+		}
+		return nil
+	}
+
+	recordCount, err := processKinesisEvent(kinesisTestEvent, processFunc, readRecords)
+	require.NoError(t, err)
+	require.Equal(t, 2, recordCount)
+	require.Equal(t, []string{"one", "two"}, read)
+	require.Equal(t, 1, processed)
+}
+
+func TestProcessKinesisEventErrors(t *testing.T) {
+	initTest()
+
+	failRead := func([]*events.KinesisEventRecord) ([]*common.DataStream, error) {
+		return nil, errors.New("readError")
+	}
+	_, err := processKinesisEvent(kinesisTestEvent, noopProcessorFunc, failRead)
+	require.EqualError(t, err, "readError")
+
+	noopRead := func([]*events.KinesisEventRecord) ([]*common.DataStream, error) {
+		return nil, nil
+	}
+	_, err = processKinesisEvent(kinesisTestEvent, failProcessorFunc, noopRead)
+	require.EqualError(t, err, "processError")
+}
@@ -19,17 +19,24 @@ package processor
  */
 
 import (
-	"bufio"
 	"io"
 	"strings"
 	"sync"
+	"time"
 
+	jsoniter "github.com/json-iterator/go"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/classification"
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/common"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/deadletter"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/dedup"
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/destinations"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/enrichment"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/filtering"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/framing"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/jsonutil"
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/registry"
 	"github.com/panther-labs/panther/pkg/metrics"
@@ -55,15 +62,62 @@ var (
 // Process orchestrates the tasks of parsing logs, classification, normalization
 // and forwarding the logs to the appropriate destination. Any errors will cause Lambda invocation to fail
 func Process(dataStreams chan *common.DataStream, destination destinations.Destination) error {
-	factory := func(r *common.DataStream) *Processor {
-		// By initializing the global parsers here we can constrain the proliferation of globals throughout the code.
-		allParsers := registry.AvailableParsers()
-		return NewProcessor(r, allParsers)
+	return processRun(dataStreams, destination, newProcessor)
+}
+
+// newProcessor creates the processor of a data stream, window holds the dedup keys of its processing run
+func newProcessor(r *common.DataStream, window *dedup.Window) *Processor {
+	// By initializing the global parsers here we can constrain the proliferation of globals throughout the code.
+	allParsers := registry.AvailableParsers()
+	return &Processor{
+		input:      r,
+		classifier: newClassifier(allParsers, r.Hints.LogTypes, common.Config.ClassificationFallback),
+		operation:  common.OpLogManager.Start(operationName),
+		enrichment: enrichmentPipeline(),
+		framing:    framingRules().Rule(r.Hints.SourceID, r.Hints.LogTypes),
+		filters:    filterRules(),
+		dedup:      window,
 	}
-	return process(dataStreams, destination, factory)
+}
+
+// processRun processes the data streams of a run with its own dedup window.
+// The keys of the window are recorded only if the whole run succeeds.
+func processRun(dataStreams chan *common.DataStream, destination destinations.Destination,
+	newProcessorFunc func(*common.DataStream, *dedup.Window) *Processor) error {
+
+	window := newDedupWindow()
+	err := process(dataStreams, destination, func(r *common.DataStream) *Processor {
+		return newProcessorFunc(r, window)
+	})
+	commitDedup(window, err)
+	return err
 }
 
 // entry point to allow customizing processor for testing
+// processStream processes a data stream, or the streams it unpacks one after the other.
+// Streams are closed once processed, unpacked streams are released once they are all processed or one fails.
+func processStream(dataStream *common.DataStream, newProcessorFunc func(*common.DataStream) *Processor,
+	outputChan chan *parsers.Result) error {
+
+	defer dataStream.Close()
+	if dataStream.Unpack == nil {
+		return newProcessorFunc(dataStream).run(outputChan)
+	}
+	streams, release, err := dataStream.Unpack()
+	if err != nil {
+		return err
+	}
+	defer release()
+	for _, stream := range streams {
+		err := newProcessorFunc(stream).run(outputChan)
+		stream.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func process(dataStreams chan *common.DataStream, destination destinations.Destination,
 	newProcessorFunc func(*common.DataStream) *Processor) error {
 
@@ -90,8 +144,7 @@ func process(dataStreams chan *common.DataStream, destination destinations.Desti
 
 	// it is important to process the streams serially to manage memory!
 	for dataStream := range dataStreams {
-		processor := newProcessorFunc(dataStream)
-		err := processor.run(parsedEventChannel)
+		err := processStream(dataStream, newProcessorFunc, parsedEventChannel)
 		if err != nil {
 			errorChannel <- err
 			break
@@ -113,53 +166,179 @@ func process(dataStreams chan *common.DataStream, destination destinations.Desti
 // processStream reads the data from an S3 the dataStream, parses it and writes events to the output channel
 func (p *Processor) run(outputChan chan *parsers.Result) error {
 	var err error
-	stream := bufio.NewReader(p.input.Reader)
+	stream := framing.NewReader(p.input.Reader, p.framing)
 	for {
 		var line string
-		line, err = stream.ReadString(common.EventDelimiter)
+		line, err = stream.ReadEvent()
 		if err != nil {
 			if err == io.EOF { // we are done
 				err = nil // not really an error
-				p.processLogLine(line, outputChan)
 			}
 			break
 		}
-		p.processLogLine(line, outputChan)
+		p.processLogLine(line, stream.Line(), outputChan)
 	}
+	p.sendDedupBatch(outputChan)
 	if err != nil {
-		err = errors.Wrap(err, "failed to ReadString()")
+		err = errors.Wrap(err, "failed to ReadEvent()")
 	}
 	p.logStats(err) // emit log line describing the processing of the file and any errors
 	return err
 }
 
-func (p *Processor) processLogLine(line string, outputChan chan *parsers.Result) {
-	classificationResult := p.classifyLogLine(line)
+// processLogLine classifies an event read from the stream, lineNum is the number of its first line
+func (p *Processor) processLogLine(line string, lineNum uint64, outputChan chan *parsers.Result) {
+	classificationResult := p.classifyLogLine(line, lineNum)
+	if classificationResult.LogType == nil || classificationResult.Panicked() {
+		p.sendDeadLetter(line, lineNum, classificationResult, outputChan)
+	}
 	if classificationResult.LogType == nil { // unable to classify, no error, keep parsing (best effort, will be logged)
 		return
 	}
-	p.sendEvents(classificationResult, outputChan)
+	if p.dedup == nil {
+		p.sendEvents(line, classificationResult, outputChan)
+		return
+	}
+	// lines are checked for repeats in batches, to look up their keys together
+	p.dedupBatch = append(p.dedupBatch, classifiedLine{line: line, result: classificationResult})
+	if len(p.dedupBatch) >= dedupBatchSize {
+		p.sendDedupBatch(outputChan)
+	}
+}
+
+// classifiedLine is a line waiting for the dedup check of its batch
+type classifiedLine struct {
+	line   string
+	result *classification.ClassifierResult
 }
 
-func (p *Processor) classifyLogLine(line string) *classification.ClassifierResult {
+// sendDedupBatch sends the events of the lines of the dedup batch that were not seen before
+func (p *Processor) sendDedupBatch(outputChan chan *parsers.Result) {
+	if len(p.dedupBatch) == 0 {
+		return
+	}
+	keys := make([]string, len(p.dedupBatch))
+	for i, classified := range p.dedupBatch {
+		// the whole line is checked once since all events of a line are repeated with it
+		keys[i] = dedup.Key(*classified.result.LogType, classified.line)
+	}
+	duplicates, err := p.dedup.Duplicates(keys, time.Now())
+	if err != nil {
+		// the events are stored, a duplicate is better than a lost event
+		p.operation.LogWarn(err, zap.Int("lines", len(keys)))
+	}
+	for i, classified := range p.dedupBatch {
+		if !duplicates[i] {
+			p.sendEvents(classified.line, classified.result, outputChan)
+			continue
+		}
+		if stats := p.classifier.ParserStats()[*classified.result.LogType]; stats != nil {
+			stats.DuplicateEventCount += uint64(len(classified.result.Events))
+		}
+	}
+	p.dedupBatch = p.dedupBatch[:0]
+}
+
+// sendDeadLetter stores log lines that failed to classify or made a parser panic, so they can be queried later
+func (p *Processor) sendDeadLetter(line string, lineNum uint64, result *classification.ClassifierResult,
+	outputChan chan *parsers.Result) {
+
+	line = strings.TrimRight(line, "\r\n")
+	if len(strings.TrimSpace(line)) == 0 {
+		return
+	}
+	deadLetter, err := deadletter.NewResult(line, lineNum, p.input.Hints.S3, result, time.Now())
+	if err != nil {
+		p.operation.LogWarn(err, zap.Uint64("lineNum", lineNum))
+		return
+	}
+	deadLetter.OutputPrefix = p.input.Hints.OutputPrefix
+	outputChan <- deadLetter
+}
+
+func (p *Processor) classifyLogLine(line string, lineNum uint64) *classification.ClassifierResult {
 	result := p.classifier.Classify(line)
 	if result.LogType == nil && len(strings.TrimSpace(line)) != 0 { // only if line is not empty do we log (often we get trailing \n's)
 		if p.input.Hints.S3 != nil { // make easy to troubleshoot but do not add log line (even partial) to avoid leaking data into CW
 			p.operation.LogWarn(errors.New("failed to classify log line"),
-				zap.Uint64("lineNum", p.classifier.Stats().LogLineCount),
+				zap.Uint64("lineNum", lineNum),
 				zap.String("bucket", p.input.Hints.S3.Bucket),
-				zap.String("key", p.input.Hints.S3.Key))
+				zap.String("key", p.input.Hints.S3.Key),
+				zap.String("archiveMember", p.input.Hints.S3.ArchiveMember))
 		}
 	}
 	return result
 }
 
-func (p *Processor) sendEvents(result *classification.ClassifierResult, outputChan chan *parsers.Result) {
+func (p *Processor) sendEvents(line string, result *classification.ClassifierResult, outputChan chan *parsers.Result) {
+	stats := p.classifier.ParserStats()[*result.LogType]
+	// unknown fields are reported for all log types but only kept if they can be attributed to a single event
+	// the values are redacted since they are stored and logged as examples
+	unknownFields := redactUnknownFields(*result.LogType, driftDetector(*result.LogType).Unknown(line))
+	driftReport.Add(*result.LogType, unknownFields)
+	if len(result.Events) != 1 || !preservesUnknownFields(*result.LogType) {
+		unknownFields = nil
+	}
 	for _, event := range result.Events {
+		decision, routePrefix := p.filters.Apply(p.input.Hints.SourceID, event.LogType, line, event.JSON)
+		switch decision {
+		case filtering.Drop:
+			if stats != nil {
+				stats.FilteredEventCount++
+			}
+			continue
+		case filtering.SampleOut:
+			if stats != nil {
+				stats.SampledOutEventCount++
+			}
+			continue
+		case filtering.Route:
+			if stats != nil {
+				stats.RoutedEventCount++
+			}
+		}
+		if err := addUnknownFields(event, unknownFields); err != nil {
+			p.operation.LogWarn(err, zap.String("logType", event.LogType))
+		}
+		if err := p.enrichment.Enrich(event); err != nil {
+			// the event is stored without enrichment
+			p.operation.LogWarn(err, zap.String("logType", event.LogType))
+		}
+		if err := addCloudWatchLogsFields(event, p.input.Hints.CloudWatchLogs); err != nil {
+			p.operation.LogWarn(err, zap.String("logType", event.LogType))
+		}
+		event.OutputPrefix = p.input.Hints.OutputPrefix
+		if event.OutputPrefix == "" {
+			// replayed data is already stored apart, routes only apply to live data
+			event.OutputPrefix = routePrefix
+		}
 		outputChan <- event
 	}
 }
 
+// addCloudWatchLogsFields adds the log group and stream of events received from CloudWatch Logs subscriptions
+func addCloudWatchLogsFields(event *parsers.Result, hints *common.CloudWatchLogsDataStreamHints) error {
+	if hints == nil || len(event.JSON) == 0 {
+		return nil
+	}
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{`"p_source_log_group":`, hints.LogGroup},
+		{`"p_source_log_stream":`, hints.LogStream},
+	} {
+		value, err := jsoniter.Marshal(field.value)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal CloudWatch Logs fields")
+		}
+		if event.JSON, err = jsonutil.AppendField(event.JSON, field.name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (p *Processor) logStats(err error) {
 	p.operation.Stop()
 	p.operation.Log(err, zap.Any(statsKey, *p.classifier.Stats()))
@@ -174,12 +353,43 @@ func (p *Processor) logStats(err error) {
 		pMetrics[0].Value, pMetrics[1].Value = parserStats.BytesProcessedCount, parserStats.EventCount
 		common.BytesProcessedLogger.Log(pMetrics, logType)
 	}
+	logDriftReport(time.Now())
 }
 
 type Processor struct {
 	input      *common.DataStream
 	classifier classification.ClassifierAPI
 	operation  *oplog.Operation
+	// enrichment is nil if no enrichment is configured
+	enrichment *enrichment.Pipeline
+	// framing is nil if the input is read line by line
+	framing *framing.Rule
+	// filters is nil if all events are stored
+	filters *filtering.Config
+	// dedup is nil if repeated events are stored
+	dedup *dedup.Window
+	// dedupBatch are the lines waiting for their dedup check
+	dedupBatch []classifiedLine
+}
+
+// newClassifier restricts classification to the parsers of the log types declared by the source of the data.
+// If fallback is true, the rest of the parsers are tried when none of those succeeds.
+// If no known log types are declared all parsers are used.
+func newClassifier(allParsers map[string]parsers.Interface, logTypes []string, fallback bool) classification.ClassifierAPI {
+	declared := make(map[string]parsers.Interface, len(logTypes))
+	for _, logType := range logTypes {
+		if parser, ok := allParsers[logType]; ok {
+			declared[logType] = parser
+			delete(allParsers, logType)
+		}
+	}
+	if len(declared) == 0 {
+		return classification.NewClassifier(allParsers)
+	}
+	if fallback {
+		return classification.NewClassifierWithFallback(declared, allParsers)
+	}
+	return classification.NewClassifier(declared)
 }
 
 func NewProcessor(input *common.DataStream, parsers map[string]parsers.Interface) *Processor {
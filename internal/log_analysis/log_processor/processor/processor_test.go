@@ -20,10 +20,14 @@ package processor
 
 import (
 	"fmt"
+	"io"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	jsoniter "github.com/json-iterator/go"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -34,9 +38,17 @@ import (
 
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/classification"
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/common"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/deadletter"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/dedup"
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/destinations"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/drift"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/enrichment"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/filtering"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/framing"
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/testutil"
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/timestamp"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/redaction"
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/registry"
 	"github.com/panther-labs/panther/pkg/metrics"
 	"github.com/panther-labs/panther/pkg/oplog"
@@ -148,7 +160,7 @@ func TestProcessDataStreamError(t *testing.T) {
 			zap.Any(statsKey, *mockStats),
 
 			// error
-			zap.Error(errors.Wrap(errFailingReader, "failed to ReadString()")), // from run()
+			zap.Error(errors.Wrap(errFailingReader, "failed to ReadEvent()")), // from run()
 
 			// standard
 			zap.String("namespace", common.OpLogNamespace),
@@ -166,6 +178,64 @@ func TestProcessDataStreamError(t *testing.T) {
 	assertLogEqual(t, expectedLog, actualLog)
 }
 
+func TestProcessUnpack(t *testing.T) {
+	parser := testutil.ParserConfig{
+		"member": []*parsers.Result{{LogType: testLogType, JSON: []byte(`{}`)}},
+	}.Parser()
+	newProcessorFunc := func(r *common.DataStream) *Processor {
+		return NewProcessor(r, map[string]parsers.Interface{testLogType: parser})
+	}
+	for name, failing := range map[string]bool{"success": false, "failure": true} {
+		failing := failing
+		t.Run(name, func(t *testing.T) {
+			members := []*closingReader{{Reader: strings.NewReader("member\n")}, {Reader: strings.NewReader("member\n")}}
+			if failing {
+				members[0].Reader = &failingReader{}
+			}
+			released := false
+			archive := &closingReader{Reader: strings.NewReader("archive")}
+			dataStream := &common.DataStream{
+				Reader: archive,
+				Unpack: func() ([]*common.DataStream, func(), error) {
+					assert.False(t, archive.closed)
+					streams := make([]*common.DataStream, len(members))
+					for i, member := range members {
+						streams[i] = &common.DataStream{Reader: member}
+					}
+					return streams, func() { released = true }, nil
+				},
+			}
+
+			destination := (&testDestination{}).standardMock()
+			streamChan := make(chan *common.DataStream, 1)
+			streamChan <- dataStream
+			close(streamChan)
+			err := process(streamChan, destination, newProcessorFunc)
+			// the archive is released whether its members are processed or not
+			assert.True(t, released)
+			assert.True(t, archive.closed)
+			assert.True(t, members[0].closed)
+			if failing {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, members[1].closed)
+			assert.Equal(t, uint64(2), destination.nEvents)
+		})
+	}
+}
+
+type closingReader struct {
+	io.Reader
+	closed bool
+}
+
+func (r *closingReader) Close() error {
+	r.closed = true
+	return nil
+}
+
 func TestProcessDataStreamErrorNoChannelBuffers(t *testing.T) {
 	ParsedEventBufferSize = 0 // ensure we work when event channel is blocking
 	TestProcessDataStreamError(t)
@@ -399,6 +469,443 @@ func TestProcessClassifyFailure(t *testing.T) {
 	}
 }
 
+func TestProcessDeadLetters(t *testing.T) {
+	result := &parsers.Result{LogType: testLogType, JSON: []byte(`{}`)}
+	panicParser := &testutil.MockParser{}
+	panicParser.On("Parse", mock.Anything).Run(func(args mock.Arguments) { panic("test parser panic") })
+	p := NewProcessor(&common.DataStream{
+		Reader: strings.NewReader("good\nbad\n\n"),
+		Hints:  common.DataStreamHints{S3: s3Hint},
+	}, map[string]parsers.Interface{
+		testLogType: testutil.ParserConfig{"good": result}.Parser(),
+		"panic":     panicParser,
+	})
+
+	outputChan := make(chan *parsers.Result, 10)
+	require.NoError(t, p.run(outputChan))
+	close(outputChan)
+
+	var events, deadLetters []*parsers.Result
+	for event := range outputChan {
+		if event.LogType == deadletter.LogType {
+			deadLetters = append(deadLetters, event)
+		} else {
+			events = append(events, event)
+		}
+	}
+	require.Equal(t, []*parsers.Result{result}, events)
+	// Empty lines are not dead letters. Parsers with the same priority are tried in the order of their log types,
+	// the panic parser is tried first and the good line is a dead letter too.
+	require.Len(t, deadLetters, 2)
+	var lines []string
+	for _, deadLetter := range deadLetters {
+		event := deadletter.Event{}
+		require.NoError(t, jsoniter.Unmarshal(deadLetter.JSON, &struct {
+			*deadletter.Event
+			ParseTime string `json:"p_parse_time"`
+		}{Event: &event}))
+		require.Equal(t, testBucket, event.Bucket)
+		require.Equal(t, testKey, event.Key)
+		lines = append(lines, event.Line)
+		switch event.Line {
+		case "good": // parsed, but the panic is recorded
+			require.Equal(t, uint64(1), event.LineNumber)
+			require.Equal(t, testLogType, *event.LogType)
+			require.Len(t, event.Errors, 1)
+		case "bad":
+			require.Equal(t, uint64(2), event.LineNumber)
+			require.Nil(t, event.LogType)
+			require.Len(t, event.Errors, 2)
+		default:
+			require.Fail(t, "unexpected dead letter", event.Line)
+		}
+		panicked := false
+		for _, parserErr := range event.Errors {
+			panicked = panicked || parserErr.Panic
+		}
+		require.True(t, panicked)
+	}
+	require.Equal(t, []string{"good", "bad"}, lines)
+}
+
+func TestProcessFraming(t *testing.T) {
+	config, err := framing.ParseConfig([]byte(`logTypes: {testLogType: {mode: multiline, continuationPattern: '^\s'}}`))
+	require.NoError(t, err)
+	result := &parsers.Result{LogType: testLogType, JSON: []byte(`{}`)}
+	p := NewProcessor(&common.DataStream{
+		Reader: strings.NewReader("good\n  at trace\nbad\n  at other\n\ngood\n"),
+		Hints:  common.DataStreamHints{S3: s3Hint},
+	}, map[string]parsers.Interface{
+		testLogType: testutil.ParserConfig{"good\n  at trace": result, "good": result}.Parser(),
+	})
+	p.framing = config.Rule("", []string{testLogType})
+	require.NotNil(t, p.framing)
+
+	outputChan := make(chan *parsers.Result, 10)
+	require.NoError(t, p.run(outputChan))
+	close(outputChan)
+
+	var events []*parsers.Result
+	var deadLetters []deadletter.Event
+	for event := range outputChan {
+		if event.LogType != deadletter.LogType {
+			events = append(events, event)
+			continue
+		}
+		deadLetter := deadletter.Event{}
+		require.NoError(t, jsoniter.Unmarshal(event.JSON, &struct {
+			*deadletter.Event
+			ParseTime string `json:"p_parse_time"`
+		}{Event: &deadLetter}))
+		deadLetters = append(deadLetters, deadLetter)
+	}
+	require.Equal(t, []*parsers.Result{result, result}, events)
+	// dead letters hold the whole event and the number of its first line
+	require.Len(t, deadLetters, 1)
+	require.Equal(t, "bad\n  at other", deadLetters[0].Line)
+	require.Equal(t, uint64(3), deadLetters[0].LineNumber)
+}
+
+func TestProcessFilters(t *testing.T) {
+	config, err := filtering.ParseConfig([]byte(`
+sources:
+  source-id:
+    - {action: drop, match: [{field: action, equals: REJECT}]}
+logTypes:
+  testLogType:
+    - {action: sample, sampleRate: 0.0001, match: [{field: action, equals: NODATA}]}
+    - {action: route, prefix: archive, match: [{field: action, equals: SKIPDATA}]}
+`))
+	require.NoError(t, err)
+	accept := &parsers.Result{LogType: testLogType, JSON: []byte(`{"action":"ACCEPT"}`)}
+	reject := &parsers.Result{LogType: testLogType, JSON: []byte(`{"action":"REJECT"}`)}
+	noData := &parsers.Result{LogType: testLogType, JSON: []byte(`{"action":"NODATA"}`)}
+	skipData := &parsers.Result{LogType: testLogType, JSON: []byte(`{"action":"SKIPDATA"}`)}
+	p := NewProcessor(&common.DataStream{
+		Reader: strings.NewReader("flows\n"),
+		Hints:  common.DataStreamHints{SourceID: "source-id"},
+	}, map[string]parsers.Interface{
+		testLogType: testutil.ParserConfig{"flows": []*parsers.Result{accept, reject, noData, skipData}}.Parser(),
+	})
+	p.filters = config
+
+	outputChan := make(chan *parsers.Result, 10)
+	require.NoError(t, p.run(outputChan))
+	close(outputChan)
+
+	var events []*parsers.Result
+	for event := range outputChan {
+		events = append(events, event)
+	}
+	require.Equal(t, []*parsers.Result{accept, skipData}, events)
+	require.Empty(t, accept.OutputPrefix)
+	require.Equal(t, "routed/archive/", skipData.OutputPrefix)
+	stats := p.classifier.ParserStats()[testLogType]
+	require.Equal(t, uint64(4), stats.EventCount)
+	require.Equal(t, uint64(1), stats.FilteredEventCount)
+	require.Equal(t, uint64(1), stats.SampledOutEventCount)
+	require.Equal(t, uint64(1), stats.RoutedEventCount)
+}
+
+func TestProcessDedup(t *testing.T) {
+	event1 := &parsers.Result{LogType: testLogType, JSON: []byte(`{"id":1}`)}
+	event2 := &parsers.Result{LogType: testLogType, JSON: []byte(`{"id":2}`)}
+	window := dedup.NewWindow(dedup.NewMemoryStore(), time.Hour)
+	run := func(input string) ([]*parsers.Result, *classification.ParserStats) {
+		p := NewProcessor(&common.DataStream{
+			Reader: strings.NewReader(input),
+		}, map[string]parsers.Interface{
+			testLogType: testutil.ParserConfig{"batch": []*parsers.Result{event1, event2}}.Parser(),
+		})
+		p.dedup = window
+		outputChan := make(chan *parsers.Result, 10)
+		require.NoError(t, p.run(outputChan))
+		close(outputChan)
+		var events []*parsers.Result
+		for event := range outputChan {
+			events = append(events, event)
+		}
+		return events, p.classifier.ParserStats()[testLogType]
+	}
+
+	events, stats := run("batch\nbatch\n")
+	// the repeated line is dropped along with all its events
+	require.Equal(t, []*parsers.Result{event1, event2}, events)
+	require.Equal(t, uint64(2), stats.DuplicateEventCount)
+
+	// the keys of a failed invocation are discarded, its input is processed again when redelivered
+	commitDedup(window, errors.New("failed to write"))
+	events, stats = run("batch\n")
+	require.Equal(t, []*parsers.Result{event1, event2}, events)
+	require.Equal(t, uint64(0), stats.DuplicateEventCount)
+
+	// a redelivery of the same object after a successful invocation is dropped entirely
+	commitDedup(window, nil)
+	events, stats = run("batch\n")
+	require.Empty(t, events)
+	require.Equal(t, uint64(2), stats.DuplicateEventCount)
+}
+
+func TestProcessDedupConcurrentRuns(t *testing.T) {
+	defer func(store dedup.Store, config common.EnvConfig) {
+		dedupStore = store
+		common.Config = config
+	}(dedupStore, common.Config)
+	dedupStore = dedup.NewMemoryStore()
+	common.Config.DedupWindowMinutes = 60
+
+	const lines = 200
+	results := testutil.ParserConfig{}
+	input := func(prefix string) string {
+		var b strings.Builder
+		for i := 0; i < lines; i++ {
+			line := fmt.Sprintf("%s%d", prefix, i)
+			results[line] = []*parsers.Result{{LogType: testLogType, JSON: []byte(`{}`)}}
+			b.WriteString(line + "\n")
+		}
+		return b.String()
+	}
+	inputs := map[string]string{"a": input("a"), "b": input("b")}
+	parser := results.Parser()
+	newProcessorFunc := func(r *common.DataStream, window *dedup.Window) *Processor {
+		p := NewProcessor(r, map[string]parsers.Interface{testLogType: parser})
+		p.dedup = window
+		return p
+	}
+	run := func(prefix string, destination destinations.Destination) error {
+		streamChan := make(chan *common.DataStream, 1)
+		streamChan <- &common.DataStream{Reader: strings.NewReader(inputs[prefix])}
+		close(streamChan)
+		return processRun(streamChan, destination, newProcessorFunc)
+	}
+	counting := func(events *int64) *testDestination {
+		destination := &testDestination{}
+		destination.On("SendEvents", mock.Anything, mock.Anything).Return().Run(func(args mock.Arguments) {
+			for range args.Get(0).(chan *parsers.Result) {
+				atomic.AddInt64(events, 1)
+			}
+		})
+		return destination
+	}
+
+	// run b fails once run a has succeeded, while its keys are still pending
+	aDone := make(chan struct{})
+	failing := &testDestination{}
+	failing.On("SendEvents", mock.Anything, mock.Anything).Return().Run(func(args mock.Arguments) {
+		for range args.Get(0).(chan *parsers.Result) {
+		}
+		<-aDone
+		args.Get(1).(chan error) <- errors.New("failed to write")
+	})
+	var wg sync.WaitGroup
+	wg.Add(2)
+	var aEvents int64
+	go func() {
+		defer wg.Done()
+		defer close(aDone)
+		assert.NoError(t, run("a", counting(&aEvents)))
+	}()
+	go func() {
+		defer wg.Done()
+		assert.Error(t, run("b", failing))
+	}()
+	wg.Wait()
+	require.Equal(t, int64(lines), aEvents)
+
+	// the redelivered input of the failed run is processed again, the input of the successful run is dropped
+	var bEvents, aRepeats int64
+	require.NoError(t, run("b", counting(&bEvents)))
+	require.Equal(t, int64(lines), bEvents)
+	require.NoError(t, run("a", counting(&aRepeats)))
+	require.Zero(t, aRepeats)
+}
+
+func TestProcessDedupBatches(t *testing.T) {
+	event := &parsers.Result{LogType: testLogType, JSON: []byte(`{"id":1}`)}
+	store := &countingDedupStore{MemoryStore: dedup.NewMemoryStore()}
+	p := NewProcessor(&common.DataStream{
+		Reader: strings.NewReader(strings.Repeat("line\n", dedupBatchSize) + "other\n"),
+	}, map[string]parsers.Interface{
+		testLogType: testutil.ParserConfig{"line": []*parsers.Result{event}, "other": []*parsers.Result{event}}.Parser(),
+	})
+	p.dedup = dedup.NewWindow(store, time.Hour)
+	outputChan := make(chan *parsers.Result, dedupBatchSize+1)
+	require.NoError(t, p.run(outputChan))
+	close(outputChan)
+
+	// one lookup per batch of lines, the repeats of the first line are dropped
+	require.Equal(t, 2, store.lookups)
+	require.Len(t, outputChan, 2)
+	require.Equal(t, uint64(dedupBatchSize-1), p.classifier.ParserStats()[testLogType].DuplicateEventCount)
+}
+
+type countingDedupStore struct {
+	*dedup.MemoryStore
+	lookups int
+}
+
+func (s *countingDedupStore) Seen(keys []string, now time.Time) (map[string]bool, error) {
+	s.lookups++
+	return s.MemoryStore.Seen(keys, now)
+}
+
+func TestProcessUnknownFields(t *testing.T) {
+	defer func(config common.EnvConfig, report *drift.Report) {
+		common.Config, driftReport = config, report
+	}(common.Config, driftReport)
+	common.Config.PreserveUnknownFields = []string{"Osquery.Status"}
+	now := time.Now()
+	driftReport = drift.NewReport(time.Hour, now)
+
+	// nolint:lll
+	log := `{"hostIdentifier":"jaguar.local","calendarTime":"Tue Nov 5 06:08:26 2018 UTC","unixTime":"1535731040","severity":"0","filename":"tls.cpp","line":"253","message":"request","version":"4.1.2","numerics":false}`
+	parser, err := registry.Lookup("Osquery.Status").NewParser(nil)
+	require.NoError(t, err)
+	p := NewProcessor(&common.DataStream{
+		Reader: strings.NewReader(log + "\n"),
+	}, map[string]parsers.Interface{"Osquery.Status": parser})
+
+	outputChan := make(chan *parsers.Result, 10)
+	require.NoError(t, p.run(outputChan))
+	close(outputChan)
+	var events []*parsers.Result
+	for event := range outputChan {
+		events = append(events, event)
+	}
+	require.Len(t, events, 1)
+	require.Equal(t, `{"numerics":"false"}`, jsoniter.Get(events[0].JSON, "p_unknown_fields").ToString())
+	require.Equal(t, []drift.FieldStats{
+		{LogType: "Osquery.Status", Path: "numerics", Count: 1, Example: "false"},
+	}, driftReport.Flush(now.Add(time.Hour)))
+}
+
+func TestProcessUnknownFieldsRedacted(t *testing.T) {
+	defer func(config common.EnvConfig, report *drift.Report) {
+		common.Config, driftReport = config, report
+	}(common.Config, driftReport)
+	common.Config.PreserveUnknownFields = []string{"Osquery.Status"}
+	now := time.Now()
+	driftReport = drift.NewReport(time.Hour, now)
+	config, err := redaction.ParseConfig([]byte(`
+logTypes:
+  Osquery.Status:
+    - field: numerics
+      action: drop
+    - field: secret.token
+      action: mask
+    - field: items.token
+      action: mask
+`), nil)
+	require.NoError(t, err)
+	parsers.SetRedactor(redaction.New(config))
+	defer parsers.SetRedactor(nil)
+
+	// nolint:lll
+	log := `{"hostIdentifier":"jaguar.local","calendarTime":"Tue Nov 5 06:08:26 2018 UTC","unixTime":"1535731040","severity":"0","filename":"tls.cpp","line":"253","message":"request","version":"4.1.2","numerics":false,"secret":{"token":"abc","id":1},"items":[{"token":"xyz"}],"empty":null}`
+	parser, err := registry.Lookup("Osquery.Status").NewParser(nil)
+	require.NoError(t, err)
+	p := NewProcessor(&common.DataStream{
+		Reader: strings.NewReader(log + "\n"),
+	}, map[string]parsers.Interface{"Osquery.Status": parser})
+
+	outputChan := make(chan *parsers.Result, 10)
+	require.NoError(t, p.run(outputChan))
+	close(outputChan)
+	require.Len(t, outputChan, 1)
+	event := <-outputChan
+	unknownFields := map[string]string{}
+	require.NoError(t, jsoniter.UnmarshalFromString(jsoniter.Get(event.JSON, "p_unknown_fields").ToString(), &unknownFields))
+	require.Len(t, unknownFields, 3)
+	require.JSONEq(t, `{"id":1,"token":"****"}`, unknownFields["secret"])
+	require.JSONEq(t, `[{"token":"****"}]`, unknownFields["items"])
+	require.Equal(t, "null", unknownFields["empty"])
+	// the examples of the report are redacted as well
+	for _, stats := range driftReport.Flush(now.Add(time.Hour)) {
+		require.NotContains(t, stats.Example, "abc")
+		require.NotContains(t, stats.Example, "xyz")
+		require.NotEqual(t, "numerics", stats.Path)
+	}
+}
+
+func TestProcessEnrichment(t *testing.T) {
+	result := &parsers.Result{LogType: testLogType, JSON: []byte(`{"host":"web"}`)}
+	p := NewProcessor(&common.DataStream{
+		Reader: strings.NewReader("good\nbad\n"),
+		Hints:  common.DataStreamHints{S3: s3Hint},
+	}, map[string]parsers.Interface{
+		testLogType: testutil.ParserConfig{"good": result}.Parser(),
+	})
+	table := enrichment.Table{"web": {"owner": "alice"}}
+	p.enrichment = enrichment.NewPipeline(enrichment.NewLookup("hosts", table, []string{"host"}))
+
+	outputChan := make(chan *parsers.Result, 10)
+	require.NoError(t, p.run(outputChan))
+	close(outputChan)
+
+	var events []*parsers.Result
+	for event := range outputChan {
+		if event.LogType == deadletter.LogType {
+			// dead letters are not enriched
+			require.NotContains(t, string(event.JSON), "p_enrichment")
+			continue
+		}
+		events = append(events, event)
+	}
+	require.Len(t, events, 1)
+	require.JSONEq(t, `{"host":"web","p_enrichment":{"lookups":[{"table":"hosts","key":"web","values":{"owner":"alice"}}]}}`,
+		string(events[0].JSON))
+}
+
+func TestProcessCloudWatchLogs(t *testing.T) {
+	result := &parsers.Result{LogType: testLogType, JSON: []byte(`{"host":"web"}`)}
+	p := NewProcessor(&common.DataStream{
+		Reader: strings.NewReader("good\n"),
+		Hints: common.DataStreamHints{
+			CloudWatchLogs: &common.CloudWatchLogsDataStreamHints{
+				Owner:     "123456789012",
+				LogGroup:  "/aws/lambda/api",
+				LogStream: "2020/06/01/[$LATEST]0123",
+			},
+		},
+	}, map[string]parsers.Interface{
+		testLogType: testutil.ParserConfig{"good": result}.Parser(),
+	})
+
+	outputChan := make(chan *parsers.Result, 10)
+	require.NoError(t, p.run(outputChan))
+	close(outputChan)
+
+	event := <-outputChan
+	require.JSONEq(t, `{"host":"web","p_source_log_group":"/aws/lambda/api","p_source_log_stream":"2020/06/01/[$LATEST]0123"}`,
+		string(event.JSON))
+}
+
+func TestNewClassifierLogTypeHints(t *testing.T) {
+	declaredResult := &parsers.Result{LogType: "declared", JSON: []byte(`{}`)}
+	otherResult := &parsers.Result{LogType: "other", JSON: []byte(`{}`)}
+	newParsers := func() map[string]parsers.Interface {
+		return map[string]parsers.Interface{
+			"declared": testutil.ParserConfig{"declared": declaredResult, "other": errors.New("fail")}.Parser(),
+			"other":    testutil.ParserConfig{"other": otherResult, "declared": errors.New("fail")}.Parser(),
+		}
+	}
+
+	// only the declared log types are tried
+	classifier := newClassifier(newParsers(), []string{"declared", "unknown"}, false)
+	require.Equal(t, "declared", *classifier.Classify("declared").LogType)
+	require.Nil(t, classifier.Classify("other").LogType)
+
+	// other log types are tried if fallback is enabled
+	classifier = newClassifier(newParsers(), []string{"declared"}, true)
+	require.Equal(t, "declared", *classifier.Classify("declared").LogType)
+	require.Equal(t, "other", *classifier.Classify("other").LogType)
+
+	// all log types are tried if none of the declared log types is known
+	classifier = newClassifier(newParsers(), []string{"unknown"}, false)
+	require.Equal(t, "declared", *classifier.Classify("declared").LogType)
+	require.Equal(t, "other", *classifier.Classify("other").LogType)
+}
+
 // deals with the error package inserting line numbers into errors
 func assertLogEqual(t *testing.T, expected, actual observer.LoggedEntry) {
 	for k, v := range expected.ContextMap() {
@@ -32,6 +32,7 @@ import (
 
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/common"
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/destinations"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/registry"
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/sources"
 	"github.com/panther-labs/panther/pkg/awsbatch/sqsbatch"
@@ -52,9 +53,35 @@ the lambda will continue to read events and maximally aggregate data to produce
 Fewer, bigger files makes Athena queries much faster.
 */
 func StreamEvents(sqsClient sqsiface.SQSAPI, deadlineTime time.Time, event events.SQSEvent) (sqsMessageCount int, err error) {
+	if len(event.Records) > 0 {
+		if err := refreshConfig(); err != nil {
+			return 0, err
+		}
+	}
 	return streamEvents(sqsClient, deadlineTime, event, Process, sources.ReadSnsMessages)
 }
 
+// refreshConfig loads the configuration that can change while the Lambda is running
+func refreshConfig() error {
+	// user-defined log types can change at any time, load them before any parser is created
+	sources.RefreshCustomLogTypes()
+	for _, config := range []*reloadedConfig{enrichmentConfig, framingConfig, filterConfig, flushConfig} {
+		// events are processed without the config until it is loaded
+		if err := config.refresh(); err != nil {
+			zap.L().Warn("failed to load config", zap.Error(err))
+		}
+	}
+	setupDedup()
+	// sensitive data must not be stored, events are not processed until redaction rules are loaded
+	if err := redactionConfig.refresh(); err != nil {
+		return err
+	}
+	if r := redactor(); r != nil {
+		parsers.SetRedactor(r)
+	}
+	return nil
+}
+
 // entry point for unit testing, pass in read/process functions
 func streamEvents(sqsClient sqsiface.SQSAPI, deadlineTime time.Time, event events.SQSEvent,
 	processFunc func(chan *common.DataStream, destinations.Destination) error,
@@ -145,7 +172,7 @@ func streamEvents(sqsClient sqsiface.SQSAPI, deadlineTime time.Time, event event
 	}()
 
 	// process streamChan until closed (blocks)
-	err = processFunc(streamChan, destinations.CreateS3Destination(registry.Default()))
+	err = processFunc(streamChan, destinations.CreateS3Destination(registry.Default(), flushPolicies()))
 	if err != nil { // prefer Process() error to readEventError
 		return 0, err
 	}
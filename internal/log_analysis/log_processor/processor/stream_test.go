@@ -235,7 +235,11 @@ func failProcessorFunc(streamChan chan *common.DataStream, dest destinations.Des
 }
 
 func noopReadSnsMessagesFunc(messages []string) ([]*common.DataStream, error) {
-	return make([]*common.DataStream, len(messages)), nil
+	dataStreams := make([]*common.DataStream, len(messages))
+	for i := range dataStreams {
+		dataStreams[i] = &common.DataStream{}
+	}
+	return dataStreams, nil
 }
 
 // simulated error parsing sqs message or reading s3 object
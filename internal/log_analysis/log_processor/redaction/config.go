@@ -0,0 +1,177 @@
+package redaction
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/reload"
+)
+
+// Redaction actions
+const (
+	// ActionDrop removes the field
+	ActionDrop = "drop"
+	// ActionHash replaces string values with their hex encoded HMAC-SHA256
+	ActionHash = "hash"
+	// ActionMask replaces the matches of a regular expression in string values
+	ActionMask = "mask"
+)
+
+const (
+	// DefaultMask replaces the whole value if a mask rule has no pattern
+	DefaultMask = "****"
+)
+
+var (
+	// the core Panther fields are needed to store and query rows
+	protectedFields = map[string]bool{
+		"p_log_type":   true,
+		"p_row_id":     true,
+		"p_event_time": true,
+		"p_parse_time": true,
+	}
+)
+
+// Config declares the redaction rules of each log type
+type Config struct {
+	// RefreshInterval is a Go duration (e.g. 30m), defaults to reload.DefaultInterval
+	RefreshInterval string             `yaml:"refreshInterval,omitempty" json:"refreshInterval,omitempty"`
+	LogTypes        map[string][]*Rule `yaml:"logTypes" json:"logTypes"`
+
+	// hmacKey is the secret key of hash rules, it is not part of the config file
+	hmacKey []byte
+}
+
+// KeyFunc returns the secret key of hash rules
+type KeyFunc func() ([]byte, error)
+
+// Rule redacts the values of a field
+type Rule struct {
+	// Field is the JSON name of the field as stored, nested fields are separated by dots (e.g. userIdentity.userName).
+	// Arrays are traversed, the rule applies to all their elements.
+	Field  string `yaml:"field" json:"field"`
+	Action string `yaml:"action" json:"action"`
+	// Pattern is the regular expression of mask rules, the whole value is masked if it is empty
+	Pattern string `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+	// Replacement of the matches of mask rules, defaults to DefaultMask. It can refer to submatches (e.g. ${1}).
+	Replacement string `yaml:"replacement,omitempty" json:"replacement,omitempty"`
+
+	path    []string
+	pattern *regexp.Regexp
+	key     []byte
+}
+
+// ParseConfig reads a config in YAML or JSON format and validates it.
+// The HMAC key is only loaded if the config has hash rules, key can be nil if it has none.
+func ParseConfig(data []byte, key KeyFunc) (*Config, error) {
+	config := Config{}
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, errors.Wrap(err, "invalid redaction config")
+	}
+	if config.hasHashRules() && key != nil {
+		hmacKey, err := key()
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to load the HMAC key of hash rules")
+		}
+		config.hmacKey = hmacKey
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Validate checks the config and compiles its rules
+func (c *Config) Validate() error {
+	if _, err := c.Interval(); err != nil {
+		return err
+	}
+	for logType, rules := range c.LogTypes {
+		for _, rule := range rules {
+			if rule == nil {
+				return errors.Errorf("empty rule for log type %q", logType)
+			}
+			if err := rule.compile(c.hmacKey); err != nil {
+				return errors.WithMessagef(err, "invalid rule for field %q of log type %q", rule.Field, logType)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Config) hasHashRules() bool {
+	for _, rules := range c.LogTypes {
+		for _, rule := range rules {
+			if rule != nil && rule.Action == ActionHash {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Interval returns how often the config is reloaded
+func (c *Config) Interval() (time.Duration, error) {
+	return reload.ParseInterval(c.RefreshInterval)
+}
+
+func (r *Rule) compile(key []byte) error {
+	r.path = strings.Split(r.Field, ".")
+	for _, name := range r.path {
+		if name == "" {
+			return errors.New("invalid field name")
+		}
+	}
+	if protectedFields[r.Field] {
+		return errors.New("core Panther fields cannot be redacted")
+	}
+	if r.Action != ActionMask && (r.Pattern != "" || r.Replacement != "") {
+		return errors.New("pattern and replacement are only valid for mask rules")
+	}
+	switch r.Action {
+	case ActionDrop:
+	case ActionHash:
+		if len(key) == 0 {
+			return errors.New("hash rules need an HMAC key")
+		}
+		r.key = key
+	case ActionMask:
+		pattern := r.Pattern
+		if pattern == "" {
+			pattern = `(?s)^.*$`
+		}
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return errors.Wrap(err, "invalid pattern")
+		}
+		r.pattern = compiled
+		if r.Replacement == "" {
+			r.Replacement = DefaultMask
+		}
+	default:
+		return errors.Errorf("unknown action %q", r.Action)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package redaction
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey() ([]byte, error) {
+	return []byte("key"), nil
+}
+
+func TestParseConfig(t *testing.T) {
+	for _, invalid := range []string{
+		`refreshInterval: 1 hour`,
+		`logTypes: {A.B: [{field: a, action: hash}]}`,
+		`logTypes: {A.B: [{field: a, action: erase}]}`,
+		`logTypes: {A.B: [{field: a, action: drop, pattern: x}]}`,
+		`logTypes: {A.B: [{field: a, action: mask, pattern: "("}]}`,
+		`logTypes: {A.B: [{field: a..b, action: drop}]}`,
+		`logTypes: {A.B: [{field: p_event_time, action: drop}]}`,
+		`logTypes: {A.B: [null]}`,
+		`unknown: true`,
+	} {
+		_, err := ParseConfig([]byte(invalid), nil)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestParseConfigKey(t *testing.T) {
+	rules := []byte(`logTypes: {A.B: [{field: a, action: hash}]}`)
+	config, err := ParseConfig(rules, testKey)
+	require.NoError(t, err)
+	require.Equal(t, []byte("key"), config.LogTypes["A.B"][0].key)
+
+	_, err = ParseConfig(rules, func() ([]byte, error) {
+		return nil, errors.New("access denied")
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "access denied")
+
+	// the key is only loaded for hash rules
+	_, err = ParseConfig([]byte(`logTypes: {A.B: [{field: a, action: drop}]}`), func() ([]byte, error) {
+		panic("unexpected key load")
+	})
+	require.NoError(t, err)
+
+	// the key is not part of the config file
+	_, err = ParseConfig([]byte(`{hmacKey: key, logTypes: {A.B: [{field: a, action: hash}]}}`), testKey)
+	require.Error(t, err)
+}
+
+func TestParse(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/redaction.yml")
+	require.NoError(t, err)
+	value, interval, err := Parser(testKey)(data)
+	require.NoError(t, err)
+	require.Equal(t, 10*time.Minute, interval)
+	redactor := value.(*Redactor)
+	require.Len(t, redactor.rules["GitLab.API"], 2)
+	require.Len(t, redactor.rules["AWS.CloudTrail"], 2)
+}
@@ -0,0 +1,39 @@
+package redaction
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"time"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/reload"
+)
+
+// Parser returns the reload.Parse of redaction configs, the value is a *Redactor.
+// The HMAC key is loaded with every reload of a config with hash rules, so a rotated key is used from the next reload.
+func Parser(key KeyFunc) reload.Parse {
+	return func(data []byte) (interface{}, time.Duration, error) {
+		config, err := ParseConfig(data, key)
+		if err != nil {
+			return nil, 0, err
+		}
+		// Validate already checked the interval
+		interval, _ := config.Interval()
+		return New(config), interval, nil
+	}
+}
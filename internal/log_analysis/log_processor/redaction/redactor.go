@@ -0,0 +1,73 @@
+package redaction
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
+)
+
+// Redactor applies the rules of each log type to parsed events, it implements parsers.Redactor
+type Redactor struct {
+	rules map[string][]*Rule
+}
+
+// New returns a redactor for the rules of a validated config
+func New(config *Config) *Redactor {
+	return &Redactor{
+		rules: config.LogTypes,
+	}
+}
+
+// Redact applies the rules of the log type to the event in order.
+// Indicators of the p_any_* fields found in the redacted values are removed from the event as well, unless they are
+// still found in the values left in the event (e.g. because they were also extracted from a field that is kept).
+func (r *Redactor) Redact(logType string, event interface{}) {
+	var redacted []string
+	for _, rule := range r.rules[logType] {
+		redacted = append(redacted, rule.Apply(event)...)
+	}
+	log, ok := event.(interface{ Log() *parsers.PantherLog })
+	if !ok || len(redacted) == 0 {
+		return
+	}
+	var remaining []string
+	collectStrings(reflect.ValueOf(event), &remaining)
+	log.Log().RemoveAnyStrings(func(value string) bool {
+		return containsPart(redacted, value) && !containsPart(remaining, value)
+	})
+}
+
+// containsPart checks if one of the values contains s, indicators can be extracted from a part of a value
+// (e.g. an IP address in a message)
+func containsPart(values []string, s string) bool {
+	for _, value := range values {
+		if strings.Contains(value, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redacts checks if the log type has rules
+func (r *Redactor) Redacts(logType string) bool {
+	return len(r.rules[logType]) > 0
+}
@@ -0,0 +1,291 @@
+package redaction
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
+)
+
+var (
+	// raw JSON values are decoded to apply rules to their fields
+	rawJSON = jsoniter.Config{UseNumber: true}.Froze()
+
+	rawMessageTypes = map[reflect.Type]bool{
+		reflect.TypeOf(jsoniter.RawMessage{}): true,
+		reflect.TypeOf(json.RawMessage{}):     true,
+	}
+	numberTypes = map[reflect.Type]bool{
+		reflect.TypeOf(jsoniter.Number("")): true,
+		reflect.TypeOf(json.Number("")):     true,
+	}
+)
+
+// Apply redacts the field of a parsed event, the event must be a pointer.
+// Values that cannot be hashed or masked (e.g. numbers of typed fields or invalid raw JSON) are dropped.
+// It returns the original string values of the redacted field.
+func (r *Rule) Apply(event interface{}) (redacted []string) {
+	v := reflect.ValueOf(event)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return nil
+	}
+	r.apply(v, r.path, &redacted)
+	return redacted
+}
+
+// apply applies the rule to the field at path inside v and returns the updated value.
+// If ok is false the value must be removed. The original strings of redacted values are appended to redacted.
+func (r *Rule) apply(v reflect.Value, path []string, redacted *[]string) (_ reflect.Value, ok bool) {
+	if len(path) == 0 {
+		collectStrings(v, redacted)
+		return r.redact(v)
+	}
+	if rawMessageTypes[v.Type()] {
+		return r.applyRaw(v, func(value reflect.Value) (reflect.Value, bool) {
+			return r.apply(value, path, redacted)
+		})
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v, true
+		}
+		// the pointer is removed with its value
+		elem, ok := r.apply(v.Elem(), path, redacted)
+		return v, set(v.Elem(), elem, ok)
+	case reflect.Interface:
+		if v.IsNil() {
+			return v, true
+		}
+		return r.apply(v.Elem(), path, redacted)
+	case reflect.Struct:
+		if !v.CanAddr() {
+			addressable := reflect.New(v.Type()).Elem()
+			addressable.Set(v)
+			v = addressable
+		}
+		r.applyStruct(v, path, redacted)
+		return v, true
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String {
+			return v, true
+		}
+		key := reflect.ValueOf(path[0]).Convert(v.Type().Key())
+		value := v.MapIndex(key)
+		if !value.IsValid() {
+			return v, true
+		}
+		value, ok := r.apply(value, path[1:], redacted)
+		setMapIndex(v, key, value, ok)
+		return v, true
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			elem, ok := r.apply(v.Index(i), path, redacted)
+			set(v.Index(i), elem, ok)
+		}
+		return v, true
+	default:
+		// the field does not exist
+		return v, true
+	}
+}
+
+func (r *Rule) applyStruct(v reflect.Value, path []string, redacted *[]string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+		name, tagged := jsonName(field)
+		if field.Anonymous && !tagged {
+			// fields of embedded structs (e.g. parsers.PantherLog) are marshaled as fields of the event
+			if embedded := v.Field(i); embedded.Kind() == reflect.Struct {
+				r.applyStruct(embedded, path, redacted)
+			} else if embedded.Kind() == reflect.Ptr && !embedded.IsNil() && embedded.Elem().Kind() == reflect.Struct {
+				r.applyStruct(embedded.Elem(), path, redacted)
+			}
+			continue
+		}
+		if name != path[0] && parsers.RewriteFieldName(name) != path[0] {
+			continue
+		}
+		value, ok := r.apply(v.Field(i), path[1:], redacted)
+		set(v.Field(i), value, ok)
+	}
+}
+
+// redact applies the action of the rule to a value
+func (r *Rule) redact(v reflect.Value) (_ reflect.Value, ok bool) {
+	if r.Action == ActionDrop {
+		return v, false
+	}
+	if rawMessageTypes[v.Type()] {
+		return r.applyRaw(v, r.redact)
+	}
+	if numberTypes[v.Type()] {
+		// numbers of raw JSON values are replaced by strings
+		return reflect.ValueOf(r.transform(v.String())), true
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v, true
+		}
+		// values of parsed events can be shared, they are not modified in place
+		elem, ok := r.redact(v.Elem())
+		ptr := reflect.New(v.Type().Elem())
+		return ptr, set(ptr.Elem(), elem, ok)
+	case reflect.Interface:
+		if v.IsNil() {
+			return v, true
+		}
+		return r.redact(v.Elem())
+	case reflect.String:
+		return reflect.ValueOf(r.transform(v.String())).Convert(v.Type()), true
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			elem, ok := r.redact(v.Index(i))
+			set(v.Index(i), elem, ok)
+		}
+		return v, true
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			value, ok := r.redact(v.MapIndex(key))
+			setMapIndex(v, key, value, ok)
+		}
+		return v, true
+	default:
+		return v, false
+	}
+}
+
+// applyRaw applies a function to the decoded value of raw JSON and encodes the result
+func (r *Rule) applyRaw(v reflect.Value, apply func(reflect.Value) (reflect.Value, bool)) (reflect.Value, bool) {
+	if len(v.Bytes()) == 0 {
+		return v, true
+	}
+	var value interface{}
+	if err := rawJSON.Unmarshal(v.Bytes(), &value); err != nil {
+		return v, false
+	}
+	if value == nil {
+		return v, true
+	}
+	redacted, ok := apply(reflect.ValueOf(&value).Elem())
+	if !ok {
+		return v, false
+	}
+	data, err := rawJSON.Marshal(redacted.Interface())
+	if err != nil {
+		return v, false
+	}
+	return reflect.ValueOf(data).Convert(v.Type()), true
+}
+
+// collectStrings appends the strings of a value before it is redacted, including the numbers and strings of raw JSON
+func collectStrings(v reflect.Value, values *[]string) {
+	if !v.IsValid() {
+		return
+	}
+	if rawMessageTypes[v.Type()] {
+		var value interface{}
+		if err := rawJSON.Unmarshal(v.Bytes(), &value); err == nil && value != nil {
+			collectStrings(reflect.ValueOf(value), values)
+		}
+		return
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			collectStrings(v.Elem(), values)
+		}
+	case reflect.String:
+		if s := v.String(); s != "" {
+			*values = append(*values, s)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			collectStrings(v.Index(i), values)
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			collectStrings(v.MapIndex(key), values)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				collectStrings(v.Field(i), values)
+			}
+		}
+	}
+}
+
+func (r *Rule) transform(s string) string {
+	if r.Action == ActionHash {
+		mac := hmac.New(sha256.New, r.key)
+		mac.Write([]byte(s))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	return r.pattern.ReplaceAllString(s, r.Replacement)
+}
+
+// set stores a redacted value, dst is set to its zero value if the value must be removed or has another type.
+// It returns false if dst was set to its zero value.
+func set(dst, value reflect.Value, ok bool) bool {
+	if ok && value.Type().AssignableTo(dst.Type()) {
+		dst.Set(value)
+		return true
+	}
+	if rawMessageTypes[dst.Type()] {
+		// empty raw messages are not valid JSON
+		dst.Set(reflect.ValueOf([]byte("null")).Convert(dst.Type()))
+		return false
+	}
+	dst.Set(reflect.Zero(dst.Type()))
+	return false
+}
+
+func setMapIndex(m, key, value reflect.Value, ok bool) {
+	if ok && value.Type().AssignableTo(m.Type().Elem()) {
+		m.SetMapIndex(key, value)
+		return
+	}
+	m.SetMapIndex(key, reflect.Value{})
+}
+
+// jsonName returns the JSON name of a struct field and whether it is set by a tag
+func jsonName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+	if name := strings.Split(tag, ",")[0]; name != "" {
+		return name, true
+	}
+	return field.Name, false
+}
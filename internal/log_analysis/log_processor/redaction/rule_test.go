@@ -0,0 +1,180 @@
+package redaction
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
+)
+
+type testIdentity struct {
+	UserName *string `json:"userName,omitempty"`
+	Type     string  `json:"type"`
+}
+
+type testEvent struct {
+	Name       *string              `json:"name,omitempty"`
+	Count      *int                 `json:"count,omitempty"`
+	Identity   *testIdentity        `json:"userIdentity,omitempty"`
+	Identities []testIdentity       `json:"identities,omitempty"`
+	Tags       map[string]string    `json:"tags,omitempty"`
+	Params     *jsoniter.RawMessage `json:"requestParameters,omitempty"`
+	Dashed     *string              `json:"@forwarded,omitempty"`
+	Any        interface{}          `json:"any,omitempty"`
+
+	parsers.PantherLog
+}
+
+func newTestEvent() *testEvent {
+	name, alice, bob, ip := "name", "alice@example.com", "bob@example.com", "1.2.3.4"
+	count := 3
+	params := jsoniter.RawMessage(`{"password":"secret","user":{"emails":["alice@example.com",1]},"count":1}`)
+	event := &testEvent{
+		Name:       &name,
+		Count:      &count,
+		Identity:   &testIdentity{UserName: &alice, Type: "IAMUser"},
+		Identities: []testIdentity{{UserName: &alice}, {UserName: &bob}},
+		Tags:       map[string]string{"owner": "alice", "team": "security"},
+		Params:     &params,
+		Dashed:     &ip,
+		Any:        map[string]interface{}{"token": "abc", "n": 1},
+	}
+	event.AppendAnyIPAddress(ip)
+	return event
+}
+
+func compileRule(t *testing.T, rule *Rule) *Rule {
+	require.NoError(t, rule.compile([]byte("key")))
+	return rule
+}
+
+func hash(s string) string {
+	mac := hmac.New(sha256.New, []byte("key"))
+	mac.Write([]byte(s))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestRuleDrop(t *testing.T) {
+	event := newTestEvent()
+	for _, field := range []string{"name", "count", "userIdentity.userName", "identities.userName", "tags.owner",
+		"requestParameters.password", "at_sign_forwarded", "any.token", "p_any_ip_addresses", "missing.field"} {
+
+		compileRule(t, &Rule{Field: field, Action: ActionDrop}).Apply(event)
+	}
+	require.Nil(t, event.Name)
+	require.Nil(t, event.Count)
+	require.Nil(t, event.Identity.UserName)
+	require.Equal(t, "IAMUser", event.Identity.Type)
+	require.Equal(t, []testIdentity{{}, {}}, event.Identities)
+	require.Equal(t, map[string]string{"team": "security"}, event.Tags)
+	require.JSONEq(t, `{"user":{"emails":["alice@example.com",1]},"count":1}`, string(*event.Params))
+	require.Nil(t, event.Dashed)
+	require.Equal(t, map[string]interface{}{"n": 1}, event.Any)
+	require.Nil(t, event.PantherAnyIPAddresses)
+}
+
+func TestRuleHash(t *testing.T) {
+	event := newTestEvent()
+	for _, field := range []string{"name", "count", "identities.userName", "tags", "requestParameters.user", "any"} {
+		compileRule(t, &Rule{Field: field, Action: ActionHash}).Apply(event)
+	}
+	require.Equal(t, hash("name"), *event.Name)
+	// typed numbers cannot be hashed and are dropped
+	require.Nil(t, event.Count)
+	require.Equal(t, hash("alice@example.com"), *event.Identities[0].UserName)
+	require.Equal(t, hash("bob@example.com"), *event.Identities[1].UserName)
+	require.Equal(t, map[string]string{"owner": hash("alice"), "team": hash("security")}, event.Tags)
+	// numbers of raw JSON are hashed as strings
+	require.JSONEq(t, `{"password":"secret","user":{"emails":["`+hash("alice@example.com")+`","`+hash("1")+`"]},"count":1}`,
+		string(*event.Params))
+	require.Equal(t, map[string]interface{}{"token": hash("abc")}, event.Any)
+}
+
+func TestRuleMask(t *testing.T) {
+	event := newTestEvent()
+	compileRule(t, &Rule{Field: "userIdentity.userName", Action: ActionMask, Pattern: `^[^@]+`, Replacement: "***"}).Apply(event)
+	compileRule(t, &Rule{Field: "requestParameters.password", Action: ActionMask}).Apply(event)
+	compileRule(t, &Rule{Field: "@forwarded", Action: ActionMask, Pattern: `(\d+)\.\d+$`, Replacement: "${1}.0"}).Apply(event)
+	require.Equal(t, "***@example.com", *event.Identity.UserName)
+	require.JSONEq(t, `{"password":"****","user":{"emails":["alice@example.com",1]},"count":1}`, string(*event.Params))
+	require.Equal(t, "1.2.3.0", *event.Dashed)
+}
+
+func TestRuleInvalidRawJSON(t *testing.T) {
+	params := jsoniter.RawMessage(`{"password":`)
+	event := &testEvent{Params: &params}
+	compileRule(t, &Rule{Field: "requestParameters.password", Action: ActionDrop}).Apply(event)
+	require.Nil(t, event.Params)
+}
+
+func TestRedactorResult(t *testing.T) {
+	config, err := ParseConfig([]byte(`{"logTypes":{"Test.Event":[{"field":"userIdentity","action":"drop"}]}}`), nil)
+	require.NoError(t, err)
+	parsers.SetRedactor(New(config))
+	defer parsers.SetRedactor(nil)
+
+	event := newTestEvent()
+	event.SetCoreFields("Test.Event", nil, event)
+	result, err := event.Result()
+	require.NoError(t, err)
+	require.NotContains(t, string(result.JSON), "userIdentity")
+	require.Contains(t, string(result.JSON), "identities")
+
+	event = newTestEvent()
+	event.SetCoreFields("Test.Other", nil, event)
+	result, err = event.Result()
+	require.NoError(t, err)
+	require.Contains(t, string(result.JSON), "userIdentity")
+}
+
+func TestRedactorRemovesIndicators(t *testing.T) {
+	config, err := ParseConfig([]byte(`{"logTypes":{"Test.Event":[
+		{"field":"userIdentity.userName","action":"hash"},
+		{"field":"requestParameters","action":"drop"},
+		{"field":"identities","action":"drop"},
+		{"field":"at_sign_forwarded","action":"mask","pattern":"\\d+$","replacement":"0"}
+	]}}`), testKey)
+	require.NoError(t, err)
+	event := newTestEvent()
+	event.AppendAnyEmails("alice@example.com", "bob@example.com")
+	event.AppendAnyUsernames("alice", "bob")
+	event.AppendAnyIPAddress("5.6.7.8")
+
+	New(config).Redact("Test.Event", event)
+	// the emails are only in redacted fields, the IP address is masked
+	require.Nil(t, event.PantherAnyEmails)
+	require.Equal(t, []string{"5.6.7.8"}, anyValues(t, event.PantherAnyIPAddresses))
+	// indicators extracted from a part of a redacted value are removed as well,
+	// alice is kept since it is also the owner tag
+	require.Equal(t, []string{"alice"}, anyValues(t, event.PantherAnyUsernames))
+}
+
+func anyValues(t *testing.T, any *parsers.PantherAnyString) (values []string) {
+	data, err := any.MarshalJSON()
+	require.NoError(t, err)
+	require.NoError(t, jsoniter.Unmarshal(data, &values))
+	return values
+}
@@ -28,10 +28,12 @@ import (
 	// Register log types in init() blocks
 	_ "github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/apachelogs"
 	_ "github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/awslogs"
+	_ "github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/ceflogs"
 	_ "github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/fluentdsyslogs"
 	_ "github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/gitlablogs"
 	_ "github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/juniperlogs"
 	_ "github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/laceworklogs"
+	_ "github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/leeflogs"
 	_ "github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/nginxlogs"
 	_ "github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/osquerylogs"
 	_ "github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers/osseclogs"
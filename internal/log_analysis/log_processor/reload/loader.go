@@ -0,0 +1,105 @@
+package reload
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultInterval is how often a config is reloaded if it does not set its refresh interval
+const DefaultInterval = time.Hour
+
+// Parse builds the value of a config file (e.g. compiled rules) and returns it with the refresh interval of the config
+type Parse func(data []byte) (value interface{}, interval time.Duration, err error)
+
+// Loader loads a config from a file and reloads it periodically.
+// It is safe for concurrent use, concurrent refreshes wait for the same load.
+type Loader struct {
+	configPath string
+	open       Opener
+	parse      Parse
+	// mu guards the fields below
+	mu       sync.Mutex
+	value    interface{}
+	interval time.Duration
+	loadTime time.Time
+}
+
+// NewLoader returns a loader for the config at configPath, an empty path disables the config
+func NewLoader(configPath string, open Opener, parse Parse) *Loader {
+	return &Loader{
+		configPath: configPath,
+		open:       open,
+		parse:      parse,
+		interval:   DefaultInterval,
+	}
+}
+
+// Value returns the last loaded value, it is nil until a config is loaded or if the loader is nil
+func (l *Loader) Value() interface{} {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.value
+}
+
+// Refresh reloads the config if the refresh interval has passed since the last attempt.
+// On failure the previous value is kept until the next attempt.
+// Until a config is loaded, every call is an attempt.
+func (l *Loader) Refresh(now time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.configPath == "" || (l.value != nil && now.Before(l.loadTime.Add(l.interval))) {
+		return nil
+	}
+	l.loadTime = now
+	r, err := l.open(l.configPath)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", l.configPath)
+	}
+	value, interval, err := l.parse(data)
+	if err != nil {
+		return errors.WithMessagef(err, "failed to load %s", l.configPath)
+	}
+	l.value, l.interval = value, interval
+	return nil
+}
+
+// ParseInterval parses the refresh interval of a config, a Go duration (e.g. 30m), it defaults to DefaultInterval
+func ParseInterval(interval string) (time.Duration, error) {
+	if interval == "" {
+		return DefaultInterval, nil
+	}
+	d, err := time.ParseDuration(interval)
+	if err != nil || d <= 0 {
+		return 0, errors.Errorf("invalid refresh interval %q", interval)
+	}
+	return d, nil
+}
@@ -0,0 +1,91 @@
+package reload
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func parseLines(data []byte) (interface{}, time.Duration, error) {
+	lines := strings.Fields(string(data))
+	if len(lines) == 0 {
+		return nil, 0, errors.New("empty config")
+	}
+	return lines, 10 * time.Minute, nil
+}
+
+func TestLoader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reload")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.txt")
+	open := func(path string) (io.ReadCloser, error) {
+		return os.Open(path)
+	}
+
+	// without a loaded config every refresh is an attempt
+	loader := NewLoader(path, open, parseLines)
+	require.Nil(t, loader.Value())
+	now := time.Now()
+	require.Error(t, loader.Refresh(now))
+	require.NoError(t, ioutil.WriteFile(path, nil, 0600))
+	require.Error(t, loader.Refresh(now.Add(time.Second)))
+	require.Nil(t, loader.Value())
+	require.NoError(t, ioutil.WriteFile(path, []byte("a b"), 0600))
+	require.NoError(t, loader.Refresh(now.Add(2*time.Second)))
+	require.Equal(t, []string{"a", "b"}, loader.Value())
+
+	// not reloaded before the refresh interval of the config
+	require.NoError(t, ioutil.WriteFile(path, []byte("c"), 0600))
+	require.NoError(t, loader.Refresh(now.Add(9*time.Minute)))
+	require.Equal(t, []string{"a", "b"}, loader.Value())
+	require.NoError(t, loader.Refresh(now.Add(11*time.Minute)))
+	require.Equal(t, []string{"c"}, loader.Value())
+
+	// failures keep the previous value
+	require.NoError(t, os.Remove(path))
+	require.Error(t, loader.Refresh(now.Add(22*time.Minute)))
+	require.Equal(t, []string{"c"}, loader.Value())
+
+	require.NoError(t, NewLoader("", open, parseLines).Refresh(now))
+	var none *Loader
+	require.Nil(t, none.Value())
+}
+
+func TestParseInterval(t *testing.T) {
+	interval, err := ParseInterval("")
+	require.NoError(t, err)
+	require.Equal(t, DefaultInterval, interval)
+	interval, err = ParseInterval("30m")
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Minute, interval)
+	for _, invalid := range []string{"1 hour", "-1m", "0s"} {
+		_, err := ParseInterval(invalid)
+		require.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,89 @@
+package reload
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"compress/gzip"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/pkg/errors"
+)
+
+// Opener opens the file at path, e.g. a config or the data files it refers to
+type Opener func(path string) (io.ReadCloser, error)
+
+// NewOpener opens s3://bucket/key URLs with the client and any other path as a local file.
+// Files ending in .gz are decompressed.
+func NewOpener(client s3iface.S3API) Opener {
+	return func(path string) (io.ReadCloser, error) {
+		var r io.ReadCloser
+		var err error
+		if strings.HasPrefix(path, "s3://") {
+			r, err = openS3(client, path)
+		} else {
+			r, err = os.Open(path)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to open %s", path)
+		}
+		if !strings.HasSuffix(path, ".gz") {
+			return r, nil
+		}
+		gzipReader, err := gzip.NewReader(r)
+		if err != nil {
+			r.Close()
+			return nil, errors.Wrapf(err, "failed to decompress %s", path)
+		}
+		return &gzipReadCloser{Reader: gzipReader, closer: r}, nil
+	}
+}
+
+func openS3(client s3iface.S3API, path string) (io.ReadCloser, error) {
+	if client == nil {
+		return nil, errors.New("no S3 client")
+	}
+	u, err := url.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	output, err := client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(u.Host),
+		Key:    aws.String(strings.TrimPrefix(u.Path, "/")),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output.Body, nil
+}
+
+type gzipReadCloser struct {
+	*gzip.Reader
+	closer io.Closer
+}
+
+func (r *gzipReadCloser) Close() error {
+	r.Reader.Close()
+	return r.closer.Close()
+}
@@ -0,0 +1,401 @@
+package sources
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/common"
+	"github.com/panther-labs/panther/pkg/metrics"
+)
+
+var (
+	// MaxArchiveSize is the max uncompressed size of an archive.
+	// Archives are copied to a temporary file because formats like zip need random access to list their members.
+	MaxArchiveSize int64 = 256 * 1024 * 1024
+
+	// MaxSpilledSize is the space the temporary files of archives can use at once, for all processing runs.
+	// An archive is copied when its members are about to be processed, so a run has at most one file at a time.
+	// Runs wait for space, which is freed when the members of an archive are processed.
+	// The limit keeps the files within the 512MB of /tmp of a Lambda function.
+	MaxSpilledSize int64 = 512 * 1024 * 1024
+
+	spillSpace = newSpaceBudget()
+)
+
+// objectStream is the uncompressed data of an S3 object or of one of its archive members
+type objectStream struct {
+	// Member is the name of the archive member, empty if the object is not an archive
+	Member string
+	Reader io.Reader
+	// Archive is the format of the object if it is an archive, its members are listed by unpackArchive
+	Archive *archiveFormat
+}
+
+// archiveFormat describes a multi-file archive format
+type archiveFormat struct {
+	Name string
+	// Detect checks the signature of the archive
+	Detect func(header []byte) bool
+	// Suffixes are used when the signature is missing (e.g. pre-POSIX tar files)
+	Suffixes []string
+	// Members lists the regular files of an archive.
+	// If the archive is truncated, it returns the members found before the error.
+	Members func(r io.ReaderAt, size int64) ([]*archiveMember, error)
+}
+
+type archiveMember struct {
+	Name string
+	Open func() (io.Reader, error)
+}
+
+var archiveFormats = []*archiveFormat{
+	{
+		Name: "zip",
+		Detect: func(header []byte) bool {
+			return bytes.HasPrefix(header, []byte("PK\x03\x04")) || bytes.HasPrefix(header, []byte("PK\x05\x06"))
+		},
+		Suffixes: []string{".zip"},
+		Members:  zipMembers,
+	},
+	{
+		Name: "tar",
+		Detect: func(header []byte) bool {
+			// POSIX and GNU tar files have the 'ustar' magic at offset 257
+			const magicOffset = 257
+			return len(header) > magicOffset && bytes.HasPrefix(header[magicOffset:], []byte("ustar"))
+		},
+		Suffixes: []string{".tar", ".tgz", ".tar.gz", ".tbz2", ".tar.bz2", ".tar.zst"},
+		Members:  tarMembers,
+	},
+}
+
+func detectArchive(header []byte, key string) *archiveFormat {
+	for _, archive := range archiveFormats {
+		if archive.Detect(header) {
+			return archive
+		}
+	}
+	if isPlainText(header) {
+		return nil
+	}
+	key = strings.ToLower(key)
+	for _, archive := range archiveFormats {
+		for _, suffix := range archive.Suffixes {
+			if strings.HasSuffix(key, suffix) {
+				return archive
+			}
+		}
+	}
+	return nil
+}
+
+func zipMembers(r io.ReaderAt, size int64) ([]*archiveMember, error) {
+	zipReader, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	var members []*archiveMember
+	for _, file := range zipReader.File {
+		if !file.Mode().IsRegular() {
+			continue
+		}
+		file := file
+		members = append(members, &archiveMember{
+			Name: file.Name,
+			Open: func() (io.Reader, error) {
+				return file.Open()
+			},
+		})
+	}
+	return members, nil
+}
+
+func tarMembers(r io.ReaderAt, size int64) ([]*archiveMember, error) {
+	tarReader := tar.NewReader(io.NewSectionReader(r, 0, size))
+	var members []*archiveMember
+	for index := 0; ; index++ {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return members, nil
+		}
+		if err != nil {
+			return members, err
+		}
+		if !header.FileInfo().Mode().IsRegular() {
+			continue
+		}
+		index := index
+		members = append(members, &archiveMember{
+			Name: header.Name,
+			Open: func() (io.Reader, error) {
+				return openTarMember(io.NewSectionReader(r, 0, size), index)
+			},
+		})
+	}
+}
+
+// openTarMember scans the archive up to the member at index.
+// The underlying reader is seekable so file contents are skipped without reading them.
+func openTarMember(r io.ReadSeeker, index int) (io.Reader, error) {
+	tarReader := tar.NewReader(r)
+	for i := 0; i <= index; i++ {
+		if _, err := tarReader.Next(); err != nil {
+			return nil, err
+		}
+	}
+	return tarReader, nil
+}
+
+// newDataStream returns the data stream of an object.
+// The stream of an archive is unpacked to a stream per member when it is processed, wrap applies to the member readers.
+func newDataStream(object *objectStream, s3Object *S3ObjectInfo, hints common.DataStreamHints,
+	wrap func(io.Reader) io.Reader) *common.DataStream {
+
+	dataStream := &common.DataStream{Hints: hints}
+	if object.Archive == nil {
+		dataStream.Reader = wrap(object.Reader)
+		return dataStream
+	}
+	dataStream.Reader = object.Reader
+	dataStream.Unpack = func() ([]*common.DataStream, func(), error) {
+		members, release, err := unpackArchive(object, s3Object)
+		if err != nil {
+			return nil, nil, err
+		}
+		streams := make([]*common.DataStream, len(members))
+		for i, member := range members {
+			// the hints can change after the stream is created, e.g. the output prefix of a notification
+			memberHints := dataStream.Hints
+			if memberHints.S3 != nil {
+				s3Hints := *memberHints.S3
+				s3Hints.ArchiveMember = member.Member
+				memberHints.S3 = &s3Hints
+			}
+			streams[i] = &common.DataStream{
+				Reader:  wrap(member.Reader),
+				Hints:   memberHints,
+				LogType: dataStream.LogType,
+			}
+		}
+		return streams, release, nil
+	}
+	return dataStream
+}
+
+// unpackArchive copies an archive to a temporary file and returns its members.
+// The returned function closes the file, it must be called once the members are processed.
+// Archives that are too large or corrupted are reported and have no members, retrying them would fail again.
+func unpackArchive(archive *objectStream, s3Object *S3ObjectInfo) ([]*objectStream, func(), error) {
+	noMembers := func() {}
+	// the size of the archive is unknown until it is copied
+	reserved := MaxArchiveSize + 1
+	spillSpace.reserve(reserved)
+	file, size, err := spillArchive(archive.Reader, reserved)
+	if err != nil {
+		spillSpace.release(reserved)
+		if errors.Is(err, syscall.ENOSPC) {
+			skipArchive(s3Object, archive.Archive, errors.Wrapf(err, "no space left to copy %s archive", archive.Archive.Name))
+			return nil, noMembers, nil
+		}
+		return nil, nil, errors.Wrapf(err, "failed to read %s archive", archive.Archive.Name)
+	}
+	spillSpace.release(reserved - size)
+	release := func() {
+		_ = file.Close()
+		spillSpace.release(size)
+	}
+	if size > MaxArchiveSize {
+		release()
+		skipArchive(s3Object, archive.Archive, &ErrArchiveTooLarge{Format: archive.Archive.Name, MaxSize: MaxArchiveSize})
+		return nil, noMembers, nil
+	}
+	members, err := archive.Archive.Members(file, size)
+	if err != nil {
+		// Process the members we found, the rest of the archive is unreadable
+		logArchiveFailure(s3Object, "", errors.Wrapf(err, "failed to list %s archive members", archive.Archive.Name))
+	}
+	streams := make([]*objectStream, len(members))
+	for i, member := range members {
+		streams[i] = &objectStream{
+			Member: member.Name,
+			Reader: &archiveMemberReader{
+				s3Object: s3Object,
+				member:   member,
+			},
+		}
+	}
+	return streams, release, nil
+}
+
+// spillArchive copies up to limit bytes of an archive to a temporary file.
+// The file is removed right away, its space is freed once it is closed.
+func spillArchive(r io.Reader, limit int64) (file *os.File, size int64, err error) {
+	file, err = ioutil.TempFile("", "archive-")
+	if err != nil {
+		return nil, 0, err
+	}
+	if err = os.Remove(file.Name()); err == nil {
+		size, err = io.Copy(file, io.LimitReader(r, limit))
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, 0, err
+	}
+	return file, size, nil
+}
+
+// skipArchive reports an archive that cannot be processed, as an error since none of its data is stored
+func skipArchive(s3Object *S3ObjectInfo, archive *archiveFormat, err error) {
+	operation := common.OpLogManager.Start("readArchive", common.OpLogS3ServiceDim).Stop()
+	operation.LogError(err,
+		// s3 dim info
+		zap.String("bucket", s3Object.S3Bucket),
+		zap.String("key", s3Object.S3ObjectKey))
+	common.ArchivesTooLargeLogger.LogSingle(1, metrics.Dimension{Name: "ArchiveFormat", Value: archive.Name})
+}
+
+// spaceBudget bounds the bytes used by concurrent processing runs
+type spaceBudget struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	used int64
+}
+
+func newSpaceBudget() *spaceBudget {
+	b := &spaceBudget{}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// reserve waits until size bytes fit in MaxSpilledSize.
+// A reservation always succeeds if nothing else is reserved, so that a run never waits forever.
+func (b *spaceBudget) reserve(size int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.used > 0 && b.used+size > MaxSpilledSize {
+		b.cond.Wait()
+	}
+	b.used += size
+}
+
+func (b *spaceBudget) release(size int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.used -= size
+	b.cond.Broadcast()
+}
+
+// archiveMemberReader lazily opens and decompresses an archive member.
+// Errors are logged and end the member stream so they don't prevent processing the other members.
+type archiveMemberReader struct {
+	s3Object *S3ObjectInfo
+	member   *archiveMember
+	reader   *decompressedReader
+	done     bool
+}
+
+func (r *archiveMemberReader) Read(p []byte) (n int, err error) {
+	if r.done {
+		return 0, io.EOF
+	}
+	if r.reader == nil {
+		if r.reader, err = r.open(); err != nil {
+			r.fail(err)
+			return 0, io.EOF
+		}
+	}
+	n, err = r.reader.Read(p)
+	if err == io.EOF {
+		_ = r.Close()
+	}
+	if err != nil && err != io.EOF {
+		r.fail(err)
+		return n, io.EOF
+	}
+	return n, err
+}
+
+// Close releases the decoders of the member, it can be called before the member is read to the end
+func (r *archiveMemberReader) Close() error {
+	r.done = true
+	if r.reader != nil {
+		return r.reader.Close()
+	}
+	return nil
+}
+
+func (r *archiveMemberReader) open() (*decompressedReader, error) {
+	member, err := r.member.Open()
+	if err != nil {
+		return nil, err
+	}
+	reader, applied, err := decompress(bufio.NewReader(member), "", r.member.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPlainText(reader.Reader, applied); err != nil {
+		_ = reader.Close()
+		return nil, err
+	}
+	return reader, nil
+}
+
+func (r *archiveMemberReader) fail(err error) {
+	_ = r.Close()
+	logArchiveFailure(r.s3Object, r.member.Name, err)
+}
+
+func logArchiveFailure(s3Object *S3ObjectInfo, member string, err error) {
+	operation := common.OpLogManager.Start("readArchiveMember", common.OpLogS3ServiceDim).Stop()
+	operation.LogWarn(err,
+		// s3 dim info
+		zap.String("bucket", s3Object.S3Bucket),
+		zap.String("key", s3Object.S3ObjectKey),
+		zap.String("member", member))
+}
+
+// checkPlainText makes sure that uncompressed data is text.
+// Compressed data is trusted to be text, as it was before archives were supported.
+func checkPlainText(r *bufio.Reader, applied []string) error {
+	if len(applied) > 0 {
+		return nil
+	}
+	header, err := peekHeader(r)
+	if err != nil {
+		return err
+	}
+	if !isPlainText(header) {
+		return &ErrUnsupportedFileType{Type: http.DetectContentType(header)}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package sources
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"github.com/panther-labs/panther/api/lambda/source/models"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/common"
+	"github.com/panther-labs/panther/pkg/genericapi"
+)
+
+// ListAWSAccountNames returns the labels of the AWS accounts onboarded as sources by account id
+func ListAWSAccountNames() (map[string]string, error) {
+	input := &models.LambdaInput{
+		ListIntegrations: &models.ListIntegrationsInput{},
+	}
+	var integrations []*models.SourceIntegration
+	if err := genericapi.Invoke(common.LambdaClient, sourceAPIFunctionName, input, &integrations); err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(integrations))
+	for _, integration := range integrations {
+		if integration.AWSAccountID == "" {
+			continue
+		}
+		// cloud security sources are named after their account, prefer their label over log sources
+		if _, ok := names[integration.AWSAccountID]; !ok || integration.IntegrationType == models.IntegrationTypeAWSScan {
+			names[integration.AWSAccountID] = integration.IntegrationLabel
+		}
+	}
+	return names, nil
+}
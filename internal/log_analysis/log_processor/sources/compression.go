@@ -0,0 +1,215 @@
+package sources
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+const (
+	// http.DetectContentType only uses up to the first 512 bytes
+	sniffLen = 512
+	// maxCompressionLayers bounds how many nested compression layers we unwrap (e.g. a gzipped gzip file)
+	maxCompressionLayers = 2
+)
+
+// Compression describes a compression format that the S3 source transparently decompresses
+type Compression struct {
+	// Name identifies the format in logs
+	Name string
+	// Magic is the signature at the start of the compressed data
+	Magic []byte
+	// ContentEncodings are the S3 Content-Encoding values that identify the format
+	ContentEncodings []string
+	// Suffixes are the object key suffixes that identify the format
+	Suffixes []string
+	// NewReader returns a reader that decompresses r
+	NewReader func(r io.Reader) (io.Reader, error)
+}
+
+// compressions are tried in order, the first match wins
+var compressions = []*Compression{
+	{
+		Name:             "gzip",
+		Magic:            []byte{0x1f, 0x8b},
+		ContentEncodings: []string{"gzip", "x-gzip"},
+		Suffixes:         []string{".gz", ".gzip", ".tgz"},
+		NewReader: func(r io.Reader) (io.Reader, error) {
+			return gzip.NewReader(r)
+		},
+	},
+	{
+		Name:             "zstd",
+		Magic:            []byte{0x28, 0xb5, 0x2f, 0xfd},
+		ContentEncodings: []string{"zstd"},
+		Suffixes:         []string{".zst", ".zstd"},
+		NewReader: func(r io.Reader) (io.Reader, error) {
+			// We don't need the concurrent decoder, the processor reads streams serially
+			dec, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+			if err != nil {
+				return nil, err
+			}
+			return &zstdReader{Decoder: dec}, nil
+		},
+	},
+	{
+		Name:             "bzip2",
+		Magic:            []byte("BZh"),
+		ContentEncodings: []string{"bzip2", "x-bzip2"},
+		Suffixes:         []string{".bz2", ".bzip2", ".tbz2"},
+		NewReader: func(r io.Reader) (io.Reader, error) {
+			return bzip2.NewReader(r), nil
+		},
+	},
+}
+
+// zstdReader stops the goroutines of the decoder once the stream ends or the reader is closed
+type zstdReader struct {
+	*zstd.Decoder
+	// err ends the stream, the decoder cannot be read once closed
+	err error
+}
+
+func (r *zstdReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	n, err := r.Decoder.Read(p)
+	if err != nil {
+		r.err = err
+		r.Decoder.Close()
+	}
+	return n, err
+}
+
+func (r *zstdReader) Close() error {
+	r.Decoder.Close()
+	return nil
+}
+
+// RegisterCompression adds a compression format to the ones detected by the S3 source.
+// It is not safe for concurrent use and should be called during initialization.
+func RegisterCompression(c *Compression) {
+	compressions = append(compressions, c)
+}
+
+// detectCompression identifies the compression of a stream by its magic bytes.
+// If the header is not recognized and the data is not plain text, the Content-Encoding and key suffix are used.
+func detectCompression(header []byte, contentEncoding, key string) *Compression {
+	for _, c := range compressions {
+		if bytes.HasPrefix(header, c.Magic) {
+			return c
+		}
+	}
+	if isPlainText(header) {
+		return nil
+	}
+	for _, c := range compressions {
+		for _, encoding := range c.ContentEncodings {
+			if strings.EqualFold(contentEncoding, encoding) {
+				return c
+			}
+		}
+	}
+	key = strings.ToLower(key)
+	for _, c := range compressions {
+		for _, suffix := range c.Suffixes {
+			if strings.HasSuffix(key, suffix) {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// decompressedReader is the uncompressed data of a stream, closing it releases the decoders of the compression layers
+type decompressedReader struct {
+	*bufio.Reader
+	closers []io.Closer
+}
+
+func (r *decompressedReader) Close() error {
+	for i := len(r.closers) - 1; i >= 0; i-- {
+		_ = r.closers[i].Close()
+	}
+	return nil
+}
+
+// decompress unwraps all compression layers of a stream.
+// It returns a buffered reader over the uncompressed data and the names of the compressions found, outermost first.
+func decompress(reader *bufio.Reader, contentEncoding, key string) (*decompressedReader, []string, error) {
+	var applied []string
+	// the decoders of the layers unwrapped so far, closed on failure
+	decoders := &decompressedReader{}
+	for {
+		header, err := peekHeader(reader)
+		if err != nil {
+			_ = decoders.Close()
+			return nil, nil, err
+		}
+		// Content-Encoding and key suffix only describe the outermost layer
+		if len(applied) > 0 {
+			contentEncoding, key = "", ""
+		}
+		c := detectCompression(header, contentEncoding, key)
+		if c == nil {
+			decoders.Reader = reader
+			return decoders, applied, nil
+		}
+		if len(applied) == maxCompressionLayers {
+			_ = decoders.Close()
+			return nil, nil, errors.Errorf("too many compression layers %v", append(applied, c.Name))
+		}
+		uncompressed, err := c.NewReader(reader)
+		if err != nil {
+			_ = decoders.Close()
+			return nil, nil, errors.Wrapf(err, "failed to create %s reader", c.Name)
+		}
+		if closer, ok := uncompressed.(io.Closer); ok {
+			decoders.closers = append(decoders.closers, closer)
+		}
+		applied = append(applied, c.Name)
+		reader = bufio.NewReader(uncompressed)
+	}
+}
+
+// peekHeader returns the first bytes of a stream without consuming them
+func peekHeader(r *bufio.Reader) ([]byte, error) {
+	header, err := r.Peek(sniffLen)
+	if err != nil {
+		if err != bufio.ErrBufferFull && err != io.EOF { // EOF or ErrBufferFull means stream is shorter than n
+			return nil, errors.Wrap(err, "failed to Peek()")
+		}
+	}
+	return header, nil
+}
+
+func isPlainText(header []byte) bool {
+	// Checking for prefix because the returned type can have also charset used
+	return strings.HasPrefix(http.DetectContentType(header), "text/plain")
+}
@@ -0,0 +1,256 @@
+package sources
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+const testLogData = "line1\nline2\n"
+
+// bzip2 of testLogData, the standard library has no bzip2 writer
+const testLogDataBzip2 = "425a68393141592653591605154b00000449000010300002252000310c0094687a926089c278bb9229c28480b028aa58"
+
+func TestOpenObjectStreamsCompressed(t *testing.T) {
+	bzipped, err := hex.DecodeString(testLogDataBzip2)
+	require.NoError(t, err)
+
+	for name, data := range map[string][]byte{
+		"plain":       []byte(testLogData),
+		"gzip":        gzipData(t, []byte(testLogData)),
+		"gzip twice":  gzipData(t, gzipData(t, []byte(testLogData))),
+		"zstd":        zstdData(t, []byte(testLogData)),
+		"bzip2":       bzipped,
+		"empty gzip":  gzipData(t, nil),
+		"empty plain": nil,
+	} {
+		data := data
+		t.Run(name, func(t *testing.T) {
+			streams := openTestObject(t, data, "key", "")
+			require.Len(t, streams, 1)
+			require.Empty(t, streams[0].Member)
+			content, err := ioutil.ReadAll(streams[0].Reader)
+			require.NoError(t, err)
+			if len(data) > 0 && name != "empty gzip" {
+				require.Equal(t, testLogData, string(content))
+			} else {
+				require.Empty(t, content)
+			}
+		})
+	}
+}
+
+func TestOpenObjectStreamsUnsupported(t *testing.T) {
+	s3Object := &S3ObjectInfo{S3Bucket: "bucket", S3ObjectKey: "key"}
+	data := []byte(`<?xml version="1.0" encoding="UTF-8" standalone="no" ?>`)
+	_, err := openObject(bufio.NewReader(bytes.NewReader(data)), s3Object, "")
+	require.IsType(t, &ErrUnsupportedFileType{}, err)
+}
+
+func TestOpenObjectStreamsContentEncoding(t *testing.T) {
+	// The Content-Encoding and key suffix are only used if the data is not recognized
+	require.Equal(t, "gzip", detectCompression([]byte{0x00, 0x01}, "GZIP", "key").Name)
+	require.Equal(t, "zstd", detectCompression([]byte{0x00, 0x01}, "", "key.ZST").Name)
+	require.Nil(t, detectCompression([]byte(testLogData), "gzip", "key.gz"))
+	require.Nil(t, detectCompression([]byte{0x00, 0x01}, "identity", "key.json"))
+}
+
+func TestOpenObjectStreamsZip(t *testing.T) {
+	var buffer bytes.Buffer
+	zipWriter := zip.NewWriter(&buffer)
+	addZipFile(t, zipWriter, "plain.log", []byte(testLogData))
+	addZipFile(t, zipWriter, "compressed.log.gz", gzipData(t, []byte(testLogData)))
+	addZipFile(t, zipWriter, "image.png", []byte("\x89PNG\x0D\x0A\x1A\x0A"))
+	_, err := zipWriter.Create("dir/")
+	require.NoError(t, err)
+	require.NoError(t, zipWriter.Close())
+
+	streams := openTestObject(t, buffer.Bytes(), "logs.zip", "")
+	require.Len(t, streams, 3)
+	require.Equal(t, "plain.log", streams[0].Member)
+	require.Equal(t, testLogData, readAll(t, streams[0]))
+	require.Equal(t, "compressed.log.gz", streams[1].Member)
+	require.Equal(t, testLogData, readAll(t, streams[1]))
+	// unsupported members are skipped
+	require.Equal(t, "image.png", streams[2].Member)
+	require.Empty(t, readAll(t, streams[2]))
+}
+
+func TestOpenObjectStreamsTarGzip(t *testing.T) {
+	var buffer bytes.Buffer
+	tarWriter := tar.NewWriter(&buffer)
+	addTarFile(t, tarWriter, "first.log", []byte(testLogData))
+	// a corrupted member doesn't prevent reading the rest of the archive
+	addTarFile(t, tarWriter, "corrupted.log.gz", gzipData(t, []byte(testLogData))[:20])
+	addTarFile(t, tarWriter, "last.log", []byte(testLogData))
+	require.NoError(t, tarWriter.Close())
+
+	s3Object := &S3ObjectInfo{S3Bucket: "bucket", S3ObjectKey: "logs.tar.gz"}
+	object, err := openObject(bufio.NewReader(bytes.NewReader(gzipData(t, buffer.Bytes()))), s3Object, "")
+	require.NoError(t, err)
+	require.Equal(t, "tar", object.Archive.Name)
+	streams, release, err := unpackArchive(object, s3Object)
+	require.NoError(t, err)
+	require.Len(t, streams, 3)
+	// read out of order to verify that members are independent
+	require.Equal(t, "last.log", streams[2].Member)
+	require.Equal(t, testLogData, readAll(t, streams[2]))
+	require.Equal(t, "corrupted.log.gz", streams[1].Member)
+	require.Empty(t, readAll(t, streams[1]))
+	require.Equal(t, "first.log", streams[0].Member)
+	require.Equal(t, testLogData, readAll(t, streams[0]))
+	// the space of the archive file is freed once its members are released
+	require.Equal(t, buffer.Len(), int(spillSpace.used))
+	release()
+	require.Zero(t, spillSpace.used)
+}
+
+func TestUnpackArchiveTooLarge(t *testing.T) {
+	defer func(size int64) { MaxArchiveSize = size }(MaxArchiveSize)
+	MaxArchiveSize = 10
+
+	var buffer bytes.Buffer
+	tarWriter := tar.NewWriter(&buffer)
+	addTarFile(t, tarWriter, "first.log", []byte(testLogData))
+	require.NoError(t, tarWriter.Close())
+
+	// the archive is skipped, retrying it would fail again
+	streams := openTestObject(t, buffer.Bytes(), "logs.tar", "")
+	require.Empty(t, streams)
+	require.Zero(t, spillSpace.used)
+}
+
+func TestUnpackArchiveWaitsForSpace(t *testing.T) {
+	defer func(size int64) { MaxSpilledSize = size }(MaxSpilledSize)
+	MaxSpilledSize = MaxArchiveSize + 1
+
+	var buffer bytes.Buffer
+	zipWriter := zip.NewWriter(&buffer)
+	addZipFile(t, zipWriter, "plain.log", []byte(testLogData))
+	require.NoError(t, zipWriter.Close())
+	unpack := func() ([]*objectStream, func()) {
+		s3Object := &S3ObjectInfo{S3Bucket: "bucket", S3ObjectKey: "logs.zip"}
+		object, err := openObject(bufio.NewReader(bytes.NewReader(buffer.Bytes())), s3Object, "")
+		require.NoError(t, err)
+		streams, release, err := unpackArchive(object, s3Object)
+		require.NoError(t, err)
+		return streams, release
+	}
+
+	_, release := unpack()
+	unpacked := make(chan struct{})
+	go func() {
+		defer close(unpacked)
+		_, release := unpack()
+		release()
+	}()
+	// the second archive is copied once the first one is released
+	select {
+	case <-unpacked:
+		t.Fatal("archive unpacked while the space is used")
+	case <-time.After(100 * time.Millisecond):
+	}
+	release()
+	<-unpacked
+	require.Zero(t, spillSpace.used)
+}
+
+func TestZstdDecoderClose(t *testing.T) {
+	// the data is larger than the buffer of the header, so that the decoder is still running after it is peeked
+	data := zstdData(t, bytes.Repeat([]byte(testLogData), 100000))
+	s3Object := &S3ObjectInfo{S3Bucket: "bucket", S3ObjectKey: "key"}
+	object, err := openObject(bufio.NewReader(bytes.NewReader(data)), s3Object, "")
+	require.NoError(t, err)
+	decoder := object.Reader.(*decompressedReader).closers[0].(*zstdReader).Decoder
+	_, err = decoder.Read(make([]byte, 1))
+	require.NoError(t, err)
+	// a stream that is not read to the end stops the decoder when it is closed
+	require.NoError(t, object.Reader.(io.Closer).Close())
+	_, err = decoder.Read(make([]byte, 1))
+	require.Error(t, err)
+}
+
+// openTestObject opens an object and unpacks its members if it is an archive
+func openTestObject(t *testing.T, data []byte, key, contentEncoding string) []*objectStream {
+	s3Object := &S3ObjectInfo{S3Bucket: "bucket", S3ObjectKey: key}
+	object, err := openObject(bufio.NewReader(bytes.NewReader(data)), s3Object, contentEncoding)
+	require.NoError(t, err)
+	if object.Archive == nil {
+		return []*objectStream{object}
+	}
+	streams, release, err := unpackArchive(object, s3Object)
+	require.NoError(t, err)
+	t.Cleanup(release)
+	return streams
+}
+
+func readAll(t *testing.T, stream *objectStream) string {
+	content, err := ioutil.ReadAll(stream.Reader)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func gzipData(t *testing.T, data []byte) []byte {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	_, err := writer.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return buffer.Bytes()
+}
+
+func zstdData(t *testing.T, data []byte) []byte {
+	var buffer bytes.Buffer
+	writer, err := zstd.NewWriter(&buffer)
+	require.NoError(t, err)
+	_, err = writer.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return buffer.Bytes()
+}
+
+func addZipFile(t *testing.T, w *zip.Writer, name string, data []byte) {
+	fileWriter, err := w.Create(name)
+	require.NoError(t, err)
+	_, err = fileWriter.Write(data)
+	require.NoError(t, err)
+}
+
+func addTarFile(t *testing.T, w *tar.Writer, name string, data []byte) {
+	require.NoError(t, w.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0600,
+		Size:     int64(len(data)),
+		Typeflag: tar.TypeReg,
+	}))
+	_, err := w.Write(data)
+	require.NoError(t, err)
+}
@@ -0,0 +1,75 @@
+package sources
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/panther-labs/panther/api/lambda/source/models"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/common"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/customlogs"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/registry"
+	"github.com/panther-labs/panther/pkg/genericapi"
+)
+
+var (
+	customLogsCacheUpdateTime = time.Unix(0, 0)
+	// customLogsMu serializes the refreshes of concurrent consumers
+	customLogsMu sync.Mutex
+
+	// used to simplify mocking during testing
+	listCustomLogsFunc = listCustomLogs
+)
+
+// RefreshCustomLogTypes loads the user-defined log types from the source api in the default registry.
+// Like sources, custom log types are cached for sourceCacheDuration.
+// Failures are logged and the previously loaded log types are kept.
+func RefreshCustomLogTypes() {
+	customLogsMu.Lock()
+	defer customLogsMu.Unlock()
+	now := time.Now() // No need to be UTC. We care about relative time
+	if customLogsCacheUpdateTime.Add(sourceCacheDuration).After(now) {
+		return
+	}
+	customLogs, err := listCustomLogsFunc()
+	if err != nil {
+		zap.L().Warn("failed to list custom log types", zap.Error(err))
+		return
+	}
+	customLogsCacheUpdateTime = now
+
+	specs := make([]string, len(customLogs))
+	for i, customLog := range customLogs {
+		specs[i] = customLog.Spec
+	}
+	if err := customlogs.Update(registry.Default(), specs); err != nil {
+		zap.L().Warn("failed to load custom log types", zap.Error(err))
+	}
+}
+
+func listCustomLogs() (customLogs []*models.CustomLog, err error) {
+	input := &models.LambdaInput{
+		ListCustomLogs: &models.ListCustomLogsInput{},
+	}
+	err = genericapi.Invoke(common.LambdaClient, sourceAPIFunctionName, input, &customLogs)
+	return customLogs, err
+}
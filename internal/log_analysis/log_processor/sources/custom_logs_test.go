@@ -0,0 +1,67 @@
+package sources
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/api/lambda/source/models"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/registry"
+)
+
+func TestRefreshCustomLogTypes(t *testing.T) {
+	defer func() {
+		listCustomLogsFunc = listCustomLogs
+		customLogsCacheUpdateTime = time.Unix(0, 0)
+	}()
+
+	calls := 0
+	spec := "name: Custom.Test\ndescription: d\nfields: [{name: a, type: string, required: true}]"
+	listCustomLogsFunc = func() ([]*models.CustomLog, error) {
+		calls++
+		return []*models.CustomLog{{LogType: "Custom.Test", Spec: spec}}, nil
+	}
+	RefreshCustomLogTypes()
+	require.NotNil(t, registry.Default().Get("Custom.Test"))
+	require.NotNil(t, registry.Default().Get("AWS.CloudTrail"))
+
+	// cached
+	RefreshCustomLogTypes()
+	require.Equal(t, 1, calls)
+
+	// failures keep the current log types
+	customLogsCacheUpdateTime = time.Unix(0, 0)
+	listCustomLogsFunc = func() ([]*models.CustomLog, error) {
+		return nil, errors.New("failed")
+	}
+	RefreshCustomLogTypes()
+	require.NotNil(t, registry.Default().Get("Custom.Test"))
+
+	// removed log types are unregistered
+	listCustomLogsFunc = func() ([]*models.CustomLog, error) {
+		return nil, nil
+	}
+	RefreshCustomLogTypes()
+	require.Nil(t, registry.Default().Get("Custom.Test"))
+	require.NotNil(t, registry.Default().Get("AWS.CloudTrail"))
+}
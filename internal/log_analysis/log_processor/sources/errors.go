@@ -31,3 +31,13 @@ type ErrUnsupportedFileType struct {
 func (e *ErrUnsupportedFileType) Error() string {
 	return fmt.Sprintf("unsupported file type %s", e.Type)
 }
+
+// ErrArchiveTooLarge reports an archive that exceeds the size the log processor can copy to disk
+type ErrArchiveTooLarge struct {
+	Format  string
+	MaxSize int64
+}
+
+func (e *ErrArchiveTooLarge) Error() string {
+	return fmt.Sprintf("%s archive is larger than %d bytes", e.Format, e.MaxSize)
+}
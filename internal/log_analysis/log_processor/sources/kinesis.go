@@ -0,0 +1,184 @@
+package sources
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/panther-labs/panther/api/lambda/source/models"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/common"
+)
+
+const (
+	cloudWatchLogsDataMessage    = "DATA_MESSAGE"
+	cloudWatchLogsControlMessage = "CONTROL_MESSAGE"
+)
+
+// ReadKinesisRecords returns the data streams for the records of a Kinesis stream.
+// The log events of CloudWatch Logs subscription records are grouped by log stream, with one line per log event.
+// All other records are returned as a single data stream, with at least one line per record.
+// Records that can't be decompressed, and the records of streams without a source, are dropped.
+func ReadKinesisRecords(records []*events.KinesisEventRecord) (result []*common.DataStream, err error) {
+	if len(records) == 0 {
+		return nil, nil
+	}
+	// All records of an event come from the same stream
+	streamARN := records[0].EventSourceArn
+	source, err := getKinesisSource(streamARN)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to fetch the source of stream %s", streamARN)
+	}
+	if source == nil {
+		// Retrying can't help: the stream was subscribed by a source that has since been deleted
+		zap.L().Error("dropping the records of a stream without a source",
+			zap.String("streamArn", streamARN), zap.Int("numRecords", len(records)))
+		return nil, nil
+	}
+	logTypes := source.KinesisConfig.LogTypes
+
+	var lines bytes.Buffer
+	logStreams := make(map[common.CloudWatchLogsDataStreamHints]*bytes.Buffer)
+	var logStreamOrder []common.CloudWatchLogsDataStreamHints
+	for _, record := range records {
+		data, err := decompressRecord(record.Kinesis.Data)
+		if err != nil {
+			// A corrupt record would fail every retry of the batch, so it is skipped instead
+			zap.L().Warn("skipping unreadable record", zap.String("streamArn", streamARN),
+				zap.String("sequenceNumber", record.Kinesis.SequenceNumber), zap.Error(err))
+			continue
+		}
+		logsData, ok := readCloudWatchLogsData(data)
+		if !ok {
+			lines.Write(data)
+			if len(data) > 0 && data[len(data)-1] != common.EventDelimiter {
+				lines.WriteByte(common.EventDelimiter)
+			}
+			continue
+		}
+		if logsData.MessageType != cloudWatchLogsDataMessage {
+			// CONTROL_MESSAGE records only check that the destination is reachable
+			continue
+		}
+		key := common.CloudWatchLogsDataStreamHints{
+			Owner:     logsData.Owner,
+			LogGroup:  logsData.LogGroup,
+			LogStream: logsData.LogStream,
+		}
+		buffer, ok := logStreams[key]
+		if !ok {
+			buffer = &bytes.Buffer{}
+			logStreams[key] = buffer
+			logStreamOrder = append(logStreamOrder, key)
+		}
+		for _, logEvent := range logsData.LogEvents {
+			buffer.WriteString(strings.TrimRight(logEvent.Message, "\r\n"))
+			buffer.WriteByte(common.EventDelimiter)
+		}
+	}
+
+	if lines.Len() > 0 {
+		result = append(result, &common.DataStream{
+			Reader: &lines,
+			Hints: common.DataStreamHints{
+				LogTypes: logTypes,
+				SourceID: source.IntegrationID,
+			},
+		})
+	}
+	for _, key := range logStreamOrder {
+		hints := key
+		result = append(result, &common.DataStream{
+			Reader: logStreams[key],
+			Hints: common.DataStreamHints{
+				CloudWatchLogs: &hints,
+				LogTypes:       logTypes,
+				SourceID:       source.IntegrationID,
+			},
+		})
+	}
+	markEventReceived(source, time.Now())
+	return result, nil
+}
+
+// getKinesisSource returns the source of a Kinesis stream or nil if there is none
+func getKinesisSource(streamARN string) (*models.SourceIntegration, error) {
+	sources, err := refreshSourceCache(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if source := findKinesisSource(sources, streamARN); source != nil {
+		return source, nil
+	}
+	// The records of a new source can arrive before the cache expires
+	expireSourceCache()
+	if sources, err = refreshSourceCache(time.Now()); err != nil {
+		return nil, err
+	}
+	return findKinesisSource(sources, streamARN), nil
+}
+
+func findKinesisSource(sources []*models.SourceIntegration, streamARN string) *models.SourceIntegration {
+	for _, source := range sources {
+		if source.IntegrationType == models.IntegrationTypeKinesis && source.KinesisConfig.StreamARN == streamARN {
+			return source
+		}
+	}
+	return nil
+}
+
+// decompressRecord returns the data of a record, decompressing data such as the gzipped CloudWatch Logs subscription data
+func decompressRecord(data []byte) ([]byte, error) {
+	reader, compressions, err := decompress(bufio.NewReader(bytes.NewReader(data)), "", "")
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	if len(compressions) == 0 {
+		return data, nil
+	}
+	return ioutil.ReadAll(reader)
+}
+
+// readCloudWatchLogsData reads the payload of a CloudWatch Logs subscription, ok is false for any other data
+func readCloudWatchLogsData(data []byte) (logsData events.CloudwatchLogsData, ok bool) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return logsData, false
+	}
+	if err := jsoniter.Unmarshal(trimmed, &logsData); err != nil {
+		return logsData, false
+	}
+	switch logsData.MessageType {
+	case cloudWatchLogsDataMessage:
+		return logsData, logsData.LogGroup != ""
+	case cloudWatchLogsControlMessage:
+		return logsData, true
+	default:
+		return logsData, false
+	}
+}
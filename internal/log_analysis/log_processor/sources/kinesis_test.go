@@ -0,0 +1,178 @@
+package sources
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/api/lambda/source/models"
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/common"
+	"github.com/panther-labs/panther/pkg/testutils"
+)
+
+const testStreamARN = "arn:aws:kinesis:us-west-2:123456789012:stream/cloudwatch-logs"
+
+var kinesisIntegration = &models.SourceIntegration{
+	SourceIntegrationMetadata: models.SourceIntegrationMetadata{
+		IntegrationType: models.IntegrationTypeKinesis,
+		IntegrationID:   "b2a5bb0c-5b8e-4e2c-9d5a-0d8b5a0e5c1f",
+		KinesisConfig: &models.KinesisConfig{
+			StreamARN: testStreamARN,
+			LogTypes:  []string{"AWS.VPCFlow"},
+		},
+	},
+}
+
+func mockKinesisSources(t *testing.T, sources ...*models.SourceIntegration) *testutils.LambdaMock {
+	return mockKinesisSourceLists(t, sources)
+}
+
+// mockKinesisSourceLists returns each list of sources in turn, for every refresh of the cache
+func mockKinesisSourceLists(t *testing.T, lists ...[]*models.SourceIntegration) *testutils.LambdaMock {
+	resetCaches()
+	lambdaMock := &testutils.LambdaMock{}
+	common.LambdaClient = lambdaMock
+	for _, sources := range lists {
+		payload, err := jsoniter.Marshal(sources)
+		require.NoError(t, err)
+		// List the sources
+		lambdaMock.On("Invoke", mock.Anything).Return(&lambda.InvokeOutput{Payload: payload}, nil).Once()
+	}
+	// Update the status of the source
+	lambdaMock.On("Invoke", mock.Anything).Return(&lambda.InvokeOutput{}, nil).Maybe()
+	return lambdaMock
+}
+
+func kinesisRecord(data []byte) *events.KinesisEventRecord {
+	return &events.KinesisEventRecord{
+		EventSource:    "aws:kinesis",
+		EventSourceArn: testStreamARN,
+		Kinesis: events.KinesisRecord{
+			Data:           data,
+			SequenceNumber: "1",
+		},
+	}
+}
+
+func readStream(t *testing.T, stream *common.DataStream) string {
+	data, err := ioutil.ReadAll(stream.Reader)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestReadKinesisRecords(t *testing.T) {
+	lambdaMock := mockKinesisSources(t, integration, kinesisIntegration)
+
+	// nolint:lll
+	logsData := `{
+"messageType": "DATA_MESSAGE",
+"owner": "123456789012",
+"logGroup": "/aws/lambda/api",
+"logStream": "2020/06/01/[$LATEST]0123",
+"subscriptionFilters": ["panther"],
+"logEvents": [
+	{"id": "1", "timestamp": 1590969600000, "message": "START RequestId: 1\n"},
+	{"id": "2", "timestamp": 1590969600001, "message": "END RequestId: 1"}
+]}`
+	otherStream := `{"messageType": "DATA_MESSAGE", "owner": "123456789012", "logGroup": "/aws/lambda/api", "logStream": "other",
+"logEvents": [{"id": "3", "timestamp": 1590969600002, "message": "other stream"}]}`
+	controlMessage := `{"messageType": "CONTROL_MESSAGE", "owner": "CloudwatchLogs", "logGroup": "", "logStream": "",
+"logEvents": [{"id": "", "timestamp": 1590969600000, "message": "CWL CONTROL MESSAGE: Checking health of destination Kinesis stream."}]}`
+
+	streams, err := ReadKinesisRecords([]*events.KinesisEventRecord{
+		kinesisRecord(gzipData(t, []byte(logsData))),
+		kinesisRecord([]byte("plain line")),
+		kinesisRecord(gzipData(t, []byte(controlMessage))),
+		kinesisRecord(gzipData(t, []byte(otherStream))),
+		kinesisRecord([]byte("two\nlines\n")),
+		kinesisRecord(gzipData(t, []byte(logsData))),
+		// JSON records that are not CloudWatch Logs data are plain lines
+		kinesisRecord([]byte(`{"messageType": "custom"}`)),
+	})
+	require.NoError(t, err)
+	require.Len(t, streams, 3)
+
+	// Plain records first
+	require.Nil(t, streams[0].Hints.CloudWatchLogs)
+	require.Equal(t, []string{"AWS.VPCFlow"}, streams[0].Hints.LogTypes)
+	require.Equal(t, "plain line\ntwo\nlines\n{\"messageType\": \"custom\"}\n", readStream(t, streams[0]))
+
+	// Log events grouped by log stream
+	require.Equal(t, &common.CloudWatchLogsDataStreamHints{
+		Owner:     "123456789012",
+		LogGroup:  "/aws/lambda/api",
+		LogStream: "2020/06/01/[$LATEST]0123",
+	}, streams[1].Hints.CloudWatchLogs)
+	require.Equal(t, []string{"AWS.VPCFlow"}, streams[1].Hints.LogTypes)
+	require.Equal(t, "START RequestId: 1\nEND RequestId: 1\nSTART RequestId: 1\nEND RequestId: 1\n", readStream(t, streams[1]))
+	require.Equal(t, "other", streams[2].Hints.CloudWatchLogs.LogStream)
+	require.Equal(t, "other stream\n", readStream(t, streams[2]))
+
+	lambdaMock.AssertExpectations(t)
+}
+
+func TestReadKinesisRecordsUnknownStream(t *testing.T) {
+	// The cache is refreshed again before dropping the records
+	lambdaMock := mockKinesisSourceLists(t,
+		[]*models.SourceIntegration{integration},
+		[]*models.SourceIntegration{integration},
+	)
+
+	streams, err := ReadKinesisRecords([]*events.KinesisEventRecord{kinesisRecord([]byte("line"))})
+	require.NoError(t, err)
+	require.Empty(t, streams)
+	lambdaMock.AssertExpectations(t)
+}
+
+func TestReadKinesisRecordsNewStream(t *testing.T) {
+	// The source was created after the cache was last refreshed
+	lambdaMock := mockKinesisSourceLists(t,
+		[]*models.SourceIntegration{integration},
+		[]*models.SourceIntegration{integration, kinesisIntegration},
+	)
+
+	streams, err := ReadKinesisRecords([]*events.KinesisEventRecord{kinesisRecord([]byte("line"))})
+	require.NoError(t, err)
+	require.Len(t, streams, 1)
+	require.Equal(t, kinesisIntegration.IntegrationID, streams[0].Hints.SourceID)
+	require.Equal(t, "line\n", readStream(t, streams[0]))
+	lambdaMock.AssertExpectations(t)
+}
+
+func TestReadKinesisRecordsSkipsUnreadableRecords(t *testing.T) {
+	mockKinesisSources(t, kinesisIntegration)
+	// A gzip header followed by garbage
+	corrupt := append([]byte{0x1f, 0x8b, 0x08, 0x00}, []byte("not gzip data")...)
+
+	streams, err := ReadKinesisRecords([]*events.KinesisEventRecord{
+		kinesisRecord([]byte("before")),
+		kinesisRecord(corrupt),
+		kinesisRecord([]byte("after")),
+	})
+	require.NoError(t, err)
+	require.Len(t, streams, 1)
+	require.Equal(t, "before\nafter\n", readStream(t, streams[0]))
+}
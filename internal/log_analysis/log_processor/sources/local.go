@@ -0,0 +1,43 @@
+package sources
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/common"
+)
+
+// ReadLocalFile returns the data stream of a local file, unpacked to a stream per member if the file is an archive.
+// Files are decompressed and unpacked the same way as S3 objects, this is used to run the log processor offline.
+func ReadLocalFile(name string, r io.Reader) (*common.DataStream, error) {
+	// Local files have no bucket, archive member failures are logged with the file name as key
+	file := &S3ObjectInfo{S3ObjectKey: name}
+	object, err := openObject(bufio.NewReader(r), file, "")
+	if err != nil {
+		return nil, err
+	}
+	hints := common.DataStreamHints{
+		S3: &common.S3DataStreamHints{
+			Key: name,
+		},
+	}
+	return newDataStream(object, file, hints, func(r io.Reader) io.Reader { return r }), nil
+}
@@ -28,6 +28,7 @@ import (
 )
 
 type MessageForwarderReader struct {
+	input  io.Reader
 	dec    *jsoniter.Decoder
 	buffer bytes.Buffer
 }
@@ -35,10 +36,19 @@ type MessageForwarderReader struct {
 // Reader for messages sent by the Message Forwarder Lambda
 func NewMessageForwarderReader(input io.Reader) *MessageForwarderReader {
 	return &MessageForwarderReader{
-		dec: jsoniter.NewDecoder(input),
+		input: input,
+		dec:   jsoniter.NewDecoder(input),
 	}
 }
 
+// Close closes the input if it is an io.Closer
+func (r *MessageForwarderReader) Close() error {
+	if closer, ok := r.input.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
 func (r *MessageForwarderReader) Read(p []byte) (n int, err error) {
 	if len(r.buffer.Bytes()) > 0 {
 		return r.buffer.Read(p)
@@ -20,11 +20,9 @@ package sources
 
 import (
 	"bufio"
-	"compress/gzip"
 	"io"
 	"net/http"
 	"net/url"
-	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-sdk-go/aws"
@@ -42,6 +40,12 @@ import (
 const (
 	s3TestEvent                 = "s3:TestEvent"
 	cloudTrailValidationMessage = "CloudTrail validation message."
+
+	// OutputPrefixAttribute is the SNS message attribute with the S3 key prefix to store the events of replayed objects.
+	// Events stored under a prefix are kept apart from the live table partitions and are not analyzed.
+	OutputPrefixAttribute = "OutputPrefix"
+	// OutputPrefixRoot is the root of all output prefixes, the log processor is only allowed to write under it
+	OutputPrefixRoot = "backfill/"
 )
 
 // ReadSnsMessages reads incoming messages containing SNS notifications and returns a slice of DataStream items
@@ -104,8 +108,8 @@ func handleNotificationMessage(notification *SnsNotification) (result []*common.
 		return nil, err
 	}
 	for _, s3Object := range s3Objects {
-		var dataStream *common.DataStream
-		dataStream, err = readS3Object(s3Object)
+		var dataStreams []*common.DataStream
+		dataStreams, err = readS3Object(s3Object)
 		if err != nil {
 			if _, ok := err.(*ErrUnsupportedFileType); ok {
 				// If the incoming message is not of a supported type, just skip it
@@ -114,22 +118,37 @@ func handleNotificationMessage(notification *SnsNotification) (result []*common.
 			}
 			return
 		}
-		result = append(result, dataStream)
+		for _, dataStream := range dataStreams {
+			dataStream.Hints.OutputPrefix = outputPrefix(notification)
+		}
+		result = append(result, dataStreams...)
 	}
 	return result, err
 }
 
-func readS3Object(s3Object *S3ObjectInfo) (dataStream *common.DataStream, err error) {
+// outputPrefix returns the value of the OutputPrefixAttribute of a notification, if any
+func outputPrefix(notification *SnsNotification) string {
+	attribute, ok := notification.MessageAttributes[OutputPrefixAttribute].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	prefix, _ := attribute["Value"].(string)
+	return prefix
+}
+
+// readS3Object returns a data stream for the object, or one per member if the object is an archive
+func readS3Object(s3Object *S3ObjectInfo) (dataStreams []*common.DataStream, err error) {
 	operation := common.OpLogManager.Start("readS3Object", common.OpLogS3ServiceDim)
 	defer func() {
 		operation.Stop()
 		operation.Log(err,
 			// s3 dim info
 			zap.String("bucket", s3Object.S3Bucket),
-			zap.String("key", s3Object.S3ObjectKey))
+			zap.String("key", s3Object.S3ObjectKey),
+			zap.Int("numStreams", len(dataStreams)))
 	}()
 
-	s3Client, sourceType, err := getS3Client(s3Object)
+	s3Client, source, err := getS3Client(s3Object)
 	if err != nil {
 		err = errors.Wrapf(err, "failed to get S3 client for s3://%s/%s",
 			s3Object.S3Bucket, s3Object.S3ObjectKey)
@@ -150,53 +169,59 @@ func readS3Object(s3Object *S3ObjectInfo) (dataStream *common.DataStream, err er
 	bufferedReader := bufio.NewReader(output.Body)
 
 	// We peek into the file header to identify the content type
-	// http.DetectContentType only uses up to the first 512 bytes
-	headerBytes, err := bufferedReader.Peek(512)
+	headerBytes, err := peekHeader(bufferedReader)
 	if err != nil {
-		if err != bufio.ErrBufferFull && err != io.EOF { // EOF or ErrBufferFull means file is shorter than n
-			err = errors.Wrapf(err, "failed to Peek() in S3 payload for s3://%s/%s",
-				s3Object.S3Bucket, s3Object.S3ObjectKey)
-			return nil, err
-		}
-		err = nil // not really an error
+		err = errors.Wrapf(err, "failed to read S3 payload for s3://%s/%s",
+			s3Object.S3Bucket, s3Object.S3ObjectKey)
+		return nil, err
 	}
 	contentType := http.DetectContentType(headerBytes)
 
-	var streamReader io.Reader
-
-	// Checking for prefix because the returned type can have also charset used
-	if strings.HasPrefix(contentType, "text/plain") {
-		// if it's plain text, just return the buffered reader
-		streamReader = bufferedReader
-	} else if strings.HasPrefix(contentType, "application/x-gzip") {
-		var gzipReader *gzip.Reader
-		gzipReader, err = gzip.NewReader(bufferedReader)
-		if err != nil {
-			err = errors.Wrapf(err, "failed to created gzip reader for s3://%s/%s",
-				s3Object.S3Bucket, s3Object.S3ObjectKey)
-			return nil, err
+	object, err := openObject(bufferedReader, s3Object, aws.StringValue(output.ContentEncoding))
+	if err != nil {
+		if _, ok := err.(*ErrUnsupportedFileType); !ok {
+			err = errors.Wrapf(err, "failed to read s3://%s/%s", s3Object.S3Bucket, s3Object.S3ObjectKey)
 		}
-		streamReader = gzipReader
-	} else {
-		err = &ErrUnsupportedFileType{Type: contentType}
 		return nil, err
 	}
 
-	if sourceType == models.IntegrationTypeSqs {
-		streamReader = NewMessageForwarderReader(streamReader)
+	wrap := func(r io.Reader) io.Reader { return r }
+	switch source.IntegrationType {
+	case models.IntegrationTypeSqs, models.IntegrationTypeHTTP:
+		wrap = func(r io.Reader) io.Reader { return NewMessageForwarderReader(r) }
 	}
-
-	dataStream = &common.DataStream{
-		Reader: streamReader,
-		Hints: common.DataStreamHints{
-			S3: &common.S3DataStreamHints{
-				Bucket:      s3Object.S3Bucket,
-				Key:         s3Object.S3ObjectKey,
-				ContentType: contentType,
-			},
+	hints := common.DataStreamHints{
+		S3: &common.S3DataStreamHints{
+			Bucket:      s3Object.S3Bucket,
+			Key:         s3Object.S3ObjectKey,
+			ContentType: contentType,
 		},
+		LogTypes: getSourceLogTypes(source, s3Object.S3ObjectKey),
+		SourceID: source.IntegrationID,
+	}
+	return []*common.DataStream{newDataStream(object, s3Object, hints, wrap)}, nil
+}
+
+// openObject unwraps the compression layers of an S3 object and detects archives.
+// The returned reader must be closed to release the decoders.
+func openObject(r *bufio.Reader, s3Object *S3ObjectInfo, contentEncoding string) (*objectStream, error) {
+	reader, applied, err := decompress(r, contentEncoding, s3Object.S3ObjectKey)
+	if err != nil {
+		return nil, err
+	}
+	header, err := peekHeader(reader.Reader)
+	if err != nil {
+		_ = reader.Close()
+		return nil, err
+	}
+	if archive := detectArchive(header, s3Object.S3ObjectKey); archive != nil {
+		return &objectStream{Reader: reader, Archive: archive}, nil
+	}
+	if err := checkPlainText(reader.Reader, applied); err != nil {
+		_ = reader.Close()
+		return nil, err
 	}
-	return dataStream, err
+	return &objectStream{Reader: reader}, nil
 }
 
 // ParseNotification parses a message received
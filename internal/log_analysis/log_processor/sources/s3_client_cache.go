@@ -20,6 +20,7 @@ package sources
 
 import (
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -55,6 +56,8 @@ type s3ClientCacheKey struct {
 }
 
 type sourceCacheStruct struct {
+	// mu guards the fields below, the sources are read and refreshed by concurrent consumers
+	mu              sync.Mutex
 	cacheUpdateTime time.Time
 	sources         []*models.SourceIntegration
 }
@@ -75,7 +78,8 @@ var (
 	newS3ClientFunc    = getNewS3Client
 
 	// Map from integrationId -> last time an event was received
-	lastEventReceived = make(map[string]time.Time)
+	lastEventReceived   = make(map[string]time.Time)
+	lastEventReceivedMu sync.Mutex
 	// How frequently to update the status
 	statusUpdateFrequency = 1 * time.Minute
 )
@@ -95,15 +99,15 @@ func init() {
 
 // getS3Client Fetches
 // 1. S3 client with permissions to read data from the account that contains the event
-// 2. The type of the integration
-func getS3Client(s3Object *S3ObjectInfo) (s3iface.S3API, string, error) {
+// 2. The integration of the S3 object
+func getS3Client(s3Object *S3ObjectInfo) (s3iface.S3API, *models.SourceIntegration, error) {
 	sourceInfo, err := getSourceInfo(s3Object)
 	if err != nil {
-		return nil, "", errors.Wrapf(err, "failed to fetch the appropriate role arn to retrieve S3 object %#v", s3Object)
+		return nil, nil, errors.Wrapf(err, "failed to fetch the appropriate role arn to retrieve S3 object %#v", s3Object)
 	}
 
 	if sourceInfo == nil {
-		return nil, "", errors.Errorf("there is no source configured for S3 object %#v", s3Object)
+		return nil, nil, errors.Errorf("there is no source configured for S3 object %#v", s3Object)
 	}
 	var awsCreds *credentials.Credentials // lazy create below
 	roleArn := getSourceLogProcessingRole(sourceInfo)
@@ -113,12 +117,12 @@ func getS3Client(s3Object *S3ObjectInfo) (s3iface.S3API, string, error) {
 		zap.L().Debug("bucket region was not cached, fetching it", zap.String("bucket", s3Object.S3Bucket))
 		awsCreds = getAwsCredentials(roleArn)
 		if awsCreds == nil {
-			return nil, "", errors.Errorf("failed to fetch credentials for assumed role %s to read %#v",
+			return nil, nil, errors.Errorf("failed to fetch credentials for assumed role %s to read %#v",
 				roleArn, s3Object)
 		}
 		bucketRegion, err = getBucketRegion(s3Object.S3Bucket, awsCreds)
 		if err != nil {
-			return nil, "", err
+			return nil, nil, err
 		}
 		bucketCache.Add(s3Object.S3Bucket, bucketRegion)
 	}
@@ -135,14 +139,14 @@ func getS3Client(s3Object *S3ObjectInfo) (s3iface.S3API, string, error) {
 		if awsCreds == nil {
 			awsCreds = getAwsCredentials(roleArn)
 			if awsCreds == nil {
-				return nil, "", errors.Errorf("failed to fetch credentials for assumed role %s to read %#v",
+				return nil, nil, errors.Errorf("failed to fetch credentials for assumed role %s to read %#v",
 					roleArn, s3Object)
 			}
 		}
 		client = newS3ClientFunc(box.String(cacheKey.awsRegion), awsCreds)
 		s3ClientCache.Add(cacheKey, client)
 	}
-	return client.(s3iface.S3API), sourceInfo.IntegrationType, nil
+	return client.(s3iface.S3API), sourceInfo, nil
 }
 
 func getBucketRegion(s3Bucket string, awsCreds *credentials.Credentials) (string, error) {
@@ -177,21 +181,12 @@ func getAwsCredentials(roleArn string) *credentials.Credentials {
 // It will return nil result if no source exists for this object.
 func getSourceInfo(s3Object *S3ObjectInfo) (result *models.SourceIntegration, err error) {
 	now := time.Now() // No need to be UTC. We care about relative time
-	if sourceCache.cacheUpdateTime.Add(sourceCacheDuration).Before(now) {
-		// we need to update the cache
-		input := &models.LambdaInput{
-			ListIntegrations: &models.ListIntegrationsInput{},
-		}
-		var output []*models.SourceIntegration
-		err = genericapi.Invoke(common.LambdaClient, sourceAPIFunctionName, input, &output)
-		if err != nil {
-			return nil, err
-		}
-		sourceCache.cacheUpdateTime = now
-		sourceCache.sources = output
+	sources, err := refreshSourceCache(now)
+	if err != nil {
+		return nil, err
 	}
 
-	for _, source := range sourceCache.sources {
+	for _, source := range sources {
 		integrationBucket, integrationPrefix := getSourceS3Info(source)
 		if integrationBucket == s3Object.S3Bucket {
 			if strings.HasPrefix(s3Object.S3ObjectKey, integrationPrefix) {
@@ -203,17 +198,54 @@ func getSourceInfo(s3Object *S3ObjectInfo) (result *models.SourceIntegration, er
 
 	// If the incoming notification maps to a known source, update the source information
 	if result != nil {
-		deadline := lastEventReceived[result.IntegrationID].Add(statusUpdateFrequency)
-		// if more than 'statusUpdateFrequency' time has passed, update status
-		if now.After(deadline) {
-			updateIntegrationStatus(result.IntegrationID, now)
-			lastEventReceived[result.IntegrationID] = now
-		}
+		markEventReceived(result, now)
 	}
 
 	return result, nil
 }
 
+// refreshSourceCache queries the sources_api for the sources if the cache has expired and returns the cached sources.
+// Concurrent callers wait for the same refresh.
+func refreshSourceCache(now time.Time) ([]*models.SourceIntegration, error) {
+	sourceCache.mu.Lock()
+	defer sourceCache.mu.Unlock()
+	if sourceCache.cacheUpdateTime.Add(sourceCacheDuration).Before(now) {
+		// we need to update the cache
+		input := &models.LambdaInput{
+			ListIntegrations: &models.ListIntegrationsInput{},
+		}
+		var output []*models.SourceIntegration
+		if err := genericapi.Invoke(common.LambdaClient, sourceAPIFunctionName, input, &output); err != nil {
+			return nil, err
+		}
+		sourceCache.cacheUpdateTime = now
+		sourceCache.sources = output
+	}
+	return sourceCache.sources, nil
+}
+
+// expireSourceCache makes the next refreshSourceCache query the sources_api
+func expireSourceCache() {
+	sourceCache.mu.Lock()
+	defer sourceCache.mu.Unlock()
+	sourceCache.cacheUpdateTime = time.Unix(0, 0)
+}
+
+// markEventReceived updates the status of a source that received events
+func markEventReceived(source *models.SourceIntegration, now time.Time) {
+	lastEventReceivedMu.Lock()
+	deadline := lastEventReceived[source.IntegrationID].Add(statusUpdateFrequency)
+	// if more than 'statusUpdateFrequency' time has passed, update status
+	update := now.After(deadline)
+	if update {
+		lastEventReceived[source.IntegrationID] = now
+	}
+	lastEventReceivedMu.Unlock()
+	if update {
+		updateIntegrationStatus(source.IntegrationID, now)
+	}
+}
+
 func updateIntegrationStatus(integrationID string, timestamp time.Time) {
 	input := &models.LambdaInput{
 		UpdateStatus: &models.UpdateStatusInput{
@@ -244,16 +276,36 @@ func getSourceS3Info(source *models.SourceIntegration) (string, string) {
 		return source.S3Bucket, source.S3Prefix
 	case models.IntegrationTypeSqs:
 		return source.SqsConfig.S3Bucket, source.SqsConfig.S3Prefix
+	case models.IntegrationTypeHTTP:
+		return source.HTTPConfig.S3Bucket, source.HTTPConfig.S3Prefix
 	}
 	return "", ""
 }
 
+// Returns the log types declared by this source for an S3 object
+func getSourceLogTypes(source *models.SourceIntegration, key string) []string {
+	switch source.IntegrationType {
+	case models.IntegrationTypeAWS3:
+		if logTypes := source.S3PrefixLogTypes.LogTypes(key); logTypes != nil {
+			return logTypes
+		}
+		return source.LogTypes
+	case models.IntegrationTypeSqs:
+		return source.SqsConfig.LogTypes
+	case models.IntegrationTypeHTTP:
+		return source.HTTPConfig.LogTypes
+	}
+	return nil
+}
+
 func getSourceLogProcessingRole(source *models.SourceIntegration) (roleArn string) {
 	switch source.IntegrationType {
 	case models.IntegrationTypeAWS3:
 		roleArn = source.LogProcessingRole
 	case models.IntegrationTypeSqs:
 		roleArn = source.SqsConfig.LogProcessingRole
+	case models.IntegrationTypeHTTP:
+		roleArn = source.HTTPConfig.LogProcessingRole
 	}
 	return roleArn
 }
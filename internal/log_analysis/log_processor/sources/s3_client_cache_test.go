@@ -19,6 +19,7 @@ package sources
  */
 
 import (
+	"sync"
 	"testing"
 	"time"
 
@@ -31,6 +32,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	lru "github.com/hashicorp/golang-lru"
 	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
@@ -86,16 +88,16 @@ func TestGetS3Client(t *testing.T) {
 		S3Bucket:    "test-bucket",
 		S3ObjectKey: "prefix/key",
 	}
-	result, sourceType, err := getS3Client(s3Object)
+	result, source, err := getS3Client(s3Object)
 	require.NoError(t, err)
 	require.NotNil(t, result)
-	require.Equal(t, models.IntegrationTypeAWS3, sourceType)
+	require.Equal(t, models.IntegrationTypeAWS3, source.IntegrationType)
 
 	// Subsequent calls should use cache
-	result, sourceType, err = getS3Client(s3Object)
+	result, source, err = getS3Client(s3Object)
 	require.NoError(t, err)
 	require.NotNil(t, result)
-	require.Equal(t, models.IntegrationTypeAWS3, sourceType)
+	require.Equal(t, models.IntegrationTypeAWS3, source.IntegrationType)
 
 	// verify that we have updated the source with the last time scanned status
 	updateStatusInvokeInput := lambdaMock.Calls[1].Arguments.Get(0).(*lambda.InvokeInput)
@@ -137,10 +139,10 @@ func TestGetS3ClientUnknownBucket(t *testing.T) {
 		S3ObjectKey: "prefix/key",
 	}
 
-	result, sourceType, err := getS3Client(s3Object)
+	result, source, err := getS3Client(s3Object)
 	require.Error(t, err)
 	require.Nil(t, result)
-	require.Equal(t, "", sourceType)
+	require.Nil(t, source)
 
 	s3Mock.AssertExpectations(t)
 	lambdaMock.AssertExpectations(t)
@@ -191,15 +193,46 @@ func TestGetS3ClientSourceNoPrefix(t *testing.T) {
 		S3ObjectKey: "test",
 	}
 
-	result, sourceType, err := getS3Client(s3Object)
+	result, source, err := getS3Client(s3Object)
 	require.NoError(t, err)
 	require.NotNil(t, result)
-	require.Equal(t, models.IntegrationTypeAWS3, sourceType)
+	require.Equal(t, models.IntegrationTypeAWS3, source.IntegrationType)
 
 	s3Mock.AssertExpectations(t)
 	lambdaMock.AssertExpectations(t)
 }
 
+func TestGetSourceInfoConcurrent(t *testing.T) {
+	resetCaches()
+	lastEventReceived = make(map[string]time.Time)
+	lambdaMock := &testutils.LambdaMock{}
+	common.LambdaClient = lambdaMock
+
+	marshaledResult, err := jsoniter.Marshal([]*models.SourceIntegration{integration})
+	require.NoError(t, err)
+	// the sources are listed and the status is updated once for all consumers
+	lambdaMock.On("Invoke", mock.Anything).Return(&lambda.InvokeOutput{Payload: marshaledResult}, nil).Once()
+	lambdaMock.On("Invoke", mock.Anything).Return(&lambda.InvokeOutput{}, nil).Once()
+
+	s3Object := &S3ObjectInfo{
+		S3Bucket:    integration.S3Bucket,
+		S3ObjectKey: integration.S3Prefix + "/key",
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			source, err := getSourceInfo(s3Object)
+			assert.NoError(t, err)
+			assert.Equal(t, integration, source)
+		}()
+	}
+	wg.Wait()
+
+	lambdaMock.AssertExpectations(t)
+}
+
 func resetCaches() {
 	// resetting cache
 	sourceCache.cacheUpdateTime = time.Unix(0, 0)
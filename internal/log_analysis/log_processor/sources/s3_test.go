@@ -100,6 +100,15 @@ func TestParseUnknownMessage(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestOutputPrefix(t *testing.T) {
+	notification := &SnsNotification{}
+	message := `{"Type":"Notification","MessageAttributes":{"OutputPrefix":{"Type":"String","Value":"backfill/"}}}`
+	require.NoError(t, jsoniter.UnmarshalFromString(message, notification))
+	require.Equal(t, "backfill/", outputPrefix(notification))
+
+	require.Equal(t, "", outputPrefix(&SnsNotification{}))
+}
+
 func TestHandleUnsupportedFileType(t *testing.T) {
 	resetCaches()
 	// if we encounter an unsupported file type, we should just skip the object
@@ -0,0 +1,197 @@
+package parquet
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/xitongsys/parquet-go/parquet"
+)
+
+const (
+	// Julian day of the Unix epoch, INT96 timestamps count days since the start of the Julian period
+	julianDayOfEpoch = 2440588
+	nanosPerDay      = int64(24 * time.Hour)
+
+	// timestampLayout is the format of timestamps in Panther JSON events
+	timestampLayout = "2006-01-02 15:04:05.000000000"
+)
+
+// column buffers the values and levels of a leaf column, nulls are nil values.
+// The values have the Go types the Parquet writer encodes: bool, int32, int64, float32, float64 and
+// string for INT96 and BYTE_ARRAY values.
+type column struct {
+	*columnSchema
+	values []interface{}
+	defs   []int32
+	reps   []int32
+}
+
+func (c *column) append(rep, def int16, value interface{}) {
+	c.values = append(c.values, value)
+	c.defs = append(c.defs, int32(def))
+	c.reps = append(c.reps, int32(rep))
+}
+
+// appendNull adds a missing value, def is the level of the deepest defined ancestor
+func (c *column) appendNull(rep, def int16) {
+	c.append(rep, def, nil)
+}
+
+// appendString adds a string value, it is only used for map keys
+func (c *column) appendString(rep int16, value string) {
+	c.append(rep, c.maxDef, value)
+}
+
+// read reads a JSON value and adds it to the column.
+// If the value cannot be converted to the column type it is skipped and false is returned.
+func (c *column) read(iter *jsoniter.Iterator, rep int16) bool {
+	value, ok := c.convert(iter)
+	if ok {
+		c.append(rep, c.maxDef, value)
+	}
+	return ok
+}
+
+// convert reads a JSON value as a value of the column type
+func (c *column) convert(iter *jsoniter.Iterator) (interface{}, bool) {
+	switch c.physical {
+	case parquet.Type_BYTE_ARRAY:
+		if iter.WhatIsNext() == jsoniter.StringValue {
+			return iter.ReadString(), true
+		}
+		// store other JSON values as is
+		return string(iter.SkipAndReturnBytes()), true
+	case parquet.Type_BOOLEAN:
+		switch iter.WhatIsNext() {
+		case jsoniter.BoolValue:
+			return iter.ReadBool(), true
+		case jsoniter.StringValue:
+			value, err := strconv.ParseBool(iter.ReadString())
+			return value, err == nil
+		default:
+			iter.Skip()
+			return nil, false
+		}
+	case parquet.Type_INT32:
+		value, ok := readInt(iter)
+		if !ok || !c.inRange(value) {
+			return nil, false
+		}
+		return int32(value), true
+	case parquet.Type_INT64:
+		return readInt(iter)
+	case parquet.Type_FLOAT:
+		value, ok := readFloat(iter)
+		return float32(value), ok
+	case parquet.Type_DOUBLE:
+		return readFloat(iter)
+	case parquet.Type_INT96:
+		if iter.WhatIsNext() != jsoniter.StringValue {
+			iter.Skip()
+			return nil, false
+		}
+		tm, err := parseTimestamp(iter.ReadString())
+		if err != nil {
+			return nil, false
+		}
+		return string(int96Timestamp(tm)), true
+	default:
+		iter.Skip()
+		return nil, false
+	}
+}
+
+func (c *column) inRange(value int64) bool {
+	switch {
+	case c.converted == parquet.ConvertedType_INT_8:
+		return math.MinInt8 <= value && value <= math.MaxInt8
+	case c.converted == parquet.ConvertedType_INT_16:
+		return math.MinInt16 <= value && value <= math.MaxInt16
+	case c.physical == parquet.Type_INT32:
+		return math.MinInt32 <= value && value <= math.MaxInt32
+	default:
+		return true
+	}
+}
+
+// readNumber reads a JSON number or a string containing a number
+func readNumber(iter *jsoniter.Iterator) (string, bool) {
+	switch iter.WhatIsNext() {
+	case jsoniter.NumberValue:
+		return string(iter.ReadNumber()), true
+	case jsoniter.StringValue:
+		return iter.ReadString(), true
+	default:
+		iter.Skip()
+		return "", false
+	}
+}
+
+func readInt(iter *jsoniter.Iterator) (int64, bool) {
+	number, ok := readNumber(iter)
+	if !ok {
+		return 0, false
+	}
+	if value, err := strconv.ParseInt(number, 10, 64); err == nil {
+		return value, true
+	}
+	// numbers with a fraction or exponent
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value != math.Trunc(value) || value < math.MinInt64 || value >= math.MaxInt64 {
+		return 0, false
+	}
+	return int64(value), true
+}
+
+func readFloat(iter *jsoniter.Iterator) (float64, bool) {
+	number, ok := readNumber(iter)
+	if !ok {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	return value, err == nil
+}
+
+func parseTimestamp(value string) (time.Time, error) {
+	tm, err := time.Parse(timestampLayout, value)
+	if err != nil {
+		// timestamps that are not produced by the log processor
+		return time.Parse(time.RFC3339Nano, value)
+	}
+	return tm, nil
+}
+
+// int96Timestamp encodes a timestamp as nanoseconds of the day followed by the Julian day
+func int96Timestamp(tm time.Time) []byte {
+	nanos := tm.UnixNano()
+	days := nanos / nanosPerDay
+	if nanos%nanosPerDay < 0 {
+		days--
+	}
+	nanos -= days * nanosPerDay
+	value := make([]byte, 12)
+	binary.LittleEndian.PutUint64(value, uint64(nanos))
+	binary.LittleEndian.PutUint32(value[8:], uint32(days+julianDayOfEpoch))
+	return value
+}
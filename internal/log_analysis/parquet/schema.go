@@ -0,0 +1,358 @@
+package parquet
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/xitongsys/parquet-go/parquet"
+
+	"github.com/panther-labs/panther/internal/log_analysis/awsglue"
+)
+
+// convertedNone marks nodes without a converted type annotation
+const convertedNone parquet.ConvertedType = -1
+
+type nodeKind int
+
+const (
+	kindPrimitive nodeKind = iota
+	kindStruct
+	kindList
+	kindMap
+)
+
+// Schema is the Parquet schema of a Glue table.
+// Glue types are mapped to the Parquet types read by the Hive Parquet SerDe:
+//   - array<T> is a LIST group of optional elements
+//   - map<string,T> is a MAP group of optional values
+//   - timestamp is an INT96 value
+type Schema struct {
+	root        *node
+	columns     []*columnSchema
+	numElements int32
+}
+
+// node is an element of the schema tree, leaves are columns
+type node struct {
+	name       string
+	kind       nodeKind
+	repetition parquet.FieldRepetitionType
+	physical   parquet.Type
+	converted  parquet.ConvertedType
+	children   []*node
+	index      map[string]int // child index by name for structs
+	column     *columnSchema  // set for leaves
+	element    int32          // index of the node in the schema elements
+	// maximum definition and repetition levels of the values of this node
+	maxDef, maxRep int16
+}
+
+// columnSchema describes a leaf column
+type columnSchema struct {
+	index          int
+	element        int32
+	path           []string
+	physical       parquet.Type
+	converted      parquet.ConvertedType
+	maxDef, maxRep int16
+}
+
+// NewSchema returns the Parquet schema of a table with the Glue columns.
+// All columns are optional since fields can be missing from events.
+func NewSchema(columns []awsglue.Column) (*Schema, error) {
+	root := &node{
+		name:       "schema",
+		kind:       kindStruct,
+		repetition: parquet.FieldRepetitionType_REQUIRED,
+		converted:  convertedNone,
+		index:      make(map[string]int, len(columns)),
+	}
+	for _, column := range columns {
+		if _, duplicate := root.index[column.Name]; duplicate {
+			return nil, errors.Errorf("duplicate column %q", column.Name)
+		}
+		p := typeParser{input: column.Type}
+		child, err := p.parse(column.Name, parquet.FieldRepetitionType_OPTIONAL)
+		if err == nil && p.pos != len(p.input) {
+			err = p.errorf("unexpected input")
+		}
+		if err != nil {
+			return nil, errors.WithMessagef(err, "invalid type for column %q", column.Name)
+		}
+		root.index[column.Name] = len(root.children)
+		root.children = append(root.children, child)
+	}
+	schema := &Schema{
+		root: root,
+	}
+	schema.init(root, nil, 0, 0)
+	return schema, nil
+}
+
+// init sets the levels of a node and collects the leaf columns
+func (s *Schema) init(n *node, path []string, def, rep int16) {
+	n.element = s.numElements
+	s.numElements++
+	switch n.repetition {
+	case parquet.FieldRepetitionType_OPTIONAL:
+		def++
+	case parquet.FieldRepetitionType_REPEATED:
+		def++
+		rep++
+	}
+	n.maxDef, n.maxRep = def, rep
+	if n.kind == kindPrimitive {
+		n.column = &columnSchema{
+			index:     len(s.columns),
+			element:   n.element,
+			path:      path,
+			physical:  n.physical,
+			converted: n.converted,
+			maxDef:    def,
+			maxRep:    rep,
+		}
+		s.columns = append(s.columns, n.column)
+		return
+	}
+	for _, child := range n.children {
+		// copy the path, it is shared by siblings
+		childPath := append(append(make([]string, 0, len(path)+1), path...), child.name)
+		s.init(child, childPath, def, rep)
+	}
+}
+
+// elements returns the schema elements of the nodes in depth first order, as stored in the file footer.
+// The elements are not shared, the Parquet writer renames them when it writes the footer.
+func (s *Schema) elements() []*parquet.SchemaElement {
+	elements := make([]*parquet.SchemaElement, 0, s.numElements)
+	var walk func(n *node)
+	walk = func(n *node) {
+		element := &parquet.SchemaElement{
+			Name: n.name,
+		}
+		if n.kind == kindPrimitive {
+			physical := n.physical
+			element.Type = &physical
+		} else {
+			numChildren := int32(len(n.children))
+			element.NumChildren = &numChildren
+		}
+		if n != s.root {
+			repetition := n.repetition
+			element.RepetitionType = &repetition
+		}
+		if n.converted != convertedNone {
+			converted := n.converted
+			element.ConvertedType = &converted
+		}
+		elements = append(elements, element)
+		for _, child := range n.children {
+			walk(child)
+		}
+	}
+	walk(s.root)
+	return elements
+}
+
+// typeParser parses Glue (Hive) type definitions
+type typeParser struct {
+	input string
+	pos   int
+}
+
+func (p *typeParser) errorf(format string, args ...interface{}) error {
+	return errors.Errorf(format+" at position %d of %q", append(args, p.pos, p.input)...)
+}
+
+func (p *typeParser) skipSpace() {
+	for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// next reads until one of the delimiters
+func (p *typeParser) next(delimiters string) string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.input) && strings.IndexByte(delimiters, p.input[p.pos]) == -1 {
+		p.pos++
+	}
+	return strings.TrimSpace(p.input[start:p.pos])
+}
+
+// accept consumes c if it is the next character
+func (p *typeParser) accept(c byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.input) && p.input[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *typeParser) expect(c byte) error {
+	if !p.accept(c) {
+		return p.errorf("expected %q", c)
+	}
+	return nil
+}
+
+func (p *typeParser) parse(name string, repetition parquet.FieldRepetitionType) (*node, error) {
+	typeName := strings.ToLower(p.next("<>,:"))
+	switch typeName {
+	case "array":
+		return p.parseArray(name, repetition)
+	case "map":
+		return p.parseMap(name, repetition)
+	case "struct":
+		return p.parseStruct(name, repetition)
+	}
+	n := &node{
+		name:       name,
+		kind:       kindPrimitive,
+		repetition: repetition,
+		converted:  convertedNone,
+	}
+	switch typeName {
+	case "string":
+		n.physical, n.converted = parquet.Type_BYTE_ARRAY, parquet.ConvertedType_UTF8
+	case "boolean":
+		n.physical = parquet.Type_BOOLEAN
+	case "tinyint":
+		n.physical, n.converted = parquet.Type_INT32, parquet.ConvertedType_INT_8
+	case "smallint":
+		n.physical, n.converted = parquet.Type_INT32, parquet.ConvertedType_INT_16
+	case "int":
+		n.physical = parquet.Type_INT32
+	case "bigint":
+		n.physical = parquet.Type_INT64
+	case "float":
+		n.physical = parquet.Type_FLOAT
+	case "double":
+		n.physical = parquet.Type_DOUBLE
+	case awsglue.GlueTimestampType:
+		n.physical = parquet.Type_INT96
+	default:
+		return nil, p.errorf("unsupported type %q", typeName)
+	}
+	return n, nil
+}
+
+func (p *typeParser) parseArray(name string, repetition parquet.FieldRepetitionType) (*node, error) {
+	if err := p.expect('<'); err != nil {
+		return nil, err
+	}
+	element, err := p.parse("element", parquet.FieldRepetitionType_OPTIONAL)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect('>'); err != nil {
+		return nil, err
+	}
+	return &node{
+		name:       name,
+		kind:       kindList,
+		repetition: repetition,
+		converted:  parquet.ConvertedType_LIST,
+		children: []*node{{
+			name:       "list",
+			kind:       kindStruct,
+			repetition: parquet.FieldRepetitionType_REPEATED,
+			converted:  convertedNone,
+			children:   []*node{element},
+		}},
+	}, nil
+}
+
+func (p *typeParser) parseMap(name string, repetition parquet.FieldRepetitionType) (*node, error) {
+	if err := p.expect('<'); err != nil {
+		return nil, err
+	}
+	key, err := p.parse("key", parquet.FieldRepetitionType_REQUIRED)
+	if err != nil {
+		return nil, err
+	}
+	// JSON object keys are strings
+	if key.kind != kindPrimitive || key.physical != parquet.Type_BYTE_ARRAY {
+		return nil, p.errorf("unsupported map key type")
+	}
+	if err := p.expect(','); err != nil {
+		return nil, err
+	}
+	value, err := p.parse("value", parquet.FieldRepetitionType_OPTIONAL)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect('>'); err != nil {
+		return nil, err
+	}
+	return &node{
+		name:       name,
+		kind:       kindMap,
+		repetition: repetition,
+		converted:  parquet.ConvertedType_MAP,
+		children: []*node{{
+			name:       "key_value",
+			kind:       kindStruct,
+			repetition: parquet.FieldRepetitionType_REPEATED,
+			converted:  convertedNone,
+			children:   []*node{key, value},
+		}},
+	}, nil
+}
+
+func (p *typeParser) parseStruct(name string, repetition parquet.FieldRepetitionType) (*node, error) {
+	if err := p.expect('<'); err != nil {
+		return nil, err
+	}
+	n := &node{
+		name:       name,
+		kind:       kindStruct,
+		repetition: repetition,
+		converted:  convertedNone,
+		index:      make(map[string]int),
+	}
+	for {
+		fieldName := p.next(":<>,")
+		if fieldName == "" {
+			return nil, p.errorf("missing struct field name")
+		}
+		if err := p.expect(':'); err != nil {
+			return nil, err
+		}
+		field, err := p.parse(fieldName, parquet.FieldRepetitionType_OPTIONAL)
+		if err != nil {
+			return nil, err
+		}
+		if _, duplicate := n.index[fieldName]; duplicate {
+			return nil, p.errorf("duplicate struct field %q", fieldName)
+		}
+		n.index[fieldName] = len(n.children)
+		n.children = append(n.children, field)
+		if !p.accept(',') {
+			break
+		}
+	}
+	if err := p.expect('>'); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
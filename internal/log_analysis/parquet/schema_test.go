@@ -0,0 +1,114 @@
+package parquet
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go/parquet"
+
+	"github.com/panther-labs/panther/internal/log_analysis/awsglue"
+)
+
+func TestNewSchema(t *testing.T) {
+	schema, err := NewSchema([]awsglue.Column{
+		{Name: "name", Type: "string"},
+		{Name: "tags", Type: "array<string>"},
+		{Name: "labels", Type: "map<string,array<string>>"},
+		{Name: "nested", Type: "struct<a:int,b:array<struct<c:timestamp>>>"},
+	})
+	require.NoError(t, err)
+
+	type level struct {
+		path           []string
+		maxDef, maxRep int16
+	}
+	var levels []level
+	for _, c := range schema.columns {
+		levels = append(levels, level{c.path, c.maxDef, c.maxRep})
+	}
+	require.Equal(t, []level{
+		{[]string{"name"}, 1, 0},
+		{[]string{"tags", "list", "element"}, 3, 1},
+		{[]string{"labels", "key_value", "key"}, 2, 1},
+		{[]string{"labels", "key_value", "value", "list", "element"}, 5, 2},
+		{[]string{"nested", "a"}, 2, 0},
+		{[]string{"nested", "b", "list", "element", "c"}, 5, 1},
+	}, levels)
+
+	var names []string
+	for _, element := range schema.elements() {
+		names = append(names, element.Name)
+	}
+	require.Equal(t, []string{
+		"schema",
+		"name",
+		"tags", "list", "element",
+		"labels", "key_value", "key", "value", "list", "element",
+		"nested", "a", "b", "list", "element", "c",
+	}, names)
+}
+
+func TestNewSchemaTypes(t *testing.T) {
+	type types struct {
+		physical  parquet.Type
+		converted parquet.ConvertedType
+	}
+	for glueType, expected := range map[string]types{
+		"string":    {parquet.Type_BYTE_ARRAY, parquet.ConvertedType_UTF8},
+		"boolean":   {parquet.Type_BOOLEAN, convertedNone},
+		"tinyint":   {parquet.Type_INT32, parquet.ConvertedType_INT_8},
+		"smallint":  {parquet.Type_INT32, parquet.ConvertedType_INT_16},
+		"int":       {parquet.Type_INT32, convertedNone},
+		"bigint":    {parquet.Type_INT64, convertedNone},
+		"float":     {parquet.Type_FLOAT, convertedNone},
+		"double":    {parquet.Type_DOUBLE, convertedNone},
+		"timestamp": {parquet.Type_INT96, convertedNone},
+	} {
+		schema, err := NewSchema([]awsglue.Column{{Name: "col", Type: glueType}})
+		require.NoError(t, err, glueType)
+		require.Equal(t, expected, types{schema.columns[0].physical, schema.columns[0].converted}, glueType)
+	}
+}
+
+func TestNewSchemaErrors(t *testing.T) {
+	for _, glueType := range []string{
+		"",
+		"decimal(10,2)",
+		"array<string",
+		"array<string>>",
+		"map<int,string>",
+		"map<string>",
+		"struct<>",
+		"struct<a:string,a:int>",
+		"struct<a string>",
+	} {
+		_, err := NewSchema([]awsglue.Column{{Name: "col", Type: glueType}})
+		require.Error(t, err, glueType)
+	}
+
+	_, err := NewSchema([]awsglue.Column{{Name: "col", Type: "string"}, {Name: "col", Type: "int"}})
+	require.Error(t, err)
+}
+
+func TestNewSchemaRuleMatchColumns(t *testing.T) {
+	_, err := NewSchema(awsglue.RuleMatchColumns)
+	require.NoError(t, err)
+}
@@ -0,0 +1,257 @@
+package parquet
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+	sourcewriter "github.com/xitongsys/parquet-go-source/writer"
+	"github.com/xitongsys/parquet-go/common"
+	"github.com/xitongsys/parquet-go/layout"
+	"github.com/xitongsys/parquet-go/parquet"
+	parquetschema "github.com/xitongsys/parquet-go/schema"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+const (
+	// pageSize is the uncompressed size of the data pages.
+	// The Parquet writer also shreds the buffered rows when their size reaches pageSize times the number of columns.
+	pageSize = 64 * 1024
+
+	createdBy = "panther"
+)
+
+// Writer writes JSON events as a Snappy compressed Parquet file.
+// Rows are shredded in columns by the Writer, pages, row groups and the footer are written by parquet-go.
+type Writer struct {
+	schema *Schema
+	file   bytes.Buffer
+	writer *writer.ParquetWriter
+	rows   int
+}
+
+// NewWriter returns a writer for events of the schema
+func NewWriter(schema *Schema) (*Writer, error) {
+	w := &Writer{
+		schema: schema,
+	}
+	pw, err := writer.NewParquetWriter(sourcewriter.NewWriterFile(&w.file), schema.elements(), 1)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create parquet writer")
+	}
+	pw.PageSize = pageSize
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+	pw.Footer.CreatedBy = aws.String(createdBy)
+	pw.MarshalFunc = schema.marshal
+	w.writer = pw
+	return w, nil
+}
+
+// Rows returns the number of buffered rows
+func (w *Writer) Rows() int {
+	return w.rows
+}
+
+// Size returns the approximate size of the buffered data in bytes
+func (w *Writer) Size() int {
+	return w.file.Len() + int(w.writer.Size+w.writer.ObjsSize)
+}
+
+// WriteRow adds a JSON object as a row.
+// Fields that are not columns are ignored and values that cannot be converted to the column type are stored as nulls.
+// Rows that are not valid JSON objects are not added.
+func (w *Writer) WriteRow(data []byte) error {
+	iter := jsoniter.ConfigDefault.BorrowIterator(data)
+	defer jsoniter.ConfigDefault.ReturnIterator(iter)
+
+	if iter.WhatIsNext() != jsoniter.ObjectValue {
+		return errors.New("parquet row is not a JSON object")
+	}
+	iter.Skip()
+	if iter.Error != nil && iter.Error != io.EOF {
+		return errors.Wrap(iter.Error, "invalid parquet row")
+	}
+	// the row is shredded when the writer flushes its buffered rows, keep a copy
+	if err := w.writer.Write(append([]byte(nil), data...)); err != nil {
+		return errors.Wrap(err, "failed to write parquet pages")
+	}
+	w.rows++
+	return nil
+}
+
+// WriteTo writes the Parquet file, no rows can be added afterwards
+func (w *Writer) WriteTo(out io.Writer) (int64, error) {
+	if err := w.writer.WriteStop(); err != nil {
+		return 0, errors.Wrap(err, "failed to write parquet file")
+	}
+	return w.file.WriteTo(out)
+}
+
+// marshal shreds the JSON rows in [begin, end) in tables of column values for the Parquet writer
+func (s *Schema) marshal(rows []interface{}, begin, end int, handler *parquetschema.SchemaHandler) (
+	*map[string]*layout.Table, error) {
+
+	sh := newShredder(s)
+	for _, row := range rows[begin:end] {
+		if err := sh.writeRow(row.([]byte)); err != nil {
+			return nil, err
+		}
+	}
+	tables := make(map[string]*layout.Table, len(sh.columns))
+	for _, c := range sh.columns {
+		element := handler.SchemaElements[c.element]
+		path := handler.IndexMap[c.element]
+		tables[path] = &layout.Table{
+			RepetitionType:     element.GetRepetitionType(),
+			Schema:             element,
+			Path:               common.StrToPath(path),
+			MaxDefinitionLevel: int32(c.maxDef),
+			MaxRepetitionLevel: int32(c.maxRep),
+			Values:             c.values,
+			DefinitionLevels:   c.defs,
+			RepetitionLevels:   c.reps,
+			Info:               handler.Infos[c.element],
+		}
+	}
+	return &tables, nil
+}
+
+// shredder splits JSON rows in the values and levels of the leaf columns of a schema
+type shredder struct {
+	schema  *Schema
+	columns []*column
+}
+
+func newShredder(schema *Schema) *shredder {
+	columns := make([]*column, len(schema.columns))
+	for i, columnSchema := range schema.columns {
+		columns[i] = &column{
+			columnSchema: columnSchema,
+		}
+	}
+	return &shredder{
+		schema:  schema,
+		columns: columns,
+	}
+}
+
+// writeRow shreds a JSON object
+func (s *shredder) writeRow(data []byte) error {
+	iter := jsoniter.ConfigDefault.BorrowIterator(data)
+	defer jsoniter.ConfigDefault.ReturnIterator(iter)
+
+	s.writeStruct(iter, s.schema.root, 0)
+	if iter.Error != nil && iter.Error != io.EOF {
+		return errors.Wrap(iter.Error, "invalid parquet row")
+	}
+	return nil
+}
+
+// writeValue shreds the next JSON value in the columns of a node
+func (s *shredder) writeValue(iter *jsoniter.Iterator, n *node, rep int16) {
+	next := iter.WhatIsNext()
+	if next == jsoniter.NilValue {
+		iter.Skip()
+		s.writeNull(n, rep, n.maxDef-1)
+		return
+	}
+	switch n.kind {
+	case kindPrimitive:
+		if !s.columns[n.column.index].read(iter, rep) {
+			s.writeNull(n, rep, n.maxDef-1)
+		}
+	case kindStruct:
+		if next != jsoniter.ObjectValue {
+			iter.Skip()
+			s.writeNull(n, rep, n.maxDef-1)
+			return
+		}
+		s.writeStruct(iter, n, rep)
+	case kindList:
+		if next != jsoniter.ArrayValue {
+			iter.Skip()
+			s.writeNull(n, rep, n.maxDef-1)
+			return
+		}
+		repeated := n.children[0]
+		element := repeated.children[0]
+		elementRep := rep
+		iter.ReadArrayCB(func(iter *jsoniter.Iterator) bool {
+			s.writeValue(iter, element, elementRep)
+			elementRep = repeated.maxRep
+			return true
+		})
+		if elementRep == rep { // empty list
+			s.writeNull(n, rep, n.maxDef)
+		}
+	case kindMap:
+		if next != jsoniter.ObjectValue {
+			iter.Skip()
+			s.writeNull(n, rep, n.maxDef-1)
+			return
+		}
+		repeated := n.children[0]
+		key, value := repeated.children[0], repeated.children[1]
+		entryRep := rep
+		iter.ReadMapCB(func(iter *jsoniter.Iterator, k string) bool {
+			s.columns[key.column.index].appendString(entryRep, k)
+			s.writeValue(iter, value, entryRep)
+			entryRep = repeated.maxRep
+			return true
+		})
+		if entryRep == rep { // empty map
+			s.writeNull(n, rep, n.maxDef)
+		}
+	}
+}
+
+// writeStruct shreds the fields of a JSON object, missing fields are nulls
+func (s *shredder) writeStruct(iter *jsoniter.Iterator, n *node, rep int16) {
+	found := make([]bool, len(n.children))
+	iter.ReadMapCB(func(iter *jsoniter.Iterator, field string) bool {
+		i, ok := n.index[field]
+		if !ok || found[i] { // skip unknown and duplicate fields
+			iter.Skip()
+			return true
+		}
+		found[i] = true
+		s.writeValue(iter, n.children[i], rep)
+		return true
+	})
+	for i, child := range n.children {
+		if !found[i] {
+			s.writeNull(child, rep, n.maxDef)
+		}
+	}
+}
+
+// writeNull adds a null to all columns of a node, def is the level of the deepest defined ancestor
+func (s *shredder) writeNull(n *node, rep, def int16) {
+	if n.kind == kindPrimitive {
+		s.columns[n.column.index].appendNull(rep, def)
+		return
+	}
+	for _, child := range n.children {
+		s.writeNull(child, rep, def)
+	}
+}
@@ -0,0 +1,273 @@
+package parquet
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+
+	"github.com/panther-labs/panther/internal/log_analysis/awsglue"
+)
+
+func newTestWriter(t *testing.T, columns ...awsglue.Column) *Writer {
+	schema, err := NewSchema(columns)
+	require.NoError(t, err)
+	w, err := NewWriter(schema)
+	require.NoError(t, err)
+	return w
+}
+
+func shredRows(t *testing.T, columns []awsglue.Column, rows ...string) []*column {
+	schema, err := NewSchema(columns)
+	require.NoError(t, err)
+	s := newShredder(schema)
+	for _, row := range rows {
+		require.NoError(t, s.writeRow([]byte(row)))
+	}
+	return s.columns
+}
+
+func TestShredLevels(t *testing.T) {
+	columns := shredRows(t, []awsglue.Column{
+		{Name: "name", Type: "string"},
+		{Name: "tags", Type: "array<string>"},
+		{Name: "labels", Type: "map<string,bigint>"},
+		{Name: "nested", Type: "struct<a:int,b:array<struct<c:string>>>"},
+	},
+		`{"name":"a","tags":["x","y"],"labels":{"k":1},"nested":{"a":1,"b":[{"c":"c1"},{}]}}`,
+		`{"name":null,"tags":[],"labels":{},"nested":{"b":[]},"unknown":{"a":1}}`,
+		`{"tags":[null,"z"],"nested":null}`,
+	)
+
+	name, tags, key, value, a, c := columns[0], columns[1], columns[2], columns[3], columns[4], columns[5]
+	require.Equal(t, []int32{1, 0, 0}, name.defs)
+	require.Equal(t, []int32{0, 0, 0}, name.reps)
+	require.Equal(t, []interface{}{"a", nil, nil}, name.values)
+
+	require.Equal(t, []int32{3, 3, 1, 2, 3}, tags.defs)
+	require.Equal(t, []int32{0, 1, 0, 0, 1}, tags.reps)
+	require.Equal(t, []interface{}{"x", "y", nil, nil, "z"}, tags.values)
+
+	require.Equal(t, []int32{2, 1, 0}, key.defs)
+	require.Equal(t, []int32{3, 1, 0}, value.defs)
+	require.Equal(t, []interface{}{int64(1), nil, nil}, value.values)
+
+	require.Equal(t, []int32{2, 1, 0}, a.defs)
+	require.Equal(t, []int32{5, 4, 2, 0}, c.defs)
+	require.Equal(t, []int32{0, 1, 0, 0}, c.reps)
+}
+
+func TestShredConversions(t *testing.T) {
+	columns := shredRows(t, []awsglue.Column{
+		{Name: "str", Type: "string"},
+		{Name: "small", Type: "smallint"},
+		{Name: "big", Type: "bigint"},
+		{Name: "flag", Type: "boolean"},
+		{Name: "ratio", Type: "double"},
+		{Name: "ts", Type: "timestamp"},
+	},
+		`{"str":{"a":[1, 2]},"small":"42","big":1e3,"flag":"true","ratio":"0.5","ts":"2020-09-19 08:26:10.123000000"}`,
+		`{"str":42,"small":100000,"big":1.5,"flag":1,"ratio":"x","ts":"yesterday"}`,
+	)
+
+	require.Equal(t, []interface{}{`{"a":[1, 2]}`, `42`}, columns[0].values)
+	tm := time.Date(2020, 9, 19, 8, 26, 10, 123000000, time.UTC)
+	require.Equal(t, []interface{}{int32(42), int64(1000), true, 0.5, string(int96Timestamp(tm))}, []interface{}{
+		columns[1].values[0], columns[2].values[0], columns[3].values[0], columns[4].values[0], columns[5].values[0],
+	})
+	for _, c := range columns[1:] {
+		require.Nil(t, c.values[1])
+		require.Equal(t, []int32{1, 0}, c.defs)
+	}
+}
+
+func TestWriteRowInvalid(t *testing.T) {
+	w := newTestWriter(t,
+		awsglue.Column{Name: "name", Type: "string"},
+		awsglue.Column{Name: "tags", Type: "array<string>"},
+	)
+	require.NoError(t, w.WriteRow([]byte(`{"name":"a","tags":["x"]}`)))
+	require.Error(t, w.WriteRow([]byte(`{"name":"b","tags":["y",`)))
+	require.Error(t, w.WriteRow([]byte(`["not an object"]`)))
+	require.Equal(t, 1, w.Rows())
+
+	_, columns := readColumns(t, w)
+	require.Equal(t, []interface{}{"a"}, columns[0].values)
+	require.Equal(t, []interface{}{"x"}, columns[1].values)
+}
+
+func TestInt96Timestamp(t *testing.T) {
+	value := int96Timestamp(time.Date(1970, 1, 2, 0, 0, 1, 0, time.UTC))
+	require.Equal(t, uint64(time.Second), binary.LittleEndian.Uint64(value))
+	require.Equal(t, uint32(julianDayOfEpoch+1), binary.LittleEndian.Uint32(value[8:]))
+
+	value = int96Timestamp(time.Date(1969, 12, 31, 23, 0, 0, 0, time.UTC))
+	require.Equal(t, uint64(23*time.Hour), binary.LittleEndian.Uint64(value))
+	require.Equal(t, uint32(julianDayOfEpoch-1), binary.LittleEndian.Uint32(value[8:]))
+}
+
+// readColumns decodes a file with an independent Parquet implementation and returns the values and levels of each column
+func readColumns(t *testing.T, w *Writer) (*reader.ParquetReader, []readColumn) {
+	var buf bytes.Buffer
+	_, err := w.WriteTo(&buf)
+	require.NoError(t, err)
+	file, err := buffer.NewBufferFile(buf.Bytes())
+	require.NoError(t, err)
+	r, err := reader.NewParquetColumnReader(file, 1)
+	require.NoError(t, err)
+	require.Equal(t, int64(w.Rows()), r.GetNumRows())
+
+	columns := make([]readColumn, len(r.SchemaHandler.ValueColumns))
+	for i := range columns {
+		// levels are read per row, a row can have many values
+		columns[i].values, columns[i].reps, columns[i].defs, err = r.ReadColumnByIndex(int64(i), r.GetNumRows())
+		require.NoError(t, err)
+	}
+	return r, columns
+}
+
+type readColumn struct {
+	values []interface{}
+	reps   []int32
+	defs   []int32
+}
+
+func TestRoundTrip(t *testing.T) {
+	w := newTestWriter(t,
+		awsglue.Column{Name: "name", Type: "string"},
+		awsglue.Column{Name: "tags", Type: "array<string>"},
+		awsglue.Column{Name: "labels", Type: "map<string,bigint>"},
+		awsglue.Column{Name: "nested", Type: "struct<a:int,b:array<struct<c:string>>>"},
+		awsglue.Column{Name: "flag", Type: "boolean"},
+		awsglue.Column{Name: "ratio", Type: "double"},
+		awsglue.Column{Name: "ts", Type: "timestamp"},
+	)
+	for _, row := range []string{
+		`{"name":"a","tags":["x","y"],"labels":{"k":1},"nested":{"a":1,"b":[{"c":"c1"},{}]},"flag":true,"ratio":0.5,` +
+			`"ts":"2020-09-19 08:26:10.123000000"}`,
+		`{"name":null,"tags":[],"labels":{},"nested":{"b":[]},"flag":false,"ts":"1969-12-31 23:00:00.000000000"}`,
+		`{"tags":[null,"z"],"nested":null}`,
+	} {
+		require.NoError(t, w.WriteRow([]byte(row)))
+	}
+
+	r, columns := readColumns(t, w)
+	require.Equal(t, createdBy, r.Footer.GetCreatedBy())
+	require.Equal(t, []string{
+		"Schema.Name",
+		"Schema.Tags.List.Element",
+		"Schema.Labels.Key_value.Key",
+		"Schema.Labels.Key_value.Value",
+		"Schema.Nested.A",
+		"Schema.Nested.B.List.Element.C",
+		"Schema.Flag",
+		"Schema.Ratio",
+		"Schema.Ts",
+	}, r.SchemaHandler.ValueColumns)
+
+	// the groups are annotated as the Hive Parquet SerDe expects (the reader capitalizes names)
+	convertedTypes := make(map[string]parquet.ConvertedType)
+	physicalTypes := make(map[string]parquet.Type)
+	for _, element := range r.Footer.Schema[1:] {
+		if element.ConvertedType != nil {
+			convertedTypes[strings.ToLower(element.Name)] = *element.ConvertedType
+		}
+		if element.Type != nil {
+			physicalTypes[strings.ToLower(element.Name)] = *element.Type
+		}
+	}
+	require.Equal(t, map[string]parquet.ConvertedType{
+		"name":    parquet.ConvertedType_UTF8,
+		"tags":    parquet.ConvertedType_LIST,
+		"element": parquet.ConvertedType_UTF8,
+		"labels":  parquet.ConvertedType_MAP,
+		"key":     parquet.ConvertedType_UTF8,
+		"b":       parquet.ConvertedType_LIST,
+		"c":       parquet.ConvertedType_UTF8,
+	}, convertedTypes)
+	require.Equal(t, map[string]parquet.Type{
+		"name":    parquet.Type_BYTE_ARRAY,
+		"element": parquet.Type_BYTE_ARRAY,
+		"key":     parquet.Type_BYTE_ARRAY,
+		"value":   parquet.Type_INT64,
+		"a":       parquet.Type_INT32,
+		"c":       parquet.Type_BYTE_ARRAY,
+		"flag":    parquet.Type_BOOLEAN,
+		"ratio":   parquet.Type_DOUBLE,
+		"ts":      parquet.Type_INT96,
+	}, physicalTypes)
+
+	name, tags, key, value, a, c, flag, ratio, ts := columns[0], columns[1], columns[2], columns[3], columns[4], columns[5],
+		columns[6], columns[7], columns[8]
+	require.Equal(t, readColumn{[]interface{}{"a", nil, nil}, []int32{0, 0, 0}, []int32{1, 0, 0}}, name)
+	require.Equal(t, readColumn{[]interface{}{"x", "y", nil, nil, "z"}, []int32{0, 1, 0, 0, 1}, []int32{3, 3, 1, 2, 3}}, tags)
+	require.Equal(t, readColumn{[]interface{}{"k", nil, nil}, []int32{0, 0, 0}, []int32{2, 1, 0}}, key)
+	require.Equal(t, readColumn{[]interface{}{int64(1), nil, nil}, []int32{0, 0, 0}, []int32{3, 1, 0}}, value)
+	require.Equal(t, readColumn{[]interface{}{int32(1), nil, nil}, []int32{0, 0, 0}, []int32{2, 1, 0}}, a)
+	require.Equal(t, readColumn{[]interface{}{"c1", nil, nil, nil}, []int32{0, 1, 0, 0}, []int32{5, 4, 2, 0}}, c)
+	require.Equal(t, readColumn{[]interface{}{true, false, nil}, []int32{0, 0, 0}, []int32{1, 1, 0}}, flag)
+	require.Equal(t, readColumn{[]interface{}{0.5, nil, nil}, []int32{0, 0, 0}, []int32{1, 0, 0}}, ratio)
+
+	// INT96 timestamps are the nanoseconds of the day followed by the Julian day
+	require.Equal(t, []int32{1, 1, 0}, ts.defs)
+	require.Nil(t, ts.values[2])
+	var times []time.Time
+	for _, v := range ts.values[:2] {
+		data := []byte(v.(string))
+		require.Len(t, data, 12)
+		day := int64(binary.LittleEndian.Uint32(data[8:])) - 2440588 // the Julian day of 1970-01-01
+		nanos := int64(binary.LittleEndian.Uint64(data))
+		times = append(times, time.Unix(day*24*3600, nanos).UTC())
+	}
+	require.Equal(t, []time.Time{
+		time.Date(2020, 9, 19, 8, 26, 10, 123000000, time.UTC),
+		time.Date(1969, 12, 31, 23, 0, 0, 0, time.UTC),
+	}, times)
+}
+
+func TestRoundTripPages(t *testing.T) {
+	w := newTestWriter(t,
+		awsglue.Column{Name: "text", Type: "string"},
+		awsglue.Column{Name: "tags", Type: "array<string>"},
+	)
+	// enough data to flush the buffered rows many times and split both columns in pages
+	text := strings.Repeat("x", 1000)
+	const rows = 3000
+	for i := 0; i < rows; i++ {
+		require.NoError(t, w.WriteRow([]byte(fmt.Sprintf(`{"text":"%s%d","tags":["%s","%d"]}`, text, i, text, i))))
+	}
+
+	_, columns := readColumns(t, w)
+	require.Len(t, columns[0].values, rows)
+	require.Len(t, columns[1].values, 2*rows)
+	for i := 0; i < rows; i++ {
+		require.Equal(t, fmt.Sprintf("%s%d", text, i), columns[0].values[i])
+		require.Equal(t, []interface{}{text, fmt.Sprint(i)}, columns[1].values[2*i:2*i+2])
+		require.Equal(t, []int32{0, 1}, columns[1].reps[2*i:2*i+2])
+	}
+}
@@ -0,0 +1,156 @@
+package extract
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/tidwall/gjson"
+)
+
+var (
+	// a pragmatic subset of RFC 5322 addresses, as found in logs
+	emailRegex = regexp.MustCompile(`^[a-zA-Z0-9.!#$%&'*+/=?^_{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)+$`)
+
+	// keys of username fields, lower case without separators
+	usernameKeys = map[string]bool{
+		"username":       true,
+		"user":           true,
+		"login":          true,
+		"loginname":      true,
+		"samaccountname": true,
+	}
+
+	// keys of fields identifying cloud principals, lower case without separators
+	cloudPrincipalKeys = map[string]bool{
+		"principalid":    true,
+		"principalarn":   true,
+		"principalemail": true,
+		"serviceaccount": true,
+	}
+)
+
+// Extractors calls all its extractors
+type Extractors []Extractor
+
+func (extractors Extractors) Extract(key, value gjson.Result) {
+	for _, extractor := range extractors {
+		extractor.Extract(key, value)
+	}
+}
+
+// EmailAppender collects email addresses
+type EmailAppender interface {
+	AppendAnyEmails(values ...string)
+}
+
+// EmailExtractor extracts the string values that are email addresses
+type EmailExtractor struct {
+	appender EmailAppender
+}
+
+func NewEmailExtractor(appender EmailAppender) *EmailExtractor {
+	return &EmailExtractor{appender: appender}
+}
+
+func (e *EmailExtractor) Extract(_, value gjson.Result) {
+	if value.Type == gjson.String && IsEmail(value.Str) {
+		e.appender.AppendAnyEmails(value.Str)
+	}
+}
+
+// UsernameAppender collects usernames
+type UsernameAppender interface {
+	AppendAnyUsernames(values ...string)
+}
+
+// UsernameExtractor extracts the string values of username fields (e.g. userName, user_name, login)
+type UsernameExtractor struct {
+	appender UsernameAppender
+}
+
+func NewUsernameExtractor(appender UsernameAppender) *UsernameExtractor {
+	return &UsernameExtractor{appender: appender}
+}
+
+func (e *UsernameExtractor) Extract(key, value gjson.Result) {
+	if value.Type == gjson.String && usernameKeys[normalizeKey(key.Str)] {
+		e.appender.AppendAnyUsernames(value.Str)
+	}
+}
+
+// CloudPrincipalAppender collects cloud principals
+type CloudPrincipalAppender interface {
+	AppendAnyCloudPrincipals(values ...string)
+}
+
+// CloudPrincipalExtractor extracts the values identifying cloud principals:
+// AWS IAM user and role ARNs, GCP service accounts and the values of principal fields (e.g. principalId)
+type CloudPrincipalExtractor struct {
+	appender CloudPrincipalAppender
+}
+
+func NewCloudPrincipalExtractor(appender CloudPrincipalAppender) *CloudPrincipalExtractor {
+	return &CloudPrincipalExtractor{appender: appender}
+}
+
+func (e *CloudPrincipalExtractor) Extract(key, value gjson.Result) {
+	if value.Type != gjson.String {
+		return
+	}
+	if IsCloudPrincipal(value.Str) || cloudPrincipalKeys[normalizeKey(key.Str)] {
+		e.appender.AppendAnyCloudPrincipals(value.Str)
+	}
+}
+
+// IsEmail checks if a value is an email address
+func IsEmail(value string) bool {
+	return len(value) <= 254 && strings.IndexByte(value, '@') > 0 && emailRegex.MatchString(value)
+}
+
+// IsCloudPrincipal checks if a value is the ARN of an AWS IAM user, role or root account, or a GCP service account
+func IsCloudPrincipal(value string) bool {
+	if strings.HasPrefix(value, "arn:") {
+		parsedARN, err := arn.Parse(value)
+		if err != nil {
+			return false
+		}
+		switch parsedARN.Service {
+		case "iam":
+			return parsedARN.Resource == "root" ||
+				strings.HasPrefix(parsedARN.Resource, "user/") ||
+				strings.HasPrefix(parsedARN.Resource, "role/")
+		case "sts":
+			return strings.HasPrefix(parsedARN.Resource, "assumed-role/") ||
+				strings.HasPrefix(parsedARN.Resource, "federated-user/")
+		}
+		return false
+	}
+	return strings.HasSuffix(value, ".gserviceaccount.com") && IsEmail(value)
+}
+
+func normalizeKey(key string) string {
+	key = strings.ToLower(key)
+	if strings.ContainsAny(key, "_-") {
+		key = strings.NewReplacer("_", "", "-", "").Replace(key)
+	}
+	return key
+}
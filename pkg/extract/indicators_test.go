@@ -0,0 +1,105 @@
+package extract
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"sort"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/require"
+)
+
+type testIndicators struct {
+	Emails          []string
+	Usernames       []string
+	CloudPrincipals []string
+}
+
+func (t *testIndicators) AppendAnyEmails(values ...string) {
+	t.Emails = append(t.Emails, values...)
+}
+
+func (t *testIndicators) AppendAnyUsernames(values ...string) {
+	t.Usernames = append(t.Usernames, values...)
+}
+
+func (t *testIndicators) AppendAnyCloudPrincipals(values ...string) {
+	t.CloudPrincipals = append(t.CloudPrincipals, values...)
+}
+
+func TestIndicatorExtractors(t *testing.T) {
+	json := (jsoniter.RawMessage)(`
+{
+  "owner": "alice@example.com",
+  "notAnEmail": "alice at example.com",
+  "user_name": "alice",
+  "Login": "bob",
+  "user": {"name": "carol", "email": "carol@example.co.uk"},
+  "principalId": "AIDAJ45Q7YFFAREXAMPLE",
+  "roleArn": "arn:aws:iam::123456789012:role/Admin",
+  "sessionArn": "arn:aws:sts::123456789012:assumed-role/Admin/session",
+  "bucketArn": "arn:aws:s3:::bucket",
+  "members": ["deploy@project.iam.gserviceaccount.com", "dave@example.com"],
+  "count": 3
+}
+`)
+	indicators := &testIndicators{}
+	Extract(&json, Extractors{
+		NewEmailExtractor(indicators),
+		NewUsernameExtractor(indicators),
+		NewCloudPrincipalExtractor(indicators),
+	})
+	sort.Strings(indicators.Emails)
+	sort.Strings(indicators.Usernames)
+	sort.Strings(indicators.CloudPrincipals)
+	require.Equal(t, &testIndicators{
+		Emails: []string{
+			"alice@example.com",
+			"carol@example.co.uk",
+			"dave@example.com",
+			"deploy@project.iam.gserviceaccount.com",
+		},
+		Usernames: []string{"alice", "bob"},
+		CloudPrincipals: []string{
+			"AIDAJ45Q7YFFAREXAMPLE",
+			"arn:aws:iam::123456789012:role/Admin",
+			"arn:aws:sts::123456789012:assumed-role/Admin/session",
+			"deploy@project.iam.gserviceaccount.com",
+		},
+	}, indicators)
+}
+
+func TestIsEmail(t *testing.T) {
+	require.True(t, IsEmail("first.last+tag@sub.example.com"))
+	require.False(t, IsEmail("@example.com"))
+	require.False(t, IsEmail("alice@localhost"))
+	require.False(t, IsEmail("alice@example.com extra"))
+}
+
+func TestIsCloudPrincipal(t *testing.T) {
+	require.True(t, IsCloudPrincipal("arn:aws:iam::123456789012:root"))
+	require.True(t, IsCloudPrincipal("arn:aws:iam::123456789012:user/alice"))
+	require.True(t, IsCloudPrincipal("arn:aws:sts::123456789012:federated-user/bob"))
+	require.True(t, IsCloudPrincipal("app@project.iam.gserviceaccount.com"))
+	require.False(t, IsCloudPrincipal("arn:aws:iam::123456789012:policy/ReadOnly"))
+	require.False(t, IsCloudPrincipal("arn:aws:ec2:us-east-1:123456789012:instance/i-0123"))
+	require.False(t, IsCloudPrincipal("arn:invalid"))
+	require.False(t, IsCloudPrincipal("alice@example.com"))
+}
@@ -147,6 +147,23 @@ func (m *DynamoDBMock) Scan(input *dynamodb.ScanInput) (*dynamodb.ScanOutput, er
 	return args.Get(0).(*dynamodb.ScanOutput), args.Error(1)
 }
 
+// BatchGetItemPages passes the output of the mocked call to pageFunc as a single page
+func (m *DynamoDBMock) BatchGetItemPages(
+	input *dynamodb.BatchGetItemInput, pageFunc func(*dynamodb.BatchGetItemOutput, bool) bool) error {
+
+	args := m.Called(input)
+	if args.Error(1) != nil {
+		return args.Error(1)
+	}
+	pageFunc(args.Get(0).(*dynamodb.BatchGetItemOutput), true)
+	return nil
+}
+
+func (m *DynamoDBMock) BatchWriteItem(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*dynamodb.BatchWriteItemOutput), args.Error(1)
+}
+
 type SqsMock struct {
 	sqsiface.SQSAPI
 	mock.Mock
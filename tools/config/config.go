@@ -36,10 +36,19 @@ type PantherConfig struct {
 
 type Infra struct {
 	BaseLayerVersionArns          string   `yaml:"BaseLayerVersionArns"`
+	DedupWindowMinutes            int      `yaml:"DedupWindowMinutes"`
+	EnrichmentConfig              string   `yaml:"EnrichmentConfig"`
+	FilterConfig                  string   `yaml:"FilterConfig"`
+	FlushConfig                   string   `yaml:"FlushConfig"`
+	FramingConfig                 string   `yaml:"FramingConfig"`
 	LoadBalancerSecurityGroupCidr string   `yaml:"LoadBalancerSecurityGroupCidr"`
 	LogProcessorLambdaMemorySize  int      `yaml:"LogProcessorLambdaMemorySize"`
+	ParquetLogTypes               []string `yaml:"ParquetLogTypes"`
 	PipLayer                      []string `yaml:"PipLayer"`
+	PreserveUnknownFields         []string `yaml:"PreserveUnknownFields"`
 	PythonLayerVersionArn         string   `yaml:"PythonLayerVersionArn"`
+	RedactionConfig               string   `yaml:"RedactionConfig"`
+	RedactionHMACKeySecret        string   `yaml:"RedactionHMACKeySecret"`
 }
 
 type Monitoring struct {
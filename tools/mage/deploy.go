@@ -35,6 +35,7 @@ import (
 	"github.com/magefile/mage/sh"
 
 	"github.com/panther-labs/panther/api/lambda/users/models"
+	"github.com/panther-labs/panther/internal/log_analysis/awsglue"
 	"github.com/panther-labs/panther/internal/log_analysis/gluetables"
 	"github.com/panther-labs/panther/pkg/genericapi"
 	"github.com/panther-labs/panther/pkg/shutil"
@@ -476,6 +477,7 @@ func deployCoreStack(settings *config.PantherConfig, outputs map[string]string)
 		"InitialAnalysisPackUrls":    strings.Join(settings.Setup.InitialAnalysisSets, ","),
 		"LayerVersionArns":           settings.Infra.BaseLayerVersionArns,
 		"OutputsKeyId":               outputs["OutputsEncryptionKeyId"],
+		"ParquetLogTypes":            strings.Join(settings.Infra.ParquetLogTypes, ","),
 		"ProcessedDataBucket":        outputs["ProcessedDataBucket"],
 		"SqsKeyId":                   outputs["QueueEncryptionKeyId"],
 		"TracingMode":                settings.Monitoring.TracingMode,
@@ -496,6 +498,8 @@ func deployDashboardStack(bucket string) error {
 }
 
 func deployLogAnalysisStack(settings *config.PantherConfig, outputs map[string]string) error {
+	// the storage format is part of the table signature
+	awsglue.SetParquetLogTypes(settings.Infra.ParquetLogTypes...)
 	// this computes a signature of the deployed glue tables used for change detection, for CF use the Panther version
 	tablesSignature, err := gluetables.DeployedTablesSignature(glue.New(awsSession))
 	if err != nil {
@@ -509,11 +513,20 @@ func deployLogAnalysisStack(settings *config.PantherConfig, outputs map[string]s
 		"CloudWatchLogRetentionDays":   strconv.Itoa(settings.Monitoring.CloudWatchLogRetentionDays),
 		"CustomResourceVersion":        customResourceVersion(),
 		"Debug":                        strconv.FormatBool(settings.Monitoring.Debug),
+		"DedupWindowMinutes":           strconv.Itoa(settings.Infra.DedupWindowMinutes),
+		"EnrichmentConfig":             settings.Infra.EnrichmentConfig,
+		"FilterConfig":                 settings.Infra.FilterConfig,
+		"FlushConfig":                  settings.Infra.FlushConfig,
+		"FramingConfig":                settings.Infra.FramingConfig,
 		"LayerVersionArns":             settings.Infra.BaseLayerVersionArns,
 		"LogProcessorLambdaMemorySize": strconv.Itoa(settings.Infra.LogProcessorLambdaMemorySize),
+		"ParquetLogTypes":              strings.Join(settings.Infra.ParquetLogTypes, ","),
+		"PreserveUnknownFields":        strings.Join(settings.Infra.PreserveUnknownFields, ","),
 		"ProcessedDataBucket":          outputs["ProcessedDataBucket"],
 		"ProcessedDataTopicArn":        outputs["ProcessedDataTopicArn"],
 		"PythonLayerVersionArn":        outputs["PythonLayerVersionArn"],
+		"RedactionConfig":              settings.Infra.RedactionConfig,
+		"RedactionHMACKeySecret":       settings.Infra.RedactionHMACKeySecret,
 		"SqsKeyId":                     outputs["QueueEncryptionKeyId"],
 		"TablesSignature":              tablesSignature,
 		"TracingMode":                  settings.Monitoring.TracingMode,
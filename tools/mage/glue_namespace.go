@@ -45,6 +45,7 @@ func (t Glue) Sync() {
 	getSession()
 	glueClient := glue.New(awsSession)
 	s3Client := s3.New(awsSession)
+	awsglue.SetParquetLogTypes(getSettings().Infra.ParquetLogTypes...)
 
 	enteredText := promptUser("Enter regex to select a subset of tables (or <enter> for all tables): ", regexValidator)
 	matchTableName, _ := regexp.Compile(enteredText) // no error check already validated
@@ -108,6 +108,7 @@ export const LOG_TYPES = [
   'AWS.GuardDuty',
   'AWS.S3ServerAccess',
   'AWS.VPCFlow',
+  'CEF.Event',
   'Fluentd.Syslog3164',
   'Fluentd.Syslog5424',
   'GitLab.API',
@@ -122,6 +123,7 @@ export const LOG_TYPES = [
   'Juniper.MWS',
   'Juniper.Postgres',
   'Juniper.Security',
+  'LEEF.Event',
   'Nginx.Access',
   'Osquery.Batch',
   'Osquery.Differential',